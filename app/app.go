@@ -1,17 +1,28 @@
 package app
 
 import (
+	stdcontext "context"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
 	"net/http"
 	"net/url"
-	_path "path"
-	"path/filepath"
+	"os"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	zeroapi "github.com/zerogo-hub/zero-api"
 	"github.com/zerogo-hub/zero-api/context"
+	"github.com/zerogo-hub/zero-api/proxy"
 	"github.com/zerogo-hub/zero-api/router"
+	"github.com/zerogo-hub/zero-api/serializer"
 	"github.com/zerogo-hub/zero-api/server"
+	"github.com/zerogo-hub/zero-api/static"
+	"github.com/zerogo-hub/zero-api/websocket"
 
 	"github.com/zerogo-hub/zero-helper/logger"
 
@@ -19,6 +30,20 @@ import (
 	zamlogger "github.com/zerogo-hub/zero-api-middleware/logger"
 )
 
+// shutdownTimeout RunListeners 收到信号后等待正在处理的请求完成的最长时间
+const shutdownTimeout = 30 * time.Second
+
+var (
+	// ErrInvalidCertFile 证书或者私钥文件不存在
+	ErrInvalidCertFile = errors.New("cert file or key file is not exist")
+
+	// ErrNoCertManager 使用 RunAutoTLS 时没有通过 WithCertManager 设置证书管理器
+	ErrNoCertManager = errors.New("cert manager is not set, see WithCertManager")
+
+	// ErrNoDomains 使用 RunAutoTLS 时没有指定域名
+	ErrNoDomains = errors.New("no domains for auto tls")
+)
+
 type app struct {
 	// router 路由管理器
 	router zeroapi.Router
@@ -26,14 +51,47 @@ type app struct {
 	// server http 服务器
 	server zeroapi.Server
 
-	// context 对象池
-	ctxPool *sync.Pool
-
 	// config 应用配置
 	config *config
 
 	// middlewares App级别 中间件
 	middlewares []zeroapi.Handler
+
+	// signals 信号处理
+	signals *signals
+
+	// listenSignals 只注册一次 RunListeners 的信号处理函数
+	listenSignals sync.Once
+
+	// listenersMu 保护 listeners
+	listenersMu sync.Mutex
+
+	// listeners 通过 RunListeners 运行中的监听，热重启时传递给新进程
+	listeners []net.Listener
+
+	// validators 结构体字段验证函数
+	validators map[string]zeroapi.StructValidator
+
+	// serializers 响应序列化
+	serializers map[string]zeroapi.Serializer
+
+	// errorHandler 错误处理函数
+	errorHandler zeroapi.ErrorHandler
+
+	// services 与 http 服务一同启动与停止的服务
+	services []zeroapi.Service
+
+	// prefix 路由前缀，见 Prefix
+	prefix string
+
+	// slos 路由声明的服务等级目标
+	slos []zeroapi.RouteSLO
+
+	// notFoundHandler 路由不存在时的处理函数
+	notFoundHandler zeroapi.Handler
+
+	// methodNotAllowedHandler 请求方法不匹配时的处理函数
+	methodNotAllowedHandler zeroapi.Handler
 }
 
 // New 生成一个应用实例
@@ -57,20 +115,26 @@ func Default() zeroapi.App {
 // NewApp 生成一个应用实例
 func NewApp(opts ...Option) zeroapi.App {
 	a := &app{
-		ctxPool: &sync.Pool{},
 		config:  defaultConfig(),
-	}
+		signals: newSignals(),
 
-	a.router = router.NewRouter(a)
-	a.server = server.NewServer(a)
-	a.ctxPool.New = func() interface{} {
-		return context.NewContext(a)
+		validators:  make(map[string]zeroapi.StructValidator),
+		serializers: make(map[string]zeroapi.Serializer),
 	}
 
+	// 先应用配置，server 创建时需要读取配置
 	for _, opt := range opts {
 		opt(a.config)
 	}
 
+	a.serializers[zeroapi.SerializerJSON] = serializer.JSON(a.config.jsonCodec)
+	a.serializers[zeroapi.SerializerXML] = serializer.XML()
+	a.serializers[zeroapi.SerializerYAML] = serializer.YAML()
+	a.serializers[zeroapi.SerializerMsgPack] = serializer.MsgPack()
+
+	a.router = router.NewRouter(a, a.config.routerOptions...)
+	a.server = server.NewServer(a)
+
 	return a
 }
 
@@ -84,14 +148,10 @@ func (a *app) Server() zeroapi.Server {
 	return a.server
 }
 
-// Context 从 pool 中获取一个 Context
+// Context 为每个请求创建一个新的 Context
+// 请求结束后后台 goroutine 仍然可能持有 Context，复用会让它读写其它请求，所以 Context 不再放入 pool
 func (a *app) Context() zeroapi.Context {
-	return a.ctxPool.Get().(zeroapi.Context)
-}
-
-// ReleaseContext 将 Context 释放到 pool 中
-func (a *app) ReleaseContext(ctx zeroapi.Context) {
-	a.ctxPool.Put(ctx)
+	return context.NewContext(a)
 }
 
 // Version 获取框架版本号
@@ -104,6 +164,72 @@ func (a *app) Logger() logger.Logger {
 	return a.config.logger
 }
 
+// Metrics 获取指标管理器
+func (a *app) Metrics() zeroapi.Metrics {
+	return a.config.metrics
+}
+
+// EventBus 获取事件总线
+func (a *app) EventBus() zeroapi.EventBus {
+	return a.config.eventBus
+}
+
+// Cache 获取进程内缓存
+func (a *app) Cache() zeroapi.Cache {
+	return a.config.cache
+}
+
+// SetTrustedProxies 设置受信任的代理，支持 CIDR 与单个 IP，地址不正确时返回错误，不修改之前的设置
+func (a *app) SetTrustedProxies(cidrs ...string) error {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy: %s", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy: %s", cidr)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	a.config.trustedProxies = nets
+	return nil
+}
+
+// IsTrustedProxy ip 是否是受信任的代理
+func (a *app) IsTrustedProxy(ip string) bool {
+	if len(a.config.trustedProxies) == 0 {
+		return false
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range a.config.trustedProxies {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// FlashStore 获取一次性消息存储
+func (a *app) FlashStore() zeroapi.FlashStore {
+	return a.config.flashStore
+}
+
 // FileMaxMemory 文件系统使用的最大内存
 func (a *app) FileMaxMemory() int64 {
 	return a.config.fileMaxMemory
@@ -111,7 +237,22 @@ func (a *app) FileMaxMemory() int64 {
 
 // IsCookieEncode cookie 是否需要进行编码
 func (a *app) IsCookieEncode() bool {
-	return a.config.cookieEncode != nil && a.config.cookieDecode != nil
+	return !a.config.cookieEncodeDisabled && a.config.cookieEncode != nil && a.config.cookieDecode != nil
+}
+
+// CookieDefaults 获取 cookie 默认选项
+func (a *app) CookieDefaults() []zeroapi.CookieOption {
+	return a.config.cookieDefaults
+}
+
+// IsCookieLegacyMD5 是否接受旧版本 HMAC-MD5 签名的 cookie
+func (a *app) IsCookieLegacyMD5() bool {
+	return !a.config.cookieLegacyMD5Disabled
+}
+
+// JSONCodec 获取 JSON 编码与解码器
+func (a *app) JSONCodec() zeroapi.JSONCodec {
+	return a.config.jsonCodec
 }
 
 // CookieEncodeHandler 获取 cookie 编码函数
@@ -124,7 +265,106 @@ func (a *app) CookieDecodeHandler() zeroapi.CookieDecodeHandler {
 	return a.config.cookieDecode
 }
 
+// RegisterValidator 注册结构体字段验证函数，在 validate 标签中通过 name 使用
+func (a *app) RegisterValidator(name string, validator zeroapi.StructValidator) {
+	if _, exist := a.validators[name]; exist || validator == nil {
+		return
+	}
+
+	a.validators[name] = validator
+}
+
+// Validator 获取结构体字段验证函数
+func (a *app) Validator(name string) zeroapi.StructValidator {
+	return a.validators[name]
+}
+
+// RegisterSerializer 注册响应序列化，通过 ctx.Serialize(name, obj) 使用，同名时替换
+func (a *app) RegisterSerializer(name string, serializer zeroapi.Serializer) {
+	if name == "" || serializer == nil {
+		return
+	}
+
+	a.serializers[name] = serializer
+}
+
+// Serializer 获取响应序列化，不存在时返回 nil
+func (a *app) Serializer(name string) zeroapi.Serializer {
+	return a.serializers[name]
+}
+
+// MaxBodyBytes 请求体最大字节数，为 0 时不限制
+func (a *app) MaxBodyBytes() int64 {
+	return a.config.maxBodyBytes
+}
+
+// ServerTimeouts http 服务的超时时间
+func (a *app) ServerTimeouts() zeroapi.ServerTimeouts {
+	return a.config.serverTimeouts
+}
+
+// IsH2C 是否支持明文 HTTP/2(h2c)
+func (a *app) IsH2C() bool {
+	return a.config.h2c
+}
+
+// IsAutoOptions 是否根据路由自动响应 OPTIONS 请求
+func (a *app) IsAutoOptions() bool {
+	return a.config.autoOptions
+}
+
+// ServeMode 运行方式
+func (a *app) ServeMode() string {
+	return a.config.serveMode
+}
+
+// SetErrorHandler 设置错误处理函数
+func (a *app) SetErrorHandler(handler zeroapi.ErrorHandler) {
+	a.errorHandler = handler
+}
+
+// ErrorHandler 获取错误处理函数
+func (a *app) ErrorHandler() zeroapi.ErrorHandler {
+	return a.errorHandler
+}
+
+// NotFound 设置路由不存在时的处理函数
+func (a *app) NotFound(handler zeroapi.Handler) {
+	a.notFoundHandler = handler
+}
+
+// NotFoundHandler 获取路由不存在时的处理函数
+func (a *app) NotFoundHandler() zeroapi.Handler {
+	return a.notFoundHandler
+}
+
+// MethodNotAllowed 设置请求方法不匹配时的处理函数
+func (a *app) MethodNotAllowed(handler zeroapi.Handler) {
+	a.methodNotAllowedHandler = handler
+}
+
+// MethodNotAllowedHandler 获取请求方法不匹配时的处理函数
+func (a *app) MethodNotAllowedHandler() zeroapi.Handler {
+	return a.methodNotAllowedHandler
+}
+
+// PanicHandler 获取处理函数发生 panic 时执行的函数
+func (a *app) PanicHandler() zeroapi.PanicHandler {
+	return a.config.panicHandler
+}
+
+// Now 获取当前时间
+func (a *app) Now() time.Time {
+	return a.config.now()
+}
+
+// Rand 获取随机数来源
+func (a *app) Rand() io.Reader {
+	return a.config.rand
+}
+
 // Use 添加 App 级别 中间件，每一次路由都会调用公共中间件
+// 路由可以通过 RouteMiddleware 在其之前执行中间件，或者跳过 App 级别中间件
 func (a *app) Use(handlers ...zeroapi.Handler) {
 	for _, handler := range handlers {
 		if handler != nil {
@@ -146,11 +386,138 @@ func (a *app) ExecuteMiddlewares(ctx zeroapi.Context) {
 // Run 启动服务，此方法会阻塞，直到应用关闭
 // addr: host:port，例如: ":8080"，"192.168.1.8:80"
 func (a *app) Run(addr string) error {
+	return a.run(func() error { return a.server.Start(addr) })
+}
+
+// RunTLS 使用证书文件启动 https 服务，此方法会阻塞，直到应用关闭
+// certFile: 证书路径
+// keyFile: 私钥路径
+func (a *app) RunTLS(addr, certFile, keyFile string) error {
+	if !a.server.SetTLS(certFile, keyFile) {
+		return ErrInvalidCertFile
+	}
+
+	return a.Run(addr)
+}
+
+// RunAutoTLS 在 :443 启动 https 服务，证书通过 WithCertManager 设置的证书管理器自动获取与续期
+// 同时在 WithAutoTLSRedirectAddr 设置的地址(默认 :80)处理 HTTP-01 验证请求，其它请求重定向到 https
+func (a *app) RunAutoTLS(domains ...string) error {
+	if a.config.certManager == nil {
+		return ErrNoCertManager
+	}
+	if len(domains) == 0 {
+		return ErrNoDomains
+	}
+
+	manager := a.config.certManager(domains, a.config.autoTLSCacheDir)
+	return a.run(func() error {
+		return a.server.StartAutoTLS(":443", a.config.autoTLSRedirectAddr, manager)
+	})
+}
+
+// RunListeners 同时在多个监听上启动服务，此方法会阻塞，直到应用关闭
+// 收到 SIGINT, SIGTERM 时优雅关闭所有监听，最多等待 shutdownTimeout
+// 收到 SIGUSR2 时启动新进程并传递监听，然后优雅关闭当前进程
+func (a *app) RunListeners(listeners ...net.Listener) error {
+	a.setListeners(listeners)
+	defer a.setListeners(nil)
+
+	// 多次调用时只注册一次信号处理函数，处理函数使用当前运行中的监听
+	a.listenSignals.Do(func() {
+		shutdown := func() {
+			if a.runningListeners() == nil {
+				return
+			}
+
+			ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), shutdownTimeout)
+			defer cancel()
+
+			if err := a.server.Shutdown(ctx); err != nil {
+				a.Logger().Errorf("shutdown: %s", err.Error())
+			}
+		}
+		a.OnSignal(os.Interrupt, shutdown)
+		a.OnSignal(syscall.SIGTERM, shutdown)
+
+		// 热重启，新进程就绪后关闭当前进程
+		a.OnSignal(restartSignal, func() {
+			listeners := a.runningListeners()
+			if listeners == nil {
+				return
+			}
+
+			process, err := server.Restart(listeners...)
+			if err != nil {
+				a.Logger().Errorf("restart: %s", err.Error())
+				return
+			}
+
+			a.Logger().Infof("restart, new process PID: %d", process.Pid)
+			shutdown()
+		})
+	})
+
+	return a.run(func() error { return a.server.Serve(listeners...) })
+}
+
+// setListeners 记录通过 RunListeners 运行中的监听，返回时清空
+func (a *app) setListeners(listeners []net.Listener) {
+	a.listenersMu.Lock()
+	a.listeners = listeners
+	a.listenersMu.Unlock()
+}
+
+// runningListeners 通过 RunListeners 运行中的监听，没有运行时返回 nil
+func (a *app) runningListeners() []net.Listener {
+	a.listenersMu.Lock()
+	defer a.listenersMu.Unlock()
+	return a.listeners
+}
+
+// RunAddrs 同时在多个地址上启动服务，"unix:/path" 监听 unix socket，否则监听 TCP，此方法会阻塞，直到应用关闭
+// 由热重启启动时，按顺序使用父进程传递的监听
+func (a *app) RunAddrs(addrs ...string) error {
+	inherited, err := server.Inherited()
+	if err != nil {
+		return err
+	}
+	if len(inherited) == len(addrs) && len(addrs) > 0 {
+		return a.RunListeners(inherited...)
+	}
+	for _, ln := range inherited {
+		ln.Close()
+	}
+
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := server.Listen(addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, ln)
+	}
+
+	return a.RunListeners(listeners...)
+}
+
+// run 构建路由，启动服务，调用 start 启动 http 服务
+func (a *app) run(start func() error) error {
 	if !a.Router().Build() {
 		return errors.New("router build failed")
 	}
 
-	if err := a.server.Start(addr); err != nil {
+	for i, service := range a.services {
+		if err := service.Start(); err != nil {
+			a.stopServices(a.services[:i])
+			return err
+		}
+	}
+
+	if err := start(); err != nil {
 		if err == http.ErrServerClosed {
 			a.Logger().Info(http.ErrServerClosed.Error())
 		} else {
@@ -158,13 +525,55 @@ func (a *app) Run(addr string) error {
 		}
 	}
 
+	failed := a.stopServices(a.services)
+	if report := a.server.ShutdownReport(); report != nil {
+		report.FailedHooks = append(report.FailedHooks, failed...)
+		a.reportShutdown(report)
+	}
+
 	return nil
 }
 
+// reportShutdown 记录优雅关闭报告
+func (a *app) reportShutdown(report *zeroapi.ShutdownReport) {
+	if data, err := a.config.jsonCodec.Marshal(report); err == nil {
+		a.Logger().Infof("shutdown report: %s", data)
+	}
+
+	if a.config.shutdownReport != nil {
+		a.config.shutdownReport(report)
+	}
+}
+
+// AddService 添加与 http 服务一同启动与停止的服务
+func (a *app) AddService(service zeroapi.Service) {
+	if service != nil {
+		a.services = append(a.services, service)
+	}
+}
+
+// stopServices 按照添加的相反顺序停止服务，返回停止失败的服务
+func (a *app) stopServices(services []zeroapi.Service) []zeroapi.FailedHook {
+	var failed []zeroapi.FailedHook
+	for i := len(services) - 1; i >= 0; i-- {
+		if err := services[i].Stop(); err != nil {
+			a.Logger().Errorf("stop service: %s", err.Error())
+			failed = append(failed, zeroapi.FailedHook{
+				Name:  fmt.Sprintf("service %T", services[i]),
+				Error: err.Error(),
+			})
+		}
+	}
+	return failed
+}
+
 // Prefix 设置前缀，设置前就已添加的路由不会有该前缀
 // 例如: prefix = "/blog"，则 "/user" -> "/blog/user"
 func (a *app) Prefix(prefix string) zeroapi.App {
 	a.router.Prefix(prefix)
+	if prefix != "" {
+		a.prefix = "/" + strings.Trim(prefix, "/")
+	}
 	return a
 }
 
@@ -172,85 +581,198 @@ func (a *app) Prefix(prefix string) zeroapi.App {
 // path: 路径，以 "/" 开头，不可以为空
 // handlers: 路由级别中间件和处理函数
 func (a *app) Get(path string, handlers ...zeroapi.Handler) zeroapi.App {
-	a.router.Register(zeroapi.MethodGet, path, handlers...)
-	return a
+	return a.Handle(zeroapi.MethodGet, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Post method = "POST"
 // path: 路径，以 "/" 开头，不可以为空
 // handlers: 路由级别中间件和处理函数
 func (a *app) Post(path string, handlers ...zeroapi.Handler) zeroapi.App {
-	a.router.Register(zeroapi.MethodPost, path, handlers...)
-	return a
+	return a.Handle(zeroapi.MethodPost, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Put method = "PUT"
 // path: 路径，以 "/" 开头，不可以为空
 // handlers: 路由级别中间件和处理函数
 func (a *app) Put(path string, handlers ...zeroapi.Handler) zeroapi.App {
-	a.router.Register(zeroapi.MethodPost, path, handlers...)
-	return a
+	return a.Handle(zeroapi.MethodPut, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Delete method = "DELETE"
 // path: 路径，以 "/" 开头，不可以为空
 // handlers: 路由级别中间件和处理函数
 func (a *app) Delete(path string, handlers ...zeroapi.Handler) zeroapi.App {
-	a.router.Register(zeroapi.MethodDelete, path, handlers...)
-	return a
+	return a.Handle(zeroapi.MethodDelete, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Head method = "HEAD"
 // path: 路径，以 "/" 开头，不可以为空
 // handlers: 路由级别中间件和处理函数
 func (a *app) Head(path string, handlers ...zeroapi.Handler) zeroapi.App {
-	a.router.Register(zeroapi.MethodHead, path, handlers...)
-	return a
+	return a.Handle(zeroapi.MethodHead, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Patch method = "PATCH"
 // path: 路径，以 "/" 开头，不可以为空
 // handlers: 路由级别中间件和处理函数
 func (a *app) Patch(path string, handlers ...zeroapi.Handler) zeroapi.App {
-	a.router.Register(zeroapi.MethodPatch, path, handlers...)
-	return a
+	return a.Handle(zeroapi.MethodPatch, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Options method = "OPTIONS"
 // path: 路径，以 "/" 开头，不可以为空
 // handlers: 路由级别中间件和处理函数
 func (a *app) Options(path string, handlers ...zeroapi.Handler) zeroapi.App {
-	a.router.Register(zeroapi.MethodOptions, path, handlers...)
+	return a.Handle(zeroapi.MethodOptions, path, zeroapi.RouteMiddleware{}, handlers...)
+}
+
+// WS 注册 WebSocket 路由，method = "GET"
+// path: 路径，以 "/" 开头，不可以为空
+// handler: 握手成功后执行，返回后连接自动关闭
+// middlewares: 路由级别中间件，在握手之前执行
+func (a *app) WS(path string, handler zeroapi.WebSocketHandler, middlewares ...zeroapi.Handler) zeroapi.App {
+	handlers := make([]zeroapi.Handler, 0, len(middlewares)+1)
+	handlers = append(handlers, middlewares...)
+	handlers = append(handlers, websocket.Handler(handler))
+
+	return a.Handle(zeroapi.MethodGet, path, zeroapi.RouteMiddleware{}, handlers...)
+}
+
+// Mount 将 http.Handler 挂载到 prefix 下，所有请求方法的 prefix 以及 prefix/* 都交给 h 处理
+// 请求路径去掉 prefix 后交给 h，例如 /admin/users 挂载在 /admin 下时，h 收到的路径为 /users
+// middlewares: 路由级别中间件，在 App 级别中间件之后，h 之前执行
+func (a *app) Mount(prefix string, h http.Handler, middlewares ...zeroapi.Handler) zeroapi.App {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	handlers := make([]zeroapi.Handler, 0, len(middlewares)+1)
+	handlers = append(handlers, middlewares...)
+	handlers = append(handlers, mountHandler(h))
+
+	for _, method := range zeroapi.AllMethods() {
+		if prefix != "" {
+			a.Handle(method, prefix, zeroapi.RouteMiddleware{}, handlers...)
+		}
+		a.Handle(method, prefix+"/*"+mountParam, zeroapi.RouteMiddleware{}, handlers...)
+	}
+
 	return a
 }
 
+// Proxy 将匹配 pattern 的所有请求方法转发到上游 target
+// pattern: 路由，例如 /api/*，通配符可以没有名称
+// target: 上游地址，例如 http://127.0.0.1:8081/v1
+// config: 反向代理配置，见 ProxyConfig
+func (a *app) Proxy(pattern, target string, config ...zeroapi.ProxyConfig) error {
+	pattern = proxy.Pattern(pattern)
+
+	h, err := proxy.New(pattern, target, config...)
+	if err != nil {
+		return err
+	}
+
+	for _, method := range zeroapi.AllMethods() {
+		a.Handle(method, pattern, zeroapi.RouteMiddleware{}, h)
+	}
+
+	return nil
+}
+
+// mountParam 挂载路由中保存剩余路径的动态参数
+const mountParam = "mountpath"
+
+// mountHandler 去掉挂载前缀后交给 h 处理
+func mountHandler(h http.Handler) zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		req := ctx.Request()
+
+		r := new(http.Request)
+		*r = *req
+		r.URL = new(url.URL)
+		*r.URL = *req.URL
+		r.URL.Path = "/" + ctx.Dynamic(mountParam)
+		r.URL.RawPath = ""
+
+		h.ServeHTTP(ctx.Response(), r)
+	}
+}
+
+// Handle 注册路由，并控制路由级别中间件与 App 级别中间件的执行顺序
+// method: HTTP Method，例如 MethodGet
+// path: 路径，以 "/" 开头，不可以为空
+// m: 在 App 级别中间件之前执行的中间件，是否跳过 App 级别中间件，路由的成本，请求体限制，服务等级目标
+// handlers: 路由级别中间件和处理函数，在 App 级别中间件之后执行
+func (a *app) Handle(method, path string, m zeroapi.RouteMiddleware, handlers ...zeroapi.Handler) zeroapi.App {
+	// 请求体限制，成本，Before 与 App 级别中间件由 server 在匹配路由后按顺序执行
+	route := zeroapi.RouteInfo{
+		Method:       method,
+		Path:         path,
+		Name:         m.Name,
+		Timeout:      m.Timeout,
+		Meta:         m.Meta,
+		Before:       m.Before,
+		SkipGlobal:   m.SkipGlobal,
+		Cost:         m.Cost,
+		MaxBodyBytes: m.MaxBodyBytes,
+	}
+	if a.router.RegisterRoute(route, handlers...) && m.SLO != nil {
+		a.AddSLO(zeroapi.RouteSLO{Method: method, Path: path, SLO: *m.SLO})
+	}
+	return a
+}
+
+// AddSLO 记录路由的服务等级目标，路径加上当前的前缀
+func (a *app) AddSLO(slo zeroapi.RouteSLO) {
+	if a.prefix != "" && a.prefix != "/" {
+		slo.Path = a.prefix + slo.Path
+	}
+	a.slos = append(a.slos, slo)
+}
+
+// SLOs 所有路由声明的服务等级目标，按照注册顺序返回
+func (a *app) SLOs() []zeroapi.RouteSLO {
+	return a.slos
+}
+
 // Group 创建组路由实例
 func (a *app) Group(path string) zeroapi.Group {
 	return router.NewGroup(a, path)
 }
 
-// Static 添加静态资源服务
+// Host 创建只匹配该主机名的组路由，例如 api.example.com，*.example.com
+// 通配符匹配的子域名通过 ctx.Dynamic("subdomain") 获取
+func (a *app) Host(pattern string) zeroapi.Group {
+	return router.NewHostGroup(a, pattern)
+}
+
+// Static 添加静态资源服务，支持首页文件、目录列表、ETag/Last-Modified 缓存以及 Range 请求
 // prefix 静态资源路由前缀
 // path 资源真实位置(绝对路径，相对路径)
-func (a *app) Static(prefix, path string) {
+// config 静态资源服务配置，见 StaticConfig
+func (a *app) Static(prefix, path string, config ...zeroapi.StaticConfig) {
 	if path == "" {
 		path = "."
 	}
 
-	f := func(ctx zeroapi.Context) {
-		fileName, err := url.PathUnescape(ctx.Dynamic("*"))
-		if fileName == "" || err != nil {
-			ctx.NotFound()
-			return
-		}
-		path := filepath.Join(path, _path.Clean("/"+fileName))
-		ctx.DownloadFile(path, fileName)
-	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	a.static(prefix, static.New(prefix, http.Dir(path), config...))
+}
+
+// StaticFS 添加静态资源服务，资源来自 fs.FS，例如 embed.FS，可以将前端资源打包进可执行文件
+// prefix 静态资源路由前缀
+// fsys 文件系统，如果资源位于子目录中，可以通过 fs.Sub 获取子目录
+// config 静态资源服务配置，见 StaticConfig
+func (a *app) StaticFS(prefix string, fsys fs.FS, config ...zeroapi.StaticConfig) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	a.static(prefix, static.NewFS(prefix, fsys, config...))
+}
 
-	if prefix == "/" {
-		a.Get(prefix+"*", f)
+func (a *app) static(prefix string, f zeroapi.Handler) {
+	if prefix == "" {
+		a.Get("/*"+static.Param, f)
 		return
 	}
 
-	a.Get(prefix+"/*", f)
+	// "/assets" 重定向到 "/assets/"
+	a.Get(prefix, f)
+	a.Get(prefix+"/*"+static.Param, f)
 }
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// stdJSON 使用 encoding/json 实现 JSONCodec
+type stdJSON struct{}
+
+func (stdJSON) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSON) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (stdJSON) NewEncoder(w io.Writer) zeroapi.JSONEncoder {
+	return json.NewEncoder(w)
+}
+
+func (stdJSON) NewDecoder(r io.Reader) zeroapi.JSONDecoder {
+	return json.NewDecoder(r)
+}
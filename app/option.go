@@ -1,14 +1,31 @@
 package app
 
 import (
+	"crypto/rand"
+	"io"
+	"net"
+	"time"
+
 	zeroapi "github.com/zerogo-hub/zero-api"
 
+	"github.com/zerogo-hub/zero-api/cache"
+	"github.com/zerogo-hub/zero-api/context"
+	"github.com/zerogo-hub/zero-api/eventbus"
+	"github.com/zerogo-hub/zero-api/metrics"
+	"github.com/zerogo-hub/zero-api/router"
+
 	"github.com/zerogo-hub/zero-helper/logger"
 )
 
 var (
 	// defaultFileMaxMemory 用于限制使用内存大小 multipart/form-data，比如文件上传
 	defaultFileMaxMemory = int64(32 * 1024 * 1024) // 32M
+
+	// defaultReadHeaderTimeout 读取请求头的默认超时时间
+	defaultReadHeaderTimeout = 10 * time.Second
+
+	// defaultIdleTimeout keep-alive 连接的默认空闲时间
+	defaultIdleTimeout = 120 * time.Second
 )
 
 // config app 配置
@@ -19,21 +36,102 @@ type config struct {
 	// fileMaxMemory 文件系统使用的最大内存
 	fileMaxMemory int64
 
+	// maxBodyBytes 请求体最大字节数
+	maxBodyBytes int64
+
+	// trustedProxies 受信任的代理
+	trustedProxies []*net.IPNet
+
+	// serverTimeouts http 服务的超时时间
+	serverTimeouts zeroapi.ServerTimeouts
+
 	// logger 日志管理器
 	logger logger.Logger
 
+	// metrics 指标管理器
+	metrics zeroapi.Metrics
+
+	// eventBus 事件总线
+	eventBus zeroapi.EventBus
+
+	// cache 进程内缓存
+	cache zeroapi.Cache
+
+	// flashStore 一次性消息存储
+	flashStore zeroapi.FlashStore
+
 	// cookieEncode 对 cookie 键值编码函数
 	cookieEncode zeroapi.CookieEncodeHandler
 
 	// cookieDecode 对 cookie 键值解码函数
 	cookieDecode zeroapi.CookieDecodeHandler
+
+	// cookieEncodeDisabled 禁用 cookie 编码，即使设置了编码与解码函数
+	cookieEncodeDisabled bool
+
+	// cookieDefaults cookie 默认选项
+	cookieDefaults []zeroapi.CookieOption
+
+	// cookieLegacyMD5Disabled 拒绝旧版本 HMAC-MD5 签名的 cookie
+	cookieLegacyMD5Disabled bool
+
+	// jsonCodec JSON 编码与解码器
+	jsonCodec zeroapi.JSONCodec
+
+	// h2c 是否支持明文 HTTP/2
+	h2c bool
+
+	// autoOptions 是否自动响应 OPTIONS 请求
+	autoOptions bool
+
+	// serveMode 运行方式
+	serveMode string
+
+	// routerOptions 路由配置选项
+	routerOptions []router.Option
+
+	// panicHandler 处理函数发生 panic 时执行
+	panicHandler zeroapi.PanicHandler
+
+	// now 获取当前时间
+	now func() time.Time
+
+	// rand 随机数来源
+	rand io.Reader
+
+	// certManager 创建 RunAutoTLS 使用的证书管理器
+	certManager zeroapi.CertManagerFactory
+
+	// autoTLSCacheDir 证书缓存目录
+	autoTLSCacheDir string
+
+	// autoTLSRedirectAddr 处理 HTTP-01 验证与重定向到 https 的 http 服务地址
+	autoTLSRedirectAddr string
+
+	// shutdownReport 接收优雅关闭报告
+	shutdownReport func(report *zeroapi.ShutdownReport)
 }
 
 func defaultConfig() *config {
 	return &config{
 		version:       zeroapi.VERSION,
 		fileMaxMemory: defaultFileMaxMemory,
-		logger:        logger.NewSampleLogger(),
+		serverTimeouts: zeroapi.ServerTimeouts{
+			ReadHeader: defaultReadHeaderTimeout,
+			Idle:       defaultIdleTimeout,
+		},
+		logger:     logger.NewSampleLogger(),
+		metrics:    metrics.New(),
+		eventBus:   eventbus.New(),
+		cache:      cache.New(),
+		flashStore: context.NewFlashCookieStore(""),
+		jsonCodec:  stdJSON{},
+		serveMode:  zeroapi.ServeModeHTTP,
+		now:        time.Now,
+		rand:       rand.Reader,
+
+		autoTLSCacheDir:     "certs",
+		autoTLSRedirectAddr: ":80",
 	}
 }
 
@@ -61,6 +159,43 @@ func WithLogger(logger logger.Logger) Option {
 	}
 }
 
+// WithMetrics 设置指标管理器
+func WithMetrics(metrics zeroapi.Metrics) Option {
+	return func(config *config) {
+		if metrics != nil {
+			config.metrics = metrics
+		}
+	}
+}
+
+// WithEventBus 设置事件总线，例如跨进程的实现
+func WithEventBus(eventBus zeroapi.EventBus) Option {
+	return func(config *config) {
+		if eventBus != nil {
+			config.eventBus = eventBus
+		}
+	}
+}
+
+// WithFlashStore 设置一次性消息存储，默认保存在使用随机密钥签名的 cookie 中，只在当前进程中有效
+// 多个实例时需要使用相同的密钥 context.NewFlashCookieStore(key)，或者保存在会话中 session.NewFlashStore(store, "session")
+func WithFlashStore(store zeroapi.FlashStore) Option {
+	return func(config *config) {
+		if store != nil {
+			config.flashStore = store
+		}
+	}
+}
+
+// WithCache 设置进程内缓存，例如使用不同容量的 cache.New(cache.WithCapacity(n))
+func WithCache(c zeroapi.Cache) Option {
+	return func(config *config) {
+		if c != nil {
+			config.cache = c
+		}
+	}
+}
+
 // WithCookieHandler 设置 cookie 编码与解码函数
 func WithCookieHandler(encoder zeroapi.CookieEncodeHandler, decoder zeroapi.CookieDecodeHandler) Option {
 	return func(config *config) {
@@ -68,3 +203,184 @@ func WithCookieHandler(encoder zeroapi.CookieEncodeHandler, decoder zeroapi.Cook
 		config.cookieDecode = decoder
 	}
 }
+
+// WithCookieEncode 是否启用 cookie 编码，默认启用，需要同时通过 WithCookieHandler 设置编码与解码函数
+// 例如开发环境中关闭编码，方便调试
+func WithCookieEncode(enable bool) Option {
+	return func(config *config) {
+		config.cookieEncodeDisabled = !enable
+	}
+}
+
+// WithCookieLegacyMD5 是否接受旧版本 HMAC-MD5 签名的 cookie，默认接受，通过 ctx.Cookie 读取时使用 HMAC-SHA256 重新签名
+// 旧版本签名的 cookie 都已经重新签名或者过期后，设置为 false 拒绝，之后的版本将默认拒绝
+func WithCookieLegacyMD5(enable bool) Option {
+	return func(config *config) {
+		config.cookieLegacyMD5Disabled = !enable
+	}
+}
+
+// WithCookieDefaults 设置 cookie 默认选项，例如 domain, path, secure, SameSite
+// 每次 SetCookie 时先应用默认选项，再应用调用时传入的选项，所以调用时可以覆盖默认值
+// 例如: WithCookieDefaults(context.WithCookieDomain(".example.com"), context.WithCookieSecure(true))
+func WithCookieDefaults(opts ...zeroapi.CookieOption) Option {
+	return func(config *config) {
+		config.cookieDefaults = append(config.cookieDefaults, opts...)
+	}
+}
+
+// WithJSONCodec 设置 JSON 编码与解码器，默认使用 encoding/json
+// 用于 ctx.JSON 等响应函数，以及 ctx.Bind 解析 JSON 请求体
+func WithJSONCodec(codec zeroapi.JSONCodec) Option {
+	return func(config *config) {
+		if codec != nil {
+			config.jsonCodec = codec
+		}
+	}
+}
+
+// WithMaxBodyBytes 设置请求体最大字节数，默认不限制
+// Content-Length 超过限制时返回 413，否则在读取超过限制时返回 zeroapi.ErrBodyTooLarge
+// 路由可以通过 zeroapi.RouteMiddleware{MaxBodyBytes: n} 设置不同的限制
+func WithMaxBodyBytes(n int64) Option {
+	return func(config *config) {
+		if n >= 0 {
+			config.maxBodyBytes = n
+		}
+	}
+}
+
+// WithReadHeaderTimeout 设置读取请求头的超时时间，默认 10 秒，防止慢速发送请求头占用连接(slowloris)
+func WithReadHeaderTimeout(timeout time.Duration) Option {
+	return func(config *config) {
+		if timeout >= 0 {
+			config.serverTimeouts.ReadHeader = timeout
+		}
+	}
+}
+
+// WithReadTimeout 设置读取整个请求(包括请求体)的超时时间，默认不限制
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(config *config) {
+		if timeout >= 0 {
+			config.serverTimeouts.Read = timeout
+		}
+	}
+}
+
+// WithWriteTimeout 设置从读取完请求头到写完响应的超时时间，默认不限制
+// 超时后连接被关闭，使用 SSE, WebSocket 或者下载大文件时不要设置
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(config *config) {
+		if timeout >= 0 {
+			config.serverTimeouts.Write = timeout
+		}
+	}
+}
+
+// WithIdleTimeout 设置 keep-alive 连接等待下一个请求的超时时间，默认 120 秒
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(config *config) {
+		if timeout >= 0 {
+			config.serverTimeouts.Idle = timeout
+		}
+	}
+}
+
+// WithH2C 支持明文 HTTP/2(h2c)，一般用于内网服务或者由负载均衡终止 TLS 的场景
+// 使用 TLS 时，HTTP/2 会自动启用，不需要此选项
+func WithH2C() Option {
+	return func(config *config) {
+		config.h2c = true
+	}
+}
+
+// WithAutoOptions 根据路由自动响应 OPTIONS 请求，不需要为每个路由注册 OPTIONS
+// 路径存在时响应 204，并在响应头 Allow 中列出可用的请求方法，已注册的 OPTIONS 路由优先
+// 应用级别中间件仍然会执行，例如跨域中间件处理预检请求
+func WithAutoOptions() Option {
+	return func(config *config) {
+		config.autoOptions = true
+	}
+}
+
+// WithServeMode 设置运行方式，默认 zeroapi.ServeModeHTTP
+// ServeModeFastCGI: Run(addr) 中 addr 为空时使用前端服务器传入的标准输入监听，"unix:/path" 监听 unix socket，否则监听 TCP
+// ServeModeCGI: Run 处理一个请求后返回，addr 被忽略，标准输出用于响应，日志需要输出到标准错误或者文件
+func WithServeMode(mode string) Option {
+	return func(config *config) {
+		switch mode {
+		case zeroapi.ServeModeHTTP, zeroapi.ServeModeFastCGI, zeroapi.ServeModeCGI:
+			config.serveMode = mode
+		}
+	}
+}
+
+// WithRouterOptions 设置路由配置选项，例如 router.WithRedirectTrailingSlash()
+func WithRouterOptions(opts ...router.Option) Option {
+	return func(config *config) {
+		config.routerOptions = append(config.routerOptions, opts...)
+	}
+}
+
+// WithNow 设置获取当前时间的函数，默认 time.Now，通过 ctx.Now() 使用
+// 一般用于测试中固定时间
+func WithNow(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
+
+// WithRand 设置随机数来源，默认 crypto/rand.Reader，通过 ctx.Rand() 使用
+// 一般用于测试中使用固定的随机数，生产环境中必须是密码学安全的随机数来源
+func WithRand(rand io.Reader) Option {
+	return func(config *config) {
+		if rand != nil {
+			config.rand = rand
+		}
+	}
+}
+
+// WithPanicHandler 设置处理函数发生 panic 时执行的函数，没有设置时只记录日志
+// 例如: WithPanicHandler(recovery.New(recovery.WithDebug(true)))
+func WithPanicHandler(handler zeroapi.PanicHandler) Option {
+	return func(config *config) {
+		config.panicHandler = handler
+	}
+}
+
+// WithCertManager 设置 RunAutoTLS 使用的证书管理器，没有设置时 RunAutoTLS 返回 ErrNoCertManager
+// 例如使用 golang.org/x/crypto/acme/autocert，在 factory 中返回
+// &autocert.Manager{Prompt: autocert.AcceptTOS, HostPolicy: autocert.HostWhitelist(domains...), Cache: autocert.DirCache(cacheDir)}
+func WithCertManager(factory zeroapi.CertManagerFactory) Option {
+	return func(config *config) {
+		config.certManager = factory
+	}
+}
+
+// WithAutoTLSCacheDir 设置 RunAutoTLS 的证书缓存目录，默认 certs，重启后不需要重新申请证书
+func WithAutoTLSCacheDir(dir string) Option {
+	return func(config *config) {
+		if dir != "" {
+			config.autoTLSCacheDir = dir
+		}
+	}
+}
+
+// WithAutoTLSRedirectAddr 设置 RunAutoTLS 处理 HTTP-01 验证以及重定向到 https 的 http 服务地址，默认 :80
+// 为空时不启动，只能使用 TLS-ALPN-01 验证
+func WithAutoTLSRedirectAddr(addr string) Option {
+	return func(config *config) {
+		config.autoTLSRedirectAddr = addr
+	}
+}
+
+// WithShutdownReport 设置优雅关闭报告的处理函数，例如上报到监控系统
+// 无论是否设置，报告都会以 JSON 格式记录到日志
+func WithShutdownReport(fn func(report *zeroapi.ShutdownReport)) Option {
+	return func(config *config) {
+		config.shutdownReport = fn
+	}
+}
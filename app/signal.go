@@ -0,0 +1,74 @@
+package app
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// signals 应用的信号循环，所有通过 OnSignal 注册的处理函数共用一个监听通道
+type signals struct {
+	mu sync.RWMutex
+
+	// ch 接收信号
+	ch chan os.Signal
+
+	// handlers 按照信号存储处理函数
+	handlers map[os.Signal][]func()
+
+	once sync.Once
+}
+
+func newSignals() *signals {
+	return &signals{
+		ch:       make(chan os.Signal, 1),
+		handlers: make(map[os.Signal][]func()),
+	}
+}
+
+// OnSignal 注册信号处理函数，收到 sig 时执行 fn，比如 SIGHUP 时重新加载配置
+// 同一个信号可以注册多个处理函数，按注册顺序执行
+func (a *app) OnSignal(sig os.Signal, fn func()) {
+	if sig == nil || fn == nil {
+		return
+	}
+
+	s := a.signals
+
+	s.mu.Lock()
+	if _, exist := s.handlers[sig]; !exist {
+		signal.Notify(s.ch, sig)
+	}
+	s.handlers[sig] = append(s.handlers[sig], fn)
+	s.mu.Unlock()
+
+	s.once.Do(func() {
+		go a.listenSignal()
+	})
+}
+
+// listenSignal 信号循环
+func (a *app) listenSignal() {
+	s := a.signals
+
+	for sig := range s.ch {
+		s.mu.RLock()
+		handlers := s.handlers[sig]
+		s.mu.RUnlock()
+
+		for _, handler := range handlers {
+			a.runSignalHandler(sig, handler)
+		}
+	}
+}
+
+// runSignalHandler 执行信号处理函数，处理函数中的异常不影响信号循环
+func (a *app) runSignalHandler(sig os.Signal, handler func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			a.Logger().Errorf("signal %s handler panic: %+v", sig, p)
+		}
+	}()
+
+	handler()
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+package app_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+func TestOnSignal(t *testing.T) {
+	a := app.NewApp()
+
+	calls := make(chan int, 4)
+	a.OnSignal(syscall.SIGHUP, func() {
+		calls <- 1
+		panic("reload failed")
+	})
+	a.OnSignal(syscall.SIGHUP, func() {
+		calls <- 2
+	})
+
+	// 处理函数中的异常不影响之后的处理函数，也不影响信号循环
+	for round := 0; round < 2; round++ {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, expected := range []int{1, 2} {
+			select {
+			case got := <-calls:
+				if got != expected {
+					t.Fatalf("round %d: expect handler %d, got %d", round, expected, got)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("round %d: handler %d not called", round, expected)
+			}
+		}
+	}
+}
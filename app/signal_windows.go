@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package app
+
+import "os"
+
+// restartSignal windows 不支持热重启，OnSignal 忽略 nil 信号
+var restartSignal os.Signal
@@ -0,0 +1,172 @@
+// Package cache 进程内 LRU 缓存，支持过期时间，应用默认使用，通过 app.Cache() 获取
+//
+// 示例:
+// user, err := app.Cache().GetOrLoad("user:"+id, time.Minute, func() (interface{}, error) { return loadUser(id) })
+// cache.Register(app, "config", loadConfig, time.Minute) 在后台刷新，请求中通过 app.Cache().Get("config") 获取
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// ErrLoadPanic 加载函数发生 panic，等待同一个 key 的其它调用收到该错误
+var ErrLoadPanic = errors.New("cache load panic")
+
+// entry 一个缓存
+type entry struct {
+	key   string
+	value interface{}
+
+	// expireAt 过期时间，零值表示不过期
+	expireAt time.Time
+}
+
+// call 正在执行的加载
+type call struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+type cache struct {
+	config *config
+
+	mu sync.Mutex
+
+	// ll 按照访问时间排列，最近访问的在前面
+	ll *list.List
+
+	// items 按照 key 存储 ll 中的元素
+	items map[string]*list.Element
+
+	// calls 正在执行的加载
+	calls map[string]*call
+}
+
+// New 创建进程内缓存
+func New(opts ...Option) zeroapi.Cache {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &cache{
+		config: config,
+		ll:     list.New(),
+		items:  make(map[string]*list.Element),
+		calls:  make(map[string]*call),
+	}
+}
+
+// Get 获取缓存，不存在或者已过期时 ok 为 false
+func (c *cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.get(key)
+}
+
+// get 需要持有锁，过期的缓存在此删除
+func (c *cache) get(key string) (interface{}, bool) {
+	el, exist := c.items[key]
+	if !exist {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if !e.expireAt.IsZero() && !c.config.now().Before(e.expireAt) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// Set 设置缓存，ttl <= 0 时不过期
+func (c *cache) Set(key string, value interface{}, ttl time.Duration) {
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = c.config.now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, exist := c.items[key]; exist {
+		e := el.Value.(*entry)
+		e.value, e.expireAt = value, expireAt
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&entry{key: key, value: value, expireAt: expireAt})
+
+	for c.ll.Len() > c.config.capacity {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Delete 删除缓存
+func (c *cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, exist := c.items[key]; exist {
+		c.remove(el)
+	}
+}
+
+// GetOrLoad 获取缓存，不存在时调用 load 加载并缓存 ttl，同一个 key 同时只会调用一次 load
+func (c *cache) GetOrLoad(key string, ttl time.Duration, load func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return value, nil
+	}
+
+	if cl, exist := c.calls[key]; exist {
+		c.mu.Unlock()
+		cl.wg.Wait()
+		return cl.value, cl.err
+	}
+
+	cl := &call{err: ErrLoadPanic}
+	cl.wg.Add(1)
+	c.calls[key] = cl
+	c.mu.Unlock()
+
+	// load 发生 panic 时等待的调用收到 ErrLoadPanic
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		cl.wg.Done()
+	}()
+
+	cl.value, cl.err = load()
+	if cl.err == nil {
+		c.Set(key, cl.value, ttl)
+	}
+
+	return cl.value, cl.err
+}
+
+// Len 缓存数量，包括已过期但还没有被清理的缓存
+func (c *cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// remove 需要持有锁
+func (c *cache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
@@ -0,0 +1,99 @@
+package cache_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/cache"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+	c := cache.New(cache.WithCapacity(2), cache.WithNow(func() time.Time { return now }))
+
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, 0)
+
+	// 访问 a 后，b 是最近最少使用的
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("invalid a: %v", v)
+	}
+	c.Set("c", 3, 0)
+	if _, ok := c.Get("b"); ok || c.Len() != 2 {
+		t.Fatal("b should be evicted")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("a should be expired")
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Fatalf("invalid c: %v", v)
+	}
+
+	c.Delete("c")
+	if c.Len() != 0 {
+		t.Fatalf("invalid len: %d", c.Len())
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	c := cache.New()
+
+	var loads int32
+	release := make(chan struct{})
+	load := func() (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "user", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan interface{}, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad("user:1", time.Minute, load)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- v
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != "user" {
+			t.Fatalf("invalid result: %v", v)
+		}
+	}
+	if atomic.LoadInt32(&loads) != 1 {
+		t.Fatalf("load called %d times", loads)
+	}
+
+	// 错误不缓存
+	errLoad := errors.New("load failed")
+	if _, err := c.GetOrLoad("user:2", time.Minute, func() (interface{}, error) { return nil, errLoad }); err != errLoad {
+		t.Fatalf("expect errLoad, got %v", err)
+	}
+	if _, ok := c.Get("user:2"); ok {
+		t.Fatal("error should not be cached")
+	}
+
+	// panic 时不会一直等待
+	func() {
+		defer func() { recover() }()
+		c.GetOrLoad("user:3", time.Minute, func() (interface{}, error) { panic("boom") })
+	}()
+	if v, err := c.GetOrLoad("user:3", time.Minute, func() (interface{}, error) { return "ok", nil }); err != nil || v != "ok" {
+		t.Fatalf("invalid reload: %v %v", v, err)
+	}
+}
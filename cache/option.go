@@ -0,0 +1,40 @@
+package cache
+
+import "time"
+
+// config 缓存配置
+type config struct {
+	// capacity 最多缓存的数量
+	capacity int
+
+	// now 获取当前时间，用于判断是否过期
+	now func() time.Time
+}
+
+func defaultConfig() *config {
+	return &config{
+		capacity: 10000,
+		now:      time.Now,
+	}
+}
+
+// Option 缓存配置选项
+type Option func(config *config)
+
+// WithCapacity 设置最多缓存的数量，超过时淘汰最近最少使用的缓存，默认 10000
+func WithCapacity(capacity int) Option {
+	return func(config *config) {
+		if capacity > 0 {
+			config.capacity = capacity
+		}
+	}
+}
+
+// WithNow 设置获取当前时间的函数，用于测试
+func WithNow(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
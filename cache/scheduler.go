@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+
+	"github.com/zerogo-hub/zero-helper/logger"
+)
+
+// metricRefreshErrors 后台刷新失败的次数
+const metricRefreshErrors = "cache_refresh_errors_total"
+
+// Loader 加载缓存的值
+type Loader func() (interface{}, error)
+
+// refreshEntry 一个在后台刷新的缓存
+type refreshEntry struct {
+	key    string
+	loader Loader
+	ttl    time.Duration
+
+	// next 下次刷新的时间
+	next time.Time
+
+	// loading 是否正在刷新
+	loading bool
+}
+
+// Scheduler 在后台定期刷新缓存，请求中通过 Cache.Get 获取，不会因为缓存过期而等待加载
+// 实现了 zeroapi.Service，启动时同步加载所有缓存，之后在过期前刷新，刷新失败时提前重试
+type Scheduler struct {
+	cache   zeroapi.Cache
+	logger  logger.Logger
+	metrics zeroapi.Metrics
+
+	mu      sync.Mutex
+	entries []*refreshEntry
+	running bool
+
+	// wake 添加缓存后唤醒调度循环
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewScheduler 创建后台刷新调度器，刷新的值写入 c
+func NewScheduler(c zeroapi.Cache, log logger.Logger, m zeroapi.Metrics) *Scheduler {
+	return &Scheduler{
+		cache:   c,
+		logger:  log,
+		metrics: m,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+var (
+	schedulersMu sync.Mutex
+
+	// schedulers 每个应用一个调度器
+	schedulers = make(map[zeroapi.App]*Scheduler)
+)
+
+// Register 注册在后台刷新的缓存，写入 app.Cache()，调度器随应用一同启动与停止
+// 启动前注册的缓存在应用启动时加载，启动后注册的缓存立即在后台加载
+// ttl: 缓存的有效时间，在过期前刷新
+//
+// 示例:
+// cache.Register(app, "config", loadConfig, time.Minute)
+// config, ok := ctx.App().Cache().Get("config")
+func Register(app zeroapi.App, key string, loader Loader, ttl time.Duration) {
+	schedulersMu.Lock()
+	s, exist := schedulers[app]
+	if !exist {
+		s = NewScheduler(app.Cache(), app.Logger(), app.Metrics())
+		schedulers[app] = s
+		app.AddService(s)
+	}
+	schedulersMu.Unlock()
+
+	s.Register(key, loader, ttl)
+}
+
+// Register 注册在后台刷新的缓存，ttl <= 0 时使用 1 分钟
+func (s *Scheduler) Register(key string, loader Loader, ttl time.Duration) {
+	if loader == nil {
+		return
+	}
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+
+	s.mu.Lock()
+	s.entries = append(s.entries, &refreshEntry{key: key, loader: loader, ttl: ttl})
+	running := s.running
+	s.mu.Unlock()
+
+	if running {
+		s.notify()
+	}
+}
+
+// Start 同步加载所有缓存，然后启动调度循环
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	entries := make([]*refreshEntry, len(s.entries))
+	copy(entries, s.entries)
+	s.running = true
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	for _, e := range entries {
+		s.mu.Lock()
+		e.loading = true
+		s.mu.Unlock()
+		s.refresh(e)
+	}
+
+	go s.loop()
+	return nil
+}
+
+// Stop 停止调度循环，不等待正在执行的刷新
+func (s *Scheduler) Stop() error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+	return nil
+}
+
+// notify 唤醒调度循环
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop 调度循环，刷新到期的缓存后等待下一个到期时间
+func (s *Scheduler) loop() {
+	defer close(s.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		wait := s.dispatch(time.Now())
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch 在新的 goroutine 中刷新到期的缓存，返回距离下一个到期时间的间隔
+func (s *Scheduler) dispatch(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := time.Hour
+	for _, e := range s.entries {
+		if e.loading {
+			continue
+		}
+
+		if !e.next.After(now) {
+			e.loading = true
+			go s.refresh(e)
+			continue
+		}
+
+		if d := e.next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+
+	return wait
+}
+
+// refresh 加载缓存，成功时在 ttl 的 80% 之后再次刷新，失败时在 ttl 的 10% 之后重试
+func (s *Scheduler) refresh(e *refreshEntry) {
+	next := e.ttl / 10
+	defer func() {
+		if p := recover(); p != nil {
+			s.logger.Errorf("cache refresh %s panic: %+v", e.key, p)
+			s.metrics.Counter(metricRefreshErrors).Add(1, "key", e.key)
+		}
+
+		s.mu.Lock()
+		e.loading = false
+		e.next = time.Now().Add(next)
+		s.mu.Unlock()
+
+		s.notify()
+	}()
+
+	value, err := e.loader()
+	if err != nil {
+		s.logger.Errorf("cache refresh %s: %s", e.key, err.Error())
+		s.metrics.Counter(metricRefreshErrors).Add(1, "key", e.key)
+		return
+	}
+
+	s.cache.Set(e.key, value, e.ttl)
+	next = e.ttl * 4 / 5
+}
@@ -0,0 +1,58 @@
+package cache_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/cache"
+	"github.com/zerogo-hub/zero-api/metrics"
+
+	"github.com/zerogo-hub/zero-helper/logger"
+)
+
+func TestScheduler(t *testing.T) {
+	c := cache.New()
+	s := cache.NewScheduler(c, logger.NewSampleLogger(), metrics.New())
+
+	var version int32
+	s.Register("config", func() (interface{}, error) {
+		v := atomic.AddInt32(&version, 1)
+		if v == 2 {
+			return nil, errors.New("refresh failed")
+		}
+		return v, nil
+	}, 100*time.Millisecond)
+
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 启动时已经加载
+	if v, ok := c.Get("config"); !ok || v != int32(1) {
+		t.Fatalf("invalid config: %v", v)
+	}
+
+	// 在过期前刷新，刷新失败后提前重试
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		v, ok := c.Get("config")
+		if ok && v.(int32) >= 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("config not refreshed: %v", v)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	stopped := atomic.LoadInt32(&version)
+	time.Sleep(200 * time.Millisecond)
+	if v := atomic.LoadInt32(&version); v > stopped+1 {
+		t.Fatalf("refreshed after stop: %d > %d", v, stopped)
+	}
+}
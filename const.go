@@ -2,6 +2,7 @@ package zeroapi
 
 import (
 	"net/http"
+	"time"
 )
 
 const (
@@ -36,6 +37,68 @@ const (
 	MethodAny = "ANY"
 )
 
+const (
+	// ReasonNoRoute 路由不存在
+	ReasonNoRoute = "no_route"
+
+	// ReasonValidatorFailed 路由存在，但动态参数未通过正则表达式或者验证函数
+	ReasonValidatorFailed = "validator_failed"
+
+	// ReasonBodyTooLarge 请求体超过限制
+	ReasonBodyTooLarge = "body_too_large"
+
+	// ReasonBadContentType 不支持的 Content-Type
+	ReasonBadContentType = "bad_content_type"
+
+	// ReasonBindFailed 请求参数绑定失败
+	ReasonBindFailed = "bind_failed"
+
+	// ReasonValidationFailed 请求参数验证失败
+	ReasonValidationFailed = "validation_failed"
+
+	// ReasonMethodNotAllowed 路径存在，但请求方法不匹配
+	ReasonMethodNotAllowed = "method_not_allowed"
+
+	// ReasonHTTPError 处理函数返回了 4xx 的 HTTPError
+	ReasonHTTPError = "http_error"
+
+	// ReasonRateLimited 请求频率超过限制
+	ReasonRateLimited = "rate_limited"
+)
+
+const (
+	// ServeModeHTTP 作为 http 服务器运行，默认
+	ServeModeHTTP = "http"
+
+	// ServeModeFastCGI 作为 FastCGI 响应器运行，由 nginx, Apache 等前端服务器转发请求
+	ServeModeFastCGI = "fcgi"
+
+	// ServeModeCGI 作为 CGI 程序运行，每个进程处理一个请求，请求信息来自环境变量与标准输入
+	ServeModeCGI = "cgi"
+)
+
+const (
+	// ValueKeyPrincipal 认证通过的用户保存在 ctx.Value 中的键，由认证中间件设置，见 middleware/auth
+	ValueKeyPrincipal = "zeroapi.auth.user"
+
+	// ValueKeyRealPrincipal 模拟其它用户时，真实用户保存在 ctx.Value 中的键，此时 ValueKeyPrincipal 为被模拟的用户
+	ValueKeyRealPrincipal = "zeroapi.auth.real_user"
+)
+
+const (
+	// SerializerJSON JSON 序列化
+	SerializerJSON = "json"
+
+	// SerializerXML XML 序列化
+	SerializerXML = "xml"
+
+	// SerializerYAML YAML 序列化
+	SerializerYAML = "yaml"
+
+	// SerializerMsgPack MessagePack 序列化
+	SerializerMsgPack = "msgpack"
+)
+
 // AllMethods 所有 HTTP Method
 func AllMethods() []string {
 	return []string{
@@ -51,16 +114,270 @@ func AllMethods() []string {
 	}
 }
 
+// LimitBody 创建限制请求体大小的中间件，n 单位为字节，小于等于 0 时不限制
+// Content-Length 超过限制时直接返回 413，否则在读取超过限制时返回 ErrBodyTooLarge，见 Context.SetMaxBodySize
+func LimitBody(n int64) Handler {
+	return func(ctx Context) {
+		if n <= 0 {
+			return
+		}
+
+		if ctx.Request().ContentLength > n {
+			ctx.ClientError(http.StatusRequestEntityTooLarge, ReasonBodyTooLarge, "REQUEST ENTITY TOO LARGE")
+			return
+		}
+
+		ctx.SetMaxBodySize(n)
+	}
+}
+
+// ChargeCost 创建记录请求成本的中间件，见 Context.ChargeCost
+// 作为 RouteMiddleware.Before 时在限流中间件之前执行，请求开始时即按照该成本扣除令牌
+func ChargeCost(n int) Handler {
+	return func(ctx Context) {
+		ctx.ChargeCost(n)
+	}
+}
+
 type (
 	// Handler 处理函数
 	Handler func(ctx Context)
 
+	// HandlerE 返回错误的处理函数，通过 E 转换为 Handler，错误交给 App 的错误处理函数
+	HandlerE func(ctx Context) error
+
+	// ErrorHandler 错误处理函数，将错误转换为响应，见 App.SetErrorHandler
+	ErrorHandler func(ctx Context, err error)
+
+	// RouteMiddleware 路由级别中间件与 App 级别中间件的执行顺序
+	// 执行顺序: Before -> App 级别中间件 -> 路由级别中间件和处理函数
+	// 没有 Before 且不跳过时，App 级别中间件在匹配路由之前执行，可以改写请求路径，例如去掉前缀
+	RouteMiddleware struct {
+		// Before 在 App 级别中间件之前执行的中间件
+		Before []Handler
+
+		// SkipGlobal 跳过 App 级别中间件，例如健康检查跳过鉴权
+		SkipGlobal bool
+
+		// Name 路由名称，通过 ctx.RouteName 获取，例如 blog.show
+		Name string
+
+		// Timeout 路由的超时时间，通过 ctx.Route().Timeout 获取，timeout 中间件优先使用该时间
+		Timeout time.Duration
+
+		// Meta 路由的自定义信息，通过 ctx.Route().Meta 获取，例如 {"owner": "billing"}
+		Meta map[string]string
+
+		// Cost 路由的成本，在所有中间件之前通过 ctx.ChargeCost 记录，限流时按照成本消耗令牌
+		Cost int
+
+		// MaxBodyBytes 请求体最大字节数，为 0 时使用 App 的限制(见 app.WithMaxBodyBytes)，小于 0 时不限制
+		// 例如上传路由设置较大的限制
+		MaxBodyBytes int64
+
+		// SLO 路由的服务等级目标，通过 App.SLOs 获取，由 slo 包导出为文档或者告警规则
+		SLO *SLO
+	}
+
+	// RouteInfo 注册的路由，Router.LookupRoute 匹配时返回，见 Context.Route
+	// 所有匹配该路由的请求共享同一个实例，不能修改
+	RouteInfo struct {
+		Method string
+
+		// Path 注册路由时的路径，包括前缀，例如 /blog/:id/name
+		Path string
+
+		// Name 路由名称，见 RouteMiddleware.Name
+		Name string
+
+		// Timeout 路由的超时时间，见 RouteMiddleware.Timeout
+		Timeout time.Duration
+
+		// Meta 路由的自定义信息，见 RouteMiddleware.Meta
+		Meta map[string]string
+
+		// Before 在 App 级别中间件之前执行的中间件，见 RouteMiddleware.Before
+		Before []Handler
+
+		// SkipGlobal 跳过 App 级别中间件，见 RouteMiddleware.SkipGlobal
+		SkipGlobal bool
+
+		// Cost 路由的成本，见 RouteMiddleware.Cost
+		Cost int
+
+		// MaxBodyBytes 请求体最大字节数，见 RouteMiddleware.MaxBodyBytes
+		MaxBodyBytes int64
+	}
+
+	// Warning 请求处理过程中的警告，见 Context.AddWarning
+	Warning struct {
+		// Code 机器可读的代码，例如 deprecated_param
+		Code string `json:"code"`
+
+		// Message 说明
+		Message string `json:"message"`
+	}
+
+	// ServerTimeouts http 服务的超时时间，见 http.Server，为 0 时不限制
+	// 用于 RunListeners, RunAddrs, RunAutoTLS 启动的服务，Run, RunTLS 使用的 HTTPServer 不支持
+	ServerTimeouts struct {
+		// ReadHeader 读取请求头的超时时间，防止慢速发送请求头占用连接(slowloris)
+		ReadHeader time.Duration
+
+		// Read 读取整个请求(包括请求体)的超时时间
+		Read time.Duration
+
+		// Write 从读取完请求头到写完响应的超时时间，会中断 SSE, 大文件下载等长时间的响应
+		Write time.Duration
+
+		// Idle keep-alive 连接等待下一个请求的超时时间
+		Idle time.Duration
+	}
+
+	// SLO 服务等级目标
+	SLO struct {
+		// Availability 可用性目标，不返回 5xx 的请求比例，例如 0.999，为 0 时不声明
+		Availability float64
+
+		// Latency 延迟阈值，例如 300ms，应该与请求耗时直方图的桶一致，为 0 时不声明
+		Latency time.Duration
+
+		// LatencyTarget 耗时不超过 Latency 的请求比例，例如 0.99
+		LatencyTarget float64
+
+		// Window 统计周期，默认 30 天
+		Window time.Duration
+
+		// Labels 附加到告警上的标签，例如 team, severity
+		Labels map[string]string
+	}
+
+	// RouteSLO 路由声明的服务等级目标
+	RouteSLO struct {
+		// Host 虚拟主机，见 App.Host
+		Host string
+
+		// Method 请求方法
+		Method string
+
+		// Path 路由定义，包含前缀，例如 /blog/:id
+		Path string
+
+		SLO SLO
+	}
+
+	// StaticConfig 静态资源服务配置
+	StaticConfig struct {
+		// Index 访问目录时返回的首页文件，默认 index.html
+		Index []string
+
+		// Browse 没有首页文件时，是否列出目录内容，默认不允许
+		Browse bool
+
+		// MaxAge 设置 Cache-Control: public, max-age，0 表示不发送
+		MaxAge time.Duration
+	}
+
+	// ProxyConfig 反向代理配置
+	ProxyConfig struct {
+		// StripPrefix 去掉路由中通配符之前的部分，例如 /api/* 代理 /api/users 时，上游收到 /users
+		StripPrefix bool
+
+		// Rewrite 改写发送给上游的路径，在 StripPrefix 之后执行，返回的路径拼接在上游地址的路径之后
+		Rewrite func(path string) string
+
+		// PreserveHost 使用原始请求的 Host，默认使用上游地址的 Host
+		PreserveHost bool
+
+		// Timeout 上游的超时时间，包括重试，默认 30 秒，超时响应 504
+		Timeout time.Duration
+
+		// Retries 幂等请求(GET, HEAD, OPTIONS, PUT, DELETE)连接失败或者上游响应 502, 503, 504 时的重试次数，默认不重试
+		// 请求体不超过 1MB 时才会重试
+		Retries int
+
+		// Transport 发送请求使用的 RoundTripper，默认 http.DefaultTransport
+		Transport http.RoundTripper
+
+		// ModifyResponse 修改上游的响应，返回错误时响应 502
+		ModifyResponse func(res *http.Response) error
+
+		// Balancer 在多个上游之间选择，设置后不使用 target，例如 proxy.NewPool(proxy.ConsistentHash(proxy.HeaderKey("X-User-Id")), targets...)
+		Balancer ProxyBalancer
+	}
+
+	// ShutdownReport 优雅关闭报告，用于排查发布期间的 502
+	ShutdownReport struct {
+		// Start 开始关闭的时间
+		Start time.Time `json:"start"`
+
+		// Duration 关闭耗时
+		Duration time.Duration `json:"duration"`
+
+		// Drained 关闭期间正常断开的连接数量
+		// App.Run 启动的服务无法统计连接，使用正在处理的请求数量
+		Drained int `json:"drained"`
+
+		// Remaining 超过等待时间仍未断开的连接数量
+		Remaining int `json:"remaining"`
+
+		// Unfinished 超过等待时间仍未完成的请求
+		Unfinished []UnfinishedRequest `json:"unfinished,omitempty"`
+
+		// FailedHooks 执行失败的钩子，例如停止服务失败
+		FailedHooks []FailedHook `json:"failed_hooks,omitempty"`
+
+		// Error 关闭出错，例如超过等待时间
+		Error string `json:"error,omitempty"`
+	}
+
+	// UnfinishedRequest 优雅关闭超时时仍未完成的请求
+	UnfinishedRequest struct {
+		Method string `json:"method"`
+
+		// Route 匹配的路由路径，例如 /blog/:id，路由尚未匹配时为请求路径
+		Route string `json:"route"`
+
+		// Duration 已经处理的时间
+		Duration time.Duration `json:"duration"`
+	}
+
+	// FailedHook 优雅关闭时执行失败的钩子
+	FailedHook struct {
+		Name  string `json:"name"`
+		Error string `json:"error"`
+	}
+
+	// CertManagerFactory 创建证书管理器，见 App.RunAutoTLS
+	// domains: 需要获取证书的域名
+	// cacheDir: 证书缓存目录，见 app.WithAutoTLSCacheDir
+	CertManagerFactory func(domains []string, cacheDir string) CertManager
+
+	// WebSocketHandler WebSocket 处理函数，握手成功后执行
+	WebSocketHandler func(ctx Context, conn WebSocket)
+
+	// EventHandler 事件订阅者，topic 为事件的主题
+	EventHandler func(topic string, data interface{})
+
+	// PanicHandler 处理函数发生 panic 时执行，例如记录调用栈并响应 500，见 middleware/recovery
+	// err: recover() 的返回值
+	PanicHandler func(ctx Context, err interface{})
+
 	// HookHandler 钩子处理函数，用于中间件开发，响应 ctx.afters, ctx.ends
 	HookHandler func() error
 
 	// RouterValidator 验证函数
 	RouterValidator func(s string) bool
 
+	// RouterConstraint 路由参数约束，根据参数创建验证函数
+	// arg: 约束的参数，例如 /:name<minlen(3)> 中的 "3"，没有参数时为空
+	RouterConstraint func(arg string) (RouterValidator, error)
+
+	// StructValidator 结构体字段验证函数，用于 validate 标签中的自定义规则
+	// value: 字段的值
+	// param: 规则参数，例如 validate:"prefix=zero" 中的 "zero"
+	StructValidator func(value interface{}, param string) bool
+
 	// CookieEncodeHandler cookie 编码与解码函数
 	CookieEncodeHandler func(s string) string
 
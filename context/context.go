@@ -3,11 +3,16 @@ package context
 import (
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
 	zeroapi "github.com/zerogo-hub/zero-api"
 )
 
+// metricClientErrors 记录 4xx 错误的指标名称
+const metricClientErrors = "http_client_errors_total"
+
 const (
 	// ContextStatusNormal 正常状态
 	ContextStatusNormal = 1
@@ -29,6 +34,8 @@ type context struct {
 	httpCode int
 	// responseSize 响应内容大小
 	responseSize int64
+	// cost 请求成本
+	cost int
 
 	// dynamic 存储动态参数的值
 	// 示例:
@@ -40,6 +47,18 @@ type context struct {
 	// values 玩家自定义数据
 	values map[string]interface{}
 
+	// cookies 本次请求中设置的 cookie，在写入响应头之前统一写入
+	cookies []*http.Cookie
+
+	// flash 本次请求的一次性消息，第一次使用时读取
+	flash *flashState
+
+	// warnings 本次请求的警告
+	warnings []zeroapi.Warning
+
+	// route 匹配的路由，存储 *zeroapi.RouteInfo
+	route atomic.Value
+
 	// afters 存储钩子函数，路由执行成功后才会执行
 	afters []zeroapi.HookHandler
 	// ends 存储钩子函数，无论路由是否执行成功，无论是否发生异常，都会在最终处执行 ends，后进先出
@@ -62,11 +81,25 @@ func (ctx *context) App() zeroapi.App {
 }
 
 func (ctx *context) Reset(res http.ResponseWriter, req *http.Request) {
-	ctx.res = acquireWriter()
-	ctx.res.SetWriter(res)
+	ctx.res = &writer{ResponseWriter: res, logger: ctx.app.Logger()}
 	ctx.req = req
 	ctx.status = ContextStatusNormal
 	ctx.httpCode = http.StatusOK
+	ctx.responseSize = 0
+	ctx.cost = 0
+
+	ctx.dynamics = nil
+	ctx.values = nil
+
+	ctx.cookies = nil
+	ctx.res.BeforeWriteHeader(ctx.writeCookies)
+	ctx.flash = nil
+
+	ctx.warnings = nil
+	ctx.res.BeforeWriteHeader(ctx.writeWarnings)
+	ctx.res.BeforeFinish(ctx.logWarnings)
+
+	ctx.route.Store(noRoute)
 
 	ctx.afters = nil
 	ctx.ends = nil
@@ -76,6 +109,12 @@ func (ctx *context) Request() *http.Request {
 	return ctx.req
 }
 
+func (ctx *context) SetRequest(req *http.Request) {
+	if req != nil {
+		ctx.req = req
+	}
+}
+
 func (ctx *context) Response() zeroapi.Writer {
 	return ctx.res
 }
@@ -94,7 +133,7 @@ func (ctx *context) HTTPCode() int {
 
 func (ctx *context) SetHTTPCode(httpCode int) {
 	ctx.httpCode = httpCode
-	ctx.res.Writer().WriteHeader(httpCode)
+	ctx.res.WriteHeader(httpCode)
 }
 
 func (ctx *context) IP() string {
@@ -148,7 +187,7 @@ func (ctx *context) Host() string {
 }
 
 func (ctx *context) IsAjax() bool {
-	return ctx.Header("X-Requested-With") == "XMLHttpRequest"
+	return ctx.IsAJAX()
 }
 
 func (ctx *context) Referer() string {
@@ -160,8 +199,22 @@ func (ctx *context) UserAgent() string {
 }
 
 func (ctx *context) NotFound() {
-	ctx.SetHTTPCode(http.StatusNotFound)
-	ctx.Message(http.StatusNotFound, "PAGE NOT FOUND")
+	ctx.writeError(http.StatusNotFound, "PAGE NOT FOUND")
+}
+
+func (ctx *context) ClientError(httpCode int, reason string, message ...string) {
+	ctx.app.Metrics().Counter(metricClientErrors).Add(1,
+		"code", strconv.Itoa(httpCode),
+		"reason", reason,
+		"method", ctx.Method(),
+	)
+
+	if logger := ctx.app.Logger(); logger.IsDebugAble() {
+		logger.Debugf("client error %d %s: %s %s", httpCode, reason, ctx.Method(), ctx.Path())
+	}
+
+	ctx.writeError(httpCode, message...)
+	ctx.Stopped()
 }
 
 func (ctx *context) IsStopped() bool {
@@ -0,0 +1,226 @@
+package context
+
+import (
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// bindTagQuery 绑定 URL 参数时使用的标签
+	bindTagQuery = "query"
+
+	// bindTagForm 绑定表单参数时使用的标签
+	bindTagForm = "form"
+)
+
+var (
+	errBindTarget = errors.New("bind target must be a non-nil pointer to struct")
+
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+func (ctx *context) Bind(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errBindTarget
+	}
+
+	if err := ctx.bindBody(dst); err != nil {
+		return err
+	}
+
+	if query := ctx.req.URL.Query(); len(query) > 0 {
+		return bindValues(rv.Elem(), query, bindTagQuery)
+	}
+
+	return nil
+}
+
+// bindBody 根据 Content-Type 解析请求体
+func (ctx *context) bindBody(dst interface{}) error {
+	if ctx.req.Body == nil || ctx.req.Body == http.NoBody {
+		return nil
+	}
+
+	contentType := ctx.req.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	// 结构化语法后缀，例如 application/merge-patch+json
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		mediaType = "application/json"
+	case strings.HasSuffix(mediaType, "+xml"):
+		mediaType = "application/xml"
+	}
+
+	switch mediaType {
+	case "application/json":
+		return ignoreEOF(ctx.app.JSONCodec().NewDecoder(ctx.req.Body).Decode(dst))
+	case "application/xml", "text/xml":
+		return ignoreEOF(xml.NewDecoder(ctx.req.Body).Decode(dst))
+	case "application/x-www-form-urlencoded":
+		if err := ctx.req.ParseForm(); err != nil {
+			return err
+		}
+		return bindValues(reflect.ValueOf(dst).Elem(), ctx.req.PostForm, bindTagForm)
+	case "multipart/form-data":
+		if err := ctx.req.ParseMultipartForm(ctx.app.FileMaxMemory()); err != nil {
+			return err
+		}
+		return bindValues(reflect.ValueOf(dst).Elem(), ctx.req.MultipartForm.Value, bindTagForm)
+	}
+
+	return &zeroapi.UnsupportedMediaTypeError{ContentType: contentType}
+}
+
+// ignoreEOF 请求体为空时不认为是错误
+func ignoreEOF(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+
+	return err
+}
+
+// bindValues 将 values 按照 tag 标签绑定到结构体 rv 中
+func bindValues(rv reflect.Value, values map[string][]string, tag string) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		// 非导出字段
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		key := field.Tag.Get(tag)
+		if idx := strings.Index(key, ","); idx >= 0 {
+			key = key[:idx]
+		}
+
+		if key == "-" {
+			continue
+		}
+
+		// 未设置标签的嵌入结构体，继续解析其字段
+		if key == "" {
+			if field.Anonymous && fv.Kind() == reflect.Struct {
+				if err := bindValues(fv, values, tag); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		vs, exist := values[key]
+		if !exist || len(vs) == 0 || !fv.CanSet() {
+			continue
+		}
+
+		if err := setField(fv, vs); err != nil {
+			return &zeroapi.BindError{Field: field.Name, Key: key, Value: strings.Join(vs, ","), Err: err}
+		}
+	}
+
+	return nil
+}
+
+// setField 将 vs 转为字段类型后赋值
+func setField(fv reflect.Value, vs []string) error {
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(fv.Type(), len(vs), len(vs))
+		for i, v := range vs {
+			if err := setValue(slice.Index(i), v); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+
+	return setValue(fv, vs[0])
+}
+
+// setValue 将字符串 s 转为 fv 的类型后赋值
+func setValue(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setValue(fv.Elem(), s)
+	}
+
+	if fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			return nil
+		}
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			return nil
+		}
+		v, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			return nil
+		}
+		v, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			return nil
+		}
+		v, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(v)
+	case reflect.Slice:
+		// []byte
+		fv.SetBytes([]byte(s))
+	default:
+		return errors.New("unsupported field type " + fv.Type().String())
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package context_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+type bindUser struct {
+	ID      int64         `query:"id"`
+	Name    string        `json:"name" form:"name"`
+	Age     uint8         `json:"age" form:"age"`
+	Tags    []string      `form:"tag"`
+	Score   *float64      `form:"score"`
+	Timeout time.Duration `query:"timeout"`
+	Ignore  string        `query:"-" form:"-"`
+	bindPage
+}
+
+type bindPage struct {
+	Page int `query:"page"`
+}
+
+func newTestContext(req *http.Request) zeroapi.Context {
+	a := app.NewApp()
+	ctx := a.Context()
+	ctx.Reset(httptest.NewRecorder(), req)
+	return ctx
+}
+
+func TestBindJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/user?id=1001&page=2&timeout=3s", strings.NewReader(`{"name":"Yaha","age":18}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var user bindUser
+	if err := newTestContext(req).Bind(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	if user.ID != 1001 || user.Name != "Yaha" || user.Age != 18 || user.Page != 2 || user.Timeout != 3*time.Second {
+		t.Fatalf("invalid user: %+v", user)
+	}
+}
+
+func TestBindJSONSuffix(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPatch, "/user", strings.NewReader(`{"name":"Yaha"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	var user bindUser
+	if err := newTestContext(req).Bind(&user); err != nil || user.Name != "Yaha" {
+		t.Fatalf("invalid user: %+v, %v", user, err)
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	body := "name=Gama&age=20&tag=a&tag=b&score=9.5&Ignore=x"
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var user bindUser
+	if err := newTestContext(req).Bind(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Name != "Gama" || user.Age != 20 || len(user.Tags) != 2 || user.Tags[1] != "b" {
+		t.Fatalf("invalid user: %+v", user)
+	}
+
+	if user.Score == nil || *user.Score != 9.5 {
+		t.Fatal("invalid score")
+	}
+
+	if user.Ignore != "" {
+		t.Fatal("field should be ignored")
+	}
+}
+
+func TestBindError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/user?id=abc", nil)
+
+	var user bindUser
+	err := newTestContext(req).Bind(&user)
+
+	var bindErr *zeroapi.BindError
+	if !errors.As(err, &bindErr) || bindErr.Field != "ID" || bindErr.Key != "id" {
+		t.Fatalf("invalid error: %v", err)
+	}
+
+	if err := newTestContext(req).Bind(user); err == nil {
+		t.Fatal("bind to non-pointer should fail")
+	}
+}
+
+func TestBindUnsupportedContentType(t *testing.T) {
+	a := app.NewApp()
+	a.Post("/user", zeroapi.E(func(ctx zeroapi.Context) error {
+		var user bindUser
+		return ctx.Bind(&user)
+	}))
+	a.Router().Build()
+
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader("name=Gama"))
+	req.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("invalid status: %d", w.Code)
+	}
+
+	reported := false
+	for _, sample := range a.Metrics().Gather() {
+		for i := 0; i+1 < len(sample.Labels); i += 2 {
+			if sample.Labels[i] == "reason" && sample.Labels[i+1] == zeroapi.ReasonBadContentType {
+				reported = true
+			}
+		}
+	}
+	if !reported {
+		t.Fatal("bad content type should be reported")
+	}
+
+	var user bindUser
+	err := newTestContext(req).Bind(&user)
+
+	var mediaTypeErr *zeroapi.UnsupportedMediaTypeError
+	if !errors.As(err, &mediaTypeErr) || mediaTypeErr.ContentType != "text/plain" {
+		t.Fatalf("invalid error: %v", err)
+	}
+}
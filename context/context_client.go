@@ -0,0 +1,120 @@
+package context
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// mobileKeywords User-Agent 中表示移动设备的关键字
+var mobileKeywords = []string{"Mobi", "Android", "iPhone", "iPad", "iPod", "Windows Phone", "BlackBerry", "Opera Mini"}
+
+func (ctx *context) IsAJAX() bool {
+	if ctx.Header("X-Requested-With") == "XMLHttpRequest" {
+		return true
+	}
+
+	// fetch() 发起的请求，Sec-Fetch-Mode 为 cors 或者 same-origin，且 Sec-Fetch-Dest 为 empty
+	if ctx.Header("Sec-Fetch-Dest") == "empty" {
+		mode := ctx.Header("Sec-Fetch-Mode")
+		return mode == "cors" || mode == "same-origin"
+	}
+
+	return false
+}
+
+func (ctx *context) IsMobile() bool {
+	// Client Hints，?1 表示移动设备
+	switch ctx.Header("Sec-CH-UA-Mobile") {
+	case "?1":
+		return true
+	case "?0":
+		return false
+	}
+
+	ua := ctx.UserAgent()
+	for _, keyword := range mobileKeywords {
+		if strings.Contains(ua, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ctx *context) PrefersHTML() bool {
+	if ctx.IsAJAX() {
+		return false
+	}
+
+	switch ctx.Header("Sec-Fetch-Dest") {
+	case "document", "iframe", "frame":
+		return true
+	case "":
+	default:
+		return false
+	}
+
+	if ctx.Header("Sec-Fetch-Mode") == "navigate" {
+		return true
+	}
+
+	htmlQ, jsonQ := acceptQuality(ctx.Header("Accept"))
+	return htmlQ > 0 && htmlQ >= jsonQ
+}
+
+// acceptQuality 解析 Accept，返回 html 与 json 的权重
+// 只有 */* 时两者权重均为 0，例如 curl 等非浏览器客户端
+func acceptQuality(accept string) (htmlQ, jsonQ float64) {
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			if q > htmlQ {
+				htmlQ = q
+			}
+		case "application/json", "text/json":
+			if q > jsonQ {
+				jsonQ = q
+			}
+		}
+	}
+
+	return
+}
+
+// writeError 设置状态码并输出错误信息，浏览器访问时输出 HTML 页面，否则输出 JSON
+// 状态码会立即写入，所以需要先设置 Content-Type
+func (ctx *context) writeError(httpCode int, message ...string) {
+	if !ctx.PrefersHTML() {
+		ctx.SetHeader("Content-Type", "application/json;charset=utf-8")
+		ctx.SetHTTPCode(httpCode)
+		ctx.Message(httpCode, message...)
+		return
+	}
+
+	title := strconv.Itoa(httpCode) + " " + http.StatusText(httpCode)
+	body := title
+	if len(message) > 0 {
+		body = message[0]
+	}
+
+	ctx.SetHeader("Content-Type", "text/html;charset=utf-8")
+	ctx.SetHTTPCode(httpCode)
+	ctx.Bytes([]byte(fmt.Sprintf("<!DOCTYPE html><html><head><title>%s</title></head><body><h1>%s</h1></body></html>",
+		template.HTMLEscapeString(title), template.HTMLEscapeString(body))))
+}
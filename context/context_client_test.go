@@ -0,0 +1,89 @@
+package context_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsAJAX(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	if !newTestContext(req).IsAJAX() {
+		t.Fatal("X-Requested-With should be ajax")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Sec-Fetch-Dest", "empty")
+	req.Header.Set("Sec-Fetch-Mode", "cors")
+	if !newTestContext(req).IsAJAX() {
+		t.Fatal("fetch should be ajax")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Sec-Fetch-Dest", "document")
+	req.Header.Set("Sec-Fetch-Mode", "navigate")
+	if newTestContext(req).IsAJAX() {
+		t.Fatal("navigation should not be ajax")
+	}
+}
+
+func TestIsMobile(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 14_0 like Mac OS X) Mobile/15E148")
+	if !newTestContext(req).IsMobile() {
+		t.Fatal("iPhone should be mobile")
+	}
+
+	req.Header.Set("Sec-CH-UA-Mobile", "?0")
+	if newTestContext(req).IsMobile() {
+		t.Fatal("client hints should take precedence")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)")
+	if newTestContext(req).IsMobile() {
+		t.Fatal("desktop should not be mobile")
+	}
+}
+
+func TestPrefersHTML(t *testing.T) {
+	cases := []struct {
+		accept string
+		dest   string
+		want   bool
+	}{
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "", true},
+		{"application/json", "", false},
+		{"*/*", "", false},
+		{"", "", false},
+		{"application/json;q=0.5,text/html;q=0.9", "", true},
+		{"*/*", "document", true},
+		{"text/html", "image", false},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", c.accept)
+		if c.dest != "" {
+			req.Header.Set("Sec-Fetch-Dest", c.dest)
+		}
+		if got := newTestContext(req).PrefersHTML(); got != c.want {
+			t.Fatalf("accept: %q, dest: %q, want: %v, got: %v", c.accept, c.dest, c.want, got)
+		}
+	}
+}
+
+func TestNotFoundHTML(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+
+	ctx := newTestContext(req)
+	ctx.Reset(w, req)
+	ctx.NotFound()
+
+	if ct := w.Result().Header.Get("Content-Type"); ct != "text/html;charset=utf-8" {
+		t.Fatalf("invalid content type: %s", ct)
+	}
+}
@@ -0,0 +1,14 @@
+package context
+
+import (
+	"io"
+	"time"
+)
+
+func (ctx *context) Now() time.Time {
+	return ctx.app.Now()
+}
+
+func (ctx *context) Rand() io.Reader {
+	return ctx.app.Rand()
+}
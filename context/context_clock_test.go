@@ -0,0 +1,34 @@
+package context_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+func TestNowAndRand(t *testing.T) {
+	now := time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+	a := app.NewApp(
+		app.WithNow(func() time.Time { return now }),
+		app.WithRand(bytes.NewReader(bytes.Repeat([]byte{7}, 16))),
+	)
+	ctx := a.Context()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !ctx.Now().Equal(now) || !ctx.Copy().Time().Equal(now) {
+		t.Fatalf("invalid now: %v", ctx.Now())
+	}
+
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(ctx.Rand(), b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, bytes.Repeat([]byte{7}, 16)) {
+		t.Fatalf("invalid rand: %v", b)
+	}
+}
@@ -2,16 +2,24 @@ package context
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"io"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/timestamp"
 	"github.com/zerogo-hub/zero-helper/crypto"
-	"github.com/zerogo-hub/zero-helper/time"
 )
 
 // Cookie 获取 cookie 值
@@ -40,9 +48,19 @@ func (ctx *context) Cookie(name string, opts ...zeroapi.CookieOption) (string, e
 		cookie.Value = value
 	}
 
-	for _, opt := range opts {
-		if err := opt(cookie); err != nil {
-			return "", err
+	if len(opts) > 0 {
+		read := &cookieRead{legacyMD5: ctx.app.IsCookieLegacyMD5()}
+		cookieReads.Store(cookie, read)
+		defer cookieReads.Delete(cookie)
+
+		for _, opt := range opts {
+			if err := opt(cookie); err != nil {
+				return "", err
+			}
+		}
+
+		if read.resigned != "" {
+			ctx.resignCookie(oname, read.resigned)
 		}
 	}
 
@@ -50,6 +68,40 @@ func (ctx *context) Cookie(name string, opts ...zeroapi.CookieOption) (string, e
 	return val, err
 }
 
+// cookieReads 通过 ctx.Cookie 读取中的 cookie，WithCookieVerify 从中获取应用的设置，并记录重新签名后的值
+var cookieReads sync.Map
+
+// cookieRead ctx.Cookie 读取一个 cookie 时的状态
+type cookieRead struct {
+	// legacyMD5 是否接受旧版本 HMAC-MD5 的签名
+	legacyMD5 bool
+
+	// resigned 旧版本签名的 cookie 使用 HMAC-SHA256 重新签名后的值
+	resigned string
+}
+
+// resignCookie 将使用 HMAC-SHA256 重新签名的 cookie 写入响应，使用 cookie 默认选项
+// 本次请求中已经设置了该 cookie 时不覆盖
+func (ctx *context) resignCookie(name, value string) {
+	cookie := &http.Cookie{Name: name, Value: value}
+	for _, opt := range ctx.app.CookieDefaults() {
+		if err := opt(cookie); err != nil {
+			ctx.app.Logger().Errorf("resign cookie %s: %s", name, err.Error())
+			return
+		}
+	}
+
+	if ctx.app.IsCookieEncode() {
+		handler := ctx.app.CookieEncodeHandler()
+		cookie.Name = handler(cookie.Name)
+		cookie.Value = handler(cookie.Value)
+	}
+
+	if ctx.ResponseCookie(cookie.Name) == nil {
+		ctx.setResponseCookie(cookie)
+	}
+}
+
 // SetCookie 设置 cookie，见 https://tools.ietf.org/html/rfc6265
 // key: cookie 参数名称
 // value: cookie 值
@@ -61,15 +113,32 @@ func (ctx *context) Cookie(name string, opts ...zeroapi.CookieOption) (string, e
 // path: 见 https://tools.ietf.org/html/rfc6265#section-4.1.2.4
 // secure: 见 https://tools.ietf.org/html/rfc6265#section-4.1.2.5
 // httpOnly: 见 https://tools.ietf.org/html/rfc6265#section-4.1.2.6
+// 选项返回错误时不设置 cookie，并记录错误日志，例如 WithCookieEncrypt 的密钥长度不正确
 func (ctx *context) SetCookie(name, value string, opts ...zeroapi.CookieOption) {
+	if err := ctx.setCookie(name, value, opts...); err != nil {
+		ctx.app.Logger().Errorf("set cookie %s: %s", name, err.Error())
+	}
+}
+
+// setCookie 设置 cookie，选项返回错误时不设置
+func (ctx *context) setCookie(name, value string, opts ...zeroapi.CookieOption) error {
 	cookie := &http.Cookie{Name: name, Value: url.QueryEscape(value)}
 
+	// 先应用默认选项，调用时传入的选项可以覆盖默认值
+	for _, opt := range ctx.app.CookieDefaults() {
+		if err := opt(cookie); err != nil {
+			return err
+		}
+	}
+
 	for _, opt := range opts {
-		opt(cookie)
+		if err := opt(cookie); err != nil {
+			return err
+		}
 	}
 
-	// 默认存在 1 小时
-	if cookie.MaxAge == 0 {
+	// 默认存在 1 小时，设置了 Expires 时以 Expires 为准，浏览器优先使用 Max-Age
+	if cookie.MaxAge == 0 && cookie.Expires.IsZero() {
 		cookie.MaxAge = 3600
 	}
 
@@ -79,12 +148,15 @@ func (ctx *context) SetCookie(name, value string, opts ...zeroapi.CookieOption)
 		cookie.Value = handler(cookie.Value)
 	}
 
-	http.SetCookie(ctx.res.Writer(), cookie)
+	ctx.setResponseCookie(cookie)
+	return nil
 }
 
 // RemoveCookie 移除指定的 cookie
 func (ctx *context) RemoveCookie(name string, opts ...zeroapi.CookieOption) {
-	ctx.SetCookie(name, "", WithCookieMaxAge(-1))
+	// 删除时 domain, path 需要与设置时一致
+	opts = append(opts, WithCookieMaxAge(-1))
+	ctx.SetCookie(name, "", opts...)
 }
 
 // SetHTTPCookie 设置原始的 cookie
@@ -93,7 +165,7 @@ func (ctx *context) SetHTTPCookie(cookie *http.Cookie) {
 		panic("Cookie cannot be empty")
 	}
 
-	http.SetCookie(ctx.res.Writer(), cookie)
+	ctx.setResponseCookie(cookie)
 }
 
 // HTTPCookies 获取所有原始的 cookie
@@ -101,6 +173,43 @@ func (ctx *context) HTTPCookies() []*http.Cookie {
 	return ctx.req.Cookies()
 }
 
+// ResponseCookie 获取本次请求中已设置的 cookie，不存在时返回 nil
+func (ctx *context) ResponseCookie(name string) *http.Cookie {
+	for i := len(ctx.cookies) - 1; i >= 0; i-- {
+		if ctx.cookies[i].Name == name {
+			return ctx.cookies[i]
+		}
+	}
+
+	return nil
+}
+
+// ResponseCookies 获取本次请求中已设置的所有 cookie
+func (ctx *context) ResponseCookies() []*http.Cookie {
+	cookies := make([]*http.Cookie, len(ctx.cookies))
+	copy(cookies, ctx.cookies)
+	return cookies
+}
+
+// setResponseCookie 将 cookie 放入本次请求的 cookie 列表中，name, domain, path 相同时覆盖之前设置的 cookie
+func (ctx *context) setResponseCookie(cookie *http.Cookie) {
+	for i, c := range ctx.cookies {
+		if c.Name == cookie.Name && c.Domain == cookie.Domain && c.Path == cookie.Path {
+			ctx.cookies[i] = cookie
+			return
+		}
+	}
+
+	ctx.cookies = append(ctx.cookies, cookie)
+}
+
+// writeCookies 写入响应头之前，统一写入本次请求中设置的 cookie
+func (ctx *context) writeCookies() {
+	for _, cookie := range ctx.cookies {
+		http.SetCookie(ctx.res, cookie)
+	}
+}
+
 // WithCookieMaxAge ..
 // maxAge: 见 https://tools.ietf.org/html/rfc6265#section-4.1.2.2
 // 		 = 0: 表示不指定存活时间
@@ -141,6 +250,23 @@ func WithCookieSecure(secure bool) zeroapi.CookieOption {
 	}
 }
 
+// WithCookieSameSite SameSite: https://tools.ietf.org/html/draft-ietf-httpbis-rfc6265bis-05#section-4.1.2.7
+func WithCookieSameSite(sameSite http.SameSite) zeroapi.CookieOption {
+	return func(cookie *http.Cookie) error {
+		cookie.SameSite = sameSite
+		return nil
+	}
+}
+
+// WithCookieExpires expires: https://tools.ietf.org/html/rfc6265#section-4.1.2.1
+// 同时设置了 MaxAge 时浏览器使用 MaxAge
+func WithCookieExpires(expires time.Time) zeroapi.CookieOption {
+	return func(cookie *http.Cookie) error {
+		cookie.Expires = expires
+		return nil
+	}
+}
+
 // WithCookieHTTPOnly secure: https://tools.ietf.org/html/rfc6265#section-4.1.2.6
 func WithCookieHTTPOnly(httpOnly bool) zeroapi.CookieOption {
 	return func(cookie *http.Cookie) error {
@@ -149,28 +275,29 @@ func WithCookieHTTPOnly(httpOnly bool) zeroapi.CookieOption {
 	}
 }
 
-// WithCookieSign 对 cookie 进行签名
-func WithCookieSign(signKey string) zeroapi.CookieOption {
+// WithCookieSign 对 cookie 进行签名，使用 HMAC-SHA256
+// v: 可选，用于获取签名时间，例如 timestamp.New(timestamp.WithNow(app.Now))
+func WithCookieSign(signKey string, v ...*timestamp.Validator) zeroapi.CookieOption {
 	return func(cookie *http.Cookie) error {
 		if cookie.Name == "" {
 			return errors.New("cookie name is empty")
 		}
 
-		timestamp := strconv.Itoa(int(time.Now()))
+		stamp := cookieTimestamp(v).Stamp()
 
 		buf := cookieBuffer()
 		defer cookeReleaseBuffer(buf)
 
 		buf.WriteString(cookie.Name)
 		buf.WriteString(cookie.Value)
-		buf.WriteString(timestamp)
+		buf.WriteString(stamp)
 
-		sign := crypto.HmacMd5(buf.String(), signKey)
+		sign := cookieSign(buf.Bytes(), signKey)
 
 		buf.Reset()
 		buf.WriteString(cookie.Value)
 		buf.WriteString("|")
-		buf.WriteString(timestamp)
+		buf.WriteString(stamp)
 		buf.WriteString("|")
 		buf.WriteString(sign)
 
@@ -180,8 +307,12 @@ func WithCookieSign(signKey string) zeroapi.CookieOption {
 	}
 }
 
-// WithCookieVerify 对有签名的 cookie 进行验证
-func WithCookieVerify(signKey string) zeroapi.CookieOption {
+// WithCookieVerify 对有签名的 cookie 进行验证，兼容旧版本使用 HMAC-MD5 的签名
+// 通过 ctx.Cookie 读取旧版本签名的 cookie 时，使用 HMAC-SHA256 重新签名并写入响应，签名时间不变
+// 重新写入的 cookie 只应用 WithCookieDefaults 的选项，没有设置存活时间时为会话 cookie
+// 通过 app.WithCookieLegacyMD5(false) 拒绝旧版本的签名
+// v: 可选，用于校验签名时间，例如 timestamp.New(timestamp.WithMaxAge(24*time.Hour))，不传入时不校验签名时间，见 WithCookieVerifyMaxAge
+func WithCookieVerify(signKey string, v ...*timestamp.Validator) zeroapi.CookieOption {
 	return func(cookie *http.Cookie) error {
 		if cookie.Value == "" {
 			return errors.New("cookie value is empty")
@@ -195,7 +326,7 @@ func WithCookieVerify(signKey string) zeroapi.CookieOption {
 		}
 
 		value := l[0]
-		timestamp := l[1]
+		stamp := l[1]
 		sign := l[2]
 
 		buf := cookieBuffer()
@@ -203,20 +334,128 @@ func WithCookieVerify(signKey string) zeroapi.CookieOption {
 
 		buf.WriteString(cookie.Name)
 		buf.WriteString(value)
-		buf.WriteString(timestamp)
-		calcSign := crypto.HmacMd5(buf.String(), signKey)
+		buf.WriteString(stamp)
+		read, _ := cookieReads.Load(cookie)
+		legacy := len(sign) == cookieMd5SignLength
+		if legacy && read != nil && !read.(*cookieRead).legacyMD5 {
+			cookie.Value = ""
+			return errors.New("legacy cookie signature")
+		}
+
+		var calcSign string
+		if legacy {
+			calcSign = crypto.HmacMd5(buf.String(), signKey)
+		} else {
+			calcSign = cookieSign(buf.Bytes(), signKey)
+		}
 
-		if calcSign != sign {
+		if !hmac.Equal([]byte(calcSign), []byte(sign)) {
 			// cookie 值被篡改
 			cookie.Value = ""
 			return errors.New("invalid cookie value 2")
 		}
 
+		if len(v) > 0 && v[0] != nil {
+			if err := v[0].ValidateString(stamp); err != nil {
+				cookie.Value = ""
+				return err
+			}
+		}
+
+		if legacy && read != nil {
+			read.(*cookieRead).resigned = value + "|" + stamp + "|" + cookieSign(buf.Bytes(), signKey)
+		}
+
 		cookie.Value = value
 		return nil
 	}
 }
 
+// WithCookieEncrypt 使用 AES-GCM 加密 cookie，cookie 名称作为附加数据，一个 cookie 的值不能用于另一个 cookie
+// key: AES-128, AES-192, AES-256 分别需要 16, 24, 32 字节
+// 加密失败时清空 cookie 的值并返回错误，SetCookie 不会设置该 cookie
+func WithCookieEncrypt(key []byte) zeroapi.CookieOption {
+	return func(cookie *http.Cookie) error {
+		aead, err := cookieAEAD(key)
+		if err != nil {
+			cookie.Value = ""
+			return err
+		}
+
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(cookie.Value)+aead.Overhead())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			cookie.Value = ""
+			return err
+		}
+
+		sealed := aead.Seal(nonce, nonce, []byte(cookie.Value), []byte(cookie.Name))
+		cookie.Value = base64.RawURLEncoding.EncodeToString(sealed)
+		return nil
+	}
+}
+
+// WithCookieDecrypt 解密 WithCookieEncrypt 加密的 cookie
+// keys: 依次尝试解密，轮换密钥时将新密钥放在第一个，旧密钥在 cookie 过期之后再移除
+func WithCookieDecrypt(keys ...[]byte) zeroapi.CookieOption {
+	return func(cookie *http.Cookie) error {
+		sealed, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+		if err != nil {
+			cookie.Value = ""
+			return zeroapi.ErrCookieDecrypt
+		}
+
+		for _, key := range keys {
+			aead, err := cookieAEAD(key)
+			if err != nil || len(sealed) < aead.NonceSize() {
+				continue
+			}
+
+			nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+			if plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(cookie.Name)); err == nil {
+				cookie.Value = string(plaintext)
+				return nil
+			}
+		}
+
+		cookie.Value = ""
+		return zeroapi.ErrCookieDecrypt
+	}
+}
+
+// cookieMd5SignLength 旧版本 HMAC-MD5 签名的长度
+const cookieMd5SignLength = 32
+
+// cookieSign HMAC-SHA256 签名
+func cookieSign(data []byte, signKey string) string {
+	h := hmac.New(sha256.New, []byte(signKey))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func cookieAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// WithCookieVerifyMaxAge 对有签名的 cookie 进行验证，并拒绝签名时间早于 maxAge 之前的 cookie，防止重放很久之前截获的 cookie
+// 签名时间超过有效期时返回 timestamp.ErrExpired，maxAge <= 0 时不限制
+func WithCookieVerifyMaxAge(signKey string, maxAge time.Duration) zeroapi.CookieOption {
+	return WithCookieVerify(signKey, timestamp.New(timestamp.WithMaxAge(maxAge)))
+}
+
+// defaultCookieTimestamp 未指定时，用于获取 cookie 签名时间
+var defaultCookieTimestamp = timestamp.New()
+
+func cookieTimestamp(v []*timestamp.Validator) *timestamp.Validator {
+	if len(v) > 0 && v[0] != nil {
+		return v[0]
+	}
+	return defaultCookieTimestamp
+}
+
 var cookieBufferPool *sync.Pool
 
 // cookieBuffer 从池中获取 buffer
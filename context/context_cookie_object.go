@@ -0,0 +1,95 @@
+package context
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// cookieMaxSize 单个 cookie 的大小限制，包括名称与属性，见 https://tools.ietf.org/html/rfc6265#section-6.1
+	cookieMaxSize = 4096
+
+	// cookieChunkSize 每个分片的值的长度，为名称、属性以及编码、签名预留空间
+	cookieChunkSize = 3072
+
+	// cookieMaxChunks 最大分片数量
+	cookieMaxChunks = 8
+)
+
+func (ctx *context) SetCookieObject(name string, obj interface{}, opts ...zeroapi.CookieOption) error {
+	// encoding/json 按照键排序输出 map，编码结果稳定
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	value := base64.RawURLEncoding.EncodeToString(b)
+
+	n := (len(value) + cookieChunkSize - 1) / cookieChunkSize
+	if n > cookieMaxChunks {
+		return zeroapi.ErrCookieTooLarge
+	}
+
+	for i := 0; i < n; i++ {
+		end := (i + 1) * cookieChunkSize
+		if end > len(value) {
+			end = len(value)
+		}
+
+		chunkName := cookieChunkName(name, i)
+		if err := ctx.setCookie(chunkName, value[i*cookieChunkSize:end], opts...); err != nil {
+			return err
+		}
+
+		if c := ctx.ResponseCookie(chunkName); c != nil && len(c.String()) > cookieMaxSize {
+			ctx.removeCookieChunks(name, 0, i+1, opts...)
+			return zeroapi.ErrCookieTooLarge
+		}
+	}
+
+	// 删除之前保存的多余分片
+	ctx.removeCookieChunks(name, n, cookieMaxChunks, opts...)
+
+	return nil
+}
+
+func (ctx *context) CookieObject(name string, obj interface{}, opts ...zeroapi.CookieOption) error {
+	var value string
+
+	for i := 0; i < cookieMaxChunks; i++ {
+		chunk, err := ctx.Cookie(cookieChunkName(name, i), opts...)
+		if err != nil {
+			if i == 0 {
+				return err
+			}
+			break
+		}
+		value += chunk
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, obj)
+}
+
+// removeCookieChunks 删除 [from, to) 范围内，请求中存在或者本次请求中设置过的分片
+func (ctx *context) removeCookieChunks(name string, from, to int, opts ...zeroapi.CookieOption) {
+	for i := from; i < to; i++ {
+		chunkName := cookieChunkName(name, i)
+		if _, err := ctx.Cookie(chunkName); err == http.ErrNoCookie && ctx.ResponseCookie(chunkName) == nil {
+			continue
+		}
+		ctx.RemoveCookie(chunkName, opts...)
+	}
+}
+
+func cookieChunkName(name string, i int) string {
+	return name + "." + strconv.Itoa(i)
+}
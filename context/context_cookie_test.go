@@ -0,0 +1,365 @@
+package context_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	zctx "github.com/zerogo-hub/zero-api/context"
+	"github.com/zerogo-hub/zero-api/timestamp"
+	"github.com/zerogo-hub/zero-helper/crypto"
+	"github.com/zerogo-hub/zero-helper/logger"
+)
+
+func TestCookieDefaults(t *testing.T) {
+	a := app.NewApp(app.WithCookieDefaults(
+		zctx.WithCookieDomain(".example.com"),
+		zctx.WithCookiePath("/"),
+		zctx.WithCookieSecure(true),
+		zctx.WithCookieSameSite(http.SameSiteLaxMode),
+	))
+
+	w := httptest.NewRecorder()
+	ctx := a.Context()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	ctx.SetCookie("a", "1")
+	ctx.SetCookie("b", "2", zctx.WithCookieSecure(false), zctx.WithCookiePath("/b"))
+	ctx.Response().PrepareHeader()
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("invalid cookies: %d", len(cookies))
+	}
+
+	a1 := cookies[0]
+	if a1.Domain != "example.com" || a1.Path != "/" || !a1.Secure || a1.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("defaults not applied: %+v", a1)
+	}
+
+	b := cookies[1]
+	if b.Secure || b.Path != "/b" || b.Domain != "example.com" {
+		t.Fatalf("defaults not overridden: %+v", b)
+	}
+}
+
+func TestCookieEncodeDisabled(t *testing.T) {
+	encode := func(s string) string { return "x" + s }
+	decode := func(s string) (string, error) { return s[1:], nil }
+
+	if !app.NewApp(app.WithCookieHandler(encode, decode)).IsCookieEncode() {
+		t.Fatal("cookie encode should be enabled")
+	}
+
+	if app.NewApp(app.WithCookieHandler(encode, decode), app.WithCookieEncode(false)).IsCookieEncode() {
+		t.Fatal("cookie encode should be disabled")
+	}
+}
+
+func TestCookieJar(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := app.NewApp().Context()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	ctx.SetCookie("session", "handler")
+	ctx.SetCookie("theme", "dark")
+
+	if c := ctx.ResponseCookie("session"); c == nil || c.Value != "handler" {
+		t.Fatal("cookie should be visible before headers are written")
+	}
+
+	// 后续中间件覆盖之前设置的 cookie
+	ctx.SetCookie("session", "middleware")
+
+	if len(w.Header()["Set-Cookie"]) != 0 {
+		t.Fatal("cookies should be deferred")
+	}
+
+	ctx.Text("ok")
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 2 || cookies[0].Value != "middleware" || cookies[1].Value != "dark" {
+		t.Fatalf("invalid cookies: %v", cookies)
+	}
+}
+
+func TestCookieObject(t *testing.T) {
+	type prefs struct {
+		Theme string            `json:"theme"`
+		Tags  map[string]string `json:"tags"`
+	}
+
+	large := prefs{Theme: "dark", Tags: map[string]string{}}
+	for i := 0; i < 300; i++ {
+		large.Tags["key"+strconv.Itoa(i)] = "value" + strconv.Itoa(i)
+	}
+
+	a := app.NewApp()
+
+	w := httptest.NewRecorder()
+	ctx := a.Context()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err := ctx.SetCookieObject("prefs", large); err != nil {
+		t.Fatal(err)
+	}
+	ctx.Response().PrepareHeader()
+
+	cookies := w.Result().Cookies()
+	if len(cookies) < 2 || cookies[0].Name != "prefs.0" || cookies[1].Name != "prefs.1" {
+		t.Fatalf("cookie should be chunked: %d", len(cookies))
+	}
+	for _, c := range cookies {
+		if len(c.String()) > 4096 {
+			t.Fatalf("chunk too large: %d", len(c.String()))
+		}
+	}
+
+	// 读取时合并分片
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range cookies {
+		req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+
+	w = httptest.NewRecorder()
+	ctx.Reset(w, req)
+
+	var got prefs
+	if err := ctx.CookieObject("prefs", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Theme != "dark" || len(got.Tags) != 300 || got.Tags["key99"] != "value99" {
+		t.Fatal("invalid cookie object")
+	}
+
+	// 保存较小的值，删除多余的分片
+	if err := ctx.SetCookieObject("prefs", prefs{Theme: "light"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx.Response().PrepareHeader()
+
+	for _, c := range w.Result().Cookies() {
+		if c.Name != "prefs.0" && c.MaxAge >= 0 {
+			t.Fatalf("stale chunk not removed: %s", c.Name)
+		}
+	}
+
+	huge := map[string]string{}
+	for i := 0; i < 5000; i++ {
+		huge["key"+strconv.Itoa(i)] = "value" + strconv.Itoa(i)
+	}
+	if err := ctx.SetCookieObject("huge", huge); err != zeroapi.ErrCookieTooLarge {
+		t.Fatalf("want ErrCookieTooLarge, got: %v", err)
+	}
+}
+
+func TestCookieSignTimestamp(t *testing.T) {
+	now := time.Unix(1622534400, 0)
+	clock := func() time.Time { return now }
+	signer := timestamp.New(timestamp.WithNow(clock))
+	verifier := timestamp.New(timestamp.WithMaxAge(time.Hour), timestamp.WithNow(clock))
+
+	cookie := &http.Cookie{Name: "uid", Value: "1001"}
+	if err := zctx.WithCookieSign("key", signer)(cookie); err != nil {
+		t.Fatal(err)
+	}
+	signed := cookie.Value
+
+	if err := zctx.WithCookieVerify("key", verifier)(cookie); err != nil || cookie.Value != "1001" {
+		t.Fatalf("verify failed: %v, %q", err, cookie.Value)
+	}
+
+	now = now.Add(2 * time.Hour)
+	cookie.Value = signed
+	if err := zctx.WithCookieVerify("key", verifier)(cookie); err != timestamp.ErrExpired || cookie.Value != "" {
+		t.Fatalf("expect ErrExpired, got: %v, %q", err, cookie.Value)
+	}
+}
+
+func TestCookieEncrypt(t *testing.T) {
+	oldKey := []byte("0123456789abcdef0123456789abcdef")
+	newKey := []byte("fedcba9876543210fedcba9876543210")
+	a := app.NewApp()
+
+	w := httptest.NewRecorder()
+	ctx := a.Context()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.SetCookie("uid", "1001 & more", zctx.WithCookieEncrypt(oldKey))
+	ctx.Response().PrepareHeader()
+
+	encrypted := w.Result().Cookies()[0]
+	if strings.Contains(encrypted.Value, "1001") {
+		t.Fatalf("cookie not encrypted: %s", encrypted.Value)
+	}
+
+	read := func(name, value string, keys ...[]byte) (string, error) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+		ctx.Reset(httptest.NewRecorder(), req)
+		return ctx.Cookie(name, zctx.WithCookieDecrypt(keys...))
+	}
+
+	// 轮换密钥后，旧密钥加密的 cookie 仍然可以读取
+	if value, err := read("uid", encrypted.Value, newKey, oldKey); err != nil || value != "1001 & more" {
+		t.Fatalf("decrypt failed: %v, %q", err, value)
+	}
+
+	if _, err := read("uid", encrypted.Value, newKey); err != zeroapi.ErrCookieDecrypt {
+		t.Fatalf("expect ErrCookieDecrypt after key removed, got: %v", err)
+	}
+
+	// 一个 cookie 的值不能用于另一个 cookie
+	if _, err := read("admin", encrypted.Value, oldKey); err != zeroapi.ErrCookieDecrypt {
+		t.Fatalf("expect ErrCookieDecrypt for other cookie, got: %v", err)
+	}
+
+	cookie := &http.Cookie{Name: "uid", Value: "1001"}
+	if err := zctx.WithCookieEncrypt([]byte("short"))(cookie); err == nil || cookie.Value != "" {
+		t.Fatal("invalid key should clear the value")
+	}
+}
+
+// errorRecorder 记录错误日志的 logger，其它方法使用 logger.NewSampleLogger
+type errorRecorder struct {
+	logger.Logger
+	lines []string
+}
+
+func (r *errorRecorder) Errorf(format string, a ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, a...))
+}
+
+func TestCookieOptionError(t *testing.T) {
+	log := &errorRecorder{Logger: logger.NewSampleLogger()}
+	a := app.NewApp(app.WithLogger(log))
+
+	ctx := a.Context()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// 密钥长度不正确，不设置 cookie，也不会以明文保存
+	ctx.SetCookie("uid", "1001", zctx.WithCookieEncrypt([]byte("short")))
+	if c := ctx.ResponseCookie("uid"); c != nil {
+		t.Fatalf("cookie should not be set: %v", c)
+	}
+	if len(log.lines) != 1 || !strings.HasPrefix(log.lines[0], "set cookie uid: ") {
+		t.Fatalf("expect error logged, got %v", log.lines)
+	}
+
+	if err := ctx.SetCookieObject("profile", map[string]string{"name": "tom"}, zctx.WithCookieEncrypt([]byte("short"))); err == nil {
+		t.Fatal("expect error from SetCookieObject")
+	}
+	if c := ctx.ResponseCookie("profile.0"); c != nil {
+		t.Fatalf("cookie should not be set: %v", c)
+	}
+}
+
+func TestCookieSignLegacy(t *testing.T) {
+	cookie := &http.Cookie{Name: "uid", Value: "1001"}
+	if err := zctx.WithCookieSign("key")(cookie); err != nil {
+		t.Fatal(err)
+	}
+	if parts := strings.Split(cookie.Value, "|"); len(parts) != 3 || len(parts[2]) != 64 {
+		t.Fatalf("expect HMAC-SHA256 signature: %s", cookie.Value)
+	}
+
+	// 旧版本使用 HMAC-MD5 签名的 cookie 仍然可以验证
+	stamp := timestamp.New().Stamp()
+	legacy := &http.Cookie{Name: "uid", Value: "1001|" + stamp + "|" + crypto.HmacMd5("uid1001"+stamp, "key")}
+	if err := zctx.WithCookieVerify("key")(legacy); err != nil || legacy.Value != "1001" {
+		t.Fatalf("legacy verify failed: %v, %q", err, legacy.Value)
+	}
+
+	forged := &http.Cookie{Name: "uid", Value: "1001|" + stamp + "|" + crypto.HmacMd5("uid1001"+stamp, "other")}
+	if err := zctx.WithCookieVerify("key")(forged); err == nil {
+		t.Fatal("forged legacy signature should fail")
+	}
+}
+
+func TestCookieLegacyResign(t *testing.T) {
+	stamp := timestamp.New().Stamp()
+	legacy := "1001|" + stamp + "|" + crypto.HmacMd5("uid1001"+stamp, "key")
+
+	read := func(a zeroapi.App) (string, *http.Cookie, error) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "uid", Value: legacy})
+
+		ctx := a.Context()
+		ctx.Reset(httptest.NewRecorder(), req)
+		value, err := ctx.Cookie("uid", zctx.WithCookieVerify("key"))
+		return value, ctx.ResponseCookie("uid"), err
+	}
+
+	// 默认接受旧版本的签名，并使用 HMAC-SHA256 重新签名
+	value, resigned, err := read(app.NewApp(app.WithCookieDefaults(zctx.WithCookiePath("/"))))
+	if err != nil || value != "1001" {
+		t.Fatalf("legacy verify failed: %v, %q", err, value)
+	}
+	if resigned == nil || resigned.Path != "/" {
+		t.Fatalf("expect resigned cookie, got %+v", resigned)
+	}
+	if parts := strings.Split(resigned.Value, "|"); len(parts) != 3 || parts[1] != stamp || len(parts[2]) != 64 {
+		t.Fatalf("expect HMAC-SHA256 signature: %s", resigned.Value)
+	}
+	if err := zctx.WithCookieVerify("key")(resigned); err != nil || resigned.Value != "1001" {
+		t.Fatalf("resigned verify failed: %v, %q", err, resigned.Value)
+	}
+
+	// 拒绝旧版本的签名
+	if _, resigned, err := read(app.NewApp(app.WithCookieLegacyMD5(false))); err == nil || resigned != nil {
+		t.Fatalf("legacy signature should be rejected: %v, %+v", err, resigned)
+	}
+}
+
+func TestCookieExpires(t *testing.T) {
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	w := httptest.NewRecorder()
+	ctx := app.NewApp().Context()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.SetCookie("remember", "1", zctx.WithCookieExpires(expires), zctx.WithCookieSameSite(http.SameSiteStrictMode))
+	ctx.SetCookie("theme", "dark")
+	ctx.Response().PrepareHeader()
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("invalid cookies: %d", len(cookies))
+	}
+
+	// 设置了 Expires 时不使用默认的 MaxAge
+	remember := cookies[0]
+	if !remember.Expires.Equal(expires) || remember.MaxAge != 0 || remember.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("unexpected cookie: %+v", remember)
+	}
+	if cookies[1].MaxAge != 3600 {
+		t.Fatalf("expect default MaxAge, got %d", cookies[1].MaxAge)
+	}
+}
+
+func TestCookieVerifyMaxAge(t *testing.T) {
+	sign := func(at time.Time) string {
+		cookie := &http.Cookie{Name: "uid", Value: "1001"}
+		zctx.WithCookieSign("key", timestamp.New(timestamp.WithNow(func() time.Time { return at })))(cookie)
+		return cookie.Value
+	}
+
+	fresh := &http.Cookie{Name: "uid", Value: sign(time.Now().Add(-time.Minute))}
+	if err := zctx.WithCookieVerifyMaxAge("key", time.Hour)(fresh); err != nil || fresh.Value != "1001" {
+		t.Fatalf("verify failed: %v, %q", err, fresh.Value)
+	}
+
+	stale := &http.Cookie{Name: "uid", Value: sign(time.Now().Add(-2 * time.Hour))}
+	if err := zctx.WithCookieVerifyMaxAge("key", time.Hour)(stale); err != timestamp.ErrExpired || stale.Value != "" {
+		t.Fatalf("expect ErrExpired, got: %v, %q", err, stale.Value)
+	}
+
+	// 签名不正确时不检查签名时间
+	tampered := &http.Cookie{Name: "uid", Value: sign(time.Now())}
+	if err := zctx.WithCookieVerifyMaxAge("other", time.Hour)(tampered); err == nil || err == timestamp.ErrExpired {
+		t.Fatalf("expect signature error, got: %v", err)
+	}
+}
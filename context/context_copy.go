@@ -0,0 +1,172 @@
+package context
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+func (ctx *context) Copy() zeroapi.Snapshot {
+	s := &snapshot{
+		method: ctx.Method(),
+		path:   ctx.Path(),
+		host:   ctx.Host(),
+		ip:     ctx.ClientIP(),
+		header: ctx.req.Header.Clone(),
+		query:  ctx.req.URL.Query(),
+		time:   ctx.Now(),
+	}
+
+	if len(ctx.dynamics) > 0 {
+		s.dynamics = make(map[string]string, len(ctx.dynamics))
+		for k, v := range ctx.dynamics {
+			s.dynamics[k] = v
+		}
+	}
+
+	if len(ctx.values) > 0 {
+		s.values = make(map[string]interface{}, len(ctx.values))
+		for k, v := range ctx.values {
+			s.values[k] = v
+		}
+	}
+
+	if user, ok := ctx.Value(zeroapi.ValueKeyPrincipal).(string); ok {
+		s.principal = user
+	}
+	s.realPrincipal = s.principal
+	if user, ok := ctx.Value(zeroapi.ValueKeyRealPrincipal).(string); ok && user != "" {
+		s.realPrincipal = user
+	}
+
+	if ctx.req.Body != nil && ctx.req.Body != http.NoBody {
+		s.body, s.err = ctx.copyBody()
+	}
+
+	return s
+}
+
+// copyBody 读取请求体，读取后放回，后续仍然可以读取请求体
+// 请求体已经被 SetMaxBodySize 限制时使用该限制，否则使用 App 的 MaxBodyBytes
+// 读取失败时把已经读取的部分放回原来请求体的前面，后续读取得到的数据与错误不变
+func (ctx *context) copyBody() ([]byte, error) {
+	body := ctx.req.Body
+
+	var reader io.Reader = body
+	limit := int64(0)
+	if _, ok := body.(*maxBytesReader); !ok {
+		limit = ctx.app.MaxBodyBytes()
+	}
+	if limit > 0 {
+		reader = io.LimitReader(body, limit+1)
+	}
+
+	data, err := ioutil.ReadAll(reader)
+	if err == nil && limit > 0 && int64(len(data)) > limit {
+		err = zeroapi.ErrBodyTooLarge
+	}
+	if err != nil {
+		ctx.req.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(data), body), body: body}
+		return nil, ctx.bodyError(err)
+	}
+
+	body.Close()
+	ctx.req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// replayBody 先返回已经读取的数据，再继续读取原来的请求体
+type replayBody struct {
+	io.Reader
+
+	body io.ReadCloser
+}
+
+func (r *replayBody) Close() error {
+	return r.body.Close()
+}
+
+// snapshot 请求的只读快照
+type snapshot struct {
+	method   string
+	path     string
+	host     string
+	ip       string
+	header   http.Header
+	query    url.Values
+	dynamics map[string]string
+	values   map[string]interface{}
+	body     []byte
+	err      error
+	time     time.Time
+
+	principal     string
+	realPrincipal string
+}
+
+func (s *snapshot) Method() string {
+	return s.method
+}
+
+func (s *snapshot) Path() string {
+	return s.path
+}
+
+func (s *snapshot) Host() string {
+	return s.host
+}
+
+func (s *snapshot) IP() string {
+	return s.ip
+}
+
+func (s *snapshot) Header(key string) string {
+	return s.header.Get(key)
+}
+
+func (s *snapshot) Headers() http.Header {
+	return s.header.Clone()
+}
+
+func (s *snapshot) Query(key string) string {
+	return s.query.Get(key)
+}
+
+func (s *snapshot) Dynamic(key string) string {
+	return s.dynamics[key]
+}
+
+func (s *snapshot) Value(key string) interface{} {
+	return s.values[key]
+}
+
+func (s *snapshot) Body() []byte {
+	if s.body == nil {
+		return nil
+	}
+
+	body := make([]byte, len(s.body))
+	copy(body, s.body)
+	return body
+}
+
+func (s *snapshot) Err() error {
+	return s.err
+}
+
+func (s *snapshot) Principal() string {
+	return s.principal
+}
+
+func (s *snapshot) RealPrincipal() string {
+	return s.realPrincipal
+}
+
+func (s *snapshot) Time() time.Time {
+	return s.time
+}
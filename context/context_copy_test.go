@@ -0,0 +1,89 @@
+package context_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/app"
+)
+
+func TestCopy(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/user/1001?page=2", strings.NewReader(`{"name":"zero"}`))
+	req.Header.Set("X-Token", "abc")
+
+	ctx := newTestContext(req)
+	ctx.SetDynamics(map[string]string{"id": "1001"})
+	ctx.SetValue("user", "zero")
+
+	s := ctx.Copy()
+
+	// 请求体仍然可以读取
+	body, _ := ioutil.ReadAll(ctx.Request().Body)
+	if string(body) != `{"name":"zero"}` {
+		t.Fatalf("body should still be readable: %s", body)
+	}
+
+	// Context 被重置
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other", nil))
+
+	if s.Method() != http.MethodPost || s.Path() != "/user/1001?page=2" {
+		t.Fatalf("invalid snapshot: %s %s", s.Method(), s.Path())
+	}
+	if s.Header("X-Token") != "abc" || s.Query("page") != "2" || s.Dynamic("id") != "1001" || s.Value("user") != "zero" {
+		t.Fatal("invalid snapshot values")
+	}
+	if string(s.Body()) != `{"name":"zero"}` {
+		t.Fatalf("invalid snapshot body: %s", s.Body())
+	}
+
+	s.Headers().Set("X-Token", "changed")
+	if s.Header("X-Token") != "abc" {
+		t.Fatal("snapshot should be immutable")
+	}
+}
+
+func TestCopyBodyTooLarge(t *testing.T) {
+	a := app.NewApp(app.WithMaxBodyBytes(4))
+	ctx := a.Context()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")))
+
+	s := ctx.Copy()
+	if s.Err() != zeroapi.ErrBodyTooLarge || s.Body() != nil {
+		t.Fatalf("invalid snapshot: %v %s", s.Err(), s.Body())
+	}
+
+	// 读取失败时请求体保持不变
+	body, _ := ioutil.ReadAll(ctx.Request().Body)
+	if string(body) != "0123456789" {
+		t.Fatalf("body should be left alone: %s", body)
+	}
+}
+
+func TestCopyLimitedBody(t *testing.T) {
+	ctx := newTestContext(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")))
+	ctx.SetMaxBodySize(4)
+
+	s := ctx.Copy()
+	if s.Err() != zeroapi.ErrBodyTooLarge || s.Body() != nil {
+		t.Fatalf("invalid snapshot: %v %s", s.Err(), s.Body())
+	}
+
+	// 再次读取时同样超过限制
+	if _, err := ioutil.ReadAll(ctx.Request().Body); err != zeroapi.ErrBodyTooLarge {
+		t.Fatalf("expected ErrBodyTooLarge, got %v", err)
+	}
+}
+
+func TestCopyPrincipal(t *testing.T) {
+	ctx := newTestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.SetValue(zeroapi.ValueKeyPrincipal, "zero")
+
+	s := ctx.Copy()
+	if s.Principal() != "zero" || s.Err() != nil {
+		t.Fatalf("invalid principal: %s %v", s.Principal(), s.Err())
+	}
+}
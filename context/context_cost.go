@@ -0,0 +1,11 @@
+package context
+
+func (ctx *context) ChargeCost(n int) {
+	if n > 0 {
+		ctx.cost += n
+	}
+}
+
+func (ctx *context) Cost() int {
+	return ctx.cost
+}
@@ -2,6 +2,10 @@ package context
 
 import (
 	"errors"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
 )
 
 func (ctx *context) Dynamic(key string) string {
@@ -45,3 +49,101 @@ func (ctx *context) SetDynamic(key string, value string) error {
 func (ctx *context) SetDynamics(dynamics map[string]string) {
 	ctx.dynamics = dynamics
 }
+
+var (
+	errParamMissing = errors.New("missing dynamic parameter")
+	errNotUUID      = errors.New("invalid UUID")
+)
+
+func (ctx *context) ParamInt(key string) (int, error) {
+	value, err := ctx.param(key)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, paramError(key, value, err)
+	}
+
+	return n, nil
+}
+
+func (ctx *context) ParamInt64(key string) (int64, error) {
+	value, err := ctx.param(key)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, paramError(key, value, err)
+	}
+
+	return n, nil
+}
+
+func (ctx *context) ParamBool(key string) (bool, error) {
+	value, err := ctx.param(key)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, paramError(key, value, err)
+	}
+
+	return b, nil
+}
+
+func (ctx *context) ParamUUID(key string) (string, error) {
+	value, err := ctx.param(key)
+	if err != nil {
+		return "", err
+	}
+
+	if !isUUID(value) {
+		return "", paramError(key, value, errNotUUID)
+	}
+
+	return value, nil
+}
+
+// param 获取动态参数的值，参数不存在或者为空时返回 *BindError
+func (ctx *context) param(key string) (string, error) {
+	value := ctx.Dynamic(key)
+	if value == "" {
+		return "", paramError(key, value, errParamMissing)
+	}
+
+	return value, nil
+}
+
+func paramError(key, value string, err error) error {
+	key = strings.TrimPrefix(key, ":")
+	return &zeroapi.BindError{Field: key, Key: key, Value: value, Err: err}
+}
+
+// isUUID 格式为 8-4-4-4-12 的十六进制字符
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
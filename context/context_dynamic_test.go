@@ -0,0 +1,57 @@
+package context_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+func TestParamTyped(t *testing.T) {
+	a := app.NewApp()
+
+	var id int
+	var id64 int64
+	var enabled bool
+	var uuid string
+	a.Get("/items/:id/:enabled/:uuid", zeroapi.E(func(ctx zeroapi.Context) error {
+		var err error
+		if id, err = ctx.ParamInt("id"); err != nil {
+			return err
+		}
+		if id64, err = ctx.ParamInt64(":id"); err != nil {
+			return err
+		}
+		if enabled, err = ctx.ParamBool("enabled"); err != nil {
+			return err
+		}
+		uuid, err = ctx.ParamUUID("uuid")
+		return err
+	}))
+	a.Router().Build()
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/items/42/true/123e4567-e89b-12d3-a456-426614174000", http.StatusOK},
+		{"/items/abc/true/123e4567-e89b-12d3-a456-426614174000", http.StatusBadRequest},
+		{"/items/42/yes/123e4567-e89b-12d3-a456-426614174000", http.StatusBadRequest},
+		{"/items/42/true/123e4567", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.code {
+			t.Fatalf("%s: expect %d, got %d", tt.path, tt.code, w.Code)
+		}
+	}
+
+	a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/7/1/123E4567-E89B-12D3-A456-426614174000", nil))
+	if id != 7 || id64 != 7 || !enabled || uuid != "123E4567-E89B-12D3-A456-426614174000" {
+		t.Fatalf("invalid params: %d, %d, %v, %s", id, id64, enabled, uuid)
+	}
+}
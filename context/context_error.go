@@ -0,0 +1,61 @@
+package context
+
+import (
+	"errors"
+	"net/http"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+func (ctx *context) Error(err error) {
+	if err == nil {
+		return
+	}
+
+	ctx.Stopped()
+
+	if handler := ctx.app.ErrorHandler(); handler != nil {
+		handler(ctx, err)
+		return
+	}
+
+	ctx.handleError(err)
+}
+
+// handleError 默认的错误处理
+// *HTTPError 按照状态码响应，请求参数绑定、验证失败、请求体过大以及不支持的 Content-Type 分别响应 400, 422, 413, 415
+// 其它错误记录日志并响应 500，不会把错误信息返回给客户端
+func (ctx *context) handleError(err error) {
+	if ctx.res.Written() {
+		ctx.app.Logger().Errorf("%s %s: %s, response already written", ctx.Method(), ctx.Path(), err.Error())
+		return
+	}
+
+	var httpErr *zeroapi.HTTPError
+	var bindErr *zeroapi.BindError
+	var validationErrs zeroapi.ValidationErrors
+	var mediaTypeErr *zeroapi.UnsupportedMediaTypeError
+
+	switch {
+	case errors.As(err, &httpErr):
+		if httpErr.Code >= http.StatusBadRequest && httpErr.Code < http.StatusInternalServerError {
+			ctx.ClientError(httpErr.Code, zeroapi.ReasonHTTPError, httpErr.Text())
+			return
+		}
+		if httpErr.Code >= http.StatusInternalServerError {
+			ctx.app.Logger().Errorf("%s %s: %s", ctx.Method(), ctx.Path(), err.Error())
+		}
+		ctx.writeError(httpErr.Code, httpErr.Text())
+	case errors.As(err, &bindErr):
+		ctx.ClientError(http.StatusBadRequest, zeroapi.ReasonBindFailed, bindErr.Error())
+	case errors.As(err, &validationErrs):
+		ctx.ClientError(http.StatusUnprocessableEntity, zeroapi.ReasonValidationFailed, validationErrs.Error())
+	case errors.As(err, &mediaTypeErr):
+		ctx.ClientError(http.StatusUnsupportedMediaType, zeroapi.ReasonBadContentType, mediaTypeErr.Error())
+	case errors.Is(err, zeroapi.ErrBodyTooLarge):
+		ctx.ClientError(http.StatusRequestEntityTooLarge, zeroapi.ReasonBodyTooLarge, "REQUEST ENTITY TOO LARGE")
+	default:
+		ctx.app.Logger().Errorf("%s %s: %s", ctx.Method(), ctx.Path(), err.Error())
+		ctx.writeError(http.StatusInternalServerError, "INTERNAL SERVER ERROR")
+	}
+}
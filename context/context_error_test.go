@@ -0,0 +1,72 @@
+package context_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+var errUserNotFound = zeroapi.NewHTTPError(http.StatusNotFound, "user not found")
+
+func serveError(a zeroapi.App, err error) (*http.Response, map[string]string) {
+	called := false
+	a.Get("/user", zeroapi.E(func(ctx zeroapi.Context) error {
+		return err
+	}), func(ctx zeroapi.Context) {
+		called = true
+	})
+	a.Router().Build()
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user", nil))
+	if called && err != nil {
+		panic("handler chain should stop after error")
+	}
+
+	var body map[string]string
+	json.NewDecoder(w.Result().Body).Decode(&body)
+	return w.Result(), body
+}
+
+func TestErrorDefault(t *testing.T) {
+	res, body := serveError(app.NewApp(), errUserNotFound.Wrap(errors.New("sql: no rows")))
+	if res.StatusCode != http.StatusNotFound || body["message"] != "user not found" {
+		t.Fatalf("invalid response: %d, %v", res.StatusCode, body)
+	}
+
+	res, body = serveError(app.NewApp(), errors.New("db down"))
+	if res.StatusCode != http.StatusInternalServerError || body["message"] != "INTERNAL SERVER ERROR" {
+		t.Fatalf("invalid response: %d, %v", res.StatusCode, body)
+	}
+
+	res, _ = serveError(app.NewApp(), zeroapi.ValidationErrors{{Field: "name", Rule: "required", Message: "name is required"}})
+	if res.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("invalid status: %d", res.StatusCode)
+	}
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	a := app.NewApp()
+
+	var got error
+	a.SetErrorHandler(func(ctx zeroapi.Context, err error) {
+		got = err
+		ctx.SetHTTPCode(http.StatusTeapot)
+	})
+
+	wrapped := errUserNotFound.Wrap(errors.New("sql: no rows"))
+	res, _ := serveError(a, wrapped)
+	if res.StatusCode != http.StatusTeapot || !errors.Is(got, wrapped) {
+		t.Fatalf("invalid response: %d, %v", res.StatusCode, got)
+	}
+
+	var httpErr *zeroapi.HTTPError
+	if !errors.As(got, &httpErr) || httpErr.Code != http.StatusNotFound || errors.Unwrap(got).Error() != "sql: no rows" {
+		t.Fatalf("invalid error: %v", got)
+	}
+}
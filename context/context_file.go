@@ -14,6 +14,11 @@ import (
 
 // upload 从临时文件夹或者内存中写入到指定位置的文件夹中
 func upload(dest string, header *multipart.FileHeader) (int64, error) {
+	return saveUploadedFile(header, filepath.Join(dest, filepath.Base(header.Filename)))
+}
+
+// saveUploadedFile 将上传文件保存到 dst
+func saveUploadedFile(header *multipart.FileHeader, dst string) (int64, error) {
 	// 打开临时文件或者内存中的文件内容
 	src, err := header.Open()
 	if err != nil {
@@ -21,13 +26,20 @@ func upload(dest string, header *multipart.FileHeader) (int64, error) {
 	}
 	defer src.Close()
 
-	file, err := os.OpenFile(filepath.Join(dest, header.Filename), os.O_WRONLY|os.O_CREATE, os.FileMode(0666))
+	if err := os.MkdirAll(filepath.Dir(dst), os.FileMode(0755)); err != nil {
+		return 0, err
+	}
+
+	file, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0666))
 	if err != nil {
 		return 0, err
 	}
-	defer file.Close()
 
-	return io.Copy(file, src)
+	n, err := io.Copy(file, src)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
 }
 
 func (ctx *context) File(key string) (multipart.File, *multipart.FileHeader, error) {
@@ -70,7 +82,7 @@ func (ctx *context) Files(destDirectory string, cbs ...func(zeroapi.Context, *mu
 
 func (ctx *context) DownloadFile(path string, filename ...string) {
 	if !file.IsExist(path) {
-		http.ServeFile(ctx.res.Writer(), ctx.req, path)
+		http.ServeFile(ctx.res, ctx.req, path)
 		return
 	}
 
@@ -88,5 +100,5 @@ func (ctx *context) DownloadFile(path string, filename ...string) {
 	ctx.AddHeader("Expires", "0")
 	ctx.AddHeader("Cache-Control", "must-revalidate")
 	ctx.AddHeader("Pragma", "public")
-	http.ServeFile(ctx.res.Writer(), ctx.req, path)
+	http.ServeFile(ctx.res, ctx.req, path)
 }
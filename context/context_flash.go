@@ -0,0 +1,141 @@
+package context
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// flashCookieName 保存一次性消息的 cookie 名称
+const flashCookieName = "_flash"
+
+// flashState 本次请求的一次性消息
+type flashState struct {
+	// incoming 之前的请求添加的消息，读取后清空
+	incoming map[string][]string
+
+	// pending 本次请求添加的消息
+	pending map[string][]string
+}
+
+// Flash 添加一条消息，在之后的请求中通过 Flashes 读取
+func (ctx *context) Flash(key, message string) {
+	f := ctx.loadFlash()
+	if f.pending == nil {
+		f.pending = make(map[string][]string)
+	}
+	f.pending[key] = append(f.pending[key], message)
+
+	ctx.saveFlash(f)
+}
+
+// Flashes 读取之前的请求添加的所有消息，读取后删除
+func (ctx *context) Flashes() map[string][]string {
+	f := ctx.loadFlash()
+
+	flashes := f.incoming
+	if flashes == nil {
+		return map[string][]string{}
+	}
+
+	f.incoming = nil
+	ctx.saveFlash(f)
+	return flashes
+}
+
+// loadFlash 第一次使用时从存储中读取之前的请求添加的消息
+func (ctx *context) loadFlash() *flashState {
+	if ctx.flash != nil {
+		return ctx.flash
+	}
+
+	incoming, err := ctx.app.FlashStore().Load(ctx)
+	if err != nil {
+		// 消息被篡改或者已经过期，丢弃
+		incoming = nil
+	}
+	if len(incoming) == 0 {
+		incoming = nil
+	}
+
+	ctx.flash = &flashState{incoming: incoming}
+	return ctx.flash
+}
+
+// saveFlash 保存还没有读取的消息以及本次请求添加的消息
+func (ctx *context) saveFlash(f *flashState) {
+	flashes := make(map[string][]string, len(f.incoming)+len(f.pending))
+	for _, m := range []map[string][]string{f.incoming, f.pending} {
+		for key, messages := range m {
+			flashes[key] = append(flashes[key], messages...)
+		}
+	}
+
+	if err := ctx.app.FlashStore().Save(ctx, flashes); err != nil {
+		ctx.app.Logger().Errorf("save flash failed: %s", err.Error())
+	}
+}
+
+// flashCookieStore 一次性消息保存在签名的 cookie 中
+type flashCookieStore struct {
+	signKey string
+}
+
+// NewFlashCookieStore 一次性消息保存在签名的 cookie 中，cookie 名称为 _flash
+// signKey: 签名密钥，为空时使用随机生成的密钥，只在当前进程中有效，多个实例时需要设置相同的密钥
+func NewFlashCookieStore(signKey string) zeroapi.FlashStore {
+	if signKey == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+		signKey = hex.EncodeToString(b)
+	}
+
+	return &flashCookieStore{signKey: signKey}
+}
+
+func (s *flashCookieStore) Load(ctx zeroapi.Context) (map[string][]string, error) {
+	value, err := ctx.Cookie(flashCookieName, WithCookieVerify(s.signKey))
+	if err == http.ErrNoCookie {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var flashes map[string][]string
+	if err := json.Unmarshal(b, &flashes); err != nil {
+		return nil, err
+	}
+	return flashes, nil
+}
+
+func (s *flashCookieStore) Save(ctx zeroapi.Context, flashes map[string][]string) error {
+	if len(flashes) == 0 {
+		// 请求中没有消息并且本次请求没有设置过时，不需要删除
+		if _, err := ctx.Request().Cookie(flashCookieName); err == http.ErrNoCookie && ctx.ResponseCookie(flashCookieName) == nil {
+			return nil
+		}
+		ctx.RemoveCookie(flashCookieName, WithCookiePath("/"))
+		return nil
+	}
+
+	b, err := json.Marshal(flashes)
+	if err != nil {
+		return err
+	}
+
+	ctx.SetCookie(flashCookieName, base64.RawURLEncoding.EncodeToString(b),
+		WithCookiePath("/"), WithCookieHTTPOnly(true), WithCookieSign(s.signKey))
+	return nil
+}
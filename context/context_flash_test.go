@@ -0,0 +1,119 @@
+package context_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	zctx "github.com/zerogo-hub/zero-api/context"
+)
+
+// flashClient 保存响应中的 cookie，并在之后的请求中发送
+type flashClient struct {
+	a       zeroapi.App
+	cookies map[string]*http.Cookie
+}
+
+func (c *flashClient) do(t *testing.T, method, path string) *http.Response {
+	req := httptest.NewRequest(method, path, nil)
+	for _, cookie := range c.cookies {
+		req.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	c.a.Server().ServeHTTP(w, req)
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(c.cookies, cookie.Name)
+		} else {
+			c.cookies[cookie.Name] = cookie
+		}
+	}
+	return w.Result()
+}
+
+func newFlashApp(opts ...app.Option) *flashClient {
+	a := app.NewApp(opts...)
+	a.Post("/posts", func(ctx zeroapi.Context) {
+		ctx.Flash("success", "saved")
+		ctx.Flash("success", "published")
+		ctx.Redirect(http.StatusSeeOther, "/posts")
+	})
+	a.Get("/posts", func(ctx zeroapi.Context) {
+		flashes := ctx.Flashes()
+		ctx.Text(strings.Join(flashes["success"], ","))
+	})
+	a.Get("/other", func(ctx zeroapi.Context) {
+		ctx.Text("other")
+	})
+	a.Router().Build()
+
+	return &flashClient{a: a, cookies: make(map[string]*http.Cookie)}
+}
+
+func readBody(res *http.Response) string {
+	body, _ := ioutil.ReadAll(res.Body)
+	return string(body)
+}
+
+func TestFlash(t *testing.T) {
+	c := newFlashApp()
+
+	res := c.do(t, http.MethodPost, "/posts")
+	if res.StatusCode != http.StatusSeeOther || c.cookies["_flash"] == nil {
+		t.Fatalf("invalid response: %d, %v", res.StatusCode, c.cookies)
+	}
+	if !c.cookies["_flash"].HttpOnly || c.cookies["_flash"].Path != "/" {
+		t.Fatalf("invalid cookie: %v", c.cookies["_flash"])
+	}
+
+	// 没有读取消息的请求不会删除消息
+	if body := readBody(c.do(t, http.MethodGet, "/other")); body != "other" || c.cookies["_flash"] == nil {
+		t.Fatalf("flash should be kept: %s", body)
+	}
+
+	if body := readBody(c.do(t, http.MethodGet, "/posts")); body != "saved,published" {
+		t.Fatalf("invalid flashes: %s", body)
+	}
+	if c.cookies["_flash"] != nil {
+		t.Fatal("flash cookie should be removed")
+	}
+
+	if body := readBody(c.do(t, http.MethodGet, "/posts")); body != "" {
+		t.Fatalf("flashes should be consumed: %s", body)
+	}
+}
+
+func TestFlashTampered(t *testing.T) {
+	c := newFlashApp(app.WithFlashStore(zctx.NewFlashCookieStore("key")))
+
+	c.do(t, http.MethodPost, "/posts")
+	cookie := c.cookies["_flash"]
+	cookie.Value = "e30" + cookie.Value[strings.Index(cookie.Value, "|"):]
+
+	if body := readBody(c.do(t, http.MethodGet, "/posts")); body != "" {
+		t.Fatalf("tampered flashes should be dropped: %s", body)
+	}
+}
+
+func TestFlashSameRequest(t *testing.T) {
+	a := app.NewApp()
+	w := httptest.NewRecorder()
+	ctx := a.Context()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	ctx.Flash("info", "hello")
+	if flashes := ctx.Flashes(); len(flashes) != 0 {
+		t.Fatalf("flashes added in this request should not be read: %v", flashes)
+	}
+	ctx.Response().PrepareHeader()
+
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Name != "_flash" {
+		t.Fatalf("invalid cookies: %v", cookies)
+	}
+}
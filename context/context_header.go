@@ -1,23 +1,42 @@
 package context
 
+import (
+	"math/rand"
+	"strconv"
+	"time"
+)
+
 func (ctx *context) Header(key string) string {
 	return ctx.req.Header.Get(key)
 }
 
 func (ctx *context) AddHeader(key, value string) {
 	if key != "" && value != "" {
-		ctx.res.Writer().Header().Add(key, value)
+		ctx.res.Header().Add(key, value)
 	}
 }
 
 func (ctx *context) SetHeader(key, value string) {
 	if key != "" && value != "" {
-		ctx.res.Writer().Header().Set(key, value)
+		ctx.res.Header().Set(key, value)
 	}
 }
 
 func (ctx *context) DelHeader(key string) {
 	if key != "" {
-		ctx.res.Writer().Header().Del(key)
+		ctx.res.Header().Del(key)
+	}
+}
+
+func (ctx *context) SetRetryAfter(d time.Duration, jitter ...time.Duration) {
+	if len(jitter) > 0 && jitter[0] > 0 {
+		d += time.Duration(rand.Int63n(int64(jitter[0])))
 	}
+
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	ctx.res.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
 }
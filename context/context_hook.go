@@ -17,7 +17,6 @@ func (ctx *context) RunAfter() {
 }
 
 func (ctx *context) RunEnd() {
-	defer ctx.app.ReleaseContext(ctx)
 	run(ctx.ends)
 }
 
@@ -0,0 +1,87 @@
+package context
+
+import (
+	"net"
+	"strings"
+)
+
+// ClientIP 获取客户端 IP
+// 直接连接的对端是受信任的代理(见 App.SetTrustedProxies)时，才从 Forwarded, X-Forwarded-For, X-Real-IP 中获取
+// 从右向左跳过受信任的代理，第一个不受信任的地址即客户端，客户端无法通过伪造请求头冒充其它 IP
+func (ctx *context) ClientIP() string {
+	peer := remoteIP(ctx.req.RemoteAddr)
+	if !ctx.app.IsTrustedProxy(peer) {
+		return peer
+	}
+
+	var hops []string
+	if forwarded := ctx.req.Header.Values("Forwarded"); len(forwarded) > 0 {
+		hops = parseForwarded(forwarded)
+	} else if xff := ctx.req.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		for _, v := range xff {
+			hops = append(hops, strings.Split(v, ",")...)
+		}
+	} else if realIP := ctx.req.Header.Get("X-Real-IP"); realIP != "" {
+		hops = []string{realIP}
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseHop(hops[i])
+		if ip == "" {
+			// 无法识别的地址，例如 Forwarded: for=unknown，使用最后一个受信任的代理
+			return client
+		}
+
+		client = ip
+		if !ctx.app.IsTrustedProxy(ip) {
+			return ip
+		}
+	}
+
+	// 所有地址都是受信任的代理，使用最左边的地址
+	return client
+}
+
+// remoteIP 去掉 RemoteAddr 中的端口
+func remoteIP(addr string) string {
+	if ip, _, err := net.SplitHostPort(addr); err == nil {
+		return ip
+	}
+	return addr
+}
+
+// parseForwarded 获取 Forwarded(RFC 7239) 中所有的 for 参数
+// 示例: Forwarded: for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"
+func parseForwarded(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			hop := ""
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+					hop = strings.Trim(kv[1], `"`)
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// parseHop 解析代理链中的一个地址，可以包含端口，IPv6 可以使用方括号，无法识别时返回空字符串
+func parseHop(hop string) string {
+	hop = strings.TrimSpace(hop)
+	if ip := net.ParseIP(hop); ip != nil {
+		return ip.String()
+	}
+
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+	if ip := net.ParseIP(strings.Trim(hop, "[]")); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
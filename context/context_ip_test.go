@@ -0,0 +1,67 @@
+package context_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+func TestClientIP(t *testing.T) {
+	a := app.NewApp()
+	if err := a.SetTrustedProxies("10.0.0.0/8", "192.0.2.1", "2001:db8::1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Fatal("expect invalid cidr error")
+	}
+	if !a.IsTrustedProxy("10.1.2.3") || a.IsTrustedProxy("192.0.2.2") || !a.IsTrustedProxy("2001:db8::1") {
+		t.Fatal("invalid trusted proxies")
+	}
+
+	for _, c := range []struct {
+		remote string
+		header map[string]string
+		want   string
+	}{
+		// 不受信任的对端，请求头被忽略
+		{"203.0.113.9:1234", map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Real-IP": "2.2.2.2"}, "203.0.113.9"},
+		{"10.0.0.1:1234", nil, "10.0.0.1"},
+		{"10.0.0.1:1234", map[string]string{"X-Real-IP": "198.51.100.7"}, "198.51.100.7"},
+		// 客户端伪造的地址在最左边，从右向左第一个不受信任的地址是客户端
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.7, 10.0.0.2"}, "198.51.100.7"},
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7:5678"}, "198.51.100.7"},
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "garbage, 10.0.0.2"}, "10.0.0.2"},
+		// Forwarded 优先
+		{"[2001:db8::1]:443", map[string]string{
+			"Forwarded":       `for=1.1.1.1, for="[2001:db8:cafe::17]:4711";proto=https, for=192.0.2.1`,
+			"X-Forwarded-For": "3.3.3.3",
+		}, "2001:db8:cafe::17"},
+		{"192.0.2.1:1234", map[string]string{"Forwarded": "for=unknown"}, "192.0.2.1"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = c.remote
+		for k, v := range c.header {
+			req.Header.Set(k, v)
+		}
+
+		ctx := a.Context()
+		ctx.Reset(httptest.NewRecorder(), req)
+		if ip := ctx.ClientIP(); ip != c.want {
+			t.Fatalf("%s %v: expect %s, got %s", c.remote, c.header, c.want, ip)
+		}
+	}
+
+	// 没有设置受信任的代理时只使用对端地址
+	a = app.NewApp()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+	ctx := a.Context()
+	ctx.Reset(httptest.NewRecorder(), req)
+	if ip := ctx.ClientIP(); ip != "10.0.0.1" {
+		t.Fatalf("invalid client ip: %s", ip)
+	}
+}
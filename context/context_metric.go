@@ -0,0 +1,9 @@
+package context
+
+import (
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+func (ctx *context) Metric(name string) zeroapi.Metric {
+	return ctx.app.Metrics().Counter(name)
+}
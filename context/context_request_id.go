@@ -0,0 +1,9 @@
+package context
+
+import (
+	"github.com/zerogo-hub/zero-api/correlation"
+)
+
+func (ctx *context) RequestID() string {
+	return correlation.RequestID(ctx)
+}
@@ -0,0 +1,41 @@
+package context
+
+import (
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// noRoute 尚未匹配路由，atomic.Value 不能存储 nil
+var noRoute = &zeroapi.RouteInfo{}
+
+// RoutePath 匹配的路由路径，例如 /blog/:id/name
+func (ctx *context) RoutePath() string {
+	return ctx.routeInfo().Path
+}
+
+// RouteName 匹配的路由名称
+func (ctx *context) RouteName() string {
+	return ctx.routeInfo().Name
+}
+
+// Route 匹配的路由，尚未匹配时返回 nil
+func (ctx *context) Route() *zeroapi.RouteInfo {
+	if route := ctx.routeInfo(); route != noRoute {
+		return route
+	}
+	return nil
+}
+
+// SetRoute 设置匹配的路由，route 在所有请求之间共享，不能修改
+func (ctx *context) SetRoute(route *zeroapi.RouteInfo) {
+	if route == nil {
+		route = noRoute
+	}
+	ctx.route.Store(route)
+}
+
+func (ctx *context) routeInfo() *zeroapi.RouteInfo {
+	if route, ok := ctx.route.Load().(*zeroapi.RouteInfo); ok {
+		return route
+	}
+	return noRoute
+}
@@ -0,0 +1,52 @@
+package context_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+func TestRoutePath(t *testing.T) {
+	a := app.NewApp()
+
+	var path, name string
+	a.Use(func(ctx zeroapi.Context) {
+		path, name = ctx.RoutePath(), ctx.RouteName()
+	})
+	a.NotFound(func(ctx zeroapi.Context) {
+		path, name = ctx.RoutePath(), ctx.RouteName()
+		if ctx.Route() != nil {
+			t.Fatal("expect no route")
+		}
+		ctx.NotFound()
+	})
+
+	text := func(ctx zeroapi.Context) { ctx.Text("ok") }
+	a.Handle(http.MethodGet, "/blog/:id/name", zeroapi.RouteMiddleware{Name: "blog.name", Meta: map[string]string{"owner": "cms"}}, func(ctx zeroapi.Context) {
+		if route := ctx.Route(); route == nil || route.Meta["owner"] != "cms" {
+			t.Fatalf("invalid route: %+v", route)
+		}
+		ctx.Text("ok")
+	})
+	a.Group("/api").Get("/users/:id", text)
+	a.Router().Build()
+
+	tests := []struct {
+		url, path, name string
+	}{
+		{"/blog/1001/name", "/blog/:id/name", "blog.name"},
+		{"/api/users/1", "/api/users/:id", ""},
+		{"/missing", "", ""},
+	}
+	for _, test := range tests {
+		path, name = "-", "-"
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.url, nil))
+		if path != test.path || name != test.name {
+			t.Fatalf("%s: invalid route: %q, %q", test.url, path, name)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package context
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// defaultSSEHeartbeat 默认心跳间隔
+const defaultSSEHeartbeat = 15 * time.Second
+
+// errEventStreamClosed 数据流已关闭
+var errEventStreamClosed = errors.New("event stream closed")
+
+func (ctx *context) SSE(heartbeat ...time.Duration) (zeroapi.EventStream, error) {
+	if _, ok := ctx.res.Writer().(http.Flusher); !ok {
+		return nil, http.ErrNotSupported
+	}
+
+	w := ctx.res
+	flusher := w.(http.Flusher)
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream;charset=utf-8")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// 关闭 nginx 的缓冲
+	header.Set("X-Accel-Buffering", "no")
+
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	interval := defaultSSEHeartbeat
+	if len(heartbeat) > 0 {
+		interval = heartbeat[0]
+	}
+
+	s := &eventStream{
+		codec:   ctx.app.JSONCodec(),
+		w:       w,
+		flusher: flusher,
+		done:    make(chan struct{}),
+	}
+
+	// 心跳在其它 goroutine 中执行，不能读写 ctx，响应结束前关闭数据流，之后再记录写入的大小
+	w.BeforeFinish(func() {
+		s.Close()
+		ctx.responseSize += s.written()
+	})
+
+	go s.watch(interval, ctx.req.Context().Done())
+
+	return s, nil
+}
+
+type eventStream struct {
+	codec   zeroapi.JSONCodec
+	w       http.ResponseWriter
+	flusher http.Flusher
+
+	mu     sync.Mutex
+	closed bool
+
+	// size 已写入的大小，响应结束时计入 ctx.Size()
+	size int64
+
+	// done 客户端断开或者 Close 后关闭
+	done chan struct{}
+}
+
+// watch 发送心跳，检测客户端断开
+func (s *eventStream) watch(interval time.Duration, clientGone <-chan struct{}) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-clientGone:
+			s.Close()
+			return
+		case <-tick:
+			// 以 ':' 开头的行为注释，客户端会忽略
+			if err := s.write(": ping\n\n"); err != nil {
+				s.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *eventStream) Send(event string, data interface{}) error {
+	return s.SendWithID("", event, data)
+}
+
+func (s *eventStream) SendWithID(id, event string, data interface{}) error {
+	var payload string
+	switch v := data.(type) {
+	case string:
+		payload = v
+	case []byte:
+		payload = string(v)
+	default:
+		b, err := s.codec.Marshal(data)
+		if err != nil {
+			return err
+		}
+		payload = string(b)
+	}
+
+	buf := strings.Builder{}
+	if id != "" {
+		buf.WriteString("id: ")
+		buf.WriteString(singleLine(id))
+		buf.WriteString("\n")
+	}
+	if event != "" {
+		buf.WriteString("event: ")
+		buf.WriteString(singleLine(event))
+		buf.WriteString("\n")
+	}
+
+	// 多行数据，每一行都需要 data: 前缀
+	for _, line := range strings.Split(strings.Replace(payload, "\r\n", "\n", -1), "\n") {
+		buf.WriteString("data: ")
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+
+	return s.write(buf.String())
+}
+
+func (s *eventStream) Retry(retry time.Duration) error {
+	return s.write("retry: " + strconv.FormatInt(int64(retry/time.Millisecond), 10) + "\n\n")
+}
+
+func (s *eventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *eventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+}
+
+// write 写入并立即推送
+func (s *eventStream) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errEventStreamClosed
+	}
+
+	n, err := s.w.Write([]byte(data))
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+
+	return nil
+}
+
+// written 已写入的大小
+func (s *eventStream) written() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size
+}
+
+// singleLine id 与 event 中不能包含换行符
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,83 @@
+package context_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+func TestSSE(t *testing.T) {
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+
+	ctx := app.NewApp().Context()
+	ctx.Reset(res, req)
+
+	stream, err := ctx.SSE(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream.SendWithID("1", "greeting", "hello\nworld")
+	stream.Send("", map[string]int{"count": 1})
+	stream.Close()
+
+	if err := stream.Send("", "closed"); err == nil {
+		t.Fatal("send after close should fail")
+	}
+
+	expected := "id: 1\nevent: greeting\ndata: hello\ndata: world\n\ndata: {\"count\":1}\n\n"
+	if res.Body.String() != expected {
+		t.Fatalf("invalid body: %q", res.Body.String())
+	}
+
+	if res.Header().Get("Content-Type") != "text/event-stream;charset=utf-8" {
+		t.Fatal("invalid content type")
+	}
+
+	// 响应结束时计入写入的大小
+	ctx.Response().Finish()
+	if ctx.Size() != int64(len(expected)) {
+		t.Fatalf("invalid size: %d", ctx.Size())
+	}
+}
+
+func TestSSEHeartbeat(t *testing.T) {
+	a := app.NewApp()
+
+	var stream zeroapi.EventStream
+	a.Get("/events", func(ctx zeroapi.Context) {
+		var err error
+		if stream, err = ctx.SSE(time.Millisecond); err != nil {
+			t.Error(err)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	})
+	a.Router().Build()
+
+	res := httptest.NewRecorder()
+	a.Server().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	// 请求结束后心跳停止，不会再写入响应
+	body := res.Body.String()
+	if !strings.Contains(body, ": ping\n\n") {
+		t.Fatalf("heartbeat not sent: %q", body)
+	}
+
+	select {
+	case <-stream.Done():
+	default:
+		t.Fatal("stream should be closed when the request ends")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if res.Body.String() != body {
+		t.Fatal("heartbeat written after the request ended")
+	}
+}
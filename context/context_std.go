@@ -0,0 +1,24 @@
+package context
+
+import (
+	stdcontext "context"
+	"time"
+)
+
+func (ctx *context) Context() stdcontext.Context {
+	return ctx.req.Context()
+}
+
+func (ctx *context) SetContext(c stdcontext.Context) {
+	if c != nil {
+		ctx.req = ctx.req.WithContext(c)
+	}
+}
+
+func (ctx *context) WithValue(key, value interface{}) {
+	ctx.SetContext(stdcontext.WithValue(ctx.req.Context(), key, value))
+}
+
+func (ctx *context) Deadline() (time.Time, bool) {
+	return ctx.req.Context().Deadline()
+}
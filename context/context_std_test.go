@@ -0,0 +1,39 @@
+package context_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+type ctxKey struct{}
+
+func TestStdContext(t *testing.T) {
+	ctx := app.NewApp().Context()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("unexpected deadline")
+	}
+
+	ctx.WithValue(ctxKey{}, "tenant")
+	if ctx.Context().Value(ctxKey{}) != "tenant" || ctx.Request().Context().Value(ctxKey{}) != "tenant" {
+		t.Fatal("value not found in context")
+	}
+
+	c, cancel := context.WithTimeout(ctx.Context(), time.Minute)
+	ctx.SetContext(c)
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Fatalf("invalid deadline: %v", deadline)
+	}
+
+	// 替换后仍然可以获取之前添加的值
+	cancel()
+	if ctx.Context().Err() != context.Canceled || ctx.Context().Value(ctxKey{}) != "tenant" {
+		t.Fatal("context not replaced")
+	}
+}
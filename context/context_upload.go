@@ -0,0 +1,140 @@
+package context
+
+import (
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// maxStreamFieldSize StreamFiles 中普通字段的最大长度
+const maxStreamFieldSize = 1 << 20
+
+func (ctx *context) FormFile(name string) (*multipart.FileHeader, error) {
+	files, err := ctx.FormFiles(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return files[0], nil
+}
+
+func (ctx *context) FormFiles(name string) ([]*multipart.FileHeader, error) {
+	if err := ctx.parseMultipartForm(); err != nil {
+		return nil, err
+	}
+
+	if ctx.req.MultipartForm != nil {
+		if files := ctx.req.MultipartForm.File[name]; len(files) > 0 {
+			return files, nil
+		}
+	}
+
+	return nil, http.ErrMissingFile
+}
+
+func (ctx *context) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	_, err := saveUploadedFile(fh, dst)
+	return err
+}
+
+func (ctx *context) StreamFiles(fn func(part *multipart.Part) error) error {
+	reader, err := ctx.req.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	if ctx.req.PostForm == nil {
+		ctx.req.PostForm = make(url.Values)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return ctx.bodyError(err)
+		}
+
+		if part.FileName() == "" {
+			value, err := ioutil.ReadAll(io.LimitReader(part, maxStreamFieldSize))
+			part.Close()
+			if err != nil {
+				return ctx.bodyError(err)
+			}
+			ctx.req.PostForm.Add(part.FormName(), string(value))
+			continue
+		}
+
+		err = fn(part)
+		part.Close()
+		if err != nil {
+			return ctx.bodyError(err)
+		}
+	}
+}
+
+func (ctx *context) SetMaxBodySize(n int64) {
+	if n <= 0 || ctx.req.Body == nil || ctx.req.Body == http.NoBody {
+		return
+	}
+
+	body := ctx.req.Body
+	if r, ok := body.(*maxBytesReader); ok && r.read == 0 {
+		// 还没有读取时替换之前的限制
+		body = r.body
+	}
+
+	ctx.req.Body = &maxBytesReader{
+		ReadCloser: http.MaxBytesReader(ctx.res, body, n),
+		body:       body,
+		limit:      n,
+	}
+}
+
+// parseMultipartForm 解析 multipart/form-data，超过 App.FileMaxMemory 的部分保存在临时文件中
+func (ctx *context) parseMultipartForm() error {
+	return ctx.bodyError(ctx.req.ParseMultipartForm(ctx.app.FileMaxMemory()))
+}
+
+// bodyError 请求体超过 SetMaxBodySize 的限制时，返回 ErrBodyTooLarge
+func (ctx *context) bodyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if r, ok := ctx.req.Body.(*maxBytesReader); ok && r.exceeded {
+		return zeroapi.ErrBodyTooLarge
+	}
+
+	return err
+}
+
+// maxBytesReader 包装 http.MaxBytesReader，超过限制时返回 ErrBodyTooLarge
+// http.MaxBytesReader 超过限制时还会通知 net/http 关闭连接
+type maxBytesReader struct {
+	io.ReadCloser
+
+	// body 限制之前的请求体
+	body io.ReadCloser
+
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+
+	if err != nil && err != io.EOF && r.read >= r.limit {
+		r.exceeded = true
+		return n, zeroapi.ErrBodyTooLarge
+	}
+
+	return n, err
+}
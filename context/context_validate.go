@@ -0,0 +1,13 @@
+package context
+
+import (
+	"github.com/zerogo-hub/zero-api/validator"
+)
+
+func (ctx *context) BindAndValidate(dst interface{}) error {
+	if err := ctx.Bind(dst); err != nil {
+		return err
+	}
+
+	return validator.Validate(dst, ctx.app.Validator)
+}
@@ -0,0 +1,48 @@
+package context
+
+import (
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// headerWarning 警告响应头，每条警告一个值，格式为 "code: message"
+const headerWarning = "X-Warning"
+
+// warningReplacer 响应头中不能出现换行
+var warningReplacer = strings.NewReplacer("\r", " ", "\n", " ")
+
+// AddWarning 添加一条警告
+func (ctx *context) AddWarning(code, message string) {
+	ctx.warnings = append(ctx.warnings, zeroapi.Warning{Code: code, Message: message})
+}
+
+// Warnings 本次请求添加的所有警告
+func (ctx *context) Warnings() []zeroapi.Warning {
+	return ctx.warnings
+}
+
+// writeWarnings 写入响应头之前，将警告写入 X-Warning，之后添加的警告只出现在日志中
+func (ctx *context) writeWarnings() {
+	if len(ctx.warnings) == 0 {
+		return
+	}
+
+	header := ctx.res.Header()
+	for _, w := range ctx.warnings {
+		header.Add(headerWarning, warningReplacer.Replace(w.Code+": "+w.Message))
+	}
+}
+
+// logWarnings 响应结束时记录所有警告
+func (ctx *context) logWarnings() {
+	if len(ctx.warnings) == 0 {
+		return
+	}
+
+	items := make([]string, 0, len(ctx.warnings))
+	for _, w := range ctx.warnings {
+		items = append(items, w.Code+": "+w.Message)
+	}
+	ctx.app.Logger().Infof("%s %s warnings: %s", ctx.Method(), ctx.Path(), strings.Join(items, "; "))
+}
@@ -0,0 +1,62 @@
+package context_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+func TestWarnings(t *testing.T) {
+	a := app.NewApp()
+	a.Use(func(ctx zeroapi.Context) {
+		if ctx.Query("page_size") != "" {
+			ctx.AddWarning("deprecated_param", "page_size is deprecated,\r\nuse limit")
+		}
+	})
+	a.Get("/users", func(ctx zeroapi.Context) {
+		ctx.AddWarning("cache_unavailable", "served from database")
+		ctx.Text("ok")
+		// 写入响应头之后添加的警告只记录日志
+		ctx.AddWarning("late", "after header")
+	})
+	a.Get("/orders", func(ctx zeroapi.Context) {
+		ctx.Error(zeroapi.NewHTTPError(http.StatusNotFound))
+	})
+	a.Router().Build()
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?page_size=10", nil))
+
+	warnings := w.Result().Header.Values("X-Warning")
+	if len(warnings) != 2 || warnings[0] != "deprecated_param: page_size is deprecated,  use limit" || warnings[1] != "cache_unavailable: served from database" {
+		t.Fatalf("invalid warnings: %q", warnings)
+	}
+	if w.Body.String() != "ok" {
+		t.Fatalf("invalid body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders?page_size=10", nil))
+
+	var body struct {
+		Code     string            `json:"code"`
+		Warnings []zeroapi.Warning `json:"warnings"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusNotFound || len(body.Warnings) != 1 || body.Warnings[0].Code != "deprecated_param" {
+		t.Fatalf("invalid response: %d, %+v", w.Code, body)
+	}
+
+	// 没有警告时不写入
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	if w.Result().Header.Get("X-Warning") != "" || w.Body.String() != `{"code":"404","message":"Not Found"}` {
+		t.Fatalf("invalid response: %v, %s", w.Result().Header, w.Body.String())
+	}
+}
@@ -0,0 +1,16 @@
+package context
+
+import (
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/websocket"
+)
+
+func (ctx *context) Upgrade() (zeroapi.WebSocket, error) {
+	conn, err := websocket.Upgrade(ctx.res, ctx.req)
+	if err != nil {
+		ctx.Stopped()
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -1,8 +1,6 @@
 package context
 
 import (
-	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"html/template"
@@ -11,6 +9,8 @@ import (
 	"strconv"
 	"strings"
 
+	zeroapi "github.com/zerogo-hub/zero-api"
+
 	"github.com/zerogo-hub/zero-helper/bytes"
 	"google.golang.org/protobuf/proto"
 )
@@ -19,7 +19,7 @@ func (ctx *context) Bytes(bytes []byte) (int, error) {
 	var size int
 	var err error
 
-	size, err = ctx.res.Writer().Write(bytes)
+	size, err = ctx.res.Write(bytes)
 
 	if err != nil {
 		return 0, err
@@ -34,7 +34,7 @@ func (ctx *context) Text(value string) (int, error) {
 	var size int
 	var err error
 
-	size, err = ctx.res.Writer().Write(bytes.StringToBytes(value))
+	size, err = ctx.res.Write(bytes.StringToBytes(value))
 
 	if err != nil {
 		return 0, err
@@ -51,7 +51,7 @@ func (ctx *context) Textf(format string, a ...interface{}) {
 }
 
 func (ctx *context) Map(obj interface{}) (int, error) {
-	bytes, err := json.Marshal(obj)
+	bytes, err := ctx.app.JSONCodec().Marshal(obj)
 	if err != nil {
 		return 0, err
 	}
@@ -60,25 +60,144 @@ func (ctx *context) Map(obj interface{}) (int, error) {
 }
 
 func (ctx *context) JSON(obj interface{}) (int, error) {
-	bytes, err := json.Marshal(obj)
+	ctx.SetHeader("Content-Type", "application/json;charset=utf-8")
+
+	return ctx.encodeJSON(obj, "")
+}
+
+func (ctx *context) JSONWithCode(httpCode int, obj interface{}) (int, error) {
+	ctx.SetHeader("Content-Type", "application/json;charset=utf-8")
+	ctx.SetHTTPCode(httpCode)
+
+	return ctx.encodeJSON(obj, "")
+}
+
+func (ctx *context) JSONPretty(obj interface{}, indent ...string) (int, error) {
+	ctx.SetHeader("Content-Type", "application/json;charset=utf-8")
+
+	if len(indent) > 0 && indent[0] != "" {
+		return ctx.encodeJSON(obj, indent[0])
+	}
+
+	return ctx.encodeJSON(obj, "  ")
+}
+
+func (ctx *context) JSONP(callback string, obj interface{}) (int, error) {
+	if !isValidCallback(callback) {
+		return 0, zeroapi.ErrInvalidCallback
+	}
+
+	ctx.SetHeader("Content-Type", "application/javascript;charset=utf-8")
+	ctx.SetHeader("X-Content-Type-Options", "nosniff")
+
+	// /**/ 用于防止 Rosetta Flash 攻击
+	start, err := ctx.Text("/**/ typeof " + callback + " === 'function' && " + callback + "(")
 	if err != nil {
-		return 0, err
+		return start, err
 	}
 
-	ctx.SetHeader("Content-Type", "application/json;charset=utf-8")
+	n, err := ctx.encodeJSON(obj, "")
+	if err != nil {
+		return start + n, err
+	}
 
-	return ctx.Bytes(bytes)
+	end, err := ctx.Text(");")
+	return start + n + end, err
 }
 
-func (ctx *context) XML(obj interface{}) (int, error) {
-	bytes, err := xml.Marshal(obj)
+// encodeJSON 使用流式编码器直接写入响应，避免中间缓冲
+// 编码器会在末尾添加换行，与 json.Marshal 保持一致，不写入该换行
+func (ctx *context) encodeJSON(obj interface{}, indent string) (int, error) {
+	w := &sizeWriter{ctx: ctx}
+
+	encoder := ctx.app.JSONCodec().NewEncoder(w)
+	if indent != "" {
+		encoder.SetIndent("", indent)
+	}
+
+	err := encoder.Encode(obj)
+	return w.size, err
+}
+
+// sizeWriter 通过 ctx.Bytes 写入，记录写入的字节数
+// 末尾的换行先保留，之后还有写入时才写出，所以最后一个换行不会写入响应
+type sizeWriter struct {
+	ctx     *context
+	size    int
+	newline bool
+}
+
+func (w *sizeWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if w.newline {
+		n, err := w.ctx.Bytes([]byte{'\n'})
+		w.size += n
+		if err != nil {
+			return 0, err
+		}
+		w.newline = false
+	}
+
+	data := p
+	if p[len(p)-1] == '\n' {
+		data = p[:len(p)-1]
+		w.newline = true
+	}
+	if len(data) == 0 {
+		return len(p), nil
+	}
+
+	n, err := w.ctx.Bytes(data)
+	w.size += n
 	if err != nil {
-		return 0, err
+		return n, err
+	}
+	return len(p), nil
+}
+
+// isValidCallback JSONP 回调函数名称只能包含字母、数字、_、$ 以及 .
+func isValidCallback(callback string) bool {
+	if callback == "" || len(callback) > 128 {
+		return false
 	}
 
-	ctx.SetHeader("Content-Type", "application/xml;charset=utf-8")
+	for _, c := range callback {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '$', c == '.':
+		default:
+			return false
+		}
+	}
 
-	return ctx.Bytes(bytes)
+	return true
+}
+
+func (ctx *context) XML(obj interface{}) (int, error) {
+	return ctx.Serialize(zeroapi.SerializerXML, obj)
+}
+
+func (ctx *context) YAML(obj interface{}) (int, error) {
+	return ctx.Serialize(zeroapi.SerializerYAML, obj)
+}
+
+func (ctx *context) MsgPack(obj interface{}) (int, error) {
+	return ctx.Serialize(zeroapi.SerializerMsgPack, obj)
+}
+
+func (ctx *context) Serialize(name string, obj interface{}) (int, error) {
+	serializer := ctx.app.Serializer(name)
+	if serializer == nil {
+		return 0, zeroapi.ErrSerializerNotFound
+	}
+
+	ctx.SetHeader("Content-Type", serializer.ContentType())
+
+	w := &sizeWriter{ctx: ctx}
+	err := serializer.Encode(w, obj)
+	return w.size, err
 }
 
 func (ctx *context) HTML(html string) (int, error) {
@@ -112,25 +231,40 @@ func (ctx *context) Redirect(httpCode int, url string) error {
 	}
 
 	ctx.Stopped()
-	http.Redirect(ctx.res.Writer(), ctx.req, url, httpCode)
+	http.Redirect(ctx.res, ctx.req, url, httpCode)
 
 	return nil
 }
 
 func (ctx *context) Flush() {
-	if flusher, ok := ctx.res.Writer().(http.Flusher); ok {
+	if flusher, ok := ctx.res.(http.Flusher); ok {
 		flusher.Flush()
 	}
 }
 
 func (ctx *context) Push(value string, opts *http.PushOptions) error {
-	if push, ok := ctx.res.Writer().(http.Pusher); ok {
-		push.Push(value, opts)
+	if push, ok := ctx.res.(http.Pusher); ok {
+		return push.Push(value, opts)
 	}
 
 	return http.ErrNotSupported
 }
 
+func (ctx *context) PushAll(targets ...string) error {
+	push, ok := ctx.res.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+
+	for _, target := range targets {
+		if err := push.Push(target, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (ctx *context) AutoContentType(fileExt string) {
 	if !strings.HasPrefix(fileExt, ".") {
 		fileExt = "." + fileExt
@@ -142,12 +276,15 @@ func (ctx *context) AutoContentType(fileExt string) {
 }
 
 func (ctx *context) Message(code int, message ...string) (int, error) {
-	result := make(map[string]string)
+	result := make(map[string]interface{})
 
 	result["code"] = strconv.Itoa(code)
 	if len(message) > 0 {
 		result["message"] = message[0]
 	}
+	if len(ctx.warnings) > 0 {
+		result["warnings"] = ctx.warnings
+	}
 
 	return ctx.Map(result)
 }
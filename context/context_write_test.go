@@ -0,0 +1,119 @@
+package context_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+func TestJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := newTestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	n, err := ctx.JSONWithCode(http.StatusCreated, map[string]int{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusCreated || w.Body.String() != "{\"id\":1}" || n != w.Body.Len() {
+		t.Fatalf("invalid response: %d %q %d", w.Code, w.Body.String(), n)
+	}
+	if ct := w.Result().Header.Get("Content-Type"); ct != "application/json;charset=utf-8" {
+		t.Fatalf("invalid content type: %s", ct)
+	}
+	if ctx.Size() != int64(n) {
+		t.Fatalf("invalid size: %d", ctx.Size())
+	}
+}
+
+func TestJSONPretty(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := newTestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if _, err := ctx.JSONPretty(map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Body.String() != "{\n  \"id\": 1\n}" {
+		t.Fatalf("invalid response: %q", w.Body.String())
+	}
+}
+
+func TestJSONP(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := newTestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if _, err := ctx.JSONP("app.cb", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Body.String() != "/**/ typeof app.cb === 'function' && app.cb(1);" {
+		t.Fatalf("invalid response: %q", w.Body.String())
+	}
+
+	if _, err := ctx.JSONP("alert(1)//", 1); err != zeroapi.ErrInvalidCallback {
+		t.Fatalf("want ErrInvalidCallback, got: %v", err)
+	}
+}
+
+func TestLateWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := newTestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	ctx.Text("ok")
+	ctx.Response().Finish()
+
+	if _, err := ctx.Text("late"); err != zeroapi.ErrResponseFinished {
+		t.Fatalf("want ErrResponseFinished, got: %v", err)
+	}
+
+	ctx.SetHeader("X-Late", "1")
+	if w.Header().Get("X-Late") != "" || w.Body.String() != "ok" {
+		t.Fatal("late write should not reach the response")
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := newTestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	n, err := ctx.MsgPack(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ct := w.Result().Header.Get("Content-Type"); ct != "application/msgpack" {
+		t.Fatalf("invalid content type: %s", ct)
+	}
+	if n != 4 || w.Body.Len() != 4 {
+		t.Fatalf("invalid size: %d", n)
+	}
+
+	if _, err := ctx.Serialize("toml", 1); err != zeroapi.ErrSerializerNotFound {
+		t.Fatalf("want ErrSerializerNotFound, got: %v", err)
+	}
+}
+
+func TestSuperfluousWriteHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := newTestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if ctx.Response().Written() || ctx.Response().Status() != 0 {
+		t.Fatal("nothing should be written yet")
+	}
+
+	ctx.SetHTTPCode(http.StatusAccepted)
+	ctx.SetHTTPCode(http.StatusInternalServerError)
+
+	if w.Code != http.StatusAccepted || ctx.Response().Status() != http.StatusAccepted {
+		t.Fatalf("second WriteHeader should be ignored: %d", w.Code)
+	}
+}
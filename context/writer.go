@@ -1,14 +1,44 @@
 package context
 
 import (
+	"bufio"
+	"net"
 	"net/http"
-	"sync"
+	"runtime"
+	"strings"
+	"sync/atomic"
 
 	zeroapi "github.com/zerogo-hub/zero-api"
+
+	"github.com/zerogo-hub/zero-helper/logger"
 )
 
 type writer struct {
 	http.ResponseWriter
+
+	// wroteHeader 是否已经写入响应头
+	wroteHeader bool
+
+	// befores 写入响应头之前执行的函数
+	befores []func()
+
+	// finishers 响应结束之前执行的函数
+	finishers []func()
+
+	// finished 响应是否已经结束，可能在其它 goroutine 中读取
+	finished int32
+
+	// status 已写入的状态码
+	status int
+
+	// size 已写入的响应内容大小
+	size int64
+
+	// firstPCs 第一次写入响应头时的调用栈，只在 debug 日志级别下记录，用于诊断重复写入
+	firstPCs []uintptr
+
+	// logger 记录响应结束后的写入，以及重复写入响应头
+	logger logger.Logger
 }
 
 func (w *writer) Writer() http.ResponseWriter {
@@ -19,21 +49,204 @@ func (w *writer) SetWriter(sw http.ResponseWriter) {
 	w.ResponseWriter = sw
 }
 
-var writerPool *sync.Pool
+func (w *writer) BeforeWriteHeader(fn func()) {
+	if fn != nil {
+		w.befores = append(w.befores, fn)
+	}
+}
+
+func (w *writer) BeforeFinish(fn func()) {
+	if fn != nil {
+		w.finishers = append(w.finishers, fn)
+	}
+}
+
+func (w *writer) Written() bool {
+	return w.wroteHeader
+}
+
+func (w *writer) Status() int {
+	return w.status
+}
+
+func (w *writer) Size() int64 {
+	return w.size
+}
+
+func (w *writer) PrepareHeader() {
+	if w.wroteHeader {
+		return
+	}
+
+	befores := w.befores
+	w.befores = nil
+	for _, fn := range befores {
+		fn()
+	}
+}
+
+func (w *writer) Finish() {
+	if w.Finished() {
+		return
+	}
+
+	// 从尾巴开始执行
+	finishers := w.finishers
+	w.finishers = nil
+	for i := len(finishers) - 1; i >= 0; i-- {
+		finishers[i]()
+	}
+
+	atomic.StoreInt32(&w.finished, 1)
+}
+
+func (w *writer) Finished() bool {
+	return atomic.LoadInt32(&w.finished) == 1
+}
+
+func (w *writer) Header() http.Header {
+	if w.Finished() {
+		// 响应结束后 net/http 可能正在复用该响应头，返回一个不会被发送的响应头
+		w.lateWrite("Header")
+		return http.Header{}
+	}
+	return w.ResponseWriter.Header()
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.Finished() {
+		w.lateWrite("WriteHeader")
+		return
+	}
+
+	if w.wroteHeader {
+		w.superfluousWriteHeader(code)
+		return
+	}
+
+	w.PrepareHeader()
+	w.markWritten(code)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if w.Finished() {
+		w.lateWrite("Write")
+		return 0, zeroapi.ErrResponseFinished
+	}
+
+	if !w.wroteHeader {
+		w.PrepareHeader()
+		w.markWritten(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *writer) Flush() {
+	if w.Finished() {
+		w.lateWrite("Flush")
+		return
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.PrepareHeader()
+			w.markWritten(http.StatusOK)
+		}
+		flusher.Flush()
+	}
+}
+
+func (w *writer) Push(target string, opts *http.PushOptions) error {
+	if w.Finished() {
+		return zeroapi.ErrResponseFinished
+	}
+
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.Finished() {
+		return nil, nil, zeroapi.ErrResponseFinished
+	}
+
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.wroteHeader = true
+		w.befores = nil
+	}
+	return conn, rw, err
+}
+
+// markWritten 记录响应头已写入，debug 日志级别下记录调用栈
+func (w *writer) markWritten(code int) {
+	w.wroteHeader = true
+	w.status = code
+
+	if w.logger != nil && w.logger.IsDebugAble() {
+		pcs := make([]uintptr, 16)
+		n := runtime.Callers(3, pcs)
+		w.firstPCs = pcs[:n]
+	}
+}
+
+// superfluousWriteHeader 记录重复写入响应头，替代 net/http 中没有调用位置的 "superfluous WriteHeader call" 日志
+func (w *writer) superfluousWriteHeader(code int) {
+	if w.logger == nil {
+		return
+	}
+
+	fn, file, line := caller()
+	if len(w.firstPCs) == 0 {
+		w.logger.Errorf("superfluous WriteHeader(%d), status %d already written, called from %s (%s:%d)",
+			code, w.status, fn, file, line)
+		return
+	}
+
+	firstFn, firstFile, firstLine := frameOutside(runtime.CallersFrames(w.firstPCs))
+	w.logger.Errorf("superfluous WriteHeader(%d), status %d already written by %s (%s:%d), called from %s (%s:%d)",
+		code, w.status, firstFn, firstFile, firstLine, fn, file, line)
+}
+
+// lateWrite 记录响应结束后的写入，以及发起写入的函数
+func (w *writer) lateWrite(op string) {
+	if w.logger == nil {
+		return
+	}
 
-// acquireWriter 从池中获取 Writer
-func acquireWriter() zeroapi.Writer {
-	return writerPool.Get().(*writer)
+	fn, file, line := caller()
+	w.logger.Errorf("%s after response finished, called from %s (%s:%d)", op, fn, file, line)
 }
 
-// releaseWriter 将 writer 放入池中
-func releaseWriter(w zeroapi.Writer) {
-	writerPool.Put(w)
+// caller 查找框架之外的第一个调用者
+func caller() (string, string, int) {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	return frameOutside(runtime.CallersFrames(pcs[:n]))
 }
 
-func init() {
-	writerPool = &sync.Pool{}
-	writerPool.New = func() interface{} {
-		return &writer{}
+// frameOutside 查找框架之外的第一个调用者
+func frameOutside(frames *runtime.Frames) (string, string, int) {
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/zerogo-hub/zero-api/context.") &&
+			!strings.HasPrefix(frame.Function, "net/http.") &&
+			!strings.HasPrefix(frame.Function, "encoding/") &&
+			!strings.HasPrefix(frame.Function, "fmt.") {
+			return frame.Function, frame.File, frame.Line
+		}
+		if !more {
+			return frame.Function, frame.File, frame.Line
+		}
 	}
 }
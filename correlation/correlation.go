@@ -0,0 +1,100 @@
+// Package correlation 在多级代理，上下游调用之间传递请求 ID 与链路追踪信息，使各级日志可以关联起来
+//
+// 支持的请求头:
+// X-Request-ID: 请求 ID
+// traceparent, tracestate: W3C Trace Context，见 https://www.w3.org/TR/trace-context/
+package correlation
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// HeaderRequestID 请求 ID
+	HeaderRequestID = "X-Request-ID"
+
+	// HeaderTraceParent W3C Trace Context
+	HeaderTraceParent = "traceparent"
+
+	// HeaderTraceState W3C Trace Context
+	HeaderTraceState = "tracestate"
+
+	// ValueKeyRequestID 请求 ID 在 ctx.Value 中的键
+	ValueKeyRequestID = "zeroapi.correlation.request_id"
+)
+
+// headers 需要向下游传递的请求头
+var headers = []string{HeaderRequestID, HeaderTraceParent, HeaderTraceState}
+
+// RequestID 获取当前请求的 ID，优先使用 ctx.Value 中的值，其次使用请求头中的值
+func RequestID(ctx zeroapi.Context) string {
+	if id, ok := ctx.Value(ValueKeyRequestID).(string); ok && id != "" {
+		return id
+	}
+
+	return ctx.Header(HeaderRequestID)
+}
+
+// TraceID 从 traceparent 请求头中获取链路 ID，sampled 表示上游是否采样了该链路
+// traceparent 不存在或者格式不正确时返回空字符串
+// 格式: {version}-{trace-id}-{parent-id}-{trace-flags}，例如 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func TraceID(ctx zeroapi.Context) (traceID string, sampled bool) {
+	value := ctx.Header(HeaderTraceParent)
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return "", false
+	}
+
+	traceID = value[3:35]
+	if !isHex(value[:2]) || value[:2] == "ff" || !isHex(traceID) || !isHex(value[36:52]) || !isHex(value[53:55]) {
+		return "", false
+	}
+
+	// 全部为 0 的链路 ID 无效
+	if strings.Trim(traceID, "0") == "" {
+		return "", false
+	}
+
+	flags, _ := strconv.ParseUint(value[53:55], 16, 8)
+	return traceID, flags&0x01 == 0x01
+}
+
+// isHex 是否全部为小写的十六进制字符
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Inject 将当前请求的关联信息写入发往下游的请求 req 中，req 中已存在的值不会被覆盖
+func Inject(ctx zeroapi.Context, req *http.Request) {
+	for key, value := range Headers(ctx) {
+		if req.Header.Get(key) == "" {
+			req.Header[key] = value
+		}
+	}
+}
+
+// Headers 获取当前请求需要向下游传递的关联信息
+func Headers(ctx zeroapi.Context) http.Header {
+	out := make(http.Header, len(headers))
+
+	if id := RequestID(ctx); id != "" {
+		out.Set(HeaderRequestID, id)
+	}
+
+	for _, key := range headers[1:] {
+		if value := ctx.Header(key); value != "" {
+			out.Set(key, value)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,93 @@
+package correlation_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/correlation"
+)
+
+// run 使用 headers 发起请求，在处理函数中执行 fn
+func run(t *testing.T, headers map[string]string, fn func(ctx zeroapi.Context)) {
+	a := app.NewApp()
+	a.Get("/", fn)
+	a.Router().Build()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	a.Server().ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestRequestID(t *testing.T) {
+	var fromHeader, fromValue string
+	run(t, map[string]string{correlation.HeaderRequestID: "incoming"}, func(ctx zeroapi.Context) {
+		fromHeader = correlation.RequestID(ctx)
+		ctx.SetValue(correlation.ValueKeyRequestID, "generated")
+		fromValue = correlation.RequestID(ctx)
+	})
+
+	if fromHeader != "incoming" || fromValue != "generated" {
+		t.Fatalf("unexpected request id: %q %q", fromHeader, fromValue)
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	tests := []struct {
+		traceparent string
+		traceID     string
+		sampled     bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "4bf92f3577b34da6a3ce929d0e0e4736", false},
+		{"", "", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false},
+		{"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false},
+	}
+
+	for _, tt := range tests {
+		var traceID string
+		var sampled bool
+		run(t, map[string]string{correlation.HeaderTraceParent: tt.traceparent}, func(ctx zeroapi.Context) {
+			traceID, sampled = correlation.TraceID(ctx)
+		})
+
+		if traceID != tt.traceID || sampled != tt.sampled {
+			t.Fatalf("%q: got %q %v", tt.traceparent, traceID, sampled)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	headers := map[string]string{
+		correlation.HeaderRequestID:   "incoming",
+		correlation.HeaderTraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		correlation.HeaderTraceState:  "vendor=1",
+	}
+
+	var out *http.Request
+	run(t, headers, func(ctx zeroapi.Context) {
+		ctx.SetValue(correlation.ValueKeyRequestID, "generated")
+
+		out, _ = http.NewRequest(http.MethodGet, "http://upstream/", nil)
+		out.Header.Set(correlation.HeaderTraceState, "keep=1")
+		correlation.Inject(ctx, out)
+	})
+
+	if out.Header.Get(correlation.HeaderRequestID) != "generated" {
+		t.Fatalf("unexpected request id: %q", out.Header.Get(correlation.HeaderRequestID))
+	}
+	if out.Header.Get(correlation.HeaderTraceParent) != headers[correlation.HeaderTraceParent] {
+		t.Fatalf("unexpected traceparent: %q", out.Header.Get(correlation.HeaderTraceParent))
+	}
+	// 已存在的值不会被覆盖
+	if out.Header.Get(correlation.HeaderTraceState) != "keep=1" {
+		t.Fatalf("unexpected tracestate: %q", out.Header.Get(correlation.HeaderTraceState))
+	}
+}
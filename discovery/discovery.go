@@ -0,0 +1,174 @@
+// Package discovery 服务注册与发现，对接 Consul, etcd 等注册中心
+// Discovery 用于发现上游，见 proxy.NewWatcher，Registry 用于在 App 启动时注册自己，关闭时注销
+//
+// 示例:
+// reg := discovery.Register(app, registry, discovery.Instance{Service: "users", Address: "10.0.0.1:8080"})
+// pool, _ := proxy.NewPool(nil)
+// app.AddService(proxy.NewWatcher(pool, registry, "orders"))
+// app.Proxy("/orders/*", "", zeroapi.ProxyConfig{Balancer: pool})
+package discovery
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// ErrInvalidInstance 实例缺少服务名称或者地址
+var ErrInvalidInstance = errors.New("discovery: invalid instance")
+
+// Instance 服务实例
+type Instance struct {
+	// ID 实例唯一标识，为空时使用 Service-Address
+	ID string `json:"id"`
+
+	// Service 服务名称
+	Service string `json:"service"`
+
+	// Address 实例地址，host:port
+	Address string `json:"address"`
+
+	// Scheme 协议，默认 http
+	Scheme string `json:"scheme,omitempty"`
+
+	// Tags 标签，例如 "v2", "canary"
+	Tags []string `json:"tags,omitempty"`
+
+	// Meta 元数据
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// URL 实例地址，例如 http://10.0.0.1:8080
+func (i Instance) URL() string {
+	scheme := i.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	return scheme + "://" + i.Address
+}
+
+// HasTag 实例是否有标签 tag
+func (i Instance) HasTag(tag string) bool {
+	for _, t := range i.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Discovery 服务发现
+type Discovery interface {
+	// Watch 监听服务 service 的实例，立即发送一次当前的所有实例，之后每次变化时发送变化后的所有实例
+	// 接收者处理较慢时只保留最新的实例列表，ctx 结束后关闭通道
+	Watch(ctx context.Context, service string) (<-chan []Instance, error)
+}
+
+// Registry 服务注册
+// 注册中心需要心跳(例如 Consul TTL 检查，etcd 租约)时，由实现在 Register 后维持心跳，在 Deregister 时停止
+type Registry interface {
+	// Register 注册实例，相同 ID 的实例已经存在时覆盖
+	Register(ctx context.Context, instance Instance) error
+
+	// Deregister 注销实例
+	Deregister(ctx context.Context, instance Instance) error
+}
+
+// Registration 在 App 启动时注册实例，关闭时注销，实现了 zeroapi.Service
+type Registration struct {
+	registry Registry
+	instance Instance
+	config   *config
+
+	mu         sync.Mutex
+	registered bool
+}
+
+// NewRegistration 创建实例注册，通过 app.AddService 添加
+// 服务在 http 服务关闭之后才会停止，需要在关闭前注销时使用 Register
+func NewRegistration(registry Registry, instance Instance, opts ...Option) *Registration {
+	if instance.ID == "" {
+		instance.ID = instance.Service + "-" + instance.Address
+	}
+
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Registration{registry: registry, instance: instance, config: config}
+}
+
+// Register 创建实例注册并添加到 app 中
+// 收到 SIGINT, SIGTERM 时先注销实例，然后 App 再等待正在处理的请求完成，避免关闭期间仍然有新的请求发送过来
+// 需要在 app.Run 之前调用
+func Register(app zeroapi.App, registry Registry, instance Instance, opts ...Option) *Registration {
+	r := NewRegistration(registry, instance, opts...)
+	app.AddService(r)
+
+	deregister := func() {
+		if err := r.Deregister(); err != nil {
+			app.Logger().Errorf("deregister %s: %s", r.instance.ID, err.Error())
+		}
+	}
+	app.OnSignal(os.Interrupt, deregister)
+	app.OnSignal(syscall.SIGTERM, deregister)
+
+	return r
+}
+
+// Instance 注册的实例
+func (r *Registration) Instance() Instance {
+	return r.instance
+}
+
+// Start 注册实例，失败时 App 不再启动
+func (r *Registration) Start() error {
+	if r.instance.Service == "" || r.instance.Address == "" {
+		return ErrInvalidInstance
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.registered {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.timeout)
+	defer cancel()
+
+	if err := r.registry.Register(ctx, r.instance); err != nil {
+		return err
+	}
+	r.registered = true
+	return nil
+}
+
+// Stop 注销实例
+func (r *Registration) Stop() error {
+	return r.Deregister()
+}
+
+// Deregister 注销实例，没有注册或者已经注销时不做任何事
+func (r *Registration) Deregister() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.registered {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.timeout)
+	defer cancel()
+
+	if err := r.registry.Deregister(ctx, r.instance); err != nil {
+		return err
+	}
+	r.registered = false
+	return nil
+}
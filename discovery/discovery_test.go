@@ -0,0 +1,91 @@
+package discovery_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/discovery"
+)
+
+func TestMemoryWatch(t *testing.T) {
+	m := discovery.NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, err := m.Watch(ctx, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instances := <-ch; len(instances) != 0 {
+		t.Fatalf("unexpected instances: %v", instances)
+	}
+
+	// 没有接收时只保留最新的列表
+	m.Register(ctx, discovery.Instance{Service: "users", Address: "10.0.0.1:8080"})
+	m.Register(ctx, discovery.Instance{Service: "users", Address: "10.0.0.2:8080"})
+	m.Register(ctx, discovery.Instance{Service: "orders", Address: "10.0.0.3:8080"})
+
+	instances := <-ch
+	if len(instances) != 2 || instances[0].ID != "users-10.0.0.1:8080" || instances[1].URL() != "http://10.0.0.2:8080" {
+		t.Fatalf("unexpected instances: %v", instances)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("channel should be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel should be closed after cancel")
+	}
+
+	if err := m.Register(ctx, discovery.Instance{Service: "users"}); err != discovery.ErrInvalidInstance {
+		t.Fatalf("expect invalid instance: %v", err)
+	}
+}
+
+// failRegistry 注销失败
+type failRegistry struct {
+	*discovery.Memory
+	fail bool
+}
+
+func (r *failRegistry) Deregister(ctx context.Context, instance discovery.Instance) error {
+	if r.fail {
+		return errors.New("registry unavailable")
+	}
+	return r.Memory.Deregister(ctx, instance)
+}
+
+func TestRegistration(t *testing.T) {
+	registry := &failRegistry{Memory: discovery.NewMemory(), fail: true}
+	r := discovery.NewRegistration(registry, discovery.Instance{Service: "users", Address: "10.0.0.1:8080"})
+
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if instances := registry.Instances("users"); len(instances) != 1 || instances[0].ID != r.Instance().ID {
+		t.Fatalf("unexpected instances: %v", instances)
+	}
+
+	// 注销失败时可以再次注销
+	if err := r.Stop(); err == nil {
+		t.Fatal("expect deregister error")
+	}
+	registry.fail = false
+	if err := r.Deregister(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if instances := registry.Instances("users"); len(instances) != 0 {
+		t.Fatalf("instance should be deregistered: %v", instances)
+	}
+
+	if err := discovery.NewRegistration(registry, discovery.Instance{Service: "users"}).Start(); err != discovery.ErrInvalidInstance {
+		t.Fatalf("expect invalid instance: %v", err)
+	}
+}
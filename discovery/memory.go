@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory 进程内的注册中心，同时实现了 Discovery 与 Registry，用于测试以及单进程部署
+type Memory struct {
+	mu        sync.Mutex
+	instances map[string]map[string]Instance
+	watchers  map[string]map[chan []Instance]struct{}
+}
+
+// NewMemory 创建进程内的注册中心
+func NewMemory() *Memory {
+	return &Memory{
+		instances: make(map[string]map[string]Instance),
+		watchers:  make(map[string]map[chan []Instance]struct{}),
+	}
+}
+
+// Register 注册实例
+func (m *Memory) Register(_ context.Context, instance Instance) error {
+	if instance.Service == "" || instance.Address == "" {
+		return ErrInvalidInstance
+	}
+	if instance.ID == "" {
+		instance.ID = instance.Service + "-" + instance.Address
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.instances[instance.Service] == nil {
+		m.instances[instance.Service] = make(map[string]Instance)
+	}
+	m.instances[instance.Service][instance.ID] = instance
+	m.notify(instance.Service)
+	return nil
+}
+
+// Deregister 注销实例
+func (m *Memory) Deregister(_ context.Context, instance Instance) error {
+	if instance.ID == "" {
+		instance.ID = instance.Service + "-" + instance.Address
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exist := m.instances[instance.Service][instance.ID]; !exist {
+		return nil
+	}
+	delete(m.instances[instance.Service], instance.ID)
+	m.notify(instance.Service)
+	return nil
+}
+
+// Instances 获取服务的所有实例，按 ID 排序
+func (m *Memory) Instances(service string) []Instance {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.list(service)
+}
+
+// Watch 监听服务的实例
+func (m *Memory) Watch(ctx context.Context, service string) (<-chan []Instance, error) {
+	ch := make(chan []Instance, 1)
+
+	m.mu.Lock()
+	if m.watchers[service] == nil {
+		m.watchers[service] = make(map[chan []Instance]struct{})
+	}
+	m.watchers[service][ch] = struct{}{}
+	ch <- m.list(service)
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		m.mu.Lock()
+		delete(m.watchers[service], ch)
+		close(ch)
+		m.mu.Unlock()
+	}()
+
+	return ch, nil
+}
+
+// notify 通知监听者，丢弃还没有被接收的旧列表
+func (m *Memory) notify(service string) {
+	instances := m.list(service)
+	for ch := range m.watchers[service] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- instances
+	}
+}
+
+func (m *Memory) list(service string) []Instance {
+	instances := make([]Instance, 0, len(m.instances[service]))
+	for _, instance := range m.instances[service] {
+		instances = append(instances, instance)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	return instances
+}
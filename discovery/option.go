@@ -0,0 +1,27 @@
+package discovery
+
+import "time"
+
+// config 实例注册配置
+type config struct {
+	// timeout 注册与注销的超时时间
+	timeout time.Duration
+}
+
+func defaultConfig() *config {
+	return &config{
+		timeout: 5 * time.Second,
+	}
+}
+
+// Option 实例注册配置选项
+type Option func(config *config)
+
+// WithTimeout 设置注册与注销的超时时间，默认 5 秒
+func WithTimeout(timeout time.Duration) Option {
+	return func(config *config) {
+		if timeout > 0 {
+			config.timeout = timeout
+		}
+	}
+}
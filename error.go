@@ -0,0 +1,151 @@
+package zeroapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCookieTooLarge cookie 超过大小限制
+	ErrCookieTooLarge = errors.New("cookie too large")
+
+	// ErrCookieDecrypt cookie 解密失败，例如被篡改或者加密密钥已经被移除
+	ErrCookieDecrypt = errors.New("cookie decrypt failed")
+
+	// ErrResponseFinished 响应已经结束，例如在后台 goroutine 中继续写入响应
+	ErrResponseFinished = errors.New("response already finished")
+
+	// ErrBodyTooLarge 请求体超过限制
+	ErrBodyTooLarge = errors.New("request body too large")
+
+	// ErrSerializerNotFound 响应序列化不存在
+	ErrSerializerNotFound = errors.New("serializer not found")
+
+	// ErrInvalidCallback JSONP 回调函数名称不合法
+	ErrInvalidCallback = errors.New("invalid jsonp callback")
+)
+
+// BindError 请求参数绑定到结构体时发生的错误
+type BindError struct {
+	// Field 结构体字段名称
+	Field string
+
+	// Key 请求参数名称，即标签中指定的名称
+	Key string
+
+	// Value 请求参数的值
+	Value string
+
+	// Err 原始错误
+	Err error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("bind field %s(%s) with value %q failed: %v", e.Field, e.Key, e.Value, e.Err)
+}
+
+// Unwrap 获取原始错误
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedMediaTypeError 请求体的 Content-Type 不支持绑定，默认的错误处理响应 415
+type UnsupportedMediaTypeError struct {
+	// ContentType 请求头中的 Content-Type
+	ContentType string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("unsupported content type %q", e.ContentType)
+}
+
+// FieldError 结构体字段验证失败的信息
+type FieldError struct {
+	// Field 字段名称，优先使用 json, form, query 标签中的名称，嵌套结构体使用 "." 连接
+	Field string `json:"field"`
+
+	// Rule 未通过的验证规则，例如 required, min
+	Rule string `json:"rule"`
+
+	// Param 验证规则的参数，例如 min=3 中的 3
+	Param string `json:"param,omitempty"`
+
+	// Message 错误描述
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors 结构体验证失败的字段列表，可以直接作为 422 响应的内容
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// HTTPError 带 HTTP 状态码的错误，处理函数返回后由错误处理函数转换为响应
+type HTTPError struct {
+	// Code HTTP 状态码
+	Code int
+
+	// Message 返回给客户端的错误信息，为空时使用状态码对应的描述
+	Message string
+
+	// Err 原始错误，只记录日志，不会返回给客户端
+	Err error
+}
+
+// NewHTTPError 创建带 HTTP 状态码的错误
+func NewHTTPError(code int, message ...string) *HTTPError {
+	e := &HTTPError{Code: code}
+	if len(message) > 0 {
+		e.Message = message[0]
+	}
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	s := strconv.Itoa(e.Code) + " " + e.Text()
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// Text 返回给客户端的错误信息
+func (e *HTTPError) Text() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return http.StatusText(e.Code)
+}
+
+// Unwrap 获取原始错误
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Wrap 返回包装了原始错误 err 的副本，例如 ErrUserNotFound.Wrap(err)
+func (e *HTTPError) Wrap(err error) *HTTPError {
+	return &HTTPError{Code: e.Code, Message: e.Message, Err: err}
+}
+
+// E 将返回错误的处理函数转换为 Handler，返回的错误通过 ctx.Error 交给 App 的错误处理函数
+// 示例: app.Get("/user/:id", zeroapi.E(func(ctx zeroapi.Context) error { return zeroapi.NewHTTPError(404) }))
+func E(handler HandlerE) Handler {
+	return func(ctx Context) {
+		if err := handler(ctx); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package eventbus 进程内事件总线，应用默认使用，通过 app.EventBus() 获取
+//
+// 示例:
+// unsubscribe := app.EventBus().Subscribe("order.*", func(topic string, data interface{}) { ... })
+// app.EventBus().Publish("order.paid", order)
+package eventbus
+
+import (
+	"strings"
+	"sync"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+type subscriber struct {
+	topic   string
+	handler zeroapi.EventHandler
+}
+
+// match 主题是否匹配订阅
+func (s *subscriber) match(topic string) bool {
+	switch {
+	case s.topic == "*":
+		return true
+	case strings.HasSuffix(s.topic, ".*"):
+		return strings.HasPrefix(topic, s.topic[:len(s.topic)-1])
+	}
+	return s.topic == topic
+}
+
+type bus struct {
+	mu sync.RWMutex
+
+	// subscribers 订阅者，修改时复制，发布时无需持有锁调用订阅者
+	subscribers []*subscriber
+}
+
+// New 创建进程内事件总线
+func New() zeroapi.EventBus {
+	return &bus{}
+}
+
+// Publish 发布事件，在当前 goroutine 中依次调用订阅者
+func (b *bus) Publish(topic string, data interface{}) {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	for _, s := range subscribers {
+		if s.match(topic) {
+			s.handler(topic, data)
+		}
+	}
+}
+
+// Subscribe 订阅主题，返回取消订阅的函数，可以多次调用
+func (b *bus) Subscribe(topic string, handler zeroapi.EventHandler) func() {
+	s := &subscriber{topic: topic, handler: handler}
+
+	b.mu.Lock()
+	subscribers := make([]*subscriber, len(b.subscribers), len(b.subscribers)+1)
+	copy(subscribers, b.subscribers)
+	b.subscribers = append(subscribers, s)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.unsubscribe(s) })
+	}
+}
+
+func (b *bus) unsubscribe(s *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscribers := make([]*subscriber, 0, len(b.subscribers))
+	for _, exist := range b.subscribers {
+		if exist != s {
+			subscribers = append(subscribers, exist)
+		}
+	}
+	b.subscribers = subscribers
+}
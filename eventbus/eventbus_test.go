@@ -0,0 +1,37 @@
+package eventbus_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zerogo-hub/zero-api/eventbus"
+)
+
+func TestSubscribe(t *testing.T) {
+	bus := eventbus.New()
+
+	var got []string
+	record := func(name string) func(topic string, data interface{}) {
+		return func(topic string, data interface{}) {
+			got = append(got, name+":"+topic)
+		}
+	}
+
+	bus.Subscribe("order.paid", record("exact"))
+	unsubscribe := bus.Subscribe("order.*", record("prefix"))
+	bus.Subscribe("*", record("all"))
+
+	bus.Publish("order.paid", nil)
+	bus.Publish("orders", nil)
+	if s := strings.Join(got, ","); s != "exact:order.paid,prefix:order.paid,all:order.paid,all:orders" {
+		t.Fatalf("invalid events: %s", s)
+	}
+
+	got = nil
+	unsubscribe()
+	unsubscribe()
+	bus.Publish("order.created", nil)
+	if s := strings.Join(got, ","); s != "all:order.created" {
+		t.Fatalf("invalid events after unsubscribe: %s", s)
+	}
+}
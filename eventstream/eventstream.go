@@ -0,0 +1,195 @@
+// Package eventstream 将事件总线上的事件推送给通过 SSE 或者 WebSocket 订阅的客户端
+// 客户端通过查询参数指定主题，每个主题都需要通过订阅校验
+//
+// 示例:
+// b := eventstream.New(eventstream.WithAuthorize(func(ctx zeroapi.Context, topic string) bool { return canSubscribe(ctx, topic) }))
+// app.Get("/events", b.SSE())
+// app.Get("/ws", b.WebSocket())
+// app.EventBus().Publish("order.paid", order)
+package eventstream
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/websocket"
+)
+
+// Authorize 校验客户端能否订阅主题，topic 为客户端请求的主题，可能包含通配符，例如 "order.*"
+type Authorize func(ctx zeroapi.Context, topic string) bool
+
+// Message WebSocket 推送的消息，编码为 JSON 文本消息
+// SSE 推送时 Topic 为事件名称，Data 为事件数据
+type Message struct {
+	// Topic 事件主题
+	Topic string `json:"topic"`
+
+	// Data 事件数据
+	Data interface{} `json:"data"`
+}
+
+// Bridge 将事件总线上的事件推送给客户端
+type Bridge struct {
+	config *config
+}
+
+// New 创建事件推送
+func New(opts ...Option) *Bridge {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Bridge{config: config}
+}
+
+// SSE 生成路由处理函数，通过 Server-Sent Events 推送事件，事件名称为主题
+func (b *Bridge) SSE() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		sub := b.subscribe(ctx)
+		if sub == nil {
+			return
+		}
+		defer sub.close()
+
+		stream, err := ctx.SSE(b.config.heartbeat...)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer stream.Close()
+
+		for {
+			select {
+			case m := <-sub.messages:
+				if err := stream.Send(m.Topic, m.Data); err != nil {
+					return
+				}
+			case <-sub.overflow:
+				return
+			case <-stream.Done():
+				return
+			}
+		}
+	}
+}
+
+// WebSocket 生成路由处理函数，通过 WebSocket 推送事件，消息格式见 Message
+// 客户端发送的消息会被忽略
+func (b *Bridge) WebSocket() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		sub := b.subscribe(ctx)
+		if sub == nil {
+			return
+		}
+		defer sub.close()
+
+		conn, err := websocket.Upgrade(ctx.Response().Writer(), ctx.Request(), b.config.websocketOptions...)
+		if err != nil {
+			ctx.Stopped()
+			return
+		}
+		defer conn.Close()
+
+		// 读取客户端消息，自动回复 ping，检测客户端断开
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		codec := ctx.App().JSONCodec()
+		for {
+			select {
+			case m := <-sub.messages:
+				data, err := codec.Marshal(&m)
+				if err != nil {
+					ctx.App().Logger().Errorf("eventstream: marshal %s: %s", m.Topic, err.Error())
+					continue
+				}
+				if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+					return
+				}
+			case <-sub.overflow:
+				return
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+// subscribe 校验并订阅客户端请求的主题，失败时已经写入错误响应并返回 nil
+func (b *Bridge) subscribe(ctx zeroapi.Context) *subscription {
+	topics := b.topics(ctx)
+	if len(topics) == 0 {
+		ctx.Error(zeroapi.NewHTTPError(http.StatusBadRequest, "topic required"))
+		return nil
+	}
+
+	if b.config.authorize != nil {
+		for _, topic := range topics {
+			if !b.config.authorize(ctx, topic) {
+				ctx.Error(zeroapi.NewHTTPError(http.StatusForbidden))
+				return nil
+			}
+		}
+	}
+
+	sub := &subscription{
+		messages: make(chan Message, b.config.bufferSize),
+		overflow: make(chan struct{}),
+	}
+
+	bus := ctx.App().EventBus()
+	for _, topic := range topics {
+		sub.unsubscribes = append(sub.unsubscribes, bus.Subscribe(topic, sub.push))
+	}
+
+	return sub
+}
+
+// topics 客户端请求的主题，支持重复的查询参数或者使用逗号分隔
+func (b *Bridge) topics(ctx zeroapi.Context) []string {
+	var topics []string
+	seen := make(map[string]bool)
+	for _, value := range ctx.Request().URL.Query()[b.config.topicParam] {
+		for _, topic := range strings.Split(value, ",") {
+			topic = strings.TrimSpace(topic)
+			if topic != "" && !seen[topic] {
+				seen[topic] = true
+				topics = append(topics, topic)
+			}
+		}
+	}
+	return topics
+}
+
+// subscription 一个客户端的订阅
+type subscription struct {
+	messages     chan Message
+	overflow     chan struct{}
+	overflowOnce sync.Once
+	unsubscribes []func()
+}
+
+// push 由事件总线调用，不能阻塞发布者，缓冲区满时通知断开客户端
+func (s *subscription) push(topic string, data interface{}) {
+	select {
+	case s.messages <- Message{Topic: topic, Data: data}:
+	default:
+		s.overflowOnce.Do(func() { close(s.overflow) })
+	}
+}
+
+func (s *subscription) close() {
+	for _, unsubscribe := range s.unsubscribes {
+		unsubscribe()
+	}
+}
@@ -0,0 +1,71 @@
+package eventstream_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/eventstream"
+)
+
+func newApp() zeroapi.App {
+	a := app.NewApp()
+	b := eventstream.New(eventstream.WithAuthorize(func(ctx zeroapi.Context, topic string) bool {
+		return !strings.HasPrefix(topic, "admin.")
+	}))
+	a.Get("/events", b.SSE())
+	a.Router().Build()
+	return a
+}
+
+func TestSSE(t *testing.T) {
+	a := newApp()
+	server := httptest.NewServer(a.Server())
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/events?topic=order.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// 响应头已返回，订阅已经完成
+	a.EventBus().Publish("user.created", "skipped")
+	a.EventBus().Publish("order.paid", map[string]int{"id": 1})
+
+	br := bufio.NewReader(res.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, ":") {
+			lines = append(lines, line)
+		}
+	}
+
+	if lines[0] != "event: order.paid" || lines[1] != `data: {"id":1}` {
+		t.Fatalf("invalid event: %q", lines)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	a := newApp()
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?topic=order.paid,admin.audit", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("invalid status: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid status: %d", w.Code)
+	}
+}
@@ -0,0 +1,76 @@
+package eventstream
+
+import (
+	"time"
+
+	"github.com/zerogo-hub/zero-api/websocket"
+)
+
+// config 事件推送配置
+type config struct {
+	// authorize 校验客户端能否订阅主题
+	authorize Authorize
+
+	// topicParam 客户端通过该查询参数指定订阅的主题
+	topicParam string
+
+	// bufferSize 每个客户端缓冲的事件数
+	bufferSize int
+
+	// heartbeat SSE 心跳间隔，为空时使用 ctx.SSE 的默认值
+	heartbeat []time.Duration
+
+	// websocketOptions WebSocket 握手选项
+	websocketOptions []websocket.Option
+}
+
+func defaultConfig() *config {
+	return &config{
+		topicParam: "topic",
+		bufferSize: 64,
+	}
+}
+
+// Option 事件推送配置选项
+type Option func(config *config)
+
+// WithAuthorize 设置订阅校验函数，客户端请求的每个主题都需要通过校验，否则响应 403
+// 默认允许订阅所有主题，事件可能包含敏感数据时必须设置
+func WithAuthorize(authorize Authorize) Option {
+	return func(config *config) {
+		config.authorize = authorize
+	}
+}
+
+// WithTopicParam 设置指定主题的查询参数，默认 topic，例如 ?topic=order.paid&topic=user.*
+func WithTopicParam(name string) Option {
+	return func(config *config) {
+		if name != "" {
+			config.topicParam = name
+		}
+	}
+}
+
+// WithBufferSize 设置每个客户端缓冲的事件数，默认 64
+// 客户端消费过慢导致缓冲区满时断开连接，由客户端重连
+func WithBufferSize(size int) Option {
+	return func(config *config) {
+		if size > 0 {
+			config.bufferSize = size
+		}
+	}
+}
+
+// WithHeartbeat 设置 SSE 心跳间隔，默认使用 ctx.SSE 的默认值
+func WithHeartbeat(heartbeat time.Duration) Option {
+	return func(config *config) {
+		config.heartbeat = []time.Duration{heartbeat}
+	}
+}
+
+// WithWebSocketOptions 设置 WebSocket 握手选项，例如允许跨域
+func WithWebSocketOptions(opts ...websocket.Option) Option {
+	return func(config *config) {
+		config.websocketOptions = append(config.websocketOptions, opts...)
+	}
+}
@@ -5,5 +5,7 @@ go 1.16
 require (
 	github.com/zerogo-hub/zero-api-middleware v0.2.1
 	github.com/zerogo-hub/zero-helper v0.3.1
+	golang.org/x/net v0.0.0-20210614182718-04defd469f4e
 	google.golang.org/protobuf v1.26.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
@@ -0,0 +1,165 @@
+// Package httpclient 调用第三方接口使用的 http 客户端，开启调试后将请求记录为 curl 命令，方便复现问题
+//
+// 示例:
+// client := httpclient.New(httpclient.WithDebug(app.Logger()))
+// res, err := client.Get("https://api.example.com/users?token=xxx")
+// 日志: curl -X GET 'https://api.example.com/users?token=REDACTED' # 200 OK 35ms
+package httpclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// redacted 隐藏后的值
+	redacted = "REDACTED"
+)
+
+// New 创建 http 客户端
+func New(opts ...Option) *http.Client {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	transport := config.transport
+	if config.debug != nil {
+		transport = &debugTransport{next: transport, config: config}
+	}
+
+	return &http.Client{Timeout: config.timeout, Transport: transport}
+}
+
+// debugTransport 记录 curl 格式的请求日志
+type debugTransport struct {
+	next   http.RoundTripper
+	config *config
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := t.peekBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	command := t.curl(req, body)
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		t.config.debug.Errorf("%s # error %s: %v", command, elapsed, err)
+		return nil, err
+	}
+
+	t.config.debug.Infof("%s # %s %s", command, res.Status, elapsed)
+
+	return res, nil
+}
+
+// peekBody 读取不超过 maxBodyLog 的请求体用于记录日志，并恢复请求体
+// 请求体过长或者长度未知时不读取，返回 nil
+func (t *debugTransport) peekBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.ContentLength < 0 || req.ContentLength > int64(t.config.maxBodyLog) {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// curl 生成 curl 命令，隐藏敏感的请求头与查询参数
+func (t *debugTransport) curl(req *http.Request, body []byte) string {
+	var b strings.Builder
+	b.WriteString("curl -X ")
+	b.WriteString(req.Method)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			if t.config.redact[strings.ToLower(name)] {
+				value = redacted
+			}
+			b.WriteString(" -H ")
+			b.WriteString(quote(name + ": " + value))
+		}
+	}
+
+	switch {
+	case body == nil && req.Body != nil && req.Body != http.NoBody:
+		b.WriteString(" --data-binary '<")
+		if req.ContentLength >= 0 {
+			b.WriteString(strconv.FormatInt(req.ContentLength, 10))
+		} else {
+			b.WriteString("unknown")
+		}
+		b.WriteString(" bytes>'")
+	case len(body) > 0 && utf8.Valid(body):
+		b.WriteString(" --data-binary ")
+		b.WriteString(quote(string(body)))
+	case len(body) > 0:
+		b.WriteString(" --data-binary '<")
+		b.WriteString(strconv.Itoa(len(body)))
+		b.WriteString(" bytes binary>'")
+	}
+
+	b.WriteString(" ")
+	b.WriteString(quote(t.redactURL(req.URL)))
+
+	return b.String()
+}
+
+func (t *debugTransport) redactURL(u *url.URL) string {
+	c := *u
+	if c.User != nil {
+		if _, ok := c.User.Password(); ok {
+			c.User = url.UserPassword(c.User.Username(), redacted)
+		}
+	}
+
+	if c.RawQuery != "" {
+		query := c.Query()
+		changed := false
+		for name, values := range query {
+			if t.config.redact[strings.ToLower(name)] {
+				for i := range values {
+					values[i] = redacted
+				}
+				changed = true
+			}
+		}
+		if changed {
+			c.RawQuery = query.Encode()
+		}
+	}
+
+	return c.String()
+}
+
+// quote 使用单引号包裹，可以直接复制到 shell 中执行
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,100 @@
+package httpclient_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zerogo-hub/zero-api/httpclient"
+	"github.com/zerogo-hub/zero-helper/logger"
+)
+
+// recorder 记录日志的 logger，其它方法使用 logger.NewSampleLogger
+type recorder struct {
+	logger.Logger
+
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *recorder) add(format string, a ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, fmt.Sprintf(format, a...))
+}
+
+func (r *recorder) Debugf(format string, a ...interface{}) { r.add(format, a...) }
+func (r *recorder) Info(a ...interface{})                  { r.add(fmt.Sprint(a...)) }
+func (r *recorder) Infof(format string, a ...interface{})  { r.add(format, a...) }
+func (r *recorder) Error(a ...interface{})                 { r.add(fmt.Sprint(a...)) }
+func (r *recorder) Errorf(format string, a ...interface{}) { r.add(format, a...) }
+func (r *recorder) IsDebugAble() bool                      { return true }
+
+func TestDebug(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := new(strings.Builder)
+		fmt.Fprint(buf, r.Header.Get("Authorization"), "|")
+		b := make([]byte, 64)
+		n, _ := r.Body.Read(b)
+		buf.Write(b[:n])
+		received = buf.String()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	log := &recorder{Logger: logger.NewSampleLogger()}
+	client := httpclient.New(httpclient.WithDebug(log), httpclient.WithRedact("X-Session"))
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/users?token=abc&page=1", strings.NewReader(`{"name":"it's"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Session", "s1")
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// 请求本身不受影响
+	if received != `Bearer secret|{"name":"it's"}` {
+		t.Fatalf("request changed: %s", received)
+	}
+
+	if len(log.lines) != 1 {
+		t.Fatalf("expect 1 line, got %v", log.lines)
+	}
+	line := log.lines[0]
+	for _, expect := range []string{
+		"curl -X POST",
+		"-H 'Authorization: REDACTED'",
+		"-H 'X-Session: REDACTED'",
+		"-H 'Content-Type: application/json'",
+		`--data-binary '{"name":"it'\''s"}'`,
+		"page=1&token=REDACTED'",
+		"# 201 Created",
+	} {
+		if !strings.Contains(line, expect) {
+			t.Fatalf("expect %q in %s", expect, line)
+		}
+	}
+	if strings.Contains(line, "secret") || strings.Contains(line, "abc") {
+		t.Fatalf("secret leaked: %s", line)
+	}
+}
+
+func TestDebugError(t *testing.T) {
+	log := &recorder{Logger: logger.NewSampleLogger()}
+	client := httpclient.New(httpclient.WithDebug(log))
+
+	if _, err := client.Get("http://127.0.0.1:1/unreachable"); err == nil {
+		t.Fatal("expect error")
+	}
+	if len(log.lines) != 1 || !strings.Contains(log.lines[0], "# error") {
+		t.Fatalf("unexpected log: %v", log.lines)
+	}
+}
@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zerogo-hub/zero-helper/logger"
+)
+
+// config http 客户端配置
+type config struct {
+	// timeout 请求超时时间
+	timeout time.Duration
+
+	// transport 发送请求使用的 RoundTripper
+	transport http.RoundTripper
+
+	// debug 记录 curl 格式日志的 logger，为 nil 时不记录
+	debug logger.Logger
+
+	// redact 需要隐藏值的请求头与查询参数，小写
+	redact map[string]bool
+
+	// maxBodyLog 日志中请求体的最大长度，超过时不记录内容
+	maxBodyLog int
+}
+
+func defaultConfig() *config {
+	c := &config{
+		timeout:    10 * time.Second,
+		transport:  http.DefaultTransport,
+		redact:     make(map[string]bool),
+		maxBodyLog: 4 << 10,
+	}
+
+	for _, name := range []string{
+		"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key", "X-Auth-Token",
+		"access_token", "token", "api_key", "apikey", "password", "secret", "signature",
+	} {
+		c.redact[strings.ToLower(name)] = true
+	}
+
+	return c
+}
+
+// Option http 客户端配置选项
+type Option func(config *config)
+
+// WithTimeout 设置请求超时时间，默认 10 秒，0 表示不超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(config *config) {
+		if timeout >= 0 {
+			config.timeout = timeout
+		}
+	}
+}
+
+// WithTransport 设置发送请求使用的 RoundTripper，默认 http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(config *config) {
+		if transport != nil {
+			config.transport = transport
+		}
+	}
+}
+
+// WithDebug 将发出的请求以 curl 命令的格式记录到日志中，包括耗时与响应状态
+// 默认隐藏 Authorization, Cookie 等请求头以及 token, password 等查询参数的值
+func WithDebug(log logger.Logger) Option {
+	return func(config *config) {
+		config.debug = log
+	}
+}
+
+// WithRedact 添加需要隐藏值的请求头或者查询参数，不区分大小写
+func WithRedact(names ...string) Option {
+	return func(config *config) {
+		for _, name := range names {
+			config.redact[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// WithMaxBodyLog 设置日志中请求体的最大长度，默认 4KB，超过时只记录长度
+func WithMaxBodyLog(n int) Option {
+	return func(config *config) {
+		if n >= 0 {
+			config.maxBodyLog = n
+		}
+	}
+}
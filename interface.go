@@ -1,8 +1,16 @@
 package zeroapi
 
 import (
+	"context"
+	"crypto/tls"
+	"io"
+	"io/fs"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"time"
 
 	graceful "github.com/zerogo-hub/zero-helper/graceful/http"
 	"github.com/zerogo-hub/zero-helper/logger"
@@ -17,21 +25,45 @@ type App interface {
 	// Server http 服务器
 	Server() Server
 
-	// Context 从 pool 中获取一个 Context
+	// Context 创建一个 Context，每个请求使用各自的 Context，请求结束后不会被复用
+	// 请求结束后通过它的写入返回 ErrResponseFinished，代价是每个请求都会分配新的 Context
 	Context() Context
 
-	// ReleaseContext 将 Context 释放到 pool 中
-	ReleaseContext(ctx Context)
-
 	// Version 获取框架版本号
 	Version() string
 
 	// Logger 获取日志实例
 	Logger() logger.Logger
 
+	// Metrics 获取指标管理器
+	Metrics() Metrics
+
+	// EventBus 获取进程内事件总线
+	EventBus() EventBus
+
+	// Cache 获取进程内缓存，用于缓存配置、字典等少量数据
+	Cache() Cache
+
+	// FlashStore 获取一次性消息存储，见 ctx.Flash
+	FlashStore() FlashStore
+
+	// SetTrustedProxies 设置受信任的代理，例如负载均衡的地址，支持 CIDR 与单个 IP，需要在 Run 之前调用
+	// 只有直接连接的对端是受信任的代理时，ctx.ClientIP 才使用 X-Forwarded-For 等请求头
+	// 示例: app.SetTrustedProxies("10.0.0.0/8", "127.0.0.1", "::1")
+	SetTrustedProxies(cidrs ...string) error
+
+	// IsTrustedProxy ip 是否是受信任的代理
+	IsTrustedProxy(ip string) bool
+
 	// FileMaxMemory 文件系统使用的最大内存
 	FileMaxMemory() int64
 
+	// MaxBodyBytes 请求体最大字节数，为 0 时不限制，路由可以通过 RouteMiddleware.MaxBodyBytes 覆盖
+	MaxBodyBytes() int64
+
+	// ServerTimeouts http 服务的超时时间
+	ServerTimeouts() ServerTimeouts
+
 	// IsCookieEncode cookie 是否需要进行编码
 	IsCookieEncode() bool
 
@@ -41,7 +73,70 @@ type App interface {
 	// CookieDecodeHandler 获取 cookie 解码函数
 	CookieDecodeHandler() CookieDecodeHandler
 
+	// CookieDefaults 获取 cookie 默认选项，SetCookie 时先于调用时传入的选项应用
+	CookieDefaults() []CookieOption
+
+	// IsCookieLegacyMD5 是否接受旧版本 HMAC-MD5 签名的 cookie，见 WithCookieVerify
+	IsCookieLegacyMD5() bool
+
+	// JSONCodec 获取 JSON 编码与解码器
+	JSONCodec() JSONCodec
+
+	// IsH2C 是否支持明文 HTTP/2(h2c)
+	IsH2C() bool
+
+	// IsAutoOptions 是否根据路由自动响应 OPTIONS 请求
+	IsAutoOptions() bool
+
+	// ServeMode 运行方式，ServeModeHTTP, ServeModeFastCGI 或者 ServeModeCGI
+	ServeMode() string
+
+	// PanicHandler 获取处理函数发生 panic 时执行的函数，没有设置时返回 nil
+	PanicHandler() PanicHandler
+
+	// SetErrorHandler 设置错误处理函数，集中将 ctx.Error 以及 HandlerE 返回的错误转换为响应
+	// 没有设置时，*HTTPError 按照状态码响应，其它错误记录日志并响应 500
+	SetErrorHandler(handler ErrorHandler)
+
+	// ErrorHandler 获取错误处理函数，没有设置时返回 nil
+	ErrorHandler() ErrorHandler
+
+	// NotFound 设置路由不存在时的处理函数，没有设置时响应 404
+	// 处理函数在应用级别中间件之后执行，需要自行设置状态码
+	NotFound(handler Handler)
+
+	// NotFoundHandler 获取路由不存在时的处理函数，没有设置时返回 nil
+	NotFoundHandler() Handler
+
+	// MethodNotAllowed 设置路径存在但请求方法不匹配时的处理函数，没有设置时响应 405
+	// 执行处理函数前已设置响应头 Allow，处理函数需要自行设置状态码
+	MethodNotAllowed(handler Handler)
+
+	// MethodNotAllowedHandler 获取请求方法不匹配时的处理函数，没有设置时返回 nil
+	MethodNotAllowedHandler() Handler
+
+	// Now 获取当前时间，默认 time.Now，测试中可以通过 WithNow 固定时间
+	Now() time.Time
+
+	// Rand 获取随机数来源，默认 crypto/rand.Reader，测试中可以通过 WithRand 使用固定的随机数
+	Rand() io.Reader
+
+	// RegisterValidator 注册结构体字段验证函数，在 validate 标签中通过 name 使用
+	// 已存在同名的验证函数时忽略
+	RegisterValidator(name string, validator StructValidator)
+
+	// Validator 获取结构体字段验证函数
+	Validator(name string) StructValidator
+
+	// RegisterSerializer 注册响应序列化，通过 ctx.Serialize(name, obj) 使用，同名时替换
+	// 默认已注册 SerializerJSON, SerializerXML, SerializerYAML, SerializerMsgPack
+	RegisterSerializer(name string, serializer Serializer)
+
+	// Serializer 获取响应序列化，不存在时返回 nil
+	Serializer(name string) Serializer
+
 	// Use 添加 App 级别 中间件，每一次路由都会调用公共中间件
+	// 路由可以通过 RouteMiddleware 在其之前执行中间件，或者跳过 App 级别中间件
 	Use(handlers ...Handler)
 
 	// ExecuteMiddlewares 执行 App 级别的中间件
@@ -49,11 +144,46 @@ type App interface {
 
 	// Run 启动服务，此方法会阻塞，直到应用关闭
 	// addr: host:port，例如: ":8080"，"192.168.1.8:80"
+	// 通过 AddService 添加的服务在 http 服务之前启动，在 http 服务关闭后按添加的相反顺序停止
 	Run(addr string) error
 
+	// RunTLS 使用证书文件启动 https 服务，此方法会阻塞，直到应用关闭
+	// certFile: 证书路径
+	// keyFile: 私钥路径
+	RunTLS(addr, certFile, keyFile string) error
+
+	// RunAutoTLS 在 :443 启动 https 服务，通过 ACME(例如 Let's Encrypt) 自动获取与续期 domains 的证书，此方法会阻塞，直到应用关闭
+	// 证书管理器通过 app.WithCertManager 创建，同时在 :80 处理 HTTP-01 验证请求，其它请求重定向到 https
+	RunAutoTLS(domains ...string) error
+
+	// RunListeners 同时在多个监听上启动服务，此方法会阻塞，直到应用关闭
+	// 例如 systemd socket activation 传入的监听，测试中使用的 127.0.0.1:0
+	// 收到 SIGINT, SIGTERM 时优雅关闭所有监听，收到 SIGUSR2 时热重启
+	RunListeners(listeners ...net.Listener) error
+
+	// RunAddrs 同时在多个地址上启动服务，"unix:/path" 监听 unix socket，否则监听 TCP，此方法会阻塞，直到应用关闭
+	// 收到 SIGINT, SIGTERM 时优雅关闭所有监听，收到 SIGUSR2 时热重启，新进程继承原有的监听
+	RunAddrs(addrs ...string) error
+
+	// AddService 添加与 http 服务一同启动与停止的服务，例如 rawnet 中的 TCP/UDP 监听
+	AddService(service Service)
+
+	// OnSignal 注册信号处理函数，收到 sig 时执行 fn，比如 SIGHUP 时重新加载配置
+	// 同一个信号可以注册多个处理函数，按注册顺序执行
+	OnSignal(sig os.Signal, fn func())
+
 	RouterRegister
 }
 
+// Service 与 App 一同启动与停止的服务
+type Service interface {
+	// Start 启动服务，不能阻塞，返回错误时 App 不再启动
+	Start() error
+
+	// Stop 停止服务，等待处理中的请求结束
+	Stop() error
+}
+
 // RouterRegister 路由注册相关接口
 type RouterRegister interface {
 	// Prefix 设置前缀，设置前就已添加的路由不会有该前缀
@@ -95,13 +225,52 @@ type RouterRegister interface {
 	// handlers: 路由级别中间件和处理函数
 	Options(path string, handlers ...Handler) App
 
+	// WS 注册 WebSocket 路由，method = "GET"
+	// path: 路径，以 "/" 开头，不可以为空
+	// handler: 握手成功后执行，返回后连接自动关闭
+	// middlewares: 路由级别中间件，在握手之前执行
+	WS(path string, handler WebSocketHandler, middlewares ...Handler) App
+
+	// Mount 将 http.Handler 挂载到 prefix 下，所有请求方法的 prefix 以及 prefix/* 都交给 h 处理，例如 grpc-gateway, 第三方管理后台
+	// 请求路径去掉 prefix 后交给 h，与 http.StripPrefix 相同，App 级别中间件与 middlewares 在 h 之前执行
+	Mount(prefix string, h http.Handler, middlewares ...Handler) App
+
+	// Proxy 将匹配 pattern 的所有请求方法转发到上游 target，例如 app.Proxy("/api/*", "http://127.0.0.1:8081")
+	// config 反向代理配置，见 ProxyConfig，target 不正确时返回错误，设置 ProxyConfig.Balancer 时 target 可以为空
+	Proxy(pattern, target string, config ...ProxyConfig) error
+
+	// Handle 注册路由，并控制路由级别中间件与 App 级别中间件的执行顺序
+	// method: HTTP Method，例如 MethodGet
+	// path: 路径，以 "/" 开头，不可以为空
+	// m: 在 App 级别中间件之前执行的中间件，是否跳过 App 级别中间件，路由的成本，服务等级目标
+	// handlers: 路由级别中间件和处理函数，在 App 级别中间件之后执行
+	Handle(method, path string, m RouteMiddleware, handlers ...Handler) App
+
+	// AddSLO 记录路由的服务等级目标，路径加上当前的前缀，通过 RouteMiddleware.SLO 注册路由时自动调用
+	AddSLO(slo RouteSLO)
+
+	// SLOs 所有路由声明的服务等级目标，按照注册顺序返回
+	SLOs() []RouteSLO
+
 	// Group 创建组路由实例
 	Group(path string) Group
 
-	// Static 添加静态资源服务
+	// Host 创建只匹配该主机名的组路由，例如 api.example.com，*.example.com
+	// 通配符匹配的子域名通过 ctx.Dynamic("subdomain") 获取，使用 :tenant.example.com 时通过 ctx.Dynamic("tenant") 获取
+	// 主机名匹配后只在该主机的路由中查找，不会回退到没有指定主机的路由
+	Host(pattern string) Group
+
+	// Static 添加静态资源服务，支持首页文件、目录列表、ETag/Last-Modified 缓存以及 Range 请求
 	// prefix 静态资源路由前缀
 	// path 资源真实位置(绝对路径，相对路径)
-	Static(prefix, path string)
+	// config 静态资源服务配置，见 StaticConfig
+	Static(prefix, path string, config ...StaticConfig)
+
+	// StaticFS 添加静态资源服务，资源来自 fs.FS，例如 embed.FS，可以将前端资源打包进可执行文件
+	// prefix 静态资源路由前缀
+	// fsys 文件系统，如果资源位于子目录中，可以通过 fs.Sub 获取子目录
+	// config 静态资源服务配置，见 StaticConfig
+	StaticFS(prefix string, fsys fs.FS, config ...StaticConfig)
 }
 
 // Context 上下文
@@ -112,10 +281,19 @@ type Context interface {
 	ContextGet
 	ContextPost
 	ContextDynamic
+	ContextBind
+	ContextWebSocket
+	ContextSSE
 	ContextFile
 	ContextWrite
 	ContextCookie
 	ContextHook
+	ContextMetric
+	ContextCost
+	ContextStd
+	ContextFlash
+	ContextWarning
+	ContextRoute
 }
 
 // ContextBase 基础
@@ -129,6 +307,9 @@ type ContextBase interface {
 	// Request 获取原始 http 请求
 	Request() *http.Request
 
+	// SetRequest 替换原始 http 请求，例如中间件通过 req.WithContext 设置超时
+	SetRequest(req *http.Request)
+
 	// Response 获取 http 响应
 	Response() Writer
 
@@ -144,9 +325,14 @@ type ContextBase interface {
 	// HTTPCode 设置 http 状态码
 	SetHTTPCode(code int)
 
-	// IP 获取真实IP
+	// IP 获取真实IP，直接使用 X-Real-IP, X-Forwarded-For，客户端可以伪造
+	// 用于限流、日志等需要可信 IP 的场景时使用 ClientIP
 	IP() string
 
+	// ClientIP 获取客户端 IP，只有直接连接的对端是受信任的代理时才使用 Forwarded, X-Forwarded-For, X-Real-IP
+	// 没有通过 App.SetTrustedProxies 设置受信任的代理时，返回直接连接的对端 IP
+	ClientIP() string
+
 	// IPs 获取 IP 数组，每经过一级代理(匿名代理除外)，代理服务器都会把这次请求的来源IP放到数组中
 	IPs() []string
 
@@ -156,9 +342,22 @@ type ContextBase interface {
 	// Host ..
 	Host() string
 
-	// IsAjax 判断是否是 ajax 请求
+	// IsAjax 判断是否是 ajax 请求，同 IsAJAX
 	IsAjax() bool
 
+	// IsAJAX 判断是否是 ajax 请求，依据 X-Requested-With 与 Sec-Fetch-*
+	IsAJAX() bool
+
+	// IsMobile 判断是否是移动设备，依据 Sec-CH-UA-Mobile 与 User-Agent
+	IsMobile() bool
+
+	// PrefersHTML 判断客户端是否更希望接收 HTML，例如浏览器直接访问
+	// 依据 Sec-Fetch-*、Accept 判断，ajax 请求返回 false
+	PrefersHTML() bool
+
+	// RequestID 获取请求 ID，由 requestid 中间件设置，没有设置时使用请求头 X-Request-ID 中的值
+	RequestID() string
+
 	// Referer HTTP Referer
 	Referer() string
 
@@ -168,6 +367,14 @@ type ContextBase interface {
 	// NotFound 路由未找到，设置 404
 	NotFound()
 
+	// ClientError 响应 4xx 错误，并按照 reason 分类记录到指标 http_client_errors_total 中
+	// reason: 错误原因，见 ReasonXXX，也可以使用自定义的原因
+	// message: 返回给客户端的错误信息
+	ClientError(httpCode int, reason string, message ...string)
+
+	// Error 停止继续向下调用，并将 err 交给 App 的错误处理函数
+	Error(err error)
+
 	// IsStopped 判断是否处于停止状态
 	// 比如 auth中间件判断未通过验证，就会调用 Stopped() 来停止继续向下调用
 	IsStopped() bool
@@ -180,6 +387,65 @@ type ContextBase interface {
 
 	// SetValue 设置对应的自定义值
 	SetValue(key string, value interface{})
+
+	// Copy 创建请求的只读快照，包括请求头、动态参数、自定义值、认证用户以及请求体
+	// 请求结束后 Context 不再可用，需要在后台 goroutine 中使用请求数据时，应该传递快照而不是 Context
+	// 请求体的读取受路由或 App 的 MaxBodyBytes 限制，读取成功后仍然可以通过 Request().Body 读取
+	// 读取失败时快照中没有请求体，错误见 Snapshot.Err，Request().Body 仍然返回原来的数据
+	Copy() Snapshot
+
+	// Now 获取当前时间，同 App().Now()
+	// 签发令牌、签名等依赖时间的逻辑应该使用此方法，而不是 time.Now，方便测试
+	Now() time.Time
+
+	// Rand 获取随机数来源，同 App().Rand()
+	// 生成令牌、随机数等应该从此读取，而不是直接使用 crypto/rand，方便测试
+	Rand() io.Reader
+}
+
+// Snapshot 请求的只读快照，见 ctx.Copy()
+type Snapshot interface {
+	// Method 请求方法
+	Method() string
+
+	// Path 请求路径，包括查询参数
+	Path() string
+
+	// Host ..
+	Host() string
+
+	// IP 获取客户端 IP，见 Context.ClientIP
+	IP() string
+
+	// Header 获取请求头的值
+	Header(key string) string
+
+	// Headers 获取所有请求头，返回副本
+	Headers() http.Header
+
+	// Query 获取查询参数的值
+	Query(key string) string
+
+	// Dynamic 获取动态参数的值
+	Dynamic(key string) string
+
+	// Value 获取自定义值，例如登录用户信息，值本身不会被复制
+	Value(key string) interface{}
+
+	// Body 请求体，返回副本
+	Body() []byte
+
+	// Err 读取请求体时的错误，例如超过限制时为 ErrBodyTooLarge
+	Err() error
+
+	// Principal 认证通过的用户，见 ValueKeyPrincipal，模拟其它用户时为被模拟的用户
+	Principal() string
+
+	// RealPrincipal 真实用户，模拟其它用户时为发起模拟的用户，见 ValueKeyRealPrincipal，否则同 Principal
+	RealPrincipal() string
+
+	// Time 创建快照的时间
+	Time() time.Time
 }
 
 // ContextHeader ..
@@ -197,6 +463,10 @@ type ContextHeader interface {
 
 	// DelHeader 移除响应中的 header
 	DelHeader(key string)
+
+	// SetRetryAfter 设置 Retry-After 响应头，单位为秒，向上取整，至少 1 秒
+	// jitter: 随机增加 [0, jitter) 的时长，避免被拒绝的客户端同时重试
+	SetRetryAfter(d time.Duration, jitter ...time.Duration)
 }
 
 // ContextQuery 包括 GET, POST, PUT
@@ -364,6 +634,64 @@ type ContextDynamic interface {
 
 	// SetDynamics 替换动态参数
 	SetDynamics(dynamics map[string]string)
+
+	// ParamInt 获取动态参数的值，并将结果转为 int
+	// 转换失败时返回 *BindError，交给 ctx.Error 或者由 E 包装的处理函数返回时响应 400
+	ParamInt(key string) (int, error)
+
+	// ParamInt64 获取动态参数的值，并将结果转为 int64，转换失败时返回 *BindError
+	ParamInt64(key string) (int64, error)
+
+	// ParamBool 获取动态参数的值，并将结果转为 bool，转换失败时返回 *BindError
+	ParamBool(key string) (bool, error)
+
+	// ParamUUID 获取动态参数的值，并检查是否为 UUID 格式，例如 123e4567-e89b-12d3-a456-426614174000
+	// 格式错误时返回 *BindError
+	ParamUUID(key string) (string, error)
+}
+
+// ContextBind 将请求参数绑定到结构体中
+type ContextBind interface {
+	// Bind 将请求参数解析到 dst 中，dst 必须是结构体指针
+	// 请求体根据 Content-Type 解析:
+	//   application/json 以及 +json 结尾的类型: 使用 json 标签
+	//   application/xml, text/xml 以及 +xml 结尾的类型: 使用 xml 标签
+	//   application/x-www-form-urlencoded, multipart/form-data: 使用 form 标签
+	// URL 中的参数使用 query 标签，无论哪种 Content-Type 都会解析
+	// 参数类型转换失败时返回 *BindError，请求体的 Content-Type 不是以上类型时返回 *UnsupportedMediaTypeError
+	// 示例:
+	// type User struct {
+	//     ID   int64    `query:"id"`
+	//     Name string   `json:"name" form:"name"`
+	//     Tags []string `form:"tag"`
+	// }
+	Bind(dst interface{}) error
+
+	// BindAndValidate 调用 Bind 后，根据 validate 标签验证 dst
+	// 内置规则: required, min, max, len, email, oneof，也可以使用 App.RegisterValidator 注册的规则
+	// 零值字段同样需要满足规则，可选字段使用 omitempty，值为 nil 的指针不验证
+	// 验证失败时返回 ValidationErrors
+	// 示例:
+	// type User struct {
+	//     Name  string `json:"name" validate:"required,min=3,max=20"`
+	//     Email string `json:"email" validate:"required,email"`
+	//     Role  string `json:"role" validate:"omitempty,oneof=admin user"`
+	// }
+	BindAndValidate(dst interface{}) error
+}
+
+// ContextWebSocket WebSocket 相关
+type ContextWebSocket interface {
+	// Upgrade 将当前连接升级为 WebSocket 连接，默认只允许同源请求
+	// 握手失败时已经写入错误响应
+	Upgrade() (WebSocket, error)
+}
+
+// ContextSSE Server-Sent Events 相关
+type ContextSSE interface {
+	// SSE 开始 Server-Sent Events 响应，设置响应头并立即推送给客户端
+	// heartbeat: 心跳间隔，客户端长时间没有收到数据时，代理服务器可能会断开连接，默认 15 秒，<= 0 表示不发送心跳
+	SSE(heartbeat ...time.Duration) (EventStream, error)
 }
 
 // ContextFile 文件相关
@@ -375,6 +703,24 @@ type ContextFile interface {
 	// cbs 在存盘前修改 multipart.FileHeader
 	Files(destDirectory string, cbs ...func(Context, *multipart.FileHeader)) (int64, error)
 
+	// FormFile 获取上传文件信息，文件超过 App.FileMaxMemory 的部分保存在临时文件中
+	FormFile(name string) (*multipart.FileHeader, error)
+
+	// FormFiles 获取同一个字段的多个上传文件信息
+	FormFiles(name string) ([]*multipart.FileHeader, error)
+
+	// SaveUploadedFile 将上传文件保存到 dst，dst 所在的目录不存在时自动创建
+	SaveUploadedFile(fh *multipart.FileHeader, dst string) error
+
+	// StreamFiles 流式读取 multipart/form-data，每个文件调用一次 fn，文件内容不会缓存在内存或者临时文件中
+	// 普通字段保存在 Request().PostForm 中，之后可以通过 ctx.Post 获取，只能获取 fn 调用之前的字段
+	// 与 FormFile, FormFiles, File, Files 不能同时使用
+	StreamFiles(fn func(part *multipart.Part) error) error
+
+	// SetMaxBodySize 限制请求体大小，读取超过 n 字节时返回 ErrBodyTooLarge
+	// 还没有读取请求体时再次调用会替换之前的限制
+	SetMaxBodySize(n int64)
+
 	// DownloadFile 下载文件
 	// path 文件路径
 	// filename 文件名称
@@ -395,12 +741,33 @@ type ContextWrite interface {
 	// Map map 转 text
 	Map(obj interface{}) (int, error)
 
-	// JSON 将数据转为 JSON 格式写入响应
+	// JSON 将数据转为 JSON 格式写入响应，使用 App 的 JSONCodec 直接编码到响应中
 	JSON(obj interface{}) (int, error)
 
-	// XML 将数据转为 XML 格式写入响应
+	// JSONWithCode 设置状态码，并将数据转为 JSON 格式写入响应
+	JSONWithCode(httpCode int, obj interface{}) (int, error)
+
+	// JSONPretty 将数据转为带缩进的 JSON 格式写入响应
+	// indent: 缩进，默认两个空格
+	JSONPretty(obj interface{}, indent ...string) (int, error)
+
+	// JSONP 将数据转为 JSONP 格式写入响应，例如 callback({...});
+	// callback: 回调函数名称，只能包含字母、数字、_、$ 以及 .，否则返回 ErrInvalidCallback
+	JSONP(callback string, obj interface{}) (int, error)
+
+	// XML 将数据转为 XML 格式写入响应，使用 SerializerXML
 	XML(obj interface{}) (int, error)
 
+	// YAML 将数据转为 YAML 格式写入响应，使用 SerializerYAML
+	YAML(obj interface{}) (int, error)
+
+	// MsgPack 将数据转为 MessagePack 格式写入响应，使用 SerializerMsgPack
+	MsgPack(obj interface{}) (int, error)
+
+	// Serialize 使用 App.RegisterSerializer 注册的序列化写入响应，并设置 Content-Type
+	// 不存在时返回 ErrSerializerNotFound
+	Serialize(name string, obj interface{}) (int, error)
+
 	// HTML 发送 html 响应
 	HTML(html string) (int, error)
 
@@ -424,12 +791,16 @@ type ContextWrite interface {
 	// Push HTTP/2 服务器推送
 	Push(value string, opts *http.PushOptions) error
 
+	// PushAll HTTP/2 服务器推送多个资源，例如 PushAll("/app.js", "/app.css")
+	// 不支持服务器推送时(HTTP/1.x，h2c)返回 http.ErrNotSupported
+	PushAll(targets ...string) error
+
 	// AutoContentType 根据给定的文件类型，自动设置 Content-Type
 	// .json -> app/json
 	// fileExt: 文件后缀名，例如 .json
 	AutoContentType(fileExt string)
 
-	// Message 传递 {"code": xx, "message": xxx}
+	// Message 传递 {"code": xx, "message": xxx}，有警告时包含 "warnings": [{"code": xx, "message": xxx}]
 	Message(code int, message ...string) (int, error)
 }
 
@@ -450,6 +821,7 @@ type ContextCookie interface {
 	// path: 见 https://tools.ietf.org/html/rfc6265#section-4.1.2.4
 	// secure: 见 https://tools.ietf.org/html/rfc6265#section-4.1.2.5
 	// httpOnly: 见 https://tools.ietf.org/html/rfc6265#section-4.1.2.6
+	// 选项返回错误时不设置 cookie，并记录错误日志
 	SetCookie(key, value string, opts ...CookieOption)
 
 	// RemoveCookie 移除指定的 cookie
@@ -460,6 +832,21 @@ type ContextCookie interface {
 
 	// HTTPCookies 获取所有原始的 cookie
 	HTTPCookies() []*http.Cookie
+
+	// ResponseCookie 获取本次请求中已设置的 cookie，不存在时返回 nil
+	// cookie 在写入响应头之前才会写入，所以后续中间件可以查看或者通过 SetCookie 覆盖之前设置的 cookie
+	ResponseCookie(name string) *http.Cookie
+
+	// ResponseCookies 获取本次请求中已设置的所有 cookie
+	ResponseCookies() []*http.Cookie
+
+	// SetCookieObject 将 map 或者结构体编码后保存在 cookie 中
+	// 编码后超过单个 cookie 大小限制(4KB)时，拆分为 name.0, name.1 ... 多个 cookie
+	// 超过最大分片数量时返回 ErrCookieTooLarge
+	SetCookieObject(name string, obj interface{}, opts ...CookieOption) error
+
+	// CookieObject 读取 SetCookieObject 保存的 cookie，合并分片后解码到 obj 中
+	CookieObject(name string, obj interface{}, opts ...CookieOption) error
 }
 
 // ContextHook 钩子，一般用于中间件中
@@ -479,13 +866,354 @@ type ContextHook interface {
 	RunEnd()
 }
 
+// ContextCost 请求成本，限流时成本高的请求消耗更多的令牌
+type ContextCost interface {
+	// ChargeCost 增加本次请求的成本，例如按照导出的行数计费
+	// 在限流中间件之前记录的成本在请求开始时扣除，之后记录的成本在请求结束后扣除
+	ChargeCost(n int)
+
+	// Cost 本次请求的成本，没有记录时为 0，限流中间件按照 1 计算
+	Cost() int
+}
+
+// ContextStd 请求的 context.Context，用于将取消与超时传递给数据库、RPC 等调用
+type ContextStd interface {
+	// Context 请求的 context.Context，客户端断开连接或者超时中间件超时后被取消
+	// 示例: db.QueryContext(ctx.Context(), query)
+	Context() context.Context
+
+	// SetContext 替换请求的 context.Context，例如中间件设置超时，注入链路信息
+	SetContext(c context.Context)
+
+	// WithValue 在请求的 context.Context 中添加值，通过 ctx.Context().Value(key) 获取
+	// 与 SetValue 不同，这些值会随着 ctx.Context() 传递给下游调用
+	WithValue(key, value interface{})
+
+	// Deadline 请求的截止时间，没有设置时 ok 为 false
+	Deadline() (deadline time.Time, ok bool)
+}
+
+// ContextMetric 业务指标
+type ContextMetric interface {
+	// Metric 获取名称为 name 的计数器，用于记录业务指标
+	// 示例: ctx.Metric("orders_created").Add(1, "channel", "app")
+	Metric(name string) Metric
+}
+
+// ContextFlash 一次性消息，用于 post-redirect-get，例如提交表单后重定向，在新页面显示 "保存成功"
+type ContextFlash interface {
+	// Flash 添加一条消息，在之后的请求中通过 Flashes 读取，需要在写入响应头之前调用
+	// 示例: ctx.Flash("success", "保存成功"); ctx.Redirect(http.StatusSeeOther, "/posts")
+	Flash(key, message string)
+
+	// Flashes 读取之前的请求添加的所有消息，读取后删除，之后的请求不会再读取到
+	// 需要在写入响应头之前调用，没有消息时返回空 map
+	Flashes() map[string][]string
+}
+
+// ContextWarning 不影响请求继续处理的警告，多个中间件都可以添加，例如使用了已废弃的参数，缓存不可用时降级
+// 警告在写入响应头时作为 X-Warning 响应头发送(每条一个值)，出现在 Message 与错误响应的 warnings 字段中，并在请求结束时记录日志
+type ContextWarning interface {
+	// AddWarning 添加一条警告，code 为机器可读的代码，message 为说明
+	// 示例: ctx.AddWarning("deprecated_param", "page_size is deprecated, use limit")
+	AddWarning(code, message string)
+
+	// Warnings 本次请求添加的所有警告，按照添加的顺序
+	Warnings() []Warning
+}
+
+// ContextRoute 请求匹配的路由，日志、指标、链路追踪使用路由模板作为标签，避免每个 URL 一个标签
+type ContextRoute interface {
+	// RoutePath 匹配的路由路径，例如 /blog/:id/name，路由不存在或者尚未匹配时为空
+	RoutePath() string
+
+	// RouteName 匹配的路由名称，见 RouteMiddleware.Name，没有设置时为空
+	RouteName() string
+
+	// Route 匹配的路由，包括超时时间、自定义信息，路由不存在或者尚未匹配时返回 nil
+	Route() *RouteInfo
+
+	// SetRoute 设置匹配的路由，由框架在查找到路由之后、执行中间件之前调用
+	// 可以与 RoutePath 并发调用，例如优雅关闭时读取未完成请求的路由
+	SetRoute(route *RouteInfo)
+}
+
 // Writer 实现 http.ResponseWriter
 type Writer interface {
 	http.ResponseWriter
 
+	// Writer 原始的 http.ResponseWriter，直接写入时不会执行 BeforeWriteHeader 注册的函数
 	Writer() http.ResponseWriter
 
+	// SetWriter 替换原始的 http.ResponseWriter，已注册的函数与写入状态保持不变
+	// 例如压缩中间件包装原始的 http.ResponseWriter
 	SetWriter(w http.ResponseWriter)
+
+	// BeforeWriteHeader 注册写入响应头之前执行的函数，例如写入 cookie
+	BeforeWriteHeader(fn func())
+
+	// BeforeFinish 注册响应结束之前执行的函数，无论处理过程中是否中断或者异常都会执行
+	// 先入后出顺序执行，例如压缩中间件在此写入剩余的压缩数据
+	BeforeFinish(fn func())
+
+	// Written 是否已经写入响应头
+	// 重复写入响应头时不会调用原始的 WriteHeader，而是记录调用位置，debug 日志级别下同时记录第一次写入的位置
+	Written() bool
+
+	// Status 已写入的状态码，没有写入时为 0
+	Status() int
+
+	// Size 通过 Write 写入的响应内容大小，包括直接调用 Write 写入的内容，例如反向代理
+	// 压缩等中间件替换了原始的 http.ResponseWriter 时，为压缩前的大小
+	Size() int64
+
+	// PrepareHeader 如果还没有写入响应头，执行 BeforeWriteHeader 注册的函数，只会执行一次
+	// 处理函数没有写入任何数据时，由 net/http 在返回后写入响应头，所以请求结束时需要调用
+	PrepareHeader()
+
+	// Finish 标记响应已经结束，之后的写入会返回 ErrResponseFinished，并记录写入的位置
+	Finish()
+
+	// Finished 响应是否已经结束
+	Finished() bool
+}
+
+// Serializer 响应序列化
+type Serializer interface {
+	// ContentType 响应的 Content-Type
+	ContentType() string
+
+	// Encode 序列化并写入 w
+	Encode(w io.Writer, v interface{}) error
+}
+
+// JSONCodec JSON 编码与解码，默认使用 encoding/json，可以替换为 jsoniter, sonic 等
+type JSONCodec interface {
+	// Marshal 编码
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal 解码
+	Unmarshal(data []byte, v interface{}) error
+
+	// NewEncoder 创建流式编码器，直接写入 w，避免中间缓冲
+	NewEncoder(w io.Writer) JSONEncoder
+
+	// NewDecoder 创建流式解码器
+	NewDecoder(r io.Reader) JSONDecoder
+}
+
+// JSONEncoder JSON 流式编码器，*json.Encoder 实现了该接口
+type JSONEncoder interface {
+	// Encode 编码并写入，末尾会添加换行符
+	Encode(v interface{}) error
+
+	// SetIndent 设置缩进
+	SetIndent(prefix, indent string)
+}
+
+// JSONDecoder JSON 流式解码器，*json.Decoder 实现了该接口
+type JSONDecoder interface {
+	// Decode 读取并解码
+	Decode(v interface{}) error
+}
+
+// WebSocket 一个 WebSocket 连接，见 websocket 包
+type WebSocket interface {
+	// ReadMessage 读取一条完整的消息，自动回复 ping，收到关闭帧时返回错误
+	// messageType: 1 文本消息，2 二进制消息
+	ReadMessage() (messageType int, data []byte, err error)
+
+	// WriteMessage 发送一条消息，可以在多个 goroutine 中调用
+	WriteMessage(messageType int, data []byte) error
+
+	// SetReadDeadline 设置读超时
+	SetReadDeadline(t time.Time) error
+
+	// SetWriteDeadline 设置写超时
+	SetWriteDeadline(t time.Time) error
+
+	// Subprotocol 握手时协商的子协议
+	Subprotocol() string
+
+	// RemoteAddr 对方地址
+	RemoteAddr() net.Addr
+
+	// Close 发送关闭帧并关闭连接
+	Close() error
+}
+
+// EventStream Server-Sent Events 数据流，见 https://html.spec.whatwg.org/multipage/server-sent-events.html
+type EventStream interface {
+	// Send 发送一个事件并立即推送，event 为空时客户端触发 message 事件
+	// data: string, []byte 原样发送，其它类型转为 JSON 发送
+	Send(event string, data interface{}) error
+
+	// SendWithID 发送一个带 id 的事件，客户端重连时会在 Last-Event-ID 请求头中带上最后收到的 id
+	SendWithID(id, event string, data interface{}) error
+
+	// Retry 通知客户端断线后的重连间隔
+	Retry(retry time.Duration) error
+
+	// Done 客户端断开连接或者调用 Close 后关闭
+	Done() <-chan struct{}
+
+	// Close 停止心跳，之后的 Send 都会失败
+	Close()
+}
+
+// Cache 进程内缓存，超过容量时淘汰最近最少使用的缓存，支持过期时间
+type Cache interface {
+	// Get 获取缓存，不存在或者已过期时 ok 为 false
+	Get(key string) (value interface{}, ok bool)
+
+	// Set 设置缓存，ttl <= 0 时不过期
+	Set(key string, value interface{}, ttl time.Duration)
+
+	// Delete 删除缓存
+	Delete(key string)
+
+	// GetOrLoad 获取缓存，不存在时调用 load 加载并缓存 ttl
+	// 同一个 key 同时只会调用一次 load，其它调用等待并共享结果，load 返回错误时不缓存
+	GetOrLoad(key string, ttl time.Duration, load func() (interface{}, error)) (interface{}, error)
+
+	// Len 缓存数量，包括已过期但还没有被清理的缓存
+	Len() int
+}
+
+// FlashStore 一次性消息存储，见 ctx.Flash
+type FlashStore interface {
+	// Load 读取保存的消息，没有消息时返回 nil
+	Load(ctx Context) (map[string][]string, error)
+
+	// Save 保存消息，flashes 为空时删除
+	Save(ctx Context, flashes map[string][]string) error
+}
+
+// ProxyBalancer 反向代理在多个上游之间选择，见 proxy.Pool
+type ProxyBalancer interface {
+	// Pick 为请求选择上游地址，没有可用的上游时返回 nil
+	Pick(ctx Context) *url.URL
+}
+
+// EventBus 进程内事件总线，按主题发布与订阅事件
+type EventBus interface {
+	// Publish 发布事件，在当前 goroutine 中依次调用订阅者，订阅者不应阻塞
+	Publish(topic string, data interface{})
+
+	// Subscribe 订阅主题，返回取消订阅的函数
+	// topic: "*" 匹配所有主题，以 ".*" 结尾时匹配该前缀下的主题，例如 "order.*" 匹配 "order.paid"
+	Subscribe(topic string, handler EventHandler) (unsubscribe func())
+}
+
+// Metrics 指标管理器
+type Metrics interface {
+	// Counter 获取名称为 name 的计数器，不存在时创建
+	Counter(name string) Metric
+
+	// Histogram 获取名称为 name 的直方图，不存在时创建
+	// buckets: 各个桶的上限，从小到大排列，只在创建时使用
+	Histogram(name string, buckets []float64) Histogram
+
+	// Gauge 获取名称为 name 的仪表，不存在时创建，例如正在处理的请求数
+	Gauge(name string) Gauge
+
+	// Gather 获取所有指标的当前值，按照名称，标签排序
+	Gather() []MetricSample
+}
+
+// Metric 指标
+type Metric interface {
+	// Add 累加 v
+	// labels: 标签键值对，例如 Add(1, "status", "paid", "channel", "app")
+	Add(v float64, labels ...string)
+}
+
+// Gauge 仪表，可以增加、减少或者直接设置的值
+type Gauge interface {
+	// Add 累加 v，v 可以为负数
+	// labels: 标签键值对
+	Add(v float64, labels ...string)
+
+	// Set 设置为 v
+	// labels: 标签键值对
+	Set(v float64, labels ...string)
+}
+
+// Histogram 直方图，记录数值的分布，例如响应耗时，响应大小
+type Histogram interface {
+	// Observe 记录一个值
+	// labels: 标签键值对
+	Observe(v float64, labels ...string)
+}
+
+// ExemplarHistogram 支持 exemplar 的直方图，App.Metrics() 创建的直方图都实现了该接口
+// exemplar 关联到值所在的桶，以 OpenMetrics 格式导出，例如从耗时的峰值跳转到一个对应的链路
+type ExemplarHistogram interface {
+	Histogram
+
+	// ObserveWithExemplar 记录一个值，同时记录 exemplar
+	// exemplar: exemplar 的标签键值对，例如 []string{"trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"}
+	// labels: 标签键值对
+	ObserveWithExemplar(v float64, exemplar []string, labels ...string)
+}
+
+const (
+	// MetricTypeCounter 计数器
+	MetricTypeCounter = "counter"
+
+	// MetricTypeHistogram 直方图
+	MetricTypeHistogram = "histogram"
+
+	// MetricTypeGauge 仪表
+	MetricTypeGauge = "gauge"
+)
+
+// MetricSample 指标的一个采样值
+type MetricSample struct {
+	// Name 指标名称
+	Name string
+
+	// Type 指标类型，MetricTypeCounter, MetricTypeGauge 或者 MetricTypeHistogram
+	Type string
+
+	// Labels 标签键值对，按照键排序
+	Labels []string
+
+	// Value 计数器、仪表的当前值，直方图为所有值的和
+	Value float64
+
+	// Count 直方图记录的值的数量
+	Count uint64
+
+	// Buckets 直方图各个桶的累计数量
+	Buckets []MetricBucket
+
+	// InfExemplar 直方图 +Inf 桶中最近的 exemplar，可能为 nil
+	InfExemplar *MetricExemplar
+}
+
+// MetricBucket 直方图的一个桶
+type MetricBucket struct {
+	// UpperBound 桶的上限
+	UpperBound float64
+
+	// Count 小于等于上限的值的数量
+	Count uint64
+
+	// Exemplar 落在该桶中的最近的 exemplar，可能为 nil
+	Exemplar *MetricExemplar
+}
+
+// MetricExemplar 直方图中的一个示例值，例如一次请求的耗时以及对应的链路 ID
+type MetricExemplar struct {
+	// Labels 标签键值对，例如 trace_id
+	Labels []string
+
+	// Value 记录的值
+	Value float64
+
+	// Timestamp 记录的时间
+	Timestamp time.Time
 }
 
 // Server http 服务器
@@ -503,6 +1231,30 @@ type Server interface {
 	// certFile: 证书路径
 	// keyFile: 私钥路径
 	SetTLS(certFile, keyFile string) bool
+
+	// StartAutoTLS 使用证书管理器获取证书，启动 https 服务
+	// redirectAddr: 不为空时同时在该地址启动 http 服务，处理 HTTP-01 验证请求，其它请求重定向到 https
+	StartAutoTLS(addr, redirectAddr string, manager CertManager) error
+
+	// Serve 同时在多个监听上接收连接请求，例如 TCP, unix socket, systemd socket activation 传入的监听
+	// 任意一个监听出错时关闭其它监听并返回该错误，通过 Shutdown 关闭时返回 http.ErrServerClosed
+	// 通过 SetTLS 指定证书时使用 https，由热重启启动时开始接收连接后通知父进程
+	Serve(listeners ...net.Listener) error
+
+	// Shutdown 优雅关闭通过 Start, Serve, StartAutoTLS 启动的所有 http 服务，等待正在处理的请求完成，或者 ctx 结束
+	Shutdown(ctx context.Context) error
+
+	// ShutdownReport 最近一次关闭的报告，包括通过 HTTPServer().Shutdown 关闭 Start 启动的服务，没有关闭过时返回 nil
+	ShutdownReport() *ShutdownReport
+}
+
+// CertManager 证书管理器，golang.org/x/crypto/acme/autocert.Manager 实现了该接口
+type CertManager interface {
+	// GetCertificate 根据 TLS 握手信息获取证书，用于 tls.Config.GetCertificate
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+
+	// HTTPHandler 处理 HTTP-01 验证请求，其它请求交给 fallback
+	HTTPHandler(fallback http.Handler) http.Handler
 }
 
 // Router 路由管理器
@@ -518,24 +1270,66 @@ type Router interface {
 	// handles: 处理函数和路由级别中间件，匹配成功后会调用该函数
 	Register(method, path string, handlers ...Handler) bool
 
+	// RegisterRoute 注册路由，与 Register 相同，同时保存路由信息，route.Method 与 route.Path 不能为空
+	RegisterRoute(route RouteInfo, handlers ...Handler) bool
+
 	// Build 解析路由，包括动态参数，正则表达式，验证函数
+	// 存在重复注册或者永远不会被匹配的路由时记录日志，使用 router.WithStrictRoutes 时返回 false
 	Build() bool
 
 	// Lookup 查找路由
 	Lookup(method, path string) ([]Handler, map[string]string)
 
+	// LookupRoute 查找路由，同时返回注册时的路由信息，中间件通过 ctx.Route() 读取，不需要再次查找
+	// 没有找到时都返回 nil
+	LookupRoute(method, path string) (*RouteInfo, []Handler, map[string]string)
+
+	// MissReason 分析 Lookup 失败的原因，返回 ReasonNoRoute 或者 ReasonValidatorFailed
+	MissReason(method, path string) string
+
+	// RedirectPath 路由不存在时，查找应该重定向的路径，例如去掉结尾的 /，修正大小写
+	RedirectPath(method, path string) (string, bool)
+
+	// AllowedMethods 获取可以匹配 path 的请求方法，按照 AllMethods 的顺序返回，没有时返回 nil
+	AllowedMethods(path string) []string
+
 	// RegisterRouterValidator 注册路由验证函数
 	RegisterRouterValidator(name string, validator RouterValidator)
 
 	// Validator 获取路由验证函数
 	Validator(name string) RouterValidator
+
+	// RegisterConstraint 注册路由参数约束，同名时覆盖框架自带的约束
+	// 示例: /user/:id<int,min(1)>，多个约束使用 "," 分隔
+	RegisterConstraint(name string, constraint RouterConstraint)
+
+	// Constraint 获取通过 RegisterConstraint 注册的路由参数约束
+	Constraint(name string) RouterConstraint
+
+	// Host 获取只匹配该主机名的路由，不存在时创建，与当前路由共享验证函数和参数约束
+	// pattern: 例如 api.example.com，*.example.com(子域名保存在动态参数 subdomain 中)，:tenant.example.com
+	Host(pattern string) Router
+
+	// MatchHost 根据请求的主机名查找通过 Host 创建的路由，没有匹配时返回自身
+	// 通配符匹配时同时返回子域名参数
+	MatchHost(host string) (Router, map[string]string)
 }
 
 // Group 组路由，相同前缀的一组路由，共享相同的中间件
 type Group interface {
-	// Use 添加 Group 级别 中间件
+	// Use 添加 Group 级别 中间件，在 App 级别中间件之后执行
 	Use(handlers ...Handler) Group
 
+	// UseBefore 添加 Group 级别 中间件，在 App 级别中间件之前执行
+	UseBefore(handlers ...Handler) Group
+
+	// SkipGlobal 组内路由跳过 App 级别中间件，例如健康检查跳过鉴权
+	SkipGlobal() Group
+
+	// Handle 注册路由，并控制路由级别中间件与 App 级别中间件的执行顺序
+	// Group 级别的 UseBefore 中间件在 m.Before 之前执行
+	Handle(method, path string, m RouteMiddleware, handlers ...Handler) Group
+
 	// Get method = "GET"
 	Get(path string, handlers ...Handler) Group
 
@@ -556,6 +1350,9 @@ type Group interface {
 
 	// Options method = "OPTIONS"
 	Options(path string, handlers ...Handler) Group
+
+	// WS WebSocket 路由，method = "GET"
+	WS(path string, handler WebSocketHandler, middlewares ...Handler) Group
 }
 
 // RouteNode 一颗基数树的一个节点
@@ -0,0 +1,220 @@
+// Package jsonschema 使用 JSON Schema 验证请求体，适用于在 Go 结构体之外维护 schema 的场景
+//
+// 支持的关键字:
+// type, enum, const, $ref, $defs, definitions
+// allOf, anyOf, oneOf, not, if, then, else
+// properties, required, additionalProperties, patternProperties, propertyNames, minProperties, maxProperties, dependentRequired
+// items, prefixItems, additionalItems, contains, minItems, maxItems, uniqueItems
+// minLength, maxLength, pattern, format(email, date-time, date, time, uri, uuid, ipv4, ipv6)
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf
+// 不认识的关键字和 format 会被忽略，$ref 只支持当前文档内以 "#" 开头的引用
+//
+// 示例:
+// app.Post("/users", jsonschema.New(userSchema), createUser)
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// Schema 编译好的 JSON Schema，可以并发使用
+type Schema struct {
+	root interface{}
+
+	// patterns 编译好的 pattern 与 patternProperties
+	patterns map[string]*regexp.Regexp
+}
+
+// Compile 解析 JSON Schema，检查正则表达式与 $ref 是否有效
+func Compile(schemaJSON []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(schemaJSON, &root); err != nil {
+		return nil, fmt.Errorf("jsonschema: %v", err)
+	}
+
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compile(root, "#"); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// MustCompile 解析 JSON Schema，失败时 panic，一般用于注册路由时
+func MustCompile(schemaJSON []byte) *Schema {
+	s, err := Compile(schemaJSON)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Validate 验证 JSON 数据，验证失败时返回 zeroapi.ValidationErrors
+// FieldError.Field 为出错位置的 JSON Pointer，例如 /items/0/name，Rule 为未通过的关键字
+func (s *Schema) Validate(data []byte) error {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	if d.More() {
+		return errors.New("jsonschema: invalid JSON, unexpected data after top-level value")
+	}
+
+	return s.ValidateValue(v)
+}
+
+// ValidateValue 验证 json.Unmarshal 解析得到的值
+func (s *Schema) ValidateValue(v interface{}) error {
+	var errs zeroapi.ValidationErrors
+	s.validate(s.root, v, "", &errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// schemaKeywords 值为 schema 的关键字
+var schemaKeywords = []string{"additionalProperties", "additionalItems", "items", "contains", "propertyNames", "not", "if", "then", "else"}
+
+// schemaMapKeywords 值为 name -> schema 的关键字
+var schemaMapKeywords = []string{"properties", "patternProperties", "$defs", "definitions", "dependentSchemas"}
+
+// schemaListKeywords 值为 schema 数组的关键字
+var schemaListKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+
+// compile 遍历 schema，编译正则表达式，检查引用
+func (s *Schema) compile(schema interface{}, location string) error {
+	m, ok := schema.(map[string]interface{})
+	if !ok {
+		if _, ok := schema.(bool); ok {
+			return nil
+		}
+		return fmt.Errorf("jsonschema: %s: schema must be an object or boolean", location)
+	}
+
+	if pattern, ok := m["pattern"].(string); ok {
+		if err := s.compilePattern(pattern, location+"/pattern"); err != nil {
+			return err
+		}
+	}
+
+	if ref, ok := m["$ref"].(string); ok {
+		if _, err := s.resolve(ref); err != nil {
+			return fmt.Errorf("jsonschema: %s: %v", location, err)
+		}
+	}
+
+	for _, keyword := range schemaKeywords {
+		sub, ok := m[keyword]
+		if !ok {
+			continue
+		}
+		if list, ok := sub.([]interface{}); ok && keyword == "items" {
+			for i, item := range list {
+				if err := s.compile(item, location+"/items/"+strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := s.compile(sub, location+"/"+keyword); err != nil {
+			return err
+		}
+	}
+
+	for _, keyword := range schemaMapKeywords {
+		subs, ok := m[keyword].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name, sub := range subs {
+			if keyword == "patternProperties" {
+				if err := s.compilePattern(name, location+"/patternProperties"); err != nil {
+					return err
+				}
+			}
+			if err := s.compile(sub, location+"/"+keyword+"/"+escape(name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, keyword := range schemaListKeywords {
+		subs, ok := m[keyword].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, sub := range subs {
+			if err := s.compile(sub, location+"/"+keyword+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) compilePattern(pattern, location string) error {
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("jsonschema: %s: %v", location, err)
+	}
+	s.patterns[pattern] = re
+
+	return nil
+}
+
+// resolve 查找当前文档内的引用，例如 #/$defs/address
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+
+	current := s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %q not found", ref)
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("$ref %q not found", ref)
+			}
+			current = v[i]
+		default:
+			return nil, fmt.Errorf("$ref %q not found", ref)
+		}
+	}
+
+	return current, nil
+}
+
+// escape 按照 JSON Pointer 规则转义
+func escape(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
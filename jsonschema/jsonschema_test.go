@@ -0,0 +1,146 @@
+package jsonschema_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/jsonschema"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["name", "email"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+		"email": {"type": "string", "format": "email"},
+		"age": {"type": "integer", "minimum": 0, "maximum": 150},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+		"address": {"$ref": "#/$defs/address"},
+		"role": {"enum": ["admin", "user"]}
+	},
+	"$defs": {
+		"address": {
+			"type": "object",
+			"required": ["city"],
+			"properties": {"city": {"type": "string"}}
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	s := jsonschema.MustCompile([]byte(userSchema))
+
+	if err := s.Validate([]byte(`{"name":"yaha","email":"a@b.com","age":18,"tags":["a"],"address":{"city":"sz"},"role":"admin"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.Validate([]byte(`{"name":"Y","age":1.5,"tags":["a","a"],"address":{},"role":"root","extra":1}`))
+	errs, ok := err.(zeroapi.ValidationErrors)
+	if !ok {
+		t.Fatalf("expect ValidationErrors, got %v", err)
+	}
+
+	want := map[string]string{
+		"/email":        "required",
+		"/name":         "pattern",
+		"/age":          "type",
+		"/tags/1":       "uniqueItems",
+		"/address/city": "required",
+		"/role":         "enum",
+		"/extra":        "additionalProperties",
+	}
+	got := make(map[string]bool)
+	for _, fe := range errs {
+		got[fe.Field+" "+fe.Rule] = true
+	}
+	for field, rule := range want {
+		if !got[field+" "+rule] {
+			t.Fatalf("%s: expect %s, errors: %v", field, rule, errs)
+		}
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	s := jsonschema.MustCompile([]byte(`{
+		"oneOf": [{"type": "integer"}, {"type": "string", "maxLength": 3}],
+		"not": {"const": "bad"}
+	}`))
+
+	tests := []struct {
+		data  string
+		valid bool
+	}{
+		{`1`, true},
+		{`"abc"`, true},
+		{`"abcd"`, false},
+		{`"bad"`, false},
+		{`1.5`, false},
+	}
+
+	for _, tt := range tests {
+		if err := s.Validate([]byte(tt.data)); (err == nil) != tt.valid {
+			t.Fatalf("%s: %v", tt.data, err)
+		}
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, schema := range []string{
+		`{"type": "string", "pattern": "("}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"properties": {"a": 1}}`,
+		`{`,
+	} {
+		if _, err := jsonschema.Compile([]byte(schema)); err == nil {
+			t.Fatalf("expect error: %s", schema)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a := app.NewApp()
+	var name string
+	a.Post("/users", jsonschema.New([]byte(userSchema)), func(ctx zeroapi.Context) {
+		var user struct {
+			Name string `json:"name"`
+		}
+		if err := ctx.Bind(&user); err != nil {
+			ctx.Error(err)
+			return
+		}
+		name = user.Name
+	})
+	a.Router().Build()
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"name":"yaha","email":"a@b.com"}`, http.StatusOK},
+		{`{"name":"yaha"}`, http.StatusUnprocessableEntity},
+		{`{"name":`, http.StatusBadRequest},
+		{``, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, req)
+
+		if w.Code != tt.code {
+			body, _ := ioutil.ReadAll(w.Body)
+			t.Fatalf("%q: expect %d, got %d: %s", tt.body, tt.code, w.Code, body)
+		}
+	}
+
+	if name != "yaha" {
+		t.Fatalf("body is not readable after validation: %q", name)
+	}
+}
@@ -0,0 +1,55 @@
+package jsonschema
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// New 创建请求体验证中间件，schema 无效时 panic
+// 请求体不是合法的 JSON 时响应 400，验证失败时将 zeroapi.ValidationErrors 交给 ctx.Error，默认响应 422
+// 验证后请求体可以再次读取，处理函数中可以继续使用 ctx.Bind
+func New(schemaJSON []byte) zeroapi.Handler {
+	return Handler(MustCompile(schemaJSON))
+}
+
+// Handler 使用编译好的 Schema 创建请求体验证中间件
+func Handler(s *Schema) zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		req := ctx.Request()
+
+		var body []byte
+		if req.Body != nil && req.Body != http.NoBody {
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				if err.Error() == "http: request body too large" {
+					ctx.Error(zeroapi.ErrBodyTooLarge)
+				} else {
+					ctx.Error(zeroapi.NewHTTPError(http.StatusBadRequest).Wrap(err))
+				}
+				return
+			}
+			body = b
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		if len(bytes.TrimSpace(body)) == 0 {
+			ctx.Error(zeroapi.NewHTTPError(http.StatusBadRequest, "REQUEST BODY IS REQUIRED"))
+			return
+		}
+
+		err := s.Validate(body)
+		if err == nil {
+			return
+		}
+
+		if errs, ok := err.(zeroapi.ValidationErrors); ok {
+			ctx.Error(errs)
+			return
+		}
+
+		ctx.Error(zeroapi.NewHTTPError(http.StatusBadRequest, "INVALID JSON").Wrap(err))
+	}
+}
@@ -0,0 +1,434 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// maxRefDepth $ref 最大嵌套层数，防止循环引用
+	maxRefDepth = 64
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validate 使用 schema 验证 v，pointer 为 v 的位置
+func (s *Schema) validate(schema interface{}, v interface{}, pointer string, errs *zeroapi.ValidationErrors) {
+	s.validateDepth(schema, v, pointer, 0, errs)
+}
+
+func (s *Schema) validateDepth(schema interface{}, v interface{}, pointer string, depth int, errs *zeroapi.ValidationErrors) {
+	if b, ok := schema.(bool); ok {
+		if !b {
+			addError(errs, pointer, "false", "", "%s is not allowed", name(pointer))
+		}
+		return
+	}
+
+	m, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if ref, ok := m["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			addError(errs, pointer, "$ref", ref, "%s exceeds the maximum $ref depth", name(pointer))
+			return
+		}
+		if target, err := s.resolve(ref); err == nil {
+			s.validateDepth(target, v, pointer, depth+1, errs)
+		}
+	}
+
+	s.validateGeneric(m, v, pointer, depth, errs)
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		s.validateObject(m, value, pointer, depth, errs)
+	case []interface{}:
+		s.validateArray(m, value, pointer, depth, errs)
+	case string:
+		s.validateString(m, value, pointer, errs)
+	case float64:
+		validateNumber(m, value, pointer, errs)
+	}
+}
+
+// valid 验证 v 是否满足 schema，不记录错误
+func (s *Schema) valid(schema interface{}, v interface{}, depth int) bool {
+	var errs zeroapi.ValidationErrors
+	s.validateDepth(schema, v, "", depth, &errs)
+	return len(errs) == 0
+}
+
+// validateGeneric 与类型无关的关键字
+func (s *Schema) validateGeneric(m map[string]interface{}, v interface{}, pointer string, depth int, errs *zeroapi.ValidationErrors) {
+	if t, ok := m["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []interface{}:
+			for _, item := range t {
+				if s, ok := item.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+
+		matched := false
+		for _, t := range types {
+			if isType(v, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched && len(types) > 0 {
+			param := strings.Join(types, ",")
+			addError(errs, pointer, "type", param, "%s must be of type %s", name(pointer), strings.Join(types, " or "))
+		}
+	}
+
+	if enum, ok := m["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range enum {
+			if reflect.DeepEqual(item, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addError(errs, pointer, "enum", "", "%s must be one of the allowed values", name(pointer))
+		}
+	}
+
+	if c, ok := m["const"]; ok && !reflect.DeepEqual(c, v) {
+		addError(errs, pointer, "const", "", "%s must be equal to the constant value", name(pointer))
+	}
+
+	if subs, ok := m["allOf"].([]interface{}); ok {
+		for _, sub := range subs {
+			s.validateDepth(sub, v, pointer, depth, errs)
+		}
+	}
+
+	if subs, ok := m["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range subs {
+			if s.valid(sub, v, depth) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			addError(errs, pointer, "anyOf", "", "%s must match at least one schema in anyOf", name(pointer))
+		}
+	}
+
+	if subs, ok := m["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, sub := range subs {
+			if s.valid(sub, v, depth) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			addError(errs, pointer, "oneOf", strconv.Itoa(matched), "%s must match exactly one schema in oneOf, matched %d", name(pointer), matched)
+		}
+	}
+
+	if sub, ok := m["not"]; ok && s.valid(sub, v, depth) {
+		addError(errs, pointer, "not", "", "%s must not match the schema in not", name(pointer))
+	}
+
+	if sub, ok := m["if"]; ok {
+		if s.valid(sub, v, depth) {
+			if then, ok := m["then"]; ok {
+				s.validateDepth(then, v, pointer, depth, errs)
+			}
+		} else if els, ok := m["else"]; ok {
+			s.validateDepth(els, v, pointer, depth, errs)
+		}
+	}
+}
+
+func (s *Schema) validateObject(m map[string]interface{}, obj map[string]interface{}, pointer string, depth int, errs *zeroapi.ValidationErrors) {
+	if required, ok := m["required"].([]interface{}); ok {
+		for _, item := range required {
+			key, ok := item.(string)
+			if !ok {
+				continue
+			}
+			if _, exist := obj[key]; !exist {
+				p := pointer + "/" + escape(key)
+				addError(errs, p, "required", key, "%s is required", p)
+			}
+		}
+	}
+
+	if n, ok := number(m["minProperties"]); ok && float64(len(obj)) < n {
+		addError(errs, pointer, "minProperties", format(n), "%s must have at least %s properties", name(pointer), format(n))
+	}
+	if n, ok := number(m["maxProperties"]); ok && float64(len(obj)) > n {
+		addError(errs, pointer, "maxProperties", format(n), "%s must have at most %s properties", name(pointer), format(n))
+	}
+
+	if dependent, ok := m["dependentRequired"].(map[string]interface{}); ok {
+		for key, deps := range dependent {
+			if _, exist := obj[key]; !exist {
+				continue
+			}
+			list, _ := deps.([]interface{})
+			for _, item := range list {
+				dep, ok := item.(string)
+				if !ok {
+					continue
+				}
+				if _, exist := obj[dep]; !exist {
+					p := pointer + "/" + escape(dep)
+					addError(errs, p, "dependentRequired", key, "%s is required when %s is present", p, key)
+				}
+			}
+		}
+	}
+
+	properties, _ := m["properties"].(map[string]interface{})
+	patternProperties, _ := m["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := m["additionalProperties"]
+	propertyNames, hasPropertyNames := m["propertyNames"]
+
+	// 按照名称排序，保证错误顺序稳定
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := obj[key]
+		p := pointer + "/" + escape(key)
+
+		if hasPropertyNames {
+			var nameErrs zeroapi.ValidationErrors
+			s.validateDepth(propertyNames, key, p, depth, &nameErrs)
+			if len(nameErrs) > 0 {
+				addError(errs, p, "propertyNames", "", "%s is not a valid property name", p)
+			}
+		}
+
+		matched := false
+		if sub, ok := properties[key]; ok {
+			matched = true
+			s.validateDepth(sub, value, p, depth, errs)
+		}
+
+		for pattern, sub := range patternProperties {
+			if re := s.patterns[pattern]; re != nil && re.MatchString(key) {
+				matched = true
+				s.validateDepth(sub, value, p, depth, errs)
+			}
+		}
+
+		if !matched && hasAdditional {
+			if b, ok := additional.(bool); ok && !b {
+				addError(errs, p, "additionalProperties", "", "%s is not allowed", p)
+			} else {
+				s.validateDepth(additional, value, p, depth, errs)
+			}
+		}
+	}
+}
+
+func (s *Schema) validateArray(m map[string]interface{}, arr []interface{}, pointer string, depth int, errs *zeroapi.ValidationErrors) {
+	if n, ok := number(m["minItems"]); ok && float64(len(arr)) < n {
+		addError(errs, pointer, "minItems", format(n), "%s must have at least %s items", name(pointer), format(n))
+	}
+	if n, ok := number(m["maxItems"]); ok && float64(len(arr)) > n {
+		addError(errs, pointer, "maxItems", format(n), "%s must have at most %s items", name(pointer), format(n))
+	}
+
+	if unique, _ := m["uniqueItems"].(bool); unique {
+		for i := 1; i < len(arr); i++ {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(arr[i], arr[j]) {
+					p := pointer + "/" + strconv.Itoa(i)
+					addError(errs, p, "uniqueItems", "", "%s duplicates %s/%d", p, pointer, j)
+				}
+			}
+		}
+	}
+
+	// prefixItems (2020-12) 或者数组形式的 items (draft-07) 按位置验证
+	var prefix []interface{}
+	rest, hasRest := m["items"]
+	if list, ok := m["prefixItems"].([]interface{}); ok {
+		prefix = list
+	} else if list, ok := rest.([]interface{}); ok {
+		prefix = list
+		rest, hasRest = m["additionalItems"]
+	}
+
+	for i, value := range arr {
+		p := pointer + "/" + strconv.Itoa(i)
+		if i < len(prefix) {
+			s.validateDepth(prefix[i], value, p, depth, errs)
+			continue
+		}
+		if !hasRest {
+			continue
+		}
+		if b, ok := rest.(bool); ok && !b {
+			addError(errs, p, "items", "", "%s is not allowed", p)
+			continue
+		}
+		s.validateDepth(rest, value, p, depth, errs)
+	}
+
+	if sub, ok := m["contains"]; ok {
+		found := false
+		for _, value := range arr {
+			if s.valid(sub, value, depth) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addError(errs, pointer, "contains", "", "%s must contain at least one matching item", name(pointer))
+		}
+	}
+}
+
+func (s *Schema) validateString(m map[string]interface{}, str string, pointer string, errs *zeroapi.ValidationErrors) {
+	length := float64(utf8.RuneCountInString(str))
+	if n, ok := number(m["minLength"]); ok && length < n {
+		addError(errs, pointer, "minLength", format(n), "%s must be at least %s characters", name(pointer), format(n))
+	}
+	if n, ok := number(m["maxLength"]); ok && length > n {
+		addError(errs, pointer, "maxLength", format(n), "%s must be at most %s characters", name(pointer), format(n))
+	}
+
+	if pattern, ok := m["pattern"].(string); ok {
+		if re := s.patterns[pattern]; re != nil && !re.MatchString(str) {
+			addError(errs, pointer, "pattern", pattern, "%s must match pattern %s", name(pointer), pattern)
+		}
+	}
+
+	if f, ok := m["format"].(string); ok && !isFormat(str, f) {
+		addError(errs, pointer, "format", f, "%s must be a valid %s", name(pointer), f)
+	}
+}
+
+func validateNumber(m map[string]interface{}, n float64, pointer string, errs *zeroapi.ValidationErrors) {
+	if limit, ok := number(m["minimum"]); ok && n < limit {
+		addError(errs, pointer, "minimum", format(limit), "%s must be >= %s", name(pointer), format(limit))
+	}
+	if limit, ok := number(m["maximum"]); ok && n > limit {
+		addError(errs, pointer, "maximum", format(limit), "%s must be <= %s", name(pointer), format(limit))
+	}
+	if limit, ok := number(m["exclusiveMinimum"]); ok && n <= limit {
+		addError(errs, pointer, "exclusiveMinimum", format(limit), "%s must be > %s", name(pointer), format(limit))
+	}
+	if limit, ok := number(m["exclusiveMaximum"]); ok && n >= limit {
+		addError(errs, pointer, "exclusiveMaximum", format(limit), "%s must be < %s", name(pointer), format(limit))
+	}
+	if d, ok := number(m["multipleOf"]); ok && d > 0 {
+		if q := n / d; math.Abs(q-math.Round(q)) > 1e-9 {
+			addError(errs, pointer, "multipleOf", format(d), "%s must be a multiple of %s", name(pointer), format(d))
+		}
+	}
+}
+
+func isType(v interface{}, t string) bool {
+	switch t {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	}
+
+	return false
+}
+
+// isFormat 验证常用格式，不认识的格式认为有效
+func isFormat(s, f string) bool {
+	switch f {
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", s)
+		return err == nil
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		return uuidPattern.MatchString(s)
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		return net.ParseIP(s) != nil && strings.Contains(s, ":")
+	}
+
+	return true
+}
+
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+func format(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// name 错误信息中使用的位置名称，根节点为 value
+func name(pointer string) string {
+	if pointer == "" {
+		return "value"
+	}
+	return pointer
+}
+
+func addError(errs *zeroapi.ValidationErrors, pointer, rule, param, message string, args ...interface{}) {
+	*errs = append(*errs, &zeroapi.FieldError{
+		Field:   pointer,
+		Rule:    rule,
+		Param:   param,
+		Message: fmt.Sprintf(message, args...),
+	})
+}
@@ -0,0 +1,287 @@
+// Package logfile 按照文件大小与时间切割日志文件，切割后的文件可以使用 gzip 压缩，并只保留指定数量
+//
+// 示例:
+// w, _ := logfile.New("/var/log/app/access.log", logfile.WithMaxSize(100<<20), logfile.WithCompress(true))
+// app.Use(accesslog.New(w))
+package logfile
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// backupTimeFormat 切割后文件名中的时间格式
+	backupTimeFormat = "20060102-150405.000"
+
+	// compressSuffix 压缩文件后缀
+	compressSuffix = ".gz"
+)
+
+// Writer 日志文件
+type Writer interface {
+	io.WriteCloser
+
+	// Rotate 立即切割
+	Rotate() error
+}
+
+type writer struct {
+	config *config
+
+	mu sync.Mutex
+
+	// filename 日志文件路径
+	filename string
+
+	// file 当前写入的文件
+	file *os.File
+
+	// size 当前文件大小
+	size int64
+
+	// nextRotate 下一次按照时间切割的时间
+	nextRotate time.Time
+
+	// wg 等待压缩与清理完成
+	wg sync.WaitGroup
+
+	// cleanMu 压缩与清理依次执行
+	cleanMu sync.Mutex
+}
+
+// New 创建日志文件，目录不存在时自动创建
+func New(filename string, opts ...Option) (Writer, error) {
+	w := &writer{
+		config:   defaultConfig(),
+		filename: filename,
+	}
+
+	for _, opt := range opts {
+		opt(w.config)
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write 写入日志，达到切割条件时先切割
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Close 关闭文件，并等待压缩完成
+func (w *writer) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+
+	return err
+}
+
+// Rotate 立即切割
+func (w *writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+func (w *writer) shouldRotate(size int64) bool {
+	if w.config.maxSize > 0 && w.size > 0 && w.size+size > w.config.maxSize {
+		return true
+	}
+
+	return !w.nextRotate.IsZero() && !w.config.now().Before(w.nextRotate)
+}
+
+// open 打开日志文件，追加写入
+func (w *writer) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+
+	if interval := w.config.interval; interval > 0 {
+		w.nextRotate = w.config.now().Truncate(interval).Add(interval)
+	}
+
+	return nil
+}
+
+// rotate 将当前文件重命名为备份文件，再打开新的文件
+func (w *writer) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+
+	backup := w.backupName(w.config.now())
+	if err := os.Rename(w.filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.afterRotate(backup)
+	}()
+
+	return nil
+}
+
+// backupName 例如 access.log -> access-20210601-150405.000.log
+func (w *writer) backupName(t time.Time) string {
+	dir := filepath.Dir(w.filename)
+	base := filepath.Base(w.filename)
+	ext := filepath.Ext(base)
+	prefix := base[:len(base)-len(ext)]
+
+	return filepath.Join(dir, prefix+"-"+t.Format(backupTimeFormat)+ext)
+}
+
+// afterRotate 压缩备份文件，清理多余的备份文件
+func (w *writer) afterRotate(backup string) {
+	w.cleanMu.Lock()
+	defer w.cleanMu.Unlock()
+
+	if w.config.compress {
+		if err := compress(backup); err == nil {
+			os.Remove(backup)
+		}
+	}
+
+	if w.config.maxBackups > 0 {
+		w.removeBackups()
+	}
+}
+
+// backups 获取所有备份文件，按照时间从新到旧排序
+func (w *writer) backups() []string {
+	dir := filepath.Dir(w.filename)
+	base := filepath.Base(w.filename)
+	ext := filepath.Ext(base)
+	prefix := base[:len(base)-len(ext)] + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, compressSuffix), ext)[len(prefix):]
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	// 文件名中的时间格式可以直接按照字符串排序
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		out = append(out, filepath.Join(dir, name))
+	}
+
+	return out
+}
+
+// removeBackups 只保留 maxBackups 个备份文件
+func (w *writer) removeBackups() {
+	backups := w.backups()
+	if len(backups) <= w.config.maxBackups {
+		return
+	}
+
+	for _, backup := range backups[w.config.maxBackups:] {
+		os.Remove(backup)
+	}
+}
+
+// compress 使用 gzip 压缩文件，生成 name.gz
+func compress(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(name + compressSuffix)
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(name + compressSuffix)
+		return err
+	}
+
+	return dst.Close()
+}
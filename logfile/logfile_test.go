@@ -0,0 +1,96 @@
+package logfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/logfile"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2021, 6, 1, 15, 4, 5, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	w, err := logfile.New(filepath.Join(dir, "access.log"),
+		logfile.WithMaxSize(10),
+		logfile.WithMaxBackups(2),
+		logfile.WithCompress(true),
+		logfile.WithClock(clock),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write([]byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names := listDir(t, dir)
+
+	// access.log + 2 个备份文件
+	if len(names) != 3 {
+		t.Fatalf("invalid files: %v", names)
+	}
+
+	for _, name := range names {
+		if name != "access.log" && !strings.HasSuffix(name, ".log.gz") {
+			t.Fatalf("invalid file: %s", name)
+		}
+	}
+}
+
+func TestRotateByTime(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2021, 6, 1, 23, 59, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	w, err := logfile.New(filepath.Join(dir, "audit.log"),
+		logfile.WithMaxSize(0),
+		logfile.WithInterval(24*time.Hour),
+		logfile.WithClock(clock),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Write([]byte("day1\n"))
+	now = now.Add(2 * time.Minute)
+	w.Write([]byte("day2\n"))
+	w.Close()
+
+	names := listDir(t, dir)
+	if len(names) != 2 {
+		t.Fatalf("invalid files: %v", names)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "audit.log"))
+	if string(data) != "day2\n" {
+		t.Fatalf("invalid content: %s", data)
+	}
+}
@@ -0,0 +1,70 @@
+package logfile
+
+import (
+	"time"
+)
+
+// config 日志文件配置
+type config struct {
+	// maxSize 单个文件最大字节数，超过后切割，<= 0 表示不按照大小切割
+	maxSize int64
+
+	// interval 按照时间切割的间隔，例如 24 * time.Hour，<= 0 表示不按照时间切割
+	interval time.Duration
+
+	// compress 是否使用 gzip 压缩切割后的文件
+	compress bool
+
+	// maxBackups 最多保留的备份文件数量，<= 0 表示全部保留
+	maxBackups int
+
+	// now 获取当前时间
+	now func() time.Time
+}
+
+func defaultConfig() *config {
+	return &config{
+		maxSize: 100 * 1024 * 1024, // 100M
+		now:     time.Now,
+	}
+}
+
+// Option 日志文件配置选项
+type Option func(config *config)
+
+// WithMaxSize 设置单个文件最大字节数，<= 0 表示不按照大小切割
+func WithMaxSize(maxSize int64) Option {
+	return func(config *config) {
+		config.maxSize = maxSize
+	}
+}
+
+// WithInterval 设置按照时间切割的间隔，例如 24 * time.Hour 表示每天切割一次
+func WithInterval(interval time.Duration) Option {
+	return func(config *config) {
+		config.interval = interval
+	}
+}
+
+// WithCompress 设置是否使用 gzip 压缩切割后的文件
+func WithCompress(compress bool) Option {
+	return func(config *config) {
+		config.compress = compress
+	}
+}
+
+// WithMaxBackups 设置最多保留的备份文件数量
+func WithMaxBackups(maxBackups int) Option {
+	return func(config *config) {
+		config.maxBackups = maxBackups
+	}
+}
+
+// WithClock 设置获取当前时间的函数，一般用于测试
+func WithClock(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package logsink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultJournaldSocket journald 原生协议 socket
+const defaultJournaldSocket = "/run/systemd/journal/socket"
+
+// journaldSink 使用 journald 原生协议输出，见 https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+type journaldSink struct {
+	tag string
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// NewJournald 创建 journald 输出目标
+// socket: journald socket 路径，为空时使用 /run/systemd/journal/socket
+func NewJournald(socket, tag string) (Sink, error) {
+	if socket == "" {
+		socket = defaultJournaldSocket
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &journaldSink{
+		tag:  defaultTag(tag),
+		conn: conn,
+		addr: &net.UnixAddr{Name: socket, Net: "unixgram"},
+	}, nil
+}
+
+func (s *journaldSink) Write(p []byte) (int, error) {
+	if err := s.WriteLevel(LevelInfo, string(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (s *journaldSink) WriteLevel(level Level, message string) error {
+	buf := &bytes.Buffer{}
+	writeJournaldField(buf, "MESSAGE", strings.TrimRight(message, "\n"))
+	writeJournaldField(buf, "PRIORITY", strconv.Itoa(int(level)))
+	writeJournaldField(buf, "SYSLOG_IDENTIFIER", s.tag)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.conn.WriteToUnix(buf.Bytes(), s.addr)
+	return err
+}
+
+// writeJournaldField 单行的值使用 NAME=value，包含换行符的值使用二进制格式
+func writeJournaldField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name)
+		buf.WriteString("=")
+		buf.WriteString(value)
+		buf.WriteString("\n")
+		return
+	}
+
+	buf.WriteString(name)
+	buf.WriteString("\n")
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteString("\n")
+}
+
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
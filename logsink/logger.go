@@ -0,0 +1,126 @@
+package logsink
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zerogo-hub/zero-helper/logger"
+)
+
+// ErrUnknownLevel 不支持的日志级别
+var ErrUnknownLevel = errors.New("logsink: unknown level")
+
+// Logger 把 logger.Logger 的调用按照级别写入 Sink，可以通过 app.WithLogger 作为 App 的日志
+//
+// 示例:
+// l, _ := logsink.NewLoggerFromConfig(&logsink.Config{Type: logsink.TypeJournald, Level: "debug"})
+// a := app.NewApp(app.WithLogger(l))
+type Logger struct {
+	// Logger 没有覆盖的方法交给默认日志处理
+	logger.Logger
+
+	sink  Sink
+	level Level
+}
+
+// NewLogger 创建日志，高于 level 的日志不会写入，例如 level 为 LevelInfo 时不会写入调试信息
+func NewLogger(sink Sink, level Level) *Logger {
+	return &Logger{Logger: logger.NewSampleLogger(), sink: sink, level: level}
+}
+
+// NewLoggerFromConfig 根据配置创建输出目标与日志，级别见 Config.Level
+func NewLoggerFromConfig(config *Config) (*Logger, error) {
+	level, err := ParseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	sink, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLogger(sink, level), nil
+}
+
+// ParseLevel 解析日志级别: debug, info, warn, error，为空时使用 info
+func ParseLevel(s string) (Level, error) {
+	switch s {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+
+	return 0, ErrUnknownLevel
+}
+
+// Sink 日志输出目标
+func (l *Logger) Sink() Sink {
+	return l.sink
+}
+
+// Close 关闭输出目标
+func (l *Logger) Close() error {
+	return l.sink.Close()
+}
+
+func (l *Logger) write(level Level, message string) {
+	if level > l.level {
+		return
+	}
+
+	l.sink.WriteLevel(level, message)
+}
+
+func (l *Logger) Debug(v ...interface{}) {
+	l.write(LevelDebug, fmt.Sprint(v...))
+}
+
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	l.write(LevelDebug, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Info(v ...interface{}) {
+	l.write(LevelInfo, fmt.Sprint(v...))
+}
+
+func (l *Logger) Infof(format string, v ...interface{}) {
+	l.write(LevelInfo, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Warn(v ...interface{}) {
+	l.write(LevelWarn, fmt.Sprint(v...))
+}
+
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	l.write(LevelWarn, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Error(v ...interface{}) {
+	l.write(LevelError, fmt.Sprint(v...))
+}
+
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.write(LevelError, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) IsDebugAble() bool {
+	return l.level >= LevelDebug
+}
+
+func (l *Logger) IsInfoAble() bool {
+	return l.level >= LevelInfo
+}
+
+func (l *Logger) IsWarnAble() bool {
+	return l.level >= LevelWarn
+}
+
+func (l *Logger) IsErrorAble() bool {
+	return l.level >= LevelError
+}
@@ -0,0 +1,149 @@
+// Package logsink 日志输出目标，支持 syslog(RFC 5424)，systemd-journald，文件与标准输出，通过配置选择
+//
+// 所有输出目标都实现了 io.Writer，可以直接用于 accesslog 中间件，也可以作为日志库的输出
+// 通过 NewLogger 或者 NewLoggerFromConfig 包装为 logger.Logger 后，可以作为 App 的日志，见 app.WithLogger
+//
+// 示例:
+// sink, _ := logsink.New(&logsink.Config{Type: logsink.TypeSyslog, Network: "udp", Address: "127.0.0.1:514", Tag: "api"})
+// app.Use(accesslog.New(sink))
+package logsink
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/zerogo-hub/zero-api/logfile"
+)
+
+// Level 日志级别，与 syslog severity 对应
+type Level int
+
+const (
+	// LevelError 错误
+	LevelError Level = 3
+
+	// LevelWarn 警告
+	LevelWarn Level = 4
+
+	// LevelInfo 普通信息
+	LevelInfo Level = 6
+
+	// LevelDebug 调试信息
+	LevelDebug Level = 7
+)
+
+const (
+	// TypeStdout 标准输出
+	TypeStdout = "stdout"
+
+	// TypeFile 文件，按照大小与时间切割，见 logfile 包
+	TypeFile = "file"
+
+	// TypeSyslog syslog，RFC 5424 格式
+	TypeSyslog = "syslog"
+
+	// TypeJournald systemd-journald
+	TypeJournald = "journald"
+)
+
+// ErrUnknownType 不支持的输出类型
+var ErrUnknownType = errors.New("logsink: unknown type")
+
+// Sink 日志输出目标
+type Sink interface {
+	io.WriteCloser
+
+	// WriteLevel 按照指定级别写入一条日志，Write 使用 LevelInfo
+	WriteLevel(level Level, message string) error
+}
+
+// Config 输出目标配置
+type Config struct {
+	// Type 输出类型: stdout, file, syslog, journald
+	Type string `json:"type" yaml:"type" toml:"type"`
+
+	// Tag 应用名称，对应 syslog APP-NAME 与 journald SYSLOG_IDENTIFIER，默认为进程名称
+	Tag string `json:"tag" yaml:"tag" toml:"tag"`
+
+	// Network syslog 使用的网络: udp, tcp, unix, unixgram，为空时使用本地 /dev/log
+	Network string `json:"network" yaml:"network" toml:"network"`
+
+	// Address syslog 地址，或者 journald socket 路径(为空时使用 /run/systemd/journal/socket)
+	Address string `json:"address" yaml:"address" toml:"address"`
+
+	// Facility syslog facility，默认 1(user-level)
+	Facility int `json:"facility" yaml:"facility" toml:"facility"`
+
+	// Filename 文件路径，Type = file 时使用
+	Filename string `json:"filename" yaml:"filename" toml:"filename"`
+
+	// Level 日志级别: debug, info, warn, error，默认 info，NewLoggerFromConfig 时使用
+	Level string `json:"level" yaml:"level" toml:"level"`
+
+	// FileOptions 文件切割选项，Type = file 时使用
+	FileOptions []logfile.Option `json:"-" yaml:"-" toml:"-"`
+}
+
+// New 根据配置创建输出目标
+func New(config *Config) (Sink, error) {
+	switch config.Type {
+	case TypeStdout, "":
+		return &writerSink{w: os.Stdout}, nil
+	case TypeFile:
+		w, err := logfile.New(config.Filename, config.FileOptions...)
+		if err != nil {
+			return nil, err
+		}
+		return &writerSink{w: w, closer: w}, nil
+	case TypeSyslog:
+		return NewSyslog(config.Network, config.Address, config.Tag, config.Facility)
+	case TypeJournald:
+		return NewJournald(config.Address, config.Tag)
+	}
+
+	return nil, ErrUnknownType
+}
+
+// defaultTag 默认使用进程名称
+func defaultTag(tag string) string {
+	if tag != "" {
+		return tag
+	}
+
+	name := os.Args[0]
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '/' || name[i] == '\\' {
+			return name[i+1:]
+		}
+	}
+
+	return name
+}
+
+// writerSink 输出到 io.Writer，级别不会写入
+type writerSink struct {
+	w      io.Writer
+	closer io.Closer
+}
+
+func (s *writerSink) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+func (s *writerSink) WriteLevel(level Level, message string) error {
+	if len(message) == 0 || message[len(message)-1] != '\n' {
+		message += "\n"
+	}
+
+	_, err := io.WriteString(s.w, message)
+	return err
+}
+
+func (s *writerSink) Close() error {
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+
+	return nil
+}
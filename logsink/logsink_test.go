@@ -0,0 +1,123 @@
+package logsink_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/logsink"
+)
+
+func TestSyslog(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	sink, err := logsink.New(&logsink.Config{Type: logsink.TypeSyslog, Network: "udp", Address: pc.LocalAddr().String(), Tag: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	if err := sink.WriteLevel(logsink.LevelError, "something wrong\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// facility = 1, severity = 3 -> PRI = 11
+	pattern := regexp.MustCompile(`^<11>1 \S+ \S+ api \d+ - - something wrong$`)
+	if !pattern.Match(buf[:n]) {
+		t.Fatalf("invalid message: %q", buf[:n])
+	}
+}
+
+func TestJournald(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.socket")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer server.Close()
+
+	sink, err := logsink.New(&logsink.Config{Type: logsink.TypeJournald, Address: socket, Tag: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	if _, err := sink.Write([]byte("line1\nline2\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := server.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "MESSAGE\n\x0b\x00\x00\x00\x00\x00\x00\x00line1\nline2\nPRIORITY=6\nSYSLOG_IDENTIFIER=api\n"
+	if string(buf[:n]) != expected {
+		t.Fatalf("invalid message: %q", buf[:n])
+	}
+}
+
+func TestUnknownType(t *testing.T) {
+	if _, err := logsink.New(&logsink.Config{Type: "fake"}); err != logsink.ErrUnknownType {
+		t.Fatal("unknown type should fail")
+	}
+}
+
+func TestFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+
+	sink, err := logsink.New(&logsink.Config{Type: logsink.TypeFile, Filename: filename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.WriteLevel(logsink.LevelInfo, "hello")
+	sink.Close()
+
+	data, _ := os.ReadFile(filename)
+	if string(data) != "hello\n" {
+		t.Fatalf("invalid content: %q", data)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := logsink.NewLoggerFromConfig(&logsink.Config{Type: logsink.TypeFile, Filename: filename, Level: "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debugf("debug %d", 1)
+	l.Infof("info %d", 2)
+	l.Warnf("warn %d", 3)
+	l.Errorf("error %d", 4)
+	l.Close()
+
+	if l.IsInfoAble() || !l.IsWarnAble() {
+		t.Fatal("invalid level")
+	}
+
+	data, _ := os.ReadFile(filename)
+	if string(data) != "warn 3\nerror 4\n" {
+		t.Fatalf("invalid content: %q", data)
+	}
+
+	if _, err := logsink.NewLoggerFromConfig(&logsink.Config{Level: "fake"}); err != logsink.ErrUnknownLevel {
+		t.Fatal("unknown level should fail")
+	}
+}
@@ -0,0 +1,155 @@
+package logsink
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslogTimeFormat RFC 5424 TIMESTAMP
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// localSyslogAddress 本地 syslog 地址
+var localSyslogAddress = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogSink 按照 RFC 5424 格式输出到 syslog
+type syslogSink struct {
+	network  string
+	address  string
+	tag      string
+	facility int
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslog 创建 syslog 输出目标
+// network: udp, tcp, unix, unixgram，为空时使用本地 /dev/log
+// facility: <= 0 时使用 1(user-level)
+func NewSyslog(network, address, tag string, facility int) (Sink, error) {
+	if facility <= 0 {
+		facility = 1
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	s := &syslogSink{
+		network:  network,
+		address:  address,
+		tag:      defaultTag(tag),
+		facility: facility,
+		hostname: hostname,
+	}
+
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *syslogSink) connect() error {
+	if s.network != "" {
+		conn, err := net.Dial(s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		return nil
+	}
+
+	var err error
+	for _, address := range localSyslogAddress {
+		for _, network := range []string{"unixgram", "unix"} {
+			var conn net.Conn
+			if conn, err = net.Dial(network, address); err == nil {
+				s.network = network
+				s.address = address
+				s.conn = conn
+				return nil
+			}
+		}
+	}
+
+	return err
+}
+
+func (s *syslogSink) Write(p []byte) (int, error) {
+	if err := s.WriteLevel(LevelInfo, string(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteLevel 写入一条日志，连接断开时重连一次
+func (s *syslogSink) WriteLevel(level Level, message string) error {
+	msg := s.format(level, strings.TrimRight(message, "\n"), time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		if _, err := s.conn.Write(msg); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	if err := s.connect(); err != nil {
+		return err
+	}
+
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// format 生成 RFC 5424 格式的消息
+// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (s *syslogSink) format(level Level, message string, t time.Time) []byte {
+	pri := s.facility*8 + int(level)
+
+	buf := strings.Builder{}
+	buf.WriteString("<")
+	buf.WriteString(strconv.Itoa(pri))
+	buf.WriteString(">1 ")
+	buf.WriteString(t.Format(syslogTimeFormat))
+	buf.WriteString(" ")
+	buf.WriteString(s.hostname)
+	buf.WriteString(" ")
+	buf.WriteString(s.tag)
+	buf.WriteString(" ")
+	buf.WriteString(strconv.Itoa(os.Getpid()))
+	buf.WriteString(" - - ")
+	buf.WriteString(message)
+
+	msg := buf.String()
+
+	// tcp 使用 octet counting 分帧，见 RFC 6587
+	if s.network == "tcp" || s.network == "tcp4" || s.network == "tcp6" {
+		return []byte(strconv.Itoa(len(msg)) + " " + msg)
+	}
+
+	return []byte(msg)
+}
+
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		err := s.conn.Close()
+		s.conn = nil
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package mailer
+
+import "sync"
+
+// Capture 测试模式使用的发送器，不真正发送邮件，而是保存下来供测试检查
+//
+// 示例:
+// capture := mailer.NewCapture()
+// mailer.Register(app, capture)
+// ...
+// if capture.Last().Subject != "欢迎" { t.Fatal("unexpected subject") }
+type Capture struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewCapture 创建测试模式使用的发送器
+func NewCapture() *Capture {
+	return &Capture{}
+}
+
+// Send 保存邮件，编码失败时返回错误，与真实发送的行为一致
+func (c *Capture) Send(msg *Message) error {
+	if _, err := msg.Recipients(); err != nil {
+		return err
+	}
+	if _, err := msg.Bytes(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.messages = append(c.messages, msg.clone())
+	c.mu.Unlock()
+	return nil
+}
+
+// Messages 按发送顺序返回所有保存的邮件
+func (c *Capture) Messages() []*Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Message(nil), c.messages...)
+}
+
+// Last 最后一封邮件，没有邮件时返回 nil
+func (c *Capture) Last() *Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.messages) == 0 {
+		return nil
+	}
+	return c.messages[len(c.messages)-1]
+}
+
+// Reset 清空保存的邮件
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	c.messages = nil
+	c.mu.Unlock()
+}
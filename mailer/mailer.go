@@ -0,0 +1,246 @@
+// Package mailer 渲染模板并发送事务邮件，例如注册确认、找回密码
+// 同一个模板名称对应 html 与纯文本两个模板，渲染后作为 multipart/alternative 发送，主题通过 {{define "<名称>.subject"}} 定义
+// 测试时使用 Capture 代替 SMTPSender，邮件保存在内存中
+//
+// 示例:
+// templates/welcome.html: <p>你好 {{.Name}}</p>
+// templates/welcome.txt:  {{define "welcome.subject"}}欢迎 {{.Name}}{{end}}你好 {{.Name}}
+//
+// m := mailer.Register(app, mailer.NewSMTPSender("smtp.example.com:587", user, password), mailer.WithFrom("Shop <noreply@example.com>"))
+// m.ParseFS(templates, "templates/*")
+//
+// mailer.Get(ctx.App()).SendTemplate(&mailer.Message{To: []string{user.Email}}, "welcome", user)
+package mailer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// ErrTemplateNotFound 没有找到模板
+var ErrTemplateNotFound = errors.New("mailer: template not found")
+
+// Sender 邮件发送器
+type Sender interface {
+	// Send 发送邮件
+	Send(msg *Message) error
+}
+
+// Mailer 渲染模板并使用默认配置发送邮件
+type Mailer struct {
+	sender Sender
+	config *config
+
+	mu   sync.RWMutex
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+var (
+	mailersMu sync.Mutex
+
+	// mailers 每个应用一个 Mailer
+	mailers = make(map[zeroapi.App]*Mailer)
+)
+
+// New 创建 Mailer
+func New(sender Sender, opts ...Option) *Mailer {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Mailer{
+		sender: sender,
+		config: config,
+		html:   htmltemplate.New(""),
+		text:   texttemplate.New(""),
+	}
+}
+
+// Register 创建应用的 Mailer，默认使用应用的时间与随机数来源，之后通过 Get 获取
+func Register(app zeroapi.App, sender Sender, opts ...Option) *Mailer {
+	opts = append([]Option{WithNow(app.Now), WithRand(app.Rand())}, opts...)
+	m := New(sender, opts...)
+
+	mailersMu.Lock()
+	mailers[app] = m
+	mailersMu.Unlock()
+
+	return m
+}
+
+// Get 获取通过 Register 创建的 Mailer，没有注册时返回 nil
+func Get(app zeroapi.App) *Mailer {
+	mailersMu.Lock()
+	defer mailersMu.Unlock()
+	return mailers[app]
+}
+
+// ParseFS 解析模板，.html 结尾的文件作为 html 模板，自动转义，其它文件作为纯文本模板
+// 模板名称为文件名，例如 welcome.html, welcome.txt
+func (m *Mailer) ParseFS(fsys fs.FS, patterns ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, pattern := range patterns {
+		files, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			content, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return err
+			}
+
+			name := path.Base(file)
+			if strings.HasSuffix(name, ".html") {
+				_, err = m.html.New(name).Parse(string(content))
+			} else {
+				_, err = m.text.New(name).Parse(string(content))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Render 渲染模板 <name>.html, <name>.txt 以及主题 <name>.subject，至少需要存在一个正文模板
+func (m *Mailer) Render(name string, data interface{}) (*Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	msg := &Message{}
+	found := false
+
+	if t := m.html.Lookup(name + ".html"); t != nil {
+		html, err := execute(t, data)
+		if err != nil {
+			return nil, err
+		}
+		msg.HTML, found = html, true
+	}
+
+	if t := m.text.Lookup(name + ".txt"); t != nil {
+		text, err := execute(t, data)
+		if err != nil {
+			return nil, err
+		}
+		msg.Text, found = strings.TrimSpace(text), true
+	}
+
+	if !found {
+		return nil, ErrTemplateNotFound
+	}
+
+	// 主题优先从纯文本模板中查找，html 模板中的主题会被转义
+	var subject string
+	var err error
+	if t := m.text.Lookup(name + ".subject"); t != nil {
+		subject, err = execute(t, data)
+	} else if t := m.html.Lookup(name + ".subject"); t != nil {
+		subject, err = execute(t, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	msg.Subject = strings.TrimSpace(subject)
+
+	return msg, nil
+}
+
+// SendTemplate 渲染模板并发送，msg 中需要指定收件人，msg 中已有的主题优先
+func (m *Mailer) SendTemplate(msg *Message, name string, data interface{}) error {
+	rendered, err := m.Render(name, data)
+	if err != nil {
+		return err
+	}
+
+	msg = msg.clone()
+	if msg.Subject == "" {
+		msg.Subject = rendered.Subject
+	}
+	msg.Text = rendered.Text
+	msg.HTML = rendered.HTML
+
+	return m.Send(msg)
+}
+
+// Send 填充默认的发件人、回复地址、邮件头、发送时间以及 Message-ID 后发送，不会修改 msg
+func (m *Mailer) Send(msg *Message) error {
+	msg = msg.clone()
+
+	if msg.From == "" {
+		msg.From = m.config.from
+	}
+	if msg.ReplyTo == "" {
+		msg.ReplyTo = m.config.replyTo
+	}
+	if len(m.config.headers) > 0 && msg.Headers == nil {
+		msg.Headers = make(map[string]string, len(m.config.headers))
+	}
+	for key, value := range m.config.headers {
+		if _, exist := msg.Headers[key]; !exist {
+			msg.Headers[key] = value
+		}
+	}
+	if msg.Date.IsZero() {
+		msg.Date = m.config.now()
+	}
+
+	from, err := msg.FromAddress()
+	if err != nil {
+		return err
+	}
+	if msg.MessageID == "" {
+		id, err := m.newMessageID(from)
+		if err != nil {
+			return err
+		}
+		msg.MessageID = id
+	}
+
+	return m.sender.Send(msg)
+}
+
+// newMessageID 生成 Message-ID，格式为 <随机数>@<发件人的域名>
+func (m *Mailer) newMessageID(from string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(m.config.rand, b); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 {
+		domain = from[i+1:]
+	}
+	return hex.EncodeToString(b) + "@" + domain, nil
+}
+
+// template html/template 与 text/template 共同的方法
+type template interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+func execute(t template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,195 @@
+package mailer_test
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/mailer"
+)
+
+var templates = fstest.MapFS{
+	"templates/welcome.html": {Data: []byte(`<p>你好 {{.Name}}</p>`)},
+	"templates/welcome.txt":  {Data: []byte(`{{define "welcome.subject"}}欢迎 {{.Name}}{{end}}你好 {{.Name}}`)},
+}
+
+func TestSendTemplate(t *testing.T) {
+	a := app.NewApp()
+	capture := mailer.NewCapture()
+	m := mailer.Register(a, capture, mailer.WithFrom("Shop <noreply@example.com>"), mailer.WithHeader("List-Unsubscribe", "<mailto:unsubscribe@example.com>"))
+	if mailer.Get(a) != m {
+		t.Fatal("mailer not registered")
+	}
+	if err := m.ParseFS(templates, "templates/*"); err != nil {
+		t.Fatal(err)
+	}
+
+	data := struct{ Name string }{Name: "<Tom>"}
+	if err := m.SendTemplate(&mailer.Message{To: []string{"tom@example.com"}}, "welcome", data); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := capture.Last()
+	if msg == nil {
+		t.Fatal("message not captured")
+	}
+	if msg.Subject != "欢迎 <Tom>" || msg.Text != "你好 <Tom>" || msg.HTML != "<p>你好 &lt;Tom&gt;</p>" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if msg.From != "Shop <noreply@example.com>" || msg.Date.IsZero() || !strings.HasSuffix(msg.MessageID, "@example.com") {
+		t.Fatalf("defaults not applied: %+v", msg)
+	}
+
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != "欢迎 <Tom>" || parsed.Header.Get("List-Unsubscribe") == "" {
+		t.Fatalf("unexpected header: %v", parsed.Header)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type: %s", mediaType)
+	}
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		body, _ := ioutil.ReadAll(quotedprintable.NewReader(part))
+		parts = append(parts, string(body))
+	}
+	if len(parts) != 2 || parts[0] != msg.Text || parts[1] != msg.HTML {
+		t.Fatalf("unexpected parts: %q", parts)
+	}
+
+	if _, err := m.Render("missing", data); err != mailer.ErrTemplateNotFound {
+		t.Fatal("expected ErrTemplateNotFound")
+	}
+
+	capture.Reset()
+	if len(capture.Messages()) != 0 {
+		t.Fatal("capture not reset")
+	}
+}
+
+func TestInvalidMessage(t *testing.T) {
+	capture := mailer.NewCapture()
+	m := mailer.New(capture)
+
+	if err := m.Send(&mailer.Message{To: []string{"tom@example.com"}}); err != mailer.ErrNoFrom {
+		t.Fatalf("expected ErrNoFrom, got %v", err)
+	}
+
+	m = mailer.New(capture, mailer.WithFrom("noreply@example.com"))
+	if err := m.Send(&mailer.Message{}); err != mailer.ErrNoRecipient {
+		t.Fatalf("expected ErrNoRecipient, got %v", err)
+	}
+
+	msg := &mailer.Message{
+		To:      []string{"tom@example.com"},
+		Text:    "hi",
+		Headers: map[string]string{"X-Tag": "a\r\nBcc: evil@example.com"},
+	}
+	if err := m.Send(msg); err != mailer.ErrInvalidHeader {
+		t.Fatalf("expected ErrInvalidHeader, got %v", err)
+	}
+	if len(capture.Messages()) != 0 {
+		t.Fatal("invalid message captured")
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		var lines []string
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				reply("354 go ahead")
+				for {
+					data, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if data == ".\r\n" {
+						break
+					}
+					lines = append(lines, strings.TrimRight(data, "\r\n"))
+				}
+				reply("250 ok")
+			case "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	m := mailer.New(mailer.NewSMTPSender(ln.Addr().String(), "", ""), mailer.WithFrom("noreply@example.com"))
+	err = m.Send(&mailer.Message{
+		To:      []string{"Tom <tom@example.com>"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "hello",
+		Text:    "hi",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Join(<-received, "\n")
+	for _, want := range []string{"MAIL FROM:<noreply@example.com>", "RCPT TO:<tom@example.com>", "RCPT TO:<audit@example.com>", "Subject: hello"} {
+		if !strings.Contains(lines, want) {
+			t.Fatalf("missing %q in:\n%s", want, lines)
+		}
+	}
+	if strings.Contains(lines, "Bcc:") {
+		t.Fatal("bcc leaked into headers")
+	}
+}
@@ -0,0 +1,251 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoRecipient 邮件没有收件人
+	ErrNoRecipient = errors.New("mailer: no recipient")
+
+	// ErrNoFrom 邮件没有发件人，也没有设置默认发件人
+	ErrNoFrom = errors.New("mailer: no from address")
+
+	// ErrInvalidHeader 邮件头中包含换行符
+	ErrInvalidHeader = errors.New("mailer: invalid header")
+)
+
+// Message 一封邮件，同时包含 Text 与 HTML 时发送 multipart/alternative，邮件客户端选择其中一个显示
+type Message struct {
+	// From 发件人，例如 "Shop <noreply@example.com>"
+	From string
+
+	// To 收件人
+	To []string
+
+	// Cc 抄送
+	Cc []string
+
+	// Bcc 密送，不出现在邮件头中
+	Bcc []string
+
+	// ReplyTo 回复地址
+	ReplyTo string
+
+	// Subject 主题
+	Subject string
+
+	// Text 纯文本正文
+	Text string
+
+	// HTML html 正文
+	HTML string
+
+	// Headers 附加的邮件头
+	Headers map[string]string
+
+	// Date 发送时间，为空时由 Mailer 填写
+	Date time.Time
+
+	// MessageID 邮件 id，为空时由 Mailer 生成
+	MessageID string
+}
+
+// Recipients 所有收件人的邮箱地址，包括抄送与密送，用于 SMTP 的 RCPT TO
+func (m *Message) Recipients() ([]string, error) {
+	recipients := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, s := range list {
+			address, err := mail.ParseAddress(s)
+			if err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, address.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipient
+	}
+	return recipients, nil
+}
+
+// FromAddress 发件人的邮箱地址，用于 SMTP 的 MAIL FROM
+func (m *Message) FromAddress() (string, error) {
+	if m.From == "" {
+		return "", ErrNoFrom
+	}
+	address, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return "", err
+	}
+	return address.Address, nil
+}
+
+// Bytes 按 RFC 5322 编码邮件，正文使用 quoted-printable 编码
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo 按 RFC 5322 编码邮件并写入 w
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	header := make(textproto.MIMEHeader)
+
+	from, err := formatAddresses([]string{m.From})
+	if err != nil {
+		return 0, err
+	}
+	header.Set("From", from)
+
+	if len(m.To) > 0 {
+		to, err := formatAddresses(m.To)
+		if err != nil {
+			return 0, err
+		}
+		header.Set("To", to)
+	}
+	if len(m.Cc) > 0 {
+		cc, err := formatAddresses(m.Cc)
+		if err != nil {
+			return 0, err
+		}
+		header.Set("Cc", cc)
+	}
+	if m.ReplyTo != "" {
+		replyTo, err := formatAddresses([]string{m.ReplyTo})
+		if err != nil {
+			return 0, err
+		}
+		header.Set("Reply-To", replyTo)
+	}
+
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	if !m.Date.IsZero() {
+		header.Set("Date", m.Date.Format(time.RFC1123Z))
+	}
+	if m.MessageID != "" {
+		header.Set("Message-Id", "<"+m.MessageID+">")
+	}
+	header.Set("Mime-Version", "1.0")
+
+	for key, value := range m.Headers {
+		header.Set(key, value)
+	}
+
+	var body bytes.Buffer
+	switch {
+	case m.Text != "" && m.HTML != "":
+		mw := multipart.NewWriter(&body)
+		header.Set("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+		for _, part := range []struct{ contentType, content string }{
+			{"text/plain; charset=UTF-8", m.Text},
+			{"text/html; charset=UTF-8", m.HTML},
+		} {
+			pw, err := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return 0, err
+			}
+			if err := writeQuotedPrintable(pw, part.content); err != nil {
+				return 0, err
+			}
+		}
+		if err := mw.Close(); err != nil {
+			return 0, err
+		}
+	case m.HTML != "":
+		header.Set("Content-Type", "text/html; charset=UTF-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		if err := writeQuotedPrintable(&body, m.HTML); err != nil {
+			return 0, err
+		}
+	default:
+		header.Set("Content-Type", "text/plain; charset=UTF-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		if err := writeQuotedPrintable(&body, m.Text); err != nil {
+			return 0, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeHeader(&buf, header); err != nil {
+		return 0, err
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+
+	return buf.WriteTo(w)
+}
+
+// clone 复制邮件，避免修改调用方的数据
+func (m *Message) clone() *Message {
+	c := *m
+	c.To = append([]string(nil), m.To...)
+	c.Cc = append([]string(nil), m.Cc...)
+	c.Bcc = append([]string(nil), m.Bcc...)
+	if m.Headers != nil {
+		c.Headers = make(map[string]string, len(m.Headers))
+		for key, value := range m.Headers {
+			c.Headers[key] = value
+		}
+	}
+	return &c
+}
+
+// formatAddresses 解析并编码邮箱地址，非 ASCII 的名称使用 RFC 2047 编码
+func formatAddresses(list []string) (string, error) {
+	formatted := make([]string, 0, len(list))
+	for _, s := range list {
+		address, err := mail.ParseAddress(s)
+		if err != nil {
+			return "", err
+		}
+		formatted = append(formatted, address.String())
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// writeHeader 按名称排序写入邮件头，值中包含换行符时返回 ErrInvalidHeader，防止邮件头注入
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) error {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range header[key] {
+			if strings.ContainsAny(key, "\r\n:") || strings.ContainsAny(value, "\r\n") {
+				return ErrInvalidHeader
+			}
+			buf.WriteString(key)
+			buf.WriteString(": ")
+			buf.WriteString(value)
+			buf.WriteString("\r\n")
+		}
+	}
+	return nil
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qw, content); err != nil {
+		return err
+	}
+	return qw.Close()
+}
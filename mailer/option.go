@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"io"
+	"net/textproto"
+	"time"
+)
+
+// config 邮件默认配置，作用于每一封通过 Mailer 发送的邮件
+type config struct {
+	// from 默认发件人
+	from string
+
+	// replyTo 默认回复地址
+	replyTo string
+
+	// headers 默认附加的邮件头
+	headers map[string]string
+
+	// now 获取当前时间，用于 Date 邮件头
+	now func() time.Time
+
+	// rand 随机数来源，用于生成 Message-ID
+	rand io.Reader
+}
+
+func defaultConfig() *config {
+	return &config{
+		headers: make(map[string]string),
+		now:     time.Now,
+		rand:    rand.Reader,
+	}
+}
+
+// Option 邮件默认配置选项
+type Option func(config *config)
+
+// WithFrom 设置默认发件人，例如 "Shop <noreply@example.com>"，邮件没有指定发件人时使用
+func WithFrom(from string) Option {
+	return func(config *config) {
+		if from != "" {
+			config.from = from
+		}
+	}
+}
+
+// WithReplyTo 设置默认回复地址，邮件没有指定回复地址时使用
+func WithReplyTo(replyTo string) Option {
+	return func(config *config) {
+		if replyTo != "" {
+			config.replyTo = replyTo
+		}
+	}
+}
+
+// WithHeader 设置默认附加的邮件头，例如 List-Unsubscribe，邮件中已有的同名邮件头优先
+func WithHeader(key, value string) Option {
+	return func(config *config) {
+		if key != "" {
+			config.headers[textproto.CanonicalMIMEHeaderKey(key)] = value
+		}
+	}
+}
+
+// WithNow 设置获取当前时间的函数，默认 time.Now
+func WithNow(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
+
+// WithRand 设置随机数来源，默认 crypto/rand
+func WithRand(rand io.Reader) Option {
+	return func(config *config) {
+		if rand != nil {
+			config.rand = rand
+		}
+	}
+}
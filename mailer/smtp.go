@@ -0,0 +1,95 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"net"
+	"net/smtp"
+)
+
+// SMTPSender 通过 SMTP 发送邮件
+// 服务器支持 STARTTLS 时自动升级为加密连接，TLS 不为空时使用隐式 TLS 连接，例如 465 端口
+type SMTPSender struct {
+	// Addr SMTP 服务器地址，例如 smtp.example.com:587
+	Addr string
+
+	// Auth 认证方式，为空时不认证
+	Auth smtp.Auth
+
+	// TLS 使用隐式 TLS 连接时的配置
+	TLS *tls.Config
+}
+
+// NewSMTPSender 创建 SMTP 发送器，username 不为空时使用 PLAIN 认证
+func NewSMTPSender(addr, username, password string) *SMTPSender {
+	s := &SMTPSender{Addr: addr}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		s.Auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+// Send 发送邮件
+func (s *SMTPSender) Send(msg *Message) error {
+	from, err := msg.FromAddress()
+	if err != nil {
+		return err
+	}
+	recipients, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if s.TLS == nil {
+		return smtp.SendMail(s.Addr, s.Auth, from, recipients, data)
+	}
+
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	config := s.TLS.Clone()
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+
+	conn, err := tls.Dial("tcp", s.Addr, config)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if s.Auth != nil {
+		if err := c.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
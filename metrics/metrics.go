@@ -0,0 +1,345 @@
+// Package metrics 进程内指标管理，记录业务指标，可以通过 Gather 导出
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// collector 一个指标
+type collector interface {
+	gather() []zeroapi.MetricSample
+}
+
+type metrics struct {
+	mu sync.RWMutex
+
+	// collectors 按照名称存储指标
+	collectors map[string]collector
+}
+
+// New 创建一个指标管理器
+func New() zeroapi.Metrics {
+	return &metrics{
+		collectors: make(map[string]collector),
+	}
+}
+
+// Counter 获取名称为 name 的计数器，不存在时创建
+// 同名的指标已经是其它类型时，返回一个不记录数据的计数器
+func (m *metrics) Counter(name string) zeroapi.Metric {
+	c := m.getOrCreate(name, func() collector { return newCounter(name) })
+	if counter, ok := c.(*counter); ok {
+		return counter
+	}
+
+	return discard{}
+}
+
+// Histogram 获取名称为 name 的直方图，不存在时创建
+// 同名的指标已经是其它类型时，返回一个不记录数据的直方图
+func (m *metrics) Histogram(name string, buckets []float64) zeroapi.Histogram {
+	c := m.getOrCreate(name, func() collector { return newHistogram(name, buckets) })
+	if histogram, ok := c.(*histogram); ok {
+		return histogram
+	}
+
+	return discard{}
+}
+
+// Gauge 获取名称为 name 的仪表，不存在时创建
+// 同名的指标已经是其它类型时，返回一个不记录数据的仪表
+func (m *metrics) Gauge(name string) zeroapi.Gauge {
+	c := m.getOrCreate(name, func() collector { return newGauge(name) })
+	if gauge, ok := c.(*gauge); ok {
+		return gauge
+	}
+
+	return discard{}
+}
+
+func (m *metrics) getOrCreate(name string, create func() collector) collector {
+	m.mu.RLock()
+	c, exist := m.collectors[name]
+	m.mu.RUnlock()
+
+	if exist {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, exist = m.collectors[name]; !exist {
+		c = create()
+		m.collectors[name] = c
+	}
+
+	return c
+}
+
+// Gather 获取所有指标的当前值，按照名称，标签排序
+func (m *metrics) Gather() []zeroapi.MetricSample {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.collectors))
+	for name := range m.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]collector, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		collectors = append(collectors, m.collectors[name])
+	}
+	m.mu.RUnlock()
+
+	var samples []zeroapi.MetricSample
+	for _, c := range collectors {
+		samples = append(samples, c.gather()...)
+	}
+
+	return samples
+}
+
+// counter 计数器，每一组标签对应一个值
+type counter struct {
+	name string
+
+	// typ 指标类型，仪表复用计数器的存储
+	typ string
+
+	mu sync.Mutex
+
+	// series 按照标签存储值，key 为编码后的标签
+	series map[string]*series
+}
+
+// series 一组标签对应的值
+type series struct {
+	labels []string
+	value  float64
+}
+
+func newCounter(name string) *counter {
+	return &counter{
+		name:   name,
+		typ:    zeroapi.MetricTypeCounter,
+		series: make(map[string]*series),
+	}
+}
+
+// Add 累加 v
+func (c *counter) Add(v float64, labels ...string) {
+	c.update(labels, func(s *series) { s.value += v })
+}
+
+// update 修改 labels 对应的值
+func (c *counter) update(labels []string, fn func(s *series)) {
+	labels = normalizeLabels(labels)
+	key := labelsKey(labels)
+
+	c.mu.Lock()
+	s, exist := c.series[key]
+	if !exist {
+		s = &series{labels: labels}
+		c.series[key] = s
+	}
+	fn(s)
+	c.mu.Unlock()
+}
+
+func (c *counter) gather() []zeroapi.MetricSample {
+	c.mu.Lock()
+	samples := make([]zeroapi.MetricSample, 0, len(c.series))
+	for _, s := range c.series {
+		samples = append(samples, zeroapi.MetricSample{
+			Name:   c.name,
+			Type:   c.typ,
+			Labels: s.labels,
+			Value:  s.value,
+		})
+	}
+	c.mu.Unlock()
+
+	sortSamples(samples)
+
+	return samples
+}
+
+// gauge 仪表，每一组标签对应一个值
+type gauge struct {
+	*counter
+}
+
+func newGauge(name string) *gauge {
+	c := newCounter(name)
+	c.typ = zeroapi.MetricTypeGauge
+	return &gauge{counter: c}
+}
+
+// Set 设置为 v
+func (g *gauge) Set(v float64, labels ...string) {
+	g.update(labels, func(s *series) { s.value = v })
+}
+
+// histogram 直方图，每一组标签对应一组桶
+type histogram struct {
+	name    string
+	buckets []float64
+
+	mu sync.Mutex
+
+	// series 按照标签存储值，key 为编码后的标签
+	series map[string]*histogramSeries
+}
+
+// histogramSeries 一组标签对应的桶
+type histogramSeries struct {
+	labels []string
+
+	// counts 每个桶的数量(非累计)，最后一个为 +Inf
+	counts []uint64
+	count  uint64
+	sum    float64
+
+	// exemplars 每个桶中最近的 exemplar，最后一个为 +Inf
+	exemplars []*zeroapi.MetricExemplar
+}
+
+func newHistogram(name string, buckets []float64) *histogram {
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	sort.Float64s(b)
+
+	return &histogram{
+		name:    name,
+		buckets: b,
+		series:  make(map[string]*histogramSeries),
+	}
+}
+
+// Observe 记录一个值
+func (h *histogram) Observe(v float64, labels ...string) {
+	h.observe(v, nil, labels)
+}
+
+// ObserveWithExemplar 记录一个值，同时记录 exemplar，替换所在桶中原有的 exemplar
+func (h *histogram) ObserveWithExemplar(v float64, exemplar []string, labels ...string) {
+	h.observe(v, exemplar, labels)
+}
+
+func (h *histogram) observe(v float64, exemplar []string, labels []string) {
+	labels = normalizeLabels(labels)
+	key := labelsKey(labels)
+
+	// 第一个上限 >= v 的桶
+	idx := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	s, exist := h.series[key]
+	if !exist {
+		s = &histogramSeries{labels: labels, counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+	}
+	s.counts[idx]++
+	s.count++
+	s.sum += v
+	if len(exemplar) >= 2 {
+		if s.exemplars == nil {
+			s.exemplars = make([]*zeroapi.MetricExemplar, len(h.buckets)+1)
+		}
+		s.exemplars[idx] = &zeroapi.MetricExemplar{Labels: exemplar[:len(exemplar)/2*2], Value: v, Timestamp: time.Now()}
+	}
+	h.mu.Unlock()
+}
+
+func (h *histogram) gather() []zeroapi.MetricSample {
+	h.mu.Lock()
+	samples := make([]zeroapi.MetricSample, 0, len(h.series))
+	for _, s := range h.series {
+		buckets := make([]zeroapi.MetricBucket, len(h.buckets))
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += s.counts[i]
+			buckets[i] = zeroapi.MetricBucket{UpperBound: upperBound, Count: cumulative}
+			if s.exemplars != nil {
+				buckets[i].Exemplar = s.exemplars[i]
+			}
+		}
+
+		sample := zeroapi.MetricSample{
+			Name:    h.name,
+			Type:    zeroapi.MetricTypeHistogram,
+			Labels:  s.labels,
+			Value:   s.sum,
+			Count:   s.count,
+			Buckets: buckets,
+		}
+		if s.exemplars != nil {
+			sample.InfExemplar = s.exemplars[len(h.buckets)]
+		}
+		samples = append(samples, sample)
+	}
+	h.mu.Unlock()
+
+	sortSamples(samples)
+
+	return samples
+}
+
+// discard 不记录数据
+type discard struct{}
+
+func (discard) Add(v float64, labels ...string)     {}
+func (discard) Observe(v float64, labels ...string) {}
+func (discard) Set(v float64, labels ...string)     {}
+
+func (discard) ObserveWithExemplar(v float64, exemplar []string, labels ...string) {}
+
+// ExponentialBuckets 生成 count 个桶，上限从 start 开始，每次乘以 factor
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+
+	return buckets
+}
+
+func labelsKey(labels []string) string {
+	return strings.Join(labels, "\xff")
+}
+
+func sortSamples(samples []zeroapi.MetricSample) {
+	sort.Slice(samples, func(i, j int) bool {
+		return labelsKey(samples[i].Labels) < labelsKey(samples[j].Labels)
+	})
+}
+
+// normalizeLabels 按照键排序，丢弃不成对的最后一个元素
+func normalizeLabels(labels []string) []string {
+	n := len(labels) / 2
+	if n == 0 {
+		return nil
+	}
+
+	pairs := make([][2]string, n)
+	for i := 0; i < n; i++ {
+		pairs[i] = [2]string{labels[2*i], labels[2*i+1]}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0]
+	})
+
+	out := make([]string, 0, 2*n)
+	for _, pair := range pairs {
+		out = append(out, pair[0], pair[1])
+	}
+
+	return out
+}
@@ -0,0 +1,155 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/metrics"
+)
+
+func TestCounter(t *testing.T) {
+	m := metrics.New()
+
+	m.Counter("orders").Add(1, "status", "paid", "channel", "app")
+	m.Counter("orders").Add(2, "channel", "app", "status", "paid")
+	m.Counter("orders").Add(1, "status", "canceled")
+	m.Counter("hits").Add(1, "odd")
+
+	samples := m.Gather()
+	if len(samples) != 3 {
+		t.Fatalf("invalid samples: %+v", samples)
+	}
+
+	// 按照名称排序
+	if samples[0].Name != "hits" || len(samples[0].Labels) != 0 || samples[0].Value != 1 {
+		t.Fatalf("invalid hits: %+v", samples[0])
+	}
+
+	// 标签顺序不影响结果
+	paid := samples[1]
+	if paid.Labels[0] != "channel" || paid.Labels[3] != "paid" || paid.Value != 3 {
+		t.Fatalf("invalid paid: %+v", paid)
+	}
+
+	if samples[2].Value != 1 {
+		t.Fatalf("invalid canceled: %+v", samples[2])
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	m := metrics.New()
+
+	h := m.Histogram("size", []float64{100, 10})
+	h.Observe(5, "route", "/blog")
+	h.Observe(10, "route", "/blog")
+	h.Observe(50, "route", "/blog")
+	h.Observe(500, "route", "/blog")
+
+	// 已存在的同名指标，类型不一致时不记录
+	m.Counter("size").Add(1)
+
+	samples := m.Gather()
+	if len(samples) != 1 {
+		t.Fatalf("invalid samples: %+v", samples)
+	}
+
+	s := samples[0]
+	if s.Count != 4 || s.Value != 565 || len(s.Buckets) != 2 {
+		t.Fatalf("invalid histogram: %+v", s)
+	}
+
+	// 累计数量
+	if s.Buckets[0].UpperBound != 10 || s.Buckets[0].Count != 2 || s.Buckets[1].Count != 3 {
+		t.Fatalf("invalid buckets: %+v", s.Buckets)
+	}
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	buckets := metrics.ExponentialBuckets(256, 4, 3)
+	if len(buckets) != 3 || buckets[0] != 256 || buckets[2] != 4096 {
+		t.Fatalf("invalid buckets: %v", buckets)
+	}
+}
+
+func TestGauge(t *testing.T) {
+	m := metrics.New()
+
+	g := m.Gauge("in_flight")
+	g.Add(1, "method", "GET")
+	g.Add(1, "method", "GET")
+	g.Add(-1, "method", "GET")
+	g.Set(5, "method", "POST")
+
+	samples := m.Gather()
+	if len(samples) != 2 || samples[0].Type != "gauge" || samples[0].Value != 1 || samples[1].Value != 5 {
+		t.Fatalf("invalid samples: %+v", samples)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	m := metrics.New()
+	m.Counter("http_requests_total").Add(2, "route", `/a"b`, "status", "200")
+	m.Histogram("latency.seconds", []float64{0.1, 1}).Observe(0.5)
+
+	var buf strings.Builder
+	if err := metrics.WritePrometheus(&buf, m.Gather()); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `# TYPE http_requests_total counter
+http_requests_total{route="/a\"b",status="200"} 2
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 0
+latency_seconds_bucket{le="1"} 1
+latency_seconds_bucket{le="+Inf"} 1
+latency_seconds_sum 0.5
+latency_seconds_count 1
+`
+	if buf.String() != expect {
+		t.Fatalf("invalid output:\n%s", buf.String())
+	}
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	m := metrics.New()
+	m.Counter("http_requests_total").Add(2, "status", "200")
+
+	h, ok := m.Histogram("latency_seconds", []float64{0.1, 1}).(zeroapi.ExemplarHistogram)
+	if !ok {
+		t.Fatal("histogram not support exemplar")
+	}
+	h.Observe(0.05)
+	h.ObserveWithExemplar(0.5, []string{"trace_id", "abc"})
+	h.ObserveWithExemplar(5, []string{"trace_id", "def"})
+
+	var buf strings.Builder
+	if err := metrics.WriteOpenMetrics(&buf, m.Gather()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	expects := []string{
+		`# TYPE http_requests counter`,
+		`http_requests_total{status="200"} 2`,
+		`# TYPE latency_seconds histogram`,
+		`latency_seconds_bucket{le="0.1"} 1`,
+		`latency_seconds_bucket{le="1"} 2 # {trace_id="abc"} 0.5 `,
+		`latency_seconds_bucket{le="+Inf"} 3 # {trace_id="def"} 5 `,
+		`latency_seconds_sum 5.55`,
+		`latency_seconds_count 3`,
+		`# EOF`,
+	}
+	for i, expect := range expects {
+		if i >= len(lines) || !strings.HasPrefix(lines[i], expect) {
+			t.Fatalf("invalid output:\n%s", buf.String())
+		}
+	}
+
+	// Prometheus 文本格式不支持 exemplar
+	buf.Reset()
+	metrics.WritePrometheus(&buf, m.Gather())
+	if strings.Contains(buf.String(), "trace_id") {
+		t.Fatalf("unexpected exemplar:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// OpenMetricsContentType OpenMetrics 文本格式的 Content-Type
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// WriteOpenMetrics 将 Gather 的结果按照 OpenMetrics 文本格式写入 w，包含直方图的 exemplar
+// 见 https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md
+func WriteOpenMetrics(w io.Writer, samples []zeroapi.MetricSample) error {
+	bw := bufio.NewWriter(w)
+
+	last := ""
+	for _, sample := range samples {
+		name := sanitizeName(sample.Name)
+
+		// 计数器的指标族名称不包含 _total 后缀，采样名称必须包含
+		family := name
+		if sample.Type == zeroapi.MetricTypeCounter {
+			family = strings.TrimSuffix(name, "_total")
+			name = family + "_total"
+		}
+
+		if family != last {
+			bw.WriteString("# TYPE " + family + " " + sample.Type + "\n")
+			last = family
+		}
+
+		if sample.Type != zeroapi.MetricTypeHistogram {
+			writeLine(bw, name, sample.Labels, "", "", sample.Value, nil)
+			continue
+		}
+
+		for _, bucket := range sample.Buckets {
+			writeLine(bw, name+"_bucket", sample.Labels, "le", formatFloat(bucket.UpperBound), float64(bucket.Count), bucket.Exemplar)
+		}
+		writeLine(bw, name+"_bucket", sample.Labels, "le", "+Inf", float64(sample.Count), sample.InfExemplar)
+		writeLine(bw, name+"_sum", sample.Labels, "", "", sample.Value, nil)
+		writeLine(bw, name+"_count", sample.Labels, "", "", float64(sample.Count), nil)
+	}
+
+	bw.WriteString("# EOF\n")
+
+	return bw.Flush()
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// PrometheusContentType Prometheus 文本格式的 Content-Type
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WritePrometheus 将 Gather 的结果按照 Prometheus 文本格式写入 w
+// 见 https://prometheus.io/docs/instrumenting/exposition_formats/
+func WritePrometheus(w io.Writer, samples []zeroapi.MetricSample) error {
+	bw := bufio.NewWriter(w)
+
+	last := ""
+	for _, sample := range samples {
+		name := sanitizeName(sample.Name)
+		if name != last {
+			bw.WriteString("# TYPE " + name + " " + sample.Type + "\n")
+			last = name
+		}
+
+		if sample.Type != zeroapi.MetricTypeHistogram {
+			writeLine(bw, name, sample.Labels, "", "", sample.Value, nil)
+			continue
+		}
+
+		for _, bucket := range sample.Buckets {
+			writeLine(bw, name+"_bucket", sample.Labels, "le", formatFloat(bucket.UpperBound), float64(bucket.Count), nil)
+		}
+		writeLine(bw, name+"_bucket", sample.Labels, "le", "+Inf", float64(sample.Count), nil)
+		writeLine(bw, name+"_sum", sample.Labels, "", "", sample.Value, nil)
+		writeLine(bw, name+"_count", sample.Labels, "", "", float64(sample.Count), nil)
+	}
+
+	return bw.Flush()
+}
+
+// writeLine 写入一行，extraKey 不为空时作为最后一个标签，例如直方图的 le
+// exemplar 不为 nil 时按照 OpenMetrics 格式写在行尾
+func writeLine(bw *bufio.Writer, name string, labels []string, extraKey, extraValue string, value float64, exemplar *zeroapi.MetricExemplar) {
+	bw.WriteString(name)
+
+	if len(labels) > 0 || extraKey != "" {
+		bw.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			writeLabel(bw, labels[i], labels[i+1])
+		}
+		if extraKey != "" {
+			if len(labels) > 0 {
+				bw.WriteByte(',')
+			}
+			writeLabel(bw, extraKey, extraValue)
+		}
+		bw.WriteByte('}')
+	}
+
+	bw.WriteByte(' ')
+	bw.WriteString(formatFloat(value))
+
+	if exemplar != nil {
+		bw.WriteString(" # {")
+		for i := 0; i+1 < len(exemplar.Labels); i += 2 {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			writeLabel(bw, exemplar.Labels[i], exemplar.Labels[i+1])
+		}
+		bw.WriteString("} ")
+		bw.WriteString(formatFloat(exemplar.Value))
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.FormatFloat(float64(exemplar.Timestamp.UnixNano())/1e9, 'f', 3, 64))
+	}
+
+	bw.WriteByte('\n')
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeLabel(bw *bufio.Writer, key, value string) {
+	bw.WriteString(sanitizeName(key))
+	bw.WriteString(`="`)
+	bw.WriteString(labelValueReplacer.Replace(value))
+	bw.WriteByte('"')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sanitizeName 名称只能包含字母、数字、下划线以及冒号，且不能以数字开头，其它字符替换为下划线
+func sanitizeName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9' && i > 0) {
+			continue
+		}
+		b[i] = '_'
+	}
+	return string(b)
+}
@@ -0,0 +1,74 @@
+// Package accesslog 访问日志中间件，将每一次请求按照 Combined Log Format 写入 io.Writer
+// 状态码与响应大小从响应的 Writer 中读取，包括反向代理等直接写入 Writer 的响应
+//
+// 只记录访问日志，不记录审计日志，审计需要的操作者、变更内容等与业务相关，由业务自行记录，可以同样写入 logfile
+// 日志中的用户为认证通过的用户(见 zeroapi.ValueKeyPrincipal)，模拟其它用户时为 "真实用户->被模拟的用户"
+//
+// 示例:
+// w, _ := logfile.New("/var/log/app/access.log", logfile.WithInterval(24*time.Hour), logfile.WithCompress(true))
+// app.Use(accesslog.New(w))
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// timeFormat 日志中的时间格式
+const timeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// New 创建访问日志中间件
+func New(w io.Writer) zeroapi.Handler {
+	var mu sync.Mutex
+
+	return func(ctx zeroapi.Context) {
+		start := time.Now()
+
+		ip := ctx.ClientIP()
+		method := ctx.Method()
+		path := ctx.Path()
+		protocol := ctx.Protocol()
+		referer := ctx.Referer()
+		userAgent := ctx.UserAgent()
+
+		ctx.AppendEnd(func() error {
+			// 钩子函数在响应结束后执行，此时已经执行了中断与异常的处理
+			status, size := ctx.Response().Status(), ctx.Response().Size()
+			user := principal(ctx)
+
+			// 没有写入任何数据时，由 net/http 写入 200
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			line := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %d %q %q %s\n",
+				ip, user, start.Format(timeFormat), method, path, protocol,
+				status, size, referer, userAgent, time.Since(start))
+
+			// 写入失败不影响其它钩子函数
+			mu.Lock()
+			io.WriteString(w, line)
+			mu.Unlock()
+
+			return nil
+		})
+	}
+}
+
+// principal 日志中的用户，没有认证时为 -
+func principal(ctx zeroapi.Context) string {
+	user, _ := ctx.Value(zeroapi.ValueKeyPrincipal).(string)
+	if user == "" {
+		return "-"
+	}
+
+	if realUser, ok := ctx.Value(zeroapi.ValueKeyRealPrincipal).(string); ok && realUser != "" {
+		return realUser + "->" + user
+	}
+	return user
+}
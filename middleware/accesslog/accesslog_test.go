@@ -0,0 +1,131 @@
+package accesslog_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/accesslog"
+)
+
+// buffer 访问日志在请求结束后的其它 goroutine 中写入
+type buffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *buffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newApp(w *buffer) zeroapi.App {
+	a := app.NewApp()
+	a.Use(accesslog.New(w))
+
+	a.Get("/text", func(ctx zeroapi.Context) {
+		ctx.Text("hello")
+	})
+	a.Post("/raw", func(ctx zeroapi.Context) {
+		// 不经过 Context 直接写入，例如反向代理
+		ctx.Response().WriteHeader(http.StatusCreated)
+		ctx.Response().Write([]byte("created"))
+	})
+	a.Get("/empty", func(ctx zeroapi.Context) {})
+	a.Router().Build()
+
+	return a
+}
+
+// line 等待并返回第 n 行日志
+func line(t *testing.T, w *buffer, n int) string {
+	for i := 0; i < 100; i++ {
+		lines := strings.Split(w.String(), "\n")
+		if len(lines) > n {
+			return lines[n-1]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("expect %d lines, got %q", n, w.String())
+	return ""
+}
+
+func TestAccessLog(t *testing.T) {
+	w := &buffer{}
+	a := newApp(w)
+
+	r := httptest.NewRequest(http.MethodGet, "/text", nil)
+	r.RemoteAddr = "1.2.3.4:5678"
+	r.Header.Set("Referer", "https://example.com/")
+	r.Header.Set("User-Agent", "test-agent")
+	a.Server().ServeHTTP(httptest.NewRecorder(), r)
+
+	l := line(t, w, 1)
+	if !strings.HasPrefix(l, "1.2.3.4 - - [") {
+		t.Fatalf("unexpected line: %s", l)
+	}
+	if !strings.Contains(l, `] "GET /text HTTP/1.1" 200 5 "https://example.com/" "test-agent" `) {
+		t.Fatalf("unexpected line: %s", l)
+	}
+}
+
+func TestAccessLogWriter(t *testing.T) {
+	w := &buffer{}
+	a := newApp(w)
+
+	a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/raw", nil))
+	if l := line(t, w, 1); !strings.Contains(l, `"POST /raw HTTP/1.1" 201 7 `) {
+		t.Fatalf("unexpected line: %s", l)
+	}
+
+	a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/empty", nil))
+	if l := line(t, w, 2); !strings.Contains(l, `"GET /empty HTTP/1.1" 200 0 `) {
+		t.Fatalf("unexpected line: %s", l)
+	}
+
+	a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if l := line(t, w, 3); !strings.Contains(l, `"GET /missing HTTP/1.1" 404 `) {
+		t.Fatalf("unexpected line: %s", l)
+	}
+}
+
+func TestAccessLogUser(t *testing.T) {
+	w := &buffer{}
+	a := app.NewApp()
+	a.Use(accesslog.New(w))
+	a.Get("/user", func(ctx zeroapi.Context) {
+		ctx.SetValue(zeroapi.ValueKeyPrincipal, "tom")
+	})
+	a.Get("/impersonate", func(ctx zeroapi.Context) {
+		ctx.SetValue(zeroapi.ValueKeyPrincipal, "tom")
+		ctx.SetValue(zeroapi.ValueKeyRealPrincipal, "admin")
+	})
+	a.Router().Build()
+
+	r := httptest.NewRequest(http.MethodGet, "/user", nil)
+	r.RemoteAddr = "1.2.3.4:5678"
+	a.Server().ServeHTTP(httptest.NewRecorder(), r)
+	if l := line(t, w, 1); !strings.HasPrefix(l, "1.2.3.4 - tom [") {
+		t.Fatalf("unexpected line: %s", l)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/impersonate", nil)
+	r.RemoteAddr = "1.2.3.4:5678"
+	a.Server().ServeHTTP(httptest.NewRecorder(), r)
+	if l := line(t, w, 2); !strings.HasPrefix(l, "1.2.3.4 - admin->tom [") {
+		t.Fatalf("unexpected line: %s", l)
+	}
+}
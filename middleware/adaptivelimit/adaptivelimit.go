@@ -0,0 +1,153 @@
+// Package adaptivelimit 自适应并发限制，根据请求耗时自动调整允许同时处理的请求数
+// 过载时耗时上升，并发上限随之下降，多余的请求直接拒绝，避免排队导致所有请求的耗时都变长
+// 参考 Netflix concurrency-limits，提供 AIMD 与 Gradient 两种算法，不需要手动设置固定的并发数
+//
+// 示例:
+// app.Use(adaptivelimit.New(app.Metrics()).Handler())
+// app.Use(adaptivelimit.New(app.Metrics(), adaptivelimit.WithAlgorithm(adaptivelimit.NewAIMD(20, 1, 200, time.Second))).Handler())
+package adaptivelimit
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// metricLimit 当前的并发上限
+	metricLimit = "http_adaptive_limit"
+
+	// metricInflight 正在处理的请求数
+	metricInflight = "http_adaptive_inflight"
+
+	// metricRejected 被拒绝的请求数
+	metricRejected = "http_adaptive_rejected_total"
+)
+
+// ErrRejected 并发数达到上限，请求被拒绝
+var ErrRejected = zeroapi.NewHTTPError(http.StatusServiceUnavailable, "SERVICE UNAVAILABLE")
+
+// Limiter 自适应并发限制
+type Limiter struct {
+	config *config
+
+	limit    zeroapi.Gauge
+	inflight zeroapi.Gauge
+	rejected zeroapi.Metric
+
+	mu sync.Mutex
+
+	// current 正在处理的请求数
+	current int
+
+	// latency 处理耗时的指数移动平均，用于估算 Retry-After
+	latency time.Duration
+}
+
+// New 创建自适应并发限制，指标记录在 m 中
+// http_adaptive_limit: 当前的并发上限，标签 limiter
+// http_adaptive_inflight: 正在处理的请求数，标签 limiter
+// http_adaptive_rejected_total: 被拒绝的请求数，标签 limiter
+func New(m zeroapi.Metrics, opts ...Option) *Limiter {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	l := &Limiter{
+		config:   config,
+		limit:    m.Gauge(metricLimit),
+		inflight: m.Gauge(metricInflight),
+		rejected: m.Counter(metricRejected),
+	}
+	l.limit.Set(float64(config.algorithm.Limit()), "limiter", config.name)
+
+	return l
+}
+
+// Limit 当前的并发上限
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.config.algorithm.Limit()
+}
+
+// Inflight 正在处理的请求数
+func (l *Limiter) Inflight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.current
+}
+
+// Handler 创建中间件，正在处理的请求数达到上限时拒绝请求
+// 响应结束后根据耗时调整上限
+func (l *Limiter) Handler() zeroapi.Handler {
+	name := l.config.name
+
+	return func(ctx zeroapi.Context) {
+		inflight, ok := l.acquire()
+		if !ok {
+			l.rejected.Add(1, "limiter", name)
+			l.reject(ctx)
+			return
+		}
+
+		l.inflight.Add(1, "limiter", name)
+		start := time.Now()
+
+		// 无论之后的处理是否中断或者发生 panic 都会执行
+		ctx.AppendEnd(func() error {
+			limit := l.release(time.Since(start), inflight, l.config.dropped(ctx))
+			l.inflight.Add(-1, "limiter", name)
+			l.limit.Set(float64(limit), "limiter", name)
+			return nil
+		})
+	}
+}
+
+// acquire 未达到上限时占用一个位置，返回包括该请求在内正在处理的请求数
+func (l *Limiter) acquire() (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current >= l.config.algorithm.Limit() {
+		return 0, false
+	}
+
+	l.current++
+	return l.current, true
+}
+
+// release 释放位置并调整上限
+func (l *Limiter) release(rtt time.Duration, inflight int, dropped bool) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.current--
+	if l.latency == 0 {
+		l.latency = rtt
+	} else {
+		l.latency = (l.latency*4 + rtt) / 5
+	}
+
+	return l.config.algorithm.Update(rtt, inflight, dropped)
+}
+
+// reject 拒绝请求，Retry-After 为平均处理耗时，即预计有请求处理完毕的时长
+func (l *Limiter) reject(ctx zeroapi.Context) {
+	l.mu.Lock()
+	latency := l.latency
+	l.mu.Unlock()
+
+	ctx.SetRetryAfter(latency, l.config.jitter)
+
+	if l.config.rejectHandler != nil {
+		l.config.rejectHandler(ctx)
+		ctx.Stopped()
+		return
+	}
+
+	ctx.Error(ErrRejected)
+}
@@ -0,0 +1,134 @@
+package adaptivelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/adaptivelimit"
+)
+
+func TestAIMD(t *testing.T) {
+	a := adaptivelimit.NewAIMD(10, 2, 12, 100*time.Millisecond)
+
+	// 并发数低时不增加
+	if limit := a.Update(time.Millisecond, 1, false); limit != 10 {
+		t.Fatalf("expect 10, got %d", limit)
+	}
+
+	for i := 0; i < 5; i++ {
+		a.Update(time.Millisecond, 10, false)
+	}
+	if a.Limit() != 12 {
+		t.Fatalf("expect max limit 12, got %d", a.Limit())
+	}
+
+	if limit := a.Update(time.Second, 12, false); limit != 10 {
+		t.Fatalf("expect 10 after timeout, got %d", limit)
+	}
+
+	for i := 0; i < 50; i++ {
+		a.Update(time.Millisecond, 10, true)
+	}
+	if a.Limit() != 2 {
+		t.Fatalf("expect min limit 2, got %d", a.Limit())
+	}
+}
+
+func TestGradient(t *testing.T) {
+	g := adaptivelimit.NewGradient(20, 1, 100)
+
+	for i := 0; i < 100; i++ {
+		g.Update(10*time.Millisecond, g.Limit(), false)
+	}
+	grown := g.Limit()
+	if grown <= 20 {
+		t.Fatalf("expect limit to grow with stable latency, got %d", grown)
+	}
+
+	for i := 0; i < 20; i++ {
+		g.Update(100*time.Millisecond, g.Limit(), false)
+	}
+	if g.Limit() >= grown {
+		t.Fatalf("expect limit to shrink with rising latency, got %d >= %d", g.Limit(), grown)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	a := app.NewApp()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	l := adaptivelimit.New(a.Metrics(), adaptivelimit.WithAlgorithm(adaptivelimit.NewAIMD(1, 1, 1, 0)))
+	a.Get("/slow", l.Handler(), func(ctx zeroapi.Context) {
+		entered <- struct{}{}
+		<-release
+	})
+	a.Router().Build()
+
+	retryAfter := ""
+	serve := func() int {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		retryAfter = w.Header().Get("Retry-After")
+		return w.Code
+	}
+
+	codes := make(chan int, 1)
+	go func() { codes <- serve() }()
+	<-entered
+
+	if code := serve(); code != http.StatusServiceUnavailable || retryAfter != "1" {
+		t.Fatalf("expect 503 with Retry-After, got %d %q", code, retryAfter)
+	}
+
+	close(release)
+	if code := <-codes; code != http.StatusOK {
+		t.Fatalf("expect 200, got %d", code)
+	}
+
+	// 位置在响应结束后异步释放
+	deadline := time.Now().Add(time.Second)
+	for l.Inflight() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("slot not released")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	found := false
+	for _, sample := range a.Metrics().Gather() {
+		if sample.Name == "http_adaptive_rejected_total" && sample.Value == 1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("rejected metric not recorded")
+	}
+}
+
+func TestLimiterDropped(t *testing.T) {
+	a := app.NewApp()
+
+	l := adaptivelimit.New(a.Metrics(), adaptivelimit.WithAlgorithm(adaptivelimit.NewAIMD(10, 1, 100, 0)))
+	// 直接写入 Response()，例如反向代理透传上游的 504
+	a.Get("/upstream", l.Handler(), func(ctx zeroapi.Context) {
+		http.Error(ctx.Response(), "gateway timeout", http.StatusGatewayTimeout)
+	})
+	a.Router().Build()
+
+	a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/upstream", nil))
+
+	// 上限在响应结束后异步调整
+	deadline := time.Now().Add(time.Second)
+	for l.Limit() != 9 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect limit to shrink to 9, got %d", l.Limit())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,147 @@
+package adaptivelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Algorithm 根据请求的耗时调整并发上限，由 Limiter 加锁调用，不需要并发安全
+type Algorithm interface {
+	// Limit 当前的并发上限
+	Limit() int
+
+	// Update 记录一次请求的结果，返回新的并发上限
+	// rtt: 请求耗时
+	// inflight: 请求开始时正在处理的请求数，包括该请求
+	// dropped: 请求是否因为过载失败，例如超时
+	Update(rtt time.Duration, inflight int, dropped bool) int
+}
+
+// aimd 加性增，乘性减
+type aimd struct {
+	limit    float64
+	minLimit float64
+	maxLimit float64
+
+	// backoff 过载时的缩减比例
+	backoff float64
+
+	// timeout 耗时超过该值时视为过载
+	timeout time.Duration
+}
+
+// NewAIMD 创建加性增乘性减算法
+// 请求过载或者耗时超过 timeout 时上限乘以 0.9，否则并发数达到上限一半以上时上限加 1
+// timeout 为 0 时只根据 dropped 判断
+func NewAIMD(initialLimit, minLimit, maxLimit int, timeout time.Duration) Algorithm {
+	minLimit, maxLimit = normalize(minLimit, maxLimit)
+
+	return &aimd{
+		limit:    clamp(float64(initialLimit), float64(minLimit), float64(maxLimit)),
+		minLimit: float64(minLimit),
+		maxLimit: float64(maxLimit),
+		backoff:  0.9,
+		timeout:  timeout,
+	}
+}
+
+func (a *aimd) Limit() int {
+	return int(a.limit)
+}
+
+func (a *aimd) Update(rtt time.Duration, inflight int, dropped bool) int {
+	switch {
+	case dropped || (a.timeout > 0 && rtt > a.timeout):
+		a.limit = clamp(math.Floor(a.limit*a.backoff), a.minLimit, a.maxLimit)
+	case float64(inflight)*2 >= a.limit:
+		// 并发数远低于上限时，耗时短不能说明可以承受更多请求
+		a.limit = clamp(a.limit+1, a.minLimit, a.maxLimit)
+	}
+
+	return int(a.limit)
+}
+
+// gradient 根据长期平均耗时与本次耗时的比值调整上限
+type gradient struct {
+	limit    float64
+	minLimit float64
+	maxLimit float64
+
+	// longRTT 长期平均耗时，指数移动平均
+	longRTT float64
+
+	// alpha 长期平均耗时的平滑系数
+	alpha float64
+
+	// tolerance 允许本次耗时超过长期平均耗时的倍数
+	tolerance float64
+
+	// smoothing 新上限的权重
+	smoothing float64
+}
+
+// NewGradient 创建梯度算法，参考 Netflix concurrency-limits 的 Gradient2
+// 本次耗时高于长期平均耗时时按比例减小上限，否则逐步增大，增加的排队数为 sqrt(limit)
+func NewGradient(initialLimit, minLimit, maxLimit int) Algorithm {
+	minLimit, maxLimit = normalize(minLimit, maxLimit)
+
+	return &gradient{
+		limit:     clamp(float64(initialLimit), float64(minLimit), float64(maxLimit)),
+		minLimit:  float64(minLimit),
+		maxLimit:  float64(maxLimit),
+		alpha:     2.0 / (600 + 1),
+		tolerance: 1.5,
+		smoothing: 0.2,
+	}
+}
+
+func (g *gradient) Limit() int {
+	return int(g.limit)
+}
+
+func (g *gradient) Update(rtt time.Duration, inflight int, dropped bool) int {
+	shortRTT := float64(rtt)
+	if shortRTT <= 0 {
+		return int(g.limit)
+	}
+
+	if g.longRTT == 0 {
+		g.longRTT = shortRTT
+	} else {
+		g.longRTT = g.longRTT*(1-g.alpha) + shortRTT*g.alpha
+	}
+
+	// 负载下降后耗时明显变短，长期平均耗时加快向本次耗时靠拢，避免上限长时间偏高
+	if g.longRTT/shortRTT > 2 {
+		g.longRTT *= 0.95
+	}
+
+	// 并发数远低于上限时不调整
+	if !dropped && float64(inflight)*2 < g.limit {
+		return int(g.limit)
+	}
+
+	ratio := clamp(g.tolerance*g.longRTT/shortRTT, 0.5, 1)
+	if dropped {
+		ratio = 0.5
+	}
+
+	next := g.limit*ratio + math.Sqrt(g.limit)
+	g.limit = clamp(g.limit*(1-g.smoothing)+next*g.smoothing, g.minLimit, g.maxLimit)
+
+	return int(g.limit)
+}
+
+func normalize(minLimit, maxLimit int) (int, int) {
+	if minLimit < 1 {
+		minLimit = 1
+	}
+	if maxLimit < minLimit {
+		maxLimit = minLimit
+	}
+	return minLimit, maxLimit
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
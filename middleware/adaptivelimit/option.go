@@ -0,0 +1,86 @@
+package adaptivelimit
+
+import (
+	"net/http"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// config 自适应限流配置
+type config struct {
+	// name 名称，用于指标标签
+	name string
+
+	// algorithm 调整并发上限的算法
+	algorithm Algorithm
+
+	// dropped 判断请求是否因为过载失败
+	dropped func(ctx zeroapi.Context) bool
+
+	// rejectHandler 拒绝请求时的处理函数，为空时响应 503
+	rejectHandler zeroapi.Handler
+
+	// jitter Retry-After 随机增加的最大时长
+	jitter time.Duration
+}
+
+func defaultConfig() *config {
+	return &config{
+		name:      "default",
+		algorithm: NewGradient(20, 1, 1000),
+		dropped:   defaultDropped,
+	}
+}
+
+// defaultDropped 响应 503, 504 时视为过载，包括反向代理透传的上游响应
+func defaultDropped(ctx zeroapi.Context) bool {
+	code := ctx.Response().Status()
+	return code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// Option 自适应限流配置选项
+type Option func(config *config)
+
+// WithName 设置名称，用于指标标签，默认 default
+func WithName(name string) Option {
+	return func(config *config) {
+		if name != "" {
+			config.name = name
+		}
+	}
+}
+
+// WithAlgorithm 设置调整并发上限的算法，默认 NewGradient(20, 1, 1000)
+func WithAlgorithm(algorithm Algorithm) Option {
+	return func(config *config) {
+		if algorithm != nil {
+			config.algorithm = algorithm
+		}
+	}
+}
+
+// WithDropped 设置判断请求是否因为过载失败的函数，默认响应 503, 504 时视为过载
+func WithDropped(dropped func(ctx zeroapi.Context) bool) Option {
+	return func(config *config) {
+		if dropped != nil {
+			config.dropped = dropped
+		}
+	}
+}
+
+// WithRejectHandler 设置拒绝请求时的处理函数，默认响应 503，调用前已经设置了 Retry-After
+func WithRejectHandler(handler zeroapi.Handler) Option {
+	return func(config *config) {
+		config.rejectHandler = handler
+	}
+}
+
+// WithJitter 拒绝请求时 Retry-After 随机增加 [0, jitter) 的时长，避免客户端同时重试，默认不增加
+func WithJitter(jitter time.Duration) Option {
+	return func(config *config) {
+		if jitter >= 0 {
+			config.jitter = jitter
+		}
+	}
+}
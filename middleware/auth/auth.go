@@ -0,0 +1,135 @@
+// Package auth Basic 认证与 API Key 认证中间件，用于快速保护内部接口，例如指标、管理后台
+// 比较用户名、密码以及 API Key 时使用常量时间比较，避免通过响应时间猜测
+// 客服等后台功能可以通过 Impersonate 与 Impersonation 模拟其它用户，同时记录真实用户
+//
+// 示例:
+// app.Get("/metrics", auth.BasicAuth("metrics", auth.Users(map[string]string{"prometheus": "secret"})), handler)
+// app.Group("/internal").Use(auth.APIKey("X-API-Key", auth.Keys(os.Getenv("API_KEY"))))
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// ReasonUnauthorized 认证失败，用于 ctx.ClientError
+const ReasonUnauthorized = "unauthorized"
+
+// HeaderAPIKey 默认的 API Key 请求头
+const HeaderAPIKey = "X-API-Key"
+
+// valueKeyUser 认证通过的用户名保存在 ctx.Value 中的键
+const valueKeyUser = zeroapi.ValueKeyPrincipal
+
+// BasicValidator 验证用户名与密码
+type BasicValidator func(ctx zeroapi.Context, username, password string) bool
+
+// KeyValidator 验证 API Key
+type KeyValidator func(ctx zeroapi.Context, key string) bool
+
+// BasicAuth 创建 Basic 认证中间件，认证失败时响应 401 以及 WWW-Authenticate，浏览器会弹出登录框
+// realm: 认证域，显示在浏览器登录框中
+func BasicAuth(realm string, validator BasicValidator) zeroapi.Handler {
+	if realm == "" {
+		realm = "Restricted"
+	}
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+
+	return func(ctx zeroapi.Context) {
+		username, password, ok := ctx.Request().BasicAuth()
+		if ok && validator != nil && validator(ctx, username, password) {
+			ctx.SetValue(valueKeyUser, username)
+			return
+		}
+
+		ctx.SetHeader("WWW-Authenticate", challenge)
+		ctx.ClientError(http.StatusUnauthorized, ReasonUnauthorized, "UNAUTHORIZED")
+	}
+}
+
+// APIKey 创建 API Key 认证中间件，认证失败时响应 401
+// header: 携带 API Key 的请求头，为空时使用 X-API-Key，为 Authorization 时去掉 Bearer 前缀
+func APIKey(header string, validator KeyValidator) zeroapi.Handler {
+	if header == "" {
+		header = HeaderAPIKey
+	}
+	bearer := strings.EqualFold(header, "Authorization")
+
+	return func(ctx zeroapi.Context) {
+		key := ctx.Header(header)
+		if bearer && len(key) > 7 && strings.EqualFold(key[:7], "Bearer ") {
+			key = key[7:]
+		}
+
+		if key != "" && validator != nil && validator(ctx, key) {
+			return
+		}
+
+		ctx.ClientError(http.StatusUnauthorized, ReasonUnauthorized, "UNAUTHORIZED")
+	}
+}
+
+// User 获取通过 Basic 认证的用户名，没有认证时为空字符串，模拟其它用户时为被模拟的用户，见 RealUser
+func User(ctx zeroapi.Context) string {
+	if user, ok := ctx.Value(valueKeyUser).(string); ok {
+		return user
+	}
+	return ""
+}
+
+// Users 使用固定的用户名与密码验证，key 为用户名，value 为密码
+func Users(users map[string]string) BasicValidator {
+	type credential struct {
+		username, password [sha256.Size]byte
+	}
+
+	credentials := make([]credential, 0, len(users))
+	for username, password := range users {
+		credentials = append(credentials, credential{
+			username: sha256.Sum256([]byte(username)),
+			password: sha256.Sum256([]byte(password)),
+		})
+	}
+
+	return func(_ zeroapi.Context, username, password string) bool {
+		u, p := sha256.Sum256([]byte(username)), sha256.Sum256([]byte(password))
+
+		// 比较所有用户，耗时与用户是否存在无关
+		match := 0
+		for _, c := range credentials {
+			match |= subtle.ConstantTimeCompare(u[:], c.username[:]) & subtle.ConstantTimeCompare(p[:], c.password[:])
+		}
+		return match == 1
+	}
+}
+
+// Keys 使用固定的 API Key 验证
+func Keys(keys ...string) KeyValidator {
+	digests := make([][sha256.Size]byte, 0, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			digests = append(digests, sha256.Sum256([]byte(key)))
+		}
+	}
+
+	return func(_ zeroapi.Context, key string) bool {
+		k := sha256.Sum256([]byte(key))
+
+		match := 0
+		for _, digest := range digests {
+			match |= subtle.ConstantTimeCompare(k[:], digest[:])
+		}
+		return match == 1
+	}
+}
+
+// SecureCompare 常量时间比较两个字符串，耗时与长度以及相同的前缀无关，用于自定义的验证函数
+func SecureCompare(a, b string) bool {
+	x, y := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(x[:], y[:]) == 1
+}
@@ -0,0 +1,97 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/auth"
+)
+
+func newApp() zeroapi.App {
+	a := app.NewApp()
+
+	a.Get("/basic", auth.BasicAuth("internal", auth.Users(map[string]string{"admin": "secret"})), func(ctx zeroapi.Context) {
+		ctx.Text(auth.User(ctx))
+	})
+	a.Get("/key", auth.APIKey("", auth.Keys("k1", "k2")), func(ctx zeroapi.Context) {
+		ctx.Text("ok")
+	})
+	a.Get("/bearer", auth.APIKey("Authorization", auth.Keys("token")), func(ctx zeroapi.Context) {
+		ctx.Text("ok")
+	})
+	a.Get("/public", func(ctx zeroapi.Context) {
+		ctx.Text("ok")
+	})
+	a.Router().Build()
+	return a
+}
+
+func serve(a zeroapi.App, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w
+}
+
+func TestBasicAuth(t *testing.T) {
+	a := newApp()
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/basic", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("missing credentials: %d", w.Code)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); got != `Basic realm="internal", charset="UTF-8"` {
+		t.Fatalf("unexpected challenge: %s", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/basic", nil)
+	req.SetBasicAuth("admin", "wrong")
+	if w := serve(a, req); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/basic", nil)
+	req.SetBasicAuth("admin", "secret")
+	w = serve(a, req)
+	if w.Code != http.StatusOK || w.Body.String() != "admin" {
+		t.Fatalf("valid credentials: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/public", nil)); w.Code != http.StatusOK {
+		t.Fatalf("public route: %d", w.Code)
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	a := newApp()
+
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/key", nil)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("missing key: %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/key", nil)
+	req.Header.Set(auth.HeaderAPIKey, "k3")
+	if w := serve(a, req); w.Code != http.StatusUnauthorized {
+		t.Fatalf("invalid key: %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/key", nil)
+	req.Header.Set(auth.HeaderAPIKey, "k2")
+	if w := serve(a, req); w.Code != http.StatusOK {
+		t.Fatalf("valid key: %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/bearer", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	if w := serve(a, req); w.Code != http.StatusOK {
+		t.Fatalf("bearer token: %d", w.Code)
+	}
+}
+
+func TestSecureCompare(t *testing.T) {
+	if !auth.SecureCompare("abc", "abc") || auth.SecureCompare("abc", "abd") || auth.SecureCompare("abc", "abcd") {
+		t.Fatal("unexpected compare result")
+	}
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/session"
+)
+
+// ReasonImpersonationDenied 不允许模拟该用户
+const ReasonImpersonationDenied = "impersonation_denied"
+
+// valueKeyRealUser 模拟其它用户时，真实用户保存在 ctx.Value 中的键
+const valueKeyRealUser = zeroapi.ValueKeyRealPrincipal
+
+// keyImpersonate 会话中保存被模拟用户的键
+const keyImpersonate = "auth.impersonate"
+
+var (
+	// ErrNotAuthenticated 没有认证通过的用户，无法模拟其它用户
+	ErrNotAuthenticated = errors.New("auth: not authenticated")
+
+	// ErrImpersonationDenied 不允许模拟该用户
+	ErrImpersonationDenied = errors.New("auth: impersonation denied")
+)
+
+// Impersonate 在当前请求中模拟 target，认证通过的用户成为真实用户，target 成为有效用户
+// 之后 User 返回 target，RealUser 返回真实用户，ctx.Copy() 的快照与访问日志同时记录两者
+// 已经在模拟其它用户时，真实用户保持不变
+func Impersonate(ctx zeroapi.Context, target string) error {
+	realUser := RealUser(ctx)
+	if realUser == "" {
+		return ErrNotAuthenticated
+	}
+
+	ctx.SetValue(valueKeyRealUser, realUser)
+	ctx.SetValue(valueKeyUser, target)
+	return nil
+}
+
+// RealUser 获取真实用户，模拟其它用户时为发起模拟的用户，否则同 User
+func RealUser(ctx zeroapi.Context) string {
+	if user, ok := ctx.Value(valueKeyRealUser).(string); ok && user != "" {
+		return user
+	}
+	return User(ctx)
+}
+
+// IsImpersonating 当前请求是否在模拟其它用户
+func IsImpersonating(ctx zeroapi.Context) bool {
+	user, ok := ctx.Value(valueKeyRealUser).(string)
+	return ok && user != ""
+}
+
+// ImpersonateAuthorizer 判断真实用户是否可以模拟 target，例如只允许客服模拟普通用户
+type ImpersonateAuthorizer func(ctx zeroapi.Context, realUser, target string) bool
+
+// Impersonation 跨请求的模拟登录，被模拟的用户保存在会话中，用于客服等后台功能
+//
+// 示例:
+// i := auth.NewImpersonation(store, "session", isSupportStaff)
+// admin := app.Group("/admin")
+// admin.Use(auth.BasicAuth("admin", validator), i.Middleware())
+// admin.Post("/impersonate/:user", func(ctx zeroapi.Context) { i.Start(ctx, ctx.Dynamic("user")) })
+// admin.Post("/impersonate/revert", i.Revert)
+type Impersonation struct {
+	store     session.Store
+	name      string
+	authorize ImpersonateAuthorizer
+}
+
+// NewImpersonation 创建模拟登录，name 为保存状态的会话名称，authorize 为空时不允许模拟
+func NewImpersonation(store session.Store, name string, authorize ImpersonateAuthorizer) *Impersonation {
+	if name == "" {
+		name = "session"
+	}
+
+	return &Impersonation{store: store, name: name, authorize: authorize}
+}
+
+// Middleware 在认证中间件之后使用，会话中有被模拟的用户时调用 Impersonate
+// 每个请求都会重新检查 authorize，真实用户失去权限后模拟随之失效
+func (i *Impersonation) Middleware() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		sess, err := i.store.Get(ctx, i.name)
+		if err != nil {
+			return
+		}
+
+		target, _ := sess.Get(keyImpersonate).(string)
+		if target == "" || !i.allowed(ctx, target) {
+			return
+		}
+
+		Impersonate(ctx, target)
+	}
+}
+
+// Start 开始模拟 target，保存到会话中，并记录审计日志
+// 不允许时响应 403 并返回错误，获取或者保存会话失败时交给 ctx.Error 并返回错误
+// 成功时由调用者继续响应，例如跳转到用户首页
+func (i *Impersonation) Start(ctx zeroapi.Context, target string) error {
+	realUser := RealUser(ctx)
+	if realUser == "" {
+		ctx.ClientError(http.StatusUnauthorized, ReasonUnauthorized, "UNAUTHORIZED")
+		return ErrNotAuthenticated
+	}
+	if target == "" || !i.allowed(ctx, target) {
+		ctx.ClientError(http.StatusForbidden, ReasonImpersonationDenied, "IMPERSONATION DENIED")
+		return ErrImpersonationDenied
+	}
+
+	sess, err := i.store.Get(ctx, i.name)
+	if err != nil {
+		ctx.Error(err)
+		return err
+	}
+
+	sess.Set(keyImpersonate, target)
+	if err := i.store.Save(ctx, sess); err != nil {
+		ctx.Error(err)
+		return err
+	}
+
+	Impersonate(ctx, target)
+	ctx.App().Logger().Infof("impersonation start, real user: %s, user: %s", realUser, target)
+	return nil
+}
+
+// Revert 结束模拟，恢复为真实用户，用作路由处理函数，成功时响应 204
+// 当前没有模拟其它用户时同样响应 204
+func (i *Impersonation) Revert(ctx zeroapi.Context) {
+	sess, err := i.store.Get(ctx, i.name)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	target, _ := sess.Get(keyImpersonate).(string)
+	sess.Delete(keyImpersonate)
+	if err := i.store.Save(ctx, sess); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if IsImpersonating(ctx) {
+		realUser := RealUser(ctx)
+		ctx.SetValue(valueKeyUser, realUser)
+		ctx.SetValue(valueKeyRealUser, nil)
+		ctx.App().Logger().Infof("impersonation revert, real user: %s, user: %s", realUser, target)
+	}
+
+	ctx.SetHTTPCode(http.StatusNoContent)
+}
+
+// allowed 真实用户是否可以模拟 target
+func (i *Impersonation) allowed(ctx zeroapi.Context, target string) bool {
+	realUser := RealUser(ctx)
+	return realUser != "" && realUser != target && i.authorize != nil && i.authorize(ctx, realUser, target)
+}
@@ -0,0 +1,127 @@
+package auth_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/auth"
+	"github.com/zerogo-hub/zero-api/session"
+)
+
+func newImpersonationApp() zeroapi.App {
+	store := session.NewCookieStore(session.StaticKeys(bytes.Repeat([]byte{1}, 32)))
+	i := auth.NewImpersonation(store, "", func(ctx zeroapi.Context, realUser, target string) bool {
+		return realUser == "admin" && target != "root"
+	})
+
+	a := app.NewApp()
+	admin := a.Group("/admin")
+	admin.Use(auth.BasicAuth("admin", auth.Users(map[string]string{"admin": "secret", "tom": "secret"})), i.Middleware())
+	admin.Post("/impersonate/:user", func(ctx zeroapi.Context) {
+		if i.Start(ctx, ctx.Dynamic("user")) == nil {
+			ctx.Text(auth.User(ctx))
+		}
+	})
+	admin.Post("/revert", i.Revert)
+	admin.Get("/whoami", func(ctx zeroapi.Context) {
+		ctx.Text(auth.RealUser(ctx) + " as " + auth.User(ctx) + " " + ctx.Copy().RealPrincipal())
+	})
+	a.Router().Build()
+	return a
+}
+
+// request 以 user 认证后发送请求
+func request(a zeroapi.App, method, target, user string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.SetBasicAuth(user, "secret")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	return serve(a, req)
+}
+
+func TestImpersonate(t *testing.T) {
+	a := newImpersonationApp()
+
+	w := request(a, http.MethodPost, "/admin/impersonate/tom", "admin", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "tom" {
+		t.Fatalf("start: %d %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+
+	// 之后的请求同时记录真实用户与被模拟的用户
+	if w := request(a, http.MethodGet, "/admin/whoami", "admin", cookies); w.Body.String() != "admin as tom admin" {
+		t.Fatalf("impersonating: %s", w.Body.String())
+	}
+
+	// 模拟状态保存在会话中，其它用户使用该会话时不生效
+	if w := request(a, http.MethodGet, "/admin/whoami", "tom", cookies); w.Body.String() != "tom as tom tom" {
+		t.Fatalf("other user: %s", w.Body.String())
+	}
+
+	w = request(a, http.MethodPost, "/admin/revert", "admin", cookies)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("revert: %d", w.Code)
+	}
+	if w := request(a, http.MethodGet, "/admin/whoami", "admin", w.Result().Cookies()); w.Body.String() != "admin as admin admin" {
+		t.Fatalf("reverted: %s", w.Body.String())
+	}
+}
+
+func TestImpersonateDenied(t *testing.T) {
+	a := newImpersonationApp()
+
+	if w := request(a, http.MethodPost, "/admin/impersonate/root", "admin", nil); w.Code != http.StatusForbidden {
+		t.Fatalf("expect 403, got %d", w.Code)
+	}
+	if w := request(a, http.MethodPost, "/admin/impersonate/admin", "tom", nil); w.Code != http.StatusForbidden {
+		t.Fatalf("expect 403, got %d", w.Code)
+	}
+
+	ctx := app.NewApp().Context()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if err := auth.Impersonate(ctx, "tom"); err != auth.ErrNotAuthenticated {
+		t.Fatalf("expect ErrNotAuthenticated, got %v", err)
+	}
+}
+
+var errStore = errors.New("store unavailable")
+
+// failingStore 获取会话失败的存储
+type failingStore struct{}
+
+func (failingStore) Get(ctx zeroapi.Context, name string) (*session.Session, error) {
+	return nil, errStore
+}
+
+func (failingStore) Save(ctx zeroapi.Context, s *session.Session) error {
+	return errStore
+}
+
+func TestImpersonateStoreError(t *testing.T) {
+	i := auth.NewImpersonation(failingStore{}, "", func(ctx zeroapi.Context, realUser, target string) bool {
+		return true
+	})
+
+	a := app.NewApp()
+	a.Use(auth.BasicAuth("admin", auth.Users(map[string]string{"admin": "secret"})))
+	a.Post("/impersonate/:user", func(ctx zeroapi.Context) {
+		if err := i.Start(ctx, ctx.Dynamic("user")); err != errStore {
+			t.Errorf("expect store error, got %v", err)
+		}
+	})
+	a.Post("/revert", i.Revert)
+	a.Router().Build()
+
+	if w := request(a, http.MethodPost, "/impersonate/tom", "admin", nil); w.Code != http.StatusInternalServerError {
+		t.Fatalf("start: expect 500, got %d", w.Code)
+	}
+	if w := request(a, http.MethodPost, "/revert", "admin", nil); w.Code != http.StatusInternalServerError {
+		t.Fatalf("revert: expect 500, got %d", w.Code)
+	}
+}
@@ -0,0 +1,478 @@
+// Package oauth2 OAuth2 授权码流程客户端，用于实现 "使用 X 登录"
+// 包括登录跳转、state 与 nonce 校验、PKCE、令牌交换、获取用户信息，登录状态保存在会话中
+// 提供方支持 OpenID Connect 时，同时校验 id_token 中的 iss, aud, exp 以及 nonce
+//
+// 授权服务器通过浏览器重定向回到回调地址，这是跨站的顶级 GET 导航，会话 cookie 为 SameSite=Strict 时浏览器不会发送
+// 使用 WithFormPost 时授权服务器通过跨站 POST 表单回调，SameSite=Lax 的会话 cookie 同样不会发送
+// 因此登录时 state, nonce, code_verifier 同时保存在 SameSite=None 的短期 cookie 中，会话中没有 state 时使用该 cookie 校验 (double submit)
+//
+// 示例:
+// store := session.NewCookieStore(session.StaticKeys(key))
+// provider, err := oauth2.Discover(http.DefaultClient, "https://accounts.google.com")
+// provider.ClientID, provider.ClientSecret = clientID, clientSecret
+// provider.RedirectURL = "https://example.com/auth/callback"
+// provider.Scopes = []string{"openid", "email", "profile"}
+//
+// c := oauth2.New(store, provider, oauth2.WithRand(app.Rand()), oauth2.WithNow(app.Now))
+// app.Get("/login", c.Login)
+// app.Get("/auth/callback", c.Callback)
+// app.Post("/auth/callback", c.Callback) // 使用 WithFormPost 时
+// app.Post("/logout", c.Logout)
+// app.Group("/account").Use(c.Require())
+//
+// 使用多个提供方登录同一个本地用户时使用 Registry，见 NewRegistry
+package oauth2
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/middleware/auth"
+	"github.com/zerogo-hub/zero-api/session"
+)
+
+// ReasonInvalidState 回调中的 state 与会话中的不一致，可能是 CSRF 攻击或者会话已经过期
+const ReasonInvalidState = "oauth2_invalid_state"
+
+// ReasonAccessDenied 授权服务器返回了错误，例如用户拒绝授权
+const ReasonAccessDenied = "oauth2_access_denied"
+
+// 会话中的键
+const (
+	keyState    = "oauth2.state"
+	keyNonce    = "oauth2.nonce"
+	keyVerifier = "oauth2.verifier"
+	keyReturnTo = "oauth2.return_to"
+	keyUser     = "oauth2.user"
+)
+
+// stateCookieMaxAge state cookie 的有效期，需要在这段时间内完成授权
+const stateCookieMaxAge = 10 * time.Minute
+
+var (
+	// ErrTokenExchange 使用授权码交换令牌失败
+	ErrTokenExchange = errors.New("oauth2: token exchange failed")
+
+	// ErrUserInfo 获取用户信息失败
+	ErrUserInfo = errors.New("oauth2: userinfo request failed")
+
+	// ErrLoginFailed 登录失败，响应 502
+	ErrLoginFailed = zeroapi.NewHTTPError(http.StatusBadGateway, "LOGIN FAILED")
+)
+
+// Provider 授权服务器
+type Provider struct {
+	// ClientID 客户端 id
+	ClientID string
+
+	// ClientSecret 客户端密钥，使用 client_secret_post 方式发送
+	ClientSecret string
+
+	// AuthURL 授权地址
+	AuthURL string
+
+	// TokenURL 令牌地址
+	TokenURL string
+
+	// UserInfoURL 用户信息地址，为空时不获取用户信息
+	UserInfoURL string
+
+	// Issuer OpenID Connect 签发者，不为空时校验 id_token
+	Issuer string
+
+	// RedirectURL 回调地址，需要与在授权服务器中登记的一致
+	RedirectURL string
+
+	// Scopes 申请的权限，OpenID Connect 需要包括 openid
+	Scopes []string
+
+	// AuthParams 附加到授权地址的参数，例如 prompt=consent
+	AuthParams url.Values
+}
+
+// Token 令牌
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+
+	// Expiry 过期时间，根据 ExpiresIn 计算，授权服务器没有返回时为空
+	Expiry time.Time `json:"-"`
+}
+
+// UserInfo 用户信息，即 userinfo 接口返回的数据或者 id_token 中的声明
+type UserInfo map[string]interface{}
+
+// Subject 用户在授权服务器中的唯一 id
+func (u UserInfo) Subject() string {
+	return u.String("sub")
+}
+
+// Email 邮箱
+func (u UserInfo) Email() string {
+	return u.String("email")
+}
+
+// Name 名称
+func (u UserInfo) Name() string {
+	return u.String("name")
+}
+
+// String 获取字符串类型的字段
+func (u UserInfo) String(key string) string {
+	switch v := u[key].(type) {
+	case string:
+		return v
+	case float64:
+		// 部分授权服务器的用户 id 为数字
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// Login 一次成功的登录
+type Login struct {
+	// Token 令牌
+	Token *Token
+
+	// Claims id_token 中的声明，不是 OpenID Connect 时为空
+	Claims UserInfo
+
+	// User 用户信息，合并了 id_token 与 userinfo 接口返回的数据
+	User UserInfo
+}
+
+// Client OAuth2 客户端
+type Client struct {
+	provider Provider
+	store    session.Store
+	config   *config
+}
+
+// New 创建 OAuth2 客户端，store 保存登录过程中的 state 以及登录后的用户信息
+func New(store session.Store, provider Provider, opts ...Option) *Client {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Client{provider: provider, store: store, config: config}
+}
+
+// Login 登录，生成 state, nonce 与 PKCE code_verifier 保存在会话中，然后重定向到授权地址
+// 查询参数 return_to 为登录成功后跳转的地址，只能是本站的路径
+func (c *Client) Login(ctx zeroapi.Context) {
+	sess, _ := c.store.Get(ctx, c.config.sessionName)
+
+	state, err := c.random()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	nonce, err := c.random()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	verifier, err := c.random()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	returnTo := ctx.Query("return_to")
+	if !isLocalPath(returnTo) {
+		returnTo = c.config.defaultReturnTo
+	}
+
+	sess.Set(keyState, state)
+	sess.Set(keyNonce, nonce)
+	sess.Set(keyVerifier, verifier)
+	sess.Set(keyReturnTo, returnTo)
+	if err := c.store.Save(ctx, sess); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.setStateCookie(ctx, url.Values{
+		keyState:    {state},
+		keyNonce:    {nonce},
+		keyVerifier: {verifier},
+		keyReturnTo: {returnTo},
+	}, int(stateCookieMaxAge/time.Second))
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	params := url.Values{}
+	for key, values := range c.provider.AuthParams {
+		params[key] = values
+	}
+	params.Set("response_type", "code")
+	params.Set("client_id", c.provider.ClientID)
+	params.Set("redirect_uri", c.provider.RedirectURL)
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+	if len(c.provider.Scopes) > 0 {
+		params.Set("scope", strings.Join(c.provider.Scopes, " "))
+	}
+	if c.config.formPost {
+		params.Set("response_mode", "form_post")
+	}
+
+	authURL := c.provider.AuthURL
+	if strings.Contains(authURL, "?") {
+		authURL += "&" + params.Encode()
+	} else {
+		authURL += "?" + params.Encode()
+	}
+
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 回调，校验 state，交换令牌，获取用户信息，保存到会话后跳转到登录前的地址
+// 支持 GET 查询参数以及 response_mode=form_post 的 POST 表单
+func (c *Client) Callback(ctx zeroapi.Context) {
+	sess, _ := c.store.Get(ctx, c.config.sessionName)
+
+	state, _ := sess.Get(keyState).(string)
+	nonce, _ := sess.Get(keyNonce).(string)
+	verifier, _ := sess.Get(keyVerifier).(string)
+	returnTo, _ := sess.Get(keyReturnTo).(string)
+
+	// 浏览器没有发送会话 cookie 时使用 state cookie
+	if cookie, err := ctx.Request().Cookie(c.config.stateCookie); err == nil {
+		if state == "" {
+			values, _ := url.ParseQuery(cookie.Value)
+			state, nonce = values.Get(keyState), values.Get(keyNonce)
+			verifier, returnTo = values.Get(keyVerifier), values.Get(keyReturnTo)
+		}
+		c.setStateCookie(ctx, nil, -1)
+	}
+
+	// state 只能使用一次
+	sess.Delete(keyState)
+	sess.Delete(keyNonce)
+	sess.Delete(keyVerifier)
+	sess.Delete(keyReturnTo)
+
+	if reason := param(ctx, "error"); reason != "" {
+		c.store.Save(ctx, sess)
+		ctx.ClientError(http.StatusForbidden, ReasonAccessDenied, "ACCESS DENIED")
+		return
+	}
+
+	got := param(ctx, "state")
+	if state == "" || subtle.ConstantTimeCompare([]byte(got), []byte(state)) != 1 {
+		c.store.Save(ctx, sess)
+		ctx.ClientError(http.StatusBadRequest, ReasonInvalidState, "INVALID STATE")
+		return
+	}
+
+	login, err := c.exchange(ctx, param(ctx, "code"), verifier, nonce)
+	if err == nil && c.config.onLogin != nil {
+		err = c.config.onLogin(ctx, login)
+	}
+	if err != nil {
+		c.store.Save(ctx, sess)
+		var httpErr *zeroapi.HTTPError
+		if !errors.As(err, &httpErr) {
+			httpErr = ErrLoginFailed.Wrap(err)
+		}
+		ctx.Error(httpErr)
+		return
+	}
+
+	sess.Set(keyUser, map[string]interface{}(login.User))
+	if err := c.store.Save(ctx, sess); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !isLocalPath(returnTo) {
+		returnTo = c.config.defaultReturnTo
+	}
+	ctx.Redirect(http.StatusFound, returnTo)
+}
+
+// Logout 退出登录，从会话中删除用户信息，然后跳转到默认地址
+func (c *Client) Logout(ctx zeroapi.Context) {
+	sess, _ := c.store.Get(ctx, c.config.sessionName)
+	sess.Delete(keyUser)
+	if err := c.store.Save(ctx, sess); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.Redirect(http.StatusFound, c.config.defaultReturnTo)
+}
+
+// User 获取当前登录的用户，没有登录时返回 false
+func (c *Client) User(ctx zeroapi.Context) (UserInfo, bool) {
+	sess, err := c.store.Get(ctx, c.config.sessionName)
+	if err != nil {
+		return nil, false
+	}
+	user, ok := sess.Get(keyUser).(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return UserInfo(user), true
+}
+
+// Require 需要登录的中间件，浏览器访问时重定向到登录路由，登录后返回当前地址，其它请求响应 401
+func (c *Client) Require() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		if _, ok := c.User(ctx); ok {
+			return
+		}
+
+		if ctx.Method() == http.MethodGet && ctx.PrefersHTML() {
+			ctx.Redirect(http.StatusFound, c.config.loginPath+"?return_to="+url.QueryEscape(ctx.Request().URL.RequestURI()))
+			return
+		}
+
+		ctx.ClientError(http.StatusUnauthorized, auth.ReasonUnauthorized, "UNAUTHORIZED")
+	}
+}
+
+// setStateCookie 设置或者删除(maxAge < 0) state cookie
+// 授权服务器跨站回调时也需要发送，所以为 SameSite=None，浏览器要求同时设置 Secure
+func (c *Client) setStateCookie(ctx zeroapi.Context, values url.Values, maxAge int) {
+	path := "/"
+	if u, err := url.Parse(c.provider.RedirectURL); err == nil && u.Path != "" {
+		path = u.Path
+	}
+
+	ctx.SetHTTPCookie(&http.Cookie{
+		Name:     c.config.stateCookie,
+		Value:    values.Encode(),
+		Path:     path,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	})
+}
+
+// param 获取回调参数，response_mode=form_post 时授权服务器通过 POST 表单提交
+func param(ctx zeroapi.Context, name string) string {
+	if ctx.Method() == http.MethodPost {
+		return ctx.Request().PostFormValue(name)
+	}
+	return ctx.Query(name)
+}
+
+// exchange 使用授权码交换令牌，校验 id_token 并获取用户信息
+func (c *Client) exchange(ctx zeroapi.Context, code, verifier, nonce string) (*Login, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.provider.RedirectURL)
+	form.Set("client_id", c.provider.ClientID)
+	form.Set("code_verifier", verifier)
+	if c.provider.ClientSecret != "" {
+		form.Set("client_secret", c.provider.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodPost, c.provider.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	token := &Token{}
+	if err := c.do(req, token); err != nil {
+		return nil, ErrTokenExchange
+	}
+	if token.AccessToken == "" {
+		return nil, ErrTokenExchange
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = c.config.now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	login := &Login{Token: token, User: UserInfo{}}
+
+	if c.provider.Issuer != "" {
+		claims, err := c.verifyIDToken(token.IDToken, nonce)
+		if err != nil {
+			return nil, err
+		}
+		login.Claims = claims
+		for key, value := range claims {
+			login.User[key] = value
+		}
+	}
+
+	if c.provider.UserInfoURL != "" {
+		req, err := http.NewRequestWithContext(ctx.Context(), http.MethodGet, c.provider.UserInfoURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		req.Header.Set("Accept", "application/json")
+
+		info := UserInfo{}
+		if err := c.do(req, &info); err != nil {
+			return nil, ErrUserInfo
+		}
+
+		// userinfo 中的 sub 必须与 id_token 一致
+		if login.Claims != nil && info.Subject() != login.Claims.Subject() {
+			return nil, ErrUserInfo
+		}
+		for key, value := range info {
+			login.User[key] = value
+		}
+	}
+
+	return login, nil
+}
+
+// do 发送请求并解析 JSON 响应
+func (c *Client) do(req *http.Request, v interface{}) error {
+	res, err := c.config.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return errors.New("oauth2: unexpected status " + strconv.Itoa(res.StatusCode))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// random 生成 32 字节的随机字符串
+func (c *Client) random() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(c.config.rand, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// isLocalPath 是否是本站的路径，防止登录后跳转到其它网站
+func isLocalPath(path string) bool {
+	return strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") && !strings.HasPrefix(path, "/\\")
+}
@@ -0,0 +1,251 @@
+package oauth2_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/auth/oauth2"
+	"github.com/zerogo-hub/zero-api/session"
+)
+
+// fakeProvider 模拟 OpenID Connect 授权服务器
+type fakeProvider struct {
+	*httptest.Server
+
+	// nonce, challenge 由测试从授权地址中取出
+	nonce, challenge string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	p := &fakeProvider{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"userinfo_endpoint":      p.URL + "/userinfo",
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if r.PostFormValue("code") != "code" || r.PostFormValue("client_secret") != "secret" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		claims, _ := json.Marshal(map[string]interface{}{
+			"iss":   p.URL,
+			"sub":   "1001",
+			"aud":   "client",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": p.nonce,
+		})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig",
+		})
+	})
+
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"sub": "1001", "email": "tom@example.com"})
+	})
+
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+func newApp(t *testing.T, p *fakeProvider, opts ...oauth2.Option) zeroapi.App {
+	provider, err := oauth2.Discover(nil, p.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.ClientID = "client"
+	provider.ClientSecret = "secret"
+	provider.RedirectURL = "https://example.com/callback"
+	provider.Scopes = []string{"openid", "email"}
+
+	store := session.NewCookieStore(session.StaticKeys(bytes.Repeat([]byte{1}, 32)))
+	c := oauth2.New(store, provider, opts...)
+
+	a := app.NewApp()
+	a.Get("/login", c.Login)
+	a.Get("/callback", c.Callback)
+	a.Post("/callback", c.Callback)
+	a.Get("/account", c.Require(), func(ctx zeroapi.Context) {
+		user, _ := c.User(ctx)
+		ctx.Text(user.Email())
+	})
+	a.Router().Build()
+	return a
+}
+
+func serve(a zeroapi.App, target string, cookies []*http.Cookie) *http.Response {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Accept", "text/html")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w.Result()
+}
+
+// post 提交回调表单，模拟 response_mode=form_post
+func post(a zeroapi.App, target string, form url.Values, cookies []*http.Cookie) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w.Result()
+}
+
+// named 获取指定名称的 cookie
+func named(cookies []*http.Cookie, name string) []*http.Cookie {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return []*http.Cookie{cookie}
+		}
+	}
+	return nil
+}
+
+// login 访问登录路由，返回会话 cookie 与授权地址中的参数
+func login(t *testing.T, a zeroapi.App, p *fakeProvider) ([]*http.Cookie, url.Values) {
+	res := serve(a, "/login?return_to=/account", nil)
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("login: %d", res.StatusCode)
+	}
+	location, _ := url.Parse(res.Header.Get("Location"))
+	if location.Path != "/authorize" {
+		t.Fatalf("unexpected auth url: %s", location)
+	}
+
+	params := location.Query()
+	if params.Get("client_id") != "client" || params.Get("scope") != "openid email" || params.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected params: %v", params)
+	}
+	p.nonce, p.challenge = params.Get("nonce"), params.Get("code_challenge")
+
+	return res.Cookies(), params
+}
+
+func TestLogin(t *testing.T) {
+	p := newFakeProvider(t)
+	defer p.Close()
+	a := newApp(t, p)
+
+	res := serve(a, "/account", nil)
+	if res.StatusCode != http.StatusFound || res.Header.Get("Location") != "/login?return_to=%2Faccount" {
+		t.Fatalf("require: %d %s", res.StatusCode, res.Header.Get("Location"))
+	}
+
+	cookies, params := login(t, a, p)
+
+	res = serve(a, "/callback?code=code&state="+params.Get("state"), cookies)
+	if res.StatusCode != http.StatusFound || res.Header.Get("Location") != "/account" {
+		t.Fatalf("callback: %d %s", res.StatusCode, res.Header.Get("Location"))
+	}
+
+	res = serve(a, "/account", res.Cookies())
+	body := new(bytes.Buffer)
+	body.ReadFrom(res.Body)
+	if res.StatusCode != http.StatusOK || body.String() != "tom@example.com" {
+		t.Fatalf("account: %d %s", res.StatusCode, body.String())
+	}
+}
+
+func TestInvalidState(t *testing.T) {
+	p := newFakeProvider(t)
+	defer p.Close()
+	a := newApp(t, p)
+
+	cookies, _ := login(t, a, p)
+	if res := serve(a, "/callback?code=code&state=forged", cookies); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("forged state: %d", res.StatusCode)
+	}
+
+	// 没有会话，例如会话 cookie 的 SameSite 为 Strict
+	if res := serve(a, "/callback?code=code&state=unknown", nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("missing session: %d", res.StatusCode)
+	}
+
+	cookies, params := login(t, a, p)
+	if res := serve(a, "/callback?error=access_denied&state="+params.Get("state"), cookies); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("access denied: %d", res.StatusCode)
+	}
+}
+
+func TestFormPost(t *testing.T) {
+	p := newFakeProvider(t)
+	defer p.Close()
+	a := newApp(t, p, oauth2.WithFormPost())
+
+	cookies, params := login(t, a, p)
+	if params.Get("response_mode") != "form_post" {
+		t.Fatalf("unexpected response_mode: %s", params.Get("response_mode"))
+	}
+
+	stateCookie := named(cookies, "oauth2_state")
+	if stateCookie == nil || stateCookie[0].SameSite != http.SameSiteNoneMode || !stateCookie[0].Secure || stateCookie[0].Path != "/callback" {
+		t.Fatalf("unexpected state cookie: %v", stateCookie)
+	}
+
+	// 跨站 POST 时浏览器不发送 SameSite=Lax 的会话 cookie，只有 state cookie
+	res := post(a, "/callback", url.Values{"code": {"code"}, "state": {params.Get("state")}}, stateCookie)
+	if res.StatusCode != http.StatusFound || res.Header.Get("Location") != "/account" {
+		t.Fatalf("callback: %d %s", res.StatusCode, res.Header.Get("Location"))
+	}
+	if cleared := named(res.Cookies(), "oauth2_state"); cleared == nil || cleared[0].MaxAge >= 0 {
+		t.Fatalf("state cookie should be cleared: %v", cleared)
+	}
+
+	res = serve(a, "/account", named(res.Cookies(), "session"))
+	body := new(bytes.Buffer)
+	body.ReadFrom(res.Body)
+	if res.StatusCode != http.StatusOK || body.String() != "tom@example.com" {
+		t.Fatalf("account: %d %s", res.StatusCode, body.String())
+	}
+}
+
+func TestStateCookie(t *testing.T) {
+	p := newFakeProvider(t)
+	defer p.Close()
+	a := newApp(t, p)
+
+	// state cookie 与表单中的 state 不一致
+	cookies, _ := login(t, a, p)
+	stateCookie := named(cookies, "oauth2_state")
+	if res := post(a, "/callback", url.Values{"code": {"code"}, "state": {"forged"}}, stateCookie); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("forged state: %d", res.StatusCode)
+	}
+
+	// 会话 cookie 为 SameSite=Strict，GET 回调时只有 state cookie
+	cookies, params := login(t, a, p)
+	res := serve(a, "/callback?code=code&state="+params.Get("state"), named(cookies, "oauth2_state"))
+	if res.StatusCode != http.StatusFound || res.Header.Get("Location") != "/account" {
+		t.Fatalf("callback: %d %s", res.StatusCode, res.Header.Get("Location"))
+	}
+}
@@ -0,0 +1,107 @@
+package oauth2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrInvalidIDToken id_token 格式错误或者声明校验失败
+var ErrInvalidIDToken = errors.New("oauth2: invalid id_token")
+
+// clockSkew 校验 exp 时允许的时钟误差
+const clockSkew = time.Minute
+
+// verifyIDToken 校验 id_token 中的声明
+// id_token 由客户端通过 TLS 直接从令牌地址获取，按照 OpenID Connect Core 3.1.3.7 可以不校验签名
+func (c *Client) verifyIDToken(idToken, nonce string) (UserInfo, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidIDToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	claims := UserInfo{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	if claims.String("iss") != c.provider.Issuer || claims.Subject() == "" {
+		return nil, ErrInvalidIDToken
+	}
+	if !claims.hasAudience(c.provider.ClientID) {
+		return nil, ErrInvalidIDToken
+	}
+	if nonce == "" || claims.String("nonce") != nonce {
+		return nil, ErrInvalidIDToken
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || c.config.now().After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return nil, ErrInvalidIDToken
+	}
+
+	return claims, nil
+}
+
+// hasAudience aud 可以是字符串或者字符串数组
+func (u UserInfo) hasAudience(clientID string) bool {
+	switch aud := u["aud"].(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, v := range aud {
+			if s, ok := v.(string); ok && s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Discover 通过 OpenID Connect 发现文档 <issuer>/.well-known/openid-configuration 获取授权服务器地址
+// 返回的 Provider 还需要设置 ClientID, ClientSecret, RedirectURL 以及 Scopes
+func Discover(client *http.Client, issuer string) (Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return Provider{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var document struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	c := &Client{config: &config{client: client}}
+	if err := c.do(req, &document); err != nil {
+		return Provider{}, err
+	}
+
+	// 发现文档中的 issuer 必须与请求的一致
+	if document.Issuer != strings.TrimSuffix(issuer, "/") && document.Issuer != issuer {
+		return Provider{}, errors.New("oauth2: issuer mismatch")
+	}
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" {
+		return Provider{}, errors.New("oauth2: incomplete discovery document")
+	}
+
+	return Provider{
+		Issuer:      document.Issuer,
+		AuthURL:     document.AuthorizationEndpoint,
+		TokenURL:    document.TokenEndpoint,
+		UserInfoURL: document.UserinfoEndpoint,
+	}, nil
+}
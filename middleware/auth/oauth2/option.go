@@ -0,0 +1,140 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"io"
+	"net/http"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// LoginHandler 登录成功后调用，可以在这里创建或者关联本地用户，返回错误时登录失败
+// 返回 *zeroapi.HTTPError 时按照该错误响应，其它错误响应 ErrLoginFailed
+type LoginHandler func(ctx zeroapi.Context, login *Login) error
+
+// config OAuth2 客户端配置
+type config struct {
+	// client 请求令牌与用户信息的 http 客户端
+	client *http.Client
+
+	// sessionName 保存登录状态的会话名称
+	sessionName string
+
+	// stateCookie 会话 cookie 不可用时校验 state 的 cookie 名称
+	stateCookie string
+
+	// formPost 授权服务器是否通过 POST 表单回调 (response_mode=form_post)
+	formPost bool
+
+	// loginPath 登录路由，Require 在未登录时重定向到这里
+	loginPath string
+
+	// defaultReturnTo 登录成功后默认跳转的地址
+	defaultReturnTo string
+
+	// onLogin 登录成功后调用
+	onLogin LoginHandler
+
+	// now 获取当前时间，用于检查 id_token 是否过期
+	now func() time.Time
+
+	// rand 随机数来源，用于生成 state, nonce 以及 PKCE code_verifier
+	rand io.Reader
+}
+
+func defaultConfig() *config {
+	return &config{
+		client:          &http.Client{Timeout: 10 * time.Second},
+		sessionName:     "session",
+		stateCookie:     "oauth2_state",
+		loginPath:       "/login",
+		defaultReturnTo: "/",
+		now:             time.Now,
+		rand:            rand.Reader,
+	}
+}
+
+// Option OAuth2 客户端配置选项
+type Option func(config *config)
+
+// WithClient 设置请求令牌与用户信息的 http 客户端，默认超时 10 秒
+func WithClient(client *http.Client) Option {
+	return func(config *config) {
+		if client != nil {
+			config.client = client
+		}
+	}
+}
+
+// WithSessionName 设置保存登录状态的会话名称，默认 session，可以与应用共用同一个会话
+func WithSessionName(name string) Option {
+	return func(config *config) {
+		if name != "" {
+			config.sessionName = name
+		}
+	}
+}
+
+// WithStateCookie 设置 state cookie 的名称，默认 oauth2_state
+// 该 cookie 为 SameSite=None，在会话 cookie 因 SameSite 没有随回调发送时用于校验 state
+func WithStateCookie(name string) Option {
+	return func(config *config) {
+		if name != "" {
+			config.stateCookie = name
+		}
+	}
+}
+
+// WithFormPost 授权服务器通过 POST 表单回调 (response_mode=form_post)，授权码不会出现在地址与 Referer 中
+// 需要同时使用 POST 注册回调路由，并且回调路由不能使用 csrf 中间件
+func WithFormPost() Option {
+	return func(config *config) {
+		config.formPost = true
+	}
+}
+
+// WithLoginPath 设置登录路由，默认 /login，Require 在未登录时重定向到这里
+func WithLoginPath(path string) Option {
+	return func(config *config) {
+		if path != "" {
+			config.loginPath = path
+		}
+	}
+}
+
+// WithDefaultReturnTo 设置登录成功后默认跳转的地址，默认 /
+func WithDefaultReturnTo(path string) Option {
+	return func(config *config) {
+		if isLocalPath(path) {
+			config.defaultReturnTo = path
+		}
+	}
+}
+
+// WithOnLogin 设置登录成功后调用的函数
+func WithOnLogin(onLogin LoginHandler) Option {
+	return func(config *config) {
+		if onLogin != nil {
+			config.onLogin = onLogin
+		}
+	}
+}
+
+// WithNow 设置获取当前时间的函数，默认 time.Now
+func WithNow(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
+
+// WithRand 设置随机数来源，默认 crypto/rand
+func WithRand(rand io.Reader) Option {
+	return func(config *config) {
+		if rand != nil {
+			config.rand = rand
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package oauth2
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/middleware/auth"
+	"github.com/zerogo-hub/zero-api/session"
+)
+
+// ReasonUnknownProvider 登录或者回调的提供方没有注册
+const ReasonUnknownProvider = "oauth2_unknown_provider"
+
+// keyAccount 会话中保存本地用户 id 的键
+const keyAccount = "oauth2.account"
+
+// ErrIdentityInUse 提供方的身份已经关联了其它本地用户，响应 409
+var ErrIdentityInUse = zeroapi.NewHTTPError(http.StatusConflict, "IDENTITY IN USE")
+
+// Identity 用户在某个提供方中的身份
+type Identity struct {
+	// Provider 提供方名称，即 Registry.Register 时的名称
+	Provider string
+
+	// Subject 用户在提供方中的唯一 id
+	Subject string
+
+	// Login 本次登录的令牌与用户信息
+	Login *Login
+}
+
+// Linker 保存提供方身份与本地用户的关联，由应用实现，例如保存在数据库中
+type Linker interface {
+	// Match 查找已经关联 identity 的本地用户，没有关联时返回空字符串
+	Match(ctx zeroapi.Context, identity *Identity) (string, error)
+
+	// Link 将 identity 关联到已经登录的本地用户 account
+	Link(ctx zeroapi.Context, account string, identity *Identity) error
+
+	// Create 使用 identity 创建本地用户并关联，返回本地用户 id
+	Create(ctx zeroapi.Context, identity *Identity) (string, error)
+}
+
+// Registry 多个提供方，同一个本地用户可以通过任意一个已经关联的提供方登录
+//
+// 回调时按照以下顺序处理:
+// 1. match: 身份已经关联了本地用户时使用该用户登录，当前已经登录了其它用户时响应 409
+// 2. link: 没有关联且当前已经登录时，关联到当前用户，用于在账户设置中添加登录方式
+// 3. create: 没有关联且没有登录时，创建本地用户
+//
+// 示例:
+// r := oauth2.NewRegistry(store, linker, oauth2.WithRand(app.Rand()), oauth2.WithNow(app.Now))
+// r.Register("google", google)
+// r.Register("github", github)
+// app.Get("/login/:provider", r.Login)
+// app.Get("/auth/:provider/callback", r.Callback)
+// app.Post("/logout", r.Logout)
+// app.Group("/account").Use(r.Require())
+type Registry struct {
+	store   session.Store
+	linker  Linker
+	opts    []Option
+	config  *config
+	clients map[string]*Client
+}
+
+// NewRegistry 创建提供方注册表，opts 用于所有提供方，需要使用同一个会话
+func NewRegistry(store session.Store, linker Linker, opts ...Option) *Registry {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Registry{
+		store:   store,
+		linker:  linker,
+		opts:    opts,
+		config:  config,
+		clients: make(map[string]*Client),
+	}
+}
+
+// Register 注册提供方，name 用于登录与回调路由中的 :provider 参数
+// opts 在 NewRegistry 的选项之后使用，WithOnLogin 设置的函数在关联本地用户之前调用
+func (r *Registry) Register(name string, provider Provider, opts ...Option) *Client {
+	c := New(r.store, provider, append(append([]Option{}, r.opts...), opts...)...)
+
+	onLogin := c.config.onLogin
+	c.config.onLogin = func(ctx zeroapi.Context, login *Login) error {
+		if onLogin != nil {
+			if err := onLogin(ctx, login); err != nil {
+				return err
+			}
+		}
+		return r.link(ctx, &Identity{Provider: name, Subject: login.User.Subject(), Login: login})
+	}
+
+	r.clients[name] = c
+	return c
+}
+
+// Client 获取已经注册的提供方
+func (r *Registry) Client(name string) (*Client, bool) {
+	c, ok := r.clients[name]
+	return c, ok
+}
+
+// Providers 已经注册的提供方名称，按名称排序
+func (r *Registry) Providers() []string {
+	names := make([]string, 0, len(r.clients))
+	for name := range r.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Login 使用路由参数 :provider 指定的提供方登录，见 Client.Login
+func (r *Registry) Login(ctx zeroapi.Context) {
+	if c, ok := r.client(ctx); ok {
+		c.Login(ctx)
+	}
+}
+
+// Callback 路由参数 :provider 指定的提供方的回调，见 Client.Callback
+func (r *Registry) Callback(ctx zeroapi.Context) {
+	if c, ok := r.client(ctx); ok {
+		c.Callback(ctx)
+	}
+}
+
+// Logout 退出登录，从会话中删除本地用户与提供方的用户信息，然后跳转到默认地址
+func (r *Registry) Logout(ctx zeroapi.Context) {
+	sess, _ := r.store.Get(ctx, r.config.sessionName)
+	sess.Delete(keyAccount)
+	sess.Delete(keyUser)
+	if err := r.store.Save(ctx, sess); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.Redirect(http.StatusFound, r.config.defaultReturnTo)
+}
+
+// User 获取当前登录的本地用户 id，没有登录时返回 false
+func (r *Registry) User(ctx zeroapi.Context) (string, bool) {
+	sess, err := r.store.Get(ctx, r.config.sessionName)
+	if err != nil {
+		return "", false
+	}
+	account, ok := sess.Get(keyAccount).(string)
+	return account, ok && account != ""
+}
+
+// Require 需要登录的中间件，登录后本地用户 id 保存在 ctx.Value(zeroapi.ValueKeyPrincipal) 中，见 auth.User
+// 浏览器访问时重定向到登录路由，登录后返回当前地址，其它请求响应 401
+func (r *Registry) Require() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		if account, ok := r.User(ctx); ok {
+			ctx.SetValue(zeroapi.ValueKeyPrincipal, account)
+			return
+		}
+
+		if ctx.Method() == http.MethodGet && ctx.PrefersHTML() {
+			ctx.Redirect(http.StatusFound, r.config.loginPath+"?return_to="+url.QueryEscape(ctx.Request().URL.RequestURI()))
+			return
+		}
+
+		ctx.ClientError(http.StatusUnauthorized, auth.ReasonUnauthorized, "UNAUTHORIZED")
+	}
+}
+
+// client 获取路由参数 :provider 指定的提供方，没有注册时响应 404
+func (r *Registry) client(ctx zeroapi.Context) (*Client, bool) {
+	c, ok := r.clients[ctx.Dynamic("provider")]
+	if !ok {
+		ctx.ClientError(http.StatusNotFound, ReasonUnknownProvider, "UNKNOWN PROVIDER")
+	}
+	return c, ok
+}
+
+// link 按照 match, link, create 的顺序得到本地用户，保存到会话中，由 Callback 保存会话
+func (r *Registry) link(ctx zeroapi.Context, identity *Identity) error {
+	if identity.Subject == "" {
+		return ErrUserInfo
+	}
+
+	sess, _ := r.store.Get(ctx, r.config.sessionName)
+	current, _ := sess.Get(keyAccount).(string)
+
+	account, err := r.linker.Match(ctx, identity)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case account != "":
+		if current != "" && current != account {
+			return ErrIdentityInUse
+		}
+	case current != "":
+		if err := r.linker.Link(ctx, current, identity); err != nil {
+			return err
+		}
+		account = current
+	default:
+		if account, err = r.linker.Create(ctx, identity); err != nil {
+			return err
+		}
+	}
+
+	sess.Set(keyAccount, account)
+	return nil
+}
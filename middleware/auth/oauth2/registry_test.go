@@ -0,0 +1,143 @@
+package oauth2_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/auth"
+	"github.com/zerogo-hub/zero-api/middleware/auth/oauth2"
+	"github.com/zerogo-hub/zero-api/session"
+)
+
+// memoryLinker 保存在内存中的身份关联，键为 "提供方:sub"
+type memoryLinker struct {
+	links   map[string]string
+	created int
+}
+
+func (l *memoryLinker) Match(ctx zeroapi.Context, identity *oauth2.Identity) (string, error) {
+	return l.links[identity.Provider+":"+identity.Subject], nil
+}
+
+func (l *memoryLinker) Link(ctx zeroapi.Context, account string, identity *oauth2.Identity) error {
+	l.links[identity.Provider+":"+identity.Subject] = account
+	return nil
+}
+
+func (l *memoryLinker) Create(ctx zeroapi.Context, identity *oauth2.Identity) (string, error) {
+	l.created++
+	account := "user" + strconv.Itoa(l.created)
+	l.links[identity.Provider+":"+identity.Subject] = account
+	return account, nil
+}
+
+func newRegistryApp(t *testing.T, linker oauth2.Linker, providers map[string]*fakeProvider) zeroapi.App {
+	store := session.NewCookieStore(session.StaticKeys(bytes.Repeat([]byte{1}, 32)))
+	r := oauth2.NewRegistry(store, linker)
+
+	for name, p := range providers {
+		provider, err := oauth2.Discover(nil, p.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		provider.ClientID = "client"
+		provider.ClientSecret = "secret"
+		provider.RedirectURL = "https://example.com/auth/" + name + "/callback"
+		provider.Scopes = []string{"openid", "email"}
+		r.Register(name, provider)
+	}
+
+	a := app.NewApp()
+	a.Get("/login/:provider", r.Login)
+	a.Get("/auth/:provider/callback", r.Callback)
+	a.Get("/account", r.Require(), func(ctx zeroapi.Context) {
+		ctx.Text(auth.User(ctx))
+	})
+	a.Router().Build()
+	return a
+}
+
+// signIn 通过指定的提供方登录，返回回调的响应
+func signIn(t *testing.T, a zeroapi.App, p *fakeProvider, name string, cookies []*http.Cookie) *http.Response {
+	res := serve(a, "/login/"+name, cookies)
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("login %s: %d", name, res.StatusCode)
+	}
+	location, _ := url.Parse(res.Header.Get("Location"))
+	params := location.Query()
+	p.nonce, p.challenge = params.Get("nonce"), params.Get("code_challenge")
+
+	return serve(a, "/auth/"+name+"/callback?code=code&state="+params.Get("state"), named(res.Cookies(), "session"))
+}
+
+// account 当前登录的本地用户
+func account(a zeroapi.App, cookies []*http.Cookie) string {
+	res := serve(a, "/account", cookies)
+	if res.StatusCode != http.StatusOK {
+		return ""
+	}
+	body := new(bytes.Buffer)
+	body.ReadFrom(res.Body)
+	return body.String()
+}
+
+func TestRegistry(t *testing.T) {
+	google, github := newFakeProvider(t), newFakeProvider(t)
+	defer google.Close()
+	defer github.Close()
+
+	linker := &memoryLinker{links: map[string]string{}}
+	a := newRegistryApp(t, linker, map[string]*fakeProvider{"google": google, "github": github})
+
+	if res := serve(a, "/login/unknown", nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown provider: %d", res.StatusCode)
+	}
+
+	// create: 没有关联且没有登录
+	res := signIn(t, a, google, "google", nil)
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("callback: %d", res.StatusCode)
+	}
+	cookies := named(res.Cookies(), "session")
+	if got := account(a, cookies); got != "user1" {
+		t.Fatalf("invalid account: %s", got)
+	}
+
+	// link: 已经登录时关联到当前用户
+	res = signIn(t, a, github, "github", cookies)
+	if res.StatusCode != http.StatusFound || linker.links["github:1001"] != "user1" {
+		t.Fatalf("link: %d %v", res.StatusCode, linker.links)
+	}
+
+	// match: 新的会话通过已经关联的提供方登录同一个用户
+	res = signIn(t, a, github, "github", nil)
+	if got := account(a, named(res.Cookies(), "session")); got != "user1" || linker.created != 1 {
+		t.Fatalf("match: %s %d", got, linker.created)
+	}
+}
+
+func TestRegistryIdentityInUse(t *testing.T) {
+	google, github := newFakeProvider(t), newFakeProvider(t)
+	defer google.Close()
+	defer github.Close()
+
+	linker := &memoryLinker{links: map[string]string{"github:1001": "other"}}
+	a := newRegistryApp(t, linker, map[string]*fakeProvider{"google": google, "github": github})
+
+	res := signIn(t, a, google, "google", nil)
+	cookies := named(res.Cookies(), "session")
+
+	// github 身份已经关联了其它用户
+	res = signIn(t, a, github, "github", cookies)
+	if res.StatusCode != http.StatusConflict {
+		t.Fatalf("expect 409, got %d", res.StatusCode)
+	}
+	if got := account(a, cookies); got != "user1" {
+		t.Fatalf("account should not change: %s", got)
+	}
+}
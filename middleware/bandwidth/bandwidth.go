@@ -0,0 +1,234 @@
+// Package bandwidth 限制响应的发送速度，按照字节使用令牌桶控制写入，避免大文件下载占满实例的网络带宽
+// 可以用于单个路由，也可以通过 PerClient, WithKey 限制同一个客户端或者一组响应的总带宽
+//
+// 示例:
+// app.Get("/download/:name", bandwidth.New(1<<20), download)
+// files := app.Group("/files")
+// files.Use(bandwidth.New(512<<10, bandwidth.PerClient()))
+package bandwidth
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// New 创建带宽限制中间件，bytesPerSecond 为每秒发送的字节数，小于等于 0 时不限制
+// 等待令牌时客户端断开连接，写入返回 ctx.Context().Err()
+func New(bytesPerSecond int64, opts ...Option) zeroapi.Handler {
+	config := &config{
+		burst: bytesPerSecond,
+		chunk: defaultChunk,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if int64(config.chunk) > config.burst {
+		config.chunk = int(config.burst)
+	}
+
+	l := &limiter{
+		rate:    float64(bytesPerSecond),
+		burst:   float64(config.burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+
+	return func(ctx zeroapi.Context) {
+		if bytesPerSecond <= 0 {
+			return
+		}
+
+		key := ""
+		if config.key != nil {
+			key = config.key(ctx)
+		}
+		b := l.acquire(key)
+
+		res := ctx.Response()
+		w := &throttleWriter{
+			ResponseWriter: res.Writer(),
+			limiter:        l,
+			bucket:         b,
+			ctx:            ctx.Context(),
+			chunk:          config.chunk,
+		}
+		res.SetWriter(w)
+		res.BeforeFinish(func() { l.release(key, b) })
+	}
+}
+
+// limiter 管理令牌桶
+type limiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	// now 获取当前时间
+	now func() time.Time
+
+	// nextSweep 下次清理令牌桶的时间
+	nextSweep time.Time
+}
+
+// bucket 令牌桶，一个令牌对应一个字节
+type bucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	// refs 正在使用该令牌桶的响应数量
+	refs int
+}
+
+// acquire 获取 key 对应的令牌桶，key 为空时创建单独的令牌桶
+func (l *limiter) acquire(key string) *bucket {
+	if key == "" {
+		return &bucket{tokens: l.burst, last: l.now(), refs: 1}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, exist := l.buckets[key]
+	if !exist {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.refs++
+	return b
+}
+
+// release 响应结束
+func (l *limiter) release(key string, b *bucket) {
+	if key == "" {
+		return
+	}
+
+	l.mu.Lock()
+	b.refs--
+	l.mu.Unlock()
+}
+
+// sweep 每分钟清理一次没有使用并且已恢复为满的令牌桶，与没有记录时的结果相同
+func (l *limiter) sweep(now time.Time) {
+	if now.Before(l.nextSweep) {
+		return
+	}
+	l.nextSweep = now.Add(time.Minute)
+
+	for key, b := range l.buckets {
+		if b.refs == 0 && b.full(now, l) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// reserve 取出 n 个令牌，令牌不足时透支，返回需要等待的时间
+// 透支的令牌由之后的写入等待偿还，多个响应共享令牌桶时按照取出的顺序发送
+func (b *bucket) reserve(n int, now time.Time, l *limiter) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now, l)
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / l.rate * float64(time.Second))
+}
+
+func (b *bucket) full(now time.Time, l *limiter) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now, l)
+	return b.tokens >= l.burst
+}
+
+func (b *bucket) refill(now time.Time, l *limiter) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * l.rate
+		if b.tokens > l.burst {
+			b.tokens = l.burst
+		}
+		b.last = now
+	}
+}
+
+// throttleWriter 包装原始的 http.ResponseWriter，按照令牌桶分块写入
+type throttleWriter struct {
+	http.ResponseWriter
+
+	limiter *limiter
+	bucket  *bucket
+	ctx     context.Context
+	chunk   int
+}
+
+func (w *throttleWriter) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		n := len(b)
+		if n > w.chunk {
+			n = w.chunk
+		}
+
+		if wait := w.bucket.reserve(n, w.limiter.now(), w.limiter); wait > 0 {
+			if err := w.sleep(wait); err != nil {
+				return written, err
+			}
+		}
+
+		m, err := w.ResponseWriter.Write(b[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// sleep 等待令牌，客户端断开连接时返回
+func (w *throttleWriter) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+func (w *throttleWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *throttleWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+func (w *throttleWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
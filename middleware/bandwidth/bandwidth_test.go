@@ -0,0 +1,107 @@
+package bandwidth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/bandwidth"
+)
+
+var body = strings.Repeat("a", 64*1024)
+
+func newApp(handler zeroapi.Handler) zeroapi.App {
+	a := app.NewApp()
+	a.Get("/download", handler, func(ctx zeroapi.Context) {
+		ctx.Text(body)
+	})
+	a.Router().Build()
+	return a
+}
+
+func download(a zeroapi.App, ip string) (*httptest.ResponseRecorder, time.Duration) {
+	req := httptest.NewRequest(http.MethodGet, "/download", nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	a.Server().ServeHTTP(w, req)
+	return w, time.Since(start)
+}
+
+func TestBandwidth(t *testing.T) {
+	// 突发 16KB，之后每秒 256KB，64KB 需要约 190ms
+	a := newApp(bandwidth.New(256*1024, bandwidth.WithBurst(16*1024), bandwidth.WithChunk(4*1024)))
+
+	w, elapsed := download(a, "10.0.0.1")
+	if w.Body.String() != body {
+		t.Fatalf("invalid body length: %d", w.Body.Len())
+	}
+	if elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("invalid elapsed: %s", elapsed)
+	}
+
+	// 每个响应单独限制，并发下载不会互相影响
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			download(a, "10.0.0.1")
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("responses should be limited separately: %s", elapsed)
+	}
+}
+
+func TestBandwidthPerClient(t *testing.T) {
+	a := newApp(bandwidth.New(512*1024, bandwidth.WithBurst(16*1024), bandwidth.PerClient()))
+
+	// 同一个客户端的 3 个下载共享带宽，192KB 需要约 340ms
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			download(a, "10.0.0.1")
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 280*time.Millisecond {
+		t.Fatalf("responses of the same client should share bandwidth: %s", elapsed)
+	}
+
+	// 其它客户端不受影响
+	if _, elapsed := download(a, "10.0.0.2"); elapsed > 250*time.Millisecond {
+		t.Fatalf("other clients should not be limited: %s", elapsed)
+	}
+}
+
+func TestBandwidthCanceled(t *testing.T) {
+	var err error
+	a := app.NewApp()
+	a.Get("/download", bandwidth.New(1024), func(ctx zeroapi.Context) {
+		_, err = ctx.Text(body)
+	})
+	a.Router().Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/download", nil).WithContext(ctx)
+
+	start := time.Now()
+	a.Server().ServeHTTP(httptest.NewRecorder(), req)
+	if err != context.DeadlineExceeded || time.Since(start) > time.Second {
+		t.Fatalf("write should stop after client gone: %v, %s", err, time.Since(start))
+	}
+}
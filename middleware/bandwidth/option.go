@@ -0,0 +1,56 @@
+package bandwidth
+
+import (
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// defaultChunk 每次写入的最大字节数，写入越小速度越平滑
+const defaultChunk = 16 * 1024
+
+// config 带宽限制配置
+type config struct {
+	// key 获取共享令牌桶的键，为 nil 或者返回空字符串时每个响应单独限制
+	key func(ctx zeroapi.Context) string
+
+	// burst 令牌桶容量，即允许突发发送的字节数
+	burst int64
+
+	// chunk 每次写入的最大字节数
+	chunk int
+}
+
+// Option 带宽限制配置选项
+type Option func(config *config)
+
+// WithKey 设置获取共享令牌桶的键，相同键的所有响应共享同一个带宽限制
+// 例如按照用户 ID 限制，或者返回固定值限制该路由的总带宽，返回空字符串时该响应单独限制
+func WithKey(key func(ctx zeroapi.Context) string) Option {
+	return func(config *config) {
+		config.key = key
+	}
+}
+
+// PerClient 同一个客户端 IP 的所有响应共享同一个带宽限制，避免客户端通过并发下载绕过限制
+func PerClient() Option {
+	return WithKey(func(ctx zeroapi.Context) string {
+		return ctx.ClientIP()
+	})
+}
+
+// WithBurst 设置允许突发发送的字节数，默认与每秒的字节数相同
+func WithBurst(burst int64) Option {
+	return func(config *config) {
+		if burst > 0 {
+			config.burst = burst
+		}
+	}
+}
+
+// WithChunk 设置每次写入的最大字节数，默认 16KB，不超过 burst
+func WithChunk(chunk int) Option {
+	return func(config *config) {
+		if chunk > 0 {
+			config.chunk = chunk
+		}
+	}
+}
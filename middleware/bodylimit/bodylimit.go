@@ -0,0 +1,16 @@
+// Package bodylimit 限制请求体大小，可以作为全局中间件，也可以只用于某些路由
+//
+// 示例:
+// app.Post("/upload", bodylimit.New(32<<20), upload)
+package bodylimit
+
+import (
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// New 创建请求体大小限制中间件，limit 单位为字节
+// Content-Length 超过限制时直接返回 413，否则在读取超过限制时返回 zeroapi.ErrBodyTooLarge
+// 与 App 的 WithMaxBodyBytes 同时使用时只能设置更小的限制，更大的限制通过 zeroapi.RouteMiddleware{MaxBodyBytes: n} 设置
+func New(limit int64) zeroapi.Handler {
+	return zeroapi.LimitBody(limit)
+}
@@ -0,0 +1,181 @@
+package bodylimit_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/bodylimit"
+)
+
+func multipartBody(t *testing.T, size int) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	if err := w.WriteField("title", "report"); err != nil {
+		t.Fatal(err)
+	}
+	part, err := w.CreateFormFile("file", "../../report.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write(bytes.Repeat([]byte("a"), size)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return body, w.FormDataContentType()
+}
+
+func TestContentLengthTooLarge(t *testing.T) {
+	a := app.NewApp()
+	called := false
+	a.Post("/upload", bodylimit.New(16), func(ctx zeroapi.Context) {
+		called = true
+	})
+	a.Router().Build()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", 32)))
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge || called {
+		t.Fatalf("invalid response: %d, called: %v", w.Code, called)
+	}
+}
+
+func TestStreamFilesTooLarge(t *testing.T) {
+	a := app.NewApp()
+	var err error
+	a.Post("/upload", bodylimit.New(1024), func(ctx zeroapi.Context) {
+		err = ctx.StreamFiles(func(part *multipart.Part) error {
+			_, err := io.Copy(ioutil.Discard, part)
+			return err
+		})
+	})
+	a.Router().Build()
+
+	body, contentType := multipartBody(t, 4096)
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	// 模拟分块传输，没有 Content-Length
+	req.ContentLength = -1
+	a.Server().ServeHTTP(httptest.NewRecorder(), req)
+
+	if !errors.Is(err, zeroapi.ErrBodyTooLarge) {
+		t.Fatalf("expect ErrBodyTooLarge, got: %v", err)
+	}
+}
+
+func TestStreamFiles(t *testing.T) {
+	a := app.NewApp()
+	var title string
+	var size int64
+	var err error
+	a.Post("/upload", bodylimit.New(8192), func(ctx zeroapi.Context) {
+		err = ctx.StreamFiles(func(part *multipart.Part) error {
+			title = ctx.Post("title")
+			n, err := io.Copy(ioutil.Discard, part)
+			size = n
+			return err
+		})
+	})
+	a.Router().Build()
+
+	body, contentType := multipartBody(t, 4096)
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	a.Server().ServeHTTP(httptest.NewRecorder(), req)
+
+	if err != nil || title != "report" || size != 4096 {
+		t.Fatalf("invalid stream: %v, %q, %d", err, title, size)
+	}
+}
+
+func TestSaveUploadedFile(t *testing.T) {
+	a := app.NewApp()
+	dir := t.TempDir()
+	var err error
+	a.Post("/upload", func(ctx zeroapi.Context) {
+		files, e := ctx.FormFiles("file")
+		if e != nil {
+			err = e
+			return
+		}
+		err = ctx.SaveUploadedFile(files[0], filepath.Join(dir, "sub", filepath.Base(files[0].Filename)))
+	})
+	a.Router().Build()
+
+	body, contentType := multipartBody(t, 128)
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	a.Server().ServeHTTP(httptest.NewRecorder(), req)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "sub", "report.txt"))
+	if err != nil || len(data) != 128 {
+		t.Fatalf("invalid saved file: %v, %d", err, len(data))
+	}
+}
+
+func TestAppMaxBodyBytes(t *testing.T) {
+	a := app.NewApp(app.WithMaxBodyBytes(16))
+
+	var err error
+	read := func(ctx zeroapi.Context) {
+		_, err = ioutil.ReadAll(ctx.Request().Body)
+	}
+	a.Post("/comment", read)
+	a.Handle(http.MethodPost, "/upload", zeroapi.RouteMiddleware{MaxBodyBytes: 64}, read)
+	a.Handle(http.MethodPost, "/import", zeroapi.RouteMiddleware{MaxBodyBytes: -1}, read)
+	// 路由中的中间件设置更小的限制
+	a.Post("/avatar", bodylimit.New(8), read)
+	a.Host("api.example.com").Handle(http.MethodPost, "/comment", zeroapi.RouteMiddleware{}, read)
+	a.Router().Build()
+
+	for _, c := range []struct {
+		host string
+		path string
+		size int
+		code int
+	}{
+		{"", "/comment", 16, http.StatusOK},
+		{"", "/comment", 32, http.StatusRequestEntityTooLarge},
+		{"", "/upload", 32, http.StatusOK},
+		{"", "/upload", 128, http.StatusRequestEntityTooLarge},
+		{"", "/import", 1024, http.StatusOK},
+		{"", "/avatar", 12, http.StatusRequestEntityTooLarge},
+		{"api.example.com", "/comment", 32, http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, c.path, strings.NewReader(strings.Repeat("a", c.size)))
+		if c.host != "" {
+			req.Host = c.host
+		}
+		w := httptest.NewRecorder()
+		err = nil
+		a.Server().ServeHTTP(w, req)
+
+		if w.Code != c.code || err != nil {
+			t.Fatalf("%s%s %d: invalid response: %d, %v", c.host, c.path, c.size, w.Code, err)
+		}
+	}
+
+	// 没有 Content-Length 时在读取超过限制时返回错误
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", 128)))
+	req.ContentLength = -1
+	a.Server().ServeHTTP(httptest.NewRecorder(), req)
+	if !errors.Is(err, zeroapi.ErrBodyTooLarge) {
+		t.Fatalf("expect ErrBodyTooLarge, got: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+// Package brownout 根据路由的 5xx 比例自动降级，依次丢弃可选的请求，错误率恢复后逐级恢复
+// 每个路由单独统计，并按照重要性从低到高指定可以丢弃的请求类别
+// 5xx 比例超过阈值时降级等级加一，丢弃下一个类别的请求，低于恢复阈值时减一，每个统计周期最多调整一级
+// 被丢弃的请求不计入统计，没有指定的类别永远不会被丢弃
+//
+// 示例:
+// b := brownout.New(app.Metrics(), brownout.WithThreshold(0.2, 0.05))
+// app.Get("/product/:id", b.Handler("product", "prefetch", "recommendations"), productHandler)
+// 客户端预加载时携带请求头 X-Request-Class: prefetch，5xx 比例超过 20% 时先丢弃 prefetch，仍然超过时再丢弃 recommendations
+package brownout
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// DefaultHeader 默认指定请求类别的请求头
+const DefaultHeader = "X-Request-Class"
+
+const (
+	// metricLevel 当前的降级等级
+	metricLevel = "http_brownout_level"
+
+	// metricShed 被丢弃的请求数
+	metricShed = "http_brownout_shed_total"
+)
+
+// ErrShed 请求因为降级被丢弃
+var ErrShed = zeroapi.NewHTTPError(http.StatusServiceUnavailable, "SERVICE UNAVAILABLE")
+
+// Controller 降级控制器
+type Controller struct {
+	config *config
+
+	level zeroapi.Gauge
+	shed  zeroapi.Metric
+
+	mu     sync.Mutex
+	routes map[string]*route
+}
+
+// route 一个路由的统计与降级状态
+type route struct {
+	// name 路由名称
+	name string
+
+	// classes 可以丢弃的类别，按照重要性从低到高
+	classes []string
+
+	// level 降级等级，丢弃 classes 中的前 level 个类别
+	level int
+
+	// windowStart 当前统计周期的开始时间
+	windowStart time.Time
+
+	// requests, errors 当前统计周期内的请求数与 5xx 数
+	requests, errors int
+}
+
+// Stats 路由当前的降级状态
+type Stats struct {
+	// Route 路由名称
+	Route string
+
+	// Level 降级等级
+	Level int
+
+	// Shedding 正在丢弃的类别
+	Shedding []string
+
+	// Requests, Errors 当前统计周期内的请求数与 5xx 数，不包括被丢弃的请求
+	Requests, Errors int
+}
+
+// New 创建降级控制器，指标记录在 m 中
+// http_brownout_level: 当前的降级等级，标签 route
+// http_brownout_shed_total: 被丢弃的请求数，标签 route, class
+func New(m zeroapi.Metrics, opts ...Option) *Controller {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Controller{
+		config: config,
+		level:  m.Gauge(metricLevel),
+		shed:   m.Counter(metricShed),
+		routes: make(map[string]*route),
+	}
+}
+
+// Handler 创建路由的中间件
+// name: 路由名称，用于统计与指标标签，多个路由可以使用同一个名称共同统计
+// classes: 可以丢弃的请求类别，按照重要性从低到高，降级时依次丢弃
+func (c *Controller) Handler(name string, classes ...string) zeroapi.Handler {
+	c.mu.Lock()
+	r, ok := c.routes[name]
+	if !ok {
+		r = &route{name: name, windowStart: c.config.now()}
+		c.routes[name] = r
+	}
+	r.classes = append(r.classes, classes...)
+	c.mu.Unlock()
+
+	c.level.Set(0, "route", name)
+
+	return func(ctx zeroapi.Context) {
+		class := c.config.classifier(ctx)
+
+		if c.shedding(r, class) {
+			c.shed.Add(1, "route", name, "class", class)
+			c.reject(ctx)
+			return
+		}
+
+		// 无论之后的处理是否中断或者发生 panic 都会执行
+		ctx.AppendEnd(func() error {
+			// 从响应中读取状态码，包括反向代理、挂载的 http.Handler 直接写入的 5xx
+			c.observe(r, ctx.Response().Status() >= http.StatusInternalServerError)
+			return nil
+		})
+	}
+}
+
+// Level 获取路由当前的降级等级，路由不存在时为 0
+func (c *Controller) Level(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r, ok := c.routes[name]; ok {
+		return r.level
+	}
+	return 0
+}
+
+// Stats 获取所有路由当前的降级状态，按照名称排序
+func (c *Controller) Stats() []Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]Stats, 0, len(c.routes))
+	for name, r := range c.routes {
+		stats = append(stats, Stats{
+			Route:    name,
+			Level:    r.level,
+			Shedding: append([]string(nil), r.classes[:r.level]...),
+			Requests: r.requests,
+			Errors:   r.errors,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+	return stats
+}
+
+// shedding 判断类别是否正在被丢弃
+func (c *Controller) shedding(r *route, class string) bool {
+	if class == "" {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evaluate(r)
+	for _, shed := range r.classes[:r.level] {
+		if shed == class {
+			return true
+		}
+	}
+	return false
+}
+
+// observe 记录请求结果
+func (c *Controller) observe(r *route, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evaluate(r)
+
+	r.requests++
+	if failed {
+		r.errors++
+	}
+}
+
+// evaluate 统计周期结束时调整降级等级并开始新的周期，需要持有锁
+func (c *Controller) evaluate(r *route) {
+	now := c.config.now()
+	if now.Sub(r.windowStart) < c.config.interval {
+		return
+	}
+
+	rate := 0.0
+	if r.requests > 0 {
+		rate = float64(r.errors) / float64(r.requests)
+	}
+
+	level := r.level
+	switch {
+	case r.requests >= c.config.minRequests && rate > c.config.threshold && r.level < len(r.classes):
+		r.level++
+	case rate < c.config.recoverThreshold && r.level > 0:
+		r.level--
+	}
+	if r.level != level {
+		c.level.Set(float64(r.level), "route", r.name)
+	}
+
+	r.windowStart = now
+	r.requests = 0
+	r.errors = 0
+}
+
+// reject 拒绝请求，Retry-After 为统计周期，即最早可能恢复的时间
+func (c *Controller) reject(ctx zeroapi.Context) {
+	ctx.SetRetryAfter(c.config.interval)
+
+	if c.config.rejectHandler != nil {
+		c.config.rejectHandler(ctx)
+		ctx.Stopped()
+		return
+	}
+
+	ctx.Error(ErrShed)
+}
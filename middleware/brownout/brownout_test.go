@@ -0,0 +1,159 @@
+package brownout_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/brownout"
+)
+
+func TestBrownout(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Unix(1622534400, 0)
+	advance := func() {
+		mu.Lock()
+		now = now.Add(5 * time.Second)
+		mu.Unlock()
+	}
+
+	a := app.NewApp()
+	b := brownout.New(a.Metrics(), brownout.WithMinRequests(10), brownout.WithNow(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}))
+
+	fail := true
+	a.Get("/product", b.Handler("product", "prefetch", "recommendations"), func(ctx zeroapi.Context) {
+		// 直接写入 Response()，例如反向代理透传上游的 5xx
+		if fail {
+			http.Error(ctx.Response(), "upstream failed", http.StatusInternalServerError)
+			return
+		}
+		ctx.Text("product")
+	})
+	a.Router().Build()
+
+	serve := func(class string) int {
+		req := httptest.NewRequest(http.MethodGet, "/product", nil)
+		if class != "" {
+			req.Header.Set(brownout.DefaultHeader, class)
+		}
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 请求结果在响应结束后异步记录
+	wait := func(requests int) {
+		deadline := time.Now().Add(time.Second)
+		for b.Stats()[0].Requests != requests {
+			if time.Now().After(deadline) {
+				t.Fatalf("expect %d requests, got %+v", requests, b.Stats())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		serve("")
+	}
+	wait(10)
+	advance()
+
+	// 第一级只丢弃 prefetch
+	if code := serve("prefetch"); code != http.StatusServiceUnavailable || b.Level("product") != 1 {
+		t.Fatalf("expect prefetch shed, got %d level %d", code, b.Level("product"))
+	}
+	for i := 0; i < 10; i++ {
+		if code := serve("recommendations"); code != http.StatusInternalServerError {
+			t.Fatalf("expect recommendations served, got %d", code)
+		}
+	}
+	wait(10)
+	advance()
+
+	// 第二级同时丢弃 recommendations，没有指定的类别永远不会被丢弃
+	if code := serve("recommendations"); code != http.StatusServiceUnavailable || b.Level("product") != 2 {
+		t.Fatalf("expect recommendations shed, got %d level %d", code, b.Level("product"))
+	}
+	if code := serve("checkout"); code != http.StatusInternalServerError {
+		t.Fatalf("expect unlisted class served, got %d", code)
+	}
+	wait(1)
+
+	// 错误率恢复后逐级恢复
+	fail = false
+	advance()
+	for i := 0; i < 10; i++ {
+		serve("")
+	}
+	wait(10)
+	if b.Level("product") != 2 {
+		t.Fatalf("expect level 2 within the failing window, got %d", b.Level("product"))
+	}
+
+	advance()
+	if code := serve("recommendations"); code != http.StatusOK || b.Level("product") != 1 {
+		t.Fatalf("expect recommendations restored, got %d level %d", code, b.Level("product"))
+	}
+	wait(1)
+
+	advance()
+	if code := serve("prefetch"); code != http.StatusOK || b.Level("product") != 0 {
+		t.Fatalf("expect prefetch restored, got %d level %d", code, b.Level("product"))
+	}
+
+	shed := 0.0
+	for _, sample := range a.Metrics().Gather() {
+		if sample.Name == "http_brownout_shed_total" {
+			shed += sample.Value
+		}
+	}
+	if shed != 2 {
+		t.Fatalf("expect 2 shed requests, got %v", shed)
+	}
+}
+
+func TestBrownoutPanic(t *testing.T) {
+	now := time.Unix(1622534400, 0)
+
+	a := app.NewApp()
+	b := brownout.New(a.Metrics(), brownout.WithMinRequests(10), brownout.WithNow(func() time.Time { return now }))
+
+	// 没有设置 PanicHandler，panic 视为 500
+	a.Get("/product", b.Handler("product", "prefetch"), func(ctx zeroapi.Context) {
+		panic("product failed")
+	})
+	a.Router().Build()
+
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/product", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expect 500, got %d", w.Code)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for b.Stats()[0].Requests != 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect 10 requests, got %+v", b.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	now = now.Add(5 * time.Second)
+	req := httptest.NewRequest(http.MethodGet, "/product", nil)
+	req.Header.Set(brownout.DefaultHeader, "prefetch")
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable || b.Level("product") != 1 {
+		t.Fatalf("expect prefetch shed, got %d level %d", w.Code, b.Level("product"))
+	}
+}
@@ -0,0 +1,116 @@
+package brownout
+
+import (
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// config 降级配置
+type config struct {
+	// threshold 5xx 比例超过该值时提升降级等级
+	threshold float64
+
+	// recoverThreshold 5xx 比例低于该值时降低降级等级
+	recoverThreshold float64
+
+	// minRequests 统计周期内的请求数少于该值时不提升降级等级，避免少量请求导致误判
+	minRequests int
+
+	// interval 统计周期，每个周期最多调整一级
+	interval time.Duration
+
+	// classifier 获取请求的类别
+	classifier func(ctx zeroapi.Context) string
+
+	// rejectHandler 拒绝请求时的处理函数，为空时响应 503
+	rejectHandler zeroapi.Handler
+
+	// now 获取当前时间
+	now func() time.Time
+}
+
+func defaultConfig() *config {
+	return &config{
+		threshold:        0.1,
+		recoverThreshold: 0.05,
+		minRequests:      20,
+		interval:         5 * time.Second,
+		classifier:       headerClassifier(DefaultHeader),
+		now:              time.Now,
+	}
+}
+
+// headerClassifier 从请求头中获取类别
+func headerClassifier(header string) func(ctx zeroapi.Context) string {
+	return func(ctx zeroapi.Context) string {
+		return ctx.Header(header)
+	}
+}
+
+// Option 降级配置选项
+type Option func(config *config)
+
+// WithThreshold 设置提升与降低降级等级的 5xx 比例，默认分别为 0.1 与 0.05
+// recover 需要小于 threshold，避免等级在两者之间来回变化
+func WithThreshold(threshold, recover float64) Option {
+	return func(config *config) {
+		if threshold > 0 && threshold <= 1 && recover >= 0 && recover < threshold {
+			config.threshold = threshold
+			config.recoverThreshold = recover
+		}
+	}
+}
+
+// WithMinRequests 设置统计周期内提升降级等级需要的最少请求数，默认 20
+func WithMinRequests(n int) Option {
+	return func(config *config) {
+		if n > 0 {
+			config.minRequests = n
+		}
+	}
+}
+
+// WithInterval 设置统计周期，每个周期最多调整一级，默认 5 秒
+func WithInterval(interval time.Duration) Option {
+	return func(config *config) {
+		if interval > 0 {
+			config.interval = interval
+		}
+	}
+}
+
+// WithHeader 设置指定请求类别的请求头，默认 X-Request-Class
+func WithHeader(header string) Option {
+	return func(config *config) {
+		if header != "" {
+			config.classifier = headerClassifier(header)
+		}
+	}
+}
+
+// WithClassifier 设置获取请求类别的函数，例如根据查询参数或者用户等级判断，默认使用请求头 X-Request-Class
+func WithClassifier(classifier func(ctx zeroapi.Context) string) Option {
+	return func(config *config) {
+		if classifier != nil {
+			config.classifier = classifier
+		}
+	}
+}
+
+// WithRejectHandler 设置拒绝请求时的处理函数，默认响应 503，调用前已经设置了 Retry-After
+// 对于可选的内容，也可以响应 200 与空数据，客户端不需要处理错误
+func WithRejectHandler(handler zeroapi.Handler) Option {
+	return func(config *config) {
+		config.rejectHandler = handler
+	}
+}
+
+// WithNow 设置获取当前时间的函数，默认 time.Now
+func WithNow(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
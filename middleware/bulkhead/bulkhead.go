@@ -0,0 +1,195 @@
+// Package bulkhead 舱壁隔离，限制一组路由同时处理的请求数
+// 每个舱壁的并发数与等待队列相互独立，慢接口(例如生成报表)占满自己的舱壁时，不会影响其它接口
+//
+// 示例:
+// reports := bulkhead.New("reports", bulkhead.WithMaxConcurrent(4), bulkhead.WithMaxWaiting(16), bulkhead.WithMaxWait(time.Second))
+// app.Group("/reports").Use(reports.Handler())
+// app.Get("/export", reports.Handler(), export)
+package bulkhead
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// metricActive 正在处理的请求数
+	metricActive = "http_bulkhead_active"
+
+	// metricWaiting 等待处理的请求数
+	metricWaiting = "http_bulkhead_waiting"
+
+	// metricRejected 被拒绝的请求数
+	metricRejected = "http_bulkhead_rejected_total"
+)
+
+const (
+	// ReasonFull 等待队列已满
+	ReasonFull = "full"
+
+	// ReasonTimeout 等待超时
+	ReasonTimeout = "timeout"
+
+	// ReasonCanceled 等待时请求被取消
+	ReasonCanceled = "canceled"
+)
+
+// ErrRejected 舱壁已满，请求被拒绝
+var ErrRejected = zeroapi.NewHTTPError(http.StatusServiceUnavailable, "SERVICE UNAVAILABLE")
+
+// Bulkhead 舱壁，使用同一个舱壁的路由共享并发数与等待队列
+type Bulkhead struct {
+	name   string
+	config *config
+
+	// slots 正在处理的请求占用的位置
+	slots chan struct{}
+
+	// waiting 等待处理的请求数
+	waiting int64
+
+	mu sync.Mutex
+
+	// latency 处理耗时的指数移动平均，用于估算 Retry-After
+	latency time.Duration
+}
+
+// Stats 舱壁当前状态
+type Stats struct {
+	Name          string
+	Active        int
+	Waiting       int
+	MaxConcurrent int
+	MaxWaiting    int
+}
+
+// New 创建名称为 name 的舱壁，名称用于指标标签
+func New(name string, opts ...Option) *Bulkhead {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Bulkhead{
+		name:   name,
+		config: config,
+		slots:  make(chan struct{}, config.maxConcurrent),
+	}
+}
+
+// Name 舱壁名称
+func (b *Bulkhead) Name() string {
+	return b.name
+}
+
+// Stats 获取舱壁当前状态
+func (b *Bulkhead) Stats() Stats {
+	return Stats{
+		Name:          b.name,
+		Active:        len(b.slots),
+		Waiting:       int(atomic.LoadInt64(&b.waiting)),
+		MaxConcurrent: b.config.maxConcurrent,
+		MaxWaiting:    b.config.maxWaiting,
+	}
+}
+
+// Handler 创建中间件，请求获得位置后继续执行，响应结束后释放位置
+// 没有空闲位置时进入等待队列，队列已满、等待超时或者请求被取消时拒绝
+func (b *Bulkhead) Handler() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		metrics := ctx.App().Metrics()
+
+		if reason := b.acquire(ctx); reason != "" {
+			metrics.Counter(metricRejected).Add(1, "bulkhead", b.name, "reason", reason)
+			b.reject(ctx)
+			return
+		}
+
+		metrics.Gauge(metricActive).Add(1, "bulkhead", b.name)
+		start := time.Now()
+
+		// 无论之后的处理是否中断或者发生 panic 都会执行
+		ctx.AppendEnd(func() error {
+			b.observe(time.Since(start))
+			<-b.slots
+			metrics.Gauge(metricActive).Add(-1, "bulkhead", b.name)
+			return nil
+		})
+	}
+}
+
+// acquire 获取位置，失败时返回原因
+func (b *Bulkhead) acquire(ctx zeroapi.Context) string {
+	select {
+	case b.slots <- struct{}{}:
+		return ""
+	default:
+	}
+
+	if atomic.AddInt64(&b.waiting, 1) > int64(b.config.maxWaiting) {
+		atomic.AddInt64(&b.waiting, -1)
+		return ReasonFull
+	}
+
+	metrics := ctx.App().Metrics()
+	metrics.Gauge(metricWaiting).Add(1, "bulkhead", b.name)
+	defer func() {
+		atomic.AddInt64(&b.waiting, -1)
+		metrics.Gauge(metricWaiting).Add(-1, "bulkhead", b.name)
+	}()
+
+	var timeout <-chan time.Time
+	if b.config.maxWait > 0 {
+		timer := time.NewTimer(b.config.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return ""
+	case <-timeout:
+		return ReasonTimeout
+	case <-ctx.Context().Done():
+		return ReasonCanceled
+	}
+}
+
+// observe 记录处理耗时
+func (b *Bulkhead) observe(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.latency == 0 {
+		b.latency = d
+		return
+	}
+	b.latency = (b.latency*4 + d) / 5
+}
+
+// retryAfter 估算获得位置需要等待的时长，即排在前面的请求按照平均耗时处理完毕的时长
+func (b *Bulkhead) retryAfter() time.Duration {
+	b.mu.Lock()
+	latency := b.latency
+	b.mu.Unlock()
+
+	rounds := atomic.LoadInt64(&b.waiting)/int64(b.config.maxConcurrent) + 1
+	return latency * time.Duration(rounds)
+}
+
+// reject 拒绝请求，Retry-After 为估算的等待时长
+func (b *Bulkhead) reject(ctx zeroapi.Context) {
+	ctx.SetRetryAfter(b.retryAfter(), b.config.jitter)
+
+	if b.config.rejectHandler != nil {
+		b.config.rejectHandler(ctx)
+		ctx.Stopped()
+		return
+	}
+
+	ctx.Error(ErrRejected)
+}
@@ -0,0 +1,118 @@
+package bulkhead_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/bulkhead"
+)
+
+func TestBulkhead(t *testing.T) {
+	a := app.NewApp()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	reports := bulkhead.New("reports", bulkhead.WithMaxConcurrent(1))
+	a.Group("/reports").Use(reports.Handler()).Get("/slow", func(ctx zeroapi.Context) {
+		entered <- struct{}{}
+		<-release
+		ctx.Text("report")
+	})
+	a.Get("/ping", func(ctx zeroapi.Context) { ctx.Text("pong") })
+	a.Router().Build()
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() { done <- serve("/reports/slow") }()
+	<-entered
+
+	if w := serve("/reports/slow"); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expect 503 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// 其它舱壁外的路由不受影响
+	if w := serve("/ping"); w.Code != http.StatusOK || w.Body.String() != "pong" {
+		t.Fatalf("unexpected ping response: %d %s", w.Code, w.Body.String())
+	}
+
+	close(release)
+	if w := <-done; w.Body.String() != "report" {
+		t.Fatalf("unexpected report response: %s", w.Body.String())
+	}
+
+	// 位置在响应结束后异步释放
+	deadline := time.Now().Add(time.Second)
+	for reports.Stats().Active != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("slot not released")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	go func() { <-entered }()
+	if w := serve("/reports/slow"); w.Code != http.StatusOK {
+		t.Fatalf("expect 200, got %d", w.Code)
+	}
+}
+
+func TestBulkheadWaiting(t *testing.T) {
+	a := app.NewApp()
+
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	b := bulkhead.New("export",
+		bulkhead.WithMaxConcurrent(1),
+		bulkhead.WithMaxWaiting(1),
+		bulkhead.WithMaxWait(200*time.Millisecond),
+		bulkhead.WithRejectHandler(func(ctx zeroapi.Context) {
+			ctx.SetHTTPCode(http.StatusTooManyRequests)
+		}),
+	)
+	a.Get("/export", b.Handler(), func(ctx zeroapi.Context) {
+		entered <- struct{}{}
+		<-release
+	})
+	a.Router().Build()
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+		return w.Code
+	}
+
+	go serve()
+	<-entered
+
+	// 等待超时
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Fatalf("expect 429, got %d", code)
+	}
+
+	// 等待期间获得位置
+	codes := make(chan int, 1)
+	go func() { codes <- serve() }()
+	for b.Stats().Waiting != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 等待队列已满
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Fatalf("expect 429, got %d", code)
+	}
+
+	close(release)
+	if code := <-codes; code != http.StatusOK {
+		t.Fatalf("expect 200, got %d", code)
+	}
+}
@@ -0,0 +1,77 @@
+package bulkhead
+
+import (
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// config 舱壁配置
+type config struct {
+	// maxConcurrent 同时处理的最大请求数
+	maxConcurrent int
+
+	// maxWaiting 等待处理的最大请求数，超过时直接拒绝
+	maxWaiting int
+
+	// maxWait 最长等待时间，为 0 时一直等待到请求被取消
+	maxWait time.Duration
+
+	// rejectHandler 拒绝请求时的处理函数，为空时响应 503
+	rejectHandler zeroapi.Handler
+
+	// jitter Retry-After 随机增加的最大时长
+	jitter time.Duration
+}
+
+func defaultConfig() *config {
+	return &config{
+		maxConcurrent: 10,
+	}
+}
+
+// Option 舱壁配置选项
+type Option func(config *config)
+
+// WithMaxConcurrent 设置同时处理的最大请求数，默认 10
+func WithMaxConcurrent(n int) Option {
+	return func(config *config) {
+		if n > 0 {
+			config.maxConcurrent = n
+		}
+	}
+}
+
+// WithMaxWaiting 设置等待处理的最大请求数，默认 0，即没有空闲位置时直接拒绝
+func WithMaxWaiting(n int) Option {
+	return func(config *config) {
+		if n >= 0 {
+			config.maxWaiting = n
+		}
+	}
+}
+
+// WithMaxWait 设置最长等待时间，超时后拒绝，默认 0，即一直等待到请求被取消
+func WithMaxWait(d time.Duration) Option {
+	return func(config *config) {
+		if d >= 0 {
+			config.maxWait = d
+		}
+	}
+}
+
+// WithRejectHandler 设置拒绝请求时的处理函数，默认响应 503，调用前已经设置了 Retry-After
+func WithRejectHandler(handler zeroapi.Handler) Option {
+	return func(config *config) {
+		config.rejectHandler = handler
+	}
+}
+
+// WithJitter 拒绝请求时 Retry-After 随机增加 [0, jitter) 的时长，避免客户端同时重试，默认不增加
+func WithJitter(jitter time.Duration) Option {
+	return func(config *config) {
+		if jitter >= 0 {
+			config.jitter = jitter
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Package compress 响应压缩中间件，根据 Accept-Encoding 选择压缩算法
+// 内置 gzip，brotli, zstd 等可以通过 WithEncoding 添加
+// 通过 WithDictionaries 支持字典压缩(Compression Dictionary Transport)，见 Dictionaries
+// 响应体小于最小压缩长度、Content-Type 不在允许列表中、SSE(text/event-stream) 时不压缩
+//
+// 示例:
+// app.Use(compress.New(compress.WithMinLength(512)))
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// Compressor 压缩器，*gzip.Writer, *flate.Writer 以及常见的 brotli, zstd 实现都满足该接口
+type Compressor interface {
+	io.WriteCloser
+
+	// Flush 将已压缩的数据写入下层
+	Flush() error
+
+	// Reset 丢弃当前状态，之后写入 w，用于复用
+	Reset(w io.Writer)
+}
+
+// encoding 一种压缩算法，压缩器通过 pool 复用
+type encoding struct {
+	name string
+	pool *sync.Pool
+
+	// prefix 写在压缩数据之前，字典压缩时为格式头部与字典摘要
+	prefix []byte
+}
+
+func newEncoding(name string, newWriter func(w io.Writer) Compressor) *encoding {
+	return &encoding{
+		name: name,
+		pool: &sync.Pool{
+			New: func() interface{} {
+				return newWriter(ioutil.Discard)
+			},
+		},
+	}
+}
+
+// acquire 从池中获取压缩器
+func (e *encoding) acquire(w io.Writer) Compressor {
+	c := e.pool.Get().(Compressor)
+	c.Reset(w)
+	return c
+}
+
+// release 将压缩器放入池中
+func (e *encoding) release(c Compressor) {
+	c.Reset(ioutil.Discard)
+	e.pool.Put(c)
+}
+
+// New 创建响应压缩中间件
+func New(opts ...Option) zeroapi.Handler {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	level := config.gzipLevel
+	config.encodings = append(config.encodings, newEncoding("gzip", func(w io.Writer) Compressor {
+		// 级别已经在 WithGzipLevel 中校验
+		gw, _ := gzip.NewWriterLevel(w, level)
+		return gw
+	}))
+
+	return func(ctx zeroapi.Context) {
+		res := ctx.Response()
+		res.Header().Add("Vary", "Accept-Encoding")
+
+		var enc *encoding
+		if config.dictionaries != nil {
+			res.Header().Add("Vary", "Available-Dictionary")
+
+			if enc = negotiateDictionary(config, ctx); enc == nil && ctx.Method() == http.MethodGet {
+				// 告知客户端可以使用的字典，客户端在空闲时下载
+				if d := config.dictionaries.latest(ctx.Request().URL.Path); d != nil {
+					res.Header().Add("Link", "<"+d.URL+`>; rel="compression-dictionary"`)
+				}
+			}
+		}
+
+		if ctx.Method() == http.MethodHead {
+			return
+		}
+
+		if enc == nil {
+			enc = negotiate(config.encodings, ctx.Header("Accept-Encoding"))
+		}
+		if enc == nil {
+			return
+		}
+
+		w := &compressWriter{
+			ResponseWriter: res.Writer(),
+			config:         config,
+			encoding:       enc,
+		}
+		res.SetWriter(w)
+		res.BeforeFinish(w.close)
+	}
+}
+
+// negotiate 根据 Accept-Encoding 选择压缩算法，q 值相同时按照 encodings 的顺序优先
+func negotiate(encodings []*encoding, acceptEncoding string) *encoding {
+	if acceptEncoding == "" {
+		return nil
+	}
+
+	var best *encoding
+	bestQ := 0.0
+
+	for _, enc := range encodings {
+		q := quality(acceptEncoding, enc.name)
+		if q > bestQ {
+			best = enc
+			bestQ = q
+		}
+	}
+
+	return best
+}
+
+// quality 获取 Accept-Encoding 中 name 的 q 值，没有出现时使用 * 的 q 值
+func quality(acceptEncoding, name string) float64 {
+	q, wildcard := -1.0, 0.0
+
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(item, ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+
+		v := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					v = f
+				}
+			}
+		}
+
+		switch coding {
+		case name:
+			q = v
+		case "*":
+			wildcard = v
+		}
+	}
+
+	if q < 0 {
+		return wildcard
+	}
+	return q
+}
+
+// allowed 判断该响应是否允许压缩
+func allowed(config *config, header http.Header, status int) bool {
+	if !statusAllowed(status) {
+		return false
+	}
+
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+
+	if strings.Contains(header.Get("Cache-Control"), "no-transform") {
+		return false
+	}
+
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if contentType == "" || strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+
+	for _, allow := range config.contentTypes {
+		if strings.HasPrefix(contentType, allow) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// statusAllowed 判断该状态码的响应是否允许压缩，没有响应体或者是部分内容时不压缩
+func statusAllowed(status int) bool {
+	return status >= http.StatusOK &&
+		status != http.StatusNoContent &&
+		status != http.StatusPartialContent &&
+		status != http.StatusNotModified
+}
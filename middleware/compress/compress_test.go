@@ -0,0 +1,181 @@
+package compress_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/compress"
+)
+
+var large = strings.Repeat("zero-api ", 512)
+
+func newApp(opts ...compress.Option) zeroapi.App {
+	a := app.NewApp()
+	a.Use(compress.New(opts...))
+	a.Get("/large", func(ctx zeroapi.Context) {
+		ctx.SetHeader("Content-Type", "text/plain;charset=utf-8")
+		ctx.Text(large)
+	})
+	a.Get("/small", func(ctx zeroapi.Context) {
+		ctx.Text("small")
+	})
+	a.Get("/sse", func(ctx zeroapi.Context) {
+		stream, err := ctx.SSE()
+		if err != nil {
+			return
+		}
+		defer stream.Close()
+		stream.Send("message", large)
+	})
+	a.Router().Build()
+	return a
+}
+
+func get(a zeroapi.App, path, acceptEncoding string) *http.Response {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w.Result()
+}
+
+func TestGzip(t *testing.T) {
+	res := get(newApp(), "/large", "deflate, gzip;q=0.8")
+	if res.Header.Get("Content-Encoding") != "gzip" || res.Header.Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("invalid header: %v", res.Header)
+	}
+
+	r, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(r)
+	if string(body) != large {
+		t.Fatalf("invalid body length: %d", len(body))
+	}
+}
+
+func TestNotCompressed(t *testing.T) {
+	a := newApp()
+
+	res := get(a, "/small", "gzip")
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.Header.Get("Content-Encoding") != "" || string(body) != "small" {
+		t.Fatalf("small response should not be compressed: %v, %q", res.Header, body)
+	}
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("invalid content type: %s", res.Header.Get("Content-Type"))
+	}
+
+	res = get(a, "/large", "gzip;q=0, identity")
+	if res.Header.Get("Content-Encoding") != "" {
+		t.Fatal("gzip is not acceptable")
+	}
+
+	res = get(a, "/sse", "gzip")
+	body, _ = ioutil.ReadAll(res.Body)
+	if res.Header.Get("Content-Encoding") != "" || !strings.Contains(string(body), "zero-api") {
+		t.Fatalf("event stream should not be compressed: %v", res.Header)
+	}
+}
+
+func TestCustomEncoding(t *testing.T) {
+	a := newApp(compress.WithEncoding("deflate", func(w io.Writer) compress.Compressor {
+		fw, _ := flate.NewWriter(w, flate.BestSpeed)
+		return fw
+	}))
+
+	// q 值相同时，自定义的压缩算法优先
+	res := get(a, "/large", "gzip, deflate")
+	if res.Header.Get("Content-Encoding") != "deflate" {
+		t.Fatalf("invalid encoding: %s", res.Header.Get("Content-Encoding"))
+	}
+	body, _ := ioutil.ReadAll(flate.NewReader(res.Body))
+	if string(body) != large {
+		t.Fatalf("invalid body length: %d", len(body))
+	}
+
+	res = get(a, "/large", "gzip, deflate;q=0.5")
+	if res.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("invalid encoding: %s", res.Header.Get("Content-Encoding"))
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	dicts := compress.NewDictionaries("/_dict")
+	d := dicts.Add("text-v1", "/lar*", []byte(large[:256]))
+
+	// 使用 flate 的字典模拟 brotli
+	a := app.NewApp()
+	a.Get("/_dict/:hash", dicts.Handler())
+	a.Use(compress.New(compress.WithDictionaries(dicts), compress.WithDictionaryEncoding("dcb", func(w io.Writer, dict []byte) compress.Compressor {
+		fw, _ := flate.NewWriterDict(w, flate.BestCompression, dict)
+		return fw
+	})))
+	a.Get("/large", func(ctx zeroapi.Context) {
+		ctx.SetHeader("Content-Type", "text/plain;charset=utf-8")
+		ctx.Text(large)
+	})
+	a.Router().Build()
+
+	// 没有字典时告知客户端字典地址
+	res := get(a, "/large", "gzip, dcb")
+	if res.Header.Get("Content-Encoding") != "gzip" || res.Header.Get("Link") != "<"+d.URL+`>; rel="compression-dictionary"` {
+		t.Fatalf("invalid response: %v", res.Header)
+	}
+	if vary := res.Header.Values("Vary"); len(vary) != 2 || vary[1] != "Available-Dictionary" {
+		t.Fatalf("invalid vary: %v", vary)
+	}
+
+	// 下载字典
+	res = get(a, d.URL, "")
+	data, _ := ioutil.ReadAll(res.Body)
+	if string(data) != large[:256] || res.Header.Get("Use-As-Dictionary") != `match="/lar*", id="text-v1"` {
+		t.Fatalf("invalid dictionary: %v", res.Header)
+	}
+	if res = get(a, "/_dict/00", ""); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("invalid status: %d", res.StatusCode)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/large", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br, dcb")
+	req.Header.Set("Available-Dictionary", ":"+base64.StdEncoding.EncodeToString(d.Hash[:])+":")
+	req.Header.Set("Dictionary-ID", `"text-v1"`)
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+
+	res = w.Result()
+	if res.Header.Get("Content-Encoding") != "dcb" || res.Header.Get("Link") != "" {
+		t.Fatalf("invalid response: %v", res.Header)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	if !bytes.HasPrefix(body, append([]byte{0xff, 0x44, 0x43, 0x42}, d.Hash[:]...)) {
+		t.Fatalf("invalid dcb header: %x", body[:36])
+	}
+	body, _ = ioutil.ReadAll(flate.NewReaderDict(bytes.NewReader(body[36:]), d.Data))
+	if string(body) != large {
+		t.Fatalf("invalid body length: %d", len(body))
+	}
+
+	// 移除字典后使用普通的压缩算法
+	dicts.Remove("text-v1")
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	if encoding := w.Result().Header.Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("invalid encoding: %s", encoding)
+	}
+	if len(dicts.List()) != 0 {
+		t.Fatalf("invalid dictionaries: %v", dicts.List())
+	}
+}
@@ -0,0 +1,256 @@
+package compress
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// 字典压缩(Compression Dictionary Transport, RFC 9842)
+//
+// 1. 服务端通过 Link: <url>; rel="compression-dictionary" 告知客户端字典地址
+// 2. 客户端下载字典，字典响应中的 Use-As-Dictionary 声明字典用于哪些路径
+// 3. 之后请求匹配的路径时，客户端发送 Available-Dictionary: :<字典 SHA-256 的 base64>:，Accept-Encoding 中包含 dcb 或者 dcz
+// 4. 服务端使用该字典压缩响应，Content-Encoding 为 dcb(brotli) 或者 dcz(zstd)
+//
+// 结构相似的大 JSON 响应使用字典压缩时，压缩率比 gzip, brotli 高很多
+
+var (
+	// dcbMagic dcb 格式的头部，之后是 32 字节的字典 SHA-256 以及使用字典压缩的 brotli 数据
+	dcbMagic = []byte{0xff, 0x44, 0x43, 0x42}
+
+	// dczMagic dcz 格式的头部，之后是 32 字节的字典 SHA-256 以及使用字典压缩的 zstd 数据
+	dczMagic = []byte{0x5e, 0x2a, 0x4d, 0x18, 0x20, 0x00, 0x00, 0x00}
+)
+
+// dictionaryEncoding 使用字典的压缩算法
+type dictionaryEncoding struct {
+	name      string
+	magic     []byte
+	newWriter func(w io.Writer, dict []byte) Compressor
+}
+
+// Dictionary 压缩字典
+type Dictionary struct {
+	// ID 字典标识，客户端通过 Dictionary-ID 请求头发送，可以为空
+	ID string
+
+	// Match 使用该字典的路径，以 * 结尾时匹配前缀，例如 /api/orders/*
+	Match string
+
+	// URL 字典的下载地址
+	URL string
+
+	// Data 字典内容，一般是有代表性的响应体，或者从多个响应中训练得到
+	Data []byte
+
+	// Hash 字典内容的 SHA-256
+	Hash [sha256.Size]byte
+
+	mu sync.Mutex
+
+	// encodings 每种压缩算法使用该字典的压缩器池
+	encodings map[string]*encoding
+}
+
+// matches 路径是否使用该字典
+func (d *Dictionary) matches(path string) bool {
+	if strings.HasSuffix(d.Match, "*") {
+		return strings.HasPrefix(path, strings.TrimSuffix(d.Match, "*"))
+	}
+	return path == d.Match
+}
+
+// encoding 获取使用该字典的压缩算法，第一次使用时创建
+func (d *Dictionary) encoding(de *dictionaryEncoding) *encoding {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if enc, exist := d.encodings[de.name]; exist {
+		return enc
+	}
+
+	enc := newEncoding(de.name, func(w io.Writer) Compressor {
+		return de.newWriter(w, d.Data)
+	})
+	enc.prefix = make([]byte, 0, len(de.magic)+len(d.Hash))
+	enc.prefix = append(enc.prefix, de.magic...)
+	enc.prefix = append(enc.prefix, d.Hash[:]...)
+
+	d.encodings[de.name] = enc
+	return enc
+}
+
+// Dictionaries 压缩字典管理，可以在运行时添加与移除字典，例如发布新版本的字典
+//
+// 示例:
+// dicts := compress.NewDictionaries("/_dict")
+// dicts.Add("orders-v1", "/api/orders/*", sample)
+// app.Get("/_dict/:hash", dicts.Handler())
+// app.Use(compress.New(compress.WithDictionaries(dicts), compress.WithDictionaryEncoding("dcz", newZstdWriter)))
+type Dictionaries struct {
+	prefix string
+
+	mu    sync.RWMutex
+	dicts []*Dictionary
+}
+
+// NewDictionaries 创建字典管理，prefix 为字典下载地址的前缀，需要在 prefix/:hash 上注册 Handler
+func NewDictionaries(prefix string) *Dictionaries {
+	return &Dictionaries{prefix: "/" + strings.Trim(prefix, "/")}
+}
+
+// Add 添加字典，id 相同的字典被替换，match 为使用该字典的路径，以 * 结尾时匹配前缀
+// 多个字典匹配同一个路径时，后添加的字典优先告知客户端
+func (ds *Dictionaries) Add(id, match string, data []byte) *Dictionary {
+	d := &Dictionary{
+		ID:        id,
+		Match:     match,
+		Data:      data,
+		Hash:      sha256.Sum256(data),
+		encodings: make(map[string]*encoding),
+	}
+	// 下载地址包含字典的摘要，内容不会变化，可以长时间缓存
+	d.URL = ds.prefix + "/" + hex.EncodeToString(d.Hash[:])
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	dicts := make([]*Dictionary, 0, len(ds.dicts)+1)
+	for _, old := range ds.dicts {
+		if id == "" || old.ID != id {
+			dicts = append(dicts, old)
+		}
+	}
+	ds.dicts = append(dicts, d)
+	return d
+}
+
+// Remove 移除字典，之后携带该字典的请求使用普通的压缩算法
+func (ds *Dictionaries) Remove(id string) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	dicts := make([]*Dictionary, 0, len(ds.dicts))
+	for _, d := range ds.dicts {
+		if d.ID != id {
+			dicts = append(dicts, d)
+		}
+	}
+	ds.dicts = dicts
+}
+
+// List 获取所有字典，按照添加的顺序
+func (ds *Dictionaries) List() []*Dictionary {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	dicts := make([]*Dictionary, len(ds.dicts))
+	copy(dicts, ds.dicts)
+	return dicts
+}
+
+// Lookup 根据 SHA-256 查找字典，不存在时返回 nil
+func (ds *Dictionaries) Lookup(hash []byte) *Dictionary {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	for _, d := range ds.dicts {
+		if string(d.Hash[:]) == string(hash) {
+			return d
+		}
+	}
+	return nil
+}
+
+// latest 获取路径匹配的最后添加的字典
+func (ds *Dictionaries) latest(path string) *Dictionary {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	for i := len(ds.dicts) - 1; i >= 0; i-- {
+		if ds.dicts[i].matches(path) {
+			return ds.dicts[i]
+		}
+	}
+	return nil
+}
+
+// Handler 下载字典，通过动态参数 hash 查找字典，响应中包含 Use-As-Dictionary
+func (ds *Dictionaries) Handler() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		hash, err := hex.DecodeString(ctx.Dynamic("hash"))
+		var d *Dictionary
+		if err == nil {
+			d = ds.Lookup(hash)
+		}
+		if d == nil {
+			ctx.ClientError(http.StatusNotFound, zeroapi.ReasonNoRoute, "PAGE NOT FOUND")
+			return
+		}
+
+		useAs := "match=" + sfString(d.Match)
+		if d.ID != "" {
+			useAs += ", id=" + sfString(d.ID)
+		}
+
+		ctx.SetHeader("Use-As-Dictionary", useAs)
+		ctx.SetHeader("Cache-Control", "public, max-age=31536000, immutable")
+		ctx.SetHeader("ETag", `"`+hex.EncodeToString(d.Hash[:])+`"`)
+		ctx.SetHeader("Content-Type", "application/octet-stream")
+		ctx.SetHeader("Content-Length", strconv.Itoa(len(d.Data)))
+		ctx.Bytes(d.Data)
+	}
+}
+
+// negotiateDictionary 客户端携带的字典可以用于该请求时，选择使用字典的压缩算法
+func negotiateDictionary(config *config, ctx zeroapi.Context) *encoding {
+	hash, ok := parseAvailableDictionary(ctx.Header("Available-Dictionary"))
+	if !ok {
+		return nil
+	}
+
+	d := config.dictionaries.Lookup(hash)
+	if d == nil || !d.matches(ctx.Request().URL.Path) {
+		return nil
+	}
+	if id := ctx.Header("Dictionary-ID"); id != "" && d.ID != "" && id != sfString(d.ID) {
+		return nil
+	}
+
+	acceptEncoding := ctx.Header("Accept-Encoding")
+	for _, de := range config.dictionaryEncodings {
+		if quality(acceptEncoding, de.name) > 0 {
+			return d.encoding(de)
+		}
+	}
+	return nil
+}
+
+// parseAvailableDictionary 解析 Available-Dictionary，值为结构化字段中的字节序列 :base64:
+func parseAvailableDictionary(value string) ([]byte, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+		return nil, false
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+	if err != nil || len(hash) != sha256.Size {
+		return nil, false
+	}
+	return hash, true
+}
+
+// sfString 结构化字段中的字符串，只转义 \ 与 "
+func sfString(s string) string {
+	return `"` + sfEscaper.Replace(s) + `"`
+}
+
+var sfEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
@@ -0,0 +1,118 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// config 压缩配置
+type config struct {
+	// encodings 支持的压缩算法，越靠前越优先
+	encodings []*encoding
+
+	// gzipLevel gzip 压缩级别
+	gzipLevel int
+
+	// minLength 响应体小于该字节数时不压缩
+	minLength int
+
+	// contentTypes 允许压缩的 Content-Type 前缀
+	contentTypes []string
+
+	// dictionaries 压缩字典
+	dictionaries *Dictionaries
+
+	// dictionaryEncodings 使用字典的压缩算法，越靠前越优先
+	dictionaryEncodings []*dictionaryEncoding
+}
+
+func defaultConfig() *config {
+	return &config{
+		gzipLevel: gzip.DefaultCompression,
+		minLength: 1024,
+		contentTypes: []string{
+			"text/html",
+			"text/css",
+			"text/plain",
+			"text/javascript",
+			"text/xml",
+			"text/csv",
+			"application/json",
+			"application/javascript",
+			"application/xml",
+			"application/x-yaml",
+			"application/yaml",
+			"application/wasm",
+			"application/problem+json",
+			"image/svg+xml",
+		},
+	}
+}
+
+// Option 压缩配置选项
+type Option func(config *config)
+
+// WithEncoding 添加压缩算法，name 为 Content-Encoding 的值，例如 br, zstd
+// 通过 WithEncoding 添加的算法优先于 gzip，按照添加的顺序优先
+// 例如: WithEncoding("br", func(w io.Writer) compress.Compressor { return brotli.NewWriter(w) })
+func WithEncoding(name string, newWriter func(w io.Writer) Compressor) Option {
+	return func(config *config) {
+		if name != "" && newWriter != nil {
+			config.encodings = append(config.encodings, newEncoding(name, newWriter))
+		}
+	}
+}
+
+// WithDictionaries 使用字典压缩，客户端携带可用的字典时优先于其它压缩算法
+// 需要同时通过 WithDictionaryEncoding 添加使用字典的压缩算法
+func WithDictionaries(dictionaries *Dictionaries) Option {
+	return func(config *config) {
+		config.dictionaries = dictionaries
+	}
+}
+
+// WithDictionaryEncoding 添加使用字典的压缩算法，name 为 dcb(brotli) 或者 dcz(zstd)，其它值被忽略
+// newWriter 使用 dict 作为字典创建压缩器，格式头部与字典摘要由中间件写入
+// 例如: WithDictionaryEncoding("dcz", func(w io.Writer, dict []byte) compress.Compressor { e, _ := zstd.NewWriter(w, zstd.WithEncoderDictRaw(0, dict)); return e })
+func WithDictionaryEncoding(name string, newWriter func(w io.Writer, dict []byte) Compressor) Option {
+	return func(config *config) {
+		if newWriter == nil {
+			return
+		}
+
+		switch name {
+		case "dcb":
+			config.dictionaryEncodings = append(config.dictionaryEncodings, &dictionaryEncoding{name: name, magic: dcbMagic, newWriter: newWriter})
+		case "dcz":
+			config.dictionaryEncodings = append(config.dictionaryEncodings, &dictionaryEncoding{name: name, magic: dczMagic, newWriter: newWriter})
+		}
+	}
+}
+
+// WithGzipLevel 设置 gzip 压缩级别，默认 gzip.DefaultCompression
+func WithGzipLevel(level int) Option {
+	return func(config *config) {
+		if level >= gzip.HuffmanOnly && level <= gzip.BestCompression {
+			config.gzipLevel = level
+		}
+	}
+}
+
+// WithMinLength 设置最小压缩长度，响应体小于该字节数时不压缩，默认 1024
+func WithMinLength(minLength int) Option {
+	return func(config *config) {
+		if minLength >= 0 {
+			config.minLength = minLength
+		}
+	}
+}
+
+// WithContentTypes 设置允许压缩的 Content-Type，按照前缀匹配，替换默认值
+// text/event-stream 始终不会被压缩
+func WithContentTypes(contentTypes ...string) Option {
+	return func(config *config) {
+		if len(contentTypes) > 0 {
+			config.contentTypes = contentTypes
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package compress
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressWriter 包装原始的 http.ResponseWriter
+// 响应体达到最小压缩长度之前先缓存，之后再决定是否压缩并写入响应头
+type compressWriter struct {
+	http.ResponseWriter
+
+	config   *config
+	encoding *encoding
+
+	// status 待写入的状态码，0 表示处理函数还没有写入
+	status int
+
+	// decided 是否已经决定是否压缩，决定后写入响应头
+	decided bool
+
+	// compressor 不为 nil 表示正在压缩
+	compressor Compressor
+
+	// buf 决定之前缓存的响应体
+	buf []byte
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+
+	header := w.Header()
+	if !allowed(w.config, header, code) {
+		// 没有 Content-Type 时需要根据响应体判断类型，写入时再决定
+		if !statusAllowed(code) || header.Get("Content-Type") != "" {
+			w.decide(false)
+			return
+		}
+	}
+
+	if cl := header.Get("Content-Length"); cl != "" {
+		if n, err := strconv.Atoi(cl); err == nil {
+			w.decide(n >= w.config.minLength)
+		}
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.decided {
+		if len(w.buf)+len(b) < w.config.minLength {
+			w.buf = append(w.buf, b...)
+			return len(b), nil
+		}
+
+		w.buf = append(w.buf, b...)
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.compressor != nil {
+		return w.compressor.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 流式输出，之后的数据大小未知，如果还没有决定则直接压缩
+func (w *compressWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.decided {
+		w.decide(true)
+	}
+
+	if w.compressor != nil {
+		w.compressor.Flush()
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		// 连接被接管，不再写入响应
+		w.status = http.StatusSwitchingProtocols
+		w.decided = true
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// decide 决定是否压缩，然后写入响应头以及缓存的响应体
+// compress 为 true 时仍然需要检查响应头是否允许压缩
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		// 与 net/http 一致，根据内容判断类型
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if compress && allowed(w.config, header, w.status) {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding.name)
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			// 压缩后的内容不同，强 ETag 需要改为弱 ETag
+			header.Set("ETag", "W/"+etag)
+		}
+		w.compressor = w.encoding.acquire(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if w.compressor != nil && len(w.encoding.prefix) > 0 {
+		if _, err := w.ResponseWriter.Write(w.encoding.prefix); err != nil {
+			return err
+		}
+	}
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if w.compressor != nil {
+		_, err := w.compressor.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// close 响应结束，写入剩余的数据，并回收压缩器
+func (w *compressWriter) close() {
+	if w.status == 0 {
+		// 处理函数没有写入任何数据
+		return
+	}
+
+	if !w.decided {
+		// 响应体小于最小压缩长度
+		w.decide(false)
+	}
+
+	if w.compressor != nil {
+		w.compressor.Close()
+		w.encoding.release(w.compressor)
+		w.compressor = nil
+	}
+}
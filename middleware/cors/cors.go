@@ -0,0 +1,153 @@
+// Package cors 跨域资源共享(CORS)中间件
+//
+// 预检请求(OPTIONS)会根据路由树自动响应，不需要为每个路由注册 OPTIONS 处理函数
+//
+// 示例:
+// app.Use(cors.New(cors.WithAllowOrigins("https://*.example.com"), cors.WithAllowCredentials()))
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+type cors struct {
+	config *config
+
+	// allowMethods Access-Control-Allow-Methods 的值
+	allowMethods string
+
+	// allowHeaders Access-Control-Allow-Headers 的值
+	allowHeaders string
+
+	// exposeHeaders Access-Control-Expose-Headers 的值
+	exposeHeaders string
+
+	// maxAge Access-Control-Max-Age 的值
+	maxAge string
+}
+
+// New 创建跨域中间件，一般作为 App 级别中间件使用
+func New(opts ...Option) zeroapi.Handler {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	c := &cors{
+		config:        config,
+		allowMethods:  strings.Join(config.methods, ", "),
+		allowHeaders:  strings.Join(config.headers, ", "),
+		exposeHeaders: strings.Join(config.exposeHeaders, ", "),
+	}
+
+	if config.maxAge > 0 {
+		c.maxAge = strconv.Itoa(int(config.maxAge.Seconds()))
+	}
+
+	return c.handle
+}
+
+func (c *cors) handle(ctx zeroapi.Context) {
+	origin := ctx.Header("Origin")
+	if origin == "" {
+		return
+	}
+
+	ctx.AddHeader("Vary", "Origin")
+
+	if ctx.Method() == zeroapi.MethodOptions && ctx.Header("Access-Control-Request-Method") != "" {
+		c.preflight(ctx, origin)
+		return
+	}
+
+	if !c.isAllowOrigin(origin) {
+		return
+	}
+
+	c.setAllowOrigin(ctx, origin)
+	if c.exposeHeaders != "" {
+		ctx.SetHeader("Access-Control-Expose-Headers", c.exposeHeaders)
+	}
+}
+
+// preflight 响应预检请求
+// 请求的方法未被允许，或者路由树中不存在对应的路由时，不做处理，由后续处理函数响应
+func (c *cors) preflight(ctx zeroapi.Context, origin string) {
+	ctx.AddHeader("Vary", "Access-Control-Request-Method")
+	ctx.AddHeader("Vary", "Access-Control-Request-Headers")
+
+	if !c.isAllowOrigin(origin) {
+		return
+	}
+
+	method := strings.ToUpper(ctx.Header("Access-Control-Request-Method"))
+	if !c.isAllowMethod(method) {
+		return
+	}
+
+	r, _ := ctx.App().Router().MatchHost(ctx.Host())
+	if handlers, _ := r.Lookup(method, ctx.Path()); handlers == nil {
+		return
+	}
+
+	c.setAllowOrigin(ctx, origin)
+	ctx.SetHeader("Access-Control-Allow-Methods", c.allowMethods)
+
+	if c.allowHeaders != "" {
+		ctx.SetHeader("Access-Control-Allow-Headers", c.allowHeaders)
+	} else if headers := ctx.Header("Access-Control-Request-Headers"); headers != "" {
+		ctx.SetHeader("Access-Control-Allow-Headers", headers)
+	}
+
+	if c.maxAge != "" {
+		ctx.SetHeader("Access-Control-Max-Age", c.maxAge)
+	}
+
+	ctx.SetHTTPCode(http.StatusNoContent)
+	ctx.Stopped()
+}
+
+func (c *cors) setAllowOrigin(ctx zeroapi.Context, origin string) {
+	if c.config.allowAll && !c.config.credentials {
+		ctx.SetHeader("Access-Control-Allow-Origin", "*")
+	} else {
+		ctx.SetHeader("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.config.credentials {
+		ctx.SetHeader("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) isAllowOrigin(origin string) bool {
+	if c.config.allowAll {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if c.config.origins[origin] {
+		return true
+	}
+
+	for _, pattern := range c.config.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *cors) isAllowMethod(method string) bool {
+	for _, m := range c.config.methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
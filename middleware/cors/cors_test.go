@@ -0,0 +1,80 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/cors"
+)
+
+func newApp(opts ...cors.Option) zeroapi.App {
+	a := app.NewApp()
+	a.Use(cors.New(opts...))
+	a.Get("/user/:id", func(ctx zeroapi.Context) {
+		ctx.Text("user")
+	})
+	a.Router().Build()
+	return a
+}
+
+func preflight(a zeroapi.App, path, origin, method string) *http.Response {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	req.Header.Set("Access-Control-Request-Headers", "X-Token")
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w.Result()
+}
+
+func TestPreflight(t *testing.T) {
+	a := newApp(cors.WithAllowOrigins("https://*.example.com"), cors.WithAllowCredentials())
+
+	res := preflight(a, "/user/1", "https://api.example.com", http.MethodGet)
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("invalid status: %d", res.StatusCode)
+	}
+	if origin := res.Header.Get("Access-Control-Allow-Origin"); origin != "https://api.example.com" {
+		t.Fatalf("invalid allow origin: %s", origin)
+	}
+	if headers := res.Header.Get("Access-Control-Allow-Headers"); headers != "X-Token" {
+		t.Fatalf("invalid allow headers: %s", headers)
+	}
+	if res.Header.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatal("credentials should be allowed")
+	}
+
+	// 路由树中不存在 POST /user/:id，预检失败，路径只允许 GET
+	res = preflight(a, "/user/1", "https://api.example.com", http.MethodPost)
+	if res.StatusCode != http.StatusMethodNotAllowed || res.Header.Get("Allow") != "GET" {
+		t.Fatalf("invalid status: %d", res.StatusCode)
+	}
+
+	// 来源不允许
+	res = preflight(a, "/user/1", "https://example.org", http.MethodGet)
+	if res.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("origin should not be allowed")
+	}
+}
+
+func TestSimpleRequest(t *testing.T) {
+	a := newApp(cors.WithExposeHeaders("X-Total"))
+
+	req := httptest.NewRequest(http.MethodGet, "/user/1", nil)
+	req.Header.Set("Origin", "https://example.org")
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+
+	res := w.Result()
+	if origin := res.Header.Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Fatalf("invalid allow origin: %s", origin)
+	}
+	if expose := res.Header.Get("Access-Control-Expose-Headers"); expose != "X-Total" {
+		t.Fatalf("invalid expose headers: %s", expose)
+	}
+}
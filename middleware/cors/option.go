@@ -0,0 +1,129 @@
+package cors
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// config 跨域配置
+type config struct {
+	// allowAll 允许所有来源
+	allowAll bool
+
+	// origins 允许的来源，精确匹配
+	origins map[string]bool
+
+	// patterns 允许的来源，正则表达式匹配，包括含有通配符 * 的来源
+	patterns []*regexp.Regexp
+
+	// methods 允许的方法
+	methods []string
+
+	// headers 允许的请求头，为空时允许预检请求中的所有请求头
+	headers []string
+
+	// exposeHeaders 允许客户端读取的响应头
+	exposeHeaders []string
+
+	// credentials 是否允许携带 cookie 等凭证
+	credentials bool
+
+	// maxAge 预检请求结果的缓存时间
+	maxAge time.Duration
+}
+
+func defaultConfig() *config {
+	return &config{
+		allowAll: true,
+		origins:  make(map[string]bool),
+		methods: []string{
+			zeroapi.MethodGet,
+			zeroapi.MethodPost,
+			zeroapi.MethodPut,
+			zeroapi.MethodDelete,
+			zeroapi.MethodHead,
+			zeroapi.MethodPatch,
+		},
+		maxAge: 10 * time.Minute,
+	}
+}
+
+// Option 跨域配置选项
+type Option func(config *config)
+
+// WithAllowOrigins 设置允许的来源，默认允许所有来源
+// "*" 表示所有来源，"https://*.example.com" 表示 example.com 的所有子域名
+func WithAllowOrigins(origins ...string) Option {
+	return func(config *config) {
+		config.allowAll = false
+
+		for _, origin := range origins {
+			switch {
+			case origin == "*":
+				config.allowAll = true
+			case strings.Contains(origin, "*"):
+				expr := strings.Replace(regexp.QuoteMeta(strings.ToLower(origin)), `\*`, `[^/]+`, -1)
+				config.patterns = append(config.patterns, regexp.MustCompile("^"+expr+"$"))
+			case origin != "":
+				config.origins[strings.ToLower(origin)] = true
+			}
+		}
+	}
+}
+
+// WithAllowOriginPatterns 设置允许的来源，使用正则表达式匹配
+func WithAllowOriginPatterns(patterns ...*regexp.Regexp) Option {
+	return func(config *config) {
+		config.allowAll = false
+
+		for _, pattern := range patterns {
+			if pattern != nil {
+				config.patterns = append(config.patterns, pattern)
+			}
+		}
+	}
+}
+
+// WithAllowMethods 设置允许的方法，默认 GET, POST, PUT, DELETE, HEAD, PATCH
+func WithAllowMethods(methods ...string) Option {
+	return func(config *config) {
+		if len(methods) > 0 {
+			config.methods = make([]string, 0, len(methods))
+			for _, method := range methods {
+				config.methods = append(config.methods, strings.ToUpper(method))
+			}
+		}
+	}
+}
+
+// WithAllowHeaders 设置允许的请求头，默认允许预检请求中的所有请求头
+func WithAllowHeaders(headers ...string) Option {
+	return func(config *config) {
+		config.headers = headers
+	}
+}
+
+// WithExposeHeaders 设置允许客户端读取的响应头
+func WithExposeHeaders(headers ...string) Option {
+	return func(config *config) {
+		config.exposeHeaders = headers
+	}
+}
+
+// WithAllowCredentials 允许携带 cookie 等凭证
+// 此时不会返回 Access-Control-Allow-Origin: *，而是返回请求的来源
+func WithAllowCredentials() Option {
+	return func(config *config) {
+		config.credentials = true
+	}
+}
+
+// WithMaxAge 设置预检请求结果的缓存时间，默认 10 分钟，小于等于 0 表示不发送 Access-Control-Max-Age
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(config *config) {
+		config.maxAge = maxAge
+	}
+}
@@ -0,0 +1,132 @@
+// Package csrf 跨站请求伪造(CSRF)防护中间件
+//
+// 支持两种模式:
+// ModeToken: 令牌模式，令牌保存在 cookie 中，页面通过 csrf.Token(ctx) 获取令牌，并在请求头或者表单中提交
+// ModeFetchMetadata: 根据 Sec-Fetch-Site 校验，现代浏览器无需传递令牌，其它客户端回退到令牌模式
+//
+// 示例:
+// app.Use(csrf.New(csrf.WithMode(csrf.ModeFetchMetadata)))
+package csrf
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"io"
+	"net/http"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	zctx "github.com/zerogo-hub/zero-api/context"
+)
+
+// ReasonCSRFFailed CSRF 校验失败，用于 ctx.ClientError
+const ReasonCSRFFailed = "csrf_failed"
+
+// valueKeyToken 令牌保存在 ctx.Value 中的键
+const valueKeyToken = "zeroapi.csrf.token"
+
+type csrf struct {
+	config *config
+}
+
+// New 创建 CSRF 防护中间件
+func New(opts ...Option) zeroapi.Handler {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	c := &csrf{config: config}
+	return c.handle
+}
+
+// Token 获取当前请求的令牌，用于在页面中输出，ModeFetchMetadata 模式下浏览器请求可能为空
+func Token(ctx zeroapi.Context) string {
+	if token, ok := ctx.Value(valueKeyToken).(string); ok {
+		return token
+	}
+	return ""
+}
+
+func (c *csrf) handle(ctx zeroapi.Context) {
+	if c.config.mode == ModeFetchMetadata {
+		if site := ctx.Header("Sec-Fetch-Site"); site != "" {
+			if !c.checkFetchMetadata(ctx, site) {
+				ctx.ClientError(http.StatusForbidden, ReasonCSRFFailed, "cross-site request forbidden")
+			}
+			return
+		}
+	}
+
+	token := c.ensureToken(ctx)
+
+	if isSafeMethod(ctx.Method()) {
+		return
+	}
+
+	if !c.checkToken(ctx, token) {
+		ctx.ClientError(http.StatusForbidden, ReasonCSRFFailed, "invalid csrf token")
+	}
+}
+
+// checkFetchMetadata 校验 Sec-Fetch-*，见 https://www.w3.org/TR/fetch-metadata/
+func (c *csrf) checkFetchMetadata(ctx zeroapi.Context, site string) bool {
+	switch site {
+	// none: 用户直接访问，例如输入地址、书签
+	case "same-origin", "none":
+		return true
+	case "same-site":
+		if c.config.allowSameSite {
+			return true
+		}
+	}
+
+	// 跨站请求只允许安全方法，例如从其它网站跳转过来
+	return isSafeMethod(ctx.Method())
+}
+
+// ensureToken 获取 cookie 中的令牌，不存在时生成新的令牌并设置 cookie
+func (c *csrf) ensureToken(ctx zeroapi.Context) string {
+	token, err := ctx.Cookie(c.config.cookieName)
+	if err != nil || token == "" {
+		token = newToken(ctx)
+		ctx.SetCookie(c.config.cookieName, token,
+			zctx.WithCookiePath(c.config.cookiePath),
+			zctx.WithCookieMaxAge(c.config.cookieMaxAge),
+			zctx.WithCookieSecure(c.config.cookieSecure),
+			// 页面脚本需要读取令牌后放入请求头
+			zctx.WithCookieHTTPOnly(false),
+		)
+	}
+
+	ctx.SetValue(valueKeyToken, token)
+	return token
+}
+
+func (c *csrf) checkToken(ctx zeroapi.Context, token string) bool {
+	submitted := ctx.Header(c.config.header)
+	if submitted == "" {
+		submitted = ctx.Request().PostFormValue(c.config.formField)
+	}
+
+	if submitted == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(submitted), []byte(token)) == 1
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case zeroapi.MethodGet, zeroapi.MethodHead, zeroapi.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func newToken(ctx zeroapi.Context) string {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(ctx.Rand(), b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
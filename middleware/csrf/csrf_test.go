@@ -0,0 +1,87 @@
+package csrf_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/csrf"
+)
+
+func newApp(opts ...csrf.Option) zeroapi.App {
+	a := app.NewApp()
+	a.Use(csrf.New(opts...))
+
+	ok := func(ctx zeroapi.Context) {
+		ctx.Text(csrf.Token(ctx))
+	}
+	a.Get("/form", ok)
+	a.Post("/submit", ok)
+	a.Router().Build()
+	return a
+}
+
+func serve(a zeroapi.App, req *http.Request) *http.Response {
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w.Result()
+}
+
+func TestTokenMode(t *testing.T) {
+	a := newApp()
+
+	res := serve(a, httptest.NewRequest(http.MethodGet, "/form", nil))
+	cookies := res.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "_csrf" {
+		t.Fatal("token cookie not set")
+	}
+	token := cookies[0].Value
+
+	req := httptest.NewRequest(http.MethodPost, "/submit", nil)
+	req.AddCookie(&http.Cookie{Name: "_csrf", Value: token})
+	if res := serve(a, req); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("missing token should be forbidden: %d", res.StatusCode)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/submit", nil)
+	req.AddCookie(&http.Cookie{Name: "_csrf", Value: token})
+	req.Header.Set("X-CSRF-Token", token)
+	if res := serve(a, req); res.StatusCode != http.StatusOK {
+		t.Fatalf("valid token should pass: %d", res.StatusCode)
+	}
+}
+
+func TestFetchMetadataMode(t *testing.T) {
+	a := newApp(csrf.WithMode(csrf.ModeFetchMetadata))
+
+	cases := []struct {
+		method string
+		site   string
+		want   int
+	}{
+		{http.MethodPost, "same-origin", http.StatusOK},
+		{http.MethodPost, "cross-site", http.StatusForbidden},
+		{http.MethodPost, "same-site", http.StatusForbidden},
+		{http.MethodGet, "cross-site", http.StatusOK},
+		// 不支持 Sec-Fetch-* 时回退到令牌模式
+		{http.MethodPost, "", http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		path := "/submit"
+		if c.method == http.MethodGet {
+			path = "/form"
+		}
+
+		req := httptest.NewRequest(c.method, path, nil)
+		if c.site != "" {
+			req.Header.Set("Sec-Fetch-Site", c.site)
+		}
+
+		if res := serve(a, req); res.StatusCode != c.want {
+			t.Fatalf("method: %s, site: %s, want: %d, got: %d", c.method, c.site, c.want, res.StatusCode)
+		}
+	}
+}
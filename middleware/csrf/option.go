@@ -0,0 +1,103 @@
+package csrf
+
+// Mode 校验模式
+type Mode int
+
+const (
+	// ModeToken 令牌模式(Double Submit Cookie)
+	// 令牌保存在 cookie 中，非安全方法的请求需要在请求头或者表单中携带相同的令牌
+	ModeToken Mode = iota
+
+	// ModeFetchMetadata 根据浏览器发送的 Sec-Fetch-Site 校验，跨站请求只允许安全方法，适用于只允许同源访问的接口
+	// 不支持 Sec-Fetch-* 的浏览器或者客户端，回退到令牌模式
+	ModeFetchMetadata
+)
+
+// config CSRF 配置
+type config struct {
+	// mode 校验模式
+	mode Mode
+
+	// cookieName 保存令牌的 cookie 名称
+	cookieName string
+
+	// cookiePath 保存令牌的 cookie 路径
+	cookiePath string
+
+	// cookieMaxAge 保存令牌的 cookie 存活时间，单位秒
+	cookieMaxAge int
+
+	// cookieSecure 保存令牌的 cookie 是否只在 https 中传输
+	cookieSecure bool
+
+	// header 携带令牌的请求头
+	header string
+
+	// formField 携带令牌的表单字段
+	formField string
+
+	// allowSameSite Sec-Fetch-Site 为 same-site 时是否允许
+	allowSameSite bool
+}
+
+func defaultConfig() *config {
+	return &config{
+		mode:         ModeToken,
+		cookieName:   "_csrf",
+		cookiePath:   "/",
+		cookieMaxAge: 12 * 3600,
+		header:       "X-CSRF-Token",
+		formField:    "_csrf",
+	}
+}
+
+// Option CSRF 配置选项
+type Option func(config *config)
+
+// WithMode 设置校验模式，默认 ModeToken
+func WithMode(mode Mode) Option {
+	return func(config *config) {
+		config.mode = mode
+	}
+}
+
+// WithCookie 设置保存令牌的 cookie，默认名称 _csrf，路径 /，存活 12 小时
+func WithCookie(name, path string, maxAge int, secure bool) Option {
+	return func(config *config) {
+		if name != "" {
+			config.cookieName = name
+		}
+		if path != "" {
+			config.cookiePath = path
+		}
+		if maxAge > 0 {
+			config.cookieMaxAge = maxAge
+		}
+		config.cookieSecure = secure
+	}
+}
+
+// WithHeader 设置携带令牌的请求头，默认 X-CSRF-Token
+func WithHeader(header string) Option {
+	return func(config *config) {
+		if header != "" {
+			config.header = header
+		}
+	}
+}
+
+// WithFormField 设置携带令牌的表单字段，默认 _csrf
+func WithFormField(field string) Option {
+	return func(config *config) {
+		if field != "" {
+			config.formField = field
+		}
+	}
+}
+
+// WithAllowSameSite ModeFetchMetadata 模式下，允许同站(same-site)但不同源的请求，例如子域名
+func WithAllowSameSite() Option {
+	return func(config *config) {
+		config.allowSameSite = true
+	}
+}
@@ -0,0 +1,102 @@
+package httpcache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseCacheControl 解析 Cache-Control，指令名称转为小写，没有值的指令值为空字符串
+func parseCacheControl(values []string) map[string]string {
+	directives := make(map[string]string)
+
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+
+			name, arg := item, ""
+			if i := strings.IndexByte(item, '='); i >= 0 {
+				name, arg = item[:i], strings.Trim(strings.TrimSpace(item[i+1:]), `"`)
+			}
+			directives[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+
+	return directives
+}
+
+// seconds 获取秒数指令的值，不存在或者格式不正确时 ok 为 false
+func seconds(directives map[string]string, name string) (time.Duration, bool) {
+	arg, exist := directives[name]
+	if !exist {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// has 是否存在指令
+func has(directives map[string]string, name string) bool {
+	_, exist := directives[name]
+	return exist
+}
+
+// freshness 根据响应的 Cache-Control 计算有效期与过期后仍然可以使用的时间，不能缓存时 ok 为 false
+// s-maxage 优先于 max-age，都没有时使用 WithTTL 设置的时间
+// 请求包含 Authorization 时，只有响应声明了 public 或者 s-maxage 才缓存
+func freshness(config *config, req, res http.Header) (ttl, stale time.Duration, ok bool) {
+	directives := parseCacheControl(res.Values("Cache-Control"))
+
+	if has(directives, "no-store") || has(directives, "no-cache") || has(directives, "private") {
+		return 0, 0, false
+	}
+
+	if req.Get("Authorization") != "" && !has(directives, "public") && !has(directives, "s-maxage") {
+		return 0, 0, false
+	}
+
+	ttl, exist := seconds(directives, "s-maxage")
+	if !exist {
+		if ttl, exist = seconds(directives, "max-age"); !exist {
+			ttl = config.ttl
+		}
+	}
+
+	stale, exist = seconds(directives, "stale-while-revalidate")
+	if !exist {
+		stale = config.staleWhileRevalidate
+	}
+
+	if has(directives, "must-revalidate") || has(directives, "proxy-revalidate") {
+		stale = 0
+	}
+
+	return ttl, stale, ttl+stale > 0
+}
+
+// cacheableStatus 默认可以缓存的状态码，见 RFC 9110 15.1
+func cacheableStatus(status int) bool {
+	switch status {
+	case http.StatusOK,
+		http.StatusNonAuthoritativeInfo,
+		http.StatusNoContent,
+		http.StatusMultipleChoices,
+		http.StatusMovedPermanently,
+		http.StatusPermanentRedirect,
+		http.StatusNotFound,
+		http.StatusMethodNotAllowed,
+		http.StatusGone,
+		http.StatusRequestURITooLong,
+		http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
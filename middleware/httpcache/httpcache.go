@@ -0,0 +1,283 @@
+// Package httpcache 响应缓存，缓存完整的响应(状态码、响应头、响应体)，命中时不再执行之后的处理函数
+// 只缓存 GET 请求，HEAD 请求使用 GET 请求的缓存；缓存键为主机名，请求路径与查询参数，以及 WithVary 设置的请求头
+// 有效期由响应的 Cache-Control 决定(s-maxage, max-age, stale-while-revalidate)，no-store, no-cache, private 以及设置 cookie 的响应不缓存
+// 过期后在 stale-while-revalidate 时间内仍然使用过期的响应，同时在后台重新执行一次请求更新缓存
+//
+// 示例:
+// posts := httpcache.New(httpcache.WithTTL(30*time.Second), httpcache.WithVary("Accept-Encoding"))
+// app.Get("/posts/:id", posts.Handler(), getPost)
+// app.Put("/posts/:id", func(ctx zeroapi.Context) { updatePost(ctx); posts.Invalidate(ctx.Context(), "GET "+ctx.Host()+"/posts/"+ctx.Dynamic("id")) })
+// posts.InvalidatePrefix(ctx.Context(), "GET example.com/posts") 删除 example.com 所有文章的缓存
+package httpcache
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// metricRequests 缓存结果计数器，标签 result(hit, stale, miss)
+const metricRequests = "http_cache_requests_total"
+
+const (
+	// StatusHit 命中缓存
+	StatusHit = "HIT"
+
+	// StatusStale 使用过期的缓存，同时在后台更新
+	StatusStale = "STALE"
+
+	// StatusMiss 没有命中缓存
+	StatusMiss = "MISS"
+)
+
+// varySeparator 缓存键与 Vary 请求头之间的分隔符
+const varySeparator = "\n"
+
+// revalidateKey 后台更新缓存的请求在 context.Context 中的键，值为发起更新的 Cache
+type revalidateKey struct{}
+
+// Cache 响应缓存，使用同一个 Cache 的路由共享存储
+type Cache struct {
+	config *config
+
+	mu sync.Mutex
+
+	// revalidating 正在后台更新的缓存键
+	revalidating map[string]struct{}
+}
+
+// New 创建响应缓存
+func New(opts ...Option) *Cache {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.store == nil {
+		config.store = NewMemoryStore(0)
+	}
+
+	return &Cache{
+		config:       config,
+		revalidating: make(map[string]struct{}),
+	}
+}
+
+// Handler 创建中间件，命中缓存时写入缓存的响应并停止继续向下调用，否则在响应结束时缓存
+func (c *Cache) Handler() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		method := ctx.Method()
+		if method != http.MethodGet && method != http.MethodHead {
+			return
+		}
+
+		req := ctx.Request()
+		lookup := req.Context().Value(revalidateKey{}) != c
+		if lookup && c.config.requestDirectives {
+			directives := parseCacheControl(req.Header.Values("Cache-Control"))
+			if has(directives, "no-store") {
+				return
+			}
+			if maxAge, ok := seconds(directives, "max-age"); has(directives, "no-cache") || (ok && maxAge == 0) {
+				lookup = false
+			}
+		}
+
+		key := c.key(ctx)
+		if lookup && c.lookup(ctx, key) {
+			return
+		}
+
+		c.setStatus(ctx, StatusMiss)
+		if method == http.MethodHead {
+			return
+		}
+
+		res := ctx.Response()
+		w := &captureWriter{ResponseWriter: res.Writer(), max: c.config.maxSize}
+		res.SetWriter(w)
+		res.BeforeFinish(func() {
+			c.store(ctx, key, w)
+		})
+	}
+}
+
+// Invalidate 删除缓存键为 key 的缓存，包括 Vary 请求头不同的所有缓存
+// 示例: c.Invalidate(ctx.Context(), "GET example.com/posts/1001")
+func (c *Cache) Invalidate(ctx context.Context, key string) error {
+	if err := c.config.store.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	if len(c.config.vary) > 0 {
+		return c.config.store.DeletePrefix(ctx, key+varySeparator)
+	}
+	return nil
+}
+
+// InvalidatePrefix 删除所有缓存键以 prefix 开头的缓存
+// 示例: c.InvalidatePrefix(ctx.Context(), "GET example.com/posts")
+func (c *Cache) InvalidatePrefix(ctx context.Context, prefix string) error {
+	return c.config.store.DeletePrefix(ctx, prefix)
+}
+
+// key 缓存键，加上 Vary 请求头的值
+func (c *Cache) key(ctx zeroapi.Context) string {
+	key := c.config.key(ctx)
+	if len(c.config.vary) == 0 {
+		return key
+	}
+
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range c.config.vary {
+		b.WriteString(varySeparator)
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(ctx.Request().Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// lookup 使用缓存的响应，没有可用的缓存时返回 false
+func (c *Cache) lookup(ctx zeroapi.Context, key string) bool {
+	entry, err := c.config.store.Get(ctx.Context(), key)
+	if err != nil {
+		ctx.App().Logger().Errorf("httpcache: get %q failed: %s", key, err.Error())
+		return false
+	}
+	if entry == nil {
+		return false
+	}
+
+	age := entry.age(ctx.Now())
+	switch {
+	case age < entry.TTL:
+		c.serve(ctx, entry, age, StatusHit)
+	case age < entry.TTL+entry.Stale:
+		c.serve(ctx, entry, age, StatusStale)
+		c.revalidate(ctx, key)
+	default:
+		return false
+	}
+	return true
+}
+
+// serve 写入缓存的响应，并停止继续向下调用
+func (c *Cache) serve(ctx zeroapi.Context, entry *Entry, age time.Duration, status string) {
+	header := ctx.Response().Header()
+	for name, values := range entry.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+	c.setStatus(ctx, status)
+
+	ctx.SetHTTPCode(entry.Status)
+	if ctx.Method() != http.MethodHead && len(entry.Body) > 0 {
+		ctx.Bytes(entry.Body)
+	}
+	ctx.Stopped()
+}
+
+// setStatus 设置缓存结果响应头，并记录指标
+func (c *Cache) setStatus(ctx zeroapi.Context, status string) {
+	if c.config.statusHeader != "" {
+		ctx.Response().Header().Set(c.config.statusHeader, status)
+	}
+	ctx.App().Metrics().Counter(metricRequests).Add(1, "result", strings.ToLower(status))
+}
+
+// revalidate 在后台重新执行一次请求，更新缓存，同一个缓存键同时只会更新一次
+// 请求会经过所有的中间件，去掉条件请求头，保证得到完整的响应
+func (c *Cache) revalidate(ctx zeroapi.Context, key string) {
+	c.mu.Lock()
+	if _, exist := c.revalidating[key]; exist {
+		c.mu.Unlock()
+		return
+	}
+	c.revalidating[key] = struct{}{}
+	c.mu.Unlock()
+
+	req := ctx.Request().Clone(context.WithValue(context.Background(), revalidateKey{}, c))
+	req.Method = http.MethodGet
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	for _, name := range []string{"Cache-Control", "Pragma", "If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range", "Range"} {
+		req.Header.Del(name)
+	}
+
+	server := ctx.App().Server()
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.revalidating, key)
+			c.mu.Unlock()
+		}()
+
+		server.ServeHTTP(&discardWriter{header: make(http.Header)}, req)
+	}()
+}
+
+// store 响应结束时缓存
+func (c *Cache) store(ctx zeroapi.Context, key string, w *captureWriter) {
+	if w.skip || !cacheableStatus(w.status) || len(ctx.Warnings()) > 0 {
+		return
+	}
+
+	header := w.Header()
+	if header.Get("Set-Cookie") != "" || strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") || !c.varyAllowed(header) {
+		return
+	}
+
+	ttl, stale, ok := freshness(c.config, ctx.Request().Header, header)
+	if !ok {
+		return
+	}
+
+	entry := &Entry{
+		Status:   w.status,
+		Header:   header.Clone(),
+		Body:     w.buf.Bytes(),
+		StoredAt: ctx.Now(),
+		TTL:      ttl,
+		Stale:    stale,
+	}
+	for _, name := range []string{"Age", "Connection", "Keep-Alive", "Transfer-Encoding", c.config.statusHeader} {
+		entry.Header.Del(name)
+	}
+
+	// 响应结束时请求可能已经被取消
+	if err := c.config.store.Set(context.Background(), key, entry, ttl+stale); err != nil {
+		ctx.App().Logger().Errorf("httpcache: set %q failed: %s", key, err.Error())
+	}
+}
+
+// varyAllowed 响应的 Vary 请求头是否都参与了缓存键
+func (c *Cache) varyAllowed(header http.Header) bool {
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name == "*" || !contains(c.config.vary, http.CanonicalHeaderKey(name)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package httpcache_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/httpcache"
+)
+
+func serve(a zeroapi.App, method, url string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w
+}
+
+func TestCache(t *testing.T) {
+	a := app.NewApp()
+	c := httpcache.New(httpcache.WithVary("Accept-Language"))
+
+	var calls int64
+	post := func(ctx zeroapi.Context) {
+		n := atomic.AddInt64(&calls, 1)
+		ctx.Text(ctx.Dynamic("id") + ":" + ctx.Header("Accept-Language") + ":" + strconv.FormatInt(n, 10))
+	}
+	a.Get("/posts/:id", c.Handler(), post)
+	a.Head("/posts/:id", c.Handler(), post)
+	a.Get("/private", c.Handler(), func(ctx zeroapi.Context) {
+		atomic.AddInt64(&calls, 1)
+		ctx.SetHeader("Cache-Control", "no-store")
+		ctx.Text("private")
+	})
+	a.Get("/encoded", c.Handler(), func(ctx zeroapi.Context) {
+		atomic.AddInt64(&calls, 1)
+		ctx.SetHeader("Vary", "Accept-Encoding")
+		ctx.Text("encoded")
+	})
+	a.Router().Build()
+
+	w := serve(a, http.MethodGet, "/posts/1")
+	if w.Header().Get("X-Cache") != httpcache.StatusMiss || w.Body.String() != "1::1" {
+		t.Fatalf("invalid response: %s, %s", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	w = serve(a, http.MethodGet, "/posts/1")
+	if w.Header().Get("X-Cache") != httpcache.StatusHit || w.Body.String() != "1::1" || w.Header().Get("Age") != "0" {
+		t.Fatalf("invalid response: %s, %s", w.Header().Get("X-Cache"), w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != contentType {
+		t.Fatalf("invalid content type: %s", w.Header().Get("Content-Type"))
+	}
+
+	w = serve(a, http.MethodHead, "/posts/1")
+	if w.Header().Get("X-Cache") != httpcache.StatusHit || w.Body.Len() != 0 {
+		t.Fatalf("invalid head response: %s, %s", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	// Vary 请求头不同时分别缓存
+	if w = serve(a, http.MethodGet, "/posts/1", "Accept-Language", "en"); w.Body.String() != "1:en:2" {
+		t.Fatalf("invalid response: %s", w.Body.String())
+	}
+	if w = serve(a, http.MethodGet, "/posts/1", "Accept-Language", "en"); w.Body.String() != "1:en:2" {
+		t.Fatalf("invalid response: %s", w.Body.String())
+	}
+
+	if err := c.Invalidate(context.Background(), "GET example.com/posts/1"); err != nil {
+		t.Fatal(err)
+	}
+	if w = serve(a, http.MethodGet, "/posts/1", "Accept-Language", "en"); w.Body.String() != "1:en:3" {
+		t.Fatalf("expect invalidated, got %s", w.Body.String())
+	}
+
+	serve(a, http.MethodGet, "/posts/2")
+	if err := c.InvalidatePrefix(context.Background(), "GET example.com/posts/"); err != nil {
+		t.Fatal(err)
+	}
+	if w = serve(a, http.MethodGet, "/posts/2"); w.Header().Get("X-Cache") != httpcache.StatusMiss {
+		t.Fatalf("expect invalidated, got %s", w.Header().Get("X-Cache"))
+	}
+
+	for _, url := range []string{"/private", "/encoded"} {
+		serve(a, http.MethodGet, url)
+		before := atomic.LoadInt64(&calls)
+		if w = serve(a, http.MethodGet, url); w.Header().Get("X-Cache") != httpcache.StatusMiss || atomic.LoadInt64(&calls) != before+1 {
+			t.Fatalf("%s: expect not cached", url)
+		}
+	}
+}
+
+func TestCacheHost(t *testing.T) {
+	a := app.NewApp()
+	c := httpcache.New()
+	a.Get("/", c.Handler(), func(ctx zeroapi.Context) {
+		ctx.Text(ctx.Host())
+	})
+	a.Router().Build()
+
+	for i := 0; i < 2; i++ {
+		for _, host := range []string{"a.example.com", "b.example.com"} {
+			w := serve(a, http.MethodGet, "http://"+host+"/")
+			if w.Body.String() != host {
+				t.Fatalf("%s: got %s from cache %s", host, w.Body.String(), w.Header().Get("X-Cache"))
+			}
+			if expected := []string{httpcache.StatusMiss, httpcache.StatusHit}[i]; w.Header().Get("X-Cache") != expected {
+				t.Fatalf("%s: expect %s, got %s", host, expected, w.Header().Get("X-Cache"))
+			}
+		}
+	}
+
+	if err := c.Invalidate(context.Background(), "GET a.example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(a, http.MethodGet, "http://a.example.com/"); w.Header().Get("X-Cache") != httpcache.StatusMiss {
+		t.Fatalf("expect invalidated, got %s", w.Header().Get("X-Cache"))
+	}
+	if w := serve(a, http.MethodGet, "http://b.example.com/"); w.Header().Get("X-Cache") != httpcache.StatusHit {
+		t.Fatalf("expect b.example.com still cached, got %s", w.Header().Get("X-Cache"))
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+
+	a := app.NewApp(app.WithNow(clock))
+	c := httpcache.New()
+
+	var version int64
+	a.Get("/config", c.Handler(), func(ctx zeroapi.Context) {
+		ctx.SetHeader("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		ctx.Text("v" + strconv.FormatInt(atomic.AddInt64(&version, 1), 10))
+	})
+	a.Router().Build()
+
+	serve(a, http.MethodGet, "/config")
+
+	advance(30 * time.Second)
+	w := serve(a, http.MethodGet, "/config")
+	if w.Header().Get("X-Cache") != httpcache.StatusStale || w.Body.String() != "v1" || w.Header().Get("Age") != "30" {
+		t.Fatalf("invalid stale response: %s, %s, %s", w.Header().Get("X-Cache"), w.Body.String(), w.Header().Get("Age"))
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		w = serve(a, http.MethodGet, "/config")
+		if w.Header().Get("X-Cache") == httpcache.StatusHit && w.Body.String() == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cache not revalidated: %s, %s", w.Header().Get("X-Cache"), w.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 超过 stale-while-revalidate 后不再使用
+	advance(2 * time.Minute)
+	if w = serve(a, http.MethodGet, "/config"); w.Header().Get("X-Cache") != httpcache.StatusMiss || w.Body.String() != "v3" {
+		t.Fatalf("invalid response: %s, %s", w.Header().Get("X-Cache"), w.Body.String())
+	}
+}
+
+// fakeRedis 用 Go 实现与 Lua 脚本相同的逻辑，不处理过期时间
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (r *fakeRedis) eval(_ context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case strings.Contains(script, "SCAN"):
+		n := int64(0)
+		if len(keys) > 0 {
+			if _, exist := r.values[keys[0]]; exist {
+				delete(r.values, keys[0])
+				n++
+			}
+		}
+		if len(args) > 0 {
+			prefix := strings.TrimSuffix(args[0].(string), "*")
+			for key := range r.values {
+				if strings.HasPrefix(key, prefix) {
+					delete(r.values, key)
+					n++
+				}
+			}
+		}
+		return n, nil
+	case strings.Contains(script, "'SET'"):
+		r.values[keys[0]] = args[0].(string)
+		return int64(1), nil
+	default:
+		return r.values[keys[0]], nil
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	redis := &fakeRedis{values: make(map[string]string)}
+	store := httpcache.NewRedisStore(redis.eval, "hc:")
+	ctx := context.Background()
+
+	entry := &httpcache.Entry{Status: http.StatusOK, Header: http.Header{"Content-Type": {"text/plain"}}, Body: []byte("ok"), TTL: time.Minute}
+	if err := store.Set(ctx, "GET /a", entry, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	store.Set(ctx, "GET /b", entry, time.Minute)
+
+	got, err := store.Get(ctx, "GET /a")
+	if err != nil || got == nil || string(got.Body) != "ok" || got.Header.Get("Content-Type") != "text/plain" {
+		t.Fatalf("invalid entry: %v, %v", got, err)
+	}
+
+	if got, err = store.Get(ctx, "GET /missing"); got != nil || err != nil {
+		t.Fatalf("expect missing: %v, %v", got, err)
+	}
+
+	store.DeletePrefix(ctx, "GET /")
+	if len(redis.values) != 0 {
+		t.Fatalf("expect deleted: %v", redis.values)
+	}
+}
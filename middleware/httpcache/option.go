@@ -0,0 +1,127 @@
+package httpcache
+
+import (
+	"net/http"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// config 响应缓存配置
+type config struct {
+	// store 缓存存储
+	store Store
+
+	// ttl 响应没有通过 Cache-Control 指定有效期时的缓存时间，为 0 时不缓存
+	ttl time.Duration
+
+	// staleWhileRevalidate 响应没有指定 stale-while-revalidate 时，过期后仍然可以使用的时间
+	staleWhileRevalidate time.Duration
+
+	// vary 参与缓存键的请求头，已规范化
+	vary []string
+
+	// key 获取缓存键
+	key func(ctx zeroapi.Context) string
+
+	// maxSize 可以缓存的最大响应体，超过时不缓存
+	maxSize int
+
+	// statusHeader 标记缓存结果的响应头，为空时不设置
+	statusHeader string
+
+	// requestDirectives 是否遵守请求中的 Cache-Control
+	requestDirectives bool
+}
+
+func defaultConfig() *config {
+	return &config{
+		ttl:          time.Minute,
+		key:          defaultKey,
+		maxSize:      1 << 20,
+		statusHeader: "X-Cache",
+	}
+}
+
+// defaultKey 默认使用主机名，请求路径与查询参数，HEAD 请求使用 GET 请求的缓存
+// 包含主机名，避免多个域名共享路径时互相使用对方的缓存
+func defaultKey(ctx zeroapi.Context) string {
+	return http.MethodGet + " " + ctx.Host() + ctx.Request().URL.RequestURI()
+}
+
+// Option 响应缓存配置选项
+type Option func(config *config)
+
+// WithStore 设置缓存存储，默认使用 NewMemoryStore(10000)，多实例部署时可以使用 NewRedisStore 共享缓存
+func WithStore(store Store) Option {
+	return func(config *config) {
+		if store != nil {
+			config.store = store
+		}
+	}
+}
+
+// WithTTL 设置响应没有通过 Cache-Control 的 s-maxage, max-age 指定有效期时的缓存时间，默认 1 分钟，为 0 时不缓存这些响应
+func WithTTL(ttl time.Duration) Option {
+	return func(config *config) {
+		if ttl >= 0 {
+			config.ttl = ttl
+		}
+	}
+}
+
+// WithStaleWhileRevalidate 设置响应没有指定 stale-while-revalidate 时，过期后仍然可以使用的时间，默认 0
+// 在该时间内的请求直接使用过期的响应，同时在后台重新执行一次请求更新缓存
+func WithStaleWhileRevalidate(d time.Duration) Option {
+	return func(config *config) {
+		if d >= 0 {
+			config.staleWhileRevalidate = d
+		}
+	}
+}
+
+// WithVary 设置参与缓存键的请求头，例如 Accept-Encoding, Accept-Language
+// 响应的 Vary 中包含其它请求头或者为 * 时不缓存
+func WithVary(headers ...string) Option {
+	return func(config *config) {
+		for _, header := range headers {
+			if header != "" {
+				config.vary = append(config.vary, http.CanonicalHeaderKey(header))
+			}
+		}
+	}
+}
+
+// WithKey 设置获取缓存键的函数，默认为 "GET " + 主机名 + 请求路径与查询参数，例如 "GET example.com/posts?page=2"
+// Invalidate, InvalidatePrefix 使用相同格式的键
+func WithKey(key func(ctx zeroapi.Context) string) Option {
+	return func(config *config) {
+		if key != nil {
+			config.key = key
+		}
+	}
+}
+
+// WithMaxSize 设置可以缓存的最大响应体字节数，默认 1MB
+func WithMaxSize(n int) Option {
+	return func(config *config) {
+		if n > 0 {
+			config.maxSize = n
+		}
+	}
+}
+
+// WithStatusHeader 设置标记缓存结果(HIT, STALE, MISS)的响应头，默认 X-Cache，为空时不设置
+func WithStatusHeader(name string) Option {
+	return func(config *config) {
+		config.statusHeader = name
+	}
+}
+
+// WithRequestDirectives 遵守请求中的 Cache-Control，no-store 时不使用也不更新缓存，no-cache 或者 max-age=0 时不使用缓存
+// 默认忽略，避免客户端绕过缓存
+func WithRequestDirectives() Option {
+	return func(config *config) {
+		config.requestDirectives = true
+	}
+}
@@ -0,0 +1,133 @@
+package httpcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EvalFunc 执行 Redis Lua 脚本，返回脚本的结果
+// 使用 go-redis 时: func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) { return rdb.Eval(ctx, script, keys, args...).Result() }
+type EvalFunc func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+
+// getScript 不存在时返回空字符串，避免客户端将 nil 作为错误
+const getScript = `
+local v = redis.call('GET', KEYS[1])
+if not v then
+	return ''
+end
+return v
+`
+
+// setScript ARGV[2] 为过期时间，单位毫秒
+const setScript = `
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`
+
+// deleteScript 删除 KEYS[1] 以及所有匹配 ARGV[1] 的键，返回删除的数量
+// 使用 SCAN 遍历，不会长时间阻塞，但是需要遍历所有的键
+const deleteScript = `
+local n = 0
+if KEYS[1] then
+	n = redis.call('DEL', KEYS[1])
+end
+if ARGV[1] then
+	local cursor = '0'
+	repeat
+		local reply = redis.call('SCAN', cursor, 'MATCH', ARGV[1], 'COUNT', 1000)
+		cursor = reply[1]
+		for _, key in ipairs(reply[2]) do
+			n = n + redis.call('DEL', key)
+		end
+	until cursor == '0'
+end
+return n
+`
+
+// ErrUnexpectedResult Redis 脚本返回的结果格式不正确
+var ErrUnexpectedResult = errors.New("httpcache: unexpected redis result")
+
+// redisStore 保存在 Redis 中，多个实例共享缓存
+type redisStore struct {
+	eval   EvalFunc
+	prefix string
+}
+
+// NewRedisStore 创建 Redis 存储，多实例部署时所有实例共享缓存，失效时所有实例同时失效
+// prefix: 键的前缀，例如 "httpcache:"
+func NewRedisStore(eval EvalFunc, prefix string) Store {
+	return &redisStore{eval: eval, prefix: prefix}
+}
+
+// Get 获取缓存
+func (s *redisStore) Get(ctx context.Context, key string) (*Entry, error) {
+	reply, err := s.eval(ctx, getScript, []string{s.prefix + key})
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	switch v := reply.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnexpectedResult, reply)
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	entry := &Entry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Set 设置缓存
+func (s *redisStore) Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+
+	_, err = s.eval(ctx, setScript, []string{s.prefix + key}, string(data), ms)
+	return err
+}
+
+// Delete 删除缓存
+func (s *redisStore) Delete(ctx context.Context, key string) error {
+	_, err := s.eval(ctx, deleteScript, []string{s.prefix + key})
+	return err
+}
+
+// DeletePrefix 删除所有以 prefix 开头的缓存
+func (s *redisStore) DeletePrefix(ctx context.Context, prefix string) error {
+	_, err := s.eval(ctx, deleteScript, nil, escapePattern(s.prefix+prefix)+"*")
+	return err
+}
+
+// escapePattern 转义 SCAN MATCH 中的特殊字符
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '\\', '*', '?', '[', ']':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
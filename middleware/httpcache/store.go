@@ -0,0 +1,148 @@
+package httpcache
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry 缓存的响应
+type Entry struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+
+	// StoredAt 缓存的时间
+	StoredAt time.Time `json:"stored_at"`
+
+	// TTL 有效期，超过后为过期的响应
+	TTL time.Duration `json:"ttl"`
+
+	// Stale 过期后仍然可以使用的时间，见 stale-while-revalidate
+	Stale time.Duration `json:"stale"`
+}
+
+// age 缓存的时间
+func (e *Entry) age(now time.Time) time.Duration {
+	if age := now.Sub(e.StoredAt); age > 0 {
+		return age
+	}
+	return 0
+}
+
+// Store 缓存存储
+type Store interface {
+	// Get 获取缓存，不存在时返回 nil, nil
+	Get(ctx context.Context, key string) (*Entry, error)
+
+	// Set 设置缓存，ttl 后删除
+	Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error
+
+	// Delete 删除缓存
+	Delete(ctx context.Context, key string) error
+
+	// DeletePrefix 删除所有以 prefix 开头的缓存
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// memoryItem 进程内的一个缓存
+type memoryItem struct {
+	key      string
+	entry    *Entry
+	expireAt time.Time
+}
+
+// memoryStore 进程内缓存，超过容量时淘汰最近最少使用的缓存
+type memoryStore struct {
+	capacity int
+
+	mu sync.Mutex
+
+	// ll 按照访问时间排列，最近访问的在前面
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// NewMemoryStore 创建进程内缓存存储，capacity 为最多缓存的响应数量，小于等于 0 时为 10000
+func NewMemoryStore(capacity int) Store {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+
+	return &memoryStore{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get 获取缓存
+func (s *memoryStore) Get(_ context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, exist := s.items[key]
+	if !exist {
+		return nil, nil
+	}
+
+	item := e.Value.(*memoryItem)
+	if time.Now().After(item.expireAt) {
+		s.remove(e)
+		return nil, nil
+	}
+
+	s.ll.MoveToFront(e)
+	return item.entry, nil
+}
+
+// Set 设置缓存
+func (s *memoryStore) Set(_ context.Context, key string, entry *Entry, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := &memoryItem{key: key, entry: entry, expireAt: time.Now().Add(ttl)}
+	if e, exist := s.items[key]; exist {
+		e.Value = item
+		s.ll.MoveToFront(e)
+		return nil
+	}
+
+	s.items[key] = s.ll.PushFront(item)
+	for s.ll.Len() > s.capacity {
+		s.remove(s.ll.Back())
+	}
+	return nil
+}
+
+// Delete 删除缓存
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, exist := s.items[key]; exist {
+		s.remove(e)
+	}
+	return nil
+}
+
+// DeletePrefix 删除所有以 prefix 开头的缓存
+func (s *memoryStore) DeletePrefix(_ context.Context, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, e := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(e)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) remove(e *list.Element) {
+	s.ll.Remove(e)
+	delete(s.items, e.Value.(*memoryItem).key)
+}
@@ -0,0 +1,115 @@
+package priority
+
+import (
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// Pool 队列配置
+type Pool struct {
+	// Name 名称，与请求头的值对应
+	Name string
+
+	// Weight 权重，多个队列都有请求等待时，按照权重比例分配空闲的 worker
+	Weight int
+
+	// MaxQueue 最多等待的请求数，超过时拒绝
+	MaxQueue int
+}
+
+// config 调度配置
+type config struct {
+	// header 指定优先级的请求头
+	header string
+
+	// workers 同时处理的最大请求数
+	workers int
+
+	// pools 队列配置
+	pools []Pool
+
+	// defaultPool 请求头为空或者没有对应的队列时使用的队列
+	defaultPool string
+
+	// maxWait 最长等待时间，为 0 时一直等待到请求被取消
+	maxWait time.Duration
+
+	// jitter Retry-After 随机增加的最大时长
+	jitter time.Duration
+
+	// rejectHandler 拒绝请求时的处理函数，为空时响应 503
+	rejectHandler zeroapi.Handler
+}
+
+func defaultConfig() *config {
+	return &config{
+		header:  "X-Priority",
+		workers: 8,
+	}
+}
+
+// Option 调度配置选项
+type Option func(config *config)
+
+// WithHeader 设置指定优先级的请求头，默认 X-Priority
+func WithHeader(header string) Option {
+	return func(config *config) {
+		if header != "" {
+			config.header = header
+		}
+	}
+}
+
+// WithWorkers 设置同时处理的最大请求数，默认 8
+func WithWorkers(n int) Option {
+	return func(config *config) {
+		if n > 0 {
+			config.workers = n
+		}
+	}
+}
+
+// WithPool 添加队列，第一个添加的队列为默认队列
+// weight: 权重，至少为 1
+// maxQueue: 最多等待的请求数
+func WithPool(name string, weight, maxQueue int) Option {
+	return func(config *config) {
+		if weight < 1 {
+			weight = 1
+		}
+		config.pools = append(config.pools, Pool{Name: name, Weight: weight, MaxQueue: maxQueue})
+	}
+}
+
+// WithDefaultPool 设置请求头为空或者没有对应的队列时使用的队列，默认为第一个添加的队列
+func WithDefaultPool(name string) Option {
+	return func(config *config) {
+		config.defaultPool = name
+	}
+}
+
+// WithMaxWait 设置最长等待时间，超时后拒绝，默认 0，即一直等待到请求被取消
+func WithMaxWait(d time.Duration) Option {
+	return func(config *config) {
+		if d >= 0 {
+			config.maxWait = d
+		}
+	}
+}
+
+// WithJitter 拒绝请求时 Retry-After 随机增加 [0, jitter) 的时长，避免客户端同时重试，默认不增加
+func WithJitter(jitter time.Duration) Option {
+	return func(config *config) {
+		if jitter >= 0 {
+			config.jitter = jitter
+		}
+	}
+}
+
+// WithRejectHandler 设置拒绝请求时的处理函数，默认响应 503，调用前已经设置了 Retry-After
+func WithRejectHandler(handler zeroapi.Handler) Option {
+	return func(config *config) {
+		config.rejectHandler = handler
+	}
+}
@@ -0,0 +1,282 @@
+// Package priority 按照请求头将请求分配到不同权重的队列中，由固定数量的 worker 处理
+// 例如内部的批量接口使用低权重的队列，不会影响交互请求
+// 多个队列都有请求等待时，空闲的 worker 按照权重比例分配(平滑加权轮询)，低权重的队列也不会被饿死
+//
+// 示例:
+// d := priority.New(priority.WithWorkers(16), priority.WithPool("interactive", 8, 256), priority.WithPool("batch", 1, 1024))
+// app.Use(d.Handler())
+package priority
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// metricQueued 等待处理的请求数
+	metricQueued = "http_priority_queued"
+
+	// metricRejected 被拒绝的请求数
+	metricRejected = "http_priority_rejected_total"
+)
+
+const (
+	// ReasonFull 队列已满
+	ReasonFull = "full"
+
+	// ReasonTimeout 等待超时
+	ReasonTimeout = "timeout"
+
+	// ReasonCanceled 等待时请求被取消
+	ReasonCanceled = "canceled"
+)
+
+// ErrRejected 队列已满或者等待超时，请求被拒绝
+var ErrRejected = zeroapi.NewHTTPError(http.StatusServiceUnavailable, "SERVICE UNAVAILABLE")
+
+// Dispatcher 按照优先级调度请求
+type Dispatcher struct {
+	config *config
+
+	mu sync.Mutex
+
+	// free 空闲的 worker 数量，有请求等待时一定为 0
+	free int
+
+	queues map[string]*queue
+
+	// order 按照添加顺序保存的队列，用于加权轮询
+	order []*queue
+
+	// defaultQueue 默认队列
+	defaultQueue *queue
+
+	// latency 处理耗时的指数移动平均，用于估算 Retry-After
+	latency time.Duration
+}
+
+// queue 一个优先级的等待队列
+type queue struct {
+	Pool
+
+	tickets []*ticket
+
+	// current 平滑加权轮询的当前值
+	current int
+}
+
+// ticket 等待中的请求
+type ticket struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// Stats 队列当前状态
+type Stats struct {
+	Name   string
+	Queued int
+}
+
+// New 创建调度器，没有添加队列时使用名称为 default 的队列，最多等待 1024 个请求
+func New(opts ...Option) *Dispatcher {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	if len(config.pools) == 0 {
+		config.pools = []Pool{{Name: "default", Weight: 1, MaxQueue: 1024}}
+	}
+
+	d := &Dispatcher{
+		config: config,
+		free:   config.workers,
+		queues: make(map[string]*queue, len(config.pools)),
+	}
+
+	for _, pool := range config.pools {
+		q := &queue{Pool: pool}
+		d.queues[strings.ToLower(pool.Name)] = q
+		d.order = append(d.order, q)
+	}
+
+	d.defaultQueue = d.order[0]
+	if q, ok := d.queues[strings.ToLower(config.defaultPool)]; ok {
+		d.defaultQueue = q
+	}
+
+	return d
+}
+
+// Stats 获取各个队列当前等待的请求数
+func (d *Dispatcher) Stats() []Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := make([]Stats, 0, len(d.order))
+	for _, q := range d.order {
+		stats = append(stats, Stats{Name: q.Name, Queued: len(q.tickets)})
+	}
+	return stats
+}
+
+// Handler 创建中间件，请求按照请求头进入对应的队列，获得 worker 后继续执行，响应结束后释放 worker
+func (d *Dispatcher) Handler() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		q := d.queue(ctx.Header(d.config.header))
+		metrics := ctx.App().Metrics()
+
+		if reason := d.acquire(ctx, q); reason != "" {
+			metrics.Counter(metricRejected).Add(1, "pool", q.Name, "reason", reason)
+			d.reject(ctx, q)
+			return
+		}
+
+		start := time.Now()
+
+		// 无论之后的处理是否中断或者发生 panic 都会执行
+		ctx.AppendEnd(func() error {
+			d.release(time.Since(start))
+			return nil
+		})
+	}
+}
+
+// queue 根据请求头的值获取队列
+func (d *Dispatcher) queue(name string) *queue {
+	if q, ok := d.queues[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return q
+	}
+	return d.defaultQueue
+}
+
+// acquire 获取 worker，失败时返回原因
+func (d *Dispatcher) acquire(ctx zeroapi.Context, q *queue) string {
+	d.mu.Lock()
+	if d.free > 0 {
+		d.free--
+		d.mu.Unlock()
+		return ""
+	}
+
+	if len(q.tickets) >= q.MaxQueue {
+		d.mu.Unlock()
+		return ReasonFull
+	}
+
+	t := &ticket{ready: make(chan struct{})}
+	q.tickets = append(q.tickets, t)
+	d.mu.Unlock()
+
+	gauge := ctx.App().Metrics().Gauge(metricQueued)
+	gauge.Add(1, "pool", q.Name)
+	defer gauge.Add(-1, "pool", q.Name)
+
+	var timeout <-chan time.Time
+	if d.config.maxWait > 0 {
+		timer := time.NewTimer(d.config.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	reason := ""
+	select {
+	case <-t.ready:
+		return ""
+	case <-timeout:
+		reason = ReasonTimeout
+	case <-ctx.Context().Done():
+		reason = ReasonCanceled
+	}
+
+	d.mu.Lock()
+	if t.granted {
+		// 放弃等待的同时获得了 worker，交给下一个请求
+		d.mu.Unlock()
+		d.release(0)
+		return reason
+	}
+	q.remove(t)
+	d.mu.Unlock()
+
+	return reason
+}
+
+// release 释放 worker，按照加权轮询交给等待中的请求
+func (d *Dispatcher) release(latency time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if latency > 0 {
+		if d.latency == 0 {
+			d.latency = latency
+		} else {
+			d.latency = (d.latency*4 + latency) / 5
+		}
+	}
+
+	if t := d.next(); t != nil {
+		t.granted = true
+		close(t.ready)
+		return
+	}
+
+	d.free++
+}
+
+// next 平滑加权轮询选择队列，取出最早的请求
+func (d *Dispatcher) next() *ticket {
+	var selected *queue
+	total := 0
+
+	for _, q := range d.order {
+		if len(q.tickets) == 0 {
+			continue
+		}
+		q.current += q.Weight
+		total += q.Weight
+		if selected == nil || q.current > selected.current {
+			selected = q
+		}
+	}
+
+	if selected == nil {
+		return nil
+	}
+
+	selected.current -= total
+	t := selected.tickets[0]
+	selected.tickets = selected.tickets[1:]
+	return t
+}
+
+// remove 移除放弃等待的请求
+func (q *queue) remove(t *ticket) {
+	for i, item := range q.tickets {
+		if item == t {
+			q.tickets = append(q.tickets[:i], q.tickets[i+1:]...)
+			return
+		}
+	}
+}
+
+// reject 拒绝请求，Retry-After 为排在前面的请求按照平均耗时处理完毕的时长
+func (d *Dispatcher) reject(ctx zeroapi.Context, q *queue) {
+	d.mu.Lock()
+	rounds := len(q.tickets)/d.config.workers + 1
+	retryAfter := d.latency * time.Duration(rounds)
+	d.mu.Unlock()
+
+	ctx.SetRetryAfter(retryAfter, d.config.jitter)
+
+	if d.config.rejectHandler != nil {
+		d.config.rejectHandler(ctx)
+		ctx.Stopped()
+		return
+	}
+
+	ctx.Error(ErrRejected)
+}
@@ -0,0 +1,127 @@
+package priority_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/priority"
+)
+
+func TestDispatcher(t *testing.T) {
+	a := app.NewApp()
+
+	d := priority.New(
+		priority.WithWorkers(1),
+		priority.WithPool("interactive", 3, 4),
+		priority.WithPool("batch", 1, 4),
+	)
+	a.Use(d.Handler())
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	a.Get("/block", func(ctx zeroapi.Context) {
+		entered <- struct{}{}
+		<-release
+	})
+
+	var mu sync.Mutex
+	var order []string
+	a.Get("/work", func(ctx zeroapi.Context) {
+		mu.Lock()
+		order = append(order, ctx.Header("X-Priority")[:1])
+		mu.Unlock()
+	})
+	a.Router().Build()
+
+	serve := func(path, priority string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Priority", priority)
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, req)
+		return w
+	}
+
+	go serve("/block", "batch")
+	<-entered
+
+	queued := func(n int) {
+		deadline := time.Now().Add(time.Second)
+		for {
+			total := 0
+			for _, s := range d.Stats() {
+				total += s.Queued
+			}
+			if total == n {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expect %d queued, got %d", n, total)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		p := "batch"
+		if i%2 == 0 {
+			p = "interactive"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve("/work", p)
+		}()
+		queued(i + 1)
+	}
+
+	// 队列已满
+	w := serve("/work", "batch")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expect 503 with Retry-After, got %d", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+
+	// 权重 3:1，平滑加权轮询
+	if got := strings.Join(order, ""); !strings.HasPrefix(got, "iibi") {
+		t.Fatalf("unexpected order: %s", got)
+	}
+}
+
+func TestDispatcherTimeout(t *testing.T) {
+	a := app.NewApp()
+
+	d := priority.New(priority.WithWorkers(1), priority.WithMaxWait(20*time.Millisecond))
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	a.Get("/block", d.Handler(), func(ctx zeroapi.Context) {
+		entered <- struct{}{}
+		<-release
+	})
+	a.Router().Build()
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/block", nil))
+		return w.Code
+	}
+
+	go serve()
+	<-entered
+
+	if code := serve(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503, got %d", code)
+	}
+	if d.Stats()[0].Queued != 0 {
+		t.Fatal("timed out request not removed")
+	}
+	close(release)
+}
@@ -0,0 +1,109 @@
+package prometheus
+
+import (
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/correlation"
+	"github.com/zerogo-hub/zero-api/metrics"
+)
+
+// config 指标配置
+type config struct {
+	// namespace 指标名称前缀
+	namespace string
+
+	// durationBuckets 请求耗时直方图的桶，单位秒
+	durationBuckets []float64
+
+	// sizeBuckets 响应大小直方图的桶，单位字节
+	sizeBuckets []float64
+
+	// responseSize 是否记录响应大小
+	responseSize bool
+
+	// routeLabel 获取路由标签
+	routeLabel func(ctx zeroapi.Context) string
+
+	// exemplar 获取请求耗时的 exemplar 标签
+	exemplar func(ctx zeroapi.Context) []string
+}
+
+func defaultConfig() *config {
+	return &config{
+		durationBuckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		// 256B ~ 16M
+		sizeBuckets:  metrics.ExponentialBuckets(256, 4, 9),
+		responseSize: true,
+		routeLabel:   defaultRouteLabel,
+		exemplar:     defaultExemplar,
+	}
+}
+
+// defaultRouteLabel 默认使用匹配的路由路径，例如 /blog/:id，路由不存在时统一为 NOT_FOUND，避免标签数量无限增长
+func defaultRouteLabel(ctx zeroapi.Context) string {
+	if route := ctx.RoutePath(); route != "" {
+		return route
+	}
+	return "NOT_FOUND"
+}
+
+// defaultExemplar 默认使用 traceparent 中已采样的链路 ID，未采样的链路在追踪系统中不存在
+func defaultExemplar(ctx zeroapi.Context) []string {
+	if traceID, sampled := correlation.TraceID(ctx); sampled {
+		return []string{"trace_id", traceID}
+	}
+	return nil
+}
+
+// Option 指标配置选项
+type Option func(config *config)
+
+// WithNamespace 设置指标名称前缀，例如 myapp，则 http_requests_total -> myapp_http_requests_total
+func WithNamespace(namespace string) Option {
+	return func(config *config) {
+		config.namespace = namespace
+	}
+}
+
+// WithDurationBuckets 设置请求耗时直方图的桶，单位秒
+func WithDurationBuckets(buckets []float64) Option {
+	return func(config *config) {
+		if len(buckets) > 0 {
+			config.durationBuckets = buckets
+		}
+	}
+}
+
+// WithSizeBuckets 设置响应大小直方图的桶，单位字节
+func WithSizeBuckets(buckets []float64) Option {
+	return func(config *config) {
+		if len(buckets) > 0 {
+			config.sizeBuckets = buckets
+		}
+	}
+}
+
+// WithResponseSize 是否记录响应大小，默认记录
+// 同时使用 respsize 中间件时可以关闭，两者默认使用相同的指标名称
+func WithResponseSize(enable bool) Option {
+	return func(config *config) {
+		config.responseSize = enable
+	}
+}
+
+// WithRouteLabel 设置获取路由标签的函数，默认使用 ctx.RoutePath
+// 应该返回路由定义，例如 /blog/:id，而不是请求路径，避免标签数量无限增长
+func WithRouteLabel(routeLabel func(ctx zeroapi.Context) string) Option {
+	return func(config *config) {
+		if routeLabel != nil {
+			config.routeLabel = routeLabel
+		}
+	}
+}
+
+// WithExemplar 设置获取请求耗时 exemplar 标签的函数，返回 nil 时不记录，为 nil 时关闭 exemplar
+// 默认使用 traceparent 请求头中已采样的链路 ID，标签为 trace_id
+func WithExemplar(exemplar func(ctx zeroapi.Context) []string) Option {
+	return func(config *config) {
+		config.exemplar = exemplar
+	}
+}
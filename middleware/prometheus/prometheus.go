@@ -0,0 +1,113 @@
+// Package prometheus 记录请求数、请求耗时、正在处理的请求数以及响应大小，并以 Prometheus 文本格式导出
+// 指标保存在 App.Metrics() 中，与业务指标一起导出
+//
+// 示例:
+// prometheus.Register(app, "/metrics")
+package prometheus
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/metrics"
+)
+
+// Register 添加指标记录中间件，并注册导出指标的路由 path，例如 /metrics
+func Register(app zeroapi.App, path string, opts ...Option) {
+	app.Use(New(app.Metrics(), opts...))
+	app.Get(path, Handler(app.Metrics()))
+}
+
+// New 创建指标记录中间件
+// 记录的指标:
+// http_requests_total: 请求数，标签 method, route, status
+// http_request_duration_seconds: 请求耗时直方图，标签 method, route, status，exemplar 为链路 ID，见 WithExemplar
+// http_requests_in_flight: 正在处理的请求数，标签 method
+// http_response_size_bytes: 响应大小直方图，标签 method, route, status
+func New(m zeroapi.Metrics, opts ...Option) zeroapi.Handler {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	prefix := ""
+	if config.namespace != "" {
+		prefix = config.namespace + "_"
+	}
+
+	requests := m.Counter(prefix + "http_requests_total")
+	duration := m.Histogram(prefix+"http_request_duration_seconds", config.durationBuckets)
+	exemplars, _ := duration.(zeroapi.ExemplarHistogram)
+	if config.exemplar == nil {
+		exemplars = nil
+	}
+	inFlight := m.Gauge(prefix + "http_requests_in_flight")
+
+	var size zeroapi.Histogram
+	if config.responseSize {
+		size = m.Histogram(prefix+"http_response_size_bytes", config.sizeBuckets)
+	}
+
+	return func(ctx zeroapi.Context) {
+		start := time.Now()
+		method := ctx.Method()
+
+		inFlight.Add(1, "method", method)
+
+		ctx.AppendEnd(func() error {
+			elapsed := time.Since(start)
+			inFlight.Add(-1, "method", method)
+
+			// 从响应中读取状态码与大小，包括静态文件、反向代理等直接通过 Response() 写入的响应
+			route := config.routeLabel(ctx)
+			status := strconv.Itoa(statusOf(ctx))
+
+			requests.Add(1, "method", method, "route", route, "status", status)
+			if exemplar := exemplarOf(exemplars, config, ctx); exemplar != nil {
+				exemplars.ObserveWithExemplar(elapsed.Seconds(), exemplar, "method", method, "route", route, "status", status)
+			} else {
+				duration.Observe(elapsed.Seconds(), "method", method, "route", route, "status", status)
+			}
+			if size != nil {
+				size.Observe(float64(ctx.Response().Size()), "method", method, "route", route, "status", status)
+			}
+
+			return nil
+		})
+	}
+}
+
+// statusOf 已写入的状态码，没有写入任何数据时由 net/http 写入 200
+func statusOf(ctx zeroapi.Context) int {
+	if status := ctx.Response().Status(); status != 0 {
+		return status
+	}
+	return http.StatusOK
+}
+
+// exemplarOf 获取请求耗时的 exemplar 标签，不支持或者不需要记录时返回 nil
+func exemplarOf(exemplars zeroapi.ExemplarHistogram, config *config, ctx zeroapi.Context) []string {
+	if exemplars == nil {
+		return nil
+	}
+	return config.exemplar(ctx)
+}
+
+// Handler 以 Prometheus 文本格式导出 m 中的所有指标
+// 请求头 Accept 包含 application/openmetrics-text 时以 OpenMetrics 格式导出，包含直方图的 exemplar
+func Handler(m zeroapi.Metrics) zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		contentType, write := metrics.PrometheusContentType, metrics.WritePrometheus
+		if strings.Contains(ctx.Header("Accept"), "application/openmetrics-text") {
+			contentType, write = metrics.OpenMetricsContentType, metrics.WriteOpenMetrics
+		}
+
+		ctx.SetHeader("Content-Type", contentType)
+		if err := write(ctx.Response(), m.Gather()); err != nil {
+			ctx.App().Logger().Errorf("write metrics failed: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package prometheus_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/prometheus"
+)
+
+func TestPrometheus(t *testing.T) {
+	a := app.NewApp()
+	prometheus.Register(a, "/metrics")
+	a.Get("/user", func(ctx zeroapi.Context) {
+		ctx.Text("user")
+	})
+	a.Router().Build()
+
+	a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/user", nil))
+	a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing/1", nil))
+
+	expects := []string{
+		`http_requests_total{method="GET",route="/user",status="200"} 1`,
+		`http_requests_total{method="GET",route="NOT_FOUND",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/user",status="200"} 1`,
+		`http_response_size_bytes_sum{method="GET",route="/user",status="200"} 4`,
+		`http_requests_in_flight{method="GET"} 1`,
+	}
+
+	// 记录在请求结束后的 goroutine 中执行
+	var body string
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		b, _ := ioutil.ReadAll(w.Result().Body)
+		body = string(b)
+
+		if w.Result().Header.Get("Content-Type") != "text/plain; version=0.0.4; charset=utf-8" {
+			t.Fatalf("invalid content type: %s", w.Result().Header.Get("Content-Type"))
+		}
+		if containsAll(body, expects) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("invalid metrics:\n%s", body)
+}
+
+func TestPrometheusResponse(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.js"), bytes.Repeat([]byte("a"), 5000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := app.NewApp()
+	prometheus.Register(a, "/metrics")
+	a.Static("/assets", dir)
+	// 直接通过 Response() 写入，例如反向代理透传的响应
+	a.Get("/cached", func(ctx zeroapi.Context) {
+		ctx.Response().WriteHeader(http.StatusNotModified)
+	})
+	a.Get("/upstream", func(ctx zeroapi.Context) {
+		ctx.Response().WriteHeader(http.StatusBadGateway)
+		ctx.Response().Write([]byte("bad gateway"))
+	})
+	a.Router().Build()
+
+	for _, path := range []string{"/assets/app.js", "/cached", "/upstream"} {
+		a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expects := []string{
+		`http_response_size_bytes_sum{method="GET",route="/assets/*filepath",status="200"} 5000`,
+		`http_requests_total{method="GET",route="/cached",status="304"} 1`,
+		`http_response_size_bytes_sum{method="GET",route="/upstream",status="502"} 11`,
+	}
+
+	var body string
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		b, _ := ioutil.ReadAll(w.Result().Body)
+		body = string(b)
+		if containsAll(body, expects) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("invalid metrics:\n%s", body)
+}
+
+func containsAll(s string, subs []string) bool {
+	for _, sub := range subs {
+		if !strings.Contains(s, sub) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestExemplar(t *testing.T) {
+	a := app.NewApp()
+	prometheus.Register(a, "/metrics")
+	a.Get("/user", func(ctx zeroapi.Context) {
+		ctx.Text("user")
+	})
+	a.Router().Build()
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	a.Server().ServeHTTP(httptest.NewRecorder(), req)
+
+	// 未采样的链路不记录
+	req = httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00")
+	a.Server().ServeHTTP(httptest.NewRecorder(), req)
+
+	var body string
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0,text/plain;q=0.5")
+		a.Server().ServeHTTP(w, req)
+		b, _ := ioutil.ReadAll(w.Result().Body)
+		body = string(b)
+
+		if !strings.HasPrefix(w.Result().Header.Get("Content-Type"), "application/openmetrics-text") {
+			t.Fatalf("invalid content type: %s", w.Result().Header.Get("Content-Type"))
+		}
+		if strings.Contains(body, `http_request_duration_seconds_count{method="GET",route="/user",status="200"} 2`) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if strings.Count(body, `# {trace_id="`+traceID+`"}`) != 1 || strings.Contains(body, "0af7651916cd43dd8448eb211c80319c") {
+		t.Fatalf("invalid exemplars:\n%s", body)
+	}
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Fatalf("invalid openmetrics:\n%s", body)
+	}
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStore 保存在内存中，只对当前实例生效
+type memoryStore struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+
+	// now 获取当前时间
+	now func() time.Time
+
+	// nextSweep 下次清理已恢复为满的令牌桶的时间
+	nextSweep time.Time
+}
+
+// NewMemoryStore 创建内存存储，只对当前实例生效，多实例部署时每个实例的限制分别计算
+func NewMemoryStore() Store {
+	return &memoryStore{tats: make(map[string]time.Time), now: time.Now}
+}
+
+// Take 按照 limit 从 key 对应的令牌桶中取出 cost 个令牌
+func (s *memoryStore) Take(_ context.Context, key string, limit Limit, cost int) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	result, tat := gcra(now, s.tats[key], limit, cost, false)
+	if result.Allowed {
+		s.tats[key] = tat
+	}
+
+	return result, nil
+}
+
+// Charge 按照 limit 从 key 对应的令牌桶中强制取出 cost 个令牌，令牌不足时透支
+func (s *memoryStore) Charge(_ context.Context, key string, limit Limit, cost int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, s.tats[key] = gcra(s.now(), s.tats[key], limit, cost, true)
+	return nil
+}
+
+// sweep 每分钟清理一次已恢复为满的令牌桶，与没有记录时的结果相同
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(time.Minute)
+
+	for key, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, key)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// config 限流配置
+type config struct {
+	// key 获取限流的键，例如客户端 IP，用户 ID
+	key func(ctx zeroapi.Context) string
+
+	// failOpen 存储出错时是否放行
+	failOpen bool
+
+	// headers 是否写入 RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset 响应头
+	headers bool
+
+	// jitter Retry-After 随机增加的最大时长
+	jitter time.Duration
+}
+
+func defaultConfig() *config {
+	return &config{
+		key:      defaultKey,
+		failOpen: true,
+		headers:  true,
+	}
+}
+
+// defaultKey 默认按照客户端 IP 限流，见 Context.ClientIP
+func defaultKey(ctx zeroapi.Context) string {
+	return ctx.ClientIP()
+}
+
+// Option 限流配置选项
+type Option func(config *config)
+
+// WithKey 设置获取限流键的函数，默认使用客户端 IP，返回空字符串时不限流
+func WithKey(key func(ctx zeroapi.Context) string) Option {
+	return func(config *config) {
+		if key != nil {
+			config.key = key
+		}
+	}
+}
+
+// WithFailClosed 存储出错时拒绝请求，默认记录日志后放行
+func WithFailClosed() Option {
+	return func(config *config) {
+		config.failOpen = false
+	}
+}
+
+// WithoutHeaders 不写入 RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset 响应头，Retry-After 仍然会写入
+func WithoutHeaders() Option {
+	return func(config *config) {
+		config.headers = false
+	}
+}
+
+// WithJitter 被拒绝时 Retry-After 随机增加 [0, jitter) 的时长，避免客户端同时重试，默认不增加
+func WithJitter(jitter time.Duration) Option {
+	return func(config *config) {
+		if jitter >= 0 {
+			config.jitter = jitter
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package ratelimit 基于 GCRA 的令牌桶限流，状态保存在 Store 中
+// 单实例使用内存存储，水平扩展的多个实例使用 Redis 存储共享同一个令牌桶，使限制在整个部署中准确生效
+//
+// 示例:
+// app.Use(ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.PerSecond(10, 20)))
+// app.Use(ratelimit.New(ratelimit.NewRedisStore(eval, "ratelimit:"), ratelimit.PerMinute(600, 100)))
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// New 创建限流中间件，超过限制时响应 429，Retry-After 为获得足够令牌需要等待的秒数
+// 响应头 RateLimit-Limit: 令牌桶容量，RateLimit-Remaining: 剩余令牌数，RateLimit-Reset: 令牌桶恢复为满的秒数
+// store: 限流状态的存储
+// limit: 令牌桶配置，每个请求按照 ctx.Cost() 消耗令牌，至少一个，见 zeroapi.RouteMiddleware.Cost
+// 成本超过令牌桶容量的请求永远不会被允许，直接响应 429，不扣除令牌也没有 Retry-After
+func New(store Store, limit Limit, opts ...Option) zeroapi.Handler {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return func(ctx zeroapi.Context) {
+		key := config.key(ctx)
+		if key == "" {
+			return
+		}
+
+		// 在限流中间件之前记录的成本在请求开始时扣除
+		// initial 为没有调整的成本，请求结束后与之比较得到处理过程中增加的成本
+		initial := ctx.Cost()
+		cost := initial
+		if cost < 1 {
+			cost = 1
+		}
+		if cost > limit.burst() {
+			ctx.ClientError(http.StatusTooManyRequests, zeroapi.ReasonRateLimited, "TOO MANY REQUESTS")
+			return
+		}
+
+		result, err := store.Take(ctx.Context(), key, limit, cost)
+		if err != nil {
+			if config.failOpen {
+				ctx.App().Logger().Errorf("ratelimit: %s", err.Error())
+				return
+			}
+			ctx.Error(err)
+			return
+		}
+
+		if config.headers {
+			ctx.SetHeader("RateLimit-Limit", strconv.Itoa(result.Limit))
+			ctx.SetHeader("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			ctx.SetHeader("RateLimit-Reset", strconv.FormatInt(seconds(result.ResetAfter), 10))
+		}
+
+		if !result.Allowed {
+			ctx.SetRetryAfter(result.RetryAfter, config.jitter)
+			ctx.ClientError(http.StatusTooManyRequests, zeroapi.ReasonRateLimited, "TOO MANY REQUESTS")
+			return
+		}
+
+		// 处理过程中通过 ctx.ChargeCost 增加的成本在请求结束后扣除，令牌不足时透支
+		ctx.AppendEnd(func() error {
+			extra := ctx.Cost() - initial
+			if extra <= 0 {
+				return nil
+			}
+			if err := store.Charge(context.Background(), key, limit, extra); err != nil {
+				ctx.App().Logger().Errorf("ratelimit: %s", err.Error())
+			}
+			return nil
+		})
+	}
+}
+
+// seconds 向上取整的秒数
+func seconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,219 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/ratelimit"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.PerHour(1, 3)
+
+	for i := 0; i < 3; i++ {
+		result, err := store.Take(context.Background(), "a", limit, 1)
+		if err != nil || !result.Allowed {
+			t.Fatalf("request %d: expect allowed", i)
+		}
+		if result.Remaining != 2-i {
+			t.Fatalf("request %d: expect remaining %d, got %d", i, 2-i, result.Remaining)
+		}
+	}
+
+	result, _ := store.Take(context.Background(), "a", limit, 1)
+	if result.Allowed {
+		t.Fatal("expect denied")
+	}
+	if result.RetryAfter <= 59*time.Minute || result.RetryAfter > time.Hour {
+		t.Fatalf("unexpected retry after: %s", result.RetryAfter)
+	}
+
+	// 不同的键互不影响
+	if result, _ := store.Take(context.Background(), "b", limit, 1); !result.Allowed {
+		t.Fatal("expect allowed")
+	}
+}
+
+// fakeRedis 用 Go 实现与 Lua 脚本相同的逻辑
+type fakeRedis struct {
+	now  int64
+	tats map[string]int64
+}
+
+func (r *fakeRedis) eval(_ context.Context, _ string, keys []string, args ...interface{}) (interface{}, error) {
+	burstOffset, increment, force := args[1].(int64), args[2].(int64), args[3].(int) == 1
+
+	tat, ok := r.tats[keys[0]]
+	if !ok || tat < r.now {
+		tat = r.now
+	}
+	newTat := tat + increment
+	diff := r.now - (newTat - burstOffset)
+	if diff < 0 && !force {
+		return []interface{}{int64(0), diff, tat - r.now}, nil
+	}
+	r.tats[keys[0]] = newTat
+	return []interface{}{int64(1), diff, newTat - r.now}, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	redis := &fakeRedis{now: 1e12, tats: make(map[string]int64)}
+	a := ratelimit.NewRedisStore(redis.eval, "rl:")
+	b := ratelimit.NewRedisStore(redis.eval, "rl:")
+	limit := ratelimit.PerSecond(10, 2)
+
+	// 两个实例共享同一个令牌桶
+	for i, store := range []ratelimit.Store{a, b} {
+		if result, err := store.Take(context.Background(), "ip", limit, 1); err != nil || !result.Allowed {
+			t.Fatalf("request %d: expect allowed, %v", i, err)
+		}
+	}
+
+	result, err := a.Take(context.Background(), "ip", limit, 1)
+	if err != nil || result.Allowed {
+		t.Fatal("expect denied")
+	}
+	if result.RetryAfter != 100*time.Millisecond || result.Remaining != 0 || result.ResetAfter != 200*time.Millisecond {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if _, ok := redis.tats["rl:ip"]; !ok {
+		t.Fatal("prefix not applied")
+	}
+
+	redis.now += int64(100 * time.Millisecond / time.Microsecond)
+	if result, _ := b.Take(context.Background(), "ip", limit, 1); !result.Allowed {
+		t.Fatal("expect allowed after refill")
+	}
+
+	bad := ratelimit.NewRedisStore(func(context.Context, string, []string, ...interface{}) (interface{}, error) {
+		return "OK", nil
+	}, "")
+	if _, err := bad.Take(context.Background(), "ip", limit, 1); !errors.Is(err, ratelimit.ErrUnexpectedResult) {
+		t.Fatalf("expect ErrUnexpectedResult, got %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	a := app.NewApp()
+	a.Get("/", ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.PerMinute(1, 2)), func(ctx zeroapi.Context) {
+		ctx.Text("ok")
+	})
+	a.Router().Build()
+
+	tests := []struct {
+		code       int
+		reset      string
+		retryAfter string
+	}{
+		{http.StatusOK, "60", ""},
+		{http.StatusOK, "120", ""},
+		{http.StatusTooManyRequests, "120", "60"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != tt.code {
+			t.Fatalf("request %d: expect %d, got %d", i, tt.code, w.Code)
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" {
+			t.Fatalf("request %d: missing RateLimit-Limit", i)
+		}
+		if reset := w.Header().Get("RateLimit-Reset"); reset != tt.reset {
+			t.Fatalf("request %d: expect RateLimit-Reset %s, got %s", i, tt.reset, reset)
+		}
+		if retryAfter := w.Header().Get("Retry-After"); retryAfter != tt.retryAfter {
+			t.Fatalf("request %d: expect Retry-After %q, got %q", i, tt.retryAfter, retryAfter)
+		}
+	}
+
+	// 随机增加的时长不超过 jitter
+	b := app.NewApp()
+	b.Get("/", ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.PerMinute(1, 1), ratelimit.WithJitter(30*time.Second)), func(ctx zeroapi.Context) {})
+	b.Router().Build()
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		b.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if i == 0 {
+			continue
+		}
+		retryAfter, _ := strconv.Atoi(w.Header().Get("Retry-After"))
+		if retryAfter < 60 || retryAfter > 90 {
+			t.Fatalf("unexpected Retry-After with jitter: %d", retryAfter)
+		}
+	}
+}
+
+func TestRateLimitCost(t *testing.T) {
+	a := app.NewApp()
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.PerHour(1, 5)
+	a.Use(ratelimit.New(store, limit))
+
+	a.Handle(http.MethodGet, "/report", zeroapi.RouteMiddleware{Cost: 3}, func(ctx zeroapi.Context) {})
+	a.Get("/export", func(ctx zeroapi.Context) { ctx.ChargeCost(4) })
+	a.Router().Build()
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// 成本在请求开始时扣除
+	if w := serve("/report"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "2" {
+		t.Fatalf("unexpected response: %d, remaining %s", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+
+	// 处理过程中增加的成本在请求结束后扣除，令牌不足时透支
+	if w := serve("/export"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("unexpected response: %d, remaining %s", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		result, _ := store.Take(context.Background(), "192.0.2.1", limit, 1)
+		if !result.Allowed {
+			// 请求开始时至少扣除一个，增加的 4 个在结束后全部扣除，剩余 1 - 4 = -3
+			if result.RetryAfter <= 4*time.Hour {
+				t.Fatalf("expect debt of three tokens, retry after %s", result.RetryAfter)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("extra cost not charged")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRateLimitCostOverBurst(t *testing.T) {
+	a := app.NewApp()
+	a.Use(ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.PerSecond(50, 5)))
+	a.Handle(http.MethodGet, "/report", zeroapi.RouteMiddleware{Cost: 8}, func(ctx zeroapi.Context) {})
+	a.Get("/ping", func(ctx zeroapi.Context) {})
+	a.Router().Build()
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// 成本超过容量时永远不会被允许，不提示重试
+	if w := serve("/report"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "" {
+		t.Fatalf("unexpected response: %d, retry after %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// 没有扣除令牌
+	if w := serve("/ping"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "4" {
+		t.Fatalf("unexpected response: %d, remaining %s", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EvalFunc 执行 Redis Lua 脚本，返回脚本的结果
+// 使用 go-redis 时: func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) { return rdb.Eval(ctx, script, keys, args...).Result() }
+type EvalFunc func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+
+// gcraScript 在 Redis 中原子地执行 GCRA，时间使用 Redis 的 TIME，避免各实例时钟不一致
+// 时间单位为微秒，ARGV[4] 为 1 时令牌不足也会透支，返回 {allowed, diff, resetAfter}
+// 兼容 Redis 5 之前的版本，写入前调用 redis.replicate_commands()
+const gcraScript = `
+redis.replicate_commands()
+local emission = tonumber(ARGV[1])
+local burst_offset = tonumber(ARGV[2])
+local increment = tonumber(ARGV[3])
+local force = ARGV[4] == '1'
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+local new_tat = tat + increment
+local diff = now - (new_tat - burst_offset)
+if diff < 0 and not force then
+	return {0, diff, tat - now}
+end
+redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil((new_tat - now) / 1000))
+return {1, diff, new_tat - now}
+`
+
+// ErrUnexpectedResult Redis 脚本返回的结果格式不正确
+var ErrUnexpectedResult = errors.New("ratelimit: unexpected redis result")
+
+// redisStore 保存在 Redis 中，多个实例共享限制
+type redisStore struct {
+	eval   EvalFunc
+	prefix string
+}
+
+// NewRedisStore 创建 Redis 存储，多实例部署时所有实例共享同一个令牌桶
+// prefix: 键的前缀，例如 "ratelimit:"
+func NewRedisStore(eval EvalFunc, prefix string) Store {
+	return &redisStore{eval: eval, prefix: prefix}
+}
+
+// Take 按照 limit 从 key 对应的令牌桶中取出 cost 个令牌
+func (s *redisStore) Take(ctx context.Context, key string, limit Limit, cost int) (Result, error) {
+	return s.take(ctx, key, limit, cost, false)
+}
+
+// Charge 按照 limit 从 key 对应的令牌桶中强制取出 cost 个令牌，令牌不足时透支
+func (s *redisStore) Charge(ctx context.Context, key string, limit Limit, cost int) error {
+	_, err := s.take(ctx, key, limit, cost, true)
+	return err
+}
+
+func (s *redisStore) take(ctx context.Context, key string, limit Limit, cost int, force bool) (Result, error) {
+	emission := limit.emission()
+	burst := limit.burst()
+
+	forceArg := 0
+	if force {
+		forceArg = 1
+	}
+
+	reply, err := s.eval(ctx, gcraScript, []string{s.prefix + key},
+		emission.Microseconds(),
+		(emission * time.Duration(burst)).Microseconds(),
+		(emission * time.Duration(cost)).Microseconds(),
+		forceArg,
+	)
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 3 {
+		return Result{}, fmt.Errorf("%w: %v", ErrUnexpectedResult, reply)
+	}
+
+	nums := make([]int64, len(values))
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return Result{}, fmt.Errorf("%w: %v", ErrUnexpectedResult, reply)
+		}
+		nums[i] = n
+	}
+
+	diff := time.Duration(nums[1]) * time.Microsecond
+	result := Result{
+		Allowed:    nums[0] == 1,
+		Limit:      burst,
+		ResetAfter: time.Duration(nums[2]) * time.Microsecond,
+	}
+
+	if result.Allowed {
+		result.Remaining = remaining(diff, emission)
+	} else {
+		result.RetryAfter = -diff
+		result.Remaining = remaining(emission*time.Duration(burst)-result.ResetAfter, emission)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit 令牌桶配置，所有实例使用相同的配置时，共享存储中的状态才有意义
+type Limit struct {
+	// Rate 每个 Period 补充的令牌数
+	Rate int
+
+	// Period 补充 Rate 个令牌的时长
+	Period time.Duration
+
+	// Burst 令牌桶容量，即允许的突发请求数，为 0 时等于 Rate
+	Burst int
+}
+
+// PerSecond 每秒 rate 个请求，允许突发 burst 个
+func PerSecond(rate, burst int) Limit {
+	return Limit{Rate: rate, Period: time.Second, Burst: burst}
+}
+
+// PerMinute 每分钟 rate 个请求，允许突发 burst 个
+func PerMinute(rate, burst int) Limit {
+	return Limit{Rate: rate, Period: time.Minute, Burst: burst}
+}
+
+// PerHour 每小时 rate 个请求，允许突发 burst 个
+func PerHour(rate, burst int) Limit {
+	return Limit{Rate: rate, Period: time.Hour, Burst: burst}
+}
+
+// emission 补充一个令牌的时长
+func (l Limit) emission() time.Duration {
+	if l.Rate <= 0 {
+		return l.Period
+	}
+	return l.Period / time.Duration(l.Rate)
+}
+
+// burst 令牌桶容量
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	if l.Rate > 0 {
+		return l.Rate
+	}
+	return 1
+}
+
+// Result 一次请求的限流结果
+type Result struct {
+	// Allowed 是否允许
+	Allowed bool
+
+	// Limit 令牌桶容量
+	Limit int
+
+	// Remaining 剩余令牌数
+	Remaining int
+
+	// RetryAfter 被拒绝时，需要等待多久才有足够的令牌
+	RetryAfter time.Duration
+
+	// ResetAfter 令牌桶恢复为满的时长
+	ResetAfter time.Duration
+}
+
+// Store 限流状态的存储，实现需要并发安全
+// 单实例使用 NewMemoryStore，多实例部署使用 NewRedisStore 等共享存储，使所有实例的限制合并计算
+type Store interface {
+	// Take 按照 limit 从 key 对应的令牌桶中取出 cost 个令牌，令牌不足时不取出
+	Take(ctx context.Context, key string, limit Limit, cost int) (Result, error)
+
+	// Charge 按照 limit 从 key 对应的令牌桶中强制取出 cost 个令牌，令牌不足时透支，之后的请求需要等待更久
+	// 用于请求结束后才知道的成本，见 Context.ChargeCost
+	Charge(ctx context.Context, key string, limit Limit, cost int) error
+}
+
+// gcra 按照 GCRA(通用信元速率算法) 计算结果
+// tat: 理论到达时间，即令牌桶恢复为满的时间，now 之前时视为 now
+// force: 令牌不足时是否透支
+// 返回新的 tat，没有被允许时 tat 不变
+func gcra(now, tat time.Time, limit Limit, cost int, force bool) (Result, time.Time) {
+	emission := limit.emission()
+	burst := limit.burst()
+
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTat := tat.Add(emission * time.Duration(cost))
+	allowAt := newTat.Add(-emission * time.Duration(burst))
+
+	diff := now.Sub(allowAt)
+	if diff < 0 && !force {
+		return Result{
+			Limit:      burst,
+			Remaining:  remaining(now.Sub(tat.Add(-emission*time.Duration(burst))), emission),
+			RetryAfter: -diff,
+			ResetAfter: tat.Sub(now),
+		}, tat
+	}
+
+	return Result{
+		Allowed:    true,
+		Limit:      burst,
+		Remaining:  remaining(diff, emission),
+		ResetAfter: newTat.Sub(now),
+	}, newTat
+}
+
+func remaining(d, emission time.Duration) int {
+	if d <= 0 || emission <= 0 {
+		return 0
+	}
+	return int(d / emission)
+}
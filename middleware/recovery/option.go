@@ -0,0 +1,39 @@
+package recovery
+
+import (
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// Renderer 响应 panic，stack 为发生 panic 时的调用栈
+type Renderer func(ctx zeroapi.Context, err interface{}, stack []byte)
+
+// config 恢复配置
+type config struct {
+	// debug 是否在响应中输出错误与调用栈，只能在开发环境中开启
+	debug bool
+
+	// renderer 响应 panic
+	renderer Renderer
+}
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// Option 恢复配置选项
+type Option func(config *config)
+
+// WithDebug 是否在响应中输出错误与调用栈，浏览器访问时输出 HTML 调试页面，否则输出 JSON
+// 会泄露代码信息，只能在开发环境中开启
+func WithDebug(debug bool) Option {
+	return func(config *config) {
+		config.debug = debug
+	}
+}
+
+// WithRenderer 设置响应 panic 的函数，替代默认的响应
+func WithRenderer(renderer Renderer) Option {
+	return func(config *config) {
+		config.renderer = renderer
+	}
+}
@@ -0,0 +1,79 @@
+// Package recovery 处理函数发生 panic 时，记录调用栈并响应 500
+// 中间件在处理链中按顺序执行，无法包裹之后的处理函数，所以通过 app.WithPanicHandler 设置
+//
+// 示例:
+// a := app.NewApp(app.WithPanicHandler(recovery.New(recovery.WithDebug(true))))
+package recovery
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"runtime/debug"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// New 创建 panic 处理函数
+func New(opts ...Option) zeroapi.PanicHandler {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	renderer := config.renderer
+	if renderer == nil {
+		renderer = defaultRenderer(config.debug)
+	}
+
+	return func(ctx zeroapi.Context, err interface{}) {
+		stack := debug.Stack()
+		ctx.App().Logger().Errorf("panic: %+v, %s %s\n%s", err, ctx.Method(), ctx.Path(), stack)
+
+		ctx.Stopped()
+
+		// 已经写入响应头时只能记录日志
+		if ctx.Response().Written() {
+			return
+		}
+
+		renderer(ctx, err, stack)
+	}
+}
+
+// defaultRenderer 浏览器访问时响应 HTML，否则响应 JSON
+func defaultRenderer(debug bool) Renderer {
+	return func(ctx zeroapi.Context, err interface{}, stack []byte) {
+		code := http.StatusInternalServerError
+
+		if ctx.PrefersHTML() {
+			ctx.SetHeader("Content-Type", "text/html;charset=utf-8")
+			ctx.SetHTTPCode(code)
+
+			title := "500 " + http.StatusText(code)
+			if !debug {
+				ctx.Bytes([]byte(fmt.Sprintf("<!DOCTYPE html><html><head><title>%s</title></head><body><h1>%s</h1></body></html>",
+					title, title)))
+				return
+			}
+
+			ctx.Bytes([]byte(fmt.Sprintf("<!DOCTYPE html><html><head><title>%s</title></head><body><h1>%s</h1><h2>%s</h2><pre>%s</pre></body></html>",
+				title, title, template.HTMLEscapeString(fmt.Sprint(err)), template.HTMLEscapeString(string(stack)))))
+			return
+		}
+
+		ctx.SetHeader("Content-Type", "application/json;charset=utf-8")
+		ctx.SetHTTPCode(code)
+
+		if !debug {
+			ctx.Message(code, "INTERNAL SERVER ERROR")
+			return
+		}
+
+		ctx.Map(map[string]interface{}{
+			"code":    fmt.Sprint(code),
+			"message": fmt.Sprint(err),
+			"stack":   string(stack),
+		})
+	}
+}
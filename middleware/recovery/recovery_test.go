@@ -0,0 +1,67 @@
+package recovery_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/recovery"
+)
+
+func serve(a zeroapi.App, accept string) *http.Response {
+	a.Get("/panic", func(ctx zeroapi.Context) {
+		panic("boom <script>")
+	})
+	a.Router().Build()
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("Accept", accept)
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w.Result()
+}
+
+func TestRecoveryJSON(t *testing.T) {
+	res := serve(app.NewApp(app.WithPanicHandler(recovery.New())), "application/json")
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("invalid status: %d", res.StatusCode)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["message"] != "INTERNAL SERVER ERROR" || body["stack"] != nil {
+		t.Fatalf("invalid body: %v", body)
+	}
+}
+
+func TestRecoveryDebugHTML(t *testing.T) {
+	res := serve(app.NewApp(app.WithPanicHandler(recovery.New(recovery.WithDebug(true)))), "text/html")
+	body, _ := ioutil.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusInternalServerError || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("invalid response: %d, %v", res.StatusCode, res.Header)
+	}
+	if !strings.Contains(string(body), "boom &lt;script&gt;") || !strings.Contains(string(body), "recovery_test.go") {
+		t.Fatalf("invalid debug page: %s", body)
+	}
+}
+
+func TestRecoveryRenderer(t *testing.T) {
+	var got interface{}
+	handler := recovery.New(recovery.WithRenderer(func(ctx zeroapi.Context, err interface{}, stack []byte) {
+		got = err
+		ctx.SetHTTPCode(http.StatusServiceUnavailable)
+	}))
+
+	res := serve(app.NewApp(app.WithPanicHandler(handler)), "")
+	if res.StatusCode != http.StatusServiceUnavailable || got != "boom <script>" {
+		t.Fatalf("invalid response: %d, %v", res.StatusCode, got)
+	}
+}
@@ -0,0 +1,55 @@
+package requestid
+
+import (
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/correlation"
+)
+
+// config 请求 ID 配置
+type config struct {
+	// header 请求与响应中的请求头名称
+	header string
+
+	// trustIncoming 是否使用请求中已有的请求 ID
+	trustIncoming bool
+
+	// generator 生成请求 ID
+	generator func(ctx zeroapi.Context) string
+}
+
+func defaultConfig() *config {
+	return &config{
+		header:        correlation.HeaderRequestID,
+		trustIncoming: true,
+		generator:     generate,
+	}
+}
+
+// Option 请求 ID 配置选项
+type Option func(config *config)
+
+// WithHeader 设置请求头名称，默认 X-Request-ID
+func WithHeader(header string) Option {
+	return func(config *config) {
+		if header != "" {
+			config.header = header
+		}
+	}
+}
+
+// WithTrustIncoming 是否使用请求中已有的请求 ID，默认使用
+// 服务直接暴露在公网时，可以关闭，总是生成新的请求 ID
+func WithTrustIncoming(trust bool) Option {
+	return func(config *config) {
+		config.trustIncoming = trust
+	}
+}
+
+// WithGenerator 设置生成请求 ID 的函数，默认从 ctx.Rand() 读取 16 字节并转为十六进制
+func WithGenerator(generator func(ctx zeroapi.Context) string) Option {
+	return func(config *config) {
+		if generator != nil {
+			config.generator = generator
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Package requestid 请求 ID 中间件
+// 优先使用请求头 X-Request-ID 中的值，没有或者无效时生成新的请求 ID，并写入响应头
+// 之后通过 ctx.RequestID() 获取，通过 correlation.Inject 传递给下游
+//
+// 示例:
+// app.Use(requestid.New())
+package requestid
+
+import (
+	"encoding/hex"
+	"io"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/correlation"
+)
+
+// maxLength 请求中的请求 ID 最大长度
+const maxLength = 128
+
+// New 创建请求 ID 中间件
+func New(opts ...Option) zeroapi.Handler {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return func(ctx zeroapi.Context) {
+		var id string
+		if config.trustIncoming {
+			id = ctx.Header(config.header)
+		}
+
+		if !valid(id) {
+			id = config.generator(ctx)
+		}
+
+		ctx.SetValue(correlation.ValueKeyRequestID, id)
+		ctx.SetHeader(config.header, id)
+	}
+}
+
+// valid 请求 ID 只能包含可见的 ASCII 字符，避免日志注入
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func generate(ctx zeroapi.Context) string {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(ctx.Rand(), b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
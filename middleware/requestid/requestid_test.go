@@ -0,0 +1,56 @@
+package requestid_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/requestid"
+)
+
+func serve(a zeroapi.App, id string) (string, string) {
+	var got string
+	a.Get("/", func(ctx zeroapi.Context) {
+		got = ctx.RequestID()
+	})
+	a.Router().Build()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+
+	return got, w.Result().Header.Get("X-Request-ID")
+}
+
+func TestRequestID(t *testing.T) {
+	newApp := func(opts ...requestid.Option) zeroapi.App {
+		a := app.NewApp(app.WithRand(bytes.NewReader(bytes.Repeat([]byte{0xab}, 16))))
+		a.Use(requestid.New(opts...))
+		return a
+	}
+	generated := strings.Repeat("ab", 16)
+
+	if got, header := serve(newApp(), ""); got != generated || header != generated {
+		t.Fatalf("invalid generated id: %s, %s", got, header)
+	}
+
+	if got, header := serve(newApp(), "upstream-1"); got != "upstream-1" || header != "upstream-1" {
+		t.Fatalf("invalid incoming id: %s, %s", got, header)
+	}
+
+	// 无效的请求 ID
+	if got, _ := serve(newApp(), "bad\x01id"); got != generated {
+		t.Fatalf("invalid id should be replaced: %q", got)
+	}
+
+	if got, _ := serve(newApp(requestid.WithTrustIncoming(false)), "upstream-1"); got != generated {
+		t.Fatalf("incoming id should be ignored: %s", got)
+	}
+}
@@ -0,0 +1,123 @@
+package respdiff
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// config 响应对比配置
+type config struct {
+	// ignore 对比 JSON 响应时忽略的字段
+	ignore [][]string
+
+	// headers 需要对比的响应头
+	headers []string
+
+	// methods 需要对比的请求方法，其它方法只执行原有处理函数
+	methods map[string]bool
+
+	// timeout 新处理函数的超时时间
+	timeout time.Duration
+
+	// maxBodySize 响应体超过该大小时不对比
+	maxBodySize int
+
+	// maxDifferences 最多记录多少个不同之处
+	maxDifferences int
+
+	// routeLabel 获取路由标签
+	routeLabel func(ctx zeroapi.Context) string
+
+	// onMismatch 响应不一致时执行
+	onMismatch func(result *Result)
+}
+
+func defaultConfig() *config {
+	return &config{
+		headers:        []string{"Content-Type"},
+		methods:        map[string]bool{http.MethodGet: true, http.MethodHead: true},
+		timeout:        5 * time.Second,
+		maxBodySize:    1 << 20,
+		maxDifferences: 10,
+		routeLabel:     defaultRouteLabel,
+	}
+}
+
+// defaultRouteLabel 默认使用 Method + 匹配的路由路径，路由不存在时统一为 NOT_FOUND，避免标签数量无限增长
+func defaultRouteLabel(ctx zeroapi.Context) string {
+	route := ctx.RoutePath()
+	if route == "" {
+		return "NOT_FOUND"
+	}
+	return ctx.Method() + " " + route
+}
+
+// Option 响应对比配置选项
+type Option func(config *config)
+
+// WithIgnore 对比 JSON 响应时忽略的字段，使用 "." 分隔，"*" 匹配任意字段或者数组下标
+// 示例: WithIgnore("request_id", "data.*.updated_at")
+func WithIgnore(fields ...string) Option {
+	return func(config *config) {
+		for _, field := range fields {
+			if field != "" {
+				config.ignore = append(config.ignore, strings.Split(field, "."))
+			}
+		}
+	}
+}
+
+// WithHeaders 设置需要对比的响应头，默认只对比 Content-Type
+func WithHeaders(names ...string) Option {
+	return func(config *config) {
+		config.headers = names
+	}
+}
+
+// WithMethods 设置需要对比的请求方法，默认 GET 与 HEAD
+// 新处理函数也会执行请求，对比有副作用的请求前需要确认新处理函数不会重复写入
+func WithMethods(methods ...string) Option {
+	return func(config *config) {
+		config.methods = make(map[string]bool, len(methods))
+		for _, method := range methods {
+			config.methods[strings.ToUpper(method)] = true
+		}
+	}
+}
+
+// WithTimeout 设置新处理函数的超时时间，默认 5 秒
+func WithTimeout(timeout time.Duration) Option {
+	return func(config *config) {
+		if timeout > 0 {
+			config.timeout = timeout
+		}
+	}
+}
+
+// WithMaxBodySize 设置参与对比的最大响应体，默认 1MB，超过时不对比
+func WithMaxBodySize(n int) Option {
+	return func(config *config) {
+		if n > 0 {
+			config.maxBodySize = n
+		}
+	}
+}
+
+// WithRouteLabel 设置获取路由标签的函数，用于日志和指标，默认使用 Method + 路由路径
+func WithRouteLabel(fn func(ctx zeroapi.Context) string) Option {
+	return func(config *config) {
+		if fn != nil {
+			config.routeLabel = fn
+		}
+	}
+}
+
+// WithOnMismatch 设置响应不一致时执行的函数，例如保存样本，在请求结束后执行
+func WithOnMismatch(fn func(result *Result)) Option {
+	return func(config *config) {
+		config.onMismatch = fn
+	}
+}
@@ -0,0 +1,395 @@
+// Package respdiff 迁移时对比新旧实现的响应，请求同时交给原有处理函数与新实现，只返回原有处理函数的响应
+// 响应不一致时记录日志与指标 http_response_diff_total，可以在不影响用户的情况下验证重写的接口
+//
+// 示例:
+// app.Get("/users/:id", respdiff.New(app, newService, respdiff.WithIgnore("request_id")), legacyHandler)
+package respdiff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// metricName 对比结果计数器，标签 route, result(match, mismatch, error, skipped)
+	metricName = "http_response_diff_total"
+)
+
+// Response 一次响应
+type Response struct {
+	// Status 状态码
+	Status int
+
+	// Header 响应头
+	Header http.Header
+
+	// Body 响应体
+	Body []byte
+
+	// truncated 响应体超过了 maxBodySize
+	truncated bool
+}
+
+// Result 一次对比的结果
+type Result struct {
+	// Route 路由标签
+	Route string
+
+	// Request 发送给新实现的请求，请求体已被读取
+	Request *http.Request
+
+	// Legacy 原有处理函数的响应，也是返回给客户端的响应
+	Legacy *Response
+
+	// Candidate 新实现的响应
+	Candidate *Response
+
+	// Differences 不同之处，例如 "status: 200 != 500"，"body.user.name: "a" != "b""
+	Differences []string
+}
+
+type differ struct {
+	app       zeroapi.App
+	candidate http.Handler
+	config    *config
+}
+
+// New 创建响应对比中间件，作为路由级别中间件放在原有处理函数之前
+// candidate 为新实现，例如新的 App.Server() 或者转发到新服务的 httputil.ReverseProxy
+func New(app zeroapi.App, candidate http.Handler, opts ...Option) zeroapi.Handler {
+	d := &differ{app: app, candidate: candidate, config: defaultConfig()}
+	for _, opt := range opts {
+		opt(d.config)
+	}
+
+	return d.handle
+}
+
+func (d *differ) handle(ctx zeroapi.Context) {
+	if !d.config.methods[ctx.Method()] {
+		return
+	}
+
+	req := ctx.Request()
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		body = b
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	// 新实现不受原有请求结束的影响，只受超时时间限制
+	c, cancel := context.WithTimeout(context.Background(), d.config.timeout)
+	creq := req.Clone(c)
+	creq.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	done := make(chan *Response, 1)
+	go func() {
+		defer cancel()
+		done <- d.serveCandidate(creq)
+	}()
+
+	res := ctx.Response()
+	tee := &recorder{ResponseWriter: res.Writer(), max: d.config.maxBodySize}
+	res.SetWriter(tee)
+
+	legacy := &Response{}
+	res.BeforeFinish(func() {
+		legacy.Status = res.Status()
+		if legacy.Status == 0 {
+			legacy.Status = http.StatusOK
+		}
+		legacy.Header = res.Header().Clone()
+	})
+
+	route := d.config.routeLabel(ctx)
+	ctx.AppendEnd(func() error {
+		legacy.Body, legacy.truncated = tee.buf.Bytes(), tee.truncated
+		d.compare(&Result{Route: route, Request: creq, Legacy: legacy, Candidate: <-done})
+		return nil
+	})
+}
+
+// serveCandidate 执行新实现，发生 panic 或者超时时返回 nil
+func (d *differ) serveCandidate(req *http.Request) (res *Response) {
+	defer func() {
+		if p := recover(); p != nil {
+			d.app.Logger().Errorf("respdiff: candidate panic: %v", p)
+			res = nil
+		}
+	}()
+
+	w := &recorder{header: make(http.Header), max: d.config.maxBodySize}
+	d.candidate.ServeHTTP(w, req)
+
+	if req.Context().Err() != nil {
+		d.app.Logger().Errorf("respdiff: candidate %s %s: %v", req.Method, req.URL.Path, req.Context().Err())
+		return nil
+	}
+
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return &Response{Status: status, Header: w.header.Clone(), Body: w.buf.Bytes(), truncated: w.truncated}
+}
+
+// compare 对比响应，记录日志与指标
+func (d *differ) compare(result *Result) {
+	counter := d.app.Metrics().Counter(metricName)
+
+	if result.Candidate == nil {
+		counter.Add(1, "route", result.Route, "result", "error")
+		return
+	}
+
+	if result.Legacy.truncated || result.Candidate.truncated {
+		counter.Add(1, "route", result.Route, "result", "skipped")
+		return
+	}
+
+	result.Differences = d.diff(result.Legacy, result.Candidate)
+	if len(result.Differences) == 0 {
+		counter.Add(1, "route", result.Route, "result", "match")
+		return
+	}
+
+	counter.Add(1, "route", result.Route, "result", "mismatch")
+	d.app.Logger().Errorf("respdiff: %s mismatch: %s", result.Route, strings.Join(result.Differences, "; "))
+
+	if d.config.onMismatch != nil {
+		d.config.onMismatch(result)
+	}
+}
+
+// diff 找出两个响应的不同之处
+func (d *differ) diff(legacy, candidate *Response) []string {
+	var out []string
+
+	if legacy.Status != candidate.Status {
+		out = append(out, fmt.Sprintf("status: %d != %d", legacy.Status, candidate.Status))
+	}
+
+	for _, name := range d.config.headers {
+		if l, c := legacy.Header.Get(name), candidate.Header.Get(name); !headerEqual(name, l, c) {
+			out = append(out, fmt.Sprintf("header %s: %q != %q", name, l, c))
+		}
+	}
+
+	if isJSON(legacy.Header) && isJSON(candidate.Header) {
+		var l, c interface{}
+		if json.Unmarshal(legacy.Body, &l) == nil && json.Unmarshal(candidate.Body, &c) == nil {
+			for _, field := range d.config.ignore {
+				l = remove(l, field)
+				c = remove(c, field)
+			}
+			d.diffValue("body", l, c, &out)
+			return out
+		}
+	}
+
+	if !bytes.Equal(legacy.Body, candidate.Body) {
+		out = append(out, fmt.Sprintf("body: %d bytes != %d bytes", len(legacy.Body), len(candidate.Body)))
+	}
+
+	return out
+}
+
+// diffValue 递归对比 JSON 的值，path 为当前位置
+func (d *differ) diffValue(path string, l, c interface{}, out *[]string) {
+	if len(*out) >= d.config.maxDifferences {
+		return
+	}
+
+	switch lv := l.(type) {
+	case map[string]interface{}:
+		cv, ok := c.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(lv)+len(cv))
+		for k := range lv {
+			keys = append(keys, k)
+		}
+		for k := range cv {
+			if _, exist := lv[k]; !exist {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			lc, lok := lv[k]
+			cc, cok := cv[k]
+			switch {
+			case !cok:
+				*out = append(*out, path+"."+k+": missing in candidate")
+			case !lok:
+				*out = append(*out, path+"."+k+": unexpected in candidate")
+			default:
+				d.diffValue(path+"."+k, lc, cc, out)
+			}
+			if len(*out) >= d.config.maxDifferences {
+				return
+			}
+		}
+		return
+	case []interface{}:
+		cv, ok := c.([]interface{})
+		if !ok {
+			break
+		}
+
+		if len(lv) != len(cv) {
+			*out = append(*out, fmt.Sprintf("%s: length %d != %d", path, len(lv), len(cv)))
+			return
+		}
+		for i := range lv {
+			d.diffValue(path+"."+strconv.Itoa(i), lv[i], cv[i], out)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(l, c) {
+		*out = append(*out, fmt.Sprintf("%s: %s != %s", path, short(l), short(c)))
+	}
+}
+
+// remove 删除 JSON 中的字段，"*" 匹配任意字段或者数组下标
+func remove(v interface{}, field []string) interface{} {
+	if len(field) == 0 {
+		return v
+	}
+
+	key, rest := field[0], field[1:]
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, child := range value {
+			if key != "*" && key != k {
+				continue
+			}
+			if len(rest) == 0 {
+				delete(value, k)
+			} else {
+				value[k] = remove(child, rest)
+			}
+		}
+	case []interface{}:
+		for i, child := range value {
+			if key != "*" && key != strconv.Itoa(i) {
+				continue
+			}
+			if len(rest) == 0 {
+				value[i] = nil
+			} else {
+				value[i] = remove(child, rest)
+			}
+		}
+	}
+
+	return v
+}
+
+// headerEqual 对比响应头，Content-Type 忽略空格与大小写的差异
+func headerEqual(name, l, c string) bool {
+	if l == c {
+		return true
+	}
+
+	if !strings.EqualFold(name, "Content-Type") {
+		return false
+	}
+
+	lt, lp, lerr := mime.ParseMediaType(l)
+	ct, cp, cerr := mime.ParseMediaType(c)
+	return lerr == nil && cerr == nil && lt == ct && reflect.DeepEqual(lp, cp)
+}
+
+func isJSON(header http.Header) bool {
+	return strings.Contains(strings.ToLower(header.Get("Content-Type")), "json")
+}
+
+// short 日志中显示的值，过长时截断
+func short(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(b) > 64 {
+		return string(b[:61]) + "..."
+	}
+	return string(b)
+}
+
+// recorder 记录响应，ResponseWriter 不为空时同时写入
+type recorder struct {
+	http.ResponseWriter
+
+	header    http.Header
+	status    int
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (w *recorder) Header() http.Header {
+	if w.ResponseWriter != nil {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *recorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	if w.ResponseWriter != nil {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.truncated {
+		if w.buf.Len()+len(b) > w.max {
+			w.truncated = true
+			w.buf.Reset()
+		} else {
+			w.buf.Write(b)
+		}
+	}
+
+	if w.ResponseWriter != nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return len(b), nil
+}
+
+// Flush 实现 http.Flusher
+func (w *recorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
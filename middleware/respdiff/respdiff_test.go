@@ -0,0 +1,92 @@
+package respdiff_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/respdiff"
+)
+
+func TestDiff(t *testing.T) {
+	a := app.NewApp()
+
+	candidate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/users/2" {
+			w.Write([]byte(`{"id":2,"name":"new","request_id":"b","tags":["a"]}`))
+			return
+		}
+		w.Write([]byte(`{"id":1,"name":"yaha","request_id":"b","tags":["a"]}`))
+	})
+
+	results := make(chan *respdiff.Result, 1)
+	a.Get("/users/:id", respdiff.New(a, candidate,
+		respdiff.WithIgnore("request_id"),
+		respdiff.WithOnMismatch(func(result *respdiff.Result) { results <- result }),
+	), func(ctx zeroapi.Context) {
+		ctx.JSON(map[string]interface{}{"id": ctx.Dynamic("id"), "name": "yaha", "request_id": "a", "tags": []string{"a"}})
+	})
+	a.Router().Build()
+
+	// id 的类型不同: "1" != 1
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/2", nil))
+	if !strings.Contains(w.Body.String(), `"id":"2"`) {
+		t.Fatalf("legacy response expected, got %s", w.Body.String())
+	}
+
+	select {
+	case result := <-results:
+		diffs := strings.Join(result.Differences, "; ")
+		if !strings.Contains(diffs, `body.id: "2" != 2`) || !strings.Contains(diffs, `body.name: "yaha" != "new"`) {
+			t.Fatalf("unexpected differences: %s", diffs)
+		}
+		if strings.Contains(diffs, "request_id") {
+			t.Fatalf("ignored field compared: %s", diffs)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("mismatch not reported")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	a := app.NewApp()
+
+	candidate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("pong"))
+	})
+
+	a.Get("/ping", respdiff.New(a, candidate), func(ctx zeroapi.Context) {
+		ctx.Text("pong")
+	})
+	a.Post("/ping", respdiff.New(a, candidate), func(ctx zeroapi.Context) {
+		ctx.Text("legacy")
+	})
+	a.Router().Build()
+
+	a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	// POST 默认不对比
+	a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/ping", nil))
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, sample := range a.Metrics().Gather() {
+			if sample.Name != "http_response_diff_total" {
+				continue
+			}
+			if strings.Join(sample.Labels, ",") != "result,match,route,GET /ping" || sample.Value != 1 {
+				t.Fatalf("unexpected sample: %v %v", sample.Labels, sample.Value)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("match not recorded")
+}
@@ -0,0 +1,112 @@
+package respsize
+
+import (
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/metrics"
+)
+
+// config 响应大小记录配置
+type config struct {
+	// metricName 直方图名称
+	metricName string
+
+	// buckets 直方图的桶
+	buckets []float64
+
+	// window 统计窗口
+	window time.Duration
+
+	// slots 统计窗口被分为多少段
+	slots int
+
+	// topN ReportHandler 默认输出的数量
+	topN int
+
+	// routeLabel 获取路由标签
+	routeLabel func(ctx zeroapi.Context) string
+
+	// now 获取当前时间
+	now func() time.Time
+}
+
+func defaultConfig() *config {
+	return &config{
+		metricName: "http_response_size_bytes",
+		// 256B ~ 16M
+		buckets:    metrics.ExponentialBuckets(256, 4, 9),
+		window:     time.Hour,
+		slots:      60,
+		topN:       10,
+		routeLabel: defaultRouteLabel,
+		now:        time.Now,
+	}
+}
+
+// defaultRouteLabel 默认使用 Method + 匹配的路由路径，路由不存在时统一为 NOT_FOUND，避免标签数量无限增长
+func defaultRouteLabel(ctx zeroapi.Context) string {
+	route := ctx.RoutePath()
+	if route == "" {
+		return "NOT_FOUND"
+	}
+	return ctx.Method() + " " + route
+}
+
+// Option 响应大小记录配置选项
+type Option func(config *config)
+
+// WithMetricName 设置直方图名称，默认 http_response_size_bytes
+func WithMetricName(name string) Option {
+	return func(config *config) {
+		if name != "" {
+			config.metricName = name
+		}
+	}
+}
+
+// WithBuckets 设置直方图的桶
+func WithBuckets(buckets []float64) Option {
+	return func(config *config) {
+		if len(buckets) > 0 {
+			config.buckets = buckets
+		}
+	}
+}
+
+// WithWindow 设置统计窗口，以及窗口被分为多少段，默认最近 1 小时，分为 60 段
+func WithWindow(window time.Duration, slots int) Option {
+	return func(config *config) {
+		if window > 0 && slots > 0 && window >= time.Duration(slots) {
+			config.window = window
+			config.slots = slots
+		}
+	}
+}
+
+// WithTopN 设置 ReportHandler 默认输出的数量
+func WithTopN(n int) Option {
+	return func(config *config) {
+		if n > 0 {
+			config.topN = n
+		}
+	}
+}
+
+// WithRouteLabel 设置获取路由标签的函数，默认使用 Method + ctx.RoutePath，应该返回路由模板，避免标签数量过多
+func WithRouteLabel(routeLabel func(ctx zeroapi.Context) string) Option {
+	return func(config *config) {
+		if routeLabel != nil {
+			config.routeLabel = routeLabel
+		}
+	}
+}
+
+// WithClock 设置获取当前时间的函数，一般用于测试
+func WithClock(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package respsize 记录每个路由的响应大小分布，并统计最近一段时间内响应数据最多的路由，用于优化响应体积
+//
+// 示例:
+// recorder := respsize.New(app.Metrics())
+// app.Use(recorder.Handler())
+// app.Get("/admin/heavy", auth, recorder.ReportHandler())
+package respsize
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// Recorder 响应大小记录
+type Recorder interface {
+	// Handler 记录响应大小的中间件
+	Handler() zeroapi.Handler
+
+	// Top 最近一个统计窗口内，响应总字节数最多的 n 个路由，n <= 0 表示全部
+	Top(n int) []Entry
+
+	// ReportHandler 输出 Top 结果的路由处理函数，可以通过 ?n=10 指定数量
+	ReportHandler() zeroapi.Handler
+}
+
+// Entry 一个路由在统计窗口内的响应大小
+type Entry struct {
+	// Route 路由
+	Route string `json:"route"`
+
+	// Count 请求次数
+	Count int64 `json:"count"`
+
+	// TotalBytes 响应总字节数
+	TotalBytes int64 `json:"total_bytes"`
+
+	// AvgBytes 平均响应字节数
+	AvgBytes int64 `json:"avg_bytes"`
+
+	// MaxBytes 最大响应字节数
+	MaxBytes int64 `json:"max_bytes"`
+}
+
+type recorder struct {
+	config    *config
+	histogram zeroapi.Histogram
+
+	mu sync.Mutex
+
+	// slots 环形数组，每个元素统计 window/len(slots) 时间内的数据
+	slots []*slot
+}
+
+// slot 一段时间内的统计数据
+type slot struct {
+	// epoch 该段时间的序号，等于 时间 / 每段时长
+	epoch int64
+
+	routes map[string]*Entry
+}
+
+// New 创建响应大小记录，metrics 为 nil 时不记录直方图
+func New(metrics zeroapi.Metrics, opts ...Option) Recorder {
+	r := &recorder{config: defaultConfig()}
+
+	for _, opt := range opts {
+		opt(r.config)
+	}
+
+	if metrics != nil {
+		r.histogram = metrics.Histogram(r.config.metricName, r.config.buckets)
+	}
+
+	r.slots = make([]*slot, r.config.slots)
+	for i := range r.slots {
+		r.slots[i] = &slot{epoch: -1}
+	}
+
+	return r
+}
+
+func (r *recorder) Handler() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		route := r.config.routeLabel(ctx)
+
+		ctx.AppendEnd(func() error {
+			// 包括直接通过 Response() 写入的内容，例如静态文件与反向代理
+			r.record(route, ctx.Response().Size())
+			return nil
+		})
+	}
+}
+
+// record 记录一次响应
+func (r *recorder) record(route string, size int64) {
+	if r.histogram != nil {
+		r.histogram.Observe(float64(size), "route", route)
+	}
+
+	epoch := r.epoch(r.config.now())
+	s := r.slots[epoch%int64(len(r.slots))]
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s.epoch != epoch {
+		s.epoch = epoch
+		s.routes = make(map[string]*Entry)
+	}
+
+	e, exist := s.routes[route]
+	if !exist {
+		e = &Entry{Route: route}
+		s.routes[route] = e
+	}
+
+	e.Count++
+	e.TotalBytes += size
+	if size > e.MaxBytes {
+		e.MaxBytes = size
+	}
+}
+
+func (r *recorder) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(r.config.window/time.Duration(len(r.slots)))
+}
+
+func (r *recorder) Top(n int) []Entry {
+	current := r.epoch(r.config.now())
+	oldest := current - int64(len(r.slots)) + 1
+
+	merged := make(map[string]*Entry)
+
+	r.mu.Lock()
+	for _, s := range r.slots {
+		if s.epoch < oldest || s.epoch > current {
+			continue
+		}
+
+		for route, e := range s.routes {
+			m, exist := merged[route]
+			if !exist {
+				m = &Entry{Route: route}
+				merged[route] = m
+			}
+			m.Count += e.Count
+			m.TotalBytes += e.TotalBytes
+			if e.MaxBytes > m.MaxBytes {
+				m.MaxBytes = e.MaxBytes
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	entries := make([]Entry, 0, len(merged))
+	for _, e := range merged {
+		if e.Count > 0 {
+			e.AvgBytes = e.TotalBytes / e.Count
+		}
+		entries = append(entries, *e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].TotalBytes != entries[j].TotalBytes {
+			return entries[i].TotalBytes > entries[j].TotalBytes
+		}
+		return entries[i].Route < entries[j].Route
+	})
+
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+
+	return entries
+}
+
+func (r *recorder) ReportHandler() zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		n, _ := strconv.Atoi(ctx.Get("n"))
+		if n <= 0 {
+			n = r.config.topN
+		}
+
+		ctx.JSON(map[string]interface{}{
+			"window": r.config.window.String(),
+			"top":    r.Top(n),
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package respsize_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/respsize"
+)
+
+func TestTop(t *testing.T) {
+	a := app.NewApp()
+
+	now := time.Date(2021, 6, 1, 15, 0, 0, 0, time.UTC)
+	recorder := respsize.New(a.Metrics(),
+		respsize.WithWindow(time.Minute, 6),
+		respsize.WithClock(func() time.Time { return now }),
+	)
+
+	// path 为空时模拟路由不存在
+	request := func(path string, size int) {
+		ctx := a.Context()
+		ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/request"+path, nil))
+		if path != "" {
+			ctx.SetRoute(&zeroapi.RouteInfo{Method: http.MethodGet, Path: path})
+		}
+		recorder.Handler()(ctx)
+		ctx.Bytes([]byte(strings.Repeat("a", size)))
+		ctx.RunEnd()
+	}
+
+	request("/small", 10)
+	request("/large", 1000)
+	request("/large", 3000)
+	request("/medium", 500)
+
+	top := recorder.Top(2)
+	if len(top) != 2 || top[0].Route != "GET /large" || top[0].Count != 2 || top[0].AvgBytes != 2000 || top[0].MaxBytes != 3000 {
+		t.Fatalf("invalid top: %+v", top)
+	}
+
+	if top[1].Route != "GET /medium" {
+		t.Fatalf("invalid top: %+v", top)
+	}
+
+	// 超出统计窗口
+	now = now.Add(2 * time.Minute)
+	request("/small", 5)
+
+	top = recorder.Top(0)
+	if len(top) != 1 || top[0].Route != "GET /small" || top[0].TotalBytes != 5 {
+		t.Fatalf("invalid top: %+v", top)
+	}
+
+	// 路由不存在时统一为 NOT_FOUND，不使用请求路径
+	request("", 10)
+	request("", 10)
+	top = recorder.Top(0)
+	if len(top) != 2 || top[0].Route != "NOT_FOUND" || top[0].Count != 2 {
+		t.Fatalf("invalid top: %+v", top)
+	}
+
+	samples := a.Metrics().Gather()
+	if len(samples) != 4 || samples[0].Name != "http_response_size_bytes" {
+		t.Fatalf("invalid samples: %+v", samples)
+	}
+}
+
+func TestResponseWriter(t *testing.T) {
+	a := app.NewApp()
+	recorder := respsize.New(nil)
+
+	// 直接通过 Response() 写入，例如静态文件与反向代理
+	ctx := a.Context()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/file", nil))
+	ctx.SetRoute(&zeroapi.RouteInfo{Method: http.MethodGet, Path: "/file"})
+	recorder.Handler()(ctx)
+	ctx.Response().Write([]byte(strings.Repeat("a", 5000)))
+	ctx.RunEnd()
+
+	top := recorder.Top(0)
+	if len(top) != 1 || top[0].TotalBytes != 5000 {
+		t.Fatalf("invalid top: %+v", top)
+	}
+}
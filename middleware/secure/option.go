@@ -0,0 +1,137 @@
+package secure
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config 安全响应头配置，值为空表示不发送对应的响应头
+type config struct {
+	// contentTypeNosniff X-Content-Type-Options
+	contentTypeNosniff string
+
+	// frameOptions X-Frame-Options
+	frameOptions string
+
+	// referrerPolicy Referrer-Policy
+	referrerPolicy string
+
+	// contentSecurityPolicy Content-Security-Policy
+	contentSecurityPolicy string
+
+	// hsts Strict-Transport-Security，仅在 https 请求中发送
+	hsts string
+
+	// openerPolicy Cross-Origin-Opener-Policy
+	openerPolicy string
+
+	// embedderPolicy Cross-Origin-Embedder-Policy
+	embedderPolicy string
+
+	// resourcePolicy Cross-Origin-Resource-Policy
+	resourcePolicy string
+
+	// resourcePolicies 按路径前缀覆盖 Cross-Origin-Resource-Policy，例如静态资源
+	resourcePolicies []resourcePolicy
+}
+
+// resourcePolicy 路径前缀对应的 Cross-Origin-Resource-Policy
+type resourcePolicy struct {
+	prefix string
+	policy string
+}
+
+func defaultConfig() *config {
+	return &config{
+		contentTypeNosniff: "nosniff",
+		frameOptions:       "SAMEORIGIN",
+		referrerPolicy:     "strict-origin-when-cross-origin",
+	}
+}
+
+// Option 安全响应头配置选项
+type Option func(config *config)
+
+// WithFrameOptions 设置 X-Frame-Options，默认 SAMEORIGIN，为空表示不发送
+func WithFrameOptions(value string) Option {
+	return func(config *config) {
+		config.frameOptions = value
+	}
+}
+
+// WithReferrerPolicy 设置 Referrer-Policy，默认 strict-origin-when-cross-origin，为空表示不发送
+func WithReferrerPolicy(value string) Option {
+	return func(config *config) {
+		config.referrerPolicy = value
+	}
+}
+
+// WithContentSecurityPolicy 设置 Content-Security-Policy
+func WithContentSecurityPolicy(value string) Option {
+	return func(config *config) {
+		config.contentSecurityPolicy = value
+	}
+}
+
+// WithHSTS 设置 Strict-Transport-Security，仅在 https 请求中发送
+// maxAge: 有效期
+// includeSubDomains: 是否包含子域名
+func WithHSTS(maxAge time.Duration, includeSubDomains bool) Option {
+	return func(config *config) {
+		if maxAge <= 0 {
+			config.hsts = ""
+			return
+		}
+
+		config.hsts = "max-age=" + strconv.FormatInt(int64(maxAge.Seconds()), 10)
+		if includeSubDomains {
+			config.hsts += "; includeSubDomains"
+		}
+	}
+}
+
+// WithOpenerPolicy 设置 Cross-Origin-Opener-Policy，例如 same-origin
+func WithOpenerPolicy(value string) Option {
+	return func(config *config) {
+		config.openerPolicy = value
+	}
+}
+
+// WithEmbedderPolicy 设置 Cross-Origin-Embedder-Policy，例如 require-corp, credentialless
+func WithEmbedderPolicy(value string) Option {
+	return func(config *config) {
+		config.embedderPolicy = value
+	}
+}
+
+// WithResourcePolicy 设置 Cross-Origin-Resource-Policy，例如 same-origin, same-site, cross-origin
+func WithResourcePolicy(value string) Option {
+	return func(config *config) {
+		config.resourcePolicy = value
+	}
+}
+
+// WithResourcePolicyFor 按路径前缀覆盖 Cross-Origin-Resource-Policy，前缀最长的优先
+// 例如允许其它网站引用静态资源: WithResourcePolicyFor("/static/", "cross-origin")
+func WithResourcePolicyFor(prefix, value string) Option {
+	return func(config *config) {
+		if !strings.HasPrefix(prefix, "/") {
+			prefix = "/" + prefix
+		}
+		config.resourcePolicies = append(config.resourcePolicies, resourcePolicy{prefix: prefix, policy: value})
+	}
+}
+
+// WithCrossOriginIsolation 跨源隔离预设，使页面可以使用 SharedArrayBuffer 等特性
+// Cross-Origin-Opener-Policy: same-origin
+// Cross-Origin-Embedder-Policy: require-corp
+// Cross-Origin-Resource-Policy: same-origin
+// 需要被其它网站引用的静态资源，可以通过 WithResourcePolicyFor 覆盖
+func WithCrossOriginIsolation() Option {
+	return func(config *config) {
+		config.openerPolicy = "same-origin"
+		config.embedderPolicy = "require-corp"
+		config.resourcePolicy = "same-origin"
+	}
+}
@@ -0,0 +1,55 @@
+// Package secure 设置常用的安全响应头
+//
+// 示例:
+// app.Use(secure.New(secure.WithCrossOriginIsolation(), secure.WithResourcePolicyFor("/static/", "cross-origin")))
+package secure
+
+import (
+	"sort"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// New 创建安全响应头中间件
+func New(opts ...Option) zeroapi.Handler {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// 前缀最长的优先匹配
+	sort.SliceStable(config.resourcePolicies, func(i, j int) bool {
+		return len(config.resourcePolicies[i].prefix) > len(config.resourcePolicies[j].prefix)
+	})
+
+	return func(ctx zeroapi.Context) {
+		setHeader(ctx, "X-Content-Type-Options", config.contentTypeNosniff)
+		setHeader(ctx, "X-Frame-Options", config.frameOptions)
+		setHeader(ctx, "Referrer-Policy", config.referrerPolicy)
+		setHeader(ctx, "Content-Security-Policy", config.contentSecurityPolicy)
+		setHeader(ctx, "Cross-Origin-Opener-Policy", config.openerPolicy)
+		setHeader(ctx, "Cross-Origin-Embedder-Policy", config.embedderPolicy)
+		setHeader(ctx, "Cross-Origin-Resource-Policy", resourcePolicyFor(config, ctx.Path()))
+
+		if ctx.Request().TLS != nil {
+			setHeader(ctx, "Strict-Transport-Security", config.hsts)
+		}
+	}
+}
+
+func resourcePolicyFor(config *config, path string) string {
+	for _, rp := range config.resourcePolicies {
+		if strings.HasPrefix(path, rp.prefix) {
+			return rp.policy
+		}
+	}
+
+	return config.resourcePolicy
+}
+
+func setHeader(ctx zeroapi.Context, key, value string) {
+	if value != "" {
+		ctx.SetHeader(key, value)
+	}
+}
@@ -0,0 +1,50 @@
+package secure_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/secure"
+)
+
+func TestCrossOriginIsolation(t *testing.T) {
+	a := app.NewApp()
+	a.Use(secure.New(
+		secure.WithCrossOriginIsolation(),
+		secure.WithResourcePolicyFor("/static/", "same-site"),
+		secure.WithResourcePolicyFor("/static/public/", "cross-origin"),
+	))
+
+	ok := func(ctx zeroapi.Context) {}
+	a.Get("/", ok)
+	a.Get("/static/*", ok)
+	a.Router().Build()
+
+	cases := []struct {
+		path string
+		corp string
+	}{
+		{"/", "same-origin"},
+		{"/static/app.js", "same-site"},
+		{"/static/public/logo.png", "cross-origin"},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, c.path, nil))
+
+		header := w.Result().Header
+		if header.Get("Cross-Origin-Opener-Policy") != "same-origin" || header.Get("Cross-Origin-Embedder-Policy") != "require-corp" {
+			t.Fatalf("isolation headers not set: %s", c.path)
+		}
+		if corp := header.Get("Cross-Origin-Resource-Policy"); corp != c.corp {
+			t.Fatalf("path: %s, want: %s, got: %s", c.path, c.corp, corp)
+		}
+		if header.Get("Strict-Transport-Security") != "" {
+			t.Fatal("hsts should only be sent over https")
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package timeout
+
+import "net/http"
+
+// config 超时配置
+type config struct {
+	// status 超时响应的状态码
+	status int
+
+	// message 超时响应的内容
+	message string
+}
+
+func defaultConfig() *config {
+	return &config{
+		status: http.StatusServiceUnavailable,
+	}
+}
+
+// Option 超时配置选项
+type Option func(config *config)
+
+// WithStatus 设置超时响应的状态码，默认 503，作为网关时可以使用 504
+func WithStatus(status int) Option {
+	return func(config *config) {
+		if status >= 400 && status < 600 {
+			config.status = status
+		}
+	}
+}
+
+// WithMessage 设置超时响应的内容，默认为状态码对应的文本
+func WithMessage(message string) Option {
+	return func(config *config) {
+		config.message = message
+	}
+}
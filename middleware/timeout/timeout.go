@@ -0,0 +1,177 @@
+// Package timeout 请求超时中间件，超过时间后取消请求的 context.Context，并立即响应 503
+// 处理函数的响应先写入缓冲区，处理函数结束后再发送，超时后处理函数的写入返回 http.ErrHandlerTimeout
+// 不适用于 SSE, WebSocket 等流式响应
+//
+// 示例:
+// app.Get("/report", timeout.New(2*time.Second), report)
+// 处理函数中使用 ctx.Context() 调用数据库、RPC 等，超时后会被取消
+// app.Use(timeout.New(5*time.Second)) 作为应用级别中间件时，路由可以通过 zeroapi.RouteMiddleware{Timeout: time.Minute} 设置不同的超时时间
+package timeout
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// metricTimeouts 超时的请求数
+const metricTimeouts = "http_request_timeouts_total"
+
+// New 创建请求超时中间件，timeout <= 0 时不限制，路由设置了 RouteMiddleware.Timeout 时使用路由的超时时间
+func New(timeout time.Duration, opts ...Option) zeroapi.Handler {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.message == "" {
+		config.message = http.StatusText(config.status)
+	}
+
+	return func(ctx zeroapi.Context) {
+		timeout := timeout
+		if route := ctx.Route(); route != nil && route.Timeout > 0 {
+			timeout = route.Timeout
+		}
+		if timeout <= 0 {
+			return
+		}
+
+		c, cancel := context.WithTimeout(ctx.Context(), timeout)
+		ctx.SetContext(c)
+
+		res := ctx.Response()
+		w := &timeoutWriter{
+			dst:    res.Writer(),
+			header: res.Writer().Header().Clone(),
+			config: config,
+		}
+		res.SetWriter(w)
+
+		// 无论处理函数是否中断或者发生 panic 都会执行
+		res.BeforeFinish(func() {
+			cancel()
+			if !w.finish() {
+				return
+			}
+
+			ctx.App().Metrics().Counter(metricTimeouts).Add(1, "method", ctx.Method())
+			// 记录超时响应的状态码，写入的内容会被丢弃
+			if !res.Written() {
+				ctx.SetHTTPCode(config.status)
+			}
+		})
+
+		w.timer = time.AfterFunc(timeout, w.timeout)
+	}
+}
+
+// timeoutWriter 缓冲处理函数的响应，超时后直接向原始响应写入超时响应
+type timeoutWriter struct {
+	// dst 原始响应
+	dst http.ResponseWriter
+
+	// header 处理函数写入的响应头，开始时复制原始响应头
+	header http.Header
+
+	config *config
+
+	timer *time.Timer
+
+	mu sync.Mutex
+
+	// status 处理函数写入的状态码
+	status int
+
+	// buf 处理函数写入的内容
+	buf bytes.Buffer
+
+	// timedOut 是否已经超时
+	timedOut bool
+
+	// done 处理函数是否已经结束
+	done bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut || w.done || w.status != 0 {
+		return
+	}
+	w.status = code
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+// timeout 在定时器的 goroutine 中执行，处理函数还没有结束时写入超时响应
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.done {
+		return
+	}
+	w.timedOut = true
+
+	header := w.dst.Header()
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(w.config.message)))
+	// 处理函数结束前连接无法复用
+	header.Set("Connection", "close")
+	w.dst.WriteHeader(w.config.status)
+	io.WriteString(w.dst, w.config.message)
+
+	// 不等待处理函数结束，立即发送
+	if flusher, ok := w.dst.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish 处理函数结束，没有超时时将缓冲的响应写入原始响应，返回是否已经超时
+func (w *timeoutWriter) finish() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return true
+	}
+	w.done = true
+	w.timer.Stop()
+
+	header := w.dst.Header()
+	for key := range header {
+		delete(header, key)
+	}
+	for key, value := range w.header {
+		header[key] = value
+	}
+
+	if w.status != 0 {
+		w.dst.WriteHeader(w.status)
+		w.dst.Write(w.buf.Bytes())
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package timeout_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/timeout"
+)
+
+func TestTimeout(t *testing.T) {
+	a := app.NewApp()
+
+	a.Get("/fast", timeout.New(time.Second), func(ctx zeroapi.Context) {
+		ctx.SetHeader("X-Fast", "1")
+		ctx.Text("fast")
+	})
+
+	release := make(chan struct{})
+	lateErr := make(chan error, 1)
+	a.Get("/slow", timeout.New(50*time.Millisecond, timeout.WithStatus(http.StatusGatewayTimeout)), func(ctx zeroapi.Context) {
+		<-ctx.Context().Done()
+		<-release
+		_, err := ctx.Text("late")
+		lateErr <- err
+	})
+	a.Router().Build()
+
+	s := httptest.NewServer(a.Server())
+	defer s.Close()
+
+	res, err := http.Get(s.URL + "/fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(body) != "fast" || res.Header.Get("X-Fast") != "1" {
+		t.Fatalf("invalid fast response: %d %s %v", res.StatusCode, body, res.Header)
+	}
+
+	// 处理函数还没有结束时已经收到超时响应
+	res, err = http.Get(s.URL + "/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusGatewayTimeout || string(body) != "Gateway Timeout" {
+		t.Fatalf("invalid slow response: %d %s", res.StatusCode, body)
+	}
+
+	close(release)
+	select {
+	case err := <-lateErr:
+		if err != http.ErrHandlerTimeout {
+			t.Fatalf("expect http.ErrHandlerTimeout, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not finished")
+	}
+}
+
+func TestRouteTimeout(t *testing.T) {
+	a := app.NewApp()
+	a.Use(timeout.New(time.Hour))
+
+	deadlines := make(map[string]time.Duration)
+	handler := func(ctx zeroapi.Context) {
+		deadline, _ := ctx.Deadline()
+		deadlines[ctx.Path()] = time.Until(deadline)
+	}
+	a.Get("/default", handler)
+	a.Handle(http.MethodGet, "/report", zeroapi.RouteMiddleware{Timeout: time.Minute}, handler)
+	a.Router().Build()
+
+	for _, path := range []string{"/default", "/report"} {
+		a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if d := deadlines["/default"]; d <= time.Minute {
+		t.Fatalf("invalid default deadline: %s", d)
+	}
+	if d := deadlines["/report"]; d <= 0 || d > time.Minute {
+		t.Fatalf("invalid route deadline: %s", d)
+	}
+}
@@ -0,0 +1,305 @@
+// Package mqtt 将 MQTT 主题桥接到路由处理函数，HTTP 与 MQTT 共用中间件，例如日志、指标、鉴权
+// 本包不实现 MQTT 协议，通过 Client 接口适配第三方客户端，例如 paho.mqtt.golang
+//
+// 主题模式使用路由语法，":name" 对应 MQTT 的 "+"，结尾的 "#" 匹配多级主题
+// 每条消息生成一个 Context，Method() 为 "MQTT"，Payload(ctx) 获取消息内容，Dynamic("name") 获取主题中的参数
+//
+// 示例:
+// a := mqtt.New(app, client)
+// a.Use(deviceACL)
+// a.Handle("sensors/:device/temperature", 1, func(ctx zeroapi.Context) { save(ctx.Dynamic("device"), mqtt.Payload(ctx)) })
+// a.Start()
+package mqtt
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/router"
+)
+
+// Method MQTT 消息的 Context 中 Method() 的值
+const Method = "MQTT"
+
+const (
+	// keyTopic 消息主题，保存在 Context 中
+	keyTopic = "mqtt.topic"
+
+	// keyQoS 消息 QoS，保存在 Context 中
+	keyQoS = "mqtt.qos"
+
+	// keyPayload 消息内容，保存在 Context 中
+	keyPayload = "mqtt.payload"
+)
+
+// ErrInvalidPattern 主题模式格式错误
+var ErrInvalidPattern = errors.New("mqtt: invalid topic pattern")
+
+// MessageHandler 收到消息时由 Client 调用
+type MessageHandler func(topic string, qos byte, payload []byte)
+
+// Client MQTT 客户端，由第三方客户端适配
+type Client interface {
+	// Subscribe 订阅主题，filter 可以包含 "+" 与 "#"
+	Subscribe(filter string, qos byte, handler MessageHandler) error
+
+	// Unsubscribe 取消订阅
+	Unsubscribe(filters ...string) error
+}
+
+// Topic 获取消息的主题，不是 MQTT 消息时返回空
+func Topic(ctx zeroapi.Context) string {
+	topic, _ := ctx.Value(keyTopic).(string)
+	return topic
+}
+
+// Payload 获取消息内容，也可以通过 Request().Body 读取
+func Payload(ctx zeroapi.Context) []byte {
+	payload, _ := ctx.Value(keyPayload).([]byte)
+	return payload
+}
+
+// QoS 获取消息的 QoS
+func QoS(ctx zeroapi.Context) byte {
+	qos, _ := ctx.Value(keyQoS).(byte)
+	return qos
+}
+
+type subscription struct {
+	// pattern 主题模式
+	pattern string
+
+	// filter MQTT 订阅主题
+	filter string
+
+	qos byte
+}
+
+// Adapter 将 MQTT 消息交给路由处理函数
+type Adapter struct {
+	app    zeroapi.App
+	client Client
+
+	// router 独立的路由，避免 HTTP 请求匹配到 MQTT 处理函数
+	router zeroapi.Router
+
+	middlewares   []zeroapi.Handler
+	subscriptions []subscription
+
+	mu      sync.Mutex
+	started bool
+}
+
+// New 创建 MQTT 适配器
+func New(app zeroapi.App, client Client) *Adapter {
+	return &Adapter{
+		app:    app,
+		client: client,
+		router: router.NewRouter(app),
+	}
+}
+
+// Use 添加中间件，在 App 级别中间件之后，处理函数之前执行，例如校验设备是否可以发布该主题
+// 只作用于之后通过 Handle 注册的处理函数
+func (a *Adapter) Use(handlers ...zeroapi.Handler) *Adapter {
+	a.middlewares = append(a.middlewares, handlers...)
+	return a
+}
+
+// Handle 注册主题处理函数，需要在 Start 之前调用
+// pattern: 主题模式，例如 "sensors/:device/temperature"，"logs/#"
+// qos: 订阅使用的 QoS
+func (a *Adapter) Handle(pattern string, qos byte, handlers ...zeroapi.Handler) error {
+	filter, path, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	_handlers := make([]zeroapi.Handler, 0, len(a.middlewares)+len(handlers)+1)
+	_handlers = append(_handlers, a.app.ExecuteMiddlewares)
+	_handlers = append(_handlers, a.middlewares...)
+	_handlers = append(_handlers, handlers...)
+
+	// 路由名称为主题模式，通过 ctx.RouteName() 获取
+	if !a.router.RegisterRoute(zeroapi.RouteInfo{Method: Method, Path: path, Name: pattern}, _handlers...) {
+		return ErrInvalidPattern
+	}
+
+	a.subscriptions = append(a.subscriptions, subscription{pattern: pattern, filter: filter, qos: qos})
+	return nil
+}
+
+// Start 解析路由并订阅所有主题
+func (a *Adapter) Start() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.started {
+		return nil
+	}
+
+	if !a.router.Build() {
+		return ErrInvalidPattern
+	}
+
+	for i, s := range a.subscriptions {
+		if err := a.client.Subscribe(s.filter, s.qos, a.dispatch); err != nil {
+			a.unsubscribe(a.subscriptions[:i])
+			return err
+		}
+	}
+
+	a.started = true
+	return nil
+}
+
+// Stop 取消订阅所有主题
+func (a *Adapter) Stop() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.started {
+		return nil
+	}
+
+	a.started = false
+	return a.unsubscribe(a.subscriptions)
+}
+
+func (a *Adapter) unsubscribe(subscriptions []subscription) error {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	filters := make([]string, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		filters = append(filters, s.filter)
+	}
+	return a.client.Unsubscribe(filters...)
+}
+
+// dispatch 处理一条消息，与 HTTP 请求相同，依次执行中间件与处理函数，之后执行 After 与 End 钩子
+func (a *Adapter) dispatch(topic string, qos byte, payload []byte) {
+	route, handlers, dynamic := a.router.LookupRoute(Method, "/"+topic)
+	if handlers == nil {
+		a.app.Logger().Errorf("mqtt: no handler for topic %s", topic)
+		return
+	}
+
+	ctx := a.app.Context()
+	w := &discardWriter{header: make(http.Header)}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if handler := a.app.PanicHandler(); handler != nil {
+				handler(ctx, p)
+			} else {
+				a.app.Logger().Errorf("mqtt: %s: %+v", topic, p)
+			}
+		}
+
+		ctx.Response().PrepareHeader()
+		ctx.Response().Finish()
+
+		// 没有写入任何数据时视为 200
+		code := ctx.Response().Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+		a.app.Metrics().Counter("mqtt_messages_total").Add(1, "code", strconv.Itoa(code))
+
+		go ctx.RunEnd()
+	}()
+
+	ctx.Reset(w, newRequest(topic, payload))
+	ctx.SetValue(keyTopic, topic)
+	ctx.SetValue(keyQoS, qos)
+	ctx.SetValue(keyPayload, payload)
+	ctx.SetRoute(route)
+	if dynamic != nil {
+		ctx.SetDynamics(dynamic)
+	}
+
+	for _, handler := range handlers {
+		handler(ctx)
+		if ctx.IsStopped() {
+			return
+		}
+	}
+
+	ctx.RunAfter()
+}
+
+// newRequest 生成消息对应的请求，主题作为路径
+func newRequest(topic string, payload []byte) *http.Request {
+	return &http.Request{
+		Method:        Method,
+		URL:           &url.URL{Path: "/" + topic},
+		Proto:         "MQTT",
+		Header:        make(http.Header),
+		Body:          ioutil.NopCloser(bytes.NewReader(payload)),
+		ContentLength: int64(len(payload)),
+		RequestURI:    "/" + topic,
+	}
+}
+
+// parsePattern 将主题模式转换为 MQTT 订阅主题以及路由路径
+func parsePattern(pattern string) (filter, path string, err error) {
+	if pattern == "" || strings.HasPrefix(pattern, "/") {
+		return "", "", ErrInvalidPattern
+	}
+
+	segments := strings.Split(pattern, "/")
+	filters := make([]string, len(segments))
+	paths := make([]string, len(segments))
+	for i, segment := range segments {
+		switch {
+		case segment == "#":
+			if i != len(segments)-1 {
+				return "", "", ErrInvalidPattern
+			}
+			filters[i], paths[i] = "#", "*"
+		case strings.HasPrefix(segment, ":"):
+			if len(segment) == 1 {
+				return "", "", ErrInvalidPattern
+			}
+			filters[i], paths[i] = "+", segment
+		case strings.ContainsAny(segment, "+#*"):
+			return "", "", ErrInvalidPattern
+		default:
+			filters[i], paths[i] = segment, segment
+		}
+	}
+
+	return strings.Join(filters, "/"), "/" + strings.Join(paths, "/"), nil
+}
+
+// discardWriter 丢弃 MQTT 消息的响应，只保留状态码
+type discardWriter struct {
+	header http.Header
+	code   int
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (w *discardWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
@@ -0,0 +1,116 @@
+package mqtt_test
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/mqtt"
+)
+
+// broker 内存中的 Client，按照订阅主题匹配消息
+type broker struct {
+	mu       sync.Mutex
+	handlers map[string]mqtt.MessageHandler
+}
+
+func (b *broker) Subscribe(filter string, qos byte, handler mqtt.MessageHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[filter] = handler
+	return nil
+}
+
+func (b *broker) Unsubscribe(filters ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, filter := range filters {
+		delete(b.handlers, filter)
+	}
+	return nil
+}
+
+func (b *broker) publish(topic string, payload string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for filter, handler := range b.handlers {
+		if match(filter, topic) {
+			handler(topic, 1, []byte(payload))
+		}
+	}
+}
+
+func match(filter, topic string) bool {
+	f, t := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i := range f {
+		if f[i] == "#" {
+			return true
+		}
+		if i >= len(t) || (f[i] != "+" && f[i] != t[i]) {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+func TestAdapter(t *testing.T) {
+	a := app.NewApp()
+
+	var got []string
+	a.Use(func(ctx zeroapi.Context) {
+		got = append(got, "global "+ctx.Method())
+	})
+
+	b := &broker{handlers: make(map[string]mqtt.MessageHandler)}
+	adapter := mqtt.New(a, b)
+	adapter.Use(func(ctx zeroapi.Context) {
+		if ctx.Dynamic("device") == "blocked" {
+			ctx.ClientError(http.StatusForbidden, zeroapi.ReasonNoRoute)
+		}
+	})
+
+	if err := adapter.Handle("sensors/:device/temperature", 1, func(ctx zeroapi.Context) {
+		got = append(got, ctx.Dynamic("device")+"="+string(mqtt.Payload(ctx))+" "+mqtt.Topic(ctx))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.Handle("logs/#", 0, func(ctx zeroapi.Context) {
+		got = append(got, "log "+mqtt.Topic(ctx))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.Handle("logs/#/x", 0); err != mqtt.ErrInvalidPattern {
+		t.Fatalf("invalid pattern: %v", err)
+	}
+
+	if err := adapter.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	b.publish("sensors/d1/temperature", "21.5")
+	b.publish("sensors/blocked/temperature", "0")
+	b.publish("logs/app/error", "oops")
+
+	expected := "global MQTT,d1=21.5 sensors/d1/temperature,global MQTT,global MQTT,log logs/app/error"
+	if s := strings.Join(got, ","); s != expected {
+		t.Fatalf("invalid messages: %s", s)
+	}
+
+	// HTTP 请求不会匹配 MQTT 处理函数
+	a.Router().Build()
+	if handlers, _ := a.Router().Lookup(mqtt.Method, "/logs/app"); handlers != nil {
+		t.Fatal("mqtt route leaked into http router")
+	}
+
+	if err := adapter.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	b.publish("logs/app/error", "oops")
+	if len(got) != 0 {
+		t.Fatalf("stopped adapter received: %v", got)
+	}
+}
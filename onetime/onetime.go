@@ -0,0 +1,279 @@
+// Package onetime 一次性令牌，用于邮箱验证、找回密码等通过链接完成的操作
+// 令牌使用 HMAC-SHA256 签名，签名时包含用途，一个用途的令牌不能用于另一个用途
+// 令牌带有有效期，使用后记录在 Store 中，不能再次使用
+//
+// 示例:
+// tokens := onetime.New(secret, onetime.NewMemoryStore(), onetime.WithNow(app.Now), onetime.WithRand(app.Rand()))
+// link, _ := tokens.IssueURL("https://example.com/reset", "password_reset", user.ID, time.Hour)
+//
+// app.Get("/reset", tokens.VerifyHandler("password_reset"), showResetForm)
+// app.Post("/reset", tokens.ConsumeHandler("password_reset"), resetPassword)
+// claims, _ := onetime.Get(ctx) // 在 resetPassword 中获取用户 id: claims.Subject
+package onetime
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/timestamp"
+)
+
+// 令牌校验失败的原因，用于 ctx.ClientError
+const (
+	// ReasonTokenInvalid 令牌格式错误、签名错误或者用途不一致
+	ReasonTokenInvalid = "token_invalid"
+
+	// ReasonTokenExpired 令牌已过期
+	ReasonTokenExpired = "token_expired"
+
+	// ReasonTokenConsumed 令牌已经使用
+	ReasonTokenConsumed = "token_consumed"
+)
+
+// valueKeyClaims 校验通过的令牌保存在 ctx.Value 中的键
+const valueKeyClaims = "zeroapi.onetime.claims"
+
+// minSecretSize 签名密钥的最小长度
+const minSecretSize = 32
+
+var (
+	// ErrInvalid 令牌格式错误、签名错误或者用途不一致
+	ErrInvalid = errors.New("onetime: invalid token")
+
+	// ErrExpired 令牌已过期
+	ErrExpired = errors.New("onetime: token expired")
+
+	// ErrConsumed 令牌已经使用
+	ErrConsumed = errors.New("onetime: token consumed")
+)
+
+// Claims 令牌中的数据
+type Claims struct {
+	// ID 令牌 id，用于记录是否已经使用
+	ID string `json:"id"`
+
+	// Subject 令牌对应的对象，例如用户 id 或者邮箱
+	Subject string `json:"sub"`
+
+	// IssuedAt 签发时间，unix 秒
+	IssuedAt int64 `json:"iat"`
+
+	// ExpiresAt 过期时间，unix 秒
+	ExpiresAt int64 `json:"exp"`
+
+	// Purpose 用途，不包含在令牌中，校验时填写
+	Purpose string `json:"-"`
+}
+
+// Service 签发与校验一次性令牌
+type Service struct {
+	secret []byte
+	store  Store
+	config *config
+}
+
+// New 创建一次性令牌服务，secret 为签名密钥，至少 32 字节，否则 panic
+func New(secret []byte, store Store, opts ...Option) *Service {
+	if len(secret) < minSecretSize {
+		panic("onetime: secret must be at least 32 bytes")
+	}
+
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Service{secret: secret, store: store, config: config}
+}
+
+// Issue 签发令牌
+// purpose: 用途，例如 email_verify, password_reset
+// subject: 令牌对应的对象，例如用户 id
+// ttl: 有效期，校验时允许 timestamp.DefaultSkew 的时钟偏差
+func (s *Service) Issue(purpose, subject string, ttl time.Duration) (string, error) {
+	id := make([]byte, 16)
+	if _, err := io.ReadFull(s.config.rand, id); err != nil {
+		return "", err
+	}
+
+	now := s.config.now()
+	payload, err := json.Marshal(&Claims{
+		ID:        hex.EncodeToString(id),
+		Subject:   subject,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(s.secret, purpose, encoded)), nil
+}
+
+// IssueURL 签发令牌并添加到链接的查询参数中
+func (s *Service) IssueURL(link, purpose, subject string, ttl time.Duration) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := s.Issue(purpose, subject, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set(s.config.param, token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Verify 校验令牌，但不使用，例如找回密码时先显示表单
+func (s *Service) Verify(purpose, token string) (*Claims, error) {
+	claims, err := s.parse(purpose, token)
+	if err != nil {
+		return nil, err
+	}
+
+	consumed, err := s.store.Consumed(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if consumed {
+		return nil, ErrConsumed
+	}
+	return claims, nil
+}
+
+// Consume 校验并使用令牌，并发使用同一个令牌时只有一次成功
+func (s *Service) Consume(purpose, token string) (*Claims, error) {
+	claims, err := s.parse(purpose, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// 有效期放宽了时钟偏差，记录需要保留到放宽后的过期时间，避免令牌被再次使用
+	ok, err := s.store.Consume(claims.ID, time.Unix(claims.ExpiresAt, 0).Add(timestamp.DefaultSkew))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrConsumed
+	}
+	return claims, nil
+}
+
+// VerifyHandler 校验请求中的令牌，但不使用，通过后可以使用 Get 获取令牌中的数据
+func (s *Service) VerifyHandler(purpose string) zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		claims, err := s.Verify(purpose, s.token(ctx))
+		s.handle(ctx, claims, err)
+	}
+}
+
+// ConsumeHandler 校验并使用请求中的令牌，通过后可以使用 Get 获取令牌中的数据
+// 需要放在确实完成操作的路由上，例如提交新密码，而不是打开链接，邮件客户端可能会预先访问链接
+func (s *Service) ConsumeHandler(purpose string) zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		claims, err := s.Consume(purpose, s.token(ctx))
+		s.handle(ctx, claims, err)
+	}
+}
+
+// Get 获取通过 VerifyHandler 或者 ConsumeHandler 校验的令牌数据
+func Get(ctx zeroapi.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(valueKeyClaims).(*Claims)
+	return claims, ok
+}
+
+func (s *Service) handle(ctx zeroapi.Context, claims *Claims, err error) {
+	switch err {
+	case nil:
+		ctx.SetValue(valueKeyClaims, claims)
+	case ErrInvalid:
+		ctx.ClientError(http.StatusBadRequest, ReasonTokenInvalid, "INVALID TOKEN")
+	case ErrExpired:
+		ctx.ClientError(http.StatusGone, ReasonTokenExpired, "TOKEN EXPIRED")
+	case ErrConsumed:
+		ctx.ClientError(http.StatusGone, ReasonTokenConsumed, "TOKEN CONSUMED")
+	default:
+		ctx.Error(err)
+	}
+}
+
+// token 从查询参数或者表单中获取令牌
+func (s *Service) token(ctx zeroapi.Context) string {
+	return ctx.Request().FormValue(s.config.param)
+}
+
+// parse 校验签名、用途以及有效期
+func (s *Service) parse(purpose, token string) (*Claims, error) {
+	i := strings.IndexByte(token, '.')
+	if i <= 0 {
+		return nil, ErrInvalid
+	}
+	encoded := token[:i]
+
+	mac, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	valid := hmac.Equal(mac, sign(s.secret, purpose, encoded))
+	for _, secret := range s.config.previous {
+		if valid {
+			break
+		}
+		valid = hmac.Equal(mac, sign(secret, purpose, encoded))
+	}
+	if !valid {
+		return nil, ErrInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, claims); err != nil || claims.ID == "" {
+		return nil, ErrInvalid
+	}
+
+	// 与 cookie、会话等使用同一个时钟偏差校验签发时间
+	issued := time.Unix(claims.IssuedAt, 0)
+	ttl := time.Unix(claims.ExpiresAt, 0).Sub(issued)
+	if ttl <= 0 {
+		return nil, ErrInvalid
+	}
+	validator := timestamp.New(timestamp.WithMaxAge(ttl), timestamp.WithNow(s.config.now))
+	switch validator.Validate(issued) {
+	case nil:
+	case timestamp.ErrExpired:
+		return nil, ErrExpired
+	default:
+		return nil, ErrInvalid
+	}
+
+	claims.Purpose = purpose
+	return claims, nil
+}
+
+// sign 签名，用途作为签名内容的一部分
+func sign(secret []byte, purpose, payload string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(purpose))
+	h.Write([]byte{0})
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
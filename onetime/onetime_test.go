@@ -0,0 +1,156 @@
+package onetime_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/onetime"
+	"github.com/zerogo-hub/zero-api/timestamp"
+)
+
+var (
+	oldSecret = bytes.Repeat([]byte{1}, 32)
+	newSecret = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestConsume(t *testing.T) {
+	now := time.Unix(1622534400, 0)
+	tokens := onetime.New(newSecret, onetime.NewMemoryStore(), onetime.WithNow(func() time.Time { return now }))
+
+	token, err := tokens.Issue("email_verify", "1001", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokens.Verify("password_reset", token); err != onetime.ErrInvalid {
+		t.Fatalf("expect ErrInvalid for other purpose, got: %v", err)
+	}
+	if _, err := tokens.Verify("email_verify", token[:len(token)-2]); err != onetime.ErrInvalid {
+		t.Fatalf("expect ErrInvalid for tampered token, got: %v", err)
+	}
+
+	claims, err := tokens.Verify("email_verify", token)
+	if err != nil || claims.Subject != "1001" || claims.Purpose != "email_verify" {
+		t.Fatalf("verify: %v %+v", err, claims)
+	}
+
+	// 并发使用同一个令牌，只有一次成功
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	success := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tokens.Consume("email_verify", token); err == nil {
+				mu.Lock()
+				success++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if success != 1 {
+		t.Fatalf("token consumed %d times", success)
+	}
+	if _, err := tokens.Verify("email_verify", token); err != onetime.ErrConsumed {
+		t.Fatalf("expect ErrConsumed, got: %v", err)
+	}
+
+	// 过期后仍然允许时钟偏差
+	token, _ = tokens.Issue("email_verify", "1001", time.Hour)
+	now = now.Add(time.Hour + timestamp.DefaultSkew)
+	if _, err := tokens.Verify("email_verify", token); err != nil {
+		t.Fatalf("expect token valid within skew, got: %v", err)
+	}
+	now = now.Add(time.Second)
+	if _, err := tokens.Consume("email_verify", token); err != onetime.ErrExpired {
+		t.Fatalf("expect ErrExpired, got: %v", err)
+	}
+
+	// 签发时间晚于当前时间且超过时钟偏差
+	token, _ = tokens.Issue("email_verify", "1001", time.Hour)
+	now = now.Add(-time.Minute)
+	if _, err := tokens.Verify("email_verify", token); err != onetime.ErrInvalid {
+		t.Fatalf("expect ErrInvalid for future token, got: %v", err)
+	}
+}
+
+func TestShortSecret(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic for short secret")
+		}
+	}()
+	onetime.New([]byte("short"), onetime.NewMemoryStore())
+}
+
+func TestPreviousSecrets(t *testing.T) {
+	store := onetime.NewMemoryStore()
+	token, _ := onetime.New(oldSecret, store).Issue("email_verify", "1001", time.Hour)
+
+	if _, err := onetime.New(newSecret, store).Verify("email_verify", token); err != onetime.ErrInvalid {
+		t.Fatalf("expect ErrInvalid, got: %v", err)
+	}
+	if _, err := onetime.New(newSecret, store, onetime.WithPreviousSecrets(oldSecret)).Verify("email_verify", token); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tokens := onetime.New(newSecret, onetime.NewMemoryStore())
+
+	a := app.NewApp()
+	ok := func(ctx zeroapi.Context) {
+		claims, _ := onetime.Get(ctx)
+		ctx.Text(claims.Subject)
+	}
+	a.Get("/reset", tokens.VerifyHandler("password_reset"), ok)
+	a.Post("/reset", tokens.ConsumeHandler("password_reset"), ok)
+	a.Router().Build()
+
+	link, err := tokens.IssueURL("https://example.com/reset?lang=zh", "password_reset", "1001", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(link)
+	if u.Query().Get("lang") != "zh" || u.Query().Get("token") == "" {
+		t.Fatalf("unexpected link: %s", link)
+	}
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, req)
+		return w
+	}
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/reset", strings.NewReader("token="+url.QueryEscape(u.Query().Get("token"))))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+
+	// 打开链接不会使用令牌
+	for i := 0; i < 2; i++ {
+		if w := serve(httptest.NewRequest(http.MethodGet, u.RequestURI(), nil)); w.Code != http.StatusOK || w.Body.String() != "1001" {
+			t.Fatalf("verify: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	if w := post(); w.Code != http.StatusOK {
+		t.Fatalf("consume: %d", w.Code)
+	}
+	if w := post(); w.Code != http.StatusGone {
+		t.Fatalf("consume twice: %d", w.Code)
+	}
+	if w := serve(httptest.NewRequest(http.MethodGet, "/reset?token=forged", nil)); w.Code != http.StatusBadRequest {
+		t.Fatalf("forged token: %d", w.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package onetime
+
+import (
+	"crypto/rand"
+	"io"
+	"time"
+)
+
+// config 一次性令牌配置
+type config struct {
+	// previous 轮换前的密钥，只用于校验
+	previous [][]byte
+
+	// param 携带令牌的查询参数或者表单字段
+	param string
+
+	// now 获取当前时间
+	now func() time.Time
+
+	// rand 随机数来源，用于生成令牌 id
+	rand io.Reader
+}
+
+func defaultConfig() *config {
+	return &config{
+		param: "token",
+		now:   time.Now,
+		rand:  rand.Reader,
+	}
+}
+
+// Option 一次性令牌配置选项
+type Option func(config *config)
+
+// WithPreviousSecrets 设置轮换前的密钥，使用旧密钥签发的令牌在过期前仍然有效
+func WithPreviousSecrets(secrets ...[]byte) Option {
+	return func(config *config) {
+		for _, secret := range secrets {
+			if len(secret) > 0 {
+				config.previous = append(config.previous, secret)
+			}
+		}
+	}
+}
+
+// WithParam 设置携带令牌的查询参数或者表单字段，默认 token
+func WithParam(param string) Option {
+	return func(config *config) {
+		if param != "" {
+			config.param = param
+		}
+	}
+}
+
+// WithNow 设置获取当前时间的函数，默认 time.Now
+func WithNow(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
+
+// WithRand 设置随机数来源，默认 crypto/rand
+func WithRand(rand io.Reader) Option {
+	return func(config *config) {
+		if rand != nil {
+			config.rand = rand
+		}
+	}
+}
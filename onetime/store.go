@@ -0,0 +1,66 @@
+package onetime
+
+import (
+	"sync"
+	"time"
+)
+
+// Store 记录已经使用的令牌，保存到令牌过期即可，过期的令牌本身就无法通过校验
+type Store interface {
+	// Consume 标记令牌已经使用，令牌之前已经使用过时返回 false，需要保证并发时只有一次返回 true
+	Consume(id string, expiresAt time.Time) (bool, error)
+
+	// Consumed 令牌是否已经使用
+	Consumed(id string) (bool, error)
+}
+
+// memoryStore 内存存储，只适用于单个实例，多个实例时需要使用共享的存储，例如 Redis 的 SET NX
+type memoryStore struct {
+	mu       sync.Mutex
+	consumed map[string]time.Time
+	now      func() time.Time
+
+	// nextPurge 下一次清理过期记录的时间
+	nextPurge time.Time
+}
+
+// NewMemoryStore 创建内存存储，过期的记录会定期清理
+func NewMemoryStore() Store {
+	return &memoryStore{consumed: make(map[string]time.Time), now: time.Now}
+}
+
+func (s *memoryStore) Consume(id string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge()
+
+	if _, exist := s.consumed[id]; exist {
+		return false, nil
+	}
+	s.consumed[id] = expiresAt
+	return true, nil
+}
+
+func (s *memoryStore) Consumed(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exist := s.consumed[id]
+	return exist, nil
+}
+
+// purge 每分钟最多清理一次过期的记录
+func (s *memoryStore) purge() {
+	now := s.now()
+	if now.Before(s.nextPurge) {
+		return
+	}
+	s.nextPurge = now.Add(time.Minute)
+
+	for id, expiresAt := range s.consumed {
+		if now.After(expiresAt) {
+			delete(s.consumed, id)
+		}
+	}
+}
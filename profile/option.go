@@ -0,0 +1,125 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+var (
+	// defaultCPUDuration cpu 默认采样时长
+	defaultCPUDuration = 30 * time.Second
+
+	// defaultMaxCPUDuration 通过 seconds 参数指定采样时长时，最多允许的时长
+	defaultMaxCPUDuration = 2 * time.Minute
+
+	// defaultTriggerInterval 两次 Trigger 之间的最小间隔，防止异常期间频繁采集
+	defaultTriggerInterval = time.Minute
+
+	// defaultTokenHeader 默认携带令牌的请求头
+	defaultTokenHeader = "X-Profile-Token"
+)
+
+// config 采集配置
+type config struct {
+	// cpuDuration cpu 默认采样时长
+	cpuDuration time.Duration
+
+	// maxCPUDuration cpu 最大采样时长
+	maxCPUDuration time.Duration
+
+	// token 访问下载路由需要的令牌
+	token string
+
+	// tokenHeader 携带令牌的请求头
+	tokenHeader string
+
+	// auth 自定义验证中间件，例如 basic auth
+	auth []zeroapi.Handler
+
+	// dir Trigger 时文件存储的目录
+	dir string
+
+	// triggerInterval 两次 Trigger 之间的最小间隔
+	triggerInterval time.Duration
+
+	// triggerCPU Trigger 时是否同时采集 cpu
+	triggerCPU bool
+}
+
+func defaultConfig() *config {
+	return &config{
+		cpuDuration:     defaultCPUDuration,
+		maxCPUDuration:  defaultMaxCPUDuration,
+		tokenHeader:     defaultTokenHeader,
+		dir:             filepath.Join(os.TempDir(), "profile"),
+		triggerInterval: defaultTriggerInterval,
+	}
+}
+
+// Option 采集配置选项
+type Option func(config *config)
+
+// WithCPUDuration 设置 cpu 默认采样时长
+func WithCPUDuration(duration time.Duration) Option {
+	return func(config *config) {
+		if duration > 0 {
+			config.cpuDuration = duration
+		}
+	}
+}
+
+// WithMaxCPUDuration 设置 cpu 最大采样时长
+func WithMaxCPUDuration(duration time.Duration) Option {
+	return func(config *config) {
+		if duration > 0 {
+			config.maxCPUDuration = duration
+		}
+	}
+}
+
+// WithToken 设置访问令牌，请求头 header 中需要携带该令牌，header 为空时使用 X-Profile-Token
+func WithToken(token string, header ...string) Option {
+	return func(config *config) {
+		config.token = token
+		if len(header) > 0 && header[0] != "" {
+			config.tokenHeader = header[0]
+		}
+	}
+}
+
+// WithAuth 设置自定义验证中间件，验证不通过时中间件需要调用 ctx.Stopped()
+func WithAuth(handlers ...zeroapi.Handler) Option {
+	return func(config *config) {
+		for _, handler := range handlers {
+			if handler != nil {
+				config.auth = append(config.auth, handler)
+			}
+		}
+	}
+}
+
+// WithDir 设置 Trigger 时文件存储的目录
+func WithDir(dir string) Option {
+	return func(config *config) {
+		if dir != "" {
+			config.dir = dir
+		}
+	}
+}
+
+// WithTriggerInterval 设置两次 Trigger 之间的最小间隔
+func WithTriggerInterval(interval time.Duration) Option {
+	return func(config *config) {
+		config.triggerInterval = interval
+	}
+}
+
+// WithTriggerCPU Trigger 时同时采集 cpu，会阻塞 cpu 采样时长
+func WithTriggerCPU(enable bool) Option {
+	return func(config *config) {
+		config.triggerCPU = enable
+	}
+}
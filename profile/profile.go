@@ -0,0 +1,269 @@
+// Package profile 按需采集 goroutine, heap, cpu 运行数据，提供下载路由，也可以在检测到异常时主动采集存盘
+//
+// 示例:
+// p := profile.New(profile.WithToken(token))
+// p.Register(app, "/debug/profile")
+// app.AddService(profile.NewWatchdog(p, profile.WithMaxGoroutines(10000), profile.WithMaxHeap(1<<30)))
+package profile
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// KindGoroutine 所有 goroutine 的堆栈
+	KindGoroutine = "goroutine"
+
+	// KindHeap 堆内存分配
+	KindHeap = "heap"
+
+	// KindCPU cpu 采样，需要持续一段时间
+	KindCPU = "cpu"
+)
+
+var (
+	// ErrUnknownKind 不支持的采集类型
+	ErrUnknownKind = errors.New("unknown profile kind")
+
+	// ErrCPUBusy 同一时间只能进行一次 cpu 采样
+	ErrCPUBusy = errors.New("cpu profile is already running")
+
+	// ErrTooFrequent 两次 Trigger 间隔太短
+	ErrTooFrequent = errors.New("profile trigger too frequent")
+)
+
+// Profiler 运行数据采集
+type Profiler interface {
+	// Capture 采集 kind 类型的数据，写入 w
+	// kind: KindGoroutine, KindHeap, KindCPU
+	// 对于 KindCPU，duration 为采样时长，<= 0 时使用默认时长
+	Capture(w io.Writer, kind string, duration time.Duration) error
+
+	// CaptureContext 与 Capture 相同，ctx 结束时停止 cpu 采样并返回 ctx.Err()
+	CaptureContext(ctx context.Context, w io.Writer, kind string, duration time.Duration) error
+
+	// Register 在 app 中注册下载路由 GET prefix/:kind，例如 /debug/profile/heap
+	// 必须通过 WithToken 或者 WithAuth 设置验证方式，否则不会注册
+	Register(app zeroapi.App, prefix string) bool
+
+	// Trigger 主动采集 goroutine 与 heap 数据并存盘，返回生成的文件列表
+	// 一般由 Watchdog 或者其它监控程序在检测到异常时调用，reason 会出现在文件名中
+	Trigger(reason string) ([]string, error)
+}
+
+type profiler struct {
+	config *config
+
+	// cpuRunning 防止同时进行多个 cpu 采样，1 表示正在采样
+	cpuRunning int32
+
+	// triggerMu 保护 lastTrigger
+	triggerMu sync.Mutex
+
+	// lastTrigger 上一次 Trigger 的时间
+	lastTrigger time.Time
+}
+
+// New 创建一个 Profiler
+func New(opts ...Option) Profiler {
+	p := &profiler{config: defaultConfig()}
+
+	for _, opt := range opts {
+		opt(p.config)
+	}
+
+	return p
+}
+
+// Capture 采集 kind 类型的数据，写入 w
+func (p *profiler) Capture(w io.Writer, kind string, duration time.Duration) error {
+	return p.CaptureContext(context.Background(), w, kind, duration)
+}
+
+// CaptureContext 采集 kind 类型的数据，写入 w，ctx 结束时停止 cpu 采样
+func (p *profiler) CaptureContext(ctx context.Context, w io.Writer, kind string, duration time.Duration) error {
+	switch kind {
+	case KindGoroutine:
+		return pprof.Lookup(KindGoroutine).WriteTo(w, 0)
+	case KindHeap:
+		runtime.GC()
+		return pprof.Lookup(KindHeap).WriteTo(w, 0)
+	case KindCPU:
+		return p.captureCPU(ctx, w, duration)
+	}
+
+	return ErrUnknownKind
+}
+
+func (p *profiler) captureCPU(ctx context.Context, w io.Writer, duration time.Duration) error {
+	if duration <= 0 {
+		duration = p.config.cpuDuration
+	}
+
+	if !atomic.CompareAndSwapInt32(&p.cpuRunning, 0, 1) {
+		return ErrCPUBusy
+	}
+	defer atomic.StoreInt32(&p.cpuRunning, 0)
+
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		pprof.StopCPUProfile()
+		return nil
+	case <-ctx.Done():
+		pprof.StopCPUProfile()
+		return ctx.Err()
+	}
+}
+
+// Register 在 app 中注册下载路由 GET prefix/:kind
+func (p *profiler) Register(app zeroapi.App, prefix string) bool {
+	if p.config.token == "" && len(p.config.auth) == 0 {
+		app.Logger().Error("profile: token or auth handler is required")
+		return false
+	}
+
+	handlers := make([]zeroapi.Handler, 0, len(p.config.auth)+2)
+	if p.config.token != "" {
+		handlers = append(handlers, p.checkToken)
+	}
+	handlers = append(handlers, p.config.auth...)
+	handlers = append(handlers, p.download)
+
+	app.Get(prefix+"/:kind", handlers...)
+
+	return true
+}
+
+// checkToken 验证请求头中的令牌
+func (p *profiler) checkToken(ctx zeroapi.Context) {
+	token := ctx.Header(p.config.tokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(p.config.token)) != 1 {
+		ctx.SetHTTPCode(http.StatusUnauthorized)
+		ctx.Message(http.StatusUnauthorized, "unauthorized")
+		ctx.Stopped()
+	}
+}
+
+// download 采集数据并以附件形式返回
+func (p *profiler) download(ctx zeroapi.Context) {
+	kind := ctx.Dynamic("kind")
+
+	var duration time.Duration
+	if seconds, err := strconv.Atoi(ctx.Get("seconds")); err == nil && seconds > 0 {
+		duration = time.Duration(seconds) * time.Second
+		if duration > p.config.maxCPUDuration {
+			duration = p.config.maxCPUDuration
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := p.CaptureContext(ctx.Context(), buf, kind, duration); err != nil {
+		// 客户端断开连接，不需要响应
+		if ctx.Context().Err() != nil {
+			ctx.Stopped()
+			return
+		}
+
+		code := http.StatusInternalServerError
+		if err == ErrUnknownKind {
+			code = http.StatusNotFound
+		} else if err == ErrCPUBusy {
+			code = http.StatusConflict
+		}
+		ctx.SetHTTPCode(code)
+		ctx.Message(code, err.Error())
+		return
+	}
+
+	ctx.SetHeader("Content-Type", "application/octet-stream")
+	ctx.SetHeader("Content-Disposition", "attachment; filename="+fileName(kind, ""))
+	ctx.Bytes(buf.Bytes())
+}
+
+// Trigger 主动采集 goroutine 与 heap 数据并存盘
+func (p *profiler) Trigger(reason string) ([]string, error) {
+	p.triggerMu.Lock()
+	now := time.Now()
+	if !p.lastTrigger.IsZero() && now.Sub(p.lastTrigger) < p.config.triggerInterval {
+		p.triggerMu.Unlock()
+		return nil, ErrTooFrequent
+	}
+	p.lastTrigger = now
+	p.triggerMu.Unlock()
+
+	if err := os.MkdirAll(p.config.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	kinds := []string{KindGoroutine, KindHeap}
+	if p.config.triggerCPU {
+		kinds = append(kinds, KindCPU)
+	}
+
+	files := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		path := filepath.Join(p.config.dir, fileName(kind, reason))
+		if err := p.captureFile(path, kind); err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+func (p *profiler) captureFile(path, kind string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return p.Capture(f, kind, 0)
+}
+
+// fileName 生成文件名，例如 heap-20210601-150405-oom.pprof
+func fileName(kind, reason string) string {
+	name := kind + "-" + time.Now().Format("20060102-150405")
+	if reason = safeName(reason); reason != "" {
+		name += "-" + reason
+	}
+
+	return fmt.Sprintf("%s.pprof", name)
+}
+
+// safeName 只保留字母，数字，'-' 和 '_'，用于文件名
+func safeName(s string) string {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' {
+			out = append(out, c)
+		}
+	}
+
+	return string(out)
+}
@@ -0,0 +1,144 @@
+package profile_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/profile"
+)
+
+func newApp(t *testing.T, p profile.Profiler) zeroapi.App {
+	a := app.NewApp()
+	if !p.Register(a, "/debug/profile") {
+		t.Fatal("register failed")
+	}
+	a.Router().Build()
+	return a
+}
+
+func serve(a zeroapi.App, req *http.Request, token string) *httptest.ResponseRecorder {
+	if token != "" {
+		req.Header.Set("X-Profile-Token", token)
+	}
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w
+}
+
+func TestRegisterRequiresAuth(t *testing.T) {
+	if profile.New().Register(app.NewApp(), "/debug/profile") {
+		t.Fatal("register without token or auth should fail")
+	}
+}
+
+func TestToken(t *testing.T) {
+	a := newApp(t, profile.New(profile.WithToken("secret")))
+
+	for _, token := range []string{"", "wrong"} {
+		if w := serve(a, httptest.NewRequest(http.MethodGet, "/debug/profile/heap", nil), token); w.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: expect 401, got %d", token, w.Code)
+		}
+	}
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/debug/profile/heap", nil), "secret")
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("heap: %d, %d bytes", w.Code, w.Body.Len())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment; filename=heap-") {
+		t.Fatalf("invalid Content-Disposition: %s", w.Header().Get("Content-Disposition"))
+	}
+
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/debug/profile/mutex", nil), "secret"); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown kind: expect 404, got %d", w.Code)
+	}
+}
+
+func TestCPU(t *testing.T) {
+	p := profile.New(profile.WithToken("secret"), profile.WithCPUDuration(50*time.Millisecond))
+	a := newApp(t, p)
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/debug/profile/cpu", nil), "secret")
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("cpu: %d, %d bytes", w.Code, w.Body.Len())
+	}
+
+	// 同一时间只能进行一次 cpu 采样
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	capture := func() {
+		done <- p.CaptureContext(ctx, ioutil.Discard, profile.KindCPU, time.Minute)
+	}
+	go capture()
+	time.Sleep(20 * time.Millisecond)
+
+	if w = serve(a, httptest.NewRequest(http.MethodGet, "/debug/profile/cpu", nil), "secret"); w.Code != http.StatusConflict {
+		t.Fatalf("expect 409, got %d", w.Code)
+	}
+
+	// 取消后立即停止采样
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("expect context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("cpu capture not cancelled")
+	}
+}
+
+func TestCPUClientGone(t *testing.T) {
+	a := newApp(t, profile.New(profile.WithToken("secret")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/debug/profile/cpu?seconds=60", nil).WithContext(ctx)
+
+	start := time.Now()
+	serve(a, req, "secret")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("cpu capture should stop when the client is gone, took %s", elapsed)
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var triggered []string
+	p := profile.New(profile.WithDir(dir))
+	w := profile.NewWatchdog(p, profile.WithMaxGoroutines(1), profile.WithOnTrigger(func(reason string, files []string, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		triggered = files
+	}))
+
+	if reason := w.Check(); !strings.HasPrefix(reason, "goroutines-") {
+		t.Fatalf("unexpected reason: %q", reason)
+	}
+	if len(triggered) != 2 {
+		t.Fatalf("unexpected files: %v", triggered)
+	}
+	for _, file := range triggered {
+		if info, err := os.Stat(file); err != nil || info.Size() == 0 {
+			t.Fatalf("invalid file %s: %v", file, err)
+		}
+	}
+
+	// 没有超过阈值
+	if reason := profile.NewWatchdog(p, profile.WithMaxGoroutines(1<<20)).Check(); reason != "" {
+		t.Fatalf("unexpected reason: %q", reason)
+	}
+}
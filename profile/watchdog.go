@@ -0,0 +1,160 @@
+package profile
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// watchdogConfig 异常检测配置
+type watchdogConfig struct {
+	// interval 检查间隔
+	interval time.Duration
+
+	// maxGoroutines goroutine 数量阈值，<= 0 时不检查
+	maxGoroutines int
+
+	// maxHeap 堆内存阈值，单位字节，0 时不检查
+	maxHeap uint64
+
+	// onTrigger 采集完成或者失败时调用
+	onTrigger func(reason string, files []string, err error)
+}
+
+// WatchdogOption 异常检测配置选项
+type WatchdogOption func(config *watchdogConfig)
+
+// WithCheckInterval 设置检查间隔，默认 10 秒
+func WithCheckInterval(interval time.Duration) WatchdogOption {
+	return func(config *watchdogConfig) {
+		if interval > 0 {
+			config.interval = interval
+		}
+	}
+}
+
+// WithMaxGoroutines 设置 goroutine 数量阈值，超过时采集，默认不检查
+func WithMaxGoroutines(n int) WatchdogOption {
+	return func(config *watchdogConfig) {
+		config.maxGoroutines = n
+	}
+}
+
+// WithMaxHeap 设置堆内存(HeapAlloc)阈值，单位字节，超过时采集，默认不检查
+func WithMaxHeap(bytes uint64) WatchdogOption {
+	return func(config *watchdogConfig) {
+		config.maxHeap = bytes
+	}
+}
+
+// WithOnTrigger 设置采集完成或者失败时调用的函数，例如记录日志，发送告警
+// 两次采集间隔小于 WithTriggerInterval 时 err 为 ErrTooFrequent
+func WithOnTrigger(onTrigger func(reason string, files []string, err error)) WatchdogOption {
+	return func(config *watchdogConfig) {
+		config.onTrigger = onTrigger
+	}
+}
+
+// Watchdog 按照固定间隔检查 goroutine 数量与堆内存，超过阈值时调用 Profiler.Trigger 采集并存盘，实现了 zeroapi.Service
+// 持续异常期间由 WithTriggerInterval 限制采集频率
+//
+// 示例:
+// p := profile.New(profile.WithToken(token), profile.WithDir("/data/profile"))
+// app.AddService(profile.NewWatchdog(p, profile.WithMaxGoroutines(10000), profile.WithMaxHeap(1<<30)))
+type Watchdog struct {
+	profiler Profiler
+	config   *watchdogConfig
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWatchdog 创建异常检测，需要通过 WithMaxGoroutines 或者 WithMaxHeap 设置阈值
+func NewWatchdog(profiler Profiler, opts ...WatchdogOption) *Watchdog {
+	config := &watchdogConfig{interval: 10 * time.Second}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Watchdog{profiler: profiler, config: config}
+}
+
+// Check 立即检查一次，超过阈值时采集，返回采集原因，没有超过阈值时为空
+func (w *Watchdog) Check() string {
+	reason := w.reason()
+	if reason == "" {
+		return ""
+	}
+
+	files, err := w.profiler.Trigger(reason)
+	if w.config.onTrigger != nil {
+		w.config.onTrigger(reason, files, err)
+	}
+	return reason
+}
+
+// reason 超过阈值时返回原因，例如 goroutines-10086
+func (w *Watchdog) reason() string {
+	if w.config.maxGoroutines > 0 {
+		if n := runtime.NumGoroutine(); n > w.config.maxGoroutines {
+			return "goroutines-" + strconv.Itoa(n)
+		}
+	}
+
+	if w.config.maxHeap > 0 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > w.config.maxHeap {
+			return "heap-" + strconv.FormatUint(stats.HeapAlloc>>20, 10) + "MB"
+		}
+	}
+
+	return ""
+}
+
+// Start 在后台定期检查
+func (w *Watchdog) Start() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stop != nil {
+		return nil
+	}
+
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go w.loop(w.stop, w.done)
+	return nil
+}
+
+// Stop 停止检查
+func (w *Watchdog) Stop() error {
+	w.mu.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return nil
+}
+
+func (w *Watchdog) loop(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.config.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package protohttp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// errUnknownField 消息中没有该字段
+var errUnknownField = errors.New("protohttp: unknown field")
+
+// setField 根据字段路径设置消息中的字段，例如 book.id
+// 字段名称依次匹配 protobuf 标签中的 json= 与 name=，json 标签以及 Go 字段名称(忽略大小写)
+func setField(msg interface{}, path string, values []string) error {
+	rv := reflect.ValueOf(msg)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("protohttp: message must be a non-nil pointer")
+	}
+
+	fv, err := lookupField(rv, path, true)
+	if err != nil || !fv.IsValid() {
+		return err
+	}
+
+	return setValue(fv, path, values)
+}
+
+// getField 获取字段路径对应的字段，不存在时返回无效值
+func getField(msg interface{}, path string) reflect.Value {
+	fv, _ := lookupField(reflect.ValueOf(msg), path, false)
+	return fv
+}
+
+// lookupField 查找字段，create 为 true 时创建中间的空指针
+func lookupField(rv reflect.Value, path string, create bool) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !create {
+					return reflect.Value{}, nil
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+
+		if rv.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("protohttp: %s is not a message", path)
+		}
+
+		index := fieldIndex(rv.Type(), name)
+		if index < 0 {
+			return reflect.Value{}, fmt.Errorf("%w %s", errUnknownField, path)
+		}
+		rv = rv.Field(index)
+	}
+
+	return rv, nil
+}
+
+func fieldIndex(rt reflect.Type, name string) int {
+	fallback := -1
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		for _, opt := range strings.Split(field.Tag.Get("protobuf"), ",") {
+			if opt == "json="+name || opt == "name="+name {
+				return i
+			}
+		}
+
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == name {
+			return i
+		}
+
+		if fallback < 0 && strings.EqualFold(field.Name, strings.Replace(name, "_", "", -1)) {
+			fallback = i
+		}
+	}
+
+	return fallback
+}
+
+// setValue 将字符串转换为字段的类型，重复字段使用所有值
+func setValue(fv reflect.Value, path string, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setScalar(slice.Index(i), path, v); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+
+	return setScalar(fv, path, values[len(values)-1])
+}
+
+func setScalar(fv reflect.Value, path, v string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+
+	var err error
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(v)
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(v); err == nil {
+			fv.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(v, 10, fv.Type().Bits()); err == nil {
+			fv.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if n, err = strconv.ParseUint(v, 10, fv.Type().Bits()); err == nil {
+			fv.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var n float64
+		if n, err = strconv.ParseFloat(v, fv.Type().Bits()); err == nil {
+			fv.SetFloat(n)
+		}
+	case reflect.Slice:
+		// bytes
+		fv.SetBytes([]byte(v))
+	default:
+		return fmt.Errorf("protohttp: field %s of type %s can not be set from the path or query", path, fv.Type())
+	}
+
+	if err != nil {
+		return fmt.Errorf("protohttp: invalid value %q for field %s", v, path)
+	}
+
+	return nil
+}
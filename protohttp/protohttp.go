@@ -0,0 +1,194 @@
+// Package protohttp 将 protobuf 中使用 google.api.http 注解定义的服务注册为路由
+// 不依赖 protobuf 运行时，注解需要转换为 Rule，请求与响应使用 App 的 JSONCodec 编码
+// 路径参数与查询参数按照字段路径设置到请求消息中，例如 {book.id} 设置 Book.Id
+//
+// 示例:
+// protohttp.Register(app.Group(""), &protohttp.Service{Name: "library.Library", Methods: methods})
+package protohttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// methodKey 保存当前请求对应的 Method 名称
+	methodKey = "protohttp.method"
+)
+
+// Rule 对应 google.api.HttpRule
+type Rule struct {
+	// Method HTTP 方法，例如 GET, POST
+	Method string
+
+	// Path 路径模板，例如 /v1/{name=shelves/*}/books/{book_id}
+	Path string
+
+	// Body 请求体映射到的字段，"*" 表示整个请求消息，为空表示没有请求体
+	Body string
+
+	// ResponseBody 只返回响应消息中的该字段，为空表示整个响应消息
+	ResponseBody string
+
+	// AdditionalBindings 同一个方法的其它路由
+	AdditionalBindings []Rule
+}
+
+// Method 服务中的一个方法
+type Method struct {
+	// Name 方法名称，例如 GetBook
+	Name string
+
+	// Rule HTTP 注解
+	Rule Rule
+
+	// New 创建请求消息，必须返回结构体指针
+	New func() interface{}
+
+	// Call 调用服务方法，返回的错误交给 ctx.Error 处理
+	Call func(ctx context.Context, req interface{}) (interface{}, error)
+}
+
+// Service protobuf 服务
+type Service struct {
+	// Name 服务的完整名称，例如 library.Library
+	Name string
+
+	// Methods 方法列表
+	Methods []Method
+}
+
+// Register 注册服务中的所有方法，路径模板无效时返回错误，此时不会注册任何路由
+func Register(g zeroapi.Group, services ...*Service) error {
+	type route struct {
+		method   string
+		template *template
+		handler  zeroapi.Handler
+	}
+
+	var routes []route
+	for _, service := range services {
+		for i := range service.Methods {
+			m := &service.Methods[i]
+			if m.New == nil || m.Call == nil {
+				return fmt.Errorf("protohttp: %s.%s: New and Call are required", service.Name, m.Name)
+			}
+
+			fullName := "/" + service.Name + "/" + m.Name
+			for _, rule := range append([]Rule{m.Rule}, m.Rule.AdditionalBindings...) {
+				t, err := parseTemplate(rule.Path)
+				if err != nil {
+					return fmt.Errorf("%v, method %s", err, fullName)
+				}
+
+				method := strings.ToUpper(rule.Method)
+				if method == "" {
+					return fmt.Errorf("protohttp: %s: rule for %s has no method", fullName, rule.Path)
+				}
+
+				routes = append(routes, route{method: method, template: t, handler: handle(fullName, m, rule, t)})
+			}
+		}
+	}
+
+	for _, r := range routes {
+		g.Handle(r.method, r.template.path, zeroapi.RouteMiddleware{}, r.handler)
+	}
+
+	return nil
+}
+
+// MethodName 获取当前请求对应的方法名称，例如 /library.Library/GetBook，不是 protohttp 注册的路由时返回空
+func MethodName(ctx zeroapi.Context) string {
+	name, _ := ctx.Value(methodKey).(string)
+	return name
+}
+
+func handle(fullName string, m *Method, rule Rule, t *template) zeroapi.Handler {
+	return zeroapi.E(func(ctx zeroapi.Context) error {
+		fields, ok := t.bind(ctx.Dynamic)
+		if !ok {
+			return zeroapi.NewHTTPError(http.StatusNotFound)
+		}
+
+		ctx.SetValue(methodKey, fullName)
+
+		req := m.New()
+		if err := decodeBody(ctx, req, rule.Body); err != nil {
+			return err
+		}
+
+		for field, value := range fields {
+			if err := setField(req, field, []string{value}); err != nil {
+				return zeroapi.NewHTTPError(http.StatusBadRequest, err.Error()).Wrap(err)
+			}
+		}
+
+		// 请求体映射到整个消息时，查询参数不再绑定
+		if rule.Body != "*" {
+			for key, values := range ctx.Request().URL.Query() {
+				if _, isPath := fields[key]; isPath {
+					continue
+				}
+				if err := setField(req, key, values); err != nil && !errors.Is(err, errUnknownField) {
+					return zeroapi.NewHTTPError(http.StatusBadRequest, err.Error()).Wrap(err)
+				}
+			}
+		}
+
+		res, err := m.Call(ctx.Context(), req)
+		if err != nil {
+			return err
+		}
+
+		if rule.ResponseBody != "" {
+			fv := getField(res, rule.ResponseBody)
+			if !fv.IsValid() {
+				return fmt.Errorf("protohttp: %s: unknown response field %s", fullName, rule.ResponseBody)
+			}
+			_, err = ctx.JSON(fv.Interface())
+			return err
+		}
+
+		_, err = ctx.JSON(res)
+		return err
+	})
+}
+
+// decodeBody 解码请求体到整个消息或者其中一个字段
+func decodeBody(ctx zeroapi.Context, req interface{}, body string) error {
+	r := ctx.Request()
+	if body == "" || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	target := req
+	if body != "*" {
+		fv, err := lookupField(reflect.ValueOf(req), body, true)
+		if err != nil {
+			return err
+		}
+		target = fv.Addr().Interface()
+	}
+
+	if err := ctx.App().JSONCodec().Unmarshal(data, target); err != nil {
+		return zeroapi.NewHTTPError(http.StatusBadRequest, "INVALID JSON").Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package protohttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/protohttp"
+)
+
+// 与 protoc-gen-go 生成的结构体相同的标签
+type Book struct {
+	Name   string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Title  string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Shelf  int64  `protobuf:"varint,3,opt,name=shelf,proto3" json:"shelf,omitempty"`
+	Cancel bool   `json:"cancel,omitempty"`
+}
+
+type GetBookRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+type CreateBookRequest struct {
+	Shelf int64 `protobuf:"varint,1,opt,name=shelf,proto3" json:"shelf,omitempty"`
+	Book  *Book `protobuf:"bytes,2,opt,name=book,proto3" json:"book,omitempty"`
+}
+
+type ListBooksRequest struct {
+	Shelf    int64    `protobuf:"varint,1,opt,name=shelf,proto3" json:"shelf,omitempty"`
+	PageSize int32    `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Tags     []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+}
+
+type ListBooksResponse struct {
+	Books []*Book `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+}
+
+func newApp(t *testing.T) zeroapi.App {
+	a := app.NewApp()
+
+	service := &protohttp.Service{
+		Name: "library.Library",
+		Methods: []protohttp.Method{
+			{
+				Name: "GetBook",
+				Rule: protohttp.Rule{Method: "GET", Path: "/v1/{name=shelves/*/books/*}"},
+				New:  func() interface{} { return new(GetBookRequest) },
+				Call: func(ctx context.Context, req interface{}) (interface{}, error) {
+					name := req.(*GetBookRequest).Name
+					if name == "shelves/1/books/404" {
+						return nil, zeroapi.NewHTTPError(http.StatusNotFound, "book not found")
+					}
+					return &Book{Name: name}, nil
+				},
+			},
+			{
+				Name: "CancelBook",
+				Rule: protohttp.Rule{Method: "POST", Path: "/v1/{name=shelves/*/books/*}:cancel"},
+				New:  func() interface{} { return new(GetBookRequest) },
+				Call: func(ctx context.Context, req interface{}) (interface{}, error) {
+					return &Book{Name: req.(*GetBookRequest).Name, Cancel: true}, nil
+				},
+			},
+			{
+				Name: "CreateBook",
+				Rule: protohttp.Rule{Method: "POST", Path: "/v1/shelves/{shelf}/books", Body: "book"},
+				New:  func() interface{} { return new(CreateBookRequest) },
+				Call: func(ctx context.Context, req interface{}) (interface{}, error) {
+					r := req.(*CreateBookRequest)
+					r.Book.Shelf = r.Shelf
+					return r.Book, nil
+				},
+			},
+			{
+				Name: "ListBooks",
+				Rule: protohttp.Rule{
+					Method:             "GET",
+					Path:               "/v1/shelves/{shelf}/books",
+					ResponseBody:       "books",
+					AdditionalBindings: []protohttp.Rule{{Method: "GET", Path: "/v1/books", ResponseBody: "books"}},
+				},
+				New: func() interface{} { return new(ListBooksRequest) },
+				Call: func(ctx context.Context, req interface{}) (interface{}, error) {
+					r := req.(*ListBooksRequest)
+					res := &ListBooksResponse{}
+					for i := int32(0); i < r.PageSize; i++ {
+						res.Books = append(res.Books, &Book{Shelf: r.Shelf, Title: strings.Join(r.Tags, "+")})
+					}
+					return res, nil
+				},
+			},
+		},
+	}
+
+	if err := protohttp.Register(a.Group(""), service); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Router().Build() {
+		t.Fatal("build failed")
+	}
+
+	return a
+}
+
+func TestRegister(t *testing.T) {
+	a := newApp(t)
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		code   int
+		expect string
+	}{
+		{"GET", "/v1/shelves/1/books/2", "", 200, `{"name":"shelves/1/books/2"}`},
+		{"GET", "/v1/shelves/1/books/404", "", 404, "book not found"},
+		{"POST", "/v1/shelves/1/books/2:cancel", "", 200, `{"name":"shelves/1/books/2","cancel":true}`},
+		{"POST", "/v1/shelves/7/books", `{"title":"go"}`, 200, `{"title":"go","shelf":7}`},
+		{"GET", "/v1/shelves/3/books?pageSize=2&tags=a&tags=b", "", 200, `[{"title":"a+b","shelf":3},{"title":"a+b","shelf":3}]`},
+		{"GET", "/v1/books?page_size=1", "", 200, `[{}]`},
+		{"GET", "/v1/shelves/x/books", "", 400, ""},
+		{"GET", "/v1/books?page_size=x", "", 400, ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, req)
+
+		if w.Code != tt.code {
+			t.Fatalf("%s %s: expect %d, got %d: %s", tt.method, tt.target, tt.code, w.Code, w.Body.String())
+		}
+		if tt.expect != "" && !strings.Contains(w.Body.String(), tt.expect) {
+			t.Fatalf("%s %s: unexpected body %s", tt.method, tt.target, w.Body.String())
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+	}{
+		{"/v1/books/{id}", "/v1/books/:id"},
+		{"/v1/books/{book.id}", "/v1/books/:book.id"},
+		{"/v1/{name=shelves/*}/books", "/v1/shelves/:name#1/books"},
+		{"/v1/files/{path=**}", "/v1/files/*path#1"},
+		{"/v1/{name}:cancel", "/v1/:name(:cancel$)"},
+		{"/v1/books:batchGet", "/v1/books:batchGet"},
+		{"/v1/*/books", "/v1/:#1/books"},
+	}
+
+	for _, tt := range tests {
+		path, err := protohttp.Translate(tt.template)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.template, err)
+		}
+		if path != tt.path {
+			t.Fatalf("%s: expect %s, got %s", tt.template, tt.path, path)
+		}
+	}
+
+	for _, template := range []string{"v1/books", "/v1/{id", "/v1/**/books", "/v1/{a}/{a}", "/v1/a{b}"} {
+		if _, err := protohttp.Translate(template); err == nil {
+			t.Fatalf("%s: expect error", template)
+		}
+	}
+}
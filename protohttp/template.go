@@ -0,0 +1,279 @@
+package protohttp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// templatePart 变量值的一部分，literal 为空时取参数 param 的值
+type templatePart struct {
+	literal string
+	param   string
+}
+
+// template 解析后的路径模板
+type template struct {
+	// path 路由路径，例如 /v1/shelves/:shelf/books/:book
+	path string
+
+	// variables 字段路径 -> 变量值的组成部分
+	variables map[string][]templatePart
+
+	// params 路由参数 -> 字段路径
+	params map[string]string
+
+	// verb 自定义方法，例如 /v1/{name}:cancel 中的 cancel
+	verb string
+
+	// wildcard 最后一段为 ** 时的参数名称，需要在匹配后检查 verb
+	wildcard string
+}
+
+var literalPattern = regexp.MustCompile(`^[A-Za-z0-9_.~-]+$`)
+
+// Translate 将 google.api.http 路径模板转换为路由路径
+// 示例:
+// /v1/books/{id} -> /v1/books/:id
+// /v1/{name=shelves/*}/books -> /v1/shelves/:name#1/books
+// /v1/files/{path=**} -> /v1/files/*path
+func Translate(pattern string) (string, error) {
+	t, err := parseTemplate(pattern)
+	if err != nil {
+		return "", err
+	}
+	return t.path, nil
+}
+
+// parseTemplate 解析路径模板
+// Template = "/" Segments [ Verb ]
+// Segments = Segment { "/" Segment }
+// Segment  = "*" | "**" | LITERAL | Variable
+// Variable = "{" FieldPath [ "=" Segments ] "}"
+// Verb     = ":" LITERAL
+func parseTemplate(pattern string) (*template, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("protohttp: template %q must start with /", pattern)
+	}
+
+	t := &template{
+		variables: make(map[string][]templatePart),
+		params:    make(map[string]string),
+	}
+
+	rest := pattern[1:]
+
+	// 自定义方法在最后一个 / 之后，并且不在变量中
+	if pos := strings.LastIndex(rest, ":"); pos >= 0 && pos > strings.LastIndex(rest, "/") && pos > strings.LastIndex(rest, "}") {
+		t.verb = rest[pos+1:]
+		rest = rest[:pos]
+		if !literalPattern.MatchString(t.verb) {
+			return nil, fmt.Errorf("protohttp: template %q has invalid verb", pattern)
+		}
+	}
+
+	segments, err := splitSegments(rest)
+	if err != nil {
+		return nil, fmt.Errorf("protohttp: template %q: %v", pattern, err)
+	}
+
+	anonymous := 0
+	var out []string
+	for i, segment := range segments {
+		last := i == len(segments)-1
+
+		if !strings.HasPrefix(segment, "{") {
+			p, err := t.segment(segment, "", &anonymous, last)
+			if err != nil {
+				return nil, fmt.Errorf("protohttp: template %q: %v", pattern, err)
+			}
+			out = append(out, p)
+			continue
+		}
+
+		field, sub := segment[1:len(segment)-1], "*"
+		if pos := strings.Index(field, "="); pos >= 0 {
+			field, sub = field[:pos], field[pos+1:]
+		}
+		if field == "" {
+			return nil, fmt.Errorf("protohttp: template %q has empty variable", pattern)
+		}
+		if _, exist := t.variables[field]; exist {
+			return nil, fmt.Errorf("protohttp: template %q binds %s more than once", pattern, field)
+		}
+
+		subs := strings.Split(sub, "/")
+		if sub == "*" {
+			// 最常见的 {id}，直接使用字段路径作为参数名称
+			t.params[field] = field
+			t.variables[field] = []templatePart{{param: field}}
+			out = append(out, "/:"+field)
+			continue
+		}
+
+		var parts []templatePart
+		n := 0
+		for j, s := range subs {
+			if j > 0 {
+				parts = append(parts, templatePart{literal: "/"})
+			}
+
+			param := ""
+			if s == "*" || s == "**" {
+				n++
+				param = field + "#" + strconv.Itoa(n)
+				t.params[param] = field
+				parts = append(parts, templatePart{param: param})
+			} else {
+				parts = append(parts, templatePart{literal: s})
+			}
+
+			p, err := t.segment(s, param, &anonymous, last && j == len(subs)-1)
+			if err != nil {
+				return nil, fmt.Errorf("protohttp: template %q: %v", pattern, err)
+			}
+			out = append(out, p)
+		}
+		t.variables[field] = parts
+	}
+
+	if len(out) == 0 {
+		out = append(out, "/")
+	}
+
+	if t.verb != "" && t.wildcard == "" {
+		lastIndex := len(out) - 1
+		if strings.HasPrefix(out[lastIndex], "/:") {
+			// 参数的值带有 :verb，使用正则表达式约束，匹配后再去掉
+			out[lastIndex] += "(:" + regexp.QuoteMeta(t.verb) + "$)"
+		} else {
+			out[lastIndex] += ":" + t.verb
+		}
+	}
+
+	t.path = strings.Join(out, "")
+
+	return t, nil
+}
+
+// segment 转换一个路径段，param 为空时 * 使用匿名参数
+func (t *template) segment(s, param string, anonymous *int, last bool) (string, error) {
+	switch s {
+	case "*":
+		if param == "" {
+			*anonymous++
+			param = "#" + strconv.Itoa(*anonymous)
+		}
+		return "/:" + param, nil
+	case "**":
+		if !last {
+			return "", fmt.Errorf("** must be the last segment")
+		}
+		if param == "" {
+			param = "#wildcard"
+		}
+		t.wildcard = param
+		return "/*" + param, nil
+	}
+
+	if !literalPattern.MatchString(s) {
+		return "", fmt.Errorf("invalid segment %q", s)
+	}
+
+	return "/" + s, nil
+}
+
+// splitSegments 按照 / 拆分，变量中的 / 不拆分
+func splitSegments(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var segments []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			if depth > 0 {
+				return nil, fmt.Errorf("nested variable")
+			}
+			if i != start {
+				return nil, fmt.Errorf("variable must be a whole segment")
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				return nil, fmt.Errorf("unbalanced }")
+			}
+			depth--
+			if i+1 < len(s) && s[i+1] != '/' {
+				return nil, fmt.Errorf("variable must be a whole segment")
+			}
+		case '/':
+			if depth == 0 {
+				segments = append(segments, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced {")
+	}
+
+	return append(segments, s[start:]), nil
+}
+
+// bind 根据路由参数还原字段的值，返回 false 表示自定义方法不匹配
+func (t *template) bind(dynamic func(string) string) (map[string]string, bool) {
+	var suffix string
+	if t.verb != "" {
+		suffix = ":" + t.verb
+	}
+
+	values := make(map[string]string, len(t.variables))
+	for field, parts := range t.variables {
+		var b strings.Builder
+		for _, part := range parts {
+			if part.param == "" {
+				b.WriteString(part.literal)
+				continue
+			}
+
+			v := dynamic(part.param)
+			if suffix != "" && part.param == t.lastParam() {
+				if !strings.HasSuffix(v, suffix) {
+					return nil, false
+				}
+				v = v[:len(v)-len(suffix)]
+			}
+			b.WriteString(v)
+		}
+		values[field] = b.String()
+	}
+
+	if t.wildcard != "" && suffix != "" && t.params[t.wildcard] == "" {
+		// 匿名的 ** 也需要检查 verb
+		if !strings.HasSuffix(dynamic(t.wildcard), suffix) {
+			return nil, false
+		}
+	}
+
+	return values, true
+}
+
+// lastParam 路径最后一段的参数名称，最后一段是静态路径时返回空
+func (t *template) lastParam() string {
+	pos := strings.LastIndex(t.path, "/")
+	last := t.path[pos+1:]
+	switch {
+	case strings.HasPrefix(last, ":"):
+		if end := strings.Index(last, "("); end >= 0 {
+			return last[1:end]
+		}
+		return last[1:]
+	case strings.HasPrefix(last, "*"):
+		return last[1:]
+	}
+	return ""
+}
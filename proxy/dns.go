@@ -0,0 +1,261 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoRecords 域名没有解析到任何地址
+var ErrNoRecords = errors.New("proxy: no dns records")
+
+// Resolver 域名解析，*net.Resolver 实现了该接口
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// dnsConfig 域名发现配置
+type dnsConfig struct {
+	// resolver 域名解析
+	resolver Resolver
+
+	// interval 重新解析的间隔
+	interval time.Duration
+
+	// timeout 每次解析的超时时间
+	timeout time.Duration
+
+	// onError 解析失败时调用
+	onError func(err error)
+}
+
+// DNSOption 域名发现配置选项
+type DNSOption func(config *dnsConfig)
+
+// WithResolver 设置域名解析，默认 net.DefaultResolver
+func WithResolver(resolver Resolver) DNSOption {
+	return func(config *dnsConfig) {
+		if resolver != nil {
+			config.resolver = resolver
+		}
+	}
+}
+
+// WithDNSInterval 设置重新解析的间隔，默认 30 秒
+func WithDNSInterval(interval time.Duration) DNSOption {
+	return func(config *dnsConfig) {
+		if interval > 0 {
+			config.interval = interval
+		}
+	}
+}
+
+// WithDNSTimeout 设置每次解析的超时时间，默认 5 秒
+func WithDNSTimeout(timeout time.Duration) DNSOption {
+	return func(config *dnsConfig) {
+		if timeout > 0 {
+			config.timeout = timeout
+		}
+	}
+}
+
+// WithDNSErrorHandler 设置解析失败时调用的函数，例如记录日志，失败时继续使用之前的上游
+func WithDNSErrorHandler(onError func(err error)) DNSOption {
+	return func(config *dnsConfig) {
+		config.onError = onError
+	}
+}
+
+// DNS 按照固定间隔解析域名并更新上游池，上游随后端扩容与缩容自动增加与减少，实现了 zeroapi.Service
+// 域名以 _ 开头时解析 SRV 记录，使用记录中的主机与端口，只使用优先级最高的一组记录
+// 否则解析 A, AAAA 记录，使用 target 中的端口
+// 解析失败或者没有记录时继续使用之前的上游，避免 DNS 短暂故障导致所有请求失败
+//
+// 示例:
+// pool, _ := proxy.NewPool(nil)
+// d, err := proxy.NewDNS(pool, "http://_api._tcp.backend.service.consul/v1")
+// app.AddService(d)
+// app.Proxy("/api/*", "", zeroapi.ProxyConfig{StripPrefix: true, Balancer: pool})
+type DNS struct {
+	pool   *Pool
+	target *url.URL
+	srv    bool
+	config *dnsConfig
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewDNS 创建域名发现，target 为上游地址，例如 http://backend.internal:8080 或者 http://_api._tcp.backend.internal
+func NewDNS(pool *Pool, target string, opts ...DNSOption) (*DNS, error) {
+	u, err := parseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &dnsConfig{
+		resolver: net.DefaultResolver,
+		interval: 30 * time.Second,
+		timeout:  5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &DNS{
+		pool:   pool,
+		target: u,
+		srv:    strings.HasPrefix(u.Hostname(), "_"),
+		config: config,
+	}, nil
+}
+
+// Resolve 立即解析一次并更新上游池
+func (d *DNS) Resolve(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, d.config.timeout)
+	defer cancel()
+
+	var hosts []string
+	var err error
+	if d.srv {
+		hosts, err = d.lookupSRV(ctx)
+	} else {
+		hosts, err = d.lookupHost(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	if len(hosts) == 0 {
+		return ErrNoRecords
+	}
+
+	sort.Strings(hosts)
+	targets := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		u := *d.target
+		u.Host = host
+		targets = append(targets, u.String())
+	}
+
+	if equalStrings(targets, d.pool.Targets()) {
+		return nil
+	}
+	return d.pool.Set(targets...)
+}
+
+// Start 同步解析一次，失败时返回错误，然后在后台定期解析
+func (d *DNS) Start() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stop != nil {
+		return nil
+	}
+
+	if err := d.Resolve(context.Background()); err != nil {
+		return err
+	}
+
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	go d.loop(d.stop, d.done)
+	return nil
+}
+
+// Stop 停止定期解析
+func (d *DNS) Stop() error {
+	d.mu.Lock()
+	stop, done := d.stop, d.done
+	d.stop, d.done = nil, nil
+	d.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return nil
+}
+
+func (d *DNS) loop(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(d.config.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := d.Resolve(context.Background()); err != nil && d.config.onError != nil {
+				d.config.onError(err)
+			}
+		}
+	}
+}
+
+// lookupHost 解析 A, AAAA 记录
+func (d *DNS) lookupHost(ctx context.Context) ([]string, error) {
+	addrs, err := d.config.resolver.LookupHost(ctx, d.target.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	port := d.target.Port()
+	hosts := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if port == "" {
+			if strings.Contains(addr, ":") {
+				addr = "[" + addr + "]"
+			}
+			hosts = append(hosts, addr)
+			continue
+		}
+		hosts = append(hosts, net.JoinHostPort(addr, port))
+	}
+	return hosts, nil
+}
+
+// lookupSRV 解析 SRV 记录，只使用优先级最高(数值最小)的一组
+func (d *DNS) lookupSRV(ctx context.Context) ([]string, error) {
+	_, records, err := d.config.resolver.LookupSRV(ctx, "", "", d.target.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	priority := uint16(0)
+	for i, record := range records {
+		if i == 0 || record.Priority < priority {
+			priority = record.Priority
+		}
+	}
+
+	hosts := make([]string, 0, len(records))
+	for _, record := range records {
+		if record.Priority != priority {
+			continue
+		}
+		hosts = append(hosts, net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))))
+	}
+	return hosts, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
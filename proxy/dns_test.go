@@ -0,0 +1,106 @@
+package proxy_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/proxy"
+)
+
+// fakeResolver 返回预先设置的记录
+type fakeResolver struct {
+	mu    sync.Mutex
+	hosts []string
+	srv   []*net.SRV
+	err   error
+}
+
+func (r *fakeResolver) set(hosts []string, srv []*net.SRV, err error) {
+	r.mu.Lock()
+	r.hosts, r.srv, r.err = hosts, srv, err
+	r.mu.Unlock()
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hosts, r.err
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return "", r.srv, r.err
+}
+
+func TestDNSHost(t *testing.T) {
+	resolver := &fakeResolver{}
+	resolver.set([]string{"10.0.0.2", "10.0.0.1", "fd00::1"}, nil, nil)
+
+	pool, _ := proxy.NewPool(nil)
+	d, err := proxy.NewDNS(pool, "http://backend.internal:8080/v1", proxy.WithResolver(resolver), proxy.WithDNSInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Stop()
+
+	want := []string{"http://10.0.0.1:8080/v1", "http://10.0.0.2:8080/v1", "http://[fd00::1]:8080/v1"}
+	if got := pool.Targets(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected targets: %v", got)
+	}
+
+	// 解析失败时继续使用之前的上游
+	resolver.set(nil, nil, errors.New("timeout"))
+	if err := d.Resolve(context.Background()); err == nil || len(pool.Targets()) != 3 {
+		t.Fatal("targets should be kept on error")
+	}
+	resolver.set(nil, nil, nil)
+	if err := d.Resolve(context.Background()); err != proxy.ErrNoRecords || len(pool.Targets()) != 3 {
+		t.Fatal("targets should be kept without records")
+	}
+
+	// 后端缩容后在下一次解析时移除
+	resolver.set([]string{"10.0.0.1"}, nil, nil)
+	deadline := time.Now().Add(time.Second)
+	for len(pool.Targets()) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("targets not updated: %v", pool.Targets())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDNSSRV(t *testing.T) {
+	resolver := &fakeResolver{}
+	resolver.set(nil, []*net.SRV{
+		{Target: "backup.internal.", Port: 9000, Priority: 20},
+		{Target: "b.internal.", Port: 8081, Priority: 10},
+		{Target: "a.internal.", Port: 8080, Priority: 10},
+	}, nil)
+
+	pool, _ := proxy.NewPool(nil)
+	d, err := proxy.NewDNS(pool, "https://_api._tcp.backend.internal", proxy.WithResolver(resolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Resolve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"https://a.internal:8080", "https://b.internal:8081"}
+	if got := pool.Targets(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected targets: %v", got)
+	}
+
+	if _, err := proxy.NewDNS(pool, "backend.internal"); err != proxy.ErrInvalidTarget {
+		t.Fatal("expect ErrInvalidTarget")
+	}
+}
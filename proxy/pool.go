@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"hash/crc32"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// Strategy 负载均衡策略，Pool 保证 Update 与 Pick 不会同时调用
+type Strategy interface {
+	// Update 上游列表变化时调用
+	Update(targets []*url.URL)
+
+	// Pick 为请求选择上游，targets 为空时不会调用
+	Pick(ctx zeroapi.Context) *url.URL
+}
+
+// Pool 上游池，实现了 zeroapi.ProxyBalancer，上游列表可以在运行时修改
+//
+// 示例:
+// pool, err := proxy.NewPool(proxy.ConsistentHash(proxy.CookieKey("sid")), "http://10.0.0.1:8080", "http://10.0.0.2:8080")
+// app.Proxy("/api/*", "", zeroapi.ProxyConfig{StripPrefix: true, Balancer: pool})
+type Pool struct {
+	mu       sync.RWMutex
+	targets  []*url.URL
+	strategy Strategy
+}
+
+// NewPool 创建上游池，strategy 为空时使用 RoundRobin
+func NewPool(strategy Strategy, targets ...string) (*Pool, error) {
+	if strategy == nil {
+		strategy = RoundRobin()
+	}
+
+	p := &Pool{strategy: strategy}
+	if err := p.Set(targets...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Set 替换所有上游，地址不正确时返回 ErrInvalidTarget，不修改当前的上游
+func (p *Pool) Set(targets ...string) error {
+	urls := make([]*url.URL, 0, len(targets))
+	for _, target := range targets {
+		u, err := parseTarget(target)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, u)
+	}
+
+	p.mu.Lock()
+	p.targets = urls
+	p.strategy.Update(urls)
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Targets 获取所有上游
+func (p *Pool) Targets() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	targets := make([]string, 0, len(p.targets))
+	for _, u := range p.targets {
+		targets = append(targets, u.String())
+	}
+	return targets
+}
+
+// Pick 为请求选择上游，没有上游时返回 nil
+func (p *Pool) Pick(ctx zeroapi.Context) *url.URL {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(p.targets) == 0 {
+		return nil
+	}
+	return p.strategy.Pick(ctx)
+}
+
+// roundRobin 轮询
+type roundRobin struct {
+	targets []*url.URL
+	next    uint64
+}
+
+// RoundRobin 依次选择每个上游
+func RoundRobin() Strategy {
+	return &roundRobin{}
+}
+
+func (r *roundRobin) Update(targets []*url.URL) {
+	r.targets = targets
+}
+
+func (r *roundRobin) Pick(_ zeroapi.Context) *url.URL {
+	n := atomic.AddUint64(&r.next, 1)
+	return r.targets[(n-1)%uint64(len(r.targets))]
+}
+
+// KeyFunc 获取一致性哈希的键
+type KeyFunc func(ctx zeroapi.Context) string
+
+// HeaderKey 使用请求头作为一致性哈希的键
+func HeaderKey(header string) KeyFunc {
+	return func(ctx zeroapi.Context) string {
+		return ctx.Header(header)
+	}
+}
+
+// CookieKey 使用 cookie 作为一致性哈希的键
+func CookieKey(name string) KeyFunc {
+	return func(ctx zeroapi.Context) string {
+		if cookie, err := ctx.Request().Cookie(name); err == nil {
+			return cookie.Value
+		}
+		return ""
+	}
+}
+
+// ParamKey 使用路由参数作为一致性哈希的键，例如 /users/:id 中的 id
+func ParamKey(name string) KeyFunc {
+	return func(ctx zeroapi.Context) string {
+		return ctx.Dynamic(name)
+	}
+}
+
+// virtualNodes 每个上游在哈希环上的虚拟节点数量，使请求分布更均匀
+const virtualNodes = 160
+
+// consistentHash 一致性哈希
+type consistentHash struct {
+	key KeyFunc
+
+	// ring 排序后的虚拟节点哈希值
+	ring []uint32
+
+	// nodes 虚拟节点对应的上游
+	nodes map[uint32]*url.URL
+}
+
+// ConsistentHash 一致性哈希，相同键的请求总是发送到同一个上游，适用于有本地缓存的上游
+// 增加或者减少上游时，只有少部分键会改变上游，键为空时使用客户端 IP
+func ConsistentHash(key KeyFunc) Strategy {
+	return &consistentHash{key: key}
+}
+
+func (c *consistentHash) Update(targets []*url.URL) {
+	ring := make([]uint32, 0, len(targets)*virtualNodes)
+	nodes := make(map[uint32]*url.URL, len(targets)*virtualNodes)
+
+	for _, target := range targets {
+		name := target.String()
+		for i := 0; i < virtualNodes; i++ {
+			h := crc32.ChecksumIEEE([]byte(name + "#" + strconv.Itoa(i)))
+			if _, exist := nodes[h]; exist {
+				continue
+			}
+			nodes[h] = target
+			ring = append(ring, h)
+		}
+	}
+
+	sort.Slice(ring, func(i, j int) bool { return ring[i] < ring[j] })
+	c.ring = ring
+	c.nodes = nodes
+}
+
+func (c *consistentHash) Pick(ctx zeroapi.Context) *url.URL {
+	key := ""
+	if c.key != nil {
+		key = c.key(ctx)
+	}
+	if key == "" {
+		key = ctx.ClientIP()
+	}
+
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(c.ring), func(i int) bool { return c.ring[i] >= h })
+	if i == len(c.ring) {
+		i = 0
+	}
+	return c.nodes[c.ring[i]]
+}
@@ -0,0 +1,108 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/proxy"
+)
+
+func newUpstreams(n int) ([]string, func()) {
+	targets := make([]string, 0, n)
+	servers := make([]*httptest.Server, 0, n)
+	for i := 0; i < n; i++ {
+		name := "upstream" + strconv.Itoa(i)
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+		servers = append(servers, s)
+		targets = append(targets, s.URL)
+	}
+
+	return targets, func() {
+		for _, s := range servers {
+			s.Close()
+		}
+	}
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	targets, closeAll := newUpstreams(3)
+	defer closeAll()
+
+	pool, err := proxy.NewPool(nil, targets...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := app.NewApp()
+	if err := a.Proxy("/api/*", "", zeroapi.ProxyConfig{Balancer: pool}); err != nil {
+		t.Fatal(err)
+	}
+	a.Router().Build()
+
+	seen := map[string]int{}
+	for i := 0; i < 6; i++ {
+		seen[serve(a, httptest.NewRequest(http.MethodGet, "/api/users", nil)).Body.String()]++
+	}
+	if len(seen) != 3 || seen["upstream0"] != 2 {
+		t.Fatalf("unexpected distribution: %v", seen)
+	}
+
+	if err := pool.Set("127.0.0.1:8081"); err != proxy.ErrInvalidTarget || len(pool.Targets()) != 3 {
+		t.Fatal("invalid target should not replace upstreams")
+	}
+
+	pool.Set()
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/users", nil)); w.Code != http.StatusBadGateway {
+		t.Fatalf("expect 502 without upstream, got %d", w.Code)
+	}
+}
+
+func TestPoolConsistentHash(t *testing.T) {
+	targets, closeAll := newUpstreams(4)
+	defer closeAll()
+
+	pool, err := proxy.NewPool(proxy.ConsistentHash(proxy.HeaderKey("X-User-Id")), targets...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := app.NewApp()
+	if err := a.Proxy("/api/*", "", zeroapi.ProxyConfig{Balancer: pool}); err != nil {
+		t.Fatal(err)
+	}
+	a.Router().Build()
+
+	pick := func(user string) string {
+		req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+		req.Header.Set("X-User-Id", user)
+		return serve(a, req).Body.String()
+	}
+
+	before := map[string]string{}
+	used := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		user := strconv.Itoa(i)
+		before[user] = pick(user)
+		used[before[user]] = true
+		if pick(user) != before[user] {
+			t.Fatalf("user %s moved between requests", user)
+		}
+	}
+	if len(used) != 4 {
+		t.Fatalf("expect all upstreams used, got %v", used)
+	}
+
+	// 移除一个上游，只有原来在该上游的用户改变
+	pool.Set(targets[:3]...)
+	for user, upstream := range before {
+		if upstream != "upstream3" && pick(user) != upstream {
+			t.Fatalf("user %s moved from %s to %s", user, upstream, pick(user))
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Package proxy 反向代理，支持路径改写，X-Forwarded-* 请求头，重试以及上游超时
+// 请求 ID 与链路追踪信息通过 correlation.Inject 传递给上游，上游的响应码与耗时连同请求 ID 记录到日志中
+//
+// 示例:
+// app.Proxy("/api/*", "http://127.0.0.1:8081")
+// app.Proxy("/users/*", "http://users.internal/v1", zeroapi.ProxyConfig{StripPrefix: true, Retries: 2, Timeout: 5 * time.Second})
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/correlation"
+)
+
+// Param 路由结尾的通配符没有名称时使用的参数名称
+const Param = "proxypath"
+
+// defaultTimeout 默认的上游超时时间
+const defaultTimeout = 30 * time.Second
+
+// maxRetryBody 可以重试的最大请求体，超过时不重试
+const maxRetryBody = 1 << 20
+
+var (
+	// ErrInvalidTarget 上游地址不正确，需要包含 scheme 与 host，例如 http://127.0.0.1:8081
+	ErrInvalidTarget = errors.New("proxy: invalid target")
+
+	// ErrNoUpstream 上游池中没有可用的上游
+	ErrNoUpstream = errors.New("proxy: no upstream")
+
+	// ErrBadGateway 上游连接失败或者响应不正确
+	ErrBadGateway = zeroapi.NewHTTPError(http.StatusBadGateway, "BAD GATEWAY")
+
+	// ErrGatewayTimeout 上游超时
+	ErrGatewayTimeout = zeroapi.NewHTTPError(http.StatusGatewayTimeout, "GATEWAY TIMEOUT")
+)
+
+type proxy struct {
+	target *url.URL
+	config zeroapi.ProxyConfig
+
+	// param 通配符参数名称，路由没有通配符时为空
+	param string
+
+	transport http.RoundTripper
+}
+
+// Pattern 规范化路由，结尾的通配符没有名称时使用 Param，例如 /api/* -> /api/*proxypath
+func Pattern(pattern string) string {
+	if strings.HasSuffix(pattern, "/*") {
+		return pattern + Param
+	}
+	return pattern
+}
+
+// New 创建反向代理处理函数
+// pattern: 注册的路由，需要先经过 Pattern 处理，例如 /api/*proxypath
+// target: 上游地址，例如 http://127.0.0.1:8081/v1，设置 ProxyConfig.Balancer 时可以为空
+func New(pattern, target string, config ...zeroapi.ProxyConfig) (zeroapi.Handler, error) {
+	p := &proxy{}
+	if len(config) > 0 {
+		p.config = config[0]
+	}
+
+	if p.config.Balancer == nil {
+		u, err := parseTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		p.target = u
+	}
+	if p.config.Timeout <= 0 {
+		p.config.Timeout = defaultTimeout
+	}
+
+	if pos := strings.LastIndex(pattern, "/*"); pos != -1 {
+		p.param = pattern[pos+2:]
+	}
+
+	p.transport = p.config.Transport
+	if p.transport == nil {
+		p.transport = http.DefaultTransport
+	}
+	if p.config.Retries > 0 {
+		p.transport = &retryTransport{base: p.transport, retries: p.config.Retries}
+	}
+
+	return p.handle, nil
+}
+
+func (p *proxy) handle(ctx zeroapi.Context) {
+	req := ctx.Request()
+
+	path := req.URL.Path
+	if p.config.StripPrefix {
+		path = "/"
+		if p.param != "" {
+			path += ctx.Dynamic(p.param)
+		}
+	}
+	if p.config.Rewrite != nil {
+		path = p.config.Rewrite(path)
+	}
+
+	target := p.target
+	if p.config.Balancer != nil {
+		if target = p.config.Balancer.Pick(ctx); target == nil {
+			ctx.Error(ErrBadGateway.Wrap(ErrNoUpstream))
+			return
+		}
+	}
+
+	start := time.Now()
+	c, cancel := context.WithTimeout(req.Context(), p.config.Timeout)
+	defer cancel()
+
+	outreq := req.WithContext(c)
+	if p.config.Retries > 0 && idempotent(req.Method) {
+		bufferBody(outreq)
+	}
+
+	rp := &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			p.direct(r, req, target, path)
+			// 使用当前请求的 ID，例如 requestid 中间件不信任客户端传入的 ID 时生成的新 ID
+			r.Header.Del(correlation.HeaderRequestID)
+			correlation.Inject(ctx, r)
+		},
+		Transport: p.transport,
+		ModifyResponse: func(res *http.Response) error {
+			logUpstream(ctx, target, start, strconv.Itoa(res.StatusCode))
+			if p.config.ModifyResponse != nil {
+				return p.config.ModifyResponse(res)
+			}
+			return nil
+		},
+		ErrorHandler: func(_ http.ResponseWriter, _ *http.Request, err error) {
+			logUpstream(ctx, target, start, err.Error())
+			p.fail(ctx, c, err)
+		},
+	}
+
+	rp.ServeHTTP(ctx.Response(), outreq)
+}
+
+// direct 修改发送给上游的请求
+// X-Forwarded-For 由 httputil.ReverseProxy 追加
+func (p *proxy) direct(r, origin *http.Request, target *url.URL, path string) {
+	r.URL.Scheme = target.Scheme
+	r.URL.Host = target.Host
+	r.URL.Path = joinPath(target.Path, path)
+	r.URL.RawPath = ""
+
+	switch {
+	case target.RawQuery == "":
+	case r.URL.RawQuery == "":
+		r.URL.RawQuery = target.RawQuery
+	default:
+		r.URL.RawQuery = target.RawQuery + "&" + r.URL.RawQuery
+	}
+
+	if !p.config.PreserveHost {
+		r.Host = target.Host
+	}
+
+	proto := "http"
+	if origin.TLS != nil {
+		proto = "https"
+	}
+	r.Header.Set("X-Forwarded-Host", origin.Host)
+	r.Header.Set("X-Forwarded-Proto", proto)
+
+	// 不发送 Go 默认的 User-Agent
+	if _, ok := r.Header["User-Agent"]; !ok {
+		r.Header.Set("User-Agent", "")
+	}
+}
+
+// logUpstream 记录上游的响应码或者错误，以及耗时，通过请求 ID 与链路 ID 关联各级日志
+func logUpstream(ctx zeroapi.Context, target *url.URL, start time.Time, result string) {
+	log := ctx.App().Logger()
+	if !log.IsInfoAble() {
+		return
+	}
+
+	traceID, _ := correlation.TraceID(ctx)
+	log.Infof("proxy: %s %s -> %s, %s, %s, request_id: %s, trace_id: %s",
+		ctx.Method(), ctx.Request().URL.RequestURI(), target.Host, result, time.Since(start), correlation.RequestID(ctx), traceID)
+}
+
+// parseTarget 解析上游地址，需要包含 scheme 与 host
+func parseTarget(target string) (*url.URL, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, ErrInvalidTarget
+	}
+	return u, nil
+}
+
+// fail 上游出错，超时响应 504，客户端取消时不响应，其它错误响应 502
+func (p *proxy) fail(ctx zeroapi.Context, c context.Context, err error) {
+	switch {
+	case errors.Is(c.Err(), context.DeadlineExceeded):
+		ctx.Error(ErrGatewayTimeout.Wrap(err))
+	case errors.Is(ctx.Context().Err(), context.Canceled):
+		ctx.Stopped()
+	default:
+		ctx.Error(ErrBadGateway.Wrap(err))
+	}
+}
+
+// retryTransport 幂等请求连接失败或者上游响应 502, 503, 504 时重试
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		if attempt >= t.retries || !retryable(req, res, err) || req.Context().Err() != nil {
+			return res, err
+		}
+
+		if res != nil {
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, 4<<10))
+			res.Body.Close()
+		}
+
+		next := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			next.Body = body
+		}
+		req = next
+	}
+}
+
+// retryable 幂等请求，并且请求体可以重新读取
+func retryable(req *http.Request, res *http.Response, err error) bool {
+	if !idempotent(req.Method) {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// bufferBody 读取不超过 maxRetryBody 的请求体，使其可以重试，超过时不重试
+func bufferBody(req *http.Request) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(req.Body, maxRetryBody+1))
+	if err != nil || len(b) > maxRetryBody {
+		req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(b), req.Body), Closer: req.Body}
+		return
+	}
+
+	req.Body.Close()
+	req.ContentLength = int64(len(b))
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// joinPath 拼接上游地址的路径与请求路径
+func joinPath(a, b string) string {
+	switch {
+	case a == "" || a == "/":
+		return b
+	case b == "" || b == "/":
+		return a
+	}
+	return strings.TrimSuffix(a, "/") + "/" + strings.TrimPrefix(b, "/")
+}
@@ -0,0 +1,177 @@
+package proxy_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/middleware/requestid"
+	"github.com/zerogo-hub/zero-helper/logger"
+)
+
+func serve(a zeroapi.App, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	return w
+}
+
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream-Host", r.Host)
+		w.Header().Set("X-Forwarded-Host", r.Header.Get("X-Forwarded-Host"))
+		w.Header().Set("X-Forwarded-Proto", r.Header.Get("X-Forwarded-Proto"))
+		w.Header().Set("X-Forwarded-For", r.Header.Get("X-Forwarded-For"))
+		w.Write([]byte(r.Method + " " + r.URL.RequestURI()))
+	}))
+	defer upstream.Close()
+
+	a := app.NewApp()
+	if err := a.Proxy("/api/*", upstream.URL+"/v1?key=1", zeroapi.ProxyConfig{StripPrefix: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Proxy("/raw/*rest", upstream.URL, zeroapi.ProxyConfig{
+		PreserveHost: true,
+		Rewrite:      func(path string) string { return strings.Replace(path, "/raw/", "/new/", 1) },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Proxy("/bad/*", "127.0.0.1:8081"); err == nil {
+		t.Fatal("expect invalid target")
+	}
+	a.Router().Build()
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/api/users/1?page=2", nil)
+	w := serve(a, req)
+	if body := w.Body.String(); body != "POST /v1/users/1?key=1&page=2" {
+		t.Fatalf("unexpected upstream request: %s", body)
+	}
+	if w.Header().Get("X-Upstream-Host") != strings.TrimPrefix(upstream.URL, "http://") {
+		t.Fatalf("unexpected host: %s", w.Header().Get("X-Upstream-Host"))
+	}
+	if w.Header().Get("X-Forwarded-Host") != "example.com" || w.Header().Get("X-Forwarded-Proto") != "http" {
+		t.Fatalf("unexpected forwarded headers: %v", w.Header())
+	}
+	if w.Header().Get("X-Forwarded-For") != "192.0.2.1" {
+		t.Fatalf("unexpected X-Forwarded-For: %s", w.Header().Get("X-Forwarded-For"))
+	}
+
+	w = serve(a, httptest.NewRequest(http.MethodGet, "http://example.com/raw/a/b", nil))
+	if body := w.Body.String(); body != "GET /new/a/b" {
+		t.Fatalf("unexpected upstream request: %s", body)
+	}
+	if w.Header().Get("X-Upstream-Host") != "example.com" {
+		t.Fatalf("expect original host, got %s", w.Header().Get("X-Upstream-Host"))
+	}
+}
+
+// recorder 记录日志的 logger，其它方法使用 logger.NewSampleLogger
+type recorder struct {
+	logger.Logger
+
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *recorder) Infof(format string, a ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, fmt.Sprintf(format, a...))
+}
+
+func (r *recorder) IsInfoAble() bool { return true }
+
+func TestProxyCorrelation(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(r.Header.Get("X-Request-ID") + " " + r.Header.Get("traceparent")))
+	}))
+	defer upstream.Close()
+
+	log := &recorder{Logger: logger.NewSampleLogger()}
+	a := app.NewApp(app.WithLogger(log))
+	a.Use(requestid.New(requestid.WithTrustIncoming(false), requestid.WithGenerator(func(zeroapi.Context) string { return "generated" })))
+	a.Proxy("/*", upstream.URL)
+	a.Router().Build()
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-Request-ID", "forged")
+	req.Header.Set("traceparent", traceparent)
+
+	w := serve(a, req)
+	if w.Code != http.StatusCreated || w.Body.String() != "generated "+traceparent {
+		t.Fatalf("unexpected upstream headers: %d %q", w.Code, w.Body.String())
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if len(log.lines) != 1 {
+		t.Fatalf("expect 1 log line, got %v", log.lines)
+	}
+	line := log.lines[0]
+	if !strings.Contains(line, "GET /users/1") || !strings.Contains(line, " 201, ") ||
+		!strings.Contains(line, "request_id: generated") || !strings.Contains(line, "trace_id: 4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Fatalf("unexpected log: %s", line)
+	}
+}
+
+func TestProxyRetry(t *testing.T) {
+	var attempts int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(&attempts, 1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer upstream.Close()
+
+	a := app.NewApp()
+	a.Proxy("/*", upstream.URL, zeroapi.ProxyConfig{Retries: 1})
+	a.Router().Build()
+
+	w := serve(a, httptest.NewRequest(http.MethodPut, "/items/1", strings.NewReader("payload")))
+	if w.Code != http.StatusOK || w.Body.String() != "payload" || atomic.LoadInt32(&attempts) != 2 {
+		t.Fatalf("expect retried PUT, got %d %q after %d attempts", w.Code, w.Body.String(), attempts)
+	}
+
+	// 非幂等请求不重试
+	w = serve(a, httptest.NewRequest(http.MethodPost, "/items", strings.NewReader("payload")))
+	if w.Code != http.StatusServiceUnavailable || atomic.LoadInt32(&attempts) != 3 {
+		t.Fatalf("expect POST not retried, got %d after %d attempts", w.Code, attempts)
+	}
+}
+
+func TestProxyErrors(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer upstream.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	a := app.NewApp()
+	a.Proxy("/slow/*", upstream.URL, zeroapi.ProxyConfig{Timeout: 50 * time.Millisecond})
+	a.Proxy("/down/*", closed.URL)
+	a.Router().Build()
+
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/slow/1", nil)); w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expect 504, got %d", w.Code)
+	}
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/down/1", nil)); w.Code != http.StatusBadGateway {
+		t.Fatalf("expect 502, got %d", w.Code)
+	}
+}
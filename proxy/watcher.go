@@ -0,0 +1,202 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/discovery"
+)
+
+// ErrWatchTimeout 启动时没有在超时时间内获取到实例列表
+var ErrWatchTimeout = errors.New("proxy: discovery watch timeout")
+
+// watchConfig 服务发现配置
+type watchConfig struct {
+	// timeout 启动时等待第一次实例列表的超时时间
+	timeout time.Duration
+
+	// retry 监听中断后重新监听的间隔
+	retry time.Duration
+
+	// filter 过滤实例
+	filter func(instance discovery.Instance) bool
+
+	// onError 监听失败时调用
+	onError func(err error)
+}
+
+// WatchOption 服务发现配置选项
+type WatchOption func(config *watchConfig)
+
+// WithWatchTimeout 设置启动时等待第一次实例列表的超时时间，默认 5 秒
+func WithWatchTimeout(timeout time.Duration) WatchOption {
+	return func(config *watchConfig) {
+		if timeout > 0 {
+			config.timeout = timeout
+		}
+	}
+}
+
+// WithWatchRetry 设置监听中断后重新监听的间隔，默认 5 秒
+func WithWatchRetry(retry time.Duration) WatchOption {
+	return func(config *watchConfig) {
+		if retry > 0 {
+			config.retry = retry
+		}
+	}
+}
+
+// WithWatchFilter 只使用 filter 返回 true 的实例，例如只转发到有 "v2" 标签的实例
+func WithWatchFilter(filter func(instance discovery.Instance) bool) WatchOption {
+	return func(config *watchConfig) {
+		config.filter = filter
+	}
+}
+
+// WithWatchErrorHandler 设置监听失败时调用的函数，例如记录日志，失败时继续使用之前的上游
+func WithWatchErrorHandler(onError func(err error)) WatchOption {
+	return func(config *watchConfig) {
+		config.onError = onError
+	}
+}
+
+// Watcher 监听注册中心中服务的实例并更新上游池，实现了 zeroapi.Service
+// 实例列表为空时继续使用之前的上游，与 DNS 相同，避免注册中心短暂故障导致所有请求失败
+//
+// 示例:
+// pool, _ := proxy.NewPool(nil)
+// app.AddService(proxy.NewWatcher(pool, registry, "orders", proxy.WithWatchFilter(func(i discovery.Instance) bool { return i.HasTag("v2") })))
+// app.Proxy("/orders/*", "", zeroapi.ProxyConfig{Balancer: pool})
+type Watcher struct {
+	pool      *Pool
+	discovery discovery.Discovery
+	service   string
+	config    *watchConfig
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWatcher 创建服务发现，通过 app.AddService 添加
+func NewWatcher(pool *Pool, d discovery.Discovery, service string, opts ...WatchOption) *Watcher {
+	config := &watchConfig{
+		timeout: 5 * time.Second,
+		retry:   5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Watcher{pool: pool, discovery: d, service: service, config: config}
+}
+
+// Start 开始监听，等待第一次实例列表并更新上游池，超时或者失败时返回错误，然后在后台继续监听
+func (w *Watcher) Start() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := w.discovery.Watch(ctx, w.service)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	timer := time.NewTimer(w.config.timeout)
+	defer timer.Stop()
+
+	select {
+	case instances, ok := <-ch:
+		if !ok {
+			cancel()
+			return ErrWatchTimeout
+		}
+		if err := w.update(instances); err != nil {
+			cancel()
+			return err
+		}
+	case <-timer.C:
+		cancel()
+		return ErrWatchTimeout
+	}
+
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	go w.loop(ctx, ch, w.done)
+	return nil
+}
+
+// Stop 停止监听
+func (w *Watcher) Stop() error {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return nil
+}
+
+func (w *Watcher) loop(ctx context.Context, ch <-chan []discovery.Instance, done chan struct{}) {
+	defer close(done)
+
+	for {
+		for instances := range ch {
+			if err := w.update(instances); err != nil {
+				w.onError(err)
+			}
+		}
+
+		// 通道关闭，停止时直接返回，否则等待一段时间后重新监听
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.config.retry):
+			}
+
+			var err error
+			if ch, err = w.discovery.Watch(ctx, w.service); err == nil {
+				break
+			}
+			w.onError(err)
+		}
+	}
+}
+
+// update 使用实例列表更新上游池
+func (w *Watcher) update(instances []discovery.Instance) error {
+	targets := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		if w.config.filter != nil && !w.config.filter(instance) {
+			continue
+		}
+		targets = append(targets, instance.URL())
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	sort.Strings(targets)
+	if equalStrings(targets, w.pool.Targets()) {
+		return nil
+	}
+	return w.pool.Set(targets...)
+}
+
+func (w *Watcher) onError(err error) {
+	if w.config.onError != nil {
+		w.config.onError(err)
+	}
+}
@@ -0,0 +1,85 @@
+package proxy_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/discovery"
+	"github.com/zerogo-hub/zero-api/proxy"
+)
+
+// waitTargets 等待上游池更新
+func waitTargets(t *testing.T, pool *proxy.Pool, want []string) {
+	deadline := time.Now().Add(time.Second)
+	for !reflect.DeepEqual(pool.Targets(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected targets: %v, want: %v", pool.Targets(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	registry := discovery.NewMemory()
+	ctx := context.Background()
+	registry.Register(ctx, discovery.Instance{Service: "orders", Address: "10.0.0.2:8080", Tags: []string{"v2"}})
+	registry.Register(ctx, discovery.Instance{Service: "orders", Address: "10.0.0.1:8080", Tags: []string{"v2"}})
+	registry.Register(ctx, discovery.Instance{Service: "orders", Address: "10.0.0.9:8080", Tags: []string{"v1"}})
+	registry.Register(ctx, discovery.Instance{Service: "users", Address: "10.0.1.1:8080"})
+
+	pool, _ := proxy.NewPool(nil)
+	w := proxy.NewWatcher(pool, registry, "orders", proxy.WithWatchFilter(func(i discovery.Instance) bool {
+		return i.HasTag("v2")
+	}))
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// Start 返回时已经获取到实例
+	want := []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"}
+	if got := pool.Targets(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected targets: %v", got)
+	}
+
+	registry.Register(ctx, discovery.Instance{Service: "orders", Address: "10.0.0.3:8443", Scheme: "https", Tags: []string{"v2"}})
+	waitTargets(t, pool, []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080", "https://10.0.0.3:8443"})
+
+	registry.Deregister(ctx, discovery.Instance{Service: "orders", Address: "10.0.0.1:8080"})
+	waitTargets(t, pool, []string{"http://10.0.0.2:8080", "https://10.0.0.3:8443"})
+
+	registry.Deregister(ctx, discovery.Instance{Service: "orders", Address: "10.0.0.2:8080"})
+	waitTargets(t, pool, []string{"https://10.0.0.3:8443"})
+
+	// 所有实例注销时继续使用之前的上游
+	registry.Deregister(ctx, discovery.Instance{Service: "orders", Address: "10.0.0.3:8443"})
+	time.Sleep(20 * time.Millisecond)
+	if got := pool.Targets(); len(got) != 1 {
+		t.Fatalf("targets should be kept: %v", got)
+	}
+
+	// 停止后不再更新
+	w.Stop()
+	registry.Register(ctx, discovery.Instance{Service: "orders", Address: "10.0.0.5:8080", Tags: []string{"v2"}})
+	time.Sleep(20 * time.Millisecond)
+	if got := pool.Targets(); len(got) != 1 {
+		t.Fatalf("targets should not change after stop: %v", got)
+	}
+}
+
+// silentDiscovery 不发送任何实例
+type silentDiscovery struct{}
+
+func (silentDiscovery) Watch(ctx context.Context, service string) (<-chan []discovery.Instance, error) {
+	return make(chan []discovery.Instance), nil
+}
+
+func TestWatcherTimeout(t *testing.T) {
+	pool, _ := proxy.NewPool(nil)
+	w := proxy.NewWatcher(pool, silentDiscovery{}, "orders", proxy.WithWatchTimeout(10*time.Millisecond))
+	if err := w.Start(); err != proxy.ErrWatchTimeout {
+		t.Fatalf("expect timeout: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package rawnet
+
+import "time"
+
+// config 监听配置
+type config struct {
+	// name 服务名称，用于日志与指标标签，默认使用监听地址
+	name string
+
+	// maxConns TCP 最大连接数，<= 0 表示不限制
+	maxConns int
+
+	// idleTimeout TCP 连接读写超时，每次读写前需要由处理函数自行续期，<= 0 表示不设置
+	idleTimeout time.Duration
+
+	// shutdownTimeout 停止时等待处理函数结束的时间，超时后强制关闭连接
+	shutdownTimeout time.Duration
+
+	// bufferSize UDP 读取缓冲区大小，超过的数据包会被截断
+	bufferSize int
+}
+
+func defaultConfig() *config {
+	return &config{
+		shutdownTimeout: 5 * time.Second,
+		bufferSize:      64 << 10,
+	}
+}
+
+// Option 监听配置选项
+type Option func(config *config)
+
+// WithName 设置服务名称，用于日志与指标标签，默认使用监听地址
+func WithName(name string) Option {
+	return func(config *config) {
+		config.name = name
+	}
+}
+
+// WithMaxConns 设置 TCP 最大连接数，超过时新连接会被立即关闭，默认不限制
+func WithMaxConns(maxConns int) Option {
+	return func(config *config) {
+		config.maxConns = maxConns
+	}
+}
+
+// WithIdleTimeout 设置 TCP 连接建立时的读写超时，默认不设置
+func WithIdleTimeout(idleTimeout time.Duration) Option {
+	return func(config *config) {
+		config.idleTimeout = idleTimeout
+	}
+}
+
+// WithShutdownTimeout 设置停止时等待处理函数结束的时间，默认 5 秒，超时后强制关闭连接
+func WithShutdownTimeout(shutdownTimeout time.Duration) Option {
+	return func(config *config) {
+		if shutdownTimeout >= 0 {
+			config.shutdownTimeout = shutdownTimeout
+		}
+	}
+}
+
+// WithBufferSize 设置 UDP 读取缓冲区大小，默认 64KB
+func WithBufferSize(size int) Option {
+	return func(config *config) {
+		if size > 0 {
+			config.bufferSize = size
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package rawnet_test
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/rawnet"
+)
+
+func TestTCP(t *testing.T) {
+	a := app.NewApp()
+	s := rawnet.NewTCP(a, "127.0.0.1:0", func(conn net.Conn) {
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("echo " + line))
+	}, rawnet.WithName("echo"), rawnet.WithShutdownTimeout(100*time.Millisecond))
+
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != rawnet.ErrStarted {
+		t.Fatalf("start twice: %v", err)
+	}
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("hi\n"))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "echo hi\n" {
+		t.Fatalf("invalid response: %q %v", line, err)
+	}
+	conn.Close()
+
+	// 处理函数阻塞时，超时后强制关闭连接
+	idle, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("stop should force close connections")
+	}
+
+	found := false
+	for _, sample := range a.Metrics().Gather() {
+		if sample.Name == "rawnet_connections_total" && sample.Value == 2 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("connections should be counted")
+	}
+}
+
+func TestUDP(t *testing.T) {
+	a := app.NewApp()
+	s := rawnet.NewUDP(a, "127.0.0.1:0", func(conn net.PacketConn, addr net.Addr, packet []byte) {
+		conn.WriteTo(append([]byte("pong "), packet...), addr)
+	})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	conn, err := net.Dial("udp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("ping"))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != "pong ping" {
+		t.Fatalf("invalid response: %q %v", buf[:n], err)
+	}
+}
@@ -0,0 +1,188 @@
+// Package rawnet 在 http 服务旁边提供自定义协议的 TCP/UDP 监听
+// 通过 app.AddService 与 App 一同启动与停止，共用 App 的日志与指标
+//
+// 示例:
+// app.AddService(rawnet.NewTCP(app, ":9000", func(conn net.Conn) { io.Copy(conn, conn) }, rawnet.WithName("echo")))
+// app.Run(":8080")
+package rawnet
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// ErrStarted 服务已经启动
+var ErrStarted = errors.New("rawnet: already started")
+
+// TCPHandler TCP 连接处理函数，返回后关闭连接
+type TCPHandler func(conn net.Conn)
+
+// TCP TCP 监听服务，实现 zeroapi.Service
+type TCP struct {
+	app     zeroapi.App
+	addr    string
+	handler TCPHandler
+	config  *config
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closing  bool
+	wg       sync.WaitGroup
+}
+
+// NewTCP 创建 TCP 监听服务
+func NewTCP(app zeroapi.App, addr string, handler TCPHandler, opts ...Option) *TCP {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.name == "" {
+		config.name = addr
+	}
+
+	return &TCP{
+		app:     app,
+		addr:    addr,
+		handler: handler,
+		config:  config,
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
+
+// Start 开始监听
+func (t *TCP) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.listener != nil {
+		return ErrStarted
+	}
+
+	ln, err := net.Listen("tcp", t.addr)
+	if err != nil {
+		return err
+	}
+	t.listener = ln
+	t.closing = false
+
+	t.app.Logger().Infof("rawnet: %s listen on tcp://%s", t.config.name, ln.Addr().String())
+
+	t.wg.Add(1)
+	go t.serve(ln)
+	return nil
+}
+
+// Addr 实际监听的地址，未启动时返回 nil
+func (t *TCP) Addr() net.Addr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.listener == nil {
+		return nil
+	}
+	return t.listener.Addr()
+}
+
+// Stop 停止监听，等待处理函数结束，超过 WithShutdownTimeout 后强制关闭连接
+func (t *TCP) Stop() error {
+	t.mu.Lock()
+	if t.listener == nil {
+		t.mu.Unlock()
+		return nil
+	}
+	err := t.listener.Close()
+	t.listener = nil
+	t.closing = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(t.config.shutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		t.mu.Lock()
+		for conn := range t.conns {
+			conn.Close()
+		}
+		t.mu.Unlock()
+		<-done
+	}
+
+	return err
+}
+
+func (t *TCP) serve(ln net.Listener) {
+	defer t.wg.Done()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return
+		}
+
+		if !t.track(conn) {
+			t.app.Metrics().Counter("rawnet_rejected_total").Add(1, "name", t.config.name)
+			conn.Close()
+			continue
+		}
+
+		t.wg.Add(1)
+		go t.handle(conn)
+	}
+}
+
+// track 记录连接，超过最大连接数或者正在停止时返回 false
+func (t *TCP) track(conn net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closing || (t.config.maxConns > 0 && len(t.conns) >= t.config.maxConns) {
+		return false
+	}
+	t.conns[conn] = struct{}{}
+	return true
+}
+
+func (t *TCP) handle(conn net.Conn) {
+	metrics := t.app.Metrics()
+	active := metrics.Gauge("rawnet_connections_active")
+
+	metrics.Counter("rawnet_connections_total").Add(1, "name", t.config.name)
+	active.Add(1, "name", t.config.name)
+
+	defer func() {
+		if p := recover(); p != nil {
+			t.app.Logger().Errorf("rawnet: %s %s panic: %+v", t.config.name, conn.RemoteAddr().String(), p)
+		}
+
+		conn.Close()
+		active.Add(-1, "name", t.config.name)
+
+		t.mu.Lock()
+		delete(t.conns, conn)
+		t.mu.Unlock()
+		t.wg.Done()
+	}()
+
+	if t.config.idleTimeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(t.config.idleTimeout))
+	}
+
+	t.handler(conn)
+}
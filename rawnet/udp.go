@@ -0,0 +1,121 @@
+package rawnet
+
+import (
+	"net"
+	"sync"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// UDPHandler UDP 数据包处理函数，在读取循环中依次调用，不应阻塞
+// conn: 用于回复，例如 conn.WriteTo(resp, addr)
+// packet: 只在本次调用中有效，需要保留时应该复制
+type UDPHandler func(conn net.PacketConn, addr net.Addr, packet []byte)
+
+// UDP UDP 监听服务，实现 zeroapi.Service
+type UDP struct {
+	app     zeroapi.App
+	addr    string
+	handler UDPHandler
+	config  *config
+
+	mu   sync.Mutex
+	conn net.PacketConn
+	wg   sync.WaitGroup
+}
+
+// NewUDP 创建 UDP 监听服务
+func NewUDP(app zeroapi.App, addr string, handler UDPHandler, opts ...Option) *UDP {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.name == "" {
+		config.name = addr
+	}
+
+	return &UDP{
+		app:     app,
+		addr:    addr,
+		handler: handler,
+		config:  config,
+	}
+}
+
+// Start 开始监听
+func (u *UDP) Start() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.conn != nil {
+		return ErrStarted
+	}
+
+	conn, err := net.ListenPacket("udp", u.addr)
+	if err != nil {
+		return err
+	}
+	u.conn = conn
+
+	u.app.Logger().Infof("rawnet: %s listen on udp://%s", u.config.name, conn.LocalAddr().String())
+
+	u.wg.Add(1)
+	go u.serve(conn)
+	return nil
+}
+
+// Addr 实际监听的地址，未启动时返回 nil
+func (u *UDP) Addr() net.Addr {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.conn == nil {
+		return nil
+	}
+	return u.conn.LocalAddr()
+}
+
+// Stop 停止监听，等待正在执行的处理函数结束
+func (u *UDP) Stop() error {
+	u.mu.Lock()
+	if u.conn == nil {
+		u.mu.Unlock()
+		return nil
+	}
+	err := u.conn.Close()
+	u.conn = nil
+	u.mu.Unlock()
+
+	u.wg.Wait()
+	return err
+}
+
+func (u *UDP) serve(conn net.PacketConn) {
+	defer u.wg.Done()
+
+	packets := u.app.Metrics().Counter("rawnet_packets_total")
+	buf := make([]byte, u.config.bufferSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if n > 0 {
+			packets.Add(1, "name", u.config.name)
+			u.handle(conn, addr, buf[:n])
+		}
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (u *UDP) handle(conn net.PacketConn, addr net.Addr, packet []byte) {
+	defer func() {
+		if p := recover(); p != nil {
+			u.app.Logger().Errorf("rawnet: %s %s panic: %+v", u.config.name, addr.String(), p)
+		}
+	}()
+
+	u.handler(conn, addr, packet)
+}
@@ -0,0 +1,225 @@
+package router
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+var errConstraintArg = errors.New("invalid constraint argument")
+
+// builtinConstraints 框架自带的参数约束，例如 /:id<int,min(1)>
+var builtinConstraints = map[string]zeroapi.RouterConstraint{
+	"int":    noArg(isInt),
+	"uint":   noArg(isUint),
+	"float":  noArg(isFloat),
+	"bool":   noArg(isBool),
+	"uuid":   noArg(isUUID),
+	"alpha":  noArg(isAlpha),
+	"alnum":  noArg(isAlnum),
+	"date":   dateConstraint,
+	"min":    numberConstraint(func(v, n float64) bool { return v >= n }),
+	"max":    numberConstraint(func(v, n float64) bool { return v <= n }),
+	"minlen": lengthConstraint(func(l, n int) bool { return l >= n }),
+	"maxlen": lengthConstraint(func(l, n int) bool { return l <= n }),
+	"len":    lengthConstraint(func(l, n int) bool { return l == n }),
+}
+
+// constraintsEnd 参数约束结束的位置，没有约束时返回 0
+func constraintsEnd(path string) int {
+	if len(path) < 2 || path[1] != DynamicCharacter {
+		return 0
+	}
+
+	i := 2 + len(dynamicName(path))
+	if i >= len(path) || path[i] != '<' {
+		return 0
+	}
+
+	end := strings.IndexByte(path[i:], '>')
+	if end < 0 {
+		return 0
+	}
+
+	return i + end + 1
+}
+
+// parseConstraints 解析当前节点 path 上的参数约束，约束紧跟在参数名称之后，多个约束使用 "," 分隔，需要全部满足
+// 约束依次在 Router 注册的约束，框架自带的约束，Router 注册的验证函数中查找
+func (rn *routeNode) parseConstraints(router zeroapi.Router) bool {
+	// 示例: /blog/:id<int,min(1)>|less4|
+	i := 2 + len(dynamicName(rn.path))
+	if i >= len(rn.path) || rn.path[i] != '<' {
+		return true
+	}
+
+	end := constraintsEnd(rn.path)
+	if end == 0 {
+		// 缺失 >
+		return false
+	}
+
+	for _, expr := range strings.Split(rn.path[i+1:end-1], ",") {
+		validator, ok := buildConstraint(router, strings.TrimSpace(expr))
+		if !ok {
+			return false
+		}
+		rn.validators = append(rn.validators, validator)
+	}
+
+	rn.flag |= VALIDATOR
+
+	return true
+}
+
+// buildConstraint 根据 name(arg) 创建验证函数
+func buildConstraint(router zeroapi.Router, expr string) (zeroapi.RouterValidator, bool) {
+	name, arg := expr, ""
+	if pos := strings.IndexByte(expr, '('); pos >= 0 {
+		if expr[len(expr)-1] != ')' {
+			return nil, false
+		}
+		name, arg = expr[:pos], expr[pos+1:len(expr)-1]
+	}
+	if name == "" {
+		return nil, false
+	}
+
+	var constraint zeroapi.RouterConstraint
+	if router != nil {
+		constraint = router.Constraint(name)
+	}
+	if constraint == nil {
+		constraint = builtinConstraints[name]
+	}
+	if constraint == nil && router != nil && arg == "" {
+		if validator := router.Validator(name); validator != nil {
+			return validator, true
+		}
+	}
+	if constraint == nil {
+		return nil, false
+	}
+
+	validator, err := constraint(arg)
+	if err != nil || validator == nil {
+		return nil, false
+	}
+
+	return validator, true
+}
+
+// noArg 不需要参数的约束
+func noArg(validator zeroapi.RouterValidator) zeroapi.RouterConstraint {
+	return func(arg string) (zeroapi.RouterValidator, error) {
+		if arg != "" {
+			return nil, errConstraintArg
+		}
+		return validator, nil
+	}
+}
+
+// dateConstraint 日期，默认格式 2006-01-02，可以通过参数指定格式，例如 date(20060102)
+func dateConstraint(arg string) (zeroapi.RouterValidator, error) {
+	layout := arg
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+
+	return func(s string) bool {
+		_, err := time.Parse(layout, s)
+		return err == nil
+	}, nil
+}
+
+// numberConstraint 数值比较，例如 min(1)
+func numberConstraint(compare func(v, n float64) bool) zeroapi.RouterConstraint {
+	return func(arg string) (zeroapi.RouterValidator, error) {
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, errConstraintArg
+		}
+
+		return func(s string) bool {
+			v, err := strconv.ParseFloat(s, 64)
+			return err == nil && compare(v, n)
+		}, nil
+	}
+}
+
+// lengthConstraint 长度比较，按照字符计算，例如 minlen(3)
+func lengthConstraint(compare func(l, n int) bool) zeroapi.RouterConstraint {
+	return func(arg string) (zeroapi.RouterValidator, error) {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return nil, errConstraintArg
+		}
+
+		return func(s string) bool {
+			return compare(len([]rune(s)), n)
+		}, nil
+	}
+}
+
+func isInt(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+func isUint(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+func isFloat(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func isBool(s string) bool {
+	_, err := strconv.ParseBool(s)
+	return err == nil
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isAlnum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -2,14 +2,27 @@ package router
 
 import (
 	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/websocket"
 )
 
 type group struct {
 	app    zeroapi.App
 	prefix string
 
-	// middlewares 组路由级别中间件
+	// middlewares 组路由级别中间件，在 App 级别中间件之后执行
 	middlewares []zeroapi.Handler
+
+	// befores 组路由级别中间件，在 App 级别中间件之前执行
+	befores []zeroapi.Handler
+
+	// skipGlobal 组内路由跳过 App 级别中间件
+	skipGlobal bool
+
+	// router 虚拟主机路由，为空时注册到应用的路由中
+	router zeroapi.Router
+
+	// host 虚拟主机
+	host string
 }
 
 // NewGroup 创建一个组路由示例
@@ -21,7 +34,13 @@ func NewGroup(app zeroapi.App, prefix string) zeroapi.Group {
 	return &group{app: app, prefix: prefix}
 }
 
-// Use 添加 Group 级别 中间件
+// NewHostGroup 创建一个注册到虚拟主机路由中的组路由
+// pattern: 主机名，例如 api.example.com，*.example.com，:tenant.example.com
+func NewHostGroup(app zeroapi.App, pattern string) zeroapi.Group {
+	return &group{app: app, router: app.Router().Host(pattern), host: pattern}
+}
+
+// Use 添加 Group 级别 中间件，在 App 级别中间件之后执行
 func (g *group) Use(handlers ...zeroapi.Handler) zeroapi.Group {
 
 	for _, handler := range handlers {
@@ -33,6 +52,56 @@ func (g *group) Use(handlers ...zeroapi.Handler) zeroapi.Group {
 	return g
 }
 
+// UseBefore 添加 Group 级别 中间件，在 App 级别中间件之前执行
+func (g *group) UseBefore(handlers ...zeroapi.Handler) zeroapi.Group {
+
+	for _, handler := range handlers {
+		if handler != nil {
+			g.befores = append(g.befores, handler)
+		}
+	}
+
+	return g
+}
+
+// SkipGlobal 组内路由跳过 App 级别中间件，例如健康检查跳过鉴权
+func (g *group) SkipGlobal() zeroapi.Group {
+	g.skipGlobal = true
+	return g
+}
+
+// Handle 注册路由，并控制路由级别中间件与 App 级别中间件的执行顺序
+// Group 级别的 UseBefore 中间件在 m.Before 之前执行
+func (g *group) Handle(method, path string, m zeroapi.RouteMiddleware, handlers ...zeroapi.Handler) zeroapi.Group {
+	befores := make([]zeroapi.Handler, 0, len(g.befores)+len(m.Before))
+	befores = append(befores, g.befores...)
+	befores = append(befores, m.Before...)
+
+	m.Before = befores
+	m.SkipGlobal = m.SkipGlobal || g.skipGlobal
+
+	if g.router == nil {
+		g.app.Handle(method, g.prefix+path, m, g.groupHandlers(handlers...)...)
+		return g
+	}
+
+	route := zeroapi.RouteInfo{
+		Method:       method,
+		Path:         g.prefix + path,
+		Name:         m.Name,
+		Timeout:      m.Timeout,
+		Meta:         m.Meta,
+		Before:       m.Before,
+		SkipGlobal:   m.SkipGlobal,
+		Cost:         m.Cost,
+		MaxBodyBytes: m.MaxBodyBytes,
+	}
+	if g.router.RegisterRoute(route, g.groupHandlers(handlers...)...) && m.SLO != nil {
+		g.app.AddSLO(zeroapi.RouteSLO{Host: g.host, Method: method, Path: g.prefix + path, SLO: *m.SLO})
+	}
+	return g
+}
+
 func (g *group) groupHandlers(handlers ...zeroapi.Handler) []zeroapi.Handler {
 	lenGroupMiddlewares := len(g.middlewares)
 	lenRouteHandlers := len(handlers)
@@ -47,42 +116,44 @@ func (g *group) groupHandlers(handlers ...zeroapi.Handler) []zeroapi.Handler {
 
 // Get method = "GET"
 func (g *group) Get(path string, handlers ...zeroapi.Handler) zeroapi.Group {
-	g.app.Get(g.prefix+path, g.groupHandlers(handlers...)...)
-	return g
+	return g.Handle(zeroapi.MethodGet, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Post method = "POST"
 func (g *group) Post(path string, handlers ...zeroapi.Handler) zeroapi.Group {
-	g.app.Post(g.prefix+path, g.groupHandlers(handlers...)...)
-	return g
+	return g.Handle(zeroapi.MethodPost, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Put method = "PUT"
 func (g *group) Put(path string, handlers ...zeroapi.Handler) zeroapi.Group {
-	g.app.Put(g.prefix+path, g.groupHandlers(handlers...)...)
-	return g
+	return g.Handle(zeroapi.MethodPut, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Delete method = "DELETE"
 func (g *group) Delete(path string, handlers ...zeroapi.Handler) zeroapi.Group {
-	g.app.Delete(g.prefix+path, g.groupHandlers(handlers...)...)
-	return g
+	return g.Handle(zeroapi.MethodDelete, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Head method = "HEAD"
 func (g *group) Head(path string, handlers ...zeroapi.Handler) zeroapi.Group {
-	g.app.Head(g.prefix+path, g.groupHandlers(handlers...)...)
-	return g
+	return g.Handle(zeroapi.MethodHead, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Patch method = "PATCH"
 func (g *group) Patch(path string, handlers ...zeroapi.Handler) zeroapi.Group {
-	g.app.Patch(g.prefix+path, g.groupHandlers(handlers...)...)
-	return g
+	return g.Handle(zeroapi.MethodPatch, path, zeroapi.RouteMiddleware{}, handlers...)
 }
 
 // Options method = "OPTIONS"
 func (g *group) Options(path string, handlers ...zeroapi.Handler) zeroapi.Group {
-	g.app.Options(g.prefix+path, g.groupHandlers(handlers...)...)
-	return g
+	return g.Handle(zeroapi.MethodOptions, path, zeroapi.RouteMiddleware{}, handlers...)
+}
+
+// WS WebSocket 路由，method = "GET"
+func (g *group) WS(path string, handler zeroapi.WebSocketHandler, middlewares ...zeroapi.Handler) zeroapi.Group {
+	handlers := make([]zeroapi.Handler, 0, len(middlewares)+1)
+	handlers = append(handlers, middlewares...)
+	handlers = append(handlers, websocket.Handler(handler))
+
+	return g.Handle(zeroapi.MethodGet, path, zeroapi.RouteMiddleware{}, handlers...)
 }
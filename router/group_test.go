@@ -1,8 +1,12 @@
 package router_test
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	zeroapi "github.com/zerogo-hub/zero-api"
 	app "github.com/zerogo-hub/zero-api/app"
 	router "github.com/zerogo-hub/zero-api/router"
 )
@@ -36,3 +40,90 @@ func TestGroup(t *testing.T) {
 		t.Fatal("build failed")
 	}
 }
+
+func TestGroupMiddlewareOrder(t *testing.T) {
+	a := app.NewApp()
+
+	var trace []string
+	mark := func(name string) zeroapi.Handler {
+		return func(ctx zeroapi.Context) {
+			trace = append(trace, name)
+		}
+	}
+
+	a.Use(mark("global"))
+
+	g := router.NewGroup(a, "/api")
+	g.Use(mark("group"))
+	g.UseBefore(mark("group-before"))
+	g.Handle(zeroapi.MethodGet, "/user", zeroapi.RouteMiddleware{Before: []zeroapi.Handler{mark("route-before")}}, mark("handler"))
+
+	a.Handle(zeroapi.MethodGet, "/health", zeroapi.RouteMiddleware{SkipGlobal: true}, mark("health"))
+
+	if !a.Router().Build() {
+		t.Fatal("build failed")
+	}
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/api/user", "group-before,route-before,global,group,handler"},
+		{"/health", "health"},
+		{"/missing", "global"},
+	}
+
+	for _, c := range cases {
+		trace = nil
+		a.Server().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, c.path, nil))
+
+		if got := strings.Join(trace, ","); got != c.want {
+			t.Fatalf("path: %s, want: %s, got: %s", c.path, c.want, got)
+		}
+	}
+}
+
+func TestGlobalMiddlewareBeforeLookup(t *testing.T) {
+	a := app.NewApp()
+
+	var trace []string
+	mark := func(name string) zeroapi.Handler {
+		return func(ctx zeroapi.Context) {
+			trace = append(trace, name)
+		}
+	}
+
+	a.Use(mark("global"))
+	// 去掉语言前缀，之后按照新的路径匹配路由
+	a.Use(func(ctx zeroapi.Context) {
+		ctx.Request().URL.Path = strings.TrimPrefix(ctx.Request().URL.Path, "/en")
+	})
+
+	a.Get("/user", mark("user"))
+	// 通过 Router 注册的路由同样执行 App 级别中间件
+	a.Router().Register(zeroapi.MethodGet, "/raw", mark("raw"))
+
+	if !a.Router().Build() {
+		t.Fatal("build failed")
+	}
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/en/user", "global,user"},
+		{"/user", "global,user"},
+		{"/raw", "global,raw"},
+		{"/en/missing", "global"},
+	}
+
+	for _, c := range cases {
+		trace = nil
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, c.path, nil))
+
+		if got := strings.Join(trace, ","); got != c.want {
+			t.Fatalf("path: %s, want: %s, got: %s", c.path, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// SubdomainParam 通配符虚拟主机 *.example.com 匹配的子域名保存在该动态参数中
+	SubdomainParam = "subdomain"
+)
+
+// hostRouter 虚拟主机路由
+type hostRouter struct {
+	// pattern 注册时的主机名，例如 api.example.com, *.example.com, :tenant.example.com
+	pattern string
+
+	// exact 精确匹配的主机名，为空时使用后缀匹配
+	exact string
+
+	// suffix 通配符匹配的后缀，例如 .example.com
+	suffix string
+
+	// param 子域名保存到的动态参数名称
+	param string
+
+	router *router
+}
+
+// Host 获取只匹配该主机名的路由，不存在时创建，主机名不区分大小写
+// pattern: api.example.com 精确匹配
+// pattern: *.example.com 匹配任意子域名，子域名保存在动态参数 subdomain 中
+// pattern: :tenant.example.com 匹配任意子域名，子域名保存在动态参数 tenant 中
+func (r *router) Host(pattern string) zeroapi.Router {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+
+	for _, h := range r.hosts {
+		if h.pattern == pattern {
+			return h.router
+		}
+	}
+
+	h := &hostRouter{
+		pattern: pattern,
+		router: &router{
+			app:         r.app,
+			prefix:      r.prefix,
+			routes:      make(map[string]Route, len(zeroapi.AllMethods())),
+			validators:  r.validators,
+			constraints: r.constraints,
+			registered:  make(map[string]bool),
+			config:      r.config,
+		},
+	}
+
+	switch {
+	case strings.HasPrefix(pattern, "*."):
+		h.suffix, h.param = pattern[1:], SubdomainParam
+	case strings.HasPrefix(pattern, ":") && strings.Contains(pattern, "."):
+		pos := strings.Index(pattern, ".")
+		h.suffix, h.param = pattern[pos:], pattern[1:pos]
+	default:
+		h.exact = pattern
+	}
+
+	r.hosts = append(r.hosts, h)
+	sort.SliceStable(r.hosts, func(i, j int) bool {
+		a, b := r.hosts[i], r.hosts[j]
+		if (a.exact != "") != (b.exact != "") {
+			return a.exact != ""
+		}
+		return len(a.suffix) > len(b.suffix)
+	})
+
+	return h.router
+}
+
+// MatchHost 根据请求的主机名查找虚拟主机路由，没有匹配时返回自身
+// 通配符匹配时返回的 map 中保存了子域名
+func (r *router) MatchHost(host string) (zeroapi.Router, map[string]string) {
+	if len(r.hosts) == 0 {
+		return r, nil
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, h := range r.hosts {
+		if h.exact != "" {
+			if h.exact == host {
+				return h.router, nil
+			}
+			continue
+		}
+
+		if len(host) > len(h.suffix) && strings.HasSuffix(host, h.suffix) {
+			return h.router, map[string]string{h.param: host[:len(host)-len(h.suffix)]}
+		}
+	}
+
+	return r, nil
+}
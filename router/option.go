@@ -0,0 +1,50 @@
+package router
+
+// config 路由配置
+type config struct {
+	// redirectTrailingSlash 路由不存在，但去掉或者添加结尾的 / 后存在时重定向
+	redirectTrailingSlash bool
+
+	// redirectFixedPath 路由不存在，但清理路径并忽略大小写后存在时重定向
+	redirectFixedPath bool
+
+	// caseInsensitiveMatch 忽略大小写匹配路由
+	caseInsensitiveMatch bool
+
+	// strictRoutes 存在重复注册或者永远不会被匹配的路由时，Build 返回 false
+	strictRoutes bool
+}
+
+// Option 路由配置选项
+type Option func(config *config)
+
+// WithRedirectTrailingSlash 路由不存在，但去掉或者添加结尾的 / 后存在时重定向
+// 例如只注册了 /blog 时，/blog/ 重定向到 /blog，GET 与 HEAD 使用 301，其它请求方法使用 308
+func WithRedirectTrailingSlash() Option {
+	return func(config *config) {
+		config.redirectTrailingSlash = true
+	}
+}
+
+// WithRedirectFixedPath 路由不存在，但清理路径(去掉多余的 /，处理 . 与 ..)并忽略大小写后存在时重定向
+// 例如 /Blog//List 重定向到 /blog/list，动态参数保持原样
+func WithRedirectFixedPath() Option {
+	return func(config *config) {
+		config.redirectFixedPath = true
+	}
+}
+
+// WithCaseInsensitiveMatch 忽略静态部分的大小写匹配路由，不重定向，动态参数保持原样
+func WithCaseInsensitiveMatch() Option {
+	return func(config *config) {
+		config.caseInsensitiveMatch = true
+	}
+}
+
+// WithStrictRoutes 存在重复注册或者永远不会被匹配的路由时，Build 返回 false，应用启动失败
+// 默认只记录日志，按照优先级匹配: 重复注册时后注册的生效，参数名称不同但约束相同时先注册的生效
+func WithStrictRoutes() Option {
+	return func(config *config) {
+		config.strictRoutes = true
+	}
+}
@@ -11,12 +11,27 @@ type Route interface {
 	// Insert 添加路由，路由不可重复
 	Insert(path string, handlers ...zeroapi.Handler)
 
+	// InsertRoute 添加路由，与 Insert 相同，同时保存路由信息，通过 LookupRoute 获取
+	InsertRoute(path string, route *zeroapi.RouteInfo, handlers ...zeroapi.Handler)
+
 	// Build 解析路由，包括动态参数，正则表达式，验证函数。路由优化
 	Build(router zeroapi.Router) bool
 
 	// Lookup 查找路由
 	Lookup(path string) ([]zeroapi.Handler, map[string]string)
 
+	// LookupRoute 查找路由，同时返回通过 InsertRoute 保存的路由信息，通过 Insert 添加的路由只有 Path
+	LookupRoute(path string) (*zeroapi.RouteInfo, []zeroapi.Handler, map[string]string)
+
+	// LookupLoose 查找路由，不检查动态参数的正则表达式与验证函数
+	LookupLoose(path string) []zeroapi.Handler
+
+	// FoldPath 忽略大小写查找路由，返回静态部分使用注册时大小写的路径
+	FoldPath(path string) (string, bool)
+
+	// Conflicts 查找永远不会被匹配的路由，需要在 Build 之后调用
+	Conflicts() []string
+
 	// Child 查找节点信息
 	Child(path string) zeroapi.RouteNode
 
@@ -31,6 +46,10 @@ type Route interface {
 type route struct {
 	// root 基数树根节点
 	root zeroapi.RouteNode
+
+	// static 只包含静态部分的路由，Build 时生成，查找时先于基数树查找
+	// 键为完整路径，例如 /api/v1/users，Insert 之后清空，直到再次 Build
+	static map[string]*routeNode
 }
 
 // NewRoute ..
@@ -39,19 +58,79 @@ func NewRoute() Route {
 }
 
 // Insert 添加路由，路由不可重复
+// 结尾的可选参数会展开为多条路由，例如 /archive/:year/:month? 展开为 /archive/:year/:month 与 /archive/:year
 func (re *route) Insert(path string, handlers ...zeroapi.Handler) {
-	paths := buildPath(path)
-	re.root.Put(path, paths, 0, handlers...)
+	re.InsertRoute(path, &zeroapi.RouteInfo{Path: path}, handlers...)
+}
+
+// InsertRoute 添加路由，同时保存路由信息，可选参数展开的多条路由共享同一个路由信息
+func (re *route) InsertRoute(path string, route *zeroapi.RouteInfo, handlers ...zeroapi.Handler) {
+	re.static = nil
+
+	for _, expanded := range expandPath(buildPath(path)) {
+		re.root.Put(path, expanded.paths, 0, handlers...)
+
+		if node := re.root.(*routeNode).find(expanded.paths); node != nil {
+			node.optionals = expanded.omitted
+			node.route = route
+		}
+	}
 }
 
 // Build 解析路由，包括动态参数，正则表达式，验证函数
 func (re *route) Build(router zeroapi.Router) bool {
-	return re.root.Build(router)
+	if !re.root.Build(router) {
+		return false
+	}
+
+	static := make(map[string]*routeNode)
+	re.root.(*routeNode).collectStatic("", static)
+	re.static = static
+
+	return true
 }
 
 // Lookup 查找路由
 func (re *route) Lookup(path string) ([]zeroapi.Handler, map[string]string) {
-	return re.root.Lookup(path, nil)
+	_, handlers, dynamic := re.LookupRoute(path)
+	return handlers, dynamic
+}
+
+// LookupRoute 查找路由，同时返回路由信息
+func (re *route) LookupRoute(path string) (*zeroapi.RouteInfo, []zeroapi.Handler, map[string]string) {
+	// 静态路由的优先级最高，完整匹配时与基数树查找的结果相同
+	if node, ok := re.static[path]; ok {
+		_, dynamic := node.matched(nil)
+		return node.route, node.handlers, dynamic
+	}
+
+	node, dynamic := re.root.(*routeNode).lookup(path, nil, true)
+	if node == nil {
+		return nil, nil, nil
+	}
+	return node.route, node.handlers, dynamic
+}
+
+// LookupLoose 查找路由，不检查动态参数的正则表达式与验证函数
+func (re *route) LookupLoose(path string) []zeroapi.Handler {
+	node, _ := re.root.(*routeNode).lookup(path, nil, false)
+	if node == nil {
+		return nil
+	}
+	return node.handlers
+}
+
+// FoldPath 忽略大小写查找路由，返回静态部分使用注册时大小写的路径
+func (re *route) FoldPath(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	return re.root.(*routeNode).fold(path)
+}
+
+// Conflicts 查找永远不会被匹配的路由
+func (re *route) Conflicts() []string {
+	return re.root.(*routeNode).conflicts("")
 }
 
 // Child 查找节点信息
@@ -77,6 +156,52 @@ func (re *route) Children() []zeroapi.RouteNode {
 // Reset 重置，清理所有数据
 func (re *route) Reset() {
 	re.root.Reset()
+	re.static = nil
+}
+
+// isOptional 是否为可选参数，例如 /:id?
+func isOptional(segment string) bool {
+	return len(segment) > 2 && segment[1] == DynamicCharacter && segment[len(segment)-1] == OptionalCharacter
+}
+
+// optionalPath 展开后的一条路由
+type optionalPath struct {
+	// paths 去掉了 ? 标记的路径
+	paths []string
+
+	// omitted 省略的可选参数名称
+	omitted []string
+}
+
+// expandPath 展开结尾的可选参数，最长的路径在最前面
+func expandPath(paths []string) []optionalPath {
+	required := len(paths)
+	for required > 0 && isOptional(paths[required-1]) {
+		required--
+	}
+
+	full := make([]string, len(paths))
+	copy(full, paths)
+	for i := required; i < len(full); i++ {
+		full[i] = full[i][:len(full[i])-1]
+	}
+
+	out := make([]optionalPath, 0, len(paths)-required+1)
+	for n := len(paths); n >= required; n-- {
+		var omitted []string
+		for _, p := range full[n:] {
+			omitted = append(omitted, dynamicNames(p)...)
+		}
+
+		if n == 0 {
+			// 所有参数都省略时匹配根路径
+			out = append(out, optionalPath{paths: []string{"/"}, omitted: omitted})
+			continue
+		}
+		out = append(out, optionalPath{paths: full[:n], omitted: omitted})
+	}
+
+	return out
 }
 
 func buildPath(path string) []string {
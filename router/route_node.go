@@ -2,6 +2,7 @@ package router
 
 import (
 	"regexp"
+	"sort"
 	"strings"
 
 	zeroapi "github.com/zerogo-hub/zero-api"
@@ -11,8 +12,11 @@ const (
 	// DynamicCharacter 动态路由符号，比如 /user/:name
 	DynamicCharacter = ':'
 
-	// WildcardCharacter 通配符，比如 /blog/hi/*
+	// WildcardCharacter 通配符，比如 /blog/hi/*，/files/*filepath 匹配剩余的路径并保存到动态参数 filepath 中
 	WildcardCharacter = '*'
+
+	// OptionalCharacter 可选参数，只能用于结尾的动态参数，比如 /posts/:id?，/posts 与 /posts/1 都匹配
+	OptionalCharacter = '?'
 )
 
 const (
@@ -44,6 +48,9 @@ type routeNode struct {
 	// handlers 路由处理函数 + 路由级别中间件
 	handlers []zeroapi.Handler
 
+	// route 在本节点结束的路由，通过 Route.LookupRoute 返回
+	route *zeroapi.RouteInfo
+
 	// validators 参数校验
 	validators []zeroapi.RouterValidator
 
@@ -51,11 +58,19 @@ type routeNode struct {
 	flag int
 
 	// dynamicName 动态参数名称，假设动态参数 :id，则 dynamicName = id
+	// 通配符 *filepath 的 dynamicName = filepath，没有名称时不保存匹配的路径
 	dynamicName string
 
 	// dynamicNum 本节点 + 子节点动态参数个数
 	dynamicNum int
 
+	// compoundNames 一个节点含有多个动态参数时的参数名称，例如 /:name.:ext 为 [name, ext]
+	// 此时 pattern 为按照分隔符拆分参数的正则表达式
+	compoundNames []string
+
+	// optionals 在本节点结束的路由中省略的可选参数，匹配时设置为空字符串
+	optionals []string
+
 	// pattern 编译好的正则表达式
 	pattern *regexp.Regexp
 
@@ -90,6 +105,24 @@ func (rn *routeNode) Put(fullPath string, paths []string, height int, handlers .
 	child.Put(fullPath, paths, height+1, handlers...)
 }
 
+// find 查找 Put 添加的节点，需要在 Build 之前调用
+func (rn *routeNode) find(paths []string) *routeNode {
+	node := rn
+	for _, path := range paths {
+		if node.IsWildcard() {
+			break
+		}
+
+		child := node.child(path)
+		if child == nil {
+			return nil
+		}
+		node = child.(*routeNode)
+	}
+
+	return node
+}
+
 func handlersWithoutNil(handlers ...zeroapi.Handler) []zeroapi.Handler {
 
 	out := make([]zeroapi.Handler, 0, len(handlers))
@@ -122,7 +155,7 @@ func newChild(path string) zeroapi.RouteNode {
 // child 在子节点中查找已存在的节点
 func (rn *routeNode) child(path string) zeroapi.RouteNode {
 	for _, child := range rn.children {
-		if child.Path() == path || child.IsWildcard() {
+		if child.Path() == path {
 			return child
 		}
 	}
@@ -133,11 +166,20 @@ func (rn *routeNode) child(path string) zeroapi.RouteNode {
 // Build 解析路由，包括动态参数，正则表达式，验证函数。路由优化
 func (rn *routeNode) Build(router zeroapi.Router) bool {
 	if rn.IsWildcard() {
+		rn.dynamicName = rn.path[2:]
+		rn.dynamicNum = 0
+		if rn.dynamicName != "" {
+			rn.dynamicNum = 1
+		}
 		return true
 	}
 
 	if rn.IsDynamic() {
-		if !(rn.parseRegexp() && rn.parseValidator(router) && rn.parseDynamic()) {
+		if isCompound(rn.path) {
+			if !rn.parseCompound() {
+				return false
+			}
+		} else if !(rn.parseRegexp() && rn.parseValidator(router) && rn.parseConstraints(router) && rn.parseDynamic()) {
 			return false
 		}
 	}
@@ -151,6 +193,11 @@ func (rn *routeNode) Build(router zeroapi.Router) bool {
 		}
 	}
 
+	// 按优先级排列子节点，查找时依次尝试
+	sort.SliceStable(rn.children, func(i, j int) bool {
+		return rn.children[i].(*routeNode).priority() < rn.children[j].(*routeNode).priority()
+	})
+
 	rn.countDynamicNum()
 
 	return true
@@ -161,17 +208,22 @@ func (rn *routeNode) Build(router zeroapi.Router) bool {
 // 一个节点只包含一个正则表达式
 func (rn *routeNode) parseRegexp() bool {
 	// 示例: /blog/list/:id(^\d+$)
-	pos := strings.Index(rn.path, "(")
+	// 约束的参数也使用括号，例如 /:name<minlen(3)>，从约束之后开始查找
+	offset := constraintsEnd(rn.path)
+
+	pos := strings.Index(rn.path[offset:], "(")
 
 	if pos == -1 {
 		return true
 	}
+	pos += offset
 
-	posEnd := strings.Index(rn.path, ")")
+	posEnd := strings.Index(rn.path[pos:], ")")
 	if posEnd == -1 {
 		// 缺失右括号
 		return false
 	}
+	posEnd += pos
 	if pos+1 >= posEnd {
 		// )(
 		return false
@@ -232,20 +284,100 @@ func (rn *routeNode) parseDynamic() bool {
 
 	// 当前节点的 path = /:id(^\d+$)|less4|
 	// rn.dynamicName = id
+	rn.dynamicName = dynamicName(rn.path)
+
+	return true
+}
+
+// isCompound 一个节点是否含有多个动态参数，例如 /:name.:ext，/:from-:to
+// 正则表达式与验证函数中的 : 不计算在内
+func isCompound(path string) bool {
+	if len(path) < 2 || path[1] != DynamicCharacter {
+		return false
+	}
+
+	name := dynamicName(path)
+	return strings.IndexByte(name, DynamicCharacter) >= 0
+}
+
+// parseCompound 解析含有多个动态参数的节点，参数名称只能包含字母，数字和下划线
+// 参数之间必须有分隔符，每个参数至少匹配一个字符，例如 /:name.:ext 匹配 /a.tar.gz 时 name = a.tar, ext = gz
+func (rn *routeNode) parseCompound() bool {
+	segment := rn.path[1:]
+
+	expr := "^"
+	names := make([]string, 0, 2)
+	lastParam := false
+
+	for i := 0; i < len(segment); {
+		if segment[i] == DynamicCharacter {
+			j := i + 1
+			for j < len(segment) && isNameChar(segment[j]) {
+				j++
+			}
+			if j == i+1 || lastParam {
+				// 参数名称为空，或者两个参数之间没有分隔符
+				return false
+			}
+
+			names = append(names, segment[i+1:j])
+			expr += "(.+)"
+			lastParam = true
+			i = j
+			continue
+		}
+
+		j := i
+		for j < len(segment) && segment[j] != DynamicCharacter {
+			j++
+		}
+		expr += regexp.QuoteMeta(segment[i:j])
+		lastParam = false
+		i = j
+	}
+
+	rn.pattern = regexp.MustCompile(expr + "$")
+	rn.compoundNames = names
+	rn.flag |= REGEXP
+
+	return true
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// dynamicNames 获取节点上的所有动态参数名称
+func dynamicNames(path string) []string {
+	if !isCompound(path) {
+		return []string{dynamicName(path)}
+	}
+
+	var names []string
+	for _, part := range strings.Split(path[1:], string(DynamicCharacter))[1:] {
+		i := 0
+		for i < len(part) && isNameChar(part[i]) {
+			i++
+		}
+		names = append(names, part[:i])
+	}
+
+	return names
+}
 
+// dynamicName 获取动态参数名称，path = /:id(^\d+$)|less4|，返回 id
+func dynamicName(path string) string {
 	// 开头两个符号为 /:，所以从 2 开始
 	i := 2
-	for ; i < len(rn.path); i++ {
-		c := rn.path[i]
+	for ; i < len(path); i++ {
+		c := path[i]
 
-		if c == '|' || c == '(' {
+		if c == '|' || c == '(' || c == '<' {
 			break
 		}
 	}
 
-	rn.dynamicName = rn.path[2:i]
-
-	return true
+	return path[2:i]
 }
 
 // merge 路由合并，如果只有一个子节点，且子节点是 STATIC 的，则合并
@@ -270,10 +402,107 @@ func (rn *routeNode) merge() {
 	rn.flag |= child.Flag()
 	rn.children = child.Children()
 	rn.handlers = child.Handlers()
+	rn.optionals = child.(*routeNode).optionals
+	rn.route = child.(*routeNode).route
 
 	rn.merge()
 }
 
+// collectStatic 收集只包含静态部分的路由，prefix 为父节点的完整路径，需要在 Build 之后调用
+func (rn *routeNode) collectStatic(prefix string, static map[string]*routeNode) {
+	if !rn.IsStatic() {
+		return
+	}
+
+	fullPath := prefix + rn.path
+	if rn.handlers != nil {
+		static[fullPath] = rn
+	}
+
+	for _, child := range rn.children {
+		child.(*routeNode).collectStatic(fullPath, static)
+	}
+}
+
+// priority 子节点的匹配优先级，越小越优先
+// 静态 > 带正则表达式或者验证函数的动态参数 > 动态参数 > 通配符
+func (rn *routeNode) priority() int {
+	switch {
+	case rn.IsWildcard():
+		return 3
+	case rn.IsDynamic() && (rn.IsRegexp() || rn.IsValidator()):
+		return 1
+	case rn.IsDynamic():
+		return 2
+	}
+
+	return 0
+}
+
+// segment 去掉参数名称后的路径，参数名称不同但约束相同的节点匹配相同的请求
+func (rn *routeNode) segment() string {
+	switch {
+	case rn.IsWildcard():
+		return "/*"
+	case len(rn.compoundNames) > 0:
+		return "/:" + rn.pattern.String()
+	case rn.IsDynamic():
+		return "/:" + rn.path[2+len(rn.dynamicName):]
+	}
+
+	return rn.path
+}
+
+// patterns 以当前节点开始的所有路由，去掉参数名称
+func (rn *routeNode) patterns() []string {
+	segment := rn.segment()
+
+	var out []string
+	if rn.IsHandler() {
+		out = append(out, segment)
+	}
+
+	for _, child := range rn.children {
+		for _, pattern := range child.(*routeNode).patterns() {
+			out = append(out, segment+pattern)
+		}
+	}
+
+	return out
+}
+
+// conflicts 查找无法匹配到的路由，需要在 Build 之后调用
+// 参数名称不同但约束相同的兄弟节点，例如 /blog/:id 与 /blog/:name，后者永远不会被匹配
+func (rn *routeNode) conflicts(prefix string) []string {
+	fullPath := prefix + rn.path
+
+	var out []string
+	seen := make(map[string]*routeNode)
+	for _, c := range rn.children {
+		child := c.(*routeNode)
+		segment := child.segment()
+
+		if exist, ok := seen[segment]; ok {
+			patterns := make(map[string]bool)
+			for _, pattern := range exist.patterns() {
+				patterns[pattern] = true
+			}
+			for _, pattern := range child.patterns() {
+				if patterns[pattern] {
+					out = append(out, fullPath+child.path+" is shadowed by "+fullPath+exist.path)
+					break
+				}
+			}
+		} else {
+			seen[segment] = child
+		}
+
+		out = append(out, child.conflicts(fullPath)...)
+	}
+
+	return out
+}
+
 func (rn *routeNode) countDynamicNum() {
 
 	dynamicNum := 0
@@ -285,7 +514,9 @@ func (rn *routeNode) countDynamicNum() {
 		}
 	}
 
-	if rn.IsDynamic() {
+	if len(rn.compoundNames) > 0 {
+		dynamicNum += len(rn.compoundNames)
+	} else if rn.IsDynamic() {
 		dynamicNum++
 	}
 
@@ -293,26 +524,56 @@ func (rn *routeNode) countDynamicNum() {
 }
 
 func (rn *routeNode) Lookup(path string, dynamic map[string]string) ([]zeroapi.Handler, map[string]string) {
+	node, dynamic := rn.lookup(path, dynamic, true)
+	if node == nil {
+		return nil, nil
+	}
+	return node.handlers, dynamic
+}
+
+// lookup 查找路由，返回路由结束的节点，没有找到时返回 nil
+// strict: 是否检查动态参数的正则表达式与验证函数，为 false 时用于分析查找失败的原因
+func (rn *routeNode) lookup(path string, dynamic map[string]string, strict bool) (*routeNode, map[string]string) {
 
 	if rn.IsWildcard() {
-		return rn.handlers, dynamic
+		return rn.lookupByWildcard(path, dynamic)
 	}
 
 	if rn.IsDynamic() {
-		return rn.lookupByDynamic(path, dynamic)
+		return rn.lookupByDynamic(path, dynamic, strict)
 	}
 
-	return rn.lookupByStatic(path, dynamic)
+	return rn.lookupByStatic(path, dynamic, strict)
 }
 
-func (rn *routeNode) lookupByStatic(path string, dynamic map[string]string) ([]zeroapi.Handler, map[string]string) {
+// matched 路由在本节点结束，省略的可选参数设置为空字符串
+func (rn *routeNode) matched(dynamic map[string]string) (*routeNode, map[string]string) {
+	if rn.handlers == nil {
+		return nil, nil
+	}
+
+	if len(rn.optionals) == 0 {
+		return rn, dynamic
+	}
+
+	if dynamic == nil {
+		dynamic = make(map[string]string, len(rn.optionals))
+	}
+	for _, name := range rn.optionals {
+		dynamic[name] = ""
+	}
+
+	return rn, dynamic
+}
+
+func (rn *routeNode) lookupByStatic(path string, dynamic map[string]string, strict bool) (*routeNode, map[string]string) {
 	if rn.path == path {
-		return rn.handlers, dynamic
+		return rn.matched(dynamic)
 	}
 
-	// rn.path = /users，path = /user
+	// rn.path = /users，path = /user 或者 rn.path = /large，path = /small
 	// 当前节点 rn 不匹配 path
-	if len(rn.path) > len(path) {
+	if len(rn.path) >= len(path) || !strings.HasPrefix(path, rn.path) {
 		return nil, nil
 	}
 
@@ -324,15 +585,33 @@ func (rn *routeNode) lookupByStatic(path string, dynamic map[string]string) ([]z
 	}
 
 	for _, child := range rn.children {
-		if handlers, dynamic := child.Lookup(childPath, dynamic); handlers != nil {
-			return handlers, dynamic
+		if node, dynamic := child.(*routeNode).lookup(childPath, dynamic, strict); node != nil {
+			return node, dynamic
 		}
 	}
 
 	return nil, nil
 }
 
-func (rn *routeNode) lookupByDynamic(path string, dynamic map[string]string) ([]zeroapi.Handler, map[string]string) {
+// lookupByWildcard 通配符匹配剩余的路径，rn.path = /*filepath，path = /a/b.txt，filepath = a/b.txt
+func (rn *routeNode) lookupByWildcard(path string, dynamic map[string]string) (*routeNode, map[string]string) {
+	if rn.handlers == nil {
+		return nil, nil
+	}
+
+	if rn.dynamicName == "" {
+		return rn, dynamic
+	}
+
+	if dynamic == nil {
+		dynamic = make(map[string]string, 1)
+	}
+	dynamic[rn.dynamicName] = strings.TrimPrefix(path, "/")
+
+	return rn, dynamic
+}
+
+func (rn *routeNode) lookupByDynamic(path string, dynamic map[string]string, strict bool) (*routeNode, map[string]string) {
 
 	// rn.path = /:id，path = /1001/add
 	if dynamic == nil {
@@ -348,28 +627,103 @@ func (rn *routeNode) lookupByDynamic(path string, dynamic map[string]string) ([]
 	}
 	dynamicValue := path[1 : dynamicValueEnd+1]
 
-	if !rn.checkDynamicValueValid(dynamicValue) {
-		return nil, nil
-	}
+	if len(rn.compoundNames) > 0 {
+		// rn.path = /:name.:ext，dynamicValue = a.txt，name = a, ext = txt
+		matches := rn.pattern.FindStringSubmatch(dynamicValue)
+		if matches == nil {
+			return nil, nil
+		}
+		for i, name := range rn.compoundNames {
+			dynamic[name] = matches[i+1]
+		}
+	} else {
+		if strict && !rn.checkDynamicValueValid(dynamicValue) {
+			return nil, nil
+		}
 
-	// rn.dynamicName = id
-	dynamic[rn.dynamicName] = dynamicValue
+		// rn.dynamicName = id
+		dynamic[rn.dynamicName] = dynamicValue
+	}
 
 	// 如果 path[1:] 没有 '/' 或者 '/' 在最后一个，表示该节点是最后一个节点了
 	if pos == -1 || pos == len(path)-1 {
-		return rn.handlers, dynamic
+		if node, dynamic := rn.matched(dynamic); node != nil {
+			return node, dynamic
+		}
+	} else {
+		// 向子节点查找
+		childPath := path[pos+1:]
+
+		for _, child := range rn.children {
+			if node, dynamic := child.(*routeNode).lookup(childPath, dynamic, strict); node != nil {
+				return node, dynamic
+			}
+		}
+	}
+
+	// 没有匹配，删除本节点设置的参数，回溯后匹配其它路由时不会残留
+	// 例如 /users/:id/files/:name/raw 与 /users/:id/files/*path 匹配 /users/1/files/a/b 时，回溯前已经设置了 name = a
+	if len(rn.compoundNames) > 0 {
+		for _, name := range rn.compoundNames {
+			delete(dynamic, name)
+		}
+	} else {
+		delete(dynamic, rn.dynamicName)
+	}
+
+	return nil, nil
+}
+
+// fold 忽略大小写查找路由，返回静态部分替换为注册时大小写的路径，动态参数保持不变
+func (rn *routeNode) fold(path string) (string, bool) {
+	if rn.IsWildcard() {
+		return path, rn.handlers != nil
+	}
+
+	if rn.IsDynamic() {
+		pos := strings.Index(path[1:], "/")
+		dynamicValueEnd := pos
+		if pos < 0 {
+			dynamicValueEnd = len(path) - 1
+		}
+
+		if !rn.checkDynamicValueValid(path[1 : dynamicValueEnd+1]) {
+			return "", false
+		}
+
+		if pos == -1 || pos == len(path)-1 {
+			return path, rn.handlers != nil
+		}
+
+		for _, child := range rn.children {
+			if fixed, ok := child.(*routeNode).fold(path[pos+1:]); ok {
+				return path[:pos+1] + fixed, true
+			}
+		}
+
+		return "", false
 	}
 
-	// 向子节点查找
-	childPath := path[pos+1:]
+	if strings.EqualFold(rn.path, path) {
+		return rn.path, rn.handlers != nil
+	}
+
+	if len(rn.path) >= len(path) || !strings.EqualFold(path[:len(rn.path)], rn.path) {
+		return "", false
+	}
+
+	childPath := path[len(rn.path):]
+	if childPath[0] != '/' {
+		return "", false
+	}
 
 	for _, child := range rn.children {
-		if handlers, dynamic := child.Lookup(childPath, dynamic); handlers != nil {
-			return handlers, dynamic
+		if fixed, ok := child.(*routeNode).fold(childPath); ok {
+			return rn.path + fixed, true
 		}
 	}
 
-	return nil, nil
+	return "", false
 }
 
 func (rn *routeNode) checkDynamicValueValid(dynamicValue string) bool {
@@ -430,10 +784,13 @@ func (rn *routeNode) Reset() {
 	rn.fullPath = ""
 	rn.path = ""
 	rn.handlers = nil
+	rn.route = nil
 	rn.validators = nil
 	rn.flag = STATIC
 	rn.dynamicName = ""
 	rn.dynamicNum = 0
+	rn.optionals = nil
+	rn.compoundNames = nil
 	rn.pattern = nil
 	rn.children = nil
 }
@@ -25,6 +25,29 @@ func TestRouteLookupStatic(t *testing.T) {
 	}
 }
 
+func TestRouteLookupStaticMap(t *testing.T) {
+	route := router.NewRoute()
+	route.Insert("/users/:id", emptyHandle)
+	route.Insert("/users/me", emptyHandle)
+	route.Insert("/", emptyHandle)
+	route.Build(nil)
+
+	// 静态路由优先于动态参数
+	if info, handlers, dynamic := route.LookupRoute("/users/me"); handlers == nil || dynamic != nil || info.Path != "/users/me" {
+		t.Fatalf("invalid static route: %+v", info)
+	}
+
+	if handlers, dynamic := route.Lookup("/"); handlers == nil || dynamic != nil {
+		t.Fatal("invalid root route")
+	}
+
+	// Build 之后添加的路由在再次 Build 之前通过基数树查找
+	route.Insert("/about", emptyHandle)
+	if info, handlers, _ := route.LookupRoute("/about"); handlers == nil || info.Path != "/about" {
+		t.Fatalf("invalid route inserted after build: %+v", info)
+	}
+}
+
 func TestRouteLookupNotFound(t *testing.T) {
 	route := router.NewRoute()
 	route.Insert("/blog/name", emptyHandle)
@@ -99,3 +122,151 @@ func TestRouteLookupDynamicWildcard(t *testing.T) {
 		t.Fatal("invalid 1")
 	}
 }
+
+func TestRouteLookupWildcardCapture(t *testing.T) {
+	route := router.NewRoute()
+	route.Insert("/files/*filepath", emptyHandle)
+	route.Insert("/user/:id/*rest", emptyHandle)
+	route.Build(nil)
+
+	handlers, dynamic := route.Lookup("/files/a/b/c.txt")
+	if handlers == nil || dynamic["filepath"] != "a/b/c.txt" {
+		t.Fatalf("invalid filepath: %v", dynamic)
+	}
+
+	handlers, dynamic = route.Lookup("/files/")
+	if handlers == nil || dynamic["filepath"] != "" {
+		t.Fatalf("invalid empty filepath: %v", dynamic)
+	}
+
+	handlers, dynamic = route.Lookup("/user/1001/posts/1")
+	if handlers == nil || dynamic["id"] != "1001" || dynamic["rest"] != "posts/1" {
+		t.Fatalf("invalid rest: %v", dynamic)
+	}
+}
+
+func TestRouteLookupOptional(t *testing.T) {
+	route := router.NewRoute()
+	route.Insert("/archive/:year/:month(^\\d+$)?", emptyHandle)
+	route.Insert("/posts/:id?", emptyHandle)
+	route.Build(nil)
+
+	tests := []struct {
+		path    string
+		key     string
+		value   string
+		matched bool
+	}{
+		{"/archive/2024/05", "month", "05", true},
+		{"/archive/2024", "month", "", true},
+		{"/archive/2024/may", "", "", false},
+		{"/archive", "", "", false},
+		{"/posts/1", "id", "1", true},
+		{"/posts", "id", "", true},
+		{"/", "", "", false},
+	}
+
+	for _, tt := range tests {
+		handlers, dynamic := route.Lookup(tt.path)
+		if (handlers != nil) != tt.matched {
+			t.Fatalf("%s: matched %v", tt.path, handlers != nil)
+		}
+		if !tt.matched {
+			continue
+		}
+		if value, ok := dynamic[tt.key]; !ok || value != tt.value {
+			t.Fatalf("%s: %s=%q", tt.path, tt.key, value)
+		}
+	}
+
+	if _, dynamic := route.Lookup("/archive/2024"); dynamic["year"] != "2024" {
+		t.Fatal("year missing")
+	}
+
+	// 所有参数都省略时匹配根路径
+	route.Reset()
+	route.Insert("/:lang?", emptyHandle)
+	route.Build(nil)
+
+	if handlers, dynamic := route.Lookup("/"); handlers == nil || dynamic["lang"] != "" {
+		t.Fatal("root not matched")
+	}
+	if _, dynamic := route.Lookup("/en"); dynamic["lang"] != "en" {
+		t.Fatal("lang not matched")
+	}
+}
+
+func TestRouteLookupCompound(t *testing.T) {
+	route := router.NewRoute()
+	route.Insert("/files/:name.:ext", emptyHandle)
+	route.Insert("/files/:id", emptyHandle)
+	route.Insert("/range/:from-:to/items", emptyHandle)
+	route.Build(nil)
+
+	tests := []struct {
+		path    string
+		dynamic map[string]string
+	}{
+		{"/files/report.pdf", map[string]string{"name": "report", "ext": "pdf"}},
+		{"/files/a.tar.gz", map[string]string{"name": "a.tar", "ext": "gz"}},
+		{"/files/readme", map[string]string{"id": "readme"}},
+		{"/files/.gitignore", map[string]string{"id": ".gitignore"}},
+		{"/range/1-10/items", map[string]string{"from": "1", "to": "10"}},
+		{"/range/1/items", nil},
+	}
+
+	for _, tt := range tests {
+		handlers, dynamic := route.Lookup(tt.path)
+		if tt.dynamic == nil {
+			if handlers != nil {
+				t.Fatalf("%s: should not match", tt.path)
+			}
+			continue
+		}
+		if handlers == nil {
+			t.Fatalf("%s: not matched", tt.path)
+		}
+		for key, value := range tt.dynamic {
+			if dynamic[key] != value {
+				t.Fatalf("%s: %s=%q, expect %q", tt.path, key, dynamic[key], value)
+			}
+		}
+	}
+
+	// 两个参数之间必须有分隔符
+	route.Reset()
+	route.Insert("/:a:b", emptyHandle)
+	if route.Build(nil) {
+		t.Fatal("expect build failed")
+	}
+}
+
+func TestRouteLookupTyped(t *testing.T) {
+	route := router.NewRoute()
+	route.Insert("/users/:id<int>", emptyHandle)
+	route.Insert("/users/:name<alpha>", emptyHandle)
+	route.Insert("/orders/:id<uuid>/items", emptyHandle)
+	route.Build(nil)
+
+	if _, dynamic := route.Lookup("/users/-12"); dynamic["id"] != "-12" {
+		t.Fatalf("int not matched: %v", dynamic)
+	}
+	if _, dynamic := route.Lookup("/users/yaha"); dynamic["name"] != "yaha" {
+		t.Fatalf("alpha not matched: %v", dynamic)
+	}
+	if handlers, _ := route.Lookup("/users/yaha1"); handlers != nil {
+		t.Fatal("yaha1 should not match")
+	}
+	if _, dynamic := route.Lookup("/orders/123e4567-e89b-12d3-a456-426614174000/items"); dynamic["id"] == "" {
+		t.Fatal("uuid not matched")
+	}
+	if handlers, _ := route.Lookup("/orders/1/items"); handlers != nil {
+		t.Fatal("invalid uuid should not match")
+	}
+
+	route.Reset()
+	route.Insert("/users/:id<integer>", emptyHandle)
+	if route.Build(nil) {
+		t.Fatal("unknown type should fail to build")
+	}
+}
@@ -1,6 +1,10 @@
 package router
 
 import (
+	"path"
+	"sort"
+	"strings"
+
 	zeroapi "github.com/zerogo-hub/zero-api"
 )
 
@@ -15,14 +19,36 @@ type router struct {
 
 	// validators 存储验证函数
 	validators map[string]zeroapi.RouterValidator
+
+	// constraints 注册的参数约束
+	constraints map[string]zeroapi.RouterConstraint
+
+	// registered 已注册的路由，用于检查重复注册
+	registered map[string]bool
+
+	// conflicts 注册时发现的冲突，Build 时报告
+	conflicts []string
+
+	// hosts 通过 Host 创建的虚拟主机路由，精确匹配的在前，通配符后缀越长越靠前
+	hosts []*hostRouter
+
+	config *config
 }
 
 // NewRouter 创建一个 zeroapi.Router 实例
-func NewRouter(app zeroapi.App) zeroapi.Router {
+func NewRouter(app zeroapi.App, opts ...Option) zeroapi.Router {
+	config := &config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
 	return &router{
-		app:        app,
-		routes:     make(map[string]Route, len(zeroapi.AllMethods())),
-		validators: make(map[string]zeroapi.RouterValidator),
+		app:         app,
+		routes:      make(map[string]Route, len(zeroapi.AllMethods())),
+		validators:  make(map[string]zeroapi.RouterValidator),
+		constraints: make(map[string]zeroapi.RouterConstraint),
+		registered:  make(map[string]bool),
+		config:      config,
 	}
 }
 
@@ -38,6 +64,9 @@ func (r *router) Prefix(prefix string) {
 	}
 
 	r.prefix = prefix
+	for _, h := range r.hosts {
+		h.router.prefix = prefix
+	}
 }
 
 // Register 注册路由处理函数，以及中间件
@@ -45,6 +74,13 @@ func (r *router) Prefix(prefix string) {
 // path: 路径，以 "/" 开头，不可以为空
 // handles: 处理函数和路由级别中间件，匹配成功后会调用该函数
 func (r *router) Register(method, path string, handlers ...zeroapi.Handler) bool {
+	return r.RegisterRoute(zeroapi.RouteInfo{Method: method, Path: path}, handlers...)
+}
+
+// RegisterRoute 注册路由，同时保存路由信息，匹配时通过 LookupRoute 返回
+// route.Path 加上前缀后保存
+func (r *router) RegisterRoute(route zeroapi.RouteInfo, handlers ...zeroapi.Handler) bool {
+	method, path := route.Method, route.Path
 	if len(path) == 0 {
 		return false
 	} else if len(handlers) == 0 {
@@ -52,38 +88,178 @@ func (r *router) Register(method, path string, handlers ...zeroapi.Handler) bool
 	}
 
 	if r.prefix != "" {
+		route.Path = strings.TrimSuffix(r.prefix, "/") + path
 		path = r.prefix + "/" + path
 	}
 
+	for _, expanded := range expandPath(buildPath(path)) {
+		key := method + " " + strings.Join(expanded.paths, "")
+		if r.registered[key] {
+			r.conflicts = append(r.conflicts, key+" is registered more than once")
+		}
+		r.registered[key] = true
+	}
+
 	re := r.routes[method]
 	if re == nil {
 		re = NewRoute()
 		r.routes[method] = re
 	}
 
-	re.Insert(path, handlers...)
+	re.InsertRoute(path, &route, handlers...)
 
 	return true
 }
 
 // Build 解析路由，包括动态参数，正则表达式，验证函数的解析，路由路径查找优化
+// 存在重复注册或者永远不会被匹配的路由时记录日志，按照优先级匹配，开启 WithStrictRoutes 时返回 false
 func (r *router) Build() bool {
-	for _, re := range r.routes {
-		if !re.Build(r) {
+	conflicts, ok := r.build()
+	if !ok {
+		return false
+	}
+
+	for _, h := range r.hosts {
+		hostConflicts, ok := h.router.build()
+		if !ok {
 			return false
 		}
+
+		for _, conflict := range hostConflicts {
+			conflicts = append(conflicts, h.pattern+" "+conflict)
+		}
 	}
 
-	return true
+	for _, conflict := range conflicts {
+		r.app.Logger().Errorf("route conflict: %s", conflict)
+	}
+
+	return len(conflicts) == 0 || !r.config.strictRoutes
+}
+
+// build 解析当前路由，返回发现的冲突，解析失败时返回 false
+func (r *router) build() ([]string, bool) {
+	methods := make([]string, 0, len(r.routes))
+	for method := range r.routes {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	conflicts := append([]string(nil), r.conflicts...)
+	for _, method := range methods {
+		re := r.routes[method]
+		if !re.Build(r) {
+			return nil, false
+		}
+
+		for _, conflict := range re.Conflicts() {
+			conflicts = append(conflicts, method+" "+conflict)
+		}
+	}
+
+	return conflicts, true
 }
 
-// Lookup 查找路由
+// Lookup 查找路由，开启 WithCaseInsensitiveMatch 时，未找到再忽略大小写查找
 func (r *router) Lookup(method, path string) ([]zeroapi.Handler, map[string]string) {
-	if re := r.routes[method]; re != nil {
-		return re.Lookup(path)
+	_, handlers, dynamic := r.LookupRoute(method, path)
+	return handlers, dynamic
+}
+
+// LookupRoute 查找路由，同时返回注册时的路由信息
+func (r *router) LookupRoute(method, path string) (*zeroapi.RouteInfo, []zeroapi.Handler, map[string]string) {
+	re := r.routes[method]
+	if re == nil {
+		return nil, nil, nil
+	}
+
+	route, handlers, dynamic := re.LookupRoute(path)
+	if handlers == nil && r.config.caseInsensitiveMatch {
+		if fixed, ok := re.FoldPath(path); ok {
+			return re.LookupRoute(fixed)
+		}
 	}
 
-	return nil, nil
+	return route, handlers, dynamic
+}
+
+// RedirectPath 路由不存在时，根据 WithRedirectTrailingSlash 与 WithRedirectFixedPath 查找应该重定向的路径
+func (r *router) RedirectPath(method, path string) (string, bool) {
+	re := r.routes[method]
+	if re == nil || len(path) == 0 {
+		return "", false
+	}
+
+	if r.config.redirectTrailingSlash {
+		if fixed := toggleTrailingSlash(path); fixed != "" {
+			if handlers, _ := re.Lookup(fixed); handlers != nil {
+				return fixed, true
+			}
+		}
+	}
+
+	if r.config.redirectFixedPath {
+		cleaned := cleanPath(path)
+		if fixed, ok := re.FoldPath(cleaned); ok && fixed != path {
+			return fixed, true
+		}
+
+		if r.config.redirectTrailingSlash {
+			if fixed, ok := re.FoldPath(toggleTrailingSlash(cleaned)); ok && fixed != path {
+				return fixed, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// toggleTrailingSlash 去掉或者添加结尾的 /，根路径返回空
+func toggleTrailingSlash(p string) string {
+	if p == "/" || p == "" {
+		return ""
+	}
+
+	if p[len(p)-1] == '/' {
+		return p[:len(p)-1]
+	}
+
+	return p + "/"
+}
+
+// cleanPath 清理路径，保留结尾的 /
+func cleanPath(p string) string {
+	cleaned := path.Clean("/" + p)
+	if p[len(p)-1] == '/' && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned
+}
+
+// MissReason 分析 Lookup 失败的原因
+// ReasonValidatorFailed: 路由存在，但动态参数未通过正则表达式或者验证函数
+// ReasonNoRoute: 路由不存在
+func (r *router) MissReason(method, path string) string {
+	if re := r.routes[method]; re != nil && re.LookupLoose(path) != nil {
+		return zeroapi.ReasonValidatorFailed
+	}
+
+	return zeroapi.ReasonNoRoute
+}
+
+// AllowedMethods 获取可以匹配 path 的请求方法
+func (r *router) AllowedMethods(path string) []string {
+	var methods []string
+	for _, method := range zeroapi.AllMethods() {
+		if re := r.routes[method]; re != nil {
+			if handlers, _ := re.Lookup(path); handlers != nil {
+				methods = append(methods, method)
+			}
+		}
+	}
+
+	return methods
 }
 
 // RegisterRouterValidator 注册路由验证函数
@@ -103,3 +279,17 @@ func (r *router) Validator(name string) zeroapi.RouterValidator {
 
 	return nil
 }
+
+// RegisterConstraint 注册路由参数约束，同名时覆盖框架自带的约束
+func (r *router) RegisterConstraint(name string, constraint zeroapi.RouterConstraint) {
+	if name == "" || constraint == nil {
+		return
+	}
+
+	r.constraints[name] = constraint
+}
+
+// Constraint 获取通过 RegisterConstraint 注册的路由参数约束
+func (r *router) Constraint(name string) zeroapi.RouterConstraint {
+	return r.constraints[name]
+}
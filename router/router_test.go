@@ -1,10 +1,14 @@
 package router_test
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	zeroapi "github.com/zerogo-hub/zero-api"
 	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/router"
 )
 
 func TestRouterRegister(t *testing.T) {
@@ -90,3 +94,525 @@ func TestRouterLookup(t *testing.T) {
 		t.Fatal("lookup failed")
 	}
 }
+
+func TestRouterLookupRoute(t *testing.T) {
+	a := app.NewApp()
+	r := a.Router()
+	r.Prefix("/blog")
+
+	r.RegisterRoute(zeroapi.RouteInfo{Method: zeroapi.MethodGet, Path: "/posts/:id?", Name: "post", Meta: map[string]string{"owner": "cms"}}, emptyHandle)
+	r.RegisterRoute(zeroapi.RouteInfo{Method: zeroapi.MethodGet, Path: "/docs/guide", Name: "guide"}, emptyHandle)
+	r.Register(zeroapi.MethodPost, "/posts", emptyHandle)
+
+	if !r.Build() {
+		t.Fatal("build failed")
+	}
+
+	tests := []struct {
+		method, path string
+		route        string
+		name         string
+	}{
+		{zeroapi.MethodGet, "/blog/posts/1", "/blog/posts/:id?", "post"},
+		{zeroapi.MethodGet, "/blog/posts", "/blog/posts/:id?", "post"},
+		{zeroapi.MethodGet, "/blog/docs/guide", "/blog/docs/guide", "guide"},
+		{zeroapi.MethodPost, "/blog/posts", "/blog/posts", ""},
+	}
+	for _, tt := range tests {
+		route, handlers, _ := r.LookupRoute(tt.method, tt.path)
+		if route == nil || handlers == nil || route.Method != tt.method || route.Path != tt.route || route.Name != tt.name {
+			t.Fatalf("%s %s: invalid route: %+v", tt.method, tt.path, route)
+		}
+	}
+
+	if route, _, _ := r.LookupRoute(zeroapi.MethodGet, "/blog/posts/1"); route.Meta["owner"] != "cms" {
+		t.Fatalf("invalid meta: %v", route.Meta)
+	}
+
+	if route, handlers, dynamic := r.LookupRoute(zeroapi.MethodGet, "/blog/docs"); route != nil || handlers != nil || dynamic != nil {
+		t.Fatal("expect not found")
+	}
+}
+
+func TestRouterLookupSameLength(t *testing.T) {
+	a := app.NewApp()
+	r := a.Router()
+
+	r.Register(zeroapi.MethodGet, "/large", emptyHandle)
+	r.Register(zeroapi.MethodGet, "/small", emptyHandle)
+	r.Register(zeroapi.MethodGet, "/sse/list", emptyHandle)
+
+	if !r.Build() {
+		t.Fatal("build failed")
+	}
+
+	// 与 /large 长度相同的静态路由
+	if handlers, _ := r.Lookup(zeroapi.MethodGet, "/small"); handlers == nil {
+		t.Fatal("lookup failed")
+	}
+
+	// 前缀不同的静态路由
+	if handlers, _ := r.Lookup(zeroapi.MethodGet, "/sxe/list"); handlers != nil {
+		t.Fatal("lookup should fail")
+	}
+}
+
+func TestRouterLookupBacktrack(t *testing.T) {
+	a := app.NewApp()
+	r := a.Router()
+
+	r.Register(zeroapi.MethodGet, "/users/:id/files/:name/raw", emptyHandle)
+	r.Register(zeroapi.MethodGet, "/users/:id/files/*path", emptyHandle)
+
+	if !r.Build() {
+		t.Fatal("build failed")
+	}
+
+	// 先匹配 /users/:id/files/:name/raw 失败，回溯后匹配 /users/:id/files/*path，不能残留 name
+	handlers, dynamic := r.Lookup(zeroapi.MethodGet, "/users/1001/files/a/b")
+	if handlers == nil || len(dynamic) != 2 || dynamic["id"] != "1001" || dynamic["path"] != "a/b" {
+		t.Fatalf("invalid dynamic: %v", dynamic)
+	}
+
+	handlers, dynamic = r.Lookup(zeroapi.MethodGet, "/users/1001/files/a/raw")
+	if handlers == nil || len(dynamic) != 2 || dynamic["id"] != "1001" || dynamic["name"] != "a" {
+		t.Fatalf("invalid dynamic: %v", dynamic)
+	}
+}
+
+func TestRouterMissReason(t *testing.T) {
+	a := app.NewApp()
+	r := a.Router()
+	r.RegisterRouterValidator("less4", less4)
+
+	r.Register(zeroapi.MethodGet, "/list/:id(\\d+)|less4|/detail", emptyHandle)
+
+	if !r.Build() {
+		t.Fatal("build failed")
+	}
+
+	if reason := r.MissReason(zeroapi.MethodGet, "/list/abcd/detail"); reason != zeroapi.ReasonValidatorFailed {
+		t.Fatalf("invalid reason: %s", reason)
+	}
+
+	if reason := r.MissReason(zeroapi.MethodGet, "/list/10/fake"); reason != zeroapi.ReasonNoRoute {
+		t.Fatalf("invalid reason: %s", reason)
+	}
+
+	if reason := r.MissReason(zeroapi.MethodPost, "/list/10/detail"); reason != zeroapi.ReasonNoRoute {
+		t.Fatalf("invalid reason: %s", reason)
+	}
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	a := app.NewApp()
+	a.Get("/user/:id", emptyHandle)
+	a.Put("/user/:id", emptyHandle)
+	a.Router().Build()
+
+	if methods := a.Router().AllowedMethods("/user/1"); strings.Join(methods, ",") != "GET,PUT" {
+		t.Fatalf("allowed methods: %v", methods)
+	}
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/user/1", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, PUT" {
+		t.Fatalf("405: %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/blog", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get("Allow") != "" {
+		t.Fatalf("404: %d", w.Code)
+	}
+
+	a.NotFound(func(ctx zeroapi.Context) {
+		_, _ = ctx.JSONWithCode(http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+	a.MethodNotAllowed(func(ctx zeroapi.Context) {
+		_, _ = ctx.JSONWithCode(http.StatusMethodNotAllowed, map[string]string{"allow": ctx.Response().Header().Get("Allow")})
+	})
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/blog", nil))
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `"not found"`) {
+		t.Fatalf("custom 404: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/user/1", nil))
+	if w.Code != http.StatusMethodNotAllowed || !strings.Contains(w.Body.String(), `"GET, PUT"`) {
+		t.Fatalf("custom 405: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRouterAutoOptions(t *testing.T) {
+	a := app.NewApp(app.WithAutoOptions())
+	a.Get("/user/:id", emptyHandle)
+	a.Delete("/user/:id", emptyHandle)
+	a.Router().Build()
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/user/1", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, DELETE, OPTIONS" {
+		t.Fatalf("options: %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/user/1", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, DELETE, OPTIONS" {
+		t.Fatalf("405: %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/blog", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("404: %d", w.Code)
+	}
+}
+
+func TestRouterRedirect(t *testing.T) {
+	a := app.NewApp(app.WithRouterOptions(router.WithRedirectTrailingSlash(), router.WithRedirectFixedPath()))
+	a.Get("/blog", emptyHandle)
+	a.Get("/user/:name", emptyHandle)
+	a.Post("/blog/post", emptyHandle)
+	a.Router().Build()
+
+	tests := []struct {
+		method, path string
+		code         int
+		location     string
+	}{
+		{http.MethodGet, "/blog/", http.StatusMovedPermanently, "/blog"},
+		{http.MethodGet, "/blog/?page=2", http.StatusMovedPermanently, "/blog?page=2"},
+		{http.MethodGet, "/Blog/", http.StatusMovedPermanently, "/blog"},
+		{http.MethodGet, "/x/../BLOG", http.StatusMovedPermanently, "/blog"},
+		{http.MethodGet, "/USER/Tom/", http.StatusMovedPermanently, "/user/Tom"},
+		{http.MethodPost, "/Blog/Post", http.StatusPermanentRedirect, "/blog/post"},
+		{http.MethodGet, "/about", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code || w.Header().Get("Location") != test.location {
+			t.Fatalf("%s %s: %d %q", test.method, test.path, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestRouterCaseInsensitiveMatch(t *testing.T) {
+	a := app.NewApp(app.WithRouterOptions(router.WithCaseInsensitiveMatch()))
+	a.Get("/blog/:id(^\\d+$)/Comments", func(ctx zeroapi.Context) {
+		ctx.Text(ctx.Dynamic("id"))
+	})
+	a.Router().Build()
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/BLOG/12/comments", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "12" {
+		t.Fatalf("case insensitive: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/BLOG/abc/comments", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("regexp: %d", w.Code)
+	}
+
+	// 默认区分大小写
+	b := app.NewApp()
+	b.Get("/blog", emptyHandle)
+	b.Router().Build()
+	if handlers, _ := b.Router().Lookup(http.MethodGet, "/Blog"); handlers != nil {
+		t.Fatal("should be case sensitive by default")
+	}
+}
+
+func TestRouterPriority(t *testing.T) {
+	a := app.NewApp()
+	text := func(s string) zeroapi.Handler {
+		return func(ctx zeroapi.Context) { ctx.Text(s) }
+	}
+
+	// 注册顺序与优先级相反
+	a.Get("/blog/*", text("wildcard"))
+	a.Get("/blog/:name", text("dynamic"))
+	a.Get("/blog/:id(^\\d+$)", text("regexp"))
+	a.Get("/blog/add", text("static"))
+	if !a.Router().Build() {
+		t.Fatal("build failed")
+	}
+
+	tests := map[string]string{
+		"/blog/add":   "static",
+		"/blog/1001":  "regexp",
+		"/blog/hello": "dynamic",
+		"/blog/a/b":   "wildcard",
+	}
+	for path, expected := range tests {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Body.String() != expected {
+			t.Fatalf("%s: expected %s, got %s", path, expected, w.Body.String())
+		}
+	}
+}
+
+func TestRouterConflicts(t *testing.T) {
+	strict := app.WithRouterOptions(router.WithStrictRoutes())
+
+	// 参数名称不同，后缀不同，可以匹配
+	a := app.NewApp(strict)
+	a.Get("/blog/:id/edit", emptyHandle)
+	a.Get("/blog/:name/view", emptyHandle)
+	if !a.Router().Build() {
+		t.Fatal("different suffixes should not conflict")
+	}
+
+	// /blog/:name 永远不会被匹配
+	a = app.NewApp(strict)
+	a.Get("/blog/:id", emptyHandle)
+	a.Get("/blog/:name", emptyHandle)
+	if a.Router().Build() {
+		t.Fatal("shadowed dynamic route should be reported")
+	}
+
+	// 重复注册
+	a = app.NewApp(strict)
+	a.Get("/blog/list", emptyHandle)
+	a.Get("/blog/list/", emptyHandle)
+	if a.Router().Build() {
+		t.Fatal("duplicate route should be reported")
+	}
+}
+
+func TestRouterConflictsPriority(t *testing.T) {
+	a := app.NewApp()
+	a.Get("/blog/:id", func(ctx zeroapi.Context) { ctx.Text("id " + ctx.Dynamic("id")) })
+	a.Get("/blog/:name", func(ctx zeroapi.Context) { ctx.Text("name " + ctx.Dynamic("name")) })
+	a.Get("/list", func(ctx zeroapi.Context) { ctx.Text("first") })
+	a.Get("/list", func(ctx zeroapi.Context) { ctx.Text("second") })
+
+	// 默认只记录日志
+	if !a.Router().Build() {
+		t.Fatal("conflicts should not fail the build by default")
+	}
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		// 参数名称不同但约束相同时先注册的生效
+		{"/blog/7", "id 7"},
+		// 重复注册时后注册的生效
+		{"/list", "second"},
+	}
+
+	for _, tt := range tests {
+		res := httptest.NewRecorder()
+		a.Server().ServeHTTP(res, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if res.Body.String() != tt.body {
+			t.Fatalf("%s: expect %q, got %q", tt.path, tt.body, res.Body.String())
+		}
+	}
+}
+
+func TestRouterOptionalConflicts(t *testing.T) {
+	a := app.NewApp(app.WithRouterOptions(router.WithStrictRoutes()))
+	a.Get("/posts/:id?", emptyHandle)
+	a.Get("/posts", emptyHandle)
+
+	if a.Router().Build() {
+		t.Fatal("expected conflict between /posts/:id? and /posts")
+	}
+}
+
+func TestRouterConstraints(t *testing.T) {
+	a := app.NewApp()
+	r := a.Router()
+
+	r.RegisterRouterValidator("even", func(s string) bool { return len(s) > 0 && (s[len(s)-1]-'0')%2 == 0 })
+	r.RegisterConstraint("prefix", func(arg string) (zeroapi.RouterValidator, error) {
+		return func(s string) bool { return strings.HasPrefix(s, arg) }, nil
+	})
+
+	r.Register(zeroapi.MethodGet, "/users/:id<int,min(1),max(100),even>", emptyHandle)
+	r.Register(zeroapi.MethodGet, "/users/:name<alpha,minlen(3)>", emptyHandle)
+	r.Register(zeroapi.MethodGet, "/days/:day<date>", emptyHandle)
+	r.Register(zeroapi.MethodGet, "/orders/:no<prefix(ord-),maxlen(8)>(-\\d+$)", emptyHandle)
+	if !r.Build() {
+		t.Fatal("build failed")
+	}
+
+	tests := []struct {
+		path    string
+		matched bool
+	}{
+		{"/users/42", true},
+		{"/users/43", false},
+		{"/users/0", false},
+		{"/users/102", false},
+		{"/users/abc", true},
+		{"/users/ab", false},
+		{"/days/2024-02-29", true},
+		{"/days/2023-02-29", false},
+		{"/orders/ord-12", true},
+		{"/orders/ord-x", false},
+		{"/orders/ord-12345", false},
+		{"/orders/abc-12", false},
+	}
+
+	for _, tt := range tests {
+		if handlers, _ := r.Lookup(zeroapi.MethodGet, tt.path); (handlers != nil) != tt.matched {
+			t.Fatalf("%s: expect matched %v", tt.path, tt.matched)
+		}
+	}
+
+	for _, path := range []string{"/a/:id<unknown>", "/b/:id<min(x)>", "/c/:id<int(1)>", "/d/:id<int"} {
+		b := app.NewApp()
+		b.Router().Register(zeroapi.MethodGet, path, emptyHandle)
+		if b.Router().Build() {
+			t.Fatalf("%s: expect build failed", path)
+		}
+	}
+}
+
+func TestRouterHost(t *testing.T) {
+	a := app.NewApp(app.WithRouterOptions(router.WithStrictRoutes()))
+
+	a.Get("/", func(ctx zeroapi.Context) { ctx.Text("default") })
+	a.Host("api.example.com").Get("/", func(ctx zeroapi.Context) { ctx.Text("api") })
+	a.Host("*.example.com").Get("/users/:id", func(ctx zeroapi.Context) {
+		ctx.Text(ctx.Dynamic("subdomain") + " " + ctx.Dynamic("id"))
+	})
+	a.Host(":tenant.shop.example.com").Get("/", func(ctx zeroapi.Context) { ctx.Text(ctx.Dynamic("tenant")) })
+	if !a.Router().Build() {
+		t.Fatal("build failed")
+	}
+
+	tests := []struct {
+		host string
+		path string
+		code int
+		body string
+	}{
+		{"example.org", "/", http.StatusOK, "default"},
+		{"API.example.com:8080", "/", http.StatusOK, "api"},
+		{"blog.example.com", "/users/7", http.StatusOK, "blog 7"},
+		{"blog.example.com", "/", http.StatusNotFound, ""},
+		{"example.com", "/users/7", http.StatusNotFound, ""},
+		{"acme.shop.example.com", "/", http.StatusOK, "acme"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		res := httptest.NewRecorder()
+		a.Server().ServeHTTP(res, req)
+
+		if res.Code != tt.code {
+			t.Fatalf("%s%s: expect code %d, got %d", tt.host, tt.path, tt.code, res.Code)
+		}
+		if tt.body != "" && res.Body.String() != tt.body {
+			t.Fatalf("%s%s: expect body %q, got %q", tt.host, tt.path, tt.body, res.Body.String())
+		}
+	}
+
+	a.Host("api.example.com").Get("/", emptyHandle)
+	if a.Router().Build() {
+		t.Fatal("expect host route conflict")
+	}
+}
+
+func TestRouterMount(t *testing.T) {
+	a := app.NewApp()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " users " + r.URL.RawQuery))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("index " + r.URL.Path))
+	})
+
+	a.Use(func(ctx zeroapi.Context) { ctx.SetHeader("X-App", "1") })
+	a.Mount("/admin/", mux, func(ctx zeroapi.Context) { ctx.SetHeader("X-Route", "1") })
+	a.Get("/admin/stats", func(ctx zeroapi.Context) { ctx.Text("stats") })
+	if !a.Router().Build() {
+		t.Fatal("build failed")
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/admin/users?page=2", "GET users page=2"},
+		{http.MethodPost, "/admin/users", "POST users "},
+		{http.MethodGet, "/admin", "index /"},
+		{http.MethodGet, "/admin/a/b", "index /a/b"},
+		{http.MethodGet, "/admin/stats", "stats"},
+	}
+
+	for _, tt := range tests {
+		res := httptest.NewRecorder()
+		a.Server().ServeHTTP(res, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if res.Body.String() != tt.body {
+			t.Fatalf("%s %s: expect %q, got %q", tt.method, tt.path, tt.body, res.Body.String())
+		}
+		if res.Header().Get("X-App") != "1" {
+			t.Fatalf("%s %s: app middleware not executed", tt.method, tt.path)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	a.Server().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/admin/users", nil))
+	if res.Header().Get("X-Route") != "1" {
+		t.Fatal("route middleware not executed")
+	}
+}
+
+// benchRoutes 典型 API 的路由，大部分为静态路由
+var benchRoutes = []string{
+	"/", "/health", "/metrics", "/login", "/logout",
+	"/api/v1/users", "/api/v1/users/me", "/api/v1/users/:id", "/api/v1/users/:id/orders",
+	"/api/v1/orders", "/api/v1/orders/export", "/api/v1/orders/:id", "/api/v1/orders/:id/items/:item",
+	"/api/v1/products", "/api/v1/products/search", "/api/v1/products/categories", "/api/v1/products/:id",
+	"/api/v1/settings/notifications", "/api/v1/settings/profile", "/api/v1/settings/security",
+	"/static/*filepath",
+}
+
+func benchmarkLookup(b *testing.B, path string) {
+	a := app.NewApp()
+	r := a.Router()
+	for _, route := range benchRoutes {
+		r.Register(zeroapi.MethodGet, route, emptyHandle)
+	}
+	if !r.Build() {
+		b.Fatal("build failed")
+	}
+
+	if handlers, _ := r.Lookup(zeroapi.MethodGet, path); handlers == nil {
+		b.Fatalf("%s not found", path)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Lookup(zeroapi.MethodGet, path)
+	}
+}
+
+func BenchmarkRouterLookupStatic(b *testing.B) {
+	benchmarkLookup(b, "/api/v1/settings/notifications")
+}
+
+func BenchmarkRouterLookupStaticShort(b *testing.B) {
+	benchmarkLookup(b, "/health")
+}
+
+func BenchmarkRouterLookupDynamic(b *testing.B) {
+	benchmarkLookup(b, "/api/v1/orders/1001/items/2")
+}
@@ -0,0 +1,57 @@
+package scim
+
+import (
+	"strconv"
+)
+
+const (
+	// ScimTypeInvalidFilter 过滤条件错误
+	ScimTypeInvalidFilter = "invalidFilter"
+
+	// ScimTypeInvalidSyntax 请求格式错误
+	ScimTypeInvalidSyntax = "invalidSyntax"
+
+	// ScimTypeInvalidPath PATCH 路径错误
+	ScimTypeInvalidPath = "invalidPath"
+
+	// ScimTypeInvalidValue 值错误
+	ScimTypeInvalidValue = "invalidValue"
+
+	// ScimTypeNoTarget PATCH 路径没有匹配的属性
+	ScimTypeNoTarget = "noTarget"
+
+	// ScimTypeUniqueness 违反唯一性约束，例如 userName 已存在
+	ScimTypeUniqueness = "uniqueness"
+
+	// ScimTypeMutability 修改了只读属性
+	ScimTypeMutability = "mutability"
+)
+
+// Error SCIM 错误，Provider 返回该错误时按照 Status 响应，其它错误响应 500
+type Error struct {
+	// Status HTTP 状态码
+	Status int
+
+	// ScimType 错误类型，见 ScimTypeXXX，可以为空
+	ScimType string
+
+	// Detail 错误描述
+	Detail string
+}
+
+// NewError 创建 SCIM 错误
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{Status: status, ScimType: scimType, Detail: detail}
+}
+
+func (e *Error) Error() string {
+	return "scim: " + strconv.Itoa(e.Status) + " " + e.ScimType + " " + e.Detail
+}
+
+var (
+	// ErrNotFound 资源不存在
+	ErrNotFound = NewError(404, "", "resource not found")
+
+	// ErrConflict 资源已存在，例如 userName 重复
+	ErrConflict = NewError(409, ScimTypeUniqueness, "resource already exists")
+)
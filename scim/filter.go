@@ -0,0 +1,372 @@
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Filter 过滤条件，见 RFC 7644 3.4.2.2
+// Match 用于在内存中过滤，也可以通过类型断言遍历语法树，转换为数据库查询
+type Filter interface {
+	Match(r Resource) bool
+}
+
+// LogicalFilter and, or
+type LogicalFilter struct {
+	// Op and 或者 or
+	Op string
+
+	Left, Right Filter
+}
+
+// Match ..
+func (f *LogicalFilter) Match(r Resource) bool {
+	if f.Op == "and" {
+		return f.Left.Match(r) && f.Right.Match(r)
+	}
+	return f.Left.Match(r) || f.Right.Match(r)
+}
+
+// NotFilter not
+type NotFilter struct {
+	Filter Filter
+}
+
+// Match ..
+func (f *NotFilter) Match(r Resource) bool {
+	return !f.Filter.Match(r)
+}
+
+// AttrFilter 属性比较，例如 userName eq "zero"，name.familyName sw "Z"，title pr
+type AttrFilter struct {
+	// Path 属性路径，例如 userName, name.familyName, emails.value
+	Path string
+
+	// Op 比较操作: eq, ne, co, sw, ew, gt, ge, lt, le, pr
+	Op string
+
+	// Value 比较的值，string, float64, bool 或者 nil，Op 为 pr 时没有值
+	Value interface{}
+}
+
+// Match 多值属性中任意一个值满足条件即可，ne 表示所有的值都不相等
+func (f *AttrFilter) Match(r Resource) bool {
+	values := resolve(r, f.Path)
+
+	switch f.Op {
+	case "pr":
+		for _, v := range values {
+			if present(v) {
+				return true
+			}
+		}
+		return false
+	case "ne":
+		return !(&AttrFilter{Path: f.Path, Op: "eq", Value: f.Value}).Match(r)
+	}
+
+	if f.Op == "eq" && f.Value == nil {
+		return len(values) == 0
+	}
+
+	for _, v := range values {
+		if compare(v, f.Op, f.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValuePathFilter 多值属性的元素过滤，例如 emails[type eq "work" and value co "@example.com"]
+type ValuePathFilter struct {
+	// Attr 多值属性，例如 emails
+	Attr string
+
+	// Filter 对每一个元素的过滤条件
+	Filter Filter
+}
+
+// Match 任意一个元素满足条件即可
+func (f *ValuePathFilter) Match(r Resource) bool {
+	for _, elem := range resolve(r, f.Attr) {
+		if m, ok := elem.(map[string]interface{}); ok && f.Filter.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFilter 解析过滤条件，例如 userName eq "zero" and (emails[type eq "work"] or title pr)
+func ParseFilter(filter string) (Filter, error) {
+	p := &filterParser{tokens: tokenize(filter)}
+	if len(p.tokens) == 0 {
+		return nil, invalidFilter("empty filter")
+	}
+
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, invalidFilter("unexpected " + p.tokens[p.pos])
+	}
+
+	return f, nil
+}
+
+func invalidFilter(detail string) error {
+	return NewError(400, ScimTypeInvalidFilter, detail)
+}
+
+// tokenize 拆分为 ( ) [ ] 字符串以及其它单词，字符串保留双引号
+func tokenize(s string) []string {
+	var tokens []string
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				// 没有结束的双引号，交给 parseValue 报错
+				tokens = append(tokens, s[i:])
+				return tokens
+			}
+			tokens = append(tokens, s[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\r\n()[]\"", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+
+	return tokens
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *filterParser) expect(token string) error {
+	if t := p.next(); t != token {
+		return invalidFilter("expect " + token + ", got " + t)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalFilter{Op: "or", Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalFilter{Op: "and", Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseFactor() (Filter, error) {
+	switch t := p.peek(); {
+	case strings.EqualFold(t, "not"):
+		p.next()
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &NotFilter{Filter: f}, nil
+	case t == "(":
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case t == "" || t == ")" || t == "[" || t == "]" || strings.HasPrefix(t, `"`):
+		return nil, invalidFilter("expect attribute, got " + t)
+	}
+
+	path := p.next()
+
+	if p.peek() == "[" {
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &ValuePathFilter{Attr: path, Filter: f}, nil
+	}
+
+	op := strings.ToLower(p.next())
+	switch op {
+	case "pr":
+		return &AttrFilter{Path: path, Op: op}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+		value, err := parseValue(p.next())
+		if err != nil {
+			return nil, err
+		}
+		return &AttrFilter{Path: path, Op: op, Value: value}, nil
+	}
+
+	return nil, invalidFilter("invalid operator " + op)
+}
+
+// parseValue 解析比较的值: 字符串，数字，true, false, null
+func parseValue(token string) (interface{}, error) {
+	switch strings.ToLower(token) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	if strings.HasPrefix(token, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(token), &s); err != nil {
+			return nil, invalidFilter("invalid string " + token)
+		}
+		return s, nil
+	}
+
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, invalidFilter("invalid value " + token)
+	}
+	return f, nil
+}
+
+// compare 比较 v 与过滤条件中的值，字符串不区分大小写
+func compare(v interface{}, op string, expect interface{}) bool {
+	switch e := expect.(type) {
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		s, e = strings.ToLower(s), strings.ToLower(e)
+		switch op {
+		case "eq":
+			return s == e
+		case "co":
+			return strings.Contains(s, e)
+		case "sw":
+			return strings.HasPrefix(s, e)
+		case "ew":
+			return strings.HasSuffix(s, e)
+		case "gt":
+			return s > e
+		case "ge":
+			return s >= e
+		case "lt":
+			return s < e
+		case "le":
+			return s <= e
+		}
+	case float64:
+		n, ok := v.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return n == e
+		case "gt":
+			return n > e
+		case "ge":
+			return n >= e
+		case "lt":
+			return n < e
+		case "le":
+			return n <= e
+		}
+	case bool:
+		b, ok := v.(bool)
+		return ok && op == "eq" && b == e
+	}
+
+	return false
+}
+
+func present(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case string:
+		return x != ""
+	case []interface{}:
+		return len(x) > 0
+	case map[string]interface{}:
+		return len(x) > 0
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package scim
+
+// config SCIM 配置
+type config struct {
+	// maxResults 列表每页最多返回的资源数
+	maxResults int
+
+	// maxBodySize 请求体最大字节数
+	maxBodySize int64
+}
+
+func defaultConfig() *config {
+	return &config{
+		maxResults:  100,
+		maxBodySize: 1 << 20,
+	}
+}
+
+// Option SCIM 配置选项
+type Option func(config *config)
+
+// WithMaxResults 设置列表每页最多返回的资源数，默认 100
+func WithMaxResults(maxResults int) Option {
+	return func(config *config) {
+		if maxResults > 0 {
+			config.maxResults = maxResults
+		}
+	}
+}
+
+// WithMaxBodySize 设置请求体最大字节数，默认 1M
+func WithMaxBodySize(maxBodySize int64) Option {
+	return func(config *config) {
+		if maxBodySize > 0 {
+			config.maxBodySize = maxBodySize
+		}
+	}
+}
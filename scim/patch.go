@@ -0,0 +1,278 @@
+package scim
+
+import (
+	"reflect"
+	"strings"
+)
+
+// PatchRequest PATCH 请求，见 RFC 7644 3.5.2
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation 一个修改操作
+type PatchOperation struct {
+	// Op add, remove, replace，不区分大小写
+	Op string `json:"op"`
+
+	// Path 属性路径，例如 title, name.familyName, emails[type eq "work"].value
+	Path string `json:"path,omitempty"`
+
+	Value interface{} `json:"value,omitempty"`
+}
+
+// patchPath 解析后的 PATCH 路径
+type patchPath struct {
+	// schema 扩展 schema，为空表示核心属性
+	schema string
+
+	// attr 属性名
+	attr string
+
+	// filter 多值属性的元素过滤条件
+	filter Filter
+
+	// sub 子属性
+	sub string
+}
+
+func invalidPath(detail string) error {
+	return NewError(400, ScimTypeInvalidPath, detail)
+}
+
+func parsePatchPath(path string) (*patchPath, error) {
+	p := &patchPath{}
+
+	valueFilter := ""
+	if i := strings.Index(path, "["); i >= 0 {
+		j := strings.LastIndex(path, "]")
+		if j < i {
+			return nil, invalidPath("invalid path " + path)
+		}
+		valueFilter = path[i+1 : j]
+		p.sub = strings.TrimPrefix(path[j+1:], ".")
+		path = path[:i]
+	}
+
+	schema, attrs := splitPath(path)
+	p.schema = schema
+
+	switch {
+	case len(attrs) == 1:
+		p.attr = attrs[0]
+	case len(attrs) == 2 && valueFilter == "":
+		p.attr, p.sub = attrs[0], attrs[1]
+	default:
+		return nil, invalidPath("invalid path " + path)
+	}
+
+	if p.attr == "" {
+		return nil, invalidPath("invalid path " + path)
+	}
+
+	if valueFilter != "" {
+		f, err := ParseFilter(valueFilter)
+		if err != nil {
+			return nil, invalidPath("invalid filter " + valueFilter)
+		}
+		p.filter = f
+	}
+
+	return p, nil
+}
+
+// Apply 按顺序执行 PATCH 操作，任意一个操作失败时返回错误，r 不会被修改
+func Apply(r Resource, operations []PatchOperation) (Resource, error) {
+	out := r.clone()
+
+	for _, op := range operations {
+		if err := apply(out, op); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func apply(r Resource, op PatchOperation) error {
+	name := strings.ToLower(op.Op)
+	if name != "add" && name != "remove" && name != "replace" {
+		return NewError(400, ScimTypeInvalidSyntax, "invalid op "+op.Op)
+	}
+
+	if op.Path == "" {
+		if name == "remove" {
+			return NewError(400, ScimTypeNoTarget, "remove requires path")
+		}
+
+		values, ok := op.Value.(map[string]interface{})
+		if !ok {
+			return NewError(400, ScimTypeInvalidValue, "value should be an object")
+		}
+
+		for k, v := range values {
+			if ext, ok := v.(map[string]interface{}); ok && strings.HasPrefix(strings.ToLower(k), "urn:") {
+				// 扩展 schema 的所有属性，例如 {"urn:...:enterprise:2.0:User": {"department": "R&D"}}
+				c := child(r, k, true)
+				for kk, vv := range ext {
+					set(c, kk, name, vv)
+				}
+				continue
+			}
+
+			if err := apply(r, PatchOperation{Op: name, Path: k, Value: v}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	p, err := parsePatchPath(op.Path)
+	if err != nil {
+		return err
+	}
+
+	container := map[string]interface{}(r)
+	if p.schema != "" {
+		// 扩展属性，例如 urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department
+		container = child(container, p.schema, name != "remove")
+		if container == nil {
+			return nil
+		}
+	}
+
+	if p.filter != nil {
+		return applyFilter(container, p, name, op.Value)
+	}
+
+	if p.sub != "" {
+		key, _ := getKey(container, p.attr)
+		if a, ok := container[key].([]interface{}); ok {
+			// 没有过滤条件时修改所有元素的子属性
+			for _, elem := range a {
+				if m, ok := elem.(map[string]interface{}); ok {
+					set(m, p.sub, name, op.Value)
+				}
+			}
+			return nil
+		}
+
+		m := child(container, p.attr, name != "remove")
+		if m != nil {
+			set(m, p.sub, name, op.Value)
+		}
+		return nil
+	}
+
+	set(container, p.attr, name, op.Value)
+	return nil
+}
+
+// applyFilter 修改多值属性中满足条件的元素，例如 members[value eq "1001"]
+func applyFilter(container map[string]interface{}, p *patchPath, op string, value interface{}) error {
+	key, _ := getKey(container, p.attr)
+	elems, _ := container[key].([]interface{})
+
+	matched := false
+	out := elems[:0:0]
+	for _, elem := range elems {
+		m, ok := elem.(map[string]interface{})
+		if !ok || !p.filter.Match(m) {
+			out = append(out, elem)
+			continue
+		}
+
+		matched = true
+		switch {
+		case p.sub != "":
+			set(m, p.sub, op, value)
+		case op == "remove":
+			continue
+		case op == "replace":
+			if v, ok := value.(map[string]interface{}); ok {
+				m = v
+			}
+		default:
+			if v, ok := value.(map[string]interface{}); ok {
+				for k, vv := range v {
+					set(m, k, "replace", vv)
+				}
+			}
+		}
+		out = append(out, m)
+	}
+
+	if !matched && op != "remove" {
+		// 没有满足条件的元素时，根据简单的 eq 条件创建，例如 emails[type eq "work"].value
+		f, ok := p.filter.(*AttrFilter)
+		if !ok || f.Op != "eq" || p.sub == "" {
+			return NewError(400, ScimTypeNoTarget, "no value matches "+p.attr)
+		}
+		out = append(out, map[string]interface{}{f.Path: f.Value, p.sub: value})
+	}
+
+	container[key] = out
+	return nil
+}
+
+// child 获取子对象，create 为 true 时不存在则创建
+func child(m map[string]interface{}, name string, create bool) map[string]interface{} {
+	key, _ := getKey(m, name)
+	if c, ok := m[key].(map[string]interface{}); ok {
+		return c
+	}
+
+	if !create {
+		return nil
+	}
+
+	c := make(map[string]interface{})
+	m[key] = c
+	return c
+}
+
+// set 修改属性，add 到多值属性时追加不重复的元素
+func set(m map[string]interface{}, name, op string, value interface{}) {
+	key, exist := getKey(m, name)
+
+	switch op {
+	case "remove":
+		delete(m, key)
+		return
+	case "add":
+		if a, ok := m[key].([]interface{}); ok && exist {
+			values, ok := value.([]interface{})
+			if !ok {
+				values = []interface{}{value}
+			}
+			for _, v := range values {
+				if !contains(a, v) {
+					a = append(a, v)
+				}
+			}
+			m[key] = a
+			return
+		}
+
+		if c, ok := m[key].(map[string]interface{}); ok && exist {
+			if v, ok := value.(map[string]interface{}); ok {
+				for k, vv := range v {
+					set(c, k, op, vv)
+				}
+				return
+			}
+		}
+	}
+
+	m[key] = value
+}
+
+func contains(a []interface{}, v interface{}) bool {
+	for _, e := range a {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package scim
+
+import (
+	"strings"
+)
+
+// Resource 资源，例如 User, Group，结构与 JSON 一致，属性名不区分大小写
+type Resource map[string]interface{}
+
+// ID 获取资源 id
+func (r Resource) ID() string {
+	id, _ := r.Get("id").(string)
+	return id
+}
+
+// Get 获取属性值，属性名不区分大小写，不存在时返回 nil
+// path 可以包含子属性以及扩展 schema，例如 name.familyName
+func (r Resource) Get(path string) interface{} {
+	values := resolve(r, path)
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// clone 深拷贝，PATCH 失败时不影响原资源
+func (r Resource) clone() Resource {
+	return Resource(cloneValue(map[string]interface{}(r)).(map[string]interface{}))
+}
+
+func cloneValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			m[k] = cloneValue(v)
+		}
+		return m
+	case Resource:
+		return cloneValue(map[string]interface{}(x))
+	case []interface{}:
+		a := make([]interface{}, len(x))
+		for i, v := range x {
+			a[i] = cloneValue(v)
+		}
+		return a
+	}
+	return v
+}
+
+// coreSchemas 核心 schema 的属性直接保存在资源中，扩展 schema 的属性保存在以 schema 为键的对象中
+var coreSchemas = []string{SchemaUser, SchemaGroup}
+
+func isCoreSchema(schema string) bool {
+	for _, s := range coreSchemas {
+		if strings.EqualFold(s, schema) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPath 拆分属性路径，例如
+// name.familyName -> "", [name familyName]
+// urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value -> urn:...:User, [manager value]
+// 核心 schema 前缀会被去掉
+func splitPath(path string) (string, []string) {
+	schema := ""
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		i := strings.LastIndex(path, ":")
+		schema, path = path[:i], path[i+1:]
+		if isCoreSchema(schema) {
+			schema = ""
+		}
+	}
+
+	return schema, strings.Split(path, ".")
+}
+
+// getKey 查找属性名对应的键，不区分大小写
+func getKey(m map[string]interface{}, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+
+	return name, false
+}
+
+func getAttr(m map[string]interface{}, name string) (interface{}, bool) {
+	key, ok := getKey(m, name)
+	if !ok {
+		return nil, false
+	}
+	return m[key], true
+}
+
+// resolve 获取路径对应的所有值，多值属性会被展开
+func resolve(r map[string]interface{}, path string) []interface{} {
+	schema, attrs := splitPath(path)
+
+	current := []interface{}{r}
+	if schema != "" {
+		v, ok := getAttr(r, schema)
+		if !ok {
+			return nil
+		}
+		current = []interface{}{v}
+	}
+
+	for _, attr := range attrs {
+		var next []interface{}
+		for _, c := range current {
+			m, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			v, ok := getAttr(m, attr)
+			if !ok || v == nil {
+				continue
+			}
+
+			if a, ok := v.([]interface{}); ok {
+				next = append(next, a...)
+			} else {
+				next = append(next, v)
+			}
+		}
+		current = next
+	}
+
+	return current
+}
@@ -0,0 +1,363 @@
+// Package scim SCIM 2.0 服务端，提供 Users, Groups 资源的增删改查、过滤、PATCH 以及分页
+// 见 RFC 7643, RFC 7644，资源的存储由 Provider 实现
+//
+// 示例:
+// scim.Register(app.Group("/scim/v2").Use(bearerAuth), users, groups)
+package scim
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// SchemaUser 用户
+	SchemaUser = "urn:ietf:params:scim:schemas:core:2.0:User"
+
+	// SchemaGroup 用户组
+	SchemaGroup = "urn:ietf:params:scim:schemas:core:2.0:Group"
+
+	// SchemaEnterpriseUser 企业用户扩展
+	SchemaEnterpriseUser = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+
+	// SchemaListResponse 列表响应
+	SchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+
+	// SchemaPatchOp PATCH 请求
+	SchemaPatchOp = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+
+	// SchemaError 错误响应
+	SchemaError = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// SchemaServiceProviderConfig 服务配置
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+	// SchemaResourceType 资源类型
+	SchemaResourceType = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+
+	// ContentType SCIM 响应的 Content-Type
+	ContentType = "application/scim+json;charset=utf-8"
+)
+
+// Query 列表查询条件
+type Query struct {
+	// Filter 过滤条件，没有时为 nil
+	Filter Filter
+
+	// StartIndex 第一个资源的序号，从 1 开始
+	StartIndex int
+
+	// Count 最多返回的资源数
+	Count int
+}
+
+// Provider 资源存储
+type Provider interface {
+	// List 查询资源，返回当前页的资源以及满足条件的资源总数
+	List(ctx zeroapi.Context, q Query) ([]Resource, int, error)
+
+	// Get 获取资源，不存在时返回 ErrNotFound
+	Get(ctx zeroapi.Context, id string) (Resource, error)
+
+	// Create 创建资源，需要生成 id 与 meta，userName 等重复时返回 ErrConflict
+	Create(ctx zeroapi.Context, r Resource) (Resource, error)
+
+	// Replace 替换资源，PATCH 也通过 Replace 保存修改后的资源
+	Replace(ctx zeroapi.Context, id string, r Resource) (Resource, error)
+
+	// Delete 删除资源，不存在时返回 ErrNotFound
+	Delete(ctx zeroapi.Context, id string) error
+}
+
+// Register 在 g 中注册 SCIM 路由，groups 为 nil 时不注册 /Groups
+// g 一般需要添加鉴权中间件，例如校验 Bearer Token
+func Register(g zeroapi.Group, users, groups Provider, opts ...Option) {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	resourceTypes := []interface{}{resourceType("User", "/Users", SchemaUser)}
+	register(g, "/Users", SchemaUser, users, config)
+
+	if groups != nil {
+		resourceTypes = append(resourceTypes, resourceType("Group", "/Groups", SchemaGroup))
+		register(g, "/Groups", SchemaGroup, groups, config)
+	}
+
+	g.Get("/ServiceProviderConfig", func(ctx zeroapi.Context) {
+		write(ctx, http.StatusOK, serviceProviderConfig(config))
+	})
+
+	g.Get("/ResourceTypes", func(ctx zeroapi.Context) {
+		write(ctx, http.StatusOK, listResponse(resourceTypes, len(resourceTypes), 1))
+	})
+}
+
+func register(g zeroapi.Group, path, schema string, provider Provider, config *config) {
+	h := &handler{schema: schema, provider: provider, config: config}
+
+	g.Get(path, h.list)
+	g.Post(path, h.create)
+	g.Get(path+"/:id", h.get)
+	g.Put(path+"/:id", h.replace)
+	g.Patch(path+"/:id", h.patch)
+	g.Delete(path+"/:id", h.delete)
+}
+
+type handler struct {
+	schema   string
+	provider Provider
+	config   *config
+}
+
+func (h *handler) list(ctx zeroapi.Context) {
+	q := Query{StartIndex: 1, Count: h.config.maxResults}
+
+	if filter := ctx.Query("filter"); filter != "" {
+		f, err := ParseFilter(filter)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		q.Filter = f
+	}
+
+	if v := ctx.Query("startIndex"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 1 {
+			q.StartIndex = n
+		}
+	}
+
+	if v := ctx.Query("count"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			switch {
+			case n < 0:
+				q.Count = 0
+			case n < h.config.maxResults:
+				q.Count = n
+			}
+		}
+	}
+
+	resources, total, err := h.provider.List(ctx, q)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	items := make([]interface{}, len(resources))
+	for i, r := range resources {
+		items[i] = h.withSchema(r)
+	}
+
+	write(ctx, http.StatusOK, listResponse(items, total, q.StartIndex))
+}
+
+func (h *handler) get(ctx zeroapi.Context) {
+	r, err := h.provider.Get(ctx, ctx.Dynamic("id"))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	write(ctx, http.StatusOK, h.withSchema(r))
+}
+
+func (h *handler) create(ctx zeroapi.Context) {
+	r, err := h.readResource(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	// id 由服务端生成
+	delete(r, "id")
+
+	created, err := h.provider.Create(ctx, r)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if location, ok := created.Get("meta.location").(string); ok && location != "" {
+		ctx.SetHeader("Location", location)
+	}
+
+	write(ctx, http.StatusCreated, h.withSchema(created))
+}
+
+func (h *handler) replace(ctx zeroapi.Context) {
+	r, err := h.readResource(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	id := ctx.Dynamic("id")
+	if _, err := h.provider.Get(ctx, id); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	r["id"] = id
+	h.save(ctx, id, r)
+}
+
+func (h *handler) patch(ctx zeroapi.Context) {
+	var req PatchRequest
+	if err := h.readBody(ctx, &req); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if !hasSchema(req.Schemas, SchemaPatchOp) || len(req.Operations) == 0 {
+		writeError(ctx, NewError(http.StatusBadRequest, ScimTypeInvalidSyntax, "invalid patch request"))
+		return
+	}
+
+	id := ctx.Dynamic("id")
+	r, err := h.provider.Get(ctx, id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	patched, err := Apply(r, req.Operations)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	// id 不能修改
+	patched["id"] = id
+	h.save(ctx, id, patched)
+}
+
+func (h *handler) save(ctx zeroapi.Context, id string, r Resource) {
+	saved, err := h.provider.Replace(ctx, id, r)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	write(ctx, http.StatusOK, h.withSchema(saved))
+}
+
+func (h *handler) delete(ctx zeroapi.Context) {
+	if err := h.provider.Delete(ctx, ctx.Dynamic("id")); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.SetHTTPCode(http.StatusNoContent)
+}
+
+func (h *handler) readResource(ctx zeroapi.Context) (Resource, error) {
+	r := make(Resource)
+	if err := h.readBody(ctx, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (h *handler) readBody(ctx zeroapi.Context, dst interface{}) error {
+	body, err := ioutil.ReadAll(io.LimitReader(ctx.Request().Body, h.config.maxBodySize+1))
+	if err != nil {
+		return err
+	}
+
+	if int64(len(body)) > h.config.maxBodySize {
+		return NewError(http.StatusRequestEntityTooLarge, "", "request body too large")
+	}
+
+	if err := ctx.App().JSONCodec().Unmarshal(body, dst); err != nil {
+		return NewError(http.StatusBadRequest, ScimTypeInvalidSyntax, "invalid json")
+	}
+
+	return nil
+}
+
+// withSchema Provider 没有设置 schemas 时，使用资源的核心 schema
+func (h *handler) withSchema(r Resource) Resource {
+	if _, ok := r["schemas"]; !ok {
+		r["schemas"] = []string{h.schema}
+	}
+	return r
+}
+
+func hasSchema(schemas []string, schema string) bool {
+	for _, s := range schemas {
+		if strings.EqualFold(s, schema) {
+			return true
+		}
+	}
+	return false
+}
+
+func listResponse(resources []interface{}, total, startIndex int) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":      []string{SchemaListResponse},
+		"totalResults": total,
+		"startIndex":   startIndex,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	}
+}
+
+func resourceType(name, endpoint, schema string) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":  []string{SchemaResourceType},
+		"id":       name,
+		"name":     name,
+		"endpoint": endpoint,
+		"schema":   schema,
+	}
+}
+
+func serviceProviderConfig(config *config) map[string]interface{} {
+	supported := func(b bool) map[string]interface{} {
+		return map[string]interface{}{"supported": b}
+	}
+
+	return map[string]interface{}{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": config.maxResults},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+	}
+}
+
+func write(ctx zeroapi.Context, status int, obj interface{}) {
+	ctx.SetHeader("Content-Type", ContentType)
+	ctx.SetHTTPCode(status)
+	ctx.Map(obj)
+}
+
+// writeError 响应 SCIM 错误，非 *Error 的错误记录日志并响应 500
+func writeError(ctx zeroapi.Context, err error) {
+	e, ok := err.(*Error)
+	if !ok {
+		ctx.App().Logger().Errorf("scim: %s %s: %s", ctx.Method(), ctx.Path(), err.Error())
+		e = NewError(http.StatusInternalServerError, "", "internal server error")
+	}
+
+	body := map[string]interface{}{
+		"schemas": []string{SchemaError},
+		"status":  strconv.Itoa(e.Status),
+		"detail":  e.Detail,
+	}
+	if e.ScimType != "" {
+		body["scimType"] = e.ScimType
+	}
+
+	write(ctx, e.Status, body)
+}
@@ -0,0 +1,184 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/scim"
+)
+
+// memory 内存中的 Provider
+type memory struct {
+	seq       int
+	resources map[string]scim.Resource
+}
+
+func newMemory() *memory {
+	return &memory{resources: make(map[string]scim.Resource)}
+}
+
+func (m *memory) List(ctx zeroapi.Context, q scim.Query) ([]scim.Resource, int, error) {
+	var matched []scim.Resource
+	for _, r := range m.resources {
+		if q.Filter == nil || q.Filter.Match(r) {
+			matched = append(matched, r)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID() < matched[j].ID() })
+
+	start := q.StartIndex - 1
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + q.Count
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], len(matched), nil
+}
+
+func (m *memory) Get(ctx zeroapi.Context, id string) (scim.Resource, error) {
+	if r, ok := m.resources[id]; ok {
+		return r, nil
+	}
+	return nil, scim.ErrNotFound
+}
+
+func (m *memory) Create(ctx zeroapi.Context, r scim.Resource) (scim.Resource, error) {
+	for _, exist := range m.resources {
+		if strings.EqualFold(exist.Get("userName").(string), r.Get("userName").(string)) {
+			return nil, scim.ErrConflict
+		}
+	}
+	m.seq++
+	r["id"] = strconv.Itoa(m.seq)
+	m.resources[r.ID()] = r
+	return r, nil
+}
+
+func (m *memory) Replace(ctx zeroapi.Context, id string, r scim.Resource) (scim.Resource, error) {
+	m.resources[id] = r
+	return r, nil
+}
+
+func (m *memory) Delete(ctx zeroapi.Context, id string) error {
+	if _, ok := m.resources[id]; !ok {
+		return scim.ErrNotFound
+	}
+	delete(m.resources, id)
+	return nil
+}
+
+func do(a zeroapi.App, method, path, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/scim+json")
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+
+	var out map[string]interface{}
+	json.NewDecoder(w.Result().Body).Decode(&out)
+	return w.Code, out
+}
+
+func TestSCIM(t *testing.T) {
+	a := app.NewApp()
+	scim.Register(a.Group("/scim/v2"), newMemory(), nil, scim.WithMaxResults(2))
+	a.Router().Build()
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		code, _ := do(a, http.MethodPost, "/scim/v2/Users", `{"schemas":["`+scim.SchemaUser+`"],"userName":"`+name+`","active":true,
+			"emails":[{"type":"work","value":"`+name+`@example.com"}]}`)
+		if code != http.StatusCreated {
+			t.Fatalf("create %s failed: %d", name, code)
+		}
+	}
+
+	if code, body := do(a, http.MethodPost, "/scim/v2/Users", `{"userName":"Alice"}`); code != http.StatusConflict || body["scimType"] != "uniqueness" {
+		t.Fatalf("expect conflict: %d, %v", code, body)
+	}
+
+	// 分页，每页最多 2 个
+	code, body := do(a, http.MethodGet, "/scim/v2/Users?startIndex=2&count=10", "")
+	if code != http.StatusOK || body["totalResults"] != 3.0 || body["itemsPerPage"] != 2.0 || body["startIndex"] != 2.0 {
+		t.Fatalf("invalid list: %d, %v", code, body)
+	}
+
+	// 过滤
+	_, body = do(a, http.MethodGet, `/scim/v2/Users?filter=`+url.QueryEscape(`emails[type eq "work" and value sw "BOB"]`), "")
+	if body["totalResults"] != 1.0 {
+		t.Fatalf("invalid filter result: %v", body)
+	}
+
+	if code, body = do(a, http.MethodGet, `/scim/v2/Users?filter=`+url.QueryEscape(`userName eq`), ""); code != http.StatusBadRequest || body["scimType"] != "invalidFilter" {
+		t.Fatalf("expect invalid filter: %d, %v", code, body)
+	}
+
+	// PATCH
+	code, body = do(a, http.MethodPatch, "/scim/v2/Users/2", `{"schemas":["`+scim.SchemaPatchOp+`"],"Operations":[
+		{"op":"Replace","path":"active","value":false},
+		{"op":"add","path":"name.givenName","value":"Bob"},
+		{"op":"replace","path":"emails[type eq \"home\"].value","value":"bob@home.com"},
+		{"op":"remove","path":"emails[type eq \"work\"]"},
+		{"op":"add","value":{"`+scim.SchemaEnterpriseUser+`":{"department":"R&D"}}}
+	]}`)
+	if code != http.StatusOK {
+		t.Fatalf("patch failed: %d, %v", code, body)
+	}
+
+	r := scim.Resource(body)
+	emails, _ := r.Get("emails").(map[string]interface{})
+	if r.Get("active") != false || r.Get("name.givenName") != "Bob" || emails["value"] != "bob@home.com" ||
+		r.Get(scim.SchemaEnterpriseUser+":department") != "R&D" || len(body["emails"].([]interface{})) != 1 {
+		t.Fatalf("invalid patched resource: %v", body)
+	}
+
+	if code, _ = do(a, http.MethodDelete, "/scim/v2/Users/2", ""); code != http.StatusNoContent {
+		t.Fatalf("delete failed: %d", code)
+	}
+	if code, body = do(a, http.MethodGet, "/scim/v2/Users/2", ""); code != http.StatusNotFound || body["status"] != "404" {
+		t.Fatalf("expect not found: %d, %v", code, body)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	r := scim.Resource{
+		"userName": "Zero",
+		"title":    "",
+		"meta":     map[string]interface{}{"lastModified": "2021-06-01T00:00:00Z"},
+		"emails":   []interface{}{map[string]interface{}{"type": "work", "value": "zero@example.com"}},
+	}
+
+	cases := map[string]bool{
+		`userName eq "zero"`:                                          true,
+		`not (userName eq "zero")`:                                    false,
+		`title pr or emails.value ew ".com"`:                          true,
+		`title pr and userName sw "z"`:                                false,
+		`meta.lastModified gt "2021-01-01T00:00:00Z"`:                 true,
+		`emails[type eq "home"] or (userName ne "x" and true)`:        false,
+		`urn:ietf:params:scim:schemas:core:2.0:User:userName co "er"`: true,
+	}
+
+	for filter, expect := range cases {
+		f, err := scim.ParseFilter(filter)
+		if strings.HasSuffix(filter, "and true)") {
+			if err == nil {
+				t.Fatalf("%s: expect error", filter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", filter, err)
+		}
+		if f.Match(r) != expect {
+			t.Fatalf("%s: expect %v", filter, expect)
+		}
+	}
+}
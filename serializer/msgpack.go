@@ -0,0 +1,334 @@
+package serializer
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MsgPackEncoder MessagePack 编码器
+type MsgPackEncoder struct {
+	w   *bufio.Writer
+	buf [9]byte
+}
+
+// NewMsgPackEncoder 创建 MessagePack 编码器
+func NewMsgPackEncoder(w io.Writer) *MsgPackEncoder {
+	return &MsgPackEncoder{w: bufio.NewWriter(w)}
+}
+
+// Encode 编码并写入
+func (e *MsgPackEncoder) Encode(v interface{}) error {
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func (e *MsgPackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		return e.w.WriteByte(0xc0)
+	}
+
+	if v.Type() == timeType {
+		return e.encodeTime(v.Interface().(time.Time))
+	}
+
+	if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		return e.encodeString(string(text))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return e.w.WriteByte(0xc0)
+		}
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			return e.w.WriteByte(0xc3)
+		}
+		return e.w.WriteByte(0xc2)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.encodeUint(v.Uint())
+	case reflect.Float32:
+		e.buf[0] = 0xca
+		binary.BigEndian.PutUint32(e.buf[1:], math.Float32bits(float32(v.Float())))
+		return e.write(5)
+	case reflect.Float64:
+		e.buf[0] = 0xcb
+		binary.BigEndian.PutUint64(e.buf[1:], math.Float64bits(v.Float()))
+		return e.write(9)
+	case reflect.String:
+		return e.encodeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			return e.w.WriteByte(0xc0)
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.encodeBytes(v.Bytes())
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return e.w.WriteByte(0xc0)
+		}
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	}
+
+	return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+}
+
+func (e *MsgPackEncoder) write(n int) error {
+	_, err := e.w.Write(e.buf[:n])
+	return err
+}
+
+func (e *MsgPackEncoder) encodeInt(i int64) error {
+	switch {
+	case i >= 0:
+		return e.encodeUint(uint64(i))
+	case i >= -32:
+		return e.w.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		e.buf[0], e.buf[1] = 0xd0, byte(i)
+		return e.write(2)
+	case i >= math.MinInt16:
+		e.buf[0] = 0xd1
+		binary.BigEndian.PutUint16(e.buf[1:], uint16(i))
+		return e.write(3)
+	case i >= math.MinInt32:
+		e.buf[0] = 0xd2
+		binary.BigEndian.PutUint32(e.buf[1:], uint32(i))
+		return e.write(5)
+	}
+
+	e.buf[0] = 0xd3
+	binary.BigEndian.PutUint64(e.buf[1:], uint64(i))
+	return e.write(9)
+}
+
+func (e *MsgPackEncoder) encodeUint(u uint64) error {
+	switch {
+	case u <= 0x7f:
+		return e.w.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		e.buf[0], e.buf[1] = 0xcc, byte(u)
+		return e.write(2)
+	case u <= math.MaxUint16:
+		e.buf[0] = 0xcd
+		binary.BigEndian.PutUint16(e.buf[1:], uint16(u))
+		return e.write(3)
+	case u <= math.MaxUint32:
+		e.buf[0] = 0xce
+		binary.BigEndian.PutUint32(e.buf[1:], uint32(u))
+		return e.write(5)
+	}
+
+	e.buf[0] = 0xcf
+	binary.BigEndian.PutUint64(e.buf[1:], u)
+	return e.write(9)
+}
+
+// encodeLength 写入 str, bin, array, map 的类型与长度
+// fix: fixstr, fixarray, fixmap 的前缀，0 表示不支持
+// max: fix 类型的最大长度
+// b8: 8 位长度的类型，0 表示不支持
+func (e *MsgPackEncoder) encodeLength(n int, fix byte, max int, b8, b16, b32 byte) error {
+	switch {
+	case fix != 0 && n <= max:
+		return e.w.WriteByte(fix | byte(n))
+	case b8 != 0 && n <= math.MaxUint8:
+		e.buf[0], e.buf[1] = b8, byte(n)
+		return e.write(2)
+	case n <= math.MaxUint16:
+		e.buf[0] = b16
+		binary.BigEndian.PutUint16(e.buf[1:], uint16(n))
+		return e.write(3)
+	}
+
+	e.buf[0] = b32
+	binary.BigEndian.PutUint32(e.buf[1:], uint32(n))
+	return e.write(5)
+}
+
+func (e *MsgPackEncoder) encodeString(s string) error {
+	if err := e.encodeLength(len(s), 0xa0, 31, 0xd9, 0xda, 0xdb); err != nil {
+		return err
+	}
+	_, err := e.w.WriteString(s)
+	return err
+}
+
+func (e *MsgPackEncoder) encodeBytes(b []byte) error {
+	if err := e.encodeLength(len(b), 0, 0, 0xc4, 0xc5, 0xc6); err != nil {
+		return err
+	}
+	_, err := e.w.Write(b)
+	return err
+}
+
+func (e *MsgPackEncoder) encodeArray(v reflect.Value) error {
+	if err := e.encodeLength(v.Len(), 0x90, 15, 0, 0xdc, 0xdd); err != nil {
+		return err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *MsgPackEncoder) encodeMap(v reflect.Value) error {
+	if err := e.encodeLength(v.Len(), 0x80, 15, 0, 0xde, 0xdf); err != nil {
+		return err
+	}
+
+	keys := v.MapKeys()
+	// 字符串键排序，使编码结果稳定
+	if v.Type().Key().Kind() == reflect.String {
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	}
+
+	for _, key := range keys {
+		if err := e.encode(key); err != nil {
+			return err
+		}
+		if err := e.encode(v.MapIndex(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// msgpackField 结构体字段
+type msgpackField struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+func (e *MsgPackEncoder) encodeStruct(v reflect.Value) error {
+	fields := structFields(v.Type())
+
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		fv := v.Field(field.index)
+		if field.omitEmpty && fv.IsZero() {
+			continue
+		}
+		values = append(values, fv)
+		names = append(names, field.name)
+	}
+
+	if err := e.encodeLength(len(values), 0x80, 15, 0, 0xde, 0xdf); err != nil {
+		return err
+	}
+
+	for i, fv := range values {
+		if err := e.encodeString(names[i]); err != nil {
+			return err
+		}
+		if err := e.encode(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structFields 获取需要编码的字段，字段名称优先使用 msgpack 标签，其次 json 标签
+func structFields(t reflect.Type) []msgpackField {
+	fields := make([]msgpackField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		tag, ok := sf.Tag.Lookup("msgpack")
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "" {
+			name = sf.Name
+		}
+
+		field := msgpackField{name: name, index: i}
+		for _, opt := range parts[1:] {
+			if opt == "omitempty" {
+				field.omitEmpty = true
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// encodeTime 使用 timestamp 扩展类型(-1)编码时间
+func (e *MsgPackEncoder) encodeTime(t time.Time) error {
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+
+	if sec>>34 == 0 {
+		data := uint64(nsec)<<34 | uint64(sec)
+		if data&0xffffffff00000000 == 0 {
+			// timestamp 32
+			e.buf[0], e.buf[1] = 0xd6, 0xff
+			binary.BigEndian.PutUint32(e.buf[2:], uint32(data))
+			return e.write(6)
+		}
+
+		// timestamp 64
+		e.buf[0], e.buf[1] = 0xd7, 0xff
+		if err := e.write(2); err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint64(e.buf[:], data)
+		return e.write(8)
+	}
+
+	// timestamp 96
+	e.buf[0], e.buf[1], e.buf[2] = 0xc7, 12, 0xff
+	if err := e.write(3); err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(e.buf[:], uint32(nsec))
+	if err := e.write(4); err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint64(e.buf[:], uint64(sec))
+	return e.write(8)
+}
@@ -0,0 +1,66 @@
+package serializer_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/serializer"
+)
+
+func encode(t *testing.T, v interface{}) string {
+	var buf bytes.Buffer
+	if err := serializer.NewMsgPackEncoder(&buf).Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func TestMsgPackScalar(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, "c0"},
+		{true, "c3"},
+		{false, "c2"},
+		{1, "01"},
+		{-1, "ff"},
+		{-33, "d0df"},
+		{200, "ccc8"},
+		{65536, "ce00010000"},
+		{int64(-2147483649), "d3ffffffff7fffffff"},
+		{1.5, "cb3ff8000000000000"},
+		{float32(1.5), "ca3fc00000"},
+		{"abc", "a3616263"},
+		{strings.Repeat("a", 32), "d920" + strings.Repeat("61", 32)},
+		{[]byte{1, 2}, "c4020102"},
+		{[]int{1, 2, 3}, "93010203"},
+		{map[string]int{"b": 2, "a": 1}, "82a16101a16202"},
+		{time.Unix(1, 0), "d6ff00000001"},
+	}
+
+	for _, c := range cases {
+		if got := encode(t, c.value); got != c.want {
+			t.Fatalf("value: %v, want: %s, got: %s", c.value, c.want, got)
+		}
+	}
+}
+
+func TestMsgPackStruct(t *testing.T) {
+	type user struct {
+		ID      int    `json:"id"`
+		Name    string `msgpack:"n"`
+		Email   string `json:"email,omitempty"`
+		Ignored string `json:"-"`
+		private int
+	}
+
+	got := encode(t, user{ID: 1, Name: "z"})
+	// {"id": 1, "n": "z"}
+	if want := "82a2696401a16ea17a"; got != want {
+		t.Fatalf("want: %s, got: %s", want, got)
+	}
+}
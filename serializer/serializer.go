@@ -0,0 +1,80 @@
+// Package serializer 内置的响应序列化，通过 app.RegisterSerializer 注册后，可以使用 ctx.Serialize 输出
+//
+// 内置: JSON, XML, YAML, MsgPack，app 默认已注册
+package serializer
+
+import (
+	"encoding/xml"
+	"io"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSON 使用 JSONCodec 序列化
+func JSON(codec zeroapi.JSONCodec) zeroapi.Serializer {
+	return &jsonSerializer{codec: codec}
+}
+
+type jsonSerializer struct {
+	codec zeroapi.JSONCodec
+}
+
+func (s *jsonSerializer) ContentType() string {
+	return "application/json;charset=utf-8"
+}
+
+func (s *jsonSerializer) Encode(w io.Writer, v interface{}) error {
+	return s.codec.NewEncoder(w).Encode(v)
+}
+
+// XML 使用 encoding/xml 序列化
+func XML() zeroapi.Serializer {
+	return xmlSerializer{}
+}
+
+type xmlSerializer struct{}
+
+func (xmlSerializer) ContentType() string {
+	return "application/xml;charset=utf-8"
+}
+
+func (xmlSerializer) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// YAML 使用 gopkg.in/yaml.v3 序列化
+func YAML() zeroapi.Serializer {
+	return yamlSerializer{}
+}
+
+type yamlSerializer struct{}
+
+func (yamlSerializer) ContentType() string {
+	return "application/yaml;charset=utf-8"
+}
+
+func (yamlSerializer) Encode(w io.Writer, v interface{}) error {
+	encoder := yaml.NewEncoder(w)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// MsgPack 序列化为 MessagePack，见 https://github.com/msgpack/msgpack/blob/master/spec.md
+// 结构体编码为 map，字段名称优先使用 msgpack 标签，其次 json 标签
+func MsgPack() zeroapi.Serializer {
+	return msgpackSerializer{}
+}
+
+type msgpackSerializer struct{}
+
+func (msgpackSerializer) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackSerializer) Encode(w io.Writer, v interface{}) error {
+	return NewMsgPackEncoder(w).Encode(v)
+}
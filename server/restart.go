@@ -0,0 +1,162 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvListenFDs 热重启时传递给子进程的环境变量，值为继承的监听数量，文件描述符从 3 开始
+	EnvListenFDs = "ZEROAPI_LISTEN_FDS"
+
+	// EnvReadyFD 热重启时传递给子进程的环境变量，值为通知父进程已经就绪的管道的文件描述符
+	EnvReadyFD = "ZEROAPI_READY_FD"
+)
+
+// restartTimeout 热重启时等待子进程就绪的最长时间，超时后结束子进程
+var restartTimeout = 30 * time.Second
+
+var (
+	// ErrListenerNotFile 监听不支持导出文件描述符，无法传递给子进程
+	ErrListenerNotFile = errors.New("listener can not be passed to child process")
+
+	// ErrChildNotReady 子进程在就绪之前退出，或者等待超时
+	ErrChildNotReady = errors.New("child process not ready")
+)
+
+// fileListener 可以导出文件描述符的监听，例如 *net.TCPListener, *net.UnixListener
+type fileListener interface {
+	File() (*os.File, error)
+}
+
+// Restart 使用相同的参数启动当前程序的新进程，并将 listeners 通过文件描述符传递给新进程
+// 新进程通过 Inherited 按相同顺序取回监听，开始接收连接后通过管道通知当前进程，见 Serve
+// Restart 等待新进程就绪后返回，当前进程随后应当调用 Shutdown 优雅关闭，期间连接不会中断
+// 新进程在就绪之前退出或者超时时返回 ErrChildNotReady，当前进程继续提供服务
+func Restart(listeners ...net.Listener) (*os.Process, error) {
+	if len(listeners) == 0 {
+		return nil, ErrNoListener
+	}
+
+	files := make([]*os.File, 0, len(listeners))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, ln := range listeners {
+		fl, ok := ln.(fileListener)
+		if !ok {
+			return nil, ErrListenerNotFile
+		}
+
+		// 当前进程关闭监听时不删除 socket 文件，子进程还在使用
+		if ul, ok := ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+
+		f, err := fl.File()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	// 子进程就绪后写入管道，在此之前退出时读取到 EOF
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, EnvListenFDs+"=") && !strings.HasPrefix(kv, EnvReadyFD+"=") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env, EnvListenFDs+"="+strconv.Itoa(len(files)))
+	env = append(env, EnvReadyFD+"="+strconv.Itoa(3+len(files)))
+
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = env
+	cmd.ExtraFiles = append(files, w)
+
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	_ = r.SetReadDeadline(time.Now().Add(restartTimeout))
+	if _, err := r.Read(make([]byte, 1)); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, ErrChildNotReady
+	}
+
+	return cmd.Process, nil
+}
+
+// notifyReady 由 Restart 启动时通知父进程已经就绪，只通知一次
+func notifyReady() {
+	value := os.Getenv(EnvReadyFD)
+	if value == "" {
+		return
+	}
+	os.Unsetenv(EnvReadyFD)
+
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < 3 {
+		return
+	}
+
+	f := os.NewFile(uintptr(fd), "ready")
+	f.Write([]byte{1})
+	f.Close()
+}
+
+// Inherited 取回父进程通过 Restart 传递的监听，不是由 Restart 启动时返回 nil
+// 只能取回一次
+func Inherited() ([]net.Listener, error) {
+	value := os.Getenv(EnvListenFDs)
+	if value == "" {
+		return nil, nil
+	}
+	os.Unsetenv(EnvListenFDs)
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return nil, errors.New("invalid " + EnvListenFDs + ": " + value)
+	}
+
+	listeners := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		f := os.NewFile(uintptr(3+i), "listener"+strconv.Itoa(i))
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+
+	return listeners, nil
+}
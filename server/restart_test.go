@@ -0,0 +1,110 @@
+//go:build !windows
+// +build !windows
+
+package server_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/server"
+)
+
+// restartChild 由 Restart 启动的子进程，使用继承的监听处理一个请求后退出
+func restartChild(t *testing.T) {
+	listeners, err := server.Inherited()
+	if err != nil || len(listeners) != 1 {
+		os.Exit(2)
+	}
+	if os.Getenv(server.EnvListenFDs) != "" {
+		os.Exit(3)
+	}
+
+	done := make(chan struct{})
+	a := app.NewApp()
+	a.Get("/pid", func(ctx zeroapi.Context) {
+		ctx.Text(strconv.Itoa(os.Getpid()))
+		close(done)
+	})
+	a.Router().Build()
+	go a.Server().Serve(listeners...)
+
+	<-done
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	a.Server().Shutdown(ctx)
+	os.Exit(0)
+}
+
+func TestRestart(t *testing.T) {
+	if os.Getenv(server.EnvListenFDs) != "" {
+		restartChild(t)
+		return
+	}
+
+	ln, err := server.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=^TestRestart$"}
+	process, err := server.Restart(ln)
+	os.Args = args
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Restart 在子进程就绪后返回，父进程关闭监听后，子进程继续接收连接
+	ln.Close()
+
+	res, err := http.Get("http://" + addr + "/pid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != strconv.Itoa(process.Pid) {
+		t.Fatalf("expect response from child %d, got %s", process.Pid, body)
+	}
+
+	state, err := process.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Success() {
+		t.Fatalf("child exit: %s", state)
+	}
+
+	if _, err := server.Restart(); err != server.ErrNoListener {
+		t.Fatalf("expect ErrNoListener, got %v", err)
+	}
+}
+
+func TestRestartNotReady(t *testing.T) {
+	// 子进程在就绪之前退出
+	if os.Getenv(server.EnvListenFDs) != "" {
+		os.Exit(1)
+	}
+
+	ln, err := server.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=^TestRestartNotReady$"}
+	_, err = server.Restart(ln)
+	os.Args = args
+	if err != server.ErrChildNotReady {
+		t.Fatalf("expect ErrChildNotReady, got %v", err)
+	}
+}
@@ -1,19 +1,36 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
 	"net/http"
+	"net/http/cgi"
+	"net/http/fcgi"
+	"net/url"
 	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	zeroapi "github.com/zerogo-hub/zero-api"
 
 	"github.com/zerogo-hub/zero-helper/file"
 	graceful "github.com/zerogo-hub/zero-helper/graceful/http"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type server struct {
 	// app 应用实例
 	app zeroapi.App
 
+	// handler 处理请求，开启 H2C 时包装了 s
+	handler http.Handler
+
 	// httpServer 实际使用  graceful.Server 替代 http.Server
 	httpServer graceful.Server
 
@@ -22,12 +39,58 @@ type server struct {
 
 	// tlsKeyFile tls 私钥路径
 	tlsKeyFile string
+
+	mu sync.Mutex
+
+	// servers 通过 Serve, StartAutoTLS 启动的 http 服务，由 Shutdown 统一关闭
+	servers []*http.Server
+
+	// shutdown 正在执行的 Shutdown
+	shutdown sync.WaitGroup
+
+	// conns 通过 Serve, StartAutoTLS 启动的 http 服务当前的连接数量
+	conns int64
+
+	// requests 正在处理的请求，用于生成关闭报告，键为 *http.Request，值为 inflight
+	// 每个请求都会读写，使用 sync.Map 避免所有请求竞争同一把锁
+	requests sync.Map
+
+	// active 正在处理的请求数量
+	active int64
+
+	// running 通过 Start 启动的 graceful 服务是否正在运行
+	running bool
+
+	// closing graceful 服务是否由 Shutdown 关闭，此时由 Shutdown 生成报告
+	closing bool
+
+	// report 最近一次关闭的报告
+	report *zeroapi.ShutdownReport
 }
 
+// inflight 正在处理的请求
+type inflight struct {
+	ctx   zeroapi.Context
+	start time.Time
+}
+
+// ErrNoListener 调用 Serve 时没有传入监听
+var ErrNoListener = errors.New("no listener")
+
+// gracefulTimeout 通过 HTTPServer().Shutdown 关闭 graceful 服务时，等待正在处理的请求完成的时间，与 graceful 默认的超时时间一致
+const gracefulTimeout = 5 * time.Second
+
 // NewServer 新建一个 http 服务器
 func NewServer(app zeroapi.App) zeroapi.Server {
 	s := &server{app: app}
-	s.httpServer = graceful.NewServer(s, app.Logger())
+
+	var handler http.Handler = s
+	if app.IsH2C() {
+		handler = h2c.NewHandler(s, &http2.Server{})
+	}
+
+	s.handler = handler
+	s.httpServer = graceful.NewServer(handler, app.Logger())
 
 	return s
 }
@@ -35,37 +98,144 @@ func NewServer(app zeroapi.App) zeroapi.Server {
 // ServeHTTP 实现 http.Handler 接口
 func (s *server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	ctx := s.app.Context()
+	atomic.AddInt64(&s.active, 1)
 
 	defer func() {
-		if p := recover(); p != nil {
-			s.app.Logger().Errorf("%+v", p)
+		s.requests.Delete(req)
+		atomic.AddInt64(&s.active, -1)
+
+		p := recover()
+		if p != nil && p != http.ErrAbortHandler {
+			s.recover(ctx, p)
 		}
 
+		// 没有写入任何数据时，响应头由 net/http 在返回后写入，需要在此之前写入 cookie 等
+		ctx.Response().PrepareHeader()
+
+		// 响应结束，之后通过 Context 的写入会返回 ErrResponseFinished，避免写入其它请求的响应
+		ctx.Response().Finish()
+
 		go ctx.RunEnd()
+
+		// 由 net/http 中断连接，且不记录日志
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
 	}()
 
 	ctx.Reset(res, req)
 
-	// 执行应用级别中间件
-	s.app.ExecuteMiddlewares(ctx)
-	if ctx.IsStopped() {
-		return
-	}
+	// 在 Reset 之后记录，此时已经清空路由信息
+	s.requests.Store(req, inflight{ctx: ctx, start: time.Now()})
 
 	// 匹配路由
 	method := ctx.Method()
 	path := ctx.Request().URL.Path
-	handlers, dynamic := s.app.Router().Lookup(method, path)
+	// 虚拟主机匹配后只在该主机的路由中查找
+	r, hostDynamic := s.app.Router().MatchHost(ctx.Host())
+	route, handlers, dynamic := r.LookupRoute(method, path)
+
+	// 路由没有通过 RouteMiddleware 调整顺序时，App 级别中间件在匹配路由之前执行
+	// 中间件可以改写请求路径，例如去掉前缀，之后按照新的路径重新匹配
+	global := handlers == nil || (len(route.Before) == 0 && !route.SkipGlobal)
+	if global {
+		if handlers != nil {
+			s.match(ctx, route, dynamic, hostDynamic)
+			if !s.prepare(ctx, route) {
+				return
+			}
+		}
+
+		s.app.ExecuteMiddlewares(ctx)
+		if ctx.IsStopped() {
+			return
+		}
+
+		if m, p := ctx.Method(), ctx.Request().URL.Path; m != method || p != path {
+			// 成本与请求体限制只记录一次，使用第一次匹配的路由
+			prepared := handlers != nil
+			method, path = m, p
+			route, handlers, dynamic = r.LookupRoute(method, path)
+			if handlers != nil && !prepared && !s.prepare(ctx, route) {
+				return
+			}
+		}
+	}
+
 	if handlers == nil {
-		ctx.NotFound()
+		s.notFound(ctx, r, method, path)
 		return
 	}
 
+	s.match(ctx, route, dynamic, hostDynamic)
+
+	if !global {
+		// 执行顺序: Before -> App 级别中间件 -> 路由级别中间件和处理函数
+		if !s.prepare(ctx, route) || !run(ctx, route.Before) {
+			return
+		}
+		if !route.SkipGlobal {
+			s.app.ExecuteMiddlewares(ctx)
+			if ctx.IsStopped() {
+				return
+			}
+		}
+	} else if len(route.Before) > 0 {
+		// 改写路径后匹配的路由，App 级别中间件已经执行
+		if !run(ctx, route.Before) {
+			return
+		}
+	}
+
+	// 执行路由处理函数和中间件
+	if !run(ctx, handlers) {
+		return
+	}
+
+	ctx.RunAfter()
+}
+
+// match 记录匹配的路由以及动态参数，之后的中间件通过 ctx.Route() 读取路由信息
+func (s *server) match(ctx zeroapi.Context, route *zeroapi.RouteInfo, dynamic, hostDynamic map[string]string) {
+	ctx.SetRoute(route)
+
+	if len(hostDynamic) > 0 {
+		merged := make(map[string]string, len(dynamic)+len(hostDynamic))
+		for name, value := range hostDynamic {
+			merged[name] = value
+		}
+		// 路由中的同名参数优先
+		for name, value := range dynamic {
+			merged[name] = value
+		}
+		dynamic = merged
+	}
+
 	if dynamic != nil {
 		ctx.SetDynamics(dynamic)
 	}
+}
+
+// prepare 在所有中间件之前限制请求体，记录路由的成本，限流中间件可以在请求开始时按照成本扣除
+// 请求体过大时返回 false
+func (s *server) prepare(ctx zeroapi.Context, route *zeroapi.RouteInfo) bool {
+	limit := route.MaxBodyBytes
+	if limit == 0 {
+		limit = s.app.MaxBodyBytes()
+	}
+	zeroapi.LimitBody(limit)(ctx)
+	if ctx.IsStopped() {
+		return false
+	}
+
+	if route.Cost > 0 {
+		ctx.ChargeCost(route.Cost)
+	}
+	return true
+}
 
-	// 执行路由处理函数和路由级别中间件
+// run 依次执行 handlers，中间件终止请求时返回 false
+func run(ctx zeroapi.Context, handlers []zeroapi.Handler) bool {
 	for _, handler := range handlers {
 		if handler == nil {
 			continue
@@ -73,17 +243,103 @@ func (s *server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 
 		handler(ctx)
 		if ctx.IsStopped() {
+			return false
+		}
+	}
+	return true
+}
+
+// notFound 路由未匹配，路径可以被其它请求方法匹配时响应 405，否则响应 404
+// 开启 IsAutoOptions 时，OPTIONS 请求响应 204
+func (s *server) notFound(ctx zeroapi.Context, r zeroapi.Router, method, path string) {
+	if fixed, ok := r.RedirectPath(method, path); ok && isLocalPath(fixed) {
+		location := &url.URL{Path: fixed, RawQuery: ctx.Request().URL.RawQuery}
+
+		code := http.StatusMovedPermanently
+		if method != zeroapi.MethodGet && method != zeroapi.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+		_ = ctx.Redirect(code, location.String())
+		return
+	}
+
+	if allowed := r.AllowedMethods(path); len(allowed) > 0 {
+		if s.app.IsAutoOptions() && !contains(allowed, zeroapi.MethodOptions) {
+			allowed = append(allowed, zeroapi.MethodOptions)
+		}
+		ctx.SetHeader("Allow", strings.Join(allowed, ", "))
+
+		if method == zeroapi.MethodOptions && s.app.IsAutoOptions() {
+			ctx.SetHTTPCode(http.StatusNoContent)
 			return
 		}
+
+		if handler := s.app.MethodNotAllowedHandler(); handler != nil {
+			handler(ctx)
+			return
+		}
+
+		ctx.ClientError(http.StatusMethodNotAllowed, zeroapi.ReasonMethodNotAllowed, "METHOD NOT ALLOWED")
+		return
 	}
 
-	ctx.RunAfter()
+	if handler := s.app.NotFoundHandler(); handler != nil {
+		handler(ctx)
+		return
+	}
+
+	ctx.ClientError(http.StatusNotFound, r.MissReason(method, path), "PAGE NOT FOUND")
+}
+
+// isLocalPath 是否是站内路径，避免 //example.com 被浏览器当作其它站点
+func isLocalPath(p string) bool {
+	return len(p) > 0 && p[0] == '/' && (len(p) == 1 || (p[1] != '/' && p[1] != '\\'))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// recover 处理函数发生 panic，交给 PanicHandler 处理，没有设置或者 PanicHandler 也发生 panic 时只记录日志
+func (s *server) recover(ctx zeroapi.Context, p interface{}) {
+	handler := s.app.PanicHandler()
+	if handler == nil {
+		s.app.Logger().Errorf("%+v", p)
+
+		// 还没有写入响应时响应 500，否则 net/http 会响应 200，中间件在 End 钩子中也会将其视为成功
+		if ctx.Response().Status() == 0 {
+			ctx.Response().WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	defer func() {
+		if pp := recover(); pp != nil {
+			s.app.Logger().Errorf("%+v, panic in panic handler: %+v", p, pp)
+		}
+	}()
+
+	handler(ctx, p)
 }
 
 // Start 根据配置调用 ListenAndServe 或者 ListenAndServeTLS，接收连接请求
 // addr: host:port，例如: ":8080"，"192.168.1.8:80"
 func (s *server) Start(addr string) error {
 	logger := s.app.Logger()
+
+	switch s.app.ServeMode() {
+	case zeroapi.ServeModeCGI:
+		// 标准输出用于响应，不输出启动日志
+		return cgi.Serve(s)
+	case zeroapi.ServeModeFastCGI:
+		return s.startFastCGI(addr)
+	}
+
 	logger.Infof("Framework version: %s", s.app.Version())
 	logger.Infof("PID: %d", os.Getpid())
 
@@ -94,14 +350,342 @@ func (s *server) Start(addr string) error {
 			logger.Debugf("Listen on: https://%s", addr)
 		}
 
-		return s.httpServer.ListenAndServeTLS(addr, s.tlsCertFile, s.tlsKeyFile)
+		return s.serveGraceful(func() error {
+			return s.httpServer.ListenAndServeTLS(addr, s.tlsCertFile, s.tlsKeyFile)
+		})
 	}
 
 	if logger.IsDebugAble() {
+		if s.app.IsH2C() {
+			logger.Debugf("H2C on")
+		}
 		logger.Debugf("Listen on: http://%s", addr)
 	}
 
-	return s.httpServer.ListenAndServe(addr)
+	return s.serveGraceful(func() error { return s.httpServer.ListenAndServe(addr) })
+}
+
+// serveGraceful 运行 graceful 服务，关闭后生成关闭报告
+// 由 Shutdown 关闭时等待 Shutdown 生成报告，由 HTTPServer().Shutdown 或者 ListenSignal 收到信号关闭时在这里生成
+func (s *server) serveGraceful(serve func() error) error {
+	s.mu.Lock()
+	s.running = true
+	s.mu.Unlock()
+
+	err := serve()
+
+	s.mu.Lock()
+	s.running = false
+	closing := s.closing
+	s.closing = false
+	s.mu.Unlock()
+
+	if err != http.ErrServerClosed {
+		return err
+	}
+
+	if closing {
+		s.shutdown.Wait()
+		return err
+	}
+
+	report := &zeroapi.ShutdownReport{Start: time.Now()}
+	before := atomic.LoadInt64(&s.conns) + atomic.LoadInt64(&s.active)
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracefulTimeout)
+	defer cancel()
+	s.finishReport(report, before, true, s.wait(ctx))
+
+	return err
+}
+
+// StartAutoTLS 使用证书管理器获取证书，启动 https 服务
+// redirectAddr: 不为空时同时在该地址启动 http 服务，处理 HTTP-01 验证请求，其它请求重定向到 https
+func (s *server) StartAutoTLS(addr, redirectAddr string, manager zeroapi.CertManager) error {
+	logger := s.app.Logger()
+	logger.Infof("Framework version: %s", s.app.Version())
+	logger.Infof("PID: %d", os.Getpid())
+
+	if redirectAddr != "" {
+		redirect := s.newHTTPServer(redirectAddr, manager.HTTPHandler(httpsRedirect(addr)))
+		s.track(redirect)
+		defer redirect.Close()
+
+		go func() {
+			if err := redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Errorf("auto tls redirect server: %s", err.Error())
+			}
+		}()
+	}
+
+	httpServer := s.newHTTPServer(addr, s.handler)
+	httpServer.TLSConfig = &tls.Config{
+		GetCertificate: manager.GetCertificate,
+		// acme-tls/1 用于 TLS-ALPN-01 验证
+		NextProtos: []string{"h2", "http/1.1", "acme-tls/1"},
+	}
+	s.track(httpServer)
+
+	if logger.IsDebugAble() {
+		logger.Debugf("Auto TLS on, listen on: https://%s", addr)
+		if redirectAddr != "" {
+			logger.Debugf("Redirect http://%s to https", redirectAddr)
+		}
+	}
+
+	if err := httpServer.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+		return err
+	}
+
+	// 等待正在处理的请求完成
+	s.shutdown.Wait()
+	return http.ErrServerClosed
+}
+
+// httpsRedirect 将 http 请求重定向到 https，addr 为 https 服务的监听地址
+func httpsRedirect(addr string) http.Handler {
+	_, port, _ := net.SplitHostPort(addr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		code := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
+
+// startFastCGI 作为 FastCGI 响应器运行
+// addr 为空时使用标准输入，由前端服务器创建的监听
+func (s *server) startFastCGI(addr string) error {
+	logger := s.app.Logger()
+	logger.Infof("Framework version: %s", s.app.Version())
+	logger.Infof("PID: %d", os.Getpid())
+
+	if addr == "" {
+		return fcgi.Serve(nil, s)
+	}
+
+	ln, err := Listen(addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	if logger.IsDebugAble() {
+		logger.Debugf("FastCGI listen on: %s:%s", ln.Addr().Network(), ln.Addr().String())
+	}
+
+	return fcgi.Serve(ln, s)
+}
+
+// Listen 创建监听，"unix:/path" 监听 unix socket，否则监听 TCP
+// unix socket 文件已经存在时先删除，例如上次异常退出时遗留的文件
+func Listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, "unix:") {
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, "unix:")
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(path)
+	}
+
+	return net.Listen("unix", path)
+}
+
+// Serve 同时在多个监听上接收连接请求，例如 TCP, unix socket, systemd socket activation 传入的监听
+// 通过 SetTLS 指定证书时使用 https
+// 任意一个监听出错时关闭其它监听并返回该错误，通过 Shutdown 关闭时等待关闭完成后返回 http.ErrServerClosed
+func (s *server) Serve(listeners ...net.Listener) error {
+	if len(listeners) == 0 {
+		return ErrNoListener
+	}
+
+	httpServer := s.newHTTPServer("", s.handler)
+	s.track(httpServer)
+
+	logger := s.app.Logger()
+	logger.Infof("Framework version: %s", s.app.Version())
+	logger.Infof("PID: %d", os.Getpid())
+
+	errs := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		if logger.IsDebugAble() {
+			logger.Debugf("Listen on: %s:%s", ln.Addr().Network(), ln.Addr().String())
+		}
+
+		go func(ln net.Listener) {
+			if s.tlsCertFile != "" && s.tlsKeyFile != "" {
+				errs <- httpServer.ServeTLS(ln, s.tlsCertFile, s.tlsKeyFile)
+				return
+			}
+			errs <- httpServer.Serve(ln)
+		}(ln)
+	}
+
+	// 由热重启启动时通知父进程，父进程随后关闭
+	notifyReady()
+
+	var first error
+	for range listeners {
+		if err := <-errs; err != http.ErrServerClosed && first == nil {
+			first = err
+			httpServer.Close()
+		}
+	}
+
+	if first != nil {
+		return first
+	}
+
+	// 等待正在处理的请求完成
+	s.shutdown.Wait()
+	return http.ErrServerClosed
+}
+
+// Shutdown 优雅关闭通过 Start, Serve, StartAutoTLS 启动的所有 http 服务，等待正在处理的请求完成，或者 ctx 结束
+// 关闭后通过 ShutdownReport 获取关闭报告
+func (s *server) Shutdown(ctx context.Context) error {
+	s.shutdown.Add(1)
+	defer s.shutdown.Done()
+
+	s.mu.Lock()
+	servers := s.servers
+	s.servers = nil
+	running := s.running
+	s.closing = running
+	s.mu.Unlock()
+
+	report := &zeroapi.ShutdownReport{Start: time.Now()}
+	conns := atomic.LoadInt64(&s.conns)
+
+	var first error
+	for _, httpServer := range servers {
+		if err := httpServer.Shutdown(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	// Start 启动的 graceful 服务，graceful.Server.Shutdown 会一直等到它的超时时间才返回，所以不等待它，而是等待正在处理的请求完成
+	// 无法统计 graceful 服务的连接，使用正在处理的请求数量
+	if running {
+		conns += atomic.LoadInt64(&s.active)
+		go s.httpServer.Shutdown()
+		if err := s.wait(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	s.finishReport(report, conns, running, first)
+	return first
+}
+
+// wait 等待正在处理的请求完成，或者 ctx 结束
+func (s *server) wait(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for atomic.LoadInt64(&s.active) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// finishReport 完成关闭报告，before 为开始关闭时的连接数量，graceful 为 true 时包括正在处理的请求数量
+// err 不为空时记录仍未完成的请求
+func (s *server) finishReport(report *zeroapi.ShutdownReport, before int64, graceful bool, err error) {
+	report.Duration = time.Since(report.Start)
+
+	remaining := atomic.LoadInt64(&s.conns)
+	if graceful {
+		remaining += atomic.LoadInt64(&s.active)
+	}
+
+	report.Remaining = int(remaining)
+	if report.Drained = int(before) - report.Remaining; report.Drained < 0 {
+		report.Drained = 0
+	}
+
+	if err != nil {
+		report.Error = err.Error()
+
+		now := time.Now()
+		s.requests.Range(func(key, value interface{}) bool {
+			req, r := key.(*http.Request), value.(inflight)
+			route := r.ctx.RoutePath()
+			if route == "" {
+				route = req.URL.Path
+			}
+			report.Unfinished = append(report.Unfinished, zeroapi.UnfinishedRequest{
+				Method:   req.Method,
+				Route:    route,
+				Duration: now.Sub(r.start),
+			})
+			return true
+		})
+
+		// 处理时间最长的请求排在前面
+		sort.Slice(report.Unfinished, func(i, j int) bool {
+			return report.Unfinished[i].Duration > report.Unfinished[j].Duration
+		})
+	}
+
+	s.mu.Lock()
+	s.report = report
+	s.mu.Unlock()
+}
+
+// ShutdownReport 最近一次关闭的报告，没有关闭过时返回 nil
+func (s *server) ShutdownReport() *zeroapi.ShutdownReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.report
+}
+
+// newHTTPServer 创建 http 服务，使用 App 设置的超时时间
+func (s *server) newHTTPServer(addr string, handler http.Handler) *http.Server {
+	timeouts := s.app.ServerTimeouts()
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+}
+
+// track 记录启动的 http 服务，由 Shutdown 统一关闭
+func (s *server) track(httpServer *http.Server) {
+	httpServer.ConnState = s.connState
+
+	s.mu.Lock()
+	s.servers = append(s.servers, httpServer)
+	s.mu.Unlock()
+}
+
+// connState 统计连接数量
+func (s *server) connState(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		atomic.AddInt64(&s.conns, 1)
+	case http.StateClosed, http.StateHijacked:
+		atomic.AddInt64(&s.conns, -1)
+	}
 }
 
 // HTTPServer 实际使用的 http 服务器
@@ -113,13 +697,13 @@ func (s *server) HTTPServer() graceful.Server {
 // certFile: 证书路径
 // keyFile: 私钥路径
 func (s *server) SetTLS(certFile, keyFile string) bool {
-	if !file.IsExist(s.tlsCertFile) {
-		s.app.Logger().Errorf("Cert file: \"%s\" is not exist", s.tlsCertFile)
+	if !file.IsExist(certFile) {
+		s.app.Logger().Errorf("Cert file: \"%s\" is not exist", certFile)
 		return false
 	}
 
-	if !file.IsExist(s.tlsKeyFile) {
-		s.app.Logger().Errorf("Key file: \"%s\" is not exist", s.tlsKeyFile)
+	if !file.IsExist(keyFile) {
+		s.app.Logger().Errorf("Key file: \"%s\" is not exist", keyFile)
 		return false
 	}
 
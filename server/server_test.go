@@ -0,0 +1,460 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/server"
+)
+
+func TestServeCGI(t *testing.T) {
+	env := map[string]string{
+		"REQUEST_METHOD":  "GET",
+		"SERVER_PROTOCOL": "HTTP/1.1",
+		"REQUEST_URI":     "/hello?name=cgi",
+		"HTTP_HOST":       "example.com",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	a := app.NewApp(app.WithServeMode(zeroapi.ServeModeCGI))
+	a.Get("/hello", func(ctx zeroapi.Context) {
+		ctx.Text("hello " + ctx.Query("name"))
+	})
+
+	if err := a.Run(""); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	out, _ := ioutil.ReadAll(r)
+	if !strings.HasPrefix(string(out), "Status: 200 OK") || !strings.HasSuffix(string(out), "\r\n\r\nhello cgi") {
+		t.Fatalf("invalid cgi response: %q", out)
+	}
+}
+
+// certManager 使用自签名证书的证书管理器
+type certManager struct {
+	cert *tls.Certificate
+}
+
+func newCertManager(t *testing.T) *certManager {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &certManager{cert: &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+func (m *certManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return m.cert, nil
+}
+
+func (m *certManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/") {
+			w.Write([]byte("challenge"))
+			return
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestStartAutoTLS(t *testing.T) {
+	a := app.NewApp()
+	a.Get("/hello", func(ctx zeroapi.Context) { ctx.Text("hello tls") })
+	a.Router().Build()
+
+	addr, redirectAddr := freeAddr(t), freeAddr(t)
+	go a.Server().StartAutoTLS(addr, redirectAddr, newCertManager(t))
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	get := func(url string) *http.Response {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			res, err := client.Get(url)
+			if err == nil {
+				return res
+			}
+			if time.Now().After(deadline) {
+				t.Fatal(err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	res := get("https://" + addr + "/hello")
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "hello tls" {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	_, port, _ := net.SplitHostPort(addr)
+	res = get("http://" + redirectAddr + "/hello?a=1")
+	res.Body.Close()
+	if res.StatusCode != http.StatusMovedPermanently || res.Header.Get("Location") != "https://127.0.0.1:"+port+"/hello?a=1" {
+		t.Fatalf("unexpected redirect: %d %s", res.StatusCode, res.Header.Get("Location"))
+	}
+
+	res = get("http://" + redirectAddr + "/.well-known/acme-challenge/token")
+	body, _ = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "challenge" {
+		t.Fatalf("challenge not handled: %s", body)
+	}
+}
+
+func TestRunTLSErrors(t *testing.T) {
+	a := app.NewApp()
+	if err := a.RunTLS(":0", "not-exist.crt", "not-exist.key"); !errors.Is(err, app.ErrInvalidCertFile) {
+		t.Fatalf("expect ErrInvalidCertFile, got %v", err)
+	}
+	if err := a.RunAutoTLS("example.com"); !errors.Is(err, app.ErrNoCertManager) {
+		t.Fatalf("expect ErrNoCertManager, got %v", err)
+	}
+
+	b := app.NewApp(app.WithCertManager(func([]string, string) zeroapi.CertManager { return nil }))
+	if err := b.RunAutoTLS(); !errors.Is(err, app.ErrNoDomains) {
+		t.Fatalf("expect ErrNoDomains, got %v", err)
+	}
+}
+
+func TestServeListeners(t *testing.T) {
+	a := app.NewApp()
+	a.Get("/hello", func(ctx zeroapi.Context) { ctx.Text("hello") })
+	a.Router().Build()
+
+	tcp, err := server.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock := filepath.Join(t.TempDir(), "zero.sock")
+	unix, err := server.Listen("unix:" + sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- a.Server().Serve(tcp, unix) }()
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+
+	for _, c := range []struct {
+		client *http.Client
+		url    string
+	}{
+		{http.DefaultClient, "http://" + tcp.Addr().String() + "/hello"},
+		{unixClient, "http://unix/hello"},
+	} {
+		res, err := c.client.Get(c.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if string(body) != "hello" {
+			t.Fatalf("invalid response from %s: %s", c.url, body)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := a.Server().Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != http.ErrServerClosed {
+			t.Fatalf("expect http.ErrServerClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("serve not returned after shutdown")
+	}
+
+	if err := a.Server().Serve(); err != server.ErrNoListener {
+		t.Fatalf("expect ErrNoListener, got %v", err)
+	}
+}
+
+func TestServeReadHeaderTimeout(t *testing.T) {
+	a := app.NewApp(app.WithReadHeaderTimeout(50*time.Millisecond), app.WithIdleTimeout(time.Minute))
+	a.Get("/hello", func(ctx zeroapi.Context) { ctx.Text("hello") })
+	a.Router().Build()
+
+	if timeouts := a.ServerTimeouts(); timeouts.ReadHeader != 50*time.Millisecond || timeouts.Idle != time.Minute || timeouts.Write != 0 {
+		t.Fatalf("invalid timeouts: %+v", timeouts)
+	}
+
+	ln, err := server.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go a.Server().Serve(ln)
+	defer a.Server().Shutdown(context.Background())
+
+	// 慢速发送请求头，超时后连接被关闭
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	conn.SetDeadline(start.Add(2 * time.Second))
+	if _, err := conn.Write([]byte("GET /hello HTTP/1.1\r\nHost: example.com\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(conn); err != nil || time.Since(start) > time.Second {
+		t.Fatalf("connection should be closed after read header timeout: %v, %s", err, time.Since(start))
+	}
+}
+
+// stopErrService 停止时返回错误的服务
+type stopErrService struct{}
+
+func (stopErrService) Start() error { return nil }
+func (stopErrService) Stop() error  { return errors.New("stop failed") }
+
+func TestShutdownReport(t *testing.T) {
+	reports := make(chan *zeroapi.ShutdownReport, 1)
+	a := app.NewApp(app.WithShutdownReport(func(report *zeroapi.ShutdownReport) {
+		reports <- report
+	}))
+	a.AddService(stopErrService{})
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	a.Get("/slow", func(ctx zeroapi.Context) {
+		close(entered)
+		<-release
+		ctx.Text("slow")
+	})
+
+	ln, err := server.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go a.RunListeners(ln)
+
+	go func() {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if res, err := http.Get("http://" + ln.Addr().String() + "/slow"); err == nil {
+				res.Body.Close()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := a.Server().Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expect context.DeadlineExceeded, got %v", err)
+	}
+	close(release)
+
+	var report *zeroapi.ShutdownReport
+	select {
+	case report = <-reports:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown report not received")
+	}
+
+	if report.Error == "" || report.Remaining != 1 {
+		t.Fatalf("invalid report: %+v", report)
+	}
+	if len(report.Unfinished) != 1 || report.Unfinished[0].Route != "/slow" || report.Unfinished[0].Duration <= 0 {
+		t.Fatalf("invalid unfinished requests: %+v", report.Unfinished)
+	}
+	if len(report.FailedHooks) != 1 || report.FailedHooks[0].Error != "stop failed" {
+		t.Fatalf("invalid failed hooks: %+v", report.FailedHooks)
+	}
+}
+
+// runApp 通过 App.Run 启动服务，等待服务可以访问后返回地址
+func runApp(t *testing.T, a zeroapi.App) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	go a.Run(addr)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return addr
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("server not started")
+	return ""
+}
+
+func TestShutdownReportRun(t *testing.T) {
+	reports := make(chan *zeroapi.ShutdownReport, 1)
+	a := app.NewApp(app.WithShutdownReport(func(report *zeroapi.ShutdownReport) {
+		reports <- report
+	}))
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	a.Get("/slow", func(ctx zeroapi.Context) {
+		close(entered)
+		<-release
+		ctx.Text("slow")
+	})
+
+	addr := runApp(t, a)
+	go func() {
+		if res, err := http.Get("http://" + addr + "/slow"); err == nil {
+			res.Body.Close()
+		}
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := a.Server().Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expect context.DeadlineExceeded, got %v", err)
+	}
+	close(release)
+
+	var report *zeroapi.ShutdownReport
+	select {
+	case report = <-reports:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown report not received")
+	}
+
+	if report.Error == "" || report.Remaining != 1 {
+		t.Fatalf("invalid report: %+v", report)
+	}
+	if len(report.Unfinished) != 1 || report.Unfinished[0].Route != "/slow" {
+		t.Fatalf("invalid unfinished requests: %+v", report.Unfinished)
+	}
+}
+
+func TestShutdownReportGraceful(t *testing.T) {
+	reports := make(chan *zeroapi.ShutdownReport, 1)
+	a := app.NewApp(app.WithShutdownReport(func(report *zeroapi.ShutdownReport) {
+		reports <- report
+	}))
+	a.Get("/", func(ctx zeroapi.Context) {
+		ctx.Text("ok")
+	})
+	runApp(t, a)
+
+	// 例如 ListenSignal 收到 SIGTERM
+	go a.Server().HTTPServer().Shutdown()
+
+	select {
+	case report := <-reports:
+		if report.Error != "" || report.Remaining != 0 || len(report.Unfinished) != 0 {
+			t.Fatalf("invalid report: %+v", report)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown report not received")
+	}
+}
+
+func TestLateWriteAfterRequest(t *testing.T) {
+	a := app.New()
+
+	held, ended := make(chan zeroapi.Context, 1), make(chan struct{})
+	a.Get("/first", func(ctx zeroapi.Context) {
+		held <- ctx
+		ctx.AppendEnd(func() error {
+			close(ended)
+			return nil
+		})
+		ctx.Text("first")
+	})
+	a.Get("/second", func(ctx zeroapi.Context) {
+		// 第一个请求已经结束，后台 goroutine 仍然持有它的 Context
+		if _, err := (<-held).Text("late"); err != zeroapi.ErrResponseFinished {
+			t.Errorf("want ErrResponseFinished, got: %v", err)
+		}
+		ctx.Text("second")
+	})
+	a.Router().Build()
+
+	first := httptest.NewRecorder()
+	a.Server().ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/first", nil))
+
+	// 等待 End 钩子执行完成，Context 已经释放
+	<-ended
+	time.Sleep(10 * time.Millisecond)
+
+	second := httptest.NewRecorder()
+	a.Server().ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/second", nil))
+
+	if first.Body.String() != "first" || second.Body.String() != "second" {
+		t.Fatalf("late write reached a response: %q, %q", first.Body.String(), second.Body.String())
+	}
+}
@@ -0,0 +1,170 @@
+package serverless
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// APIGatewayProxyRequest API Gateway REST API (payload 1.0) 事件
+type APIGatewayProxyRequest struct {
+	Resource                        string                        `json:"resource"`
+	Path                            string                        `json:"path"`
+	HTTPMethod                      string                        `json:"httpMethod"`
+	Headers                         map[string]string             `json:"headers"`
+	MultiValueHeaders               map[string][]string           `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string             `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string           `json:"multiValueQueryStringParameters"`
+	PathParameters                  map[string]string             `json:"pathParameters"`
+	RequestContext                  APIGatewayProxyRequestContext `json:"requestContext"`
+	Body                            string                        `json:"body"`
+	IsBase64Encoded                 bool                          `json:"isBase64Encoded"`
+}
+
+// APIGatewayProxyRequestContext API Gateway REST API 请求上下文
+type APIGatewayProxyRequestContext struct {
+	RequestID string `json:"requestId"`
+	Stage     string `json:"stage"`
+	Identity  struct {
+		SourceIP string `json:"sourceIp"`
+	} `json:"identity"`
+	// ELB 存在时为 ALB 事件
+	ELB *struct {
+		TargetGroupArn string `json:"targetGroupArn"`
+	} `json:"elb,omitempty"`
+}
+
+// APIGatewayProxyResponse API Gateway REST API 以及 ALB 的响应
+type APIGatewayProxyResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription,omitempty"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// ALBTargetGroupRequest ALB 事件，与 REST API 事件格式相同，requestContext 中包含 elb
+type ALBTargetGroupRequest = APIGatewayProxyRequest
+
+// ALBTargetGroupResponse ALB 响应，需要包含 statusDescription
+type ALBTargetGroupResponse = APIGatewayProxyResponse
+
+// APIGatewayV2HTTPRequest API Gateway HTTP API (payload 2.0) 事件
+type APIGatewayV2HTTPRequest struct {
+	Version         string                         `json:"version"`
+	RouteKey        string                         `json:"routeKey"`
+	RawPath         string                         `json:"rawPath"`
+	RawQueryString  string                         `json:"rawQueryString"`
+	Cookies         []string                       `json:"cookies"`
+	Headers         map[string]string              `json:"headers"`
+	PathParameters  map[string]string              `json:"pathParameters"`
+	RequestContext  APIGatewayV2HTTPRequestContext `json:"requestContext"`
+	Body            string                         `json:"body"`
+	IsBase64Encoded bool                           `json:"isBase64Encoded"`
+}
+
+// APIGatewayV2HTTPRequestContext HTTP API 请求上下文
+type APIGatewayV2HTTPRequestContext struct {
+	RequestID string                             `json:"requestId"`
+	Stage     string                             `json:"stage"`
+	HTTP      APIGatewayV2HTTPRequestContextHTTP `json:"http"`
+}
+
+// APIGatewayV2HTTPRequestContextHTTP HTTP API 请求信息
+type APIGatewayV2HTTPRequestContextHTTP struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Protocol string `json:"protocol"`
+	SourceIP string `json:"sourceIp"`
+}
+
+// APIGatewayV2HTTPResponse API Gateway HTTP API (payload 2.0) 响应
+type APIGatewayV2HTTPResponse struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Cookies         []string          `json:"cookies,omitempty"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// CloudEvent 结构化模式的 CloudEvents 事件，见 https://github.com/cloudevents/spec
+// 转换为二进制模式的 HTTP POST 请求，属性放在 ce- 请求头中
+type CloudEvent struct {
+	SpecVersion     string                 `json:"specversion"`
+	ID              string                 `json:"id"`
+	Source          string                 `json:"source"`
+	Type            string                 `json:"type"`
+	Subject         string                 `json:"subject,omitempty"`
+	Time            string                 `json:"time,omitempty"`
+	DataContentType string                 `json:"datacontenttype,omitempty"`
+	Data            []byte                 `json:"-"`
+	Extensions      map[string]interface{} `json:"-"`
+}
+
+// UnmarshalJSON 解析结构化模式的事件，data 为 JSON 时原样保留，data_base64 解码后保存到 Data 中，其它属性保存到 Extensions 中
+func (e *CloudEvent) UnmarshalJSON(b []byte) error {
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(b, &attrs); err != nil {
+		return err
+	}
+
+	fields := map[string]*string{
+		"specversion":     &e.SpecVersion,
+		"id":              &e.ID,
+		"source":          &e.Source,
+		"type":            &e.Type,
+		"subject":         &e.Subject,
+		"time":            &e.Time,
+		"datacontenttype": &e.DataContentType,
+	}
+
+	for name, raw := range attrs {
+		if field, ok := fields[name]; ok {
+			if err := json.Unmarshal(raw, field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch name {
+		case "data":
+			e.Data = []byte(raw)
+			// 非 JSON 的数据以字符串形式出现，例如 text/plain
+			var s string
+			if !isJSON(e.DataContentType) && json.Unmarshal(raw, &s) == nil {
+				e.Data = []byte(s)
+			}
+		case "data_base64":
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return err
+			}
+			data, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return err
+			}
+			e.Data = data
+		default:
+			var v interface{}
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return err
+			}
+			if e.Extensions == nil {
+				e.Extensions = make(map[string]interface{})
+			}
+			e.Extensions[name] = v
+		}
+	}
+
+	return nil
+}
+
+// isJSON 数据是否为 JSON，没有设置 datacontenttype 时默认为 JSON
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,36 @@
+package serverless
+
+// config 适配器配置
+type config struct {
+	// cloudEventPath CloudEvents 事件转换为请求时使用的路径
+	cloudEventPath string
+
+	// stripStage 是否去掉 API Gateway REST API 路径中的 stage 前缀
+	stripStage bool
+}
+
+func defaultConfig() *config {
+	return &config{
+		cloudEventPath: "/",
+	}
+}
+
+// Option 适配器配置选项
+type Option func(config *config)
+
+// WithCloudEventPath 设置 CloudEvents 事件转换为 POST 请求时使用的路径，默认 "/"
+func WithCloudEventPath(path string) Option {
+	return func(config *config) {
+		if path != "" && path[0] == '/' {
+			config.cloudEventPath = path
+		}
+	}
+}
+
+// WithStripStage 去掉路径中的 stage 前缀，例如 /prod/users 转换为 /users
+// 使用 API Gateway 默认域名时，部分集成方式的路径会带有 stage
+func WithStripStage() Option {
+	return func(config *config) {
+		config.stripStage = true
+	}
+}
@@ -0,0 +1,374 @@
+// Package serverless 将 API Gateway, ALB 以及 CloudEvents 事件转换为请求交给 App 处理，并将响应转换回事件响应
+// 同一个 App 可以部署到 AWS Lambda 等无服务器平台，不依赖平台的 SDK
+//
+// 示例:
+// adapter, err := serverless.New(app)
+// lambda.Start(adapter.Handle)
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+var (
+	// ErrUnknownEvent 无法识别的事件
+	ErrUnknownEvent = errors.New("serverless: unknown event")
+
+	// ErrBuildRouter 路由解析失败
+	ErrBuildRouter = errors.New("serverless: router build failed")
+)
+
+// Adapter 事件适配器
+type Adapter struct {
+	handler http.Handler
+	config  *config
+}
+
+// New 创建事件适配器，会解析 App 的路由
+func New(app zeroapi.App, opts ...Option) (*Adapter, error) {
+	if !app.Router().Build() {
+		return nil, ErrBuildRouter
+	}
+
+	return NewHandler(app.Server(), opts...), nil
+}
+
+// NewHandler 使用 http.Handler 创建事件适配器
+func NewHandler(handler http.Handler, opts ...Option) *Adapter {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Adapter{handler: handler, config: config}
+}
+
+// Handle 根据事件格式自动选择转换方式，可以直接作为 Lambda 的处理函数
+// 返回 *APIGatewayProxyResponse, *APIGatewayV2HTTPResponse，CloudEvents 事件没有响应
+func (a *Adapter) Handle(ctx context.Context, event json.RawMessage) (interface{}, error) {
+	var probe struct {
+		Version        string          `json:"version"`
+		HTTPMethod     string          `json:"httpMethod"`
+		SpecVersion    string          `json:"specversion"`
+		RequestContext json.RawMessage `json:"requestContext"`
+	}
+	if err := json.Unmarshal(event, &probe); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case probe.Version == "2.0" && len(probe.RequestContext) > 0:
+		var req APIGatewayV2HTTPRequest
+		if err := json.Unmarshal(event, &req); err != nil {
+			return nil, err
+		}
+		return a.HTTPAPI(ctx, &req)
+	case probe.HTTPMethod != "":
+		var req APIGatewayProxyRequest
+		if err := json.Unmarshal(event, &req); err != nil {
+			return nil, err
+		}
+		if req.RequestContext.ELB != nil {
+			return a.ALB(ctx, &req)
+		}
+		return a.APIGateway(ctx, &req)
+	case probe.SpecVersion != "":
+		var e CloudEvent
+		if err := json.Unmarshal(event, &e); err != nil {
+			return nil, err
+		}
+		return nil, a.CloudEvent(ctx, &e)
+	}
+
+	return nil, ErrUnknownEvent
+}
+
+// APIGateway 处理 API Gateway REST API 事件
+func (a *Adapter) APIGateway(ctx context.Context, event *APIGatewayProxyRequest) (*APIGatewayProxyResponse, error) {
+	p := event.Path
+	if a.config.stripStage && event.RequestContext.Stage != "" {
+		if stage := "/" + event.RequestContext.Stage; strings.HasPrefix(p, stage+"/") || p == stage {
+			p = p[len(stage):]
+		}
+	}
+
+	query := make(url.Values)
+	if len(event.MultiValueQueryStringParameters) > 0 {
+		for k, values := range event.MultiValueQueryStringParameters {
+			query[k] = append(query[k], values...)
+		}
+	} else {
+		for k, v := range event.QueryStringParameters {
+			query.Set(k, v)
+		}
+	}
+
+	req, err := a.newRequest(ctx, event.HTTPMethod, p, query.Encode(), event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+	setHeaders(req, event.Headers, event.MultiValueHeaders)
+	setRemoteAddr(req, event.RequestContext.Identity.SourceIP)
+
+	w := a.serve(req)
+	res := &APIGatewayProxyResponse{StatusCode: w.code}
+	res.Body, res.IsBase64Encoded = w.body()
+	if len(event.MultiValueHeaders) > 0 {
+		res.MultiValueHeaders = w.header
+	} else {
+		res.Headers = w.singleHeaders()
+	}
+
+	return res, nil
+}
+
+// ALB 处理 ALB 事件，ALB 传入的查询参数没有解码
+func (a *Adapter) ALB(ctx context.Context, event *ALBTargetGroupRequest) (*ALBTargetGroupResponse, error) {
+	var rawQuery []string
+	if len(event.MultiValueQueryStringParameters) > 0 {
+		for k, values := range event.MultiValueQueryStringParameters {
+			for _, v := range values {
+				rawQuery = append(rawQuery, k+"="+v)
+			}
+		}
+	} else {
+		for k, v := range event.QueryStringParameters {
+			rawQuery = append(rawQuery, k+"="+v)
+		}
+	}
+	sort.Strings(rawQuery)
+
+	req, err := a.newRequest(ctx, event.HTTPMethod, event.Path, strings.Join(rawQuery, "&"), event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+	setHeaders(req, event.Headers, event.MultiValueHeaders)
+	// 客户端可以伪造 X-Forwarded-For 的前几项，只有最右侧一项由 ALB 写入
+	if xff := req.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		ips := strings.Split(xff[len(xff)-1], ",")
+		setRemoteAddr(req, strings.TrimSpace(ips[len(ips)-1]))
+	}
+
+	w := a.serve(req)
+	res := &ALBTargetGroupResponse{
+		StatusCode:        w.code,
+		StatusDescription: strconv.Itoa(w.code) + " " + http.StatusText(w.code),
+	}
+	res.Body, res.IsBase64Encoded = w.body()
+	if len(event.MultiValueHeaders) > 0 {
+		res.MultiValueHeaders = w.header
+	} else {
+		res.Headers = w.singleHeaders()
+	}
+
+	return res, nil
+}
+
+// HTTPAPI 处理 API Gateway HTTP API (payload 2.0) 事件
+func (a *Adapter) HTTPAPI(ctx context.Context, event *APIGatewayV2HTTPRequest) (*APIGatewayV2HTTPResponse, error) {
+	p := event.RawPath
+	if p == "" {
+		p = event.RequestContext.HTTP.Path
+	}
+
+	req, err := a.newRequest(ctx, event.RequestContext.HTTP.Method, p, event.RawQueryString, event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+	setHeaders(req, event.Headers, nil)
+	if len(event.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	setRemoteAddr(req, event.RequestContext.HTTP.SourceIP)
+
+	w := a.serve(req)
+	res := &APIGatewayV2HTTPResponse{StatusCode: w.code, Cookies: w.header["Set-Cookie"]}
+	delete(w.header, "Set-Cookie")
+	res.Headers = w.singleHeaders()
+	res.Body, res.IsBase64Encoded = w.body()
+
+	return res, nil
+}
+
+// CloudEvent 处理 CloudEvents 事件，转换为二进制模式的 POST 请求，响应 2xx 以外的状态码时返回错误
+func (a *Adapter) CloudEvent(ctx context.Context, event *CloudEvent) error {
+	req, err := http.NewRequest(http.MethodPost, a.config.cloudEventPath, bytes.NewReader(event.Data))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	if event.DataContentType != "" {
+		req.Header.Set("Content-Type", event.DataContentType)
+	}
+	attrs := map[string]string{
+		"specversion": event.SpecVersion,
+		"id":          event.ID,
+		"source":      event.Source,
+		"type":        event.Type,
+		"subject":     event.Subject,
+		"time":        event.Time,
+	}
+	for name, value := range attrs {
+		if value != "" {
+			req.Header.Set("Ce-"+name, value)
+		}
+	}
+	for name, value := range event.Extensions {
+		if s, ok := value.(string); ok {
+			req.Header.Set("Ce-"+name, s)
+		} else if b, err := json.Marshal(value); err == nil {
+			req.Header.Set("Ce-"+name, string(b))
+		}
+	}
+
+	w := a.serve(req)
+	if w.code < 200 || w.code > 299 {
+		return errors.New("serverless: cloudevent " + event.ID + ": status " + strconv.Itoa(w.code))
+	}
+
+	return nil
+}
+
+func (a *Adapter) newRequest(ctx context.Context, method, p, rawQuery, body string, isBase64 bool) (*http.Request, error) {
+	data := []byte(body)
+	if isBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+
+	if p == "" {
+		p = "/"
+	}
+
+	u := &url.URL{Path: p, RawQuery: rawQuery}
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.RequestURI = u.RequestURI()
+
+	return req.WithContext(ctx), nil
+}
+
+func (a *Adapter) serve(req *http.Request) *responseWriter {
+	w := &responseWriter{header: make(http.Header)}
+	a.handler.ServeHTTP(w, req)
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w
+}
+
+func setHeaders(req *http.Request, headers map[string]string, multi map[string][]string) {
+	if len(multi) > 0 {
+		for k, values := range multi {
+			for _, v := range values {
+				req.Header.Add(k, v)
+			}
+		}
+	} else {
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	}
+
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+}
+
+func setRemoteAddr(req *http.Request, ip string) {
+	if ip != "" {
+		req.RemoteAddr = net.JoinHostPort(ip, "0")
+	}
+}
+
+// responseWriter 保存响应
+type responseWriter struct {
+	header http.Header
+	code   int
+	buf    bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Flush 响应在处理结束后一次返回，Flush 不做任何事情
+func (w *responseWriter) Flush() {}
+
+// singleHeaders 每个响应头只保留一个值，多个值使用 ", " 连接，Set-Cookie 只保留最后一个
+func (w *responseWriter) singleHeaders() map[string]string {
+	headers := make(map[string]string, len(w.header))
+	for k, values := range w.header {
+		if len(values) == 0 {
+			continue
+		}
+		if k == "Set-Cookie" {
+			headers[k] = values[len(values)-1]
+			continue
+		}
+		headers[k] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// body 响应体，非文本内容或者经过压缩时使用 base64 编码
+func (w *responseWriter) body() (string, bool) {
+	if w.buf.Len() == 0 {
+		return "", false
+	}
+
+	if w.header.Get("Content-Encoding") == "" && isText(w.header.Get("Content-Type")) {
+		return w.buf.String(), false
+	}
+
+	return base64.StdEncoding.EncodeToString(w.buf.Bytes()), true
+}
+
+func isText(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	switch {
+	case mediaType == "":
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-www-form-urlencoded", "image/svg+xml":
+		return true
+	}
+	return false
+}
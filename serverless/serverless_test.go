@@ -0,0 +1,150 @@
+package serverless_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/serverless"
+)
+
+func newAdapter(t *testing.T, opts ...serverless.Option) (*serverless.Adapter, *[]string) {
+	a := app.NewApp()
+	events := []string{}
+
+	a.Get("/users/:id", func(ctx zeroapi.Context) {
+		ctx.SetCookie("session", "abc")
+		ctx.Text(ctx.Dynamic("id") + ":" + ctx.Query("q") + ":" + ctx.Header("X-Test"))
+	})
+	a.Post("/upload", func(ctx zeroapi.Context) {
+		body, _ := ioutil.ReadAll(ctx.Request().Body)
+		ctx.SetHeader("Content-Type", "application/octet-stream")
+		ctx.Bytes(body)
+	})
+	a.Get("/addr", func(ctx zeroapi.Context) {
+		ctx.Text(ctx.Request().RemoteAddr)
+	})
+	a.Post("/events", func(ctx zeroapi.Context) {
+		body, _ := ioutil.ReadAll(ctx.Request().Body)
+		events = append(events, ctx.Header("Ce-Type")+":"+ctx.Header("Ce-Region")+":"+string(body))
+	})
+
+	adapter, err := serverless.New(a, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return adapter, &events
+}
+
+func TestAPIGateway(t *testing.T) {
+	adapter, _ := newAdapter(t, serverless.WithStripStage())
+
+	res, err := adapter.APIGateway(context.Background(), &serverless.APIGatewayProxyRequest{
+		Path:                  "/prod/users/1",
+		HTTPMethod:            "GET",
+		Headers:               map[string]string{"X-Test": "yes"},
+		QueryStringParameters: map[string]string{"q": "a b"},
+		RequestContext:        serverless.APIGatewayProxyRequestContext{Stage: "prod"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 200 || res.Body != "1:a b:yes" || res.IsBase64Encoded {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+	if res.Headers["Set-Cookie"] == "" {
+		t.Fatal("Set-Cookie missing")
+	}
+}
+
+func TestALBRemoteAddr(t *testing.T) {
+	adapter, _ := newAdapter(t)
+
+	res, err := adapter.ALB(context.Background(), &serverless.ALBTargetGroupRequest{
+		Path:       "/addr",
+		HTTPMethod: "GET",
+		Headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.9"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 200 || res.Body != "203.0.113.9:0" {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+}
+
+func TestHTTPAPIBinary(t *testing.T) {
+	adapter, _ := newAdapter(t)
+
+	payload := []byte{0x00, 0xff, 0x10}
+	res, err := adapter.HTTPAPI(context.Background(), &serverless.APIGatewayV2HTTPRequest{
+		Version:         "2.0",
+		RawPath:         "/upload",
+		Body:            base64.StdEncoding.EncodeToString(payload),
+		IsBase64Encoded: true,
+		RequestContext: serverless.APIGatewayV2HTTPRequestContext{
+			HTTP: serverless.APIGatewayV2HTTPRequestContextHTTP{Method: "POST"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsBase64Encoded || res.Body != base64.StdEncoding.EncodeToString(payload) {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+}
+
+func TestHandle(t *testing.T) {
+	adapter, events := newAdapter(t, serverless.WithCloudEventPath("/events"))
+
+	tests := []struct {
+		event string
+		code  int
+		body  string
+	}{
+		{`{"version":"2.0","rawPath":"/users/2","rawQueryString":"q=x","cookies":["a=1"],"requestContext":{"http":{"method":"GET"}}}`, 200, "2:x:"},
+		{`{"httpMethod":"GET","path":"/users/3","queryStringParameters":{"q":"y"},"requestContext":{}}`, 200, "3:y:"},
+		{`{"httpMethod":"GET","path":"/users/4","multiValueQueryStringParameters":{"q":["a%20b"]},"requestContext":{"elb":{"targetGroupArn":"arn"}}}`, 200, "4:a b:"},
+		{`{"httpMethod":"GET","path":"/missing","requestContext":{"elb":{"targetGroupArn":"arn"}}}`, 404, ""},
+	}
+
+	for _, tt := range tests {
+		res, err := adapter.Handle(context.Background(), json.RawMessage(tt.event))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var code int
+		var body string
+		switch r := res.(type) {
+		case *serverless.APIGatewayV2HTTPResponse:
+			code, body = r.StatusCode, r.Body
+			if len(r.Cookies) != 1 {
+				t.Fatalf("cookies: %v", r.Cookies)
+			}
+		case *serverless.APIGatewayProxyResponse:
+			code, body = r.StatusCode, r.Body
+		default:
+			t.Fatalf("unexpected response type: %T", res)
+		}
+		if code != tt.code || (tt.body != "" && body != tt.body) {
+			t.Fatalf("%s: got %d %q", tt.event, code, body)
+		}
+	}
+
+	res, err := adapter.Handle(context.Background(), json.RawMessage(`{"specversion":"1.0","id":"1","source":"test","type":"user.created","region":"cn","datacontenttype":"application/json","data":{"id":1}}`))
+	if err != nil || res != nil {
+		t.Fatalf("cloudevent: %v %v", res, err)
+	}
+	if len(*events) != 1 || (*events)[0] != `user.created:cn:{"id":1}` {
+		t.Fatalf("events: %v", *events)
+	}
+
+	if _, err := adapter.Handle(context.Background(), json.RawMessage(`{"foo":1}`)); err != serverless.ErrUnknownEvent {
+		t.Fatalf("expected ErrUnknownEvent, got %v", err)
+	}
+}
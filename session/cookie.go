@@ -0,0 +1,255 @@
+package session
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/timestamp"
+)
+
+const (
+	// maxCookieSize 浏览器对单个 cookie 的限制，包括名称、值以及属性
+	maxCookieSize = 4096
+
+	// maxDecompressedSize 解压后的最大长度
+	maxDecompressedSize = 64 * 1024
+
+	// headerSize 明文头部长度，1 字节压缩标记 + 8 字节签发时间
+	headerSize = 9
+
+	flagRaw     = 0
+	flagDeflate = 1
+
+	// valueKeyPrefix 会话在 ctx.Value 中的键前缀
+	valueKeyPrefix = "zeroapi.session."
+)
+
+// cookieStore 会话数据经过 AES-GCM 加密后保存在 cookie 中，服务端不需要存储
+type cookieStore struct {
+	keys   Keys
+	config *config
+}
+
+// NewCookieStore 创建基于 cookie 的会话存储，适用于不使用服务端存储的无状态部署
+// 会话数据使用 AES-GCM 加密，cookie 名称作为附加数据，不能把一个会话的值用于另一个会话
+// 加密后的 cookie 超过 4096 字节时，Save 返回 zeroapi.ErrCookieTooLarge
+func NewCookieStore(keys Keys, opts ...Option) Store {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &cookieStore{keys: keys, config: config}
+}
+
+func (cs *cookieStore) Get(ctx zeroapi.Context, name string) (*Session, error) {
+	if s, ok := ctx.Value(valueKeyPrefix + name).(*Session); ok {
+		return s, nil
+	}
+
+	s := NewSession(name)
+	ctx.SetValue(valueKeyPrefix+name, s)
+
+	cookie, err := ctx.Request().Cookie(name)
+	if err != nil || cookie.Value == "" {
+		return s, nil
+	}
+
+	values, err := cs.decode(ctx, name, cookie.Value)
+	if err != nil {
+		return s, err
+	}
+
+	s.values = values
+	s.isNew = false
+	return s, nil
+}
+
+func (cs *cookieStore) Save(ctx zeroapi.Context, s *Session) error {
+	cookie := &http.Cookie{Name: s.Name}
+	for _, opt := range ctx.App().CookieDefaults() {
+		opt(cookie)
+	}
+	for _, opt := range cs.config.cookieOptions {
+		opt(cookie)
+	}
+
+	if s.destroyed {
+		cookie.MaxAge = -1
+		ctx.SetHTTPCookie(cookie)
+		return nil
+	}
+
+	value, err := cs.encode(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	cookie.Value = value
+	cookie.MaxAge = int(cs.config.maxAge / time.Second)
+	if len(cookie.String()) > maxCookieSize {
+		return zeroapi.ErrCookieTooLarge
+	}
+
+	ctx.SetHTTPCookie(cookie)
+	return nil
+}
+
+// encode 序列化、压缩并加密会话数据
+func (cs *cookieStore) encode(ctx zeroapi.Context, s *Session) (string, error) {
+	keys := cs.keys.Keys()
+	if len(keys) == 0 {
+		return "", ErrNoKey
+	}
+
+	aead, err := newAEAD(keys[0])
+	if err != nil {
+		return "", err
+	}
+
+	data, err := ctx.App().JSONCodec().Marshal(s.values)
+	if err != nil {
+		return "", err
+	}
+
+	flag := byte(flagRaw)
+	if cs.config.compress && len(data) >= cs.config.compressMinLength {
+		if compressed, err := deflate(data); err == nil && len(compressed) < len(data) {
+			flag = flagDeflate
+			data = compressed
+		}
+	}
+
+	plaintext := make([]byte, headerSize+len(data))
+	plaintext[0] = flag
+	binary.BigEndian.PutUint64(plaintext[1:headerSize], uint64(ctx.Now().Unix()))
+	copy(plaintext[headerSize:], data)
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(ctx.Rand(), nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(s.Name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// decode 解密、校验签发时间、解压并反序列化会话数据，依次尝试所有的密钥
+func (cs *cookieStore) decode(ctx zeroapi.Context, name, value string) (map[string]interface{}, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	plaintext, err := cs.open(name, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plaintext) < headerSize {
+		return nil, ErrInvalid
+	}
+
+	issued := time.Unix(int64(binary.BigEndian.Uint64(plaintext[1:headerSize])), 0)
+	validator := timestamp.New(timestamp.WithMaxAge(cs.config.maxAge), timestamp.WithNow(ctx.Now))
+	if err := validator.Validate(issued); err != nil {
+		return nil, err
+	}
+
+	data := plaintext[headerSize:]
+	switch plaintext[0] {
+	case flagRaw:
+	case flagDeflate:
+		if data, err = inflate(data); err != nil {
+			return nil, ErrInvalid
+		}
+	default:
+		return nil, ErrInvalid
+	}
+
+	values := make(map[string]interface{})
+	if err := ctx.App().JSONCodec().Unmarshal(data, &values); err != nil {
+		return nil, ErrInvalid
+	}
+
+	return values, nil
+}
+
+// open 依次使用所有的密钥解密
+func (cs *cookieStore) open(name string, sealed []byte) ([]byte, error) {
+	keys := cs.keys.Keys()
+	if len(keys) == 0 {
+		return nil, ErrNoKey
+	}
+
+	for _, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(sealed) < aead.NonceSize() {
+			return nil, ErrInvalid
+		}
+
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return nil, ErrInvalid
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+
+	out, err := ioutil.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) > maxDecompressedSize {
+		return nil, ErrInvalid
+	}
+
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// flashKey 一次性消息在会话中的键
+const flashKey = "_flash"
+
+// flashStore 一次性消息保存在会话中
+type flashStore struct {
+	store Store
+	name  string
+}
+
+// NewFlashStore 一次性消息保存在名称为 name 的会话中，用于 app.WithFlashStore
+// 每次添加或者读取消息时都会保存会话
+func NewFlashStore(store Store, name string) zeroapi.FlashStore {
+	return &flashStore{store: store, name: name}
+}
+
+func (fs *flashStore) Load(ctx zeroapi.Context) (map[string][]string, error) {
+	s, err := fs.store.Get(ctx, fs.name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := s.Get(flashKey).(type) {
+	case map[string][]string:
+		return v, nil
+	case map[string]interface{}:
+		// 从 JSON 中读取
+		flashes := make(map[string][]string, len(v))
+		for key, messages := range v {
+			list, _ := messages.([]interface{})
+			for _, message := range list {
+				if str, ok := message.(string); ok {
+					flashes[key] = append(flashes[key], str)
+				}
+			}
+		}
+		return flashes, nil
+	}
+
+	return nil, nil
+}
+
+func (fs *flashStore) Save(ctx zeroapi.Context, flashes map[string][]string) error {
+	s, err := fs.store.Get(ctx, fs.name)
+	if err != nil && s == nil {
+		return err
+	}
+
+	if len(flashes) == 0 {
+		if s.Get(flashKey) == nil {
+			return nil
+		}
+		s.Delete(flashKey)
+	} else {
+		s.Set(flashKey, flashes)
+	}
+
+	return fs.store.Save(ctx, s)
+}
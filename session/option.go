@@ -0,0 +1,65 @@
+package session
+
+import (
+	"net/http"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// config 会话配置
+type config struct {
+	// maxAge 会话有效期，同时是 cookie 的 MaxAge
+	maxAge time.Duration
+
+	// compress 是否压缩会话数据
+	compress bool
+
+	// compressMinLength 数据小于该字节数时不压缩
+	compressMinLength int
+
+	// cookieOptions cookie 选项
+	cookieOptions []zeroapi.CookieOption
+}
+
+func defaultConfig() *config {
+	return &config{
+		maxAge:            24 * time.Hour,
+		compressMinLength: 256,
+		cookieOptions: []zeroapi.CookieOption{
+			func(cookie *http.Cookie) error {
+				cookie.Path = "/"
+				cookie.HttpOnly = true
+				cookie.SameSite = http.SameSiteLaxMode
+				return nil
+			},
+		},
+	}
+}
+
+// Option 会话配置选项
+type Option func(config *config)
+
+// WithMaxAge 设置会话有效期，默认 24 小时，过期的会话即使 cookie 仍然存在也会被丢弃
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(config *config) {
+		if maxAge > 0 {
+			config.maxAge = maxAge
+		}
+	}
+}
+
+// WithCompress 是否压缩会话数据，数据较多时可以减小 cookie 的大小
+func WithCompress(compress bool) Option {
+	return func(config *config) {
+		config.compress = compress
+	}
+}
+
+// WithCookieOptions 设置 cookie 选项，在 App 的 cookie 默认选项之后应用
+// 默认 Path=/，HttpOnly，SameSite=Lax
+func WithCookieOptions(opts ...zeroapi.CookieOption) Option {
+	return func(config *config) {
+		config.cookieOptions = append(config.cookieOptions, opts...)
+	}
+}
@@ -0,0 +1,99 @@
+// Package session 会话管理
+//
+// 示例:
+// store := session.NewCookieStore(session.StaticKeys(key), session.WithCompress(true))
+// sess, _ := store.Get(ctx, "session")
+// sess.Set("uid", 1001)
+// err := store.Save(ctx, sess)
+package session
+
+import (
+	"errors"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+var (
+	// ErrInvalid 会话数据无效，例如被篡改或者加密密钥已经被移除
+	ErrInvalid = errors.New("session: invalid")
+
+	// ErrNoKey 没有可用的密钥
+	ErrNoKey = errors.New("session: no key")
+)
+
+// Store 会话存储
+type Store interface {
+	// Get 获取名称为 name 的会话，同一个请求中多次获取返回同一个会话
+	// 会话不存在时返回新的会话，会话无效或者过期时同时返回错误
+	Get(ctx zeroapi.Context, name string) (*Session, error)
+
+	// Save 保存会话，需要在写入响应头之前调用，调用 Destroy 后会删除会话
+	Save(ctx zeroapi.Context, s *Session) error
+}
+
+// Keys 密钥提供者，第一个密钥用于加密，所有密钥都可以用于解密，便于轮换密钥
+// 轮换时将新密钥放在第一个，旧密钥在会话过期之后再移除
+type Keys interface {
+	Keys() [][]byte
+}
+
+type staticKeys [][]byte
+
+func (k staticKeys) Keys() [][]byte {
+	return k
+}
+
+// StaticKeys 固定的密钥，AES-128, AES-192, AES-256 分别需要 16, 24, 32 字节
+func StaticKeys(keys ...[]byte) Keys {
+	return staticKeys(keys)
+}
+
+// Session 会话
+type Session struct {
+	// Name 会话名称，即 cookie 名称
+	Name string
+
+	values    map[string]interface{}
+	isNew     bool
+	destroyed bool
+}
+
+// NewSession 创建一个新的会话
+func NewSession(name string) *Session {
+	return &Session{
+		Name:   name,
+		values: make(map[string]interface{}),
+		isNew:  true,
+	}
+}
+
+// Get 获取值，不存在时返回 nil
+func (s *Session) Get(key string) interface{} {
+	return s.values[key]
+}
+
+// Set 设置值，值需要能够序列化为 JSON，数字读取时为 float64
+func (s *Session) Set(key string, value interface{}) {
+	s.values[key] = value
+}
+
+// Delete 删除值
+func (s *Session) Delete(key string) {
+	delete(s.values, key)
+}
+
+// Values 获取所有的值
+func (s *Session) Values() map[string]interface{} {
+	return s.values
+}
+
+// IsNew 是否是新创建的会话
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// Destroy 销毁会话，之后调用 Store.Save 时删除会话
+func (s *Session) Destroy() {
+	s.values = make(map[string]interface{})
+	s.destroyed = true
+}
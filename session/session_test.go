@@ -0,0 +1,130 @@
+package session_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/session"
+	"github.com/zerogo-hub/zero-api/timestamp"
+)
+
+var (
+	oldKey = bytes.Repeat([]byte{1}, 32)
+	newKey = bytes.Repeat([]byte{2}, 32)
+)
+
+// roundTrip 保存会话，然后在下一个请求中读取
+func roundTrip(t *testing.T, a zeroapi.App, save, load session.Store, value string) (*session.Session, error) {
+	ctx := a.Context()
+	w := httptest.NewRecorder()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	s, err := save.Get(ctx, "sid")
+	if err != nil || !s.IsNew() {
+		t.Fatalf("expect new session: %v", err)
+	}
+	s.Set("uid", value)
+	if err := save.Save(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	ctx.Response().PrepareHeader()
+
+	cookie := w.Result().Cookies()[0]
+	if !cookie.HttpOnly || cookie.Path != "/" || strings.Contains(cookie.Value, value) {
+		t.Fatalf("invalid cookie: %v", cookie)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	ctx = a.Context()
+	ctx.Reset(httptest.NewRecorder(), req)
+
+	return load.Get(ctx, "sid")
+}
+
+func TestCookieStore(t *testing.T) {
+	a := app.NewApp()
+	store := session.NewCookieStore(session.StaticKeys(oldKey), session.WithCompress(true))
+
+	value := strings.Repeat("zero", 256)
+	s, err := roundTrip(t, a, store, store, value)
+	if err != nil || s.IsNew() || s.Get("uid") != value {
+		t.Fatalf("invalid session: %v, %v", err, s.Values())
+	}
+
+	// 轮换密钥后，旧密钥加密的会话仍然可以读取
+	rotated := session.NewCookieStore(session.StaticKeys(newKey, oldKey))
+	if s, err = roundTrip(t, a, store, rotated, "1001"); err != nil || s.Get("uid") != "1001" {
+		t.Fatalf("invalid session after rotation: %v", err)
+	}
+
+	// 旧密钥被移除
+	removed := session.NewCookieStore(session.StaticKeys(newKey))
+	if s, err = roundTrip(t, a, store, removed, "1001"); err != session.ErrInvalid || !s.IsNew() {
+		t.Fatalf("expect ErrInvalid, got: %v", err)
+	}
+}
+
+func TestCookieStoreExpired(t *testing.T) {
+	// 每次获取时间都前进 1 小时
+	now := time.Unix(1622534400, 0)
+	a := app.NewApp(app.WithNow(func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}))
+
+	saver := session.NewCookieStore(session.StaticKeys(oldKey))
+	loader := session.NewCookieStore(session.StaticKeys(oldKey), session.WithMaxAge(time.Minute))
+
+	if _, err := roundTrip(t, a, saver, loader, "1001"); err != timestamp.ErrExpired {
+		t.Fatalf("expect ErrExpired, got: %v", err)
+	}
+}
+
+func TestCookieStoreTooLarge(t *testing.T) {
+	a := app.NewApp()
+	store := session.NewCookieStore(session.StaticKeys(oldKey))
+
+	ctx := a.Context()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	s, _ := store.Get(ctx, "sid")
+	s.Set("data", strings.Repeat("x", 4096))
+	if err := store.Save(ctx, s); err != zeroapi.ErrCookieTooLarge {
+		t.Fatalf("expect ErrCookieTooLarge, got: %v", err)
+	}
+}
+
+func TestFlashStore(t *testing.T) {
+	store := session.NewCookieStore(session.StaticKeys(newKey))
+	a := app.NewApp(app.WithFlashStore(session.NewFlashStore(store, "sid")))
+
+	w := httptest.NewRecorder()
+	ctx := a.Context()
+	ctx.Reset(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	ctx.Flash("error", "invalid title")
+	ctx.Response().PrepareHeader()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(w.Result().Cookies()[0])
+	w = httptest.NewRecorder()
+	ctx = a.Context()
+	ctx.Reset(w, req)
+
+	flashes := ctx.Flashes()
+	if len(flashes["error"]) != 1 || flashes["error"][0] != "invalid title" {
+		t.Fatalf("invalid flashes: %v", flashes)
+	}
+	ctx.Response().PrepareHeader()
+
+	s, _ := store.Get(ctx, "sid")
+	if s.Get("_flash") != nil {
+		t.Fatalf("flashes should be removed from session: %v", s.Values())
+	}
+}
@@ -0,0 +1,69 @@
+package slo
+
+import "time"
+
+// config 导出配置
+type config struct {
+	// service 服务名称
+	service string
+
+	// namespace 指标名称前缀，与 prometheus.WithNamespace 一致
+	namespace string
+
+	// burnRate 错误预算消耗速度的告警阈值
+	burnRate float64
+
+	// burnWindow 计算消耗速度的时间范围
+	burnWindow time.Duration
+
+	// alertFor 持续多久后触发告警
+	alertFor time.Duration
+}
+
+func defaultConfig() *config {
+	return &config{
+		service: "zeroapi",
+		// 1 小时内消耗 30 天错误预算的 2%
+		burnRate:   14.4,
+		burnWindow: time.Hour,
+		alertFor:   2 * time.Minute,
+	}
+}
+
+// Option 导出配置选项
+type Option func(config *config)
+
+// WithService 设置服务名称，作为文档的 service，告警规则组的名称以及告警的 service 标签，默认 zeroapi
+func WithService(service string) Option {
+	return func(config *config) {
+		if service != "" {
+			config.service = service
+		}
+	}
+}
+
+// WithNamespace 设置指标名称前缀，需要与 prometheus.WithNamespace 一致
+func WithNamespace(namespace string) Option {
+	return func(config *config) {
+		config.namespace = namespace
+	}
+}
+
+// WithBurnRate 设置告警阈值，window 内错误预算的消耗速度超过 rate 倍时告警，默认 1 小时 14.4 倍
+func WithBurnRate(rate float64, window time.Duration) Option {
+	return func(config *config) {
+		if rate > 0 && window > 0 {
+			config.burnRate = rate
+			config.burnWindow = window
+		}
+	}
+}
+
+// WithAlertFor 设置持续多久后触发告警，默认 2 分钟
+func WithAlertFor(d time.Duration) Option {
+	return func(config *config) {
+		if d >= 0 {
+			config.alertFor = d
+		}
+	}
+}
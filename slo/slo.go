@@ -0,0 +1,239 @@
+// Package slo 导出路由声明的服务等级目标，由负责接口的代码生成告警配置
+// 告警规则基于 middleware/prometheus 记录的 http_requests_total 与 http_request_duration_seconds
+// 指标的 route 标签需要与路由定义一致，见 prometheus.WithRouteLabel
+//
+// 示例:
+// app.Handle(zeroapi.MethodGet, "/orders", zeroapi.RouteMiddleware{SLO: &zeroapi.SLO{Availability: 0.999}}, orders)
+// slo.Register(app, "/slo")
+// GET /slo 导出 JSON，GET /slo?format=yaml 导出 YAML，GET /slo?format=prometheus 导出 Prometheus 告警规则
+package slo
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FormatJSON JSON 文档
+	FormatJSON = "json"
+
+	// FormatYAML YAML 文档
+	FormatYAML = "yaml"
+
+	// FormatPrometheus Prometheus 告警规则
+	FormatPrometheus = "prometheus"
+)
+
+// defaultWindow 默认的统计周期
+const defaultWindow = 30 * 24 * time.Hour
+
+// Document 服务等级目标文档
+type Document struct {
+	Service string  `json:"service" yaml:"service"`
+	Routes  []Route `json:"routes" yaml:"routes"`
+}
+
+// Route 一个路由的服务等级目标，时间使用 time.Duration 的字符串格式，例如 300ms
+type Route struct {
+	Host          string            `json:"host,omitempty" yaml:"host,omitempty"`
+	Method        string            `json:"method" yaml:"method"`
+	Path          string            `json:"path" yaml:"path"`
+	Availability  float64           `json:"availability,omitempty" yaml:"availability,omitempty"`
+	Latency       string            `json:"latency,omitempty" yaml:"latency,omitempty"`
+	LatencyTarget float64           `json:"latency_target,omitempty" yaml:"latency_target,omitempty"`
+	Window        string            `json:"window" yaml:"window"`
+	Labels        map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// Register 注册导出服务等级目标的路由 path，例如 /slo，通过参数 format 选择格式，默认 JSON
+func Register(app zeroapi.App, path string, opts ...Option) {
+	app.Get(path, Handler(opts...))
+}
+
+// Handler 导出 ctx.App() 中所有路由声明的服务等级目标
+func Handler(opts ...Option) zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		slos := ctx.App().SLOs()
+
+		var err error
+		switch ctx.Query("format") {
+		case FormatYAML:
+			ctx.SetHeader("Content-Type", "application/yaml; charset=utf-8")
+			err = WriteYAML(ctx.Response(), slos, opts...)
+		case FormatPrometheus:
+			ctx.SetHeader("Content-Type", "application/yaml; charset=utf-8")
+			err = WritePrometheusRules(ctx.Response(), slos, opts...)
+		default:
+			ctx.SetHeader("Content-Type", "application/json; charset=utf-8")
+			err = WriteJSON(ctx.Response(), slos, opts...)
+		}
+
+		if err != nil {
+			ctx.App().Logger().Errorf("write slo failed: %s", err.Error())
+		}
+	}
+}
+
+// NewDocument 生成服务等级目标文档
+func NewDocument(slos []zeroapi.RouteSLO, opts ...Option) Document {
+	config := newConfig(opts)
+
+	doc := Document{Service: config.service, Routes: make([]Route, 0, len(slos))}
+	for _, s := range slos {
+		route := Route{
+			Host:          s.Host,
+			Method:        s.Method,
+			Path:          s.Path,
+			Availability:  s.SLO.Availability,
+			LatencyTarget: s.SLO.LatencyTarget,
+			Window:        window(s.SLO).String(),
+			Labels:        s.SLO.Labels,
+		}
+		if s.SLO.Latency > 0 {
+			route.Latency = s.SLO.Latency.String()
+		}
+		doc.Routes = append(doc.Routes, route)
+	}
+
+	return doc
+}
+
+// WriteJSON 以 JSON 格式写入服务等级目标文档
+func WriteJSON(w io.Writer, slos []zeroapi.RouteSLO, opts ...Option) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NewDocument(slos, opts...))
+}
+
+// WriteYAML 以 YAML 格式写入服务等级目标文档
+func WriteYAML(w io.Writer, slos []zeroapi.RouteSLO, opts ...Option) error {
+	encoder := yaml.NewEncoder(w)
+	defer encoder.Close()
+	return encoder.Encode(NewDocument(slos, opts...))
+}
+
+// ruleFile Prometheus 规则文件
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// WritePrometheusRules 写入 Prometheus 告警规则，错误预算的消耗速度超过阈值时告警
+// 可用性: 5xx 响应的比例，延迟: 耗时超过 Latency 的比例
+func WritePrometheusRules(w io.Writer, slos []zeroapi.RouteSLO, opts ...Option) error {
+	config := newConfig(opts)
+
+	prefix := ""
+	if config.namespace != "" {
+		prefix = config.namespace + "_"
+	}
+	requests := prefix + "http_requests_total"
+	duration := prefix + "http_request_duration_seconds"
+	rangeSelector := "[" + promDuration(config.burnWindow) + "]"
+
+	group := ruleGroup{Name: config.service + "-slo", Rules: []rule{}}
+	for _, s := range slos {
+		matchers := "method=" + strconv.Quote(s.Method) + ",route=" + strconv.Quote(s.Path)
+		name := s.Method + " " + s.Path
+
+		if target := s.SLO.Availability; target > 0 && target < 1 {
+			group.Rules = append(group.Rules, newRule(config, s, "SLOAvailabilityBurn",
+				"sum(rate("+requests+"{"+matchers+`,status=~"5.."}`+rangeSelector+")) / "+
+					"sum(rate("+requests+"{"+matchers+"}"+rangeSelector+")) > "+threshold(config, target),
+				name+" availability is burning error budget, target "+percent(target)))
+		}
+
+		if target := s.SLO.LatencyTarget; s.SLO.Latency > 0 && target > 0 && target < 1 {
+			le := strconv.FormatFloat(s.SLO.Latency.Seconds(), 'g', -1, 64)
+			group.Rules = append(group.Rules, newRule(config, s, "SLOLatencyBurn",
+				"1 - sum(rate("+duration+"_bucket{"+matchers+`,le="`+le+`"}`+rangeSelector+")) / "+
+					"sum(rate("+duration+"_count{"+matchers+"}"+rangeSelector+")) > "+threshold(config, target),
+				name+" latency above "+s.SLO.Latency.String()+" is burning error budget, target "+percent(target)))
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	defer encoder.Close()
+	return encoder.Encode(ruleFile{Groups: []ruleGroup{group}})
+}
+
+func newRule(config *config, s zeroapi.RouteSLO, alert, expr, summary string) rule {
+	labels := map[string]string{
+		"severity": "page",
+		"service":  config.service,
+		"method":   s.Method,
+		"route":    s.Path,
+	}
+	for key, value := range s.SLO.Labels {
+		labels[key] = value
+	}
+
+	r := rule{
+		Alert:  alert,
+		Expr:   expr,
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary": summary,
+			"window":  window(s.SLO).String(),
+		},
+	}
+	if config.alertFor > 0 {
+		r.For = promDuration(config.alertFor)
+	}
+
+	return r
+}
+
+func newConfig(opts []Option) *config {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// window 统计周期，没有设置时为 30 天
+func window(s zeroapi.SLO) time.Duration {
+	if s.Window > 0 {
+		return s.Window
+	}
+	return defaultWindow
+}
+
+// threshold 告警阈值，错误预算乘以消耗速度
+func threshold(config *config, target float64) string {
+	return strconv.FormatFloat(config.burnRate*(1-target), 'g', 6, 64)
+}
+
+func percent(target float64) string {
+	return strconv.FormatFloat(target*100, 'g', 6, 64) + "%"
+}
+
+// promDuration Prometheus 的时间格式，例如 1h, 5m, 30s
+func promDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	}
+	return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+}
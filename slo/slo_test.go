@@ -0,0 +1,99 @@
+package slo_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/slo"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSLO(t *testing.T) {
+	a := app.NewApp()
+	a.Prefix("/api")
+
+	handler := func(ctx zeroapi.Context) { ctx.Text("ok") }
+	a.Handle(zeroapi.MethodGet, "/orders/:id", zeroapi.RouteMiddleware{SLO: &zeroapi.SLO{
+		Availability:  0.999,
+		Latency:       300 * time.Millisecond,
+		LatencyTarget: 0.99,
+		Labels:        map[string]string{"team": "orders"},
+	}}, handler)
+	a.Host("admin.example.com").Handle(zeroapi.MethodPost, "/users", zeroapi.RouteMiddleware{SLO: &zeroapi.SLO{
+		Availability: 0.99,
+		Window:       7 * 24 * time.Hour,
+	}}, handler)
+	a.Get("/health", handler)
+	slo.Register(a, "/slo", slo.WithService("shop"))
+	a.Router().Build()
+
+	get := func(url string) string {
+		w := httptest.NewRecorder()
+		a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		body, _ := ioutil.ReadAll(w.Result().Body)
+		return string(body)
+	}
+
+	var doc slo.Document
+	if err := json.Unmarshal([]byte(get("/api/slo")), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Service != "shop" || len(doc.Routes) != 2 {
+		t.Fatalf("invalid document: %+v", doc)
+	}
+	if r := doc.Routes[0]; r.Path != "/api/orders/:id" || r.Latency != "300ms" || r.Window != "720h0m0s" || r.Labels["team"] != "orders" {
+		t.Fatalf("invalid route: %+v", r)
+	}
+	if r := doc.Routes[1]; r.Host != "admin.example.com" || r.Method != zeroapi.MethodPost || r.Window != "168h0m0s" {
+		t.Fatalf("invalid host route: %+v", r)
+	}
+
+	var yamlDoc slo.Document
+	if err := yaml.Unmarshal([]byte(get("/api/slo?format=yaml")), &yamlDoc); err != nil {
+		t.Fatal(err)
+	}
+	if len(yamlDoc.Routes) != 2 || yamlDoc.Routes[0].Path != "/api/orders/:id" {
+		t.Fatalf("invalid yaml document: %+v", yamlDoc)
+	}
+
+	var rules struct {
+		Groups []struct {
+			Name  string
+			Rules []struct {
+				Alert  string
+				Expr   string
+				For    string
+				Labels map[string]string
+			}
+		}
+	}
+	if err := yaml.Unmarshal([]byte(get("/api/slo?format=prometheus")), &rules); err != nil {
+		t.Fatal(err)
+	}
+	if len(rules.Groups) != 1 || rules.Groups[0].Name != "shop-slo" || len(rules.Groups[0].Rules) != 3 {
+		t.Fatalf("invalid rules: %+v", rules)
+	}
+
+	availability, latency := rules.Groups[0].Rules[0], rules.Groups[0].Rules[1]
+	if availability.Alert != "SLOAvailabilityBurn" || availability.For != "2m" || availability.Labels["team"] != "orders" ||
+		availability.Expr != `sum(rate(http_requests_total{method="GET",route="/api/orders/:id",status=~"5.."}[1h])) / `+
+			`sum(rate(http_requests_total{method="GET",route="/api/orders/:id"}[1h])) > 0.0144` {
+		t.Fatalf("invalid availability rule: %+v", availability)
+	}
+	if latency.Alert != "SLOLatencyBurn" ||
+		!strings.Contains(latency.Expr, `http_request_duration_seconds_bucket{method="GET",route="/api/orders/:id",le="0.3"}[1h]`) ||
+		!strings.HasSuffix(latency.Expr, "> 0.144") {
+		t.Fatalf("invalid latency rule: %+v", latency)
+	}
+	if host := rules.Groups[0].Rules[2]; host.Labels["route"] != "/api/users" || !strings.HasSuffix(host.Expr, "> 0.144") {
+		t.Fatalf("invalid host rule: %+v", host)
+	}
+}
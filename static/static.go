@@ -0,0 +1,251 @@
+// Package static 静态资源服务，支持首页文件、目录列表、ETag/Last-Modified 缓存以及 Range 请求
+//
+// 示例:
+// app.Static("/assets", "./public")
+// app.Static("/files", "./files", zeroapi.StaticConfig{Browse: true})
+// app.StaticFS("/assets", embeddedFS)
+package static
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// Param 通配符参数名称，注册路由时使用 prefix + "/*" + Param，文件路径从该参数中获取
+const Param = "filepath"
+
+// defaultIndex 默认首页文件
+var defaultIndex = []string{"index.html"}
+
+type static struct {
+	prefix string
+	root   http.FileSystem
+	config zeroapi.StaticConfig
+
+	// cacheControl Cache-Control 的值
+	cacheControl string
+
+	// etags 没有修改时间的文件(例如 embed.FS)，根据内容生成的 ETag，key: 文件路径
+	etags sync.Map
+}
+
+// New 创建静态资源处理函数
+// prefix: 路由前缀，路由没有通配符参数 Param 时，请求路径去掉前缀之后作为文件路径
+// root: 文件系统，例如 http.Dir("./public")
+func New(prefix string, root http.FileSystem, config ...zeroapi.StaticConfig) zeroapi.Handler {
+	s := &static{prefix: strings.TrimSuffix(prefix, "/"), root: root}
+
+	if len(config) > 0 {
+		s.config = config[0]
+	}
+	if len(s.config.Index) == 0 {
+		s.config.Index = defaultIndex
+	}
+	if s.config.MaxAge > 0 {
+		s.cacheControl = "public, max-age=" + strconv.Itoa(int(s.config.MaxAge.Seconds()))
+	}
+
+	return s.handle
+}
+
+// NewFS 创建静态资源处理函数，文件来自 fs.FS，例如 embed.FS
+// embed.FS 中的文件没有修改时间，ETag 根据文件内容生成，Last-Modified 使用可执行文件的修改时间
+// 如果资源位于 embed.FS 的子目录中，可以通过 fs.Sub 获取子目录
+func NewFS(prefix string, fsys fs.FS, config ...zeroapi.StaticConfig) zeroapi.Handler {
+	return New(prefix, http.FS(fsys), config...)
+}
+
+func (s *static) handle(ctx zeroapi.Context) {
+	urlPath := ctx.Request().URL.Path
+
+	// 优先使用路由通配符匹配的路径，路由前缀、组路由前缀不需要与 prefix 一致
+	rel := ctx.Dynamic(Param)
+	if rel == "" {
+		if !strings.HasPrefix(urlPath, s.prefix) {
+			ctx.NotFound()
+			return
+		}
+		rel = urlPath[len(s.prefix):]
+	}
+
+	name := path.Clean("/" + rel)
+
+	f, err := s.root.Open(name)
+	if err != nil {
+		s.openError(ctx, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		s.openError(ctx, err)
+		return
+	}
+
+	if info.IsDir() {
+		// 目录需要以 "/" 结尾，否则页面中的相对路径会出错
+		if !strings.HasSuffix(urlPath, "/") {
+			redirect(ctx, path.Base(urlPath)+"/")
+			return
+		}
+
+		for _, index := range s.config.Index {
+			if s.serveFile(ctx, path.Join(name, index)) {
+				return
+			}
+		}
+
+		if !s.config.Browse {
+			ctx.NotFound()
+			return
+		}
+
+		s.list(ctx, f)
+		return
+	}
+
+	s.serveContent(ctx, f, info, name)
+}
+
+// serveFile 输出文件，文件不存在或者是目录时返回 false
+func (s *static) serveFile(ctx zeroapi.Context, name string) bool {
+	f, err := s.root.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	s.serveContent(ctx, f, info, name)
+	return true
+}
+
+// serveContent 输出文件内容，由 http.ServeContent 处理 If-None-Match、If-Modified-Since 以及 Range
+func (s *static) serveContent(ctx zeroapi.Context, f http.File, info os.FileInfo, name string) {
+	modTime := info.ModTime()
+
+	if modTime.IsZero() {
+		etag, err := s.contentETag(f, name)
+		if err != nil {
+			ctx.SetHTTPCode(http.StatusInternalServerError)
+			return
+		}
+		ctx.SetHeader("ETag", etag)
+		modTime = executableModTime()
+	} else {
+		ctx.SetHeader("ETag", ETag(info))
+	}
+
+	if s.cacheControl != "" {
+		ctx.SetHeader("Cache-Control", s.cacheControl)
+	}
+
+	http.ServeContent(ctx.Response(), ctx.Request(), info.Name(), modTime, f)
+}
+
+// contentETag 根据文件内容生成强 ETag，文件内容不会改变，所以只计算一次
+func (s *static) contentETag(f http.File, name string) (string, error) {
+	if etag, ok := s.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.etags.Store(name, etag)
+	return etag, nil
+}
+
+// list 输出目录列表
+func (s *static) list(ctx zeroapi.Context, f http.File) {
+	infos, err := f.Readdir(-1)
+	if err != nil {
+		ctx.SetHTTPCode(http.StatusInternalServerError)
+		return
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"></head><body><pre>\n")
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() {
+			name += "/"
+		}
+
+		href := url.URL{Path: name}
+		fmt.Fprintf(&b, "<a href=\"%s\">%s</a>\n", template.HTMLEscapeString(href.String()), template.HTMLEscapeString(name))
+	}
+	b.WriteString("</pre></body></html>\n")
+
+	ctx.SetHeader("Content-Type", "text/html;charset=utf-8")
+	ctx.Text(b.String())
+}
+
+func (s *static) openError(ctx zeroapi.Context, err error) {
+	if os.IsPermission(err) {
+		ctx.SetHTTPCode(http.StatusForbidden)
+		return
+	}
+
+	ctx.NotFound()
+}
+
+// redirect 相对路径重定向，保留查询参数
+func redirect(ctx zeroapi.Context, location string) {
+	if q := ctx.Request().URL.RawQuery; q != "" {
+		location += "?" + q
+	}
+
+	ctx.SetHeader("Location", location)
+	ctx.SetHTTPCode(http.StatusMovedPermanently)
+}
+
+var (
+	exeModTime     time.Time
+	exeModTimeOnce sync.Once
+)
+
+// executableModTime 可执行文件的修改时间，获取失败时为零值，此时不发送 Last-Modified
+func executableModTime() time.Time {
+	exeModTimeOnce.Do(func() {
+		if exe, err := os.Executable(); err == nil {
+			if info, err := os.Stat(exe); err == nil {
+				exeModTime = info.ModTime()
+			}
+		}
+	})
+	return exeModTime
+}
+
+// ETag 根据文件大小与修改时间生成弱 ETag
+func ETag(info os.FileInfo) string {
+	return fmt.Sprintf(`W/"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
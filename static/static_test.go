@@ -0,0 +1,116 @@
+package static_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+)
+
+func newApp(config ...zeroapi.StaticConfig) zeroapi.App {
+	a := app.NewApp()
+	a.Static("/assets", "./testdata", config...)
+	a.Router().Build()
+	return a
+}
+
+func serve(a zeroapi.App, req *http.Request) (*http.Response, string) {
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, req)
+	res := w.Result()
+	body, _ := ioutil.ReadAll(res.Body)
+	return res, string(body)
+}
+
+func TestStaticFile(t *testing.T) {
+	a := newApp()
+
+	res, body := serve(a, httptest.NewRequest(http.MethodGet, "/assets/hello.txt", nil))
+	if res.StatusCode != http.StatusOK || body != "hello static" {
+		t.Fatalf("invalid response: %d %s", res.StatusCode, body)
+	}
+
+	etag := res.Header.Get("ETag")
+	if etag == "" || res.Header.Get("Last-Modified") == "" {
+		t.Fatal("cache headers not set")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/hello.txt", nil)
+	req.Header.Set("If-None-Match", etag)
+	if res, _ := serve(a, req); res.StatusCode != http.StatusNotModified {
+		t.Fatalf("want 304, got: %d", res.StatusCode)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/assets/hello.txt", nil)
+	req.Header.Set("Range", "bytes=0-4")
+	if res, body := serve(a, req); res.StatusCode != http.StatusPartialContent || body != "hello" {
+		t.Fatalf("invalid range response: %d %s", res.StatusCode, body)
+	}
+}
+
+func TestStaticDirectory(t *testing.T) {
+	a := newApp()
+
+	if res, body := serve(a, httptest.NewRequest(http.MethodGet, "/assets/", nil)); !strings.Contains(body, "home") {
+		t.Fatalf("index not served: %d %s", res.StatusCode, body)
+	}
+
+	if res, _ := serve(a, httptest.NewRequest(http.MethodGet, "/assets", nil)); res.StatusCode != http.StatusMovedPermanently {
+		t.Fatalf("want redirect, got: %d", res.StatusCode)
+	}
+
+	if res, _ := serve(a, httptest.NewRequest(http.MethodGet, "/assets/docs/", nil)); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("listing should be disabled: %d", res.StatusCode)
+	}
+
+	if res, _ := serve(a, httptest.NewRequest(http.MethodGet, "/assets/../static.go", nil)); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("should not escape root: %d", res.StatusCode)
+	}
+
+	a = newApp(zeroapi.StaticConfig{Browse: true})
+	if _, body := serve(a, httptest.NewRequest(http.MethodGet, "/assets/docs/", nil)); !strings.Contains(body, `href="a.txt"`) {
+		t.Fatalf("invalid listing: %s", body)
+	}
+}
+
+func TestStaticFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":  {Data: []byte("<h1>embedded</h1>")},
+		"js/app.js":   {Data: []byte("console.log(1)")},
+		"js/other.js": {Data: []byte("console.log(2)")},
+	}
+
+	a := app.NewApp()
+	a.StaticFS("/ui", fsys)
+	a.Router().Build()
+
+	if _, body := serve(a, httptest.NewRequest(http.MethodGet, "/ui/", nil)); !strings.Contains(body, "embedded") {
+		t.Fatalf("index not served: %s", body)
+	}
+
+	res, body := serve(a, httptest.NewRequest(http.MethodGet, "/ui/js/app.js", nil))
+	if body != "console.log(1)" {
+		t.Fatalf("invalid body: %s", body)
+	}
+
+	etag := res.Header.Get("ETag")
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("embedded files should have a content etag: %s", etag)
+	}
+
+	other, _ := serve(a, httptest.NewRequest(http.MethodGet, "/ui/js/other.js", nil))
+	if other.Header.Get("ETag") == etag {
+		t.Fatal("files with the same size should have different etags")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ui/js/app.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	if res, _ := serve(a, req); res.StatusCode != http.StatusNotModified {
+		t.Fatalf("want 304, got: %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,54 @@
+package timestamp
+
+import (
+	"time"
+)
+
+// config 时间戳校验配置
+type config struct {
+	// skew 允许的时钟偏差，时间戳比当前时间晚不超过 skew 时仍然有效
+	skew time.Duration
+
+	// maxAge 时间戳的有效期，<= 0 表示不限制
+	maxAge time.Duration
+
+	// now 获取当前时间
+	now func() time.Time
+}
+
+func defaultConfig() *config {
+	return &config{
+		skew: DefaultSkew,
+		now:  time.Now,
+	}
+}
+
+// Option 时间戳校验配置选项
+type Option func(config *config)
+
+// WithSkew 设置允许的时钟偏差，默认 30 秒
+// 签发方与校验方的时钟不完全一致，时间戳可能比当前时间稍晚，同时有效期也会放宽 skew
+func WithSkew(skew time.Duration) Option {
+	return func(config *config) {
+		if skew >= 0 {
+			config.skew = skew
+		}
+	}
+}
+
+// WithMaxAge 设置时间戳的有效期，<= 0 表示不限制，默认不限制
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(config *config) {
+		config.maxAge = maxAge
+	}
+}
+
+// WithNow 设置获取当前时间的函数，默认 time.Now
+// 在请求中可以使用 ctx.App().Now，与 ctx.Now() 保持一致
+func WithNow(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
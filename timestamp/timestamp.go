@@ -0,0 +1,92 @@
+// Package timestamp 时间戳签发与校验，容忍一定的时钟偏差
+// 用于 cookie 签名、webhook 校验、防重放等需要校验时间戳的场景，避免各处自行计算 time.Now()
+//
+// 示例:
+// v := timestamp.New(timestamp.WithMaxAge(5*time.Minute), timestamp.WithNow(app.Now))
+// err := v.ValidateString(r.Header.Get("X-Timestamp"))
+package timestamp
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// DefaultSkew 默认允许的时钟偏差
+const DefaultSkew = 30 * time.Second
+
+var (
+	// ErrInvalid 时间戳格式错误
+	ErrInvalid = errors.New("timestamp: invalid")
+
+	// ErrExpired 时间戳已过期
+	ErrExpired = errors.New("timestamp: expired")
+
+	// ErrFuture 时间戳晚于当前时间，超过允许的时钟偏差
+	ErrFuture = errors.New("timestamp: in the future")
+)
+
+// Validator 时间戳校验
+type Validator struct {
+	config *config
+}
+
+// New 创建时间戳校验
+func New(opts ...Option) *Validator {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Validator{config: config}
+}
+
+// Now 获取当前时间，签发时间戳时使用，与校验使用同一个时钟
+func (v *Validator) Now() time.Time {
+	return v.config.now()
+}
+
+// Stamp 签发当前时间的时间戳，格式见 Format
+func (v *Validator) Stamp() string {
+	return Format(v.Now())
+}
+
+// Validate 校验时间戳 t
+func (v *Validator) Validate(t time.Time) error {
+	now := v.Now()
+
+	if t.After(now.Add(v.config.skew)) {
+		return ErrFuture
+	}
+
+	if v.config.maxAge > 0 && now.Sub(t) > v.config.maxAge+v.config.skew {
+		return ErrExpired
+	}
+
+	return nil
+}
+
+// ValidateString 校验 Format 格式的时间戳
+func (v *Validator) ValidateString(s string) error {
+	t, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	return v.Validate(t)
+}
+
+// Format 将时间格式化为 Unix 秒
+func Format(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// Parse 解析 Unix 秒格式的时间戳
+func Parse(s string) (time.Time, error) {
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || sec < 0 {
+		return time.Time{}, ErrInvalid
+	}
+
+	return time.Unix(sec, 0), nil
+}
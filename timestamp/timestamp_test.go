@@ -0,0 +1,42 @@
+package timestamp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/timestamp"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1622534400, 0)
+	v := timestamp.New(
+		timestamp.WithSkew(10*time.Second),
+		timestamp.WithMaxAge(time.Minute),
+		timestamp.WithNow(func() time.Time { return now }),
+	)
+
+	cases := []struct {
+		t   time.Time
+		err error
+	}{
+		{now, nil},
+		{now.Add(10 * time.Second), nil},
+		{now.Add(11 * time.Second), timestamp.ErrFuture},
+		{now.Add(-70 * time.Second), nil},
+		{now.Add(-71 * time.Second), timestamp.ErrExpired},
+	}
+
+	for _, c := range cases {
+		if err := v.Validate(c.t); err != c.err {
+			t.Fatalf("%v: expect %v, got %v", now.Sub(c.t), c.err, err)
+		}
+	}
+
+	if err := v.ValidateString(v.Stamp()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.ValidateString("abc"); err != timestamp.ErrInvalid {
+		t.Fatalf("expect ErrInvalid, got %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+// Package validator 根据结构体 validate 标签验证字段
+//
+// 标签格式: validate:"rule1,rule2=param"
+// 内置规则:
+// required: 不能为零值
+// omitempty: 为零值时不验证其它规则
+// min=n: 字符串，切片，map 的长度不小于 n，数值不小于 n
+// max=n: 字符串，切片，map 的长度不大于 n，数值不大于 n
+// len=n: 字符串，切片，map 的长度等于 n
+// email: 邮箱格式
+// oneof=a b c: 值为其中之一
+//
+// 零值同样需要满足规则，例如 validate:"min=18" 的 0 验证失败，可选字段使用 omitempty
+// 值为 nil 的指针不验证，除非设置了 required
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// tagName 验证规则使用的标签
+	tagName = "validate"
+)
+
+// Finder 根据规则名称查找自定义验证函数，一般为 App.Validator
+type Finder func(name string) zeroapi.StructValidator
+
+// Validate 验证结构体 v，v 可以是结构体或者结构体指针
+// 验证失败时返回 zeroapi.ValidationErrors
+func Validate(v interface{}, finder Finder) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs zeroapi.ValidationErrors
+	if err := validateStruct(rv, "", finder, &errs); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func validateStruct(rv reflect.Value, prefix string, finder Finder, errs *zeroapi.ValidationErrors) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		fv := rv.Field(i)
+		name := fieldName(field)
+
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+
+		path := name
+		if field.Anonymous {
+			path = strings.TrimSuffix(prefix, ".")
+		} else if prefix != "" {
+			path = prefix + name
+		}
+
+		if tag != "" {
+			if err := validateField(fv, path, tag, finder, errs); err != nil {
+				return err
+			}
+		}
+
+		// 嵌套结构体
+		nested := fv
+		for nested.Kind() == reflect.Ptr && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && nested.Type().NumField() > 0 && nested.Type().PkgPath() != "time" {
+			nestedPrefix := path + "."
+			if path == "" {
+				nestedPrefix = ""
+			}
+			if err := validateStruct(nested, nestedPrefix, finder, errs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateField 验证单个字段
+func validateField(fv reflect.Value, path, tag string, finder Finder, errs *zeroapi.ValidationErrors) error {
+	rules := strings.Split(tag, ",")
+
+	required, omitempty := false, false
+	for _, rule := range rules {
+		switch strings.TrimSpace(rule) {
+		case "required":
+			required = true
+		case "omitempty":
+			omitempty = true
+		}
+	}
+
+	if isZero(fv) {
+		if required {
+			*errs = append(*errs, &zeroapi.FieldError{
+				Field:   path,
+				Rule:    "required",
+				Message: fmt.Sprintf("%s is required", path),
+			})
+			return nil
+		}
+		if omitempty {
+			return nil
+		}
+	}
+
+	value := indirect(fv)
+	if !value.IsValid() {
+		// nil 指针没有可以验证的值
+		return nil
+	}
+
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" || rule == "required" || rule == "omitempty" {
+			continue
+		}
+
+		name, param := rule, ""
+		if pos := strings.Index(rule, "="); pos >= 0 {
+			name, param = rule[:pos], rule[pos+1:]
+		}
+
+		ok, message, err := check(value, path, name, param, finder)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			*errs = append(*errs, &zeroapi.FieldError{Field: path, Rule: name, Param: param, Message: message})
+		}
+	}
+
+	return nil
+}
+
+// check 执行一条规则
+func check(value reflect.Value, path, name, param string, finder Finder) (bool, string, error) {
+	switch name {
+	case "min":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, "", fmt.Errorf("validator: invalid param of min on %s", path)
+		}
+		return size(value) >= n, fmt.Sprintf("%s must be at least %s", path, param), nil
+	case "max":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, "", fmt.Errorf("validator: invalid param of max on %s", path)
+		}
+		return size(value) <= n, fmt.Sprintf("%s must be at most %s", path, param), nil
+	case "len":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, "", fmt.Errorf("validator: invalid param of len on %s", path)
+		}
+		return size(value) == n, fmt.Sprintf("%s must have length %s", path, param), nil
+	case "email":
+		return isEmail(value), fmt.Sprintf("%s must be a valid email address", path), nil
+	case "oneof":
+		s := fmt.Sprint(value.Interface())
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return true, "", nil
+			}
+		}
+		return false, fmt.Sprintf("%s must be one of [%s]", path, param), nil
+	}
+
+	if finder != nil {
+		if validator := finder(name); validator != nil {
+			return validator(value.Interface(), param), fmt.Sprintf("%s failed on the %s rule", path, name), nil
+		}
+	}
+
+	return false, "", fmt.Errorf("validator: unknown rule %s on %s", name, path)
+}
+
+// size 字符串，切片，map 返回长度，数值返回值本身
+func size(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(len([]rune(value.String())))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	}
+
+	return 0
+}
+
+func isEmail(value reflect.Value) bool {
+	if value.Kind() != reflect.String {
+		return false
+	}
+
+	s := value.String()
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+
+	at := strings.LastIndex(s, "@")
+	return at > 0 && strings.Contains(s[at+1:], ".")
+}
+
+func isZero(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return fv.IsNil()
+	case reflect.Slice, reflect.Map:
+		return fv.Len() == 0
+	}
+
+	return fv.IsZero()
+}
+
+func indirect(fv reflect.Value) reflect.Value {
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		fv = fv.Elem()
+	}
+
+	return fv
+}
+
+// fieldName 优先使用 json, form, query 标签中的名称
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "query"} {
+		name := field.Tag.Get(key)
+		if pos := strings.Index(name, ","); pos >= 0 {
+			name = name[:pos]
+		}
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
@@ -0,0 +1,101 @@
+package validator_test
+
+import (
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	"github.com/zerogo-hub/zero-api/validator"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type user struct {
+	Name    string   `json:"name" validate:"required,min=3,max=8"`
+	Email   string   `json:"email" validate:"omitempty,email"`
+	Age     int      `form:"age" validate:"min=18"`
+	Role    string   `validate:"oneof=admin user"`
+	Tags    []string `validate:"omitempty,max=2"`
+	Code    string   `validate:"omitempty,prefix=zero"`
+	Score   *int     `validate:"min=1"`
+	Address *address `json:"address"`
+}
+
+func prefix(value interface{}, param string) bool {
+	s, ok := value.(string)
+	return ok && strings.HasPrefix(s, param)
+}
+
+func finder(name string) zeroapi.StructValidator {
+	if name == "prefix" {
+		return prefix
+	}
+
+	return nil
+}
+
+func TestValidateSuccess(t *testing.T) {
+	u := &user{Name: "Yaha", Email: "yaha@example.com", Age: 18, Role: "admin", Code: "zero-1", Address: &address{City: "Gama"}}
+	if err := validator.Validate(u, finder); err != nil {
+		t.Fatal(err)
+	}
+
+	// omitempty 字段为零值，以及 nil 指针时不验证
+	if err := validator.Validate(&user{Name: "Yaha", Age: 18, Role: "user"}, finder); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateZero(t *testing.T) {
+	zero := 0
+	err := validator.Validate(&user{Name: "Yaha", Score: &zero}, finder)
+	errs, ok := err.(zeroapi.ValidationErrors)
+	if !ok {
+		t.Fatalf("invalid error: %v", err)
+	}
+
+	// 零值同样需要满足 min, oneof
+	expected := []string{"age:min", "Role:oneof", "Score:min"}
+	if len(errs) != len(expected) {
+		t.Fatalf("invalid errors: %v", errs)
+	}
+
+	for i, fe := range errs {
+		if fe.Field+":"+fe.Rule != expected[i] {
+			t.Fatalf("invalid error %d: %s:%s", i, fe.Field, fe.Rule)
+		}
+	}
+}
+
+func TestValidateFailed(t *testing.T) {
+	u := &user{Email: "yaha", Age: 1, Role: "root", Tags: []string{"a", "b", "c"}, Code: "one", Address: &address{}}
+
+	err := validator.Validate(u, finder)
+	errs, ok := err.(zeroapi.ValidationErrors)
+	if !ok {
+		t.Fatalf("invalid error: %v", err)
+	}
+
+	expected := []string{"name:required", "email:email", "age:min", "Role:oneof", "Tags:max", "Code:prefix", "address.city:required"}
+	if len(errs) != len(expected) {
+		t.Fatalf("invalid errors: %v", errs)
+	}
+
+	for i, fe := range errs {
+		if fe.Field+":"+fe.Rule != expected[i] {
+			t.Fatalf("invalid error %d: %s:%s", i, fe.Field, fe.Rule)
+		}
+	}
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	v := struct {
+		Name string `validate:"fake"`
+	}{Name: "Yaha"}
+
+	if err := validator.Validate(v, nil); err == nil {
+		t.Fatal("unknown rule should fail")
+	}
+}
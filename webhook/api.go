@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+// RegisterAPI 在 g 中注册查询投递状态的路由，g 一般需要添加鉴权中间件
+//
+// GET  /endpoints                  接收方列表，不包括密钥
+// GET  /deliveries                 投递记录，支持查询参数 endpoint, event, status, limit
+// GET  /deliveries/:id             投递记录
+// POST /deliveries/:id/redeliver   重新投递
+func RegisterAPI(g zeroapi.Group, d *Dispatcher) {
+	g.Get("/endpoints", func(ctx zeroapi.Context) {
+		_, _ = ctx.JSON(d.Endpoints())
+	})
+
+	g.Get("/deliveries", zeroapi.E(func(ctx zeroapi.Context) error {
+		q := Query{
+			EndpointID: ctx.Query("endpoint"),
+			Event:      ctx.Query("event"),
+			Status:     Status(ctx.Query("status")),
+			Limit:      100,
+		}
+		if v := ctx.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return zeroapi.NewHTTPError(http.StatusBadRequest, "invalid limit")
+			}
+			q.Limit = n
+		}
+
+		list, err := d.Deliveries(q)
+		if err != nil {
+			return err
+		}
+		if list == nil {
+			list = []*Delivery{}
+		}
+		_, err = ctx.JSON(list)
+		return err
+	}))
+
+	g.Get("/deliveries/:id", zeroapi.E(func(ctx zeroapi.Context) error {
+		delivery, err := d.Delivery(ctx.Dynamic("id"))
+		if err != nil {
+			return apiError(err)
+		}
+		_, err = ctx.JSON(delivery)
+		return err
+	}))
+
+	g.Post("/deliveries/:id/redeliver", zeroapi.E(func(ctx zeroapi.Context) error {
+		delivery, err := d.Redeliver(ctx.Dynamic("id"))
+		if err != nil {
+			return apiError(err)
+		}
+		_, err = ctx.JSONWithCode(http.StatusAccepted, delivery)
+		return err
+	}))
+}
+
+func apiError(err error) error {
+	switch err {
+	case ErrNotFound:
+		return zeroapi.NewHTTPError(http.StatusNotFound).Wrap(err)
+	case ErrInProgress:
+		return zeroapi.NewHTTPError(http.StatusConflict).Wrap(err)
+	case ErrClosed:
+		return zeroapi.NewHTTPError(http.StatusServiceUnavailable).Wrap(err)
+	}
+	return err
+}
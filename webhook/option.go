@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"io"
+	"net/http"
+	"time"
+)
+
+// config webhook 投递配置
+type config struct {
+	// client 发送请求的 http 客户端
+	client *http.Client
+
+	// maxAttempts 最多投递次数，超过后进入死信
+	maxAttempts int
+
+	// minBackoff 第一次重试前的等待时间，之后每次翻倍
+	minBackoff time.Duration
+
+	// maxBackoff 重试等待时间的上限
+	maxBackoff time.Duration
+
+	// header 签名所在的请求头
+	header string
+
+	// now 获取当前时间
+	now func() time.Time
+
+	// rand 随机数来源，用于生成投递 id
+	rand io.Reader
+}
+
+func defaultConfig() *config {
+	return &config{
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: 5,
+		minBackoff:  time.Second,
+		maxBackoff:  time.Hour,
+		header:      DefaultSignatureHeader,
+		now:         time.Now,
+		rand:        rand.Reader,
+	}
+}
+
+// Option webhook 投递配置选项
+type Option func(config *config)
+
+// WithClient 设置发送请求的 http 客户端，默认超时 10 秒
+func WithClient(client *http.Client) Option {
+	return func(config *config) {
+		if client != nil {
+			config.client = client
+		}
+	}
+}
+
+// WithMaxAttempts 设置最多投递次数，默认 5 次，全部失败后状态为 StatusDead
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(config *config) {
+		if maxAttempts > 0 {
+			config.maxAttempts = maxAttempts
+		}
+	}
+}
+
+// WithBackoff 设置重试等待时间，第一次重试等待 min，之后每次翻倍，最多等待 max
+// 默认 1 秒至 1 小时
+func WithBackoff(min, max time.Duration) Option {
+	return func(config *config) {
+		if min > 0 && max >= min {
+			config.minBackoff = min
+			config.maxBackoff = max
+		}
+	}
+}
+
+// WithSignatureHeader 设置签名所在的请求头，默认 X-Webhook-Signature
+func WithSignatureHeader(header string) Option {
+	return func(config *config) {
+		if header != "" {
+			config.header = header
+		}
+	}
+}
+
+// WithNow 设置获取当前时间的函数，默认 time.Now，一般使用 app.Now
+func WithNow(now func() time.Time) Option {
+	return func(config *config) {
+		if now != nil {
+			config.now = now
+		}
+	}
+}
+
+// WithRand 设置随机数来源，默认 crypto/rand，一般使用 app.Rand()
+func WithRand(rand io.Reader) Option {
+	return func(config *config) {
+		if rand != nil {
+			config.rand = rand
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/zerogo-hub/zero-api/timestamp"
+)
+
+// DefaultSignatureHeader 默认签名请求头
+const DefaultSignatureHeader = "X-Webhook-Signature"
+
+// ErrSignature 签名格式错误或不匹配
+var ErrSignature = errors.New("webhook: invalid signature")
+
+// Sign 对请求体签名，格式为 t=<Unix 秒>,v1=<hex(HMAC-SHA256(secret, t + "." + body))>
+// 时间戳参与签名，接收方校验时间戳以防止重放
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := timestamp.Format(t)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// Verify 接收方校验签名，v 用于校验时间戳，为 nil 时不校验时间戳
+// 签名中可以有多个 v1，轮换密钥时发送方可以同时使用新旧密钥签名
+func Verify(secret, signature string, body []byte, v *timestamp.Validator) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(signature, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			sigs = append(sigs, kv[1])
+		}
+	}
+
+	if ts == "" || len(sigs) == 0 {
+		return ErrSignature
+	}
+
+	t, err := timestamp.Parse(ts)
+	if err != nil {
+		return err
+	}
+
+	expected := mac(secret, ts, body)
+	for _, sig := range sigs {
+		got, err := hex.DecodeString(sig)
+		if err == nil && hmac.Equal(got, expected) {
+			if v != nil {
+				return v.Validate(t)
+			}
+			return nil
+		}
+	}
+
+	return ErrSignature
+}
+
+func mac(secret, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
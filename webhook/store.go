@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status 投递状态
+type Status string
+
+const (
+	// StatusPending 等待投递或等待重试
+	StatusPending Status = "pending"
+
+	// StatusSucceeded 投递成功，接收方响应 2xx
+	StatusSucceeded Status = "succeeded"
+
+	// StatusDead 多次投递均失败，或者 endpoint 已被移除，进入死信，可以通过 Redeliver 重新投递
+	StatusDead Status = "dead"
+)
+
+// ErrNotFound 投递记录不存在
+var ErrNotFound = errors.New("webhook: delivery not found")
+
+// Delivery 一次事件对一个 endpoint 的投递记录
+type Delivery struct {
+	// ID 投递 id，通过请求头 X-Webhook-Delivery 发送给接收方，可用于去重
+	ID string `json:"id"`
+
+	// EndpointID 接收方 id
+	EndpointID string `json:"endpointId"`
+
+	// Event 事件类型
+	Event string `json:"event"`
+
+	// Payload 请求体
+	Payload json.RawMessage `json:"payload"`
+
+	// Status 投递状态
+	Status Status `json:"status"`
+
+	// Attempts 已投递次数
+	Attempts int `json:"attempts"`
+
+	// StatusCode 最近一次投递接收方响应的状态码，请求失败时为 0
+	StatusCode int `json:"statusCode,omitempty"`
+
+	// LastError 最近一次投递失败的原因
+	LastError string `json:"lastError,omitempty"`
+
+	// NextAttempt 下一次投递的时间，仅 StatusPending 时有效
+	NextAttempt time.Time `json:"nextAttempt,omitempty"`
+
+	// CreatedAt 创建时间
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt 最近一次更新的时间
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Query 投递记录查询条件，字段为空时不作为条件
+type Query struct {
+	// EndpointID 接收方 id
+	EndpointID string
+
+	// Event 事件类型
+	Event string
+
+	// Status 投递状态，查询死信使用 StatusDead
+	Status Status
+
+	// Limit 最多返回的记录数，<= 0 表示不限制
+	Limit int
+}
+
+// Match 投递记录是否满足查询条件
+func (q Query) Match(d *Delivery) bool {
+	return (q.EndpointID == "" || q.EndpointID == d.EndpointID) &&
+		(q.Event == "" || q.Event == d.Event) &&
+		(q.Status == "" || q.Status == d.Status)
+}
+
+// Store 投递记录存储，死信即状态为 StatusDead 的记录
+// 传入与返回的 *Delivery 由调用方持有，实现需要保存副本
+type Store interface {
+	// Save 保存投递记录，已存在时覆盖
+	Save(d *Delivery) error
+
+	// Get 获取投递记录，不存在时返回 ErrNotFound
+	Get(id string) (*Delivery, error)
+
+	// List 查询投递记录，按创建时间倒序
+	List(q Query) ([]*Delivery, error)
+}
+
+// memoryStore 内存中的投递记录存储
+type memoryStore struct {
+	mu         sync.RWMutex
+	deliveries map[string]*Delivery
+}
+
+// NewMemoryStore 创建内存中的投递记录存储，进程重启后记录丢失，适用于测试或者单机
+func NewMemoryStore() Store {
+	return &memoryStore{deliveries: make(map[string]*Delivery)}
+}
+
+func (s *memoryStore) Save(d *Delivery) error {
+	c := *d
+	s.mu.Lock()
+	s.deliveries[d.ID] = &c
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) Get(id string) (*Delivery, error) {
+	s.mu.RLock()
+	d, ok := s.deliveries[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	c := *d
+	return &c, nil
+}
+
+func (s *memoryStore) List(q Query) ([]*Delivery, error) {
+	s.mu.RLock()
+	var list []*Delivery
+	for _, d := range s.deliveries {
+		if q.Match(d) {
+			c := *d
+			list = append(list, &c)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID > list[j].ID
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	if q.Limit > 0 && len(list) > q.Limit {
+		list = list[:q.Limit]
+	}
+
+	return list, nil
+}
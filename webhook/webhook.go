@@ -0,0 +1,350 @@
+// Package webhook 向外投递 webhook，按事件类型注册接收方，请求体使用 HMAC-SHA256 签名
+// 失败时按指数退避重试，多次失败后进入死信，投递记录保存在 Store 中，可以通过 API 查询与重新投递
+//
+// 示例:
+// d := webhook.New(webhook.NewMemoryStore(), webhook.WithNow(app.Now), webhook.WithRand(app.Rand()))
+// d.Register(webhook.Endpoint{ID: "shop", URL: "https://example.com/hook", Secret: secret, Events: []string{"order.created"}})
+// d.Publish("order.created", order)
+// webhook.RegisterAPI(app.Group("/admin/webhooks").Use(adminAuth), d)
+package webhook
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidEndpoint endpoint 缺少 id 或者 url 格式错误
+	ErrInvalidEndpoint = errors.New("webhook: invalid endpoint")
+
+	// ErrClosed 投递器已关闭
+	ErrClosed = errors.New("webhook: dispatcher closed")
+
+	// ErrInProgress 投递正在进行或者等待重试，不能重新投递
+	ErrInProgress = errors.New("webhook: delivery in progress")
+)
+
+// Endpoint 接收方
+type Endpoint struct {
+	// ID 接收方 id
+	ID string `json:"id"`
+
+	// URL 接收地址，使用 POST 投递
+	URL string `json:"url"`
+
+	// Secret 签名密钥，不会通过 API 返回
+	Secret string `json:"-"`
+
+	// Events 订阅的事件类型，"*" 表示全部事件
+	Events []string `json:"events"`
+}
+
+func (e *Endpoint) subscribed(event string) bool {
+	for _, ev := range e.Events {
+		if ev == "*" || ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher webhook 投递器
+type Dispatcher struct {
+	config *config
+	store  Store
+
+	mu        sync.RWMutex
+	endpoints map[string]*Endpoint
+
+	// active 正在投递或者等待重试的投递 id
+	active map[string]bool
+
+	wg        sync.WaitGroup
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// New 创建 webhook 投递器
+func New(store Store, opts ...Option) *Dispatcher {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &Dispatcher{
+		config:    config,
+		store:     store,
+		endpoints: make(map[string]*Endpoint),
+		active:    make(map[string]bool),
+		closed:    make(chan struct{}),
+	}
+}
+
+// Register 注册接收方，id 相同时覆盖
+func (d *Dispatcher) Register(e Endpoint) error {
+	if e.ID == "" {
+		return ErrInvalidEndpoint
+	}
+	if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidEndpoint
+	}
+
+	e.Events = append([]string(nil), e.Events...)
+
+	d.mu.Lock()
+	d.endpoints[e.ID] = &e
+	d.mu.Unlock()
+	return nil
+}
+
+// Unregister 移除接收方，等待重试的投递将进入死信
+func (d *Dispatcher) Unregister(id string) {
+	d.mu.Lock()
+	delete(d.endpoints, id)
+	d.mu.Unlock()
+}
+
+// Endpoints 获取所有接收方
+func (d *Dispatcher) Endpoints() []Endpoint {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	list := make([]Endpoint, 0, len(d.endpoints))
+	for _, e := range d.endpoints {
+		list = append(list, *e)
+	}
+	return list
+}
+
+func (d *Dispatcher) endpoint(id string) (Endpoint, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	e, ok := d.endpoints[id]
+	if !ok {
+		return Endpoint{}, false
+	}
+	return *e, true
+}
+
+// Publish 发布事件，payload 编码为 JSON 后投递给所有订阅 event 的接收方
+// 投递在后台进行，返回创建的投递记录，没有订阅者时返回空
+func (d *Dispatcher) Publish(event string, payload interface{}) ([]*Delivery, error) {
+	select {
+	case <-d.closed:
+		return nil, ErrClosed
+	default:
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	var ids []string
+	for _, e := range d.endpoints {
+		if e.subscribed(event) {
+			ids = append(ids, e.ID)
+		}
+	}
+	d.mu.RUnlock()
+
+	now := d.config.now()
+	deliveries := make([]*Delivery, 0, len(ids))
+	for _, endpointID := range ids {
+		id, err := d.newID()
+		if err != nil {
+			return deliveries, err
+		}
+
+		delivery := &Delivery{
+			ID:          id,
+			EndpointID:  endpointID,
+			Event:       event,
+			Payload:     body,
+			Status:      StatusPending,
+			NextAttempt: now,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := d.store.Save(delivery); err != nil {
+			return deliveries, err
+		}
+
+		d.mu.Lock()
+		d.active[id] = true
+		d.mu.Unlock()
+		d.start(*delivery)
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver 重新投递，一般用于死信，投递次数重新计算
+func (d *Dispatcher) Redeliver(id string) (*Delivery, error) {
+	select {
+	case <-d.closed:
+		return nil, ErrClosed
+	default:
+	}
+
+	delivery, err := d.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	if d.active[id] {
+		d.mu.Unlock()
+		return nil, ErrInProgress
+	}
+	d.active[id] = true
+	d.mu.Unlock()
+
+	now := d.config.now()
+	delivery.Status = StatusPending
+	delivery.Attempts = 0
+	delivery.NextAttempt = now
+	delivery.UpdatedAt = now
+	if err := d.store.Save(delivery); err != nil {
+		d.done(id)
+		return nil, err
+	}
+
+	d.start(*delivery)
+	return delivery, nil
+}
+
+// Delivery 获取投递记录，不存在时返回 ErrNotFound
+func (d *Dispatcher) Delivery(id string) (*Delivery, error) {
+	return d.store.Get(id)
+}
+
+// Deliveries 查询投递记录
+func (d *Dispatcher) Deliveries(q Query) ([]*Delivery, error) {
+	return d.store.List(q)
+}
+
+// Close 停止投递，等待正在发送的请求结束
+// 等待重试的投递保持 StatusPending，可以在重启后通过 Redeliver 继续投递
+func (d *Dispatcher) Close() {
+	d.closeOnce.Do(func() { close(d.closed) })
+	d.wg.Wait()
+}
+
+// start 在后台投递，调用前需要将 id 加入 active
+func (d *Dispatcher) start(delivery Delivery) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer d.done(delivery.ID)
+		d.run(&delivery)
+	}()
+}
+
+func (d *Dispatcher) done(id string) {
+	d.mu.Lock()
+	delete(d.active, id)
+	d.mu.Unlock()
+}
+
+// run 投递直到成功、进入死信或者投递器关闭
+func (d *Dispatcher) run(delivery *Delivery) {
+	for {
+		endpoint, ok := d.endpoint(delivery.EndpointID)
+		if !ok {
+			delivery.Status = StatusDead
+			delivery.LastError = "endpoint removed"
+			delivery.UpdatedAt = d.config.now()
+			_ = d.store.Save(delivery)
+			return
+		}
+
+		delivery.StatusCode, delivery.LastError = d.send(&endpoint, delivery)
+		delivery.Attempts++
+		now := d.config.now()
+		delivery.UpdatedAt = now
+
+		switch {
+		case delivery.LastError == "":
+			delivery.Status = StatusSucceeded
+			delivery.NextAttempt = time.Time{}
+		case delivery.Attempts >= d.config.maxAttempts:
+			delivery.Status = StatusDead
+			delivery.NextAttempt = time.Time{}
+		default:
+			delivery.NextAttempt = now.Add(d.backoff(delivery.Attempts))
+		}
+		_ = d.store.Save(delivery)
+
+		if delivery.Status != StatusPending {
+			return
+		}
+
+		timer := time.NewTimer(delivery.NextAttempt.Sub(now))
+		select {
+		case <-timer.C:
+		case <-d.closed:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// send 发送一次请求，返回状态码以及失败原因，成功时失败原因为空
+func (d *Dispatcher) send(endpoint *Endpoint, delivery *Delivery) (int, string) {
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "zero-api-webhook")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set(d.config.header, Sign(endpoint.Secret, d.config.now(), delivery.Payload))
+
+	res, err := d.config.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64<<10))
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, "unexpected status " + strconv.Itoa(res.StatusCode)
+	}
+
+	return res.StatusCode, ""
+}
+
+// backoff 第 attempts 次失败后的等待时间
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.config.minBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= d.config.maxBackoff {
+			return d.config.maxBackoff
+		}
+	}
+	return wait
+}
+
+func (d *Dispatcher) newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(d.config.rand, b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,165 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/timestamp"
+	"github.com/zerogo-hub/zero-api/webhook"
+)
+
+// wait 等待投递结束
+func wait(t *testing.T, d *webhook.Dispatcher, id string, status webhook.Status) *webhook.Delivery {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		delivery, err := d.Delivery(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if delivery.Status == status {
+			return delivery
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("delivery %s not %s", id, status)
+	return nil
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	body := []byte(`{"id":1}`)
+	sig := webhook.Sign("secret", now, body)
+
+	v := timestamp.New(timestamp.WithMaxAge(5*time.Minute), timestamp.WithNow(func() time.Time { return now }))
+	if err := webhook.Verify("secret", sig, body, v); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhook.Verify("other", sig, body, v); err != webhook.ErrSignature {
+		t.Fatalf("wrong secret: %v", err)
+	}
+	if err := webhook.Verify("secret", sig, []byte(`{"id":2}`), v); err != webhook.ErrSignature {
+		t.Fatalf("tampered body: %v", err)
+	}
+
+	late := timestamp.New(timestamp.WithMaxAge(5*time.Minute), timestamp.WithNow(func() time.Time { return now.Add(time.Hour) }))
+	if err := webhook.Verify("secret", sig, body, late); err != timestamp.ErrExpired {
+		t.Fatalf("replay: %v", err)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	var got atomic.Value
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := webhook.Verify("secret", r.Header.Get(webhook.DefaultSignatureHeader), body, nil); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		got.Store(r.Header.Get("X-Webhook-Event") + " " + string(body))
+	}))
+	defer receiver.Close()
+
+	d := webhook.New(webhook.NewMemoryStore())
+	defer d.Close()
+
+	if err := d.Register(webhook.Endpoint{ID: "a", URL: receiver.URL, Secret: "secret", Events: []string{"order.created"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Register(webhook.Endpoint{ID: "b", URL: "ftp://example.com"}); err != webhook.ErrInvalidEndpoint {
+		t.Fatalf("invalid endpoint: %v", err)
+	}
+
+	deliveries, err := d.Publish("order.deleted", map[string]int{"id": 1})
+	if err != nil || len(deliveries) != 0 {
+		t.Fatalf("unsubscribed event: %v %d", err, len(deliveries))
+	}
+
+	deliveries, err = d.Publish("order.created", map[string]int{"id": 1})
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("publish: %v %d", err, len(deliveries))
+	}
+
+	delivery := wait(t, d, deliveries[0].ID, webhook.StatusSucceeded)
+	if delivery.Attempts != 1 || delivery.StatusCode != http.StatusOK {
+		t.Fatalf("delivery: %+v", delivery)
+	}
+	if got.Load() != `order.created {"id":1}` {
+		t.Fatalf("received: %v", got.Load())
+	}
+}
+
+func TestRetryDeadLetter(t *testing.T) {
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	d := webhook.New(webhook.NewMemoryStore(), webhook.WithMaxAttempts(2), webhook.WithBackoff(time.Millisecond, 10*time.Millisecond))
+	defer d.Close()
+
+	_ = d.Register(webhook.Endpoint{ID: "a", URL: receiver.URL, Secret: "secret", Events: []string{"*"}})
+	deliveries, _ := d.Publish("ping", nil)
+
+	delivery := wait(t, d, deliveries[0].ID, webhook.StatusDead)
+	if delivery.Attempts != 2 || delivery.StatusCode != http.StatusServiceUnavailable || delivery.LastError == "" {
+		t.Fatalf("dead letter: %+v", delivery)
+	}
+
+	dead, _ := d.Deliveries(webhook.Query{Status: webhook.StatusDead})
+	if len(dead) != 1 || dead[0].ID != delivery.ID {
+		t.Fatalf("dead letters: %d", len(dead))
+	}
+
+	// 第 3 次失败，第 4 次成功
+	if _, err := d.Redeliver(delivery.ID); err != nil {
+		t.Fatal(err)
+	}
+	delivery = wait(t, d, delivery.ID, webhook.StatusSucceeded)
+	if delivery.Attempts != 2 || atomic.LoadInt32(&calls) != 4 {
+		t.Fatalf("redeliver: %+v calls=%d", delivery, calls)
+	}
+}
+
+func TestAPI(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	d := webhook.New(webhook.NewMemoryStore())
+	defer d.Close()
+	_ = d.Register(webhook.Endpoint{ID: "a", URL: receiver.URL, Secret: "secret", Events: []string{"*"}})
+	deliveries, _ := d.Publish("ping", nil)
+	wait(t, d, deliveries[0].ID, webhook.StatusSucceeded)
+
+	a := app.NewApp()
+	webhook.RegisterAPI(a.Group("/webhooks"), d)
+	a.Router().Build()
+
+	w := httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries?status=succeeded", nil))
+	var list []webhook.Delivery
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].ID != deliveries[0].ID {
+		t.Fatalf("list: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("missing: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	a.Server().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/endpoints", nil))
+	if body := strings.TrimSpace(w.Body.String()); body != `[{"id":"a","url":"`+receiver.URL+`","events":["*"]}]` {
+		t.Fatalf("endpoints: %d %s", w.Code, body)
+	}
+}
@@ -0,0 +1,318 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// continuationFrame 后续分片
+	continuationFrame = 0
+
+	finalBit = 1 << 7
+	rsvBits  = 7 << 4
+	maskBit  = 1 << 7
+
+	// maxControlPayload 控制帧最大长度
+	maxControlPayload = 125
+
+	// closeWriteTimeout 发送关闭帧的超时时间
+	closeWriteTimeout = time.Second
+)
+
+var (
+	// ErrCloseSent 已经发送关闭帧，不能再发送消息
+	ErrCloseSent = errors.New("websocket: close sent")
+
+	// ErrReadLimit 消息超过最大长度
+	ErrReadLimit = errors.New("websocket: read limit exceeded")
+)
+
+// CloseError 收到对方的关闭帧
+type CloseError struct {
+	// Code 关闭状态码
+	Code int
+
+	// Text 关闭原因
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// conn WebSocket 连接
+type conn struct {
+	netConn net.Conn
+	br      *bufio.Reader
+
+	subprotocol string
+	readLimit   int64
+
+	// writeMu 保证同一时间只有一个写操作
+	writeMu sync.Mutex
+
+	// closeSent 是否已经发送关闭帧
+	closeSent bool
+
+	closeOnce sync.Once
+}
+
+func newConn(netConn net.Conn, br *bufio.Reader, subprotocol string, config *config) *conn {
+	return &conn{
+		netConn:     netConn,
+		br:          br,
+		subprotocol: subprotocol,
+		readLimit:   config.readLimit,
+	}
+}
+
+// frame 一个数据帧
+type frame struct {
+	fin     bool
+	opcode  int
+	payload []byte
+}
+
+// ReadMessage 读取一条完整的消息，自动处理 ping, pong, close 控制帧与分片
+func (c *conn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	var message []byte
+
+	for {
+		f, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch f.opcode {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, f.payload, time.Now().Add(closeWriteTimeout)); err != nil && err != ErrCloseSent {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(f.payload)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected data frame")
+			}
+			messageType = f.opcode
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if int64(len(message)+len(f.payload)) > c.readLimit {
+			return 0, nil, c.fail(CloseMessageTooBig, ErrReadLimit.Error())
+		}
+		message = append(message, f.payload...)
+
+		if f.fin {
+			break
+		}
+	}
+
+	if messageType == TextMessage && !utf8.Valid(message) {
+		return 0, nil, c.fail(CloseInvalidPayloadData, "invalid utf8 payload")
+	}
+
+	return messageType, message, nil
+}
+
+// readFrame 读取一个数据帧
+func (c *conn) readFrame() (*frame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return nil, err
+	}
+
+	f := &frame{
+		fin:    header[0]&finalBit != 0,
+		opcode: int(header[0] & 0x0f),
+	}
+
+	if header[0]&rsvBits != 0 {
+		return nil, c.fail(CloseProtocolError, "unexpected reserved bits")
+	}
+
+	// 客户端发送的数据帧必须有掩码
+	if header[1]&maskBit == 0 {
+		return nil, c.fail(CloseProtocolError, "frame not masked")
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(b[:]))
+		if length < 0 {
+			return nil, c.fail(CloseProtocolError, "invalid payload length")
+		}
+	}
+
+	if f.opcode >= CloseMessage && (length > maxControlPayload || !f.fin) {
+		return nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+
+	if length > c.readLimit {
+		return nil, c.fail(CloseMessageTooBig, ErrReadLimit.Error())
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return nil, err
+	}
+
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return nil, err
+	}
+
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+
+	return f, nil
+}
+
+// handleClose 收到关闭帧，回复关闭帧后返回 CloseError
+func (c *conn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+	}
+
+	c.writeClose(CloseNormalClosure, "")
+
+	return closeErr
+}
+
+// fail 协议错误，发送关闭帧后返回错误
+func (c *conn) fail(code int, text string) error {
+	c.writeClose(code, text)
+	return &CloseError{Code: code, Text: text}
+}
+
+// WriteMessage 发送一条消息
+func (c *conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+		return c.writeFrame(messageType, data, time.Time{})
+	case PingMessage, PongMessage:
+		if len(data) > maxControlPayload {
+			return errors.New("websocket: control frame too large")
+		}
+		return c.writeFrame(messageType, data, time.Time{})
+	case CloseMessage:
+		return c.writeFrame(CloseMessage, data, time.Now().Add(closeWriteTimeout))
+	}
+
+	return errors.New("websocket: unknown message type")
+}
+
+// writeClose 发送关闭帧
+func (c *conn) writeClose(code int, text string) error {
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+
+	return c.writeFrame(CloseMessage, payload, time.Now().Add(closeWriteTimeout))
+}
+
+// writeFrame 发送一个数据帧，服务端发送的数据帧不需要掩码
+func (c *conn) writeFrame(opcode int, payload []byte, deadline time.Time) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrCloseSent
+	}
+
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = finalBit | byte(opcode)
+
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 65535:
+		header[1] = 126
+		header = header[:4]
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header[1] = 127
+		header = header[:10]
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+
+	if !deadline.IsZero() {
+		c.netConn.SetWriteDeadline(deadline)
+		defer c.netConn.SetWriteDeadline(time.Time{})
+	}
+
+	buffers := net.Buffers{header, payload}
+	_, err := buffers.WriteTo(c.netConn)
+
+	return err
+}
+
+// SetReadDeadline 设置读超时
+func (c *conn) SetReadDeadline(t time.Time) error {
+	return c.netConn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline 设置写超时
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	return c.netConn.SetWriteDeadline(t)
+}
+
+// Subprotocol 握手时协商的子协议
+func (c *conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr 对方地址
+func (c *conn) RemoteAddr() net.Addr {
+	return c.netConn.RemoteAddr()
+}
+
+// Close 发送关闭帧并关闭连接
+func (c *conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.writeClose(CloseNormalClosure, "")
+		err = c.netConn.Close()
+	})
+
+	return err
+}
@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"net/http"
+)
+
+// config WebSocket 配置
+type config struct {
+	// checkOrigin 检查 Origin，返回 false 时拒绝握手
+	checkOrigin func(r *http.Request) bool
+
+	// subprotocols 服务端支持的子协议，按照优先级排序
+	subprotocols []string
+
+	// readLimit 单条消息最大字节数
+	readLimit int64
+
+	// readBufferSize 读缓冲区大小
+	readBufferSize int
+}
+
+func defaultConfig() *config {
+	return &config{
+		checkOrigin:    sameOrigin,
+		readLimit:      32 * 1024 * 1024, // 32M
+		readBufferSize: 4096,
+	}
+}
+
+// Option WebSocket 配置选项
+type Option func(config *config)
+
+// WithCheckOrigin 设置 Origin 检查函数，默认只允许同源请求
+func WithCheckOrigin(checkOrigin func(r *http.Request) bool) Option {
+	return func(config *config) {
+		if checkOrigin != nil {
+			config.checkOrigin = checkOrigin
+		}
+	}
+}
+
+// WithSubprotocols 设置服务端支持的子协议
+func WithSubprotocols(subprotocols ...string) Option {
+	return func(config *config) {
+		config.subprotocols = subprotocols
+	}
+}
+
+// WithReadLimit 设置单条消息最大字节数，超过时关闭连接
+func WithReadLimit(readLimit int64) Option {
+	return func(config *config) {
+		if readLimit > 0 {
+			config.readLimit = readLimit
+		}
+	}
+}
+
+// WithReadBufferSize 设置读缓冲区大小
+func WithReadBufferSize(size int) Option {
+	return func(config *config) {
+		if size > 0 {
+			config.readBufferSize = size
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package websocket 服务端 WebSocket 实现，见 https://tools.ietf.org/html/rfc6455
+//
+// 示例:
+//
+//	app.WS("/chat", func(ctx zeroapi.Context, conn zeroapi.WebSocket) {
+//	    for {
+//	        messageType, data, err := conn.ReadMessage()
+//	        if err != nil {
+//	            return
+//	        }
+//	        conn.WriteMessage(messageType, data)
+//	    }
+//	})
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+)
+
+const (
+	// TextMessage 文本消息，内容为 UTF-8 编码
+	TextMessage = 1
+
+	// BinaryMessage 二进制消息
+	BinaryMessage = 2
+
+	// CloseMessage 关闭连接
+	CloseMessage = 8
+
+	// PingMessage ping
+	PingMessage = 9
+
+	// PongMessage pong
+	PongMessage = 10
+)
+
+// 关闭状态码，见 https://tools.ietf.org/html/rfc6455#section-7.4.1
+const (
+	CloseNormalClosure       = 1000
+	CloseGoingAway           = 1001
+	CloseProtocolError       = 1002
+	CloseUnsupportedData     = 1003
+	CloseNoStatusReceived    = 1005
+	CloseInvalidPayloadData  = 1007
+	ClosePolicyViolation     = 1008
+	CloseMessageTooBig       = 1009
+	CloseInternalServerError = 1011
+)
+
+// acceptGUID 用于计算 Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrBadHandshake 不是合法的 WebSocket 握手请求
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+
+	// ErrBadOrigin 不允许的 Origin
+	ErrBadOrigin = errors.New("websocket: origin not allowed")
+
+	// ErrHijack ResponseWriter 不支持 http.Hijacker
+	ErrHijack = errors.New("websocket: response does not implement http.Hijacker")
+)
+
+// Upgrade 将 http 连接升级为 WebSocket 连接
+// 握手失败时会写入错误响应，并返回错误
+func Upgrade(w http.ResponseWriter, r *http.Request, opts ...Option) (zeroapi.WebSocket, error) {
+	config := defaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	if !config.checkOrigin(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return nil, ErrBadOrigin
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return nil, ErrHijack
+	}
+
+	subprotocol := selectSubprotocol(r, config.subprotocols)
+
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	// 握手前客户端不应该发送数据
+	if brw.Reader.Buffered() > 0 {
+		netConn.Close()
+		return nil, ErrBadHandshake
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	buf.WriteString(acceptKey(key))
+	buf.WriteString("\r\n")
+	if subprotocol != "" {
+		buf.WriteString("Sec-WebSocket-Protocol: ")
+		buf.WriteString(subprotocol)
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	if _, err := netConn.Write([]byte(buf.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return newConn(netConn, bufio.NewReaderSize(netConn, config.readBufferSize), subprotocol, config), nil
+}
+
+// Handler 生成路由处理函数，握手成功后执行 fn，fn 返回后关闭连接
+func Handler(fn zeroapi.WebSocketHandler, opts ...Option) zeroapi.Handler {
+	return func(ctx zeroapi.Context) {
+		// 通过 ctx.Response() 接管连接，记录响应已写入，之后不会再写入响应头
+		conn, err := Upgrade(ctx.Response(), ctx.Request(), opts...)
+		if err != nil {
+			ctx.Stopped()
+			return
+		}
+		defer conn.Close()
+
+		fn(ctx, conn)
+	}
+}
+
+// IsWebSocketUpgrade 判断请求是否是 WebSocket 握手请求
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key))
+	h.Write([]byte(acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContains 判断 header 中是否包含 token，不区分大小写
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, s := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	if len(supported) == 0 {
+		return ""
+	}
+
+	for _, value := range r.Header["Sec-Websocket-Protocol"] {
+		for _, protocol := range strings.Split(value, ",") {
+			protocol = strings.TrimSpace(protocol)
+			for _, s := range supported {
+				if s == protocol {
+					return s
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// sameOrigin 默认的 Origin 检查，没有 Origin 或者 Origin 与 Host 相同时允许
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
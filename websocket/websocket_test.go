@@ -0,0 +1,178 @@
+package websocket_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zeroapi "github.com/zerogo-hub/zero-api"
+	app "github.com/zerogo-hub/zero-api/app"
+	"github.com/zerogo-hub/zero-api/websocket"
+)
+
+// dial 完成握手，返回原始连接
+func dial(t *testing.T, server *httptest.Server, header string) (net.Conn, *bufio.Reader, string) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := "GET /chat HTTP/1.1\r\nHost: " + strings.TrimPrefix(server.URL, "http://") + "\r\n" +
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" + header + "\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conn, br, resp.Status + "|" + resp.Header.Get("Sec-WebSocket-Accept")
+}
+
+// writeFrame 客户端发送带掩码的数据帧
+func writeFrame(t *testing.T, conn net.Conn, fin bool, opcode byte, payload []byte) {
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{b0, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFrame 读取服务端发送的数据帧
+func readFrame(t *testing.T, br *bufio.Reader) (byte, []byte) {
+	var header [2]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		t.Fatal(err)
+	}
+
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		var b [2]byte
+		io.ReadFull(br, b[:])
+		length = int(binary.BigEndian.Uint16(b[:]))
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	return header[0] & 0x0f, payload
+}
+
+func echoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, data)
+		}
+	}))
+}
+
+func TestEcho(t *testing.T) {
+	server := echoServer()
+	defer server.Close()
+
+	conn, br, status := dial(t, server, "")
+	defer conn.Close()
+
+	// 示例来自 RFC 6455
+	if status != "101 Switching Protocols|s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("invalid handshake: %s", status)
+	}
+
+	writeFrame(t, conn, true, websocket.TextMessage, []byte("hello"))
+	if opcode, payload := readFrame(t, br); opcode != websocket.TextMessage || string(payload) != "hello" {
+		t.Fatalf("invalid echo: %d %s", opcode, payload)
+	}
+
+	// 分片消息，中间插入 ping
+	writeFrame(t, conn, false, websocket.BinaryMessage, []byte("zero"))
+	writeFrame(t, conn, true, websocket.PingMessage, []byte("p"))
+	writeFrame(t, conn, true, 0, []byte("-api"))
+
+	if opcode, payload := readFrame(t, br); opcode != websocket.PongMessage || string(payload) != "p" {
+		t.Fatalf("invalid pong: %d %s", opcode, payload)
+	}
+	if opcode, payload := readFrame(t, br); opcode != websocket.BinaryMessage || string(payload) != "zero-api" {
+		t.Fatalf("invalid echo: %d %s", opcode, payload)
+	}
+
+	// 关闭
+	writeFrame(t, conn, true, websocket.CloseMessage, []byte{0x03, 0xe8})
+	if opcode, payload := readFrame(t, br); opcode != websocket.CloseMessage || binary.BigEndian.Uint16(payload) != 1000 {
+		t.Fatalf("invalid close: %d %v", opcode, payload)
+	}
+}
+
+func TestBadOrigin(t *testing.T) {
+	server := echoServer()
+	defer server.Close()
+
+	conn, _, status := dial(t, server, "Origin: http://evil.example.com\r\n")
+	defer conn.Close()
+
+	if !strings.HasPrefix(status, "403") {
+		t.Fatalf("invalid status: %s", status)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	written := make(chan bool, 1)
+
+	a := app.NewApp()
+	a.Get("/chat", websocket.Handler(func(ctx zeroapi.Context, conn zeroapi.WebSocket) {
+		// 接管连接后响应已写入，框架不会再写入响应头
+		written <- ctx.Response().Written()
+
+		if messageType, data, err := conn.ReadMessage(); err == nil {
+			conn.WriteMessage(messageType, data)
+		}
+	}))
+	a.Router().Build()
+
+	server := httptest.NewServer(a.Server())
+	defer server.Close()
+
+	conn, br, status := dial(t, server, "")
+	defer conn.Close()
+	if !strings.HasPrefix(status, "101") {
+		t.Fatalf("invalid handshake: %s", status)
+	}
+	if !<-written {
+		t.Fatal("hijack should be recorded by the response writer")
+	}
+
+	writeFrame(t, conn, true, websocket.TextMessage, []byte("hello"))
+	if opcode, payload := readFrame(t, br); opcode != websocket.TextMessage || string(payload) != "hello" {
+		t.Fatalf("invalid echo: %d %s", opcode, payload)
+	}
+}